@@ -17,6 +17,7 @@ package backend
 
 import (
 	"crypto/sha256"
+	"errors"
 	"hash"
 
 	"github.com/consensys/gnark/constraint/solver"
@@ -29,11 +30,12 @@ const (
 	UNKNOWN ID = iota
 	GROTH16
 	PLONK
+	PLONKFRI
 )
 
 // Implemented return the list of proof systems implemented in gnark
 func Implemented() []ID {
-	return []ID{GROTH16, PLONK}
+	return []ID{GROTH16, PLONK, PLONKFRI}
 }
 
 // String returns the string representation of a proof system
@@ -43,6 +45,8 @@ func (id ID) String() string {
 		return "groth16"
 	case PLONK:
 		return "plonk"
+	case PLONKFRI:
+		return "plonk_fri"
 	default:
 		return "unknown"
 	}
@@ -61,6 +65,14 @@ type ProverConfig struct {
 	KZGFoldingHash hash.Hash
 	Accelerator    string
 	StatisticalZK  bool
+
+	// LowMemory is set by WithLowMemory
+	LowMemory    bool
+	MemoryBudget uint64
+	TempDir      string
+
+	// NoZeroKnowledge is set by WithoutZeroKnowledge
+	NoZeroKnowledge bool
 }
 
 // NewProverConfig returns a default ProverConfig with given prover options opts
@@ -77,6 +89,9 @@ func NewProverConfig(opts ...ProverOption) (ProverConfig, error) {
 			return ProverConfig{}, err
 		}
 	}
+	if opt.NoZeroKnowledge && opt.StatisticalZK {
+		return ProverConfig{}, errors.New("statistical zero knowledge requested without zero knowledge")
+	}
 	return opt, nil
 }
 
@@ -144,6 +159,90 @@ func WithStatisticalZeroKnowledge() ProverOption {
 	}
 }
 
+// WithLowMemory enables the low memory mode of the PLONK prover, for circuits
+// whose polynomials do not fit in memory. The prover keeps at most memoryBudget
+// bytes of its large vectors on the heap, and stores the other ones in
+// temporary files mapped in memory, created in tempDir (or os.TempDir() if
+// empty). The intermediate vectors are released as soon as they are not needed
+// anymore. This option makes the prover slower, depending on the storage.
+//
+// The quotient is computed one coset of the domain of the circuit at a time:
+// its numerator is evaluated, divided by the vanishing polynomial and
+// interpolated on each coset in place in its chunk of the quotient vector, and
+// the chunks are only combined at the end in a single pass. The FFTs thus only
+// go through vectors of size n, where n is the size of the domain, instead of
+// 4n. See BenchmarkLowMemory for the actual gain.
+//
+// The other backends ignore this option.
+func WithLowMemory(memoryBudget uint64, tempDir string) ProverOption {
+	return func(pc *ProverConfig) error {
+		pc.LowMemory = true
+		pc.MemoryBudget = memoryBudget
+		pc.TempDir = tempDir
+		return nil
+	}
+}
+
+// WithoutZeroKnowledge disables the zero knowledge property of the PLONK
+// prover: the polynomials of the witness are committed and opened without
+// blinding factors. The prover is faster, and needs a KZG SRS of n points
+// instead of n+3, where n is the size of the domain of the circuit, if the key
+// is set up with WithSetupWithoutZeroKnowledge.
+//
+// The domain of the circuit is not smaller: gnark does not add blinding rows to
+// the evaluation domain, the blinding factors only raise the degree of the
+// committed polynomials. Besides the SRS, only the coset domain of the
+// quotient shrinks, from 8n to 4n, and only when n is smaller than 6.
+//
+// The proofs leak information about the witness, including its secret part.
+// This option must only be used when the proof does not need to hide the
+// witness, for example when it is verified inside another proof which is zero
+// knowledge. The proofs are verified with WithVerifierWithoutZeroKnowledge,
+// they are not supported by the Solidity verifier.
+//
+// The option is incompatible with WithStatisticalZeroKnowledge. The other
+// backends ignore this option.
+func WithoutZeroKnowledge() ProverOption {
+	return func(pc *ProverConfig) error {
+		pc.NoZeroKnowledge = true
+		return nil
+	}
+}
+
+// SetupOption defines option for altering the behavior of the PLONK setup. See
+// the descriptions of functions returning instances of this type for
+// implemented options.
+type SetupOption func(*SetupConfig) error
+
+// SetupConfig is the configuration for the setup with the options applied.
+type SetupConfig struct {
+	// NoZeroKnowledge is set by WithSetupWithoutZeroKnowledge
+	NoZeroKnowledge bool
+}
+
+// NewSetupConfig returns a default [SetupConfig] with given setup options
+// applied.
+func NewSetupConfig(opts ...SetupOption) (SetupConfig, error) {
+	var opt SetupConfig
+	for _, option := range opts {
+		if err := option(&opt); err != nil {
+			return SetupConfig{}, err
+		}
+	}
+	return opt, nil
+}
+
+// WithSetupWithoutZeroKnowledge sets up a PLONK key which is only used to
+// generate proofs with the WithoutZeroKnowledge prover option, so that the KZG
+// SRS needs n points instead of n+3, where n is the size of the domain of the
+// circuit. The zero knowledge proofs fail with a key set up from such an SRS.
+func WithSetupWithoutZeroKnowledge() SetupOption {
+	return func(cfg *SetupConfig) error {
+		cfg.NoZeroKnowledge = true
+		return nil
+	}
+}
+
 // VerifierOption defines option for altering the behavior of the verifier. See
 // the descriptions of functions returning instances of this type for
 // implemented options.
@@ -154,6 +253,9 @@ type VerifierConfig struct {
 	HashToFieldFn  hash.Hash
 	ChallengeHash  hash.Hash
 	KZGFoldingHash hash.Hash
+
+	// NoZeroKnowledge is set by WithVerifierWithoutZeroKnowledge
+	NoZeroKnowledge bool
 }
 
 // NewVerifierConfig returns a default [VerifierConfig] with given verifier
@@ -205,3 +307,15 @@ func WithVerifierKZGFoldingHashFunction(hFunc hash.Hash) VerifierOption {
 		return nil
 	}
 }
+
+// WithVerifierWithoutZeroKnowledge sets the PLONK verifier to verify the proofs
+// generated with the WithoutZeroKnowledge prover option, whose quotient is
+// split differently. The zero knowledge proofs do not verify with this option.
+//
+// The other backends ignore this option.
+func WithVerifierWithoutZeroKnowledge() VerifierOption {
+	return func(pc *VerifierConfig) error {
+		pc.NoZeroKnowledge = true
+		return nil
+	}
+}
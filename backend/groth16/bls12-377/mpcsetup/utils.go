@@ -18,6 +18,7 @@ package mpcsetup
 
 import (
 	"bytes"
+	"fmt"
 	"math/big"
 	"math/bits"
 	"runtime"
@@ -34,13 +35,12 @@ type PublicKey struct {
 	XR  curve.G2Affine
 }
 
-func newPublicKey(x fr.Element, challenge []byte, dst byte) PublicKey {
+func newPublicKey(x fr.Element, challenge []byte, dst byte, rand *sampler) PublicKey {
 	var pk PublicKey
 	_, _, g1, _ := curve.Generators()
 
-	var s fr.Element
 	var sBi big.Int
-	s.SetRandom()
+	s := rand.sample()
 	s.BigInt(&sBi)
 	pk.SG.ScalarMultiplication(&g1, &sBi)
 
@@ -57,6 +57,28 @@ func newPublicKey(x fr.Element, challenge []byte, dst byte) PublicKey {
 	return pk
 }
 
+// sampler samples the secrets of a contribution, from crypto/rand or from a
+// random beacon so that anyone can recompute the contribution. A nil sampler
+// uses crypto/rand.
+type sampler struct {
+	beacon []byte
+	count  int // number of elements derived from the beacon
+}
+
+// sample returns the next secret
+func (s *sampler) sample() (x fr.Element) {
+	if s == nil {
+		x.SetRandom()
+		return
+	}
+	s.count++
+	res, err := fr.Hash(s.beacon, []byte(fmt.Sprintf("gnark mpcsetup beacon %d", s.count)), 1)
+	if err != nil {
+		panic(err)
+	}
+	return res[0]
+}
+
 func bitReverse[T any](a []T) {
 	n := uint64(len(a))
 	nn := uint64(64 - bits.TrailingZeros64(n))
@@ -0,0 +1,664 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+package groth16
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/fft"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16/distributed"
+	"github.com/consensys/gnark/backend/groth16/internal"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	cs "github.com/consensys/gnark/constraint/bls12-381"
+	"github.com/consensys/gnark/internal/utils"
+	"github.com/consensys/gnark/logger"
+	"golang.org/x/sync/errgroup"
+	"io"
+	"math/big"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// methods of the requests sent by the coordinator to the workers
+const (
+	methodMSM = "msm"
+	methodFFT = "fft"
+)
+
+// ProvingKeyShard is the part of a ProvingKey held by a worker of the
+// distributed prover: a contiguous range of each vector of points of the key.
+// See SplitProvingKey and ProveDistributed.
+type ProvingKeyShard struct {
+	// Index of the shard, and number of shards of the proving key
+	Index, NbShards uint32
+
+	G1 struct {
+		A, B, K, Z []curve.G1Affine
+	}
+	G2 struct {
+		B []curve.G2Affine
+	}
+}
+
+// CurveID returns the curveID
+func (shard *ProvingKeyShard) CurveID() ecc.ID {
+	return curve.ID
+}
+
+// SplitProvingKey splits the vectors of points of pk in nbShards shards of
+// similar sizes, one for each worker of the distributed prover. It returns
+// the proving key of the coordinator, which is pk without these vectors, and
+// the shards. pk is not modified, the shards point into its vectors.
+func SplitProvingKey(pk *ProvingKey, nbShards int) (*ProvingKey, []*ProvingKeyShard, error) {
+	if nbShards < 1 {
+		return nil, nil, errors.New("at least one shard is needed")
+	}
+
+	shards := make([]*ProvingKeyShard, nbShards)
+	for i := range shards {
+		shard := &ProvingKeyShard{Index: uint32(i), NbShards: uint32(nbShards)}
+		shard.G1.A = shardOf(pk.G1.A, nbShards, i)
+		shard.G1.B = shardOf(pk.G1.B, nbShards, i)
+		shard.G1.K = shardOf(pk.G1.K, nbShards, i)
+		shard.G1.Z = shardOf(pk.G1.Z, nbShards, i)
+		shard.G2.B = shardOf(pk.G2.B, nbShards, i)
+		shards[i] = shard
+	}
+
+	coordinator := *pk
+	coordinator.G1.A, coordinator.G1.B, coordinator.G1.K, coordinator.G1.Z = nil, nil, nil, nil
+	coordinator.G2.B = nil
+	coordinator.mapped = nil
+	return &coordinator, shards, nil
+}
+
+// shardRange returns the range of the elements of a vector of size n held by
+// the shard i of nbShards.
+func shardRange(n, nbShards, i int) (start, end int) {
+	return n * i / nbShards, n * (i + 1) / nbShards
+}
+
+// shardOf returns the elements of v held by the shard i of nbShards.
+func shardOf[T any](v []T, nbShards, i int) []T {
+	start, end := shardRange(len(v), nbShards, i)
+	return v[start:end]
+}
+
+// WriteTo writes binary encoding of the shard to w, with compressed points
+func (shard *ProvingKeyShard) WriteTo(w io.Writer) (int64, error) {
+	return shard.writeTo(curve.NewEncoder(w))
+}
+
+// WriteRawTo writes binary encoding of the shard to w, without point compression
+func (shard *ProvingKeyShard) WriteRawTo(w io.Writer) (int64, error) {
+	return shard.writeTo(curve.NewEncoder(w, curve.RawEncoding()))
+}
+
+func (shard *ProvingKeyShard) writeTo(enc *curve.Encoder) (int64, error) {
+	toEncode := []interface{}{
+		shard.Index,
+		shard.NbShards,
+		shard.G1.A,
+		shard.G1.B,
+		shard.G1.K,
+		shard.G1.Z,
+		shard.G2.B,
+	}
+	for _, v := range toEncode {
+		if err := enc.Encode(v); err != nil {
+			return enc.BytesWritten(), err
+		}
+	}
+	return enc.BytesWritten(), nil
+}
+
+// ReadFrom reads a shard written by WriteTo or WriteRawTo from r
+func (shard *ProvingKeyShard) ReadFrom(r io.Reader) (int64, error) {
+	dec := curve.NewDecoder(r)
+	toDecode := []interface{}{
+		&shard.Index,
+		&shard.NbShards,
+		&shard.G1.A,
+		&shard.G1.B,
+		&shard.G1.K,
+		&shard.G1.Z,
+		&shard.G2.B,
+	}
+	for _, v := range toDecode {
+		if err := dec.Decode(v); err != nil {
+			return dec.BytesRead(), err
+		}
+	}
+	if shard.Index >= shard.NbShards {
+		return dec.BytesRead(), fmt.Errorf("shard %d out of %d", shard.Index, shard.NbShards)
+	}
+	return dec.BytesRead(), nil
+}
+
+// Worker computes the parts of the distributed proofs which depend on its
+// shard of the proving key, and the FFTs requested by the coordinator. It
+// implements distributed.Handler.
+type Worker struct {
+	shard *ProvingKeyShard
+
+	lock    sync.Mutex
+	domains map[uint64]*fft.Domain // domains of the FFT requests, by size
+}
+
+// NewWorker returns a worker holding the given shard.
+func NewWorker(shard *ProvingKeyShard) *Worker {
+	return &Worker{
+		shard:   shard,
+		domains: make(map[uint64]*fft.Domain),
+	}
+}
+
+// Handle processes a request of the coordinator.
+func (w *Worker) Handle(method string, request []byte) ([]byte, error) {
+	switch method {
+	case methodMSM:
+		return w.msm(request)
+	case methodFFT:
+		return w.fft(request)
+	default:
+		return nil, fmt.Errorf("unknown method %q", method)
+	}
+}
+
+// msm computes the multi-exponentiations of the scalars of the request with
+// the points of the shard, for A, B (in G1 and G2), K and Z.
+func (w *Worker) msm(request []byte) ([]byte, error) {
+	var index, nbShards uint32
+	var wireValuesA, wireValuesB, wireValuesK, h []fr.Element
+	if err := decode(request, &index, &nbShards, &wireValuesA, &wireValuesB, &wireValuesK, &h); err != nil {
+		return nil, err
+	}
+	if index != w.shard.Index || nbShards != w.shard.NbShards {
+		return nil, fmt.Errorf("request for shard %d out of %d sent to shard %d out of %d", index, nbShards, w.shard.Index, w.shard.NbShards)
+	}
+
+	if len(wireValuesA) != len(w.shard.G1.A) || len(wireValuesB) != len(w.shard.G1.B) ||
+		len(wireValuesB) != len(w.shard.G2.B) || len(wireValuesK) != len(w.shard.G1.K) || len(h) != len(w.shard.G1.Z) {
+		return nil, errors.New("number of scalars doesn't match the shard")
+	}
+
+	// the multi-exponentiations of empty vectors are the point at infinity
+	var ar, bs1, krs, krs2 curve.G1Affine
+	var bs2 curve.G2Affine
+	for _, m := range []struct {
+		res     *curve.G1Affine
+		points  []curve.G1Affine
+		scalars []fr.Element
+	}{
+		{&ar, w.shard.G1.A, wireValuesA},
+		{&bs1, w.shard.G1.B, wireValuesB},
+		{&krs, w.shard.G1.K, wireValuesK},
+		{&krs2, w.shard.G1.Z, h},
+	} {
+		if len(m.points) == 0 {
+			continue
+		}
+		if _, err := m.res.MultiExp(m.points, m.scalars, ecc.MultiExpConfig{}); err != nil {
+			return nil, err
+		}
+	}
+	if len(w.shard.G2.B) != 0 {
+		if _, err := bs2.MultiExp(w.shard.G2.B, wireValuesB, ecc.MultiExpConfig{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return encode(&ar, &bs1, &krs, &krs2, &bs2)
+}
+
+// fft computes the FFTs (or inverse FFTs) of the vectors of the request, in
+// natural order, and multiplies the k-th element of the vector i by tᵢᵏ if
+// twiddles are requested.
+func (w *Worker) fft(request []byte) ([]byte, error) {
+	var inverse uint32
+	var twiddles []fr.Element
+	var vectors [][]fr.Element
+	if err := decode(request, &inverse, &twiddles, &vectors); err != nil {
+		return nil, err
+	}
+	if len(twiddles) != 0 && len(twiddles) != len(vectors) {
+		return nil, fmt.Errorf("%d twiddles for %d vectors", len(twiddles), len(vectors))
+	}
+	if len(vectors) == 0 {
+		return encode(vectors)
+	}
+	m := len(vectors[0])
+	if bits.OnesCount(uint(m)) != 1 {
+		return nil, fmt.Errorf("vectors of size %d, not a power of 2", m)
+	}
+	for i := range vectors {
+		if len(vectors[i]) != m {
+			return nil, errors.New("vectors of different sizes")
+		}
+	}
+
+	var domain *fft.Domain
+	if m > 1 {
+		domain = w.domain(uint64(m))
+	}
+	utils.Parallelize(len(vectors), func(start, end int) {
+		for i := start; i < end; i++ {
+			v := vectors[i]
+			if domain != nil {
+				if inverse != 0 {
+					domain.FFTInverse(v, fft.DIF, fft.WithNbTasks(1))
+				} else {
+					domain.FFT(v, fft.DIF, fft.WithNbTasks(1))
+				}
+				fft.BitReverse(v)
+			}
+			if len(twiddles) != 0 {
+				var acc fr.Element
+				acc.SetOne()
+				for k := range v {
+					v[k].Mul(&v[k], &acc)
+					acc.Mul(&acc, &twiddles[i])
+				}
+			}
+		}
+	})
+
+	return encode(vectors)
+}
+
+// domain returns the fft domain of size m, which is created on the first
+// request of this size.
+func (w *Worker) domain(m uint64) *fft.Domain {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if d, ok := w.domains[m]; ok {
+		return d
+	}
+	d := fft.NewDomain(m)
+	w.domains[m] = d
+	return d
+}
+
+// ProveDistributed generates the proof of knowledge of a r1cs with full witness
+// (secret + public part) like Prove, but with the multi-exponentiations and
+// the FFTs computed by workers. The coordinator, which calls ProveDistributed,
+// solves the constraint system and only holds the proving key returned by
+// SplitProvingKey; workers[i] must be connected to a Worker holding the shard
+// i of the proving key.
+//
+// The FFTs of size n = n₁n₂ of the computation of H are split with the
+// four-step algorithm: the workers compute the FFTs of size n₂ of the n₁
+// columns of the vectors seen as matrices, and after a transposition by the
+// coordinator, the FFTs of size n₁ of the rows. The coordinator holds the
+// vectors of the witness, in addition to its proving key.
+func ProveDistributed(r1cs *cs.R1CS, pk *ProvingKey, workers []distributed.Transport, fullWitness witness.Witness, opts ...backend.ProverOption) (*Proof, error) {
+	if len(workers) == 0 {
+		return nil, errors.New("no worker")
+	}
+	opt, err := newProverConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	log := logger.Logger().With().Str("curve", r1cs.CurveID().String()).Str("acceleration", "distributed").Int("nbConstraints", r1cs.GetNbConstraints()).Int("nbWorkers", len(workers)).Str("backend", "groth16").Logger()
+
+	proof, solution, err := solve(r1cs, pk, fullWitness, &opt)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+
+	c := coordinator{workers: workers, domain: &pk.Domain}
+	if err := c.prove(r1cs, pk, proof, solution); err != nil {
+		return nil, err
+	}
+
+	log.Debug().Dur("took", time.Since(start)).Msg("prover done")
+
+	return proof, nil
+}
+
+// coordinator sends the requests of a distributed proof to the workers
+type coordinator struct {
+	workers []distributed.Transport
+	domain  *fft.Domain
+}
+
+// prove computes the parts Ar, Bs and Krs of the proof from the solution,
+// like prove.
+func (c *coordinator) prove(r1cs *cs.R1CS, pk *ProvingKey, proof *Proof, solution *cs.R1CSSolution) error {
+	commitmentInfo := r1cs.CommitmentInfo.(constraint.Groth16Commitments)
+	wireValues := []fr.Element(solution.W)
+
+	// H (witness reduction / FFT part)
+	h, err := c.computeH(solution.A, solution.B, solution.C)
+	if err != nil {
+		return err
+	}
+	solution.A = nil
+	solution.B = nil
+	solution.C = nil
+	h = h[:pk.Domain.Cardinality-1] // comes from the fact the deg(H)=(n-1)+(n-1)-n=n-2
+
+	// the scalars of the multi-exponentiations, see prove
+	wireValuesA := filterInfinity(wireValues, pk.InfinityA, pk.NbInfinityA)
+	wireValuesB := filterInfinity(wireValues, pk.InfinityB, pk.NbInfinityB)
+	toRemove := commitmentInfo.GetPrivateCommitted()
+	toRemove = append(toRemove, commitmentInfo.CommitmentIndexes())
+	wireValuesK := filterHeap(wireValues[r1cs.GetNbPublicVariables():], r1cs.GetNbPublicVariables(), internal.ConcatAll(toRemove...))
+
+	// the workers compute the multi-exponentiations on their shards
+	var ar, bs1, krs curve.G1Jac
+	var Bs curve.G2Jac
+	var lock sync.Mutex
+	var g errgroup.Group
+	nbShards := len(c.workers)
+	for i := range c.workers {
+		i := i
+		g.Go(func() error {
+			request, err := encode(
+				uint32(i),
+				uint32(nbShards),
+				shardOf(wireValuesA, nbShards, i),
+				shardOf(wireValuesB, nbShards, i),
+				shardOf(wireValuesK, nbShards, i),
+				shardOf(h, nbShards, i),
+			)
+			if err != nil {
+				return err
+			}
+			response, err := c.workers[i].Call(methodMSM, request)
+			if err != nil {
+				return fmt.Errorf("worker %d: %w", i, err)
+			}
+			var arI, bs1I, krsI, krs2I curve.G1Affine
+			var bs2I curve.G2Affine
+			if err := decode(response, &arI, &bs1I, &krsI, &krs2I, &bs2I); err != nil {
+				return fmt.Errorf("worker %d: %w", i, err)
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			ar.AddMixed(&arI)
+			bs1.AddMixed(&bs1I)
+			krs.AddMixed(&krsI)
+			krs.AddMixed(&krs2I)
+			Bs.AddMixed(&bs2I)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	// sample random r and s
+	var r, s big.Int
+	var _r, _s, _kr fr.Element
+	if _, err := _r.SetRandom(); err != nil {
+		return err
+	}
+	if _, err := _s.SetRandom(); err != nil {
+		return err
+	}
+	_kr.Mul(&_r, &_s).Neg(&_kr)
+
+	_r.BigInt(&r)
+	_s.BigInt(&s)
+
+	// computes r[δ], s[δ], kr[δ]
+	deltas := curve.BatchScalarMultiplicationG1(&pk.G1.Delta, []fr.Element{_r, _s, _kr})
+
+	ar.AddMixed(&pk.G1.Alpha)
+	ar.AddMixed(&deltas[0])
+	proof.Ar.FromJacobian(&ar)
+
+	bs1.AddMixed(&pk.G1.Beta)
+	bs1.AddMixed(&deltas[1])
+
+	var deltaS curve.G2Jac
+	deltaS.FromAffine(&pk.G2.Delta)
+	deltaS.ScalarMultiplication(&deltaS, &s)
+	Bs.AddAssign(&deltaS)
+	Bs.AddMixed(&pk.G2.Beta)
+	proof.Bs.FromJacobian(&Bs)
+
+	var p1 curve.G1Jac
+	krs.AddMixed(&deltas[2])
+	p1.ScalarMultiplication(&ar, &s)
+	krs.AddAssign(&p1)
+	p1.ScalarMultiplication(&bs1, &r)
+	krs.AddAssign(&p1)
+	proof.Krs.FromJacobian(&krs)
+
+	return nil
+}
+
+// filterInfinity returns the wire values whose point is not at infinity
+func filterInfinity(wireValues []fr.Element, infinity []bool, nbInfinity uint64) []fr.Element {
+	res := make([]fr.Element, len(wireValues)-int(nbInfinity))
+	for i, j := 0, 0; j < len(res); i++ {
+		if infinity[i] {
+			continue
+		}
+		res[j] = wireValues[i]
+		j++
+	}
+	return res
+}
+
+// computeH computes H like computeH, with the FFTs computed by the workers. H
+// is returned in bit reversed order, as the points of pk.G1.Z.
+func (co *coordinator) computeH(a, b, c []fr.Element) ([]fr.Element, error) {
+	// add padding to ensure input length is domain cardinality
+	n := int(co.domain.Cardinality)
+	padding := make([]fr.Element, n-len(a))
+	a = append(a, padding...)
+	b = append(b, padding...)
+	c = append(c, padding...)
+
+	// 	1 - _a = ifft(a), _b = ifft(b), _c = ifft(c)
+	if err := co.fft([][]fr.Element{a, b, c}, true); err != nil {
+		return nil, err
+	}
+
+	// 	2 - ca = fft_coset(_a), ba = fft_coset(_b), cc = fft_coset(_c)
+	scaleByPowers([][]fr.Element{a, b, c}, co.domain.FrMultiplicativeGen)
+	if err := co.fft([][]fr.Element{a, b, c}, false); err != nil {
+		return nil, err
+	}
+
+	var den, one fr.Element
+	one.SetOne()
+	den.Exp(co.domain.FrMultiplicativeGen, big.NewInt(int64(co.domain.Cardinality)))
+	den.Sub(&den, &one).Inverse(&den)
+
+	// 	3 - h = ifft_coset(ca o cb - cc)
+	// reusing a to avoid unnecessary memory allocation
+	utils.Parallelize(n, func(start, end int) {
+		for i := start; i < end; i++ {
+			a[i].Mul(&a[i], &b[i]).
+				Sub(&a[i], &c[i]).
+				Mul(&a[i], &den)
+		}
+	})
+	if err := co.fft([][]fr.Element{a}, true); err != nil {
+		return nil, err
+	}
+	scaleByPowers([][]fr.Element{a}, co.domain.FrMultiplicativeGenInv)
+	fft.BitReverse(a)
+
+	return a, nil
+}
+
+// scaleByPowers multiplies the i-th element of the vectors by gⁱ
+func scaleByPowers(vectors [][]fr.Element, g fr.Element) {
+	utils.Parallelize(len(vectors[0]), func(start, end int) {
+		var acc fr.Element
+		acc.Exp(g, big.NewInt(int64(start)))
+		for i := start; i < end; i++ {
+			for _, v := range vectors {
+				v[i].Mul(&v[i], &acc)
+			}
+			acc.Mul(&acc, &g)
+		}
+	})
+}
+
+// fft computes in place the FFTs (or inverse FFTs) on the domain of the
+// vectors, in natural order, with the four-step algorithm. With n = n₁n₂,
+// j = j₁ + n₁j₂ and k = k₂ + n₂k₁
+//
+//	X[k] = ∑ⱼ₁ ω₁^(j₁k₁) ω^(j₁k₂) ∑ⱼ₂ ω₂^(j₂k₂) x[j₁ + n₁j₂]
+//
+// where ω₁ = ωⁿ², ω₂ = ωⁿ¹ are the generators of the domains of size n₁ and
+// n₂. The workers compute the inner FFTs of the columns j₁ and multiply them
+// by the twiddle factors ω^(j₁k₂), then the outer FFTs of the rows k₂.
+func (c *coordinator) fft(vectors [][]fr.Element, inverse bool) error {
+	n := int(c.domain.Cardinality)
+	n1 := 1 << (bits.TrailingZeros(uint(n)) / 2)
+	n2 := n / n1
+	w := c.domain.Generator
+	if inverse {
+		w = c.domain.GeneratorInv
+	}
+
+	// the columns, of size n₂, and their twiddle factors ω^j₁
+	columns := make([][]fr.Element, len(vectors)*n1)
+	twiddles := make([]fr.Element, len(columns))
+	for v := range vectors {
+		var acc fr.Element
+		acc.SetOne()
+		for j1 := 0; j1 < n1; j1++ {
+			column := make([]fr.Element, n2)
+			for j2 := range column {
+				column[j2] = vectors[v][j1+n1*j2]
+			}
+			columns[v*n1+j1] = column
+			twiddles[v*n1+j1] = acc
+			acc.Mul(&acc, &w)
+		}
+	}
+	if err := c.dispatchFFT(columns, twiddles, inverse); err != nil {
+		return err
+	}
+
+	// the rows, of size n₁
+	rows := make([][]fr.Element, len(vectors)*n2)
+	for v := range vectors {
+		for k2 := 0; k2 < n2; k2++ {
+			row := make([]fr.Element, n1)
+			for j1 := range row {
+				row[j1] = columns[v*n1+j1][k2]
+			}
+			rows[v*n2+k2] = row
+		}
+	}
+	if err := c.dispatchFFT(rows, nil, inverse); err != nil {
+		return err
+	}
+
+	for v := range vectors {
+		for k2 := 0; k2 < n2; k2++ {
+			for k1, x := range rows[v*n2+k2] {
+				vectors[v][k2+n2*k1] = x
+			}
+		}
+	}
+	return nil
+}
+
+// dispatchFFT splits the vectors between the workers, which compute their
+// FFTs and multiply them by the twiddle factors if any. The vectors are
+// replaced by the results.
+func (c *coordinator) dispatchFFT(vectors [][]fr.Element, twiddles []fr.Element, inverse bool) error {
+	var _inverse uint32
+	if inverse {
+		_inverse = 1
+	}
+	var g errgroup.Group
+	for i := range c.workers {
+		start, end := shardRange(len(vectors), len(c.workers), i)
+		if start == end {
+			continue
+		}
+		i := i
+		g.Go(func() error {
+			var _twiddles []fr.Element
+			if twiddles != nil {
+				_twiddles = twiddles[start:end]
+			}
+			request, err := encode(_inverse, _twiddles, vectors[start:end])
+			if err != nil {
+				return err
+			}
+			response, err := c.workers[i].Call(methodFFT, request)
+			if err != nil {
+				return fmt.Errorf("worker %d: %w", i, err)
+			}
+			var res [][]fr.Element
+			if err := decode(response, &res); err != nil {
+				return fmt.Errorf("worker %d: %w", i, err)
+			}
+			if len(res) != end-start {
+				return fmt.Errorf("worker %d: %d vectors instead of %d", i, len(res), end-start)
+			}
+			for j := range res {
+				if len(res[j]) != len(vectors[start+j]) {
+					return fmt.Errorf("worker %d: vector of size %d instead of %d", i, len(res[j]), len(vectors[start+j]))
+				}
+			}
+			copy(vectors[start:end], res)
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// encode encodes the values of a request or a response, without point
+// compression
+func encode(values ...interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := curve.NewEncoder(&buf, curve.RawEncoding())
+	for _, v := range values {
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// decode decodes the values of a request or a response written by encode
+func decode(data []byte, values ...interface{}) error {
+	dec := curve.NewDecoder(bytes.NewReader(data))
+	for _, v := range values {
+		if err := dec.Decode(v); err != nil {
+			return err
+		}
+	}
+	if dec.BytesRead() != int64(len(data)) {
+		return errors.New("unexpected data after the values")
+	}
+	return nil
+}
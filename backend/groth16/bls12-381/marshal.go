@@ -19,8 +19,10 @@ package groth16
 import (
 	curve "github.com/consensys/gnark-crypto/ecc/bls12-381"
 
+	"errors"
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/pedersen"
 	"github.com/consensys/gnark-crypto/utils/unsafe"
+	"github.com/consensys/gnark/internal/mmap"
 	"github.com/consensys/gnark/internal/utils"
 	"io"
 )
@@ -535,3 +537,148 @@ func (pk *ProvingKey) ReadDump(r io.Reader) error {
 	return nil
 
 }
+
+// mmapKind identifies the proving keys of this package in the files written by WriteMmapTo
+var mmapKind = "groth16/" + curve.ID.String()
+
+// WriteMmapTo writes the proving key in a versioned layout where the slices of
+// points are stored raw and aligned, so that Open can map them in memory instead
+// of reading them. As with WriteDump, the layout is platform dependent.
+func (pk *ProvingKey) WriteMmapTo(w io.Writer) (int64, error) {
+	mw, err := mmap.NewWriter(w, mmapKind)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := pk.Domain.WriteTo(mw); err != nil {
+		return mw.BytesWritten(), err
+	}
+
+	enc := curve.NewEncoder(mw, curve.RawEncoding())
+	toEncode := []interface{}{
+		&pk.G1.Alpha,
+		&pk.G1.Beta,
+		&pk.G1.Delta,
+		&pk.G2.Beta,
+		&pk.G2.Delta,
+		pk.NbInfinityA,
+		pk.NbInfinityB,
+		uint32(len(pk.CommitmentKeys)),
+	}
+	for _, v := range toEncode {
+		if err := enc.Encode(v); err != nil {
+			return mw.BytesWritten(), err
+		}
+	}
+
+	for _, v := range [][]curve.G1Affine{pk.G1.A, pk.G1.B, pk.G1.Z, pk.G1.K} {
+		if err := mmap.WriteVector(mw, v); err != nil {
+			return mw.BytesWritten(), err
+		}
+	}
+	if err := mmap.WriteVector(mw, pk.G2.B); err != nil {
+		return mw.BytesWritten(), err
+	}
+	if err := mmap.WriteVector(mw, pk.InfinityA); err != nil {
+		return mw.BytesWritten(), err
+	}
+	if err := mmap.WriteVector(mw, pk.InfinityB); err != nil {
+		return mw.BytesWritten(), err
+	}
+	for i := range pk.CommitmentKeys {
+		if err := mmap.WriteVector(mw, pk.CommitmentKeys[i].Basis); err != nil {
+			return mw.BytesWritten(), err
+		}
+		if err := mmap.WriteVector(mw, pk.CommitmentKeys[i].BasisExpSigma); err != nil {
+			return mw.BytesWritten(), err
+		}
+	}
+
+	return mw.BytesWritten(), nil
+}
+
+// ReadMmap reads a proving key written by WriteMmapTo from data without copying
+// the points: the slices of the proving key point into data, which must stay
+// valid and unmodified while the key is used. The points are not checked.
+func (pk *ProvingKey) ReadMmap(data []byte) error {
+	r, err := mmap.NewReader(data, mmapKind)
+	if err != nil {
+		return err
+	}
+
+	if _, err := pk.Domain.ReadFrom(r); err != nil {
+		return err
+	}
+
+	dec := curve.NewDecoder(r, curve.NoSubgroupChecks())
+	var nbCommitments uint32
+	toDecode := []interface{}{
+		&pk.G1.Alpha,
+		&pk.G1.Beta,
+		&pk.G1.Delta,
+		&pk.G2.Beta,
+		&pk.G2.Delta,
+		&pk.NbInfinityA,
+		&pk.NbInfinityB,
+		&nbCommitments,
+	}
+	for _, v := range toDecode {
+		if err := dec.Decode(v); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range []*[]curve.G1Affine{&pk.G1.A, &pk.G1.B, &pk.G1.Z, &pk.G1.K} {
+		if *v, err = mmap.ReadVector[curve.G1Affine](r); err != nil {
+			return err
+		}
+	}
+	if pk.G2.B, err = mmap.ReadVector[curve.G2Affine](r); err != nil {
+		return err
+	}
+	if pk.InfinityA, err = mmap.ReadVector[bool](r); err != nil {
+		return err
+	}
+	if pk.InfinityB, err = mmap.ReadVector[bool](r); err != nil {
+		return err
+	}
+	pk.CommitmentKeys = make([]pedersen.ProvingKey, nbCommitments)
+	for i := range pk.CommitmentKeys {
+		if pk.CommitmentKeys[i].Basis, err = mmap.ReadVector[curve.G1Affine](r); err != nil {
+			return err
+		}
+		if pk.CommitmentKeys[i].BasisExpSigma, err = mmap.ReadVector[curve.G1Affine](r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Open memory maps the proving key written by WriteMmapTo in the file at path.
+// The points are not read: the operating system loads them from the file when
+// the prover accesses them, and shares them with the other processes mapping
+// the same file. The key must be released with Close.
+func Open(path string) (*ProvingKey, error) {
+	f, err := mmap.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	pk := new(ProvingKey)
+	if err := pk.ReadMmap(f.Bytes()); err != nil {
+		return nil, errors.Join(err, f.Close())
+	}
+	pk.mapped = f
+	return pk, nil
+}
+
+// Close unmaps a proving key returned by Open, which must not be used anymore.
+// It is a no-op on the other proving keys.
+func (pk *ProvingKey) Close() error {
+	if pk.mapped == nil {
+		return nil
+	}
+	err := pk.mapped.Close()
+	*pk = ProvingKey{}
+	return err
+}
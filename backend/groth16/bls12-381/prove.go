@@ -61,16 +61,148 @@ func (proof *Proof) CurveID() ecc.ID {
 
 // Prove generates the proof of knowledge of a r1cs with full witness (secret + public part).
 func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...backend.ProverOption) (*Proof, error) {
+	opt, err := newProverConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	log := logger.Logger().With().Str("curve", r1cs.CurveID().String()).Str("acceleration", "none").Int("nbConstraints", r1cs.GetNbConstraints()).Str("backend", "groth16").Logger()
+
+	proof, solution, err := solve(r1cs, pk, fullWitness, &opt)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+
+	if err := prove(r1cs, pk, proof, solution, newProverBuffers(&pk.Domain)); err != nil {
+		return nil, err
+	}
+
+	log.Debug().Dur("took", time.Since(start)).Msg("prover done")
+
+	return proof, nil
+}
+
+// ProveBatch generates the proofs of knowledge of a r1cs for several full
+// witnesses. The witness i+1 is solved while the proof of the witness i is
+// computed. The FFT domain, its coset tables and the buffers of the prover are
+// set up once and reused from one proof to the next.
+//
+// The proofs are returned in the order of the witnesses. If the proof of a
+// witness fails, the proof is nil and the error is set at the same index of
+// errs; errs only contains nil errors if all the proofs succeed.
+func ProveBatch(r1cs *cs.R1CS, pk *ProvingKey, fullWitnesses []witness.Witness, opts ...backend.ProverOption) (proofs []*Proof, errs []error) {
+	proofs = make([]*Proof, len(fullWitnesses))
+	errs = make([]error, len(fullWitnesses))
+
+	opt, err := newProverConfig(opts...)
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return
+	}
+
+	log := logger.Logger().With().Str("curve", r1cs.CurveID().String()).Str("acceleration", "none").Int("nbConstraints", r1cs.GetNbConstraints()).Int("nbProofs", len(fullWitnesses)).Str("backend", "groth16").Logger()
+	start := time.Now()
+
+	// the solver runs in its own go routine, one witness ahead of the prover.
+	// The channel is not buffered so that at most two solutions are in memory.
+	type solved struct {
+		proof    *Proof
+		solution *cs.R1CSSolution
+		err      error
+	}
+	chSolved := make(chan solved)
+	go func() {
+		for _, fullWitness := range fullWitnesses {
+			proof, solution, err := solve(r1cs, pk, fullWitness, &opt)
+			chSolved <- solved{proof, solution, err}
+		}
+		close(chSolved)
+	}()
+
+	buffers := newProverBuffers(&pk.Domain)
+	for i := range fullWitnesses {
+		s := <-chSolved
+		if s.err != nil {
+			errs[i] = s.err
+			continue
+		}
+		if err := prove(r1cs, pk, s.proof, s.solution, buffers); err != nil {
+			errs[i] = err
+			continue
+		}
+		proofs[i] = s.proof
+	}
+
+	log.Debug().Dur("took", time.Since(start)).Msg("batch prover done")
+
+	return
+}
+
+func newProverConfig(opts ...backend.ProverOption) (backend.ProverConfig, error) {
 	opt, err := backend.NewProverConfig(opts...)
 	if err != nil {
-		return nil, fmt.Errorf("new prover config: %w", err)
+		return opt, fmt.Errorf("new prover config: %w", err)
 	}
 	if opt.HashToFieldFn == nil {
 		opt.HashToFieldFn = hash_to_field.New([]byte(constraint.CommitmentDst))
 	}
+	return opt, nil
+}
 
-	log := logger.Logger().With().Str("curve", r1cs.CurveID().String()).Str("acceleration", "none").Int("nbConstraints", r1cs.GetNbConstraints()).Str("backend", "groth16").Logger()
+// proverBuffers holds the memory of the prover which does not depend on the
+// witness, so that it can be reused by the proofs of a same circuit.
+type proverBuffers struct {
+	// domain is the FFT domain of the proving key, with precomputed twiddles
+	// and coset tables.
+	domain *fft.Domain
+	// den is 1/(gⁿ-1), the inverse of the vanishing polynomial on the coset.
+	den fr.Element
+
+	wireValuesA, wireValuesB []fr.Element
+	// a, b and c are the inputs of computeH, padded to the size of the domain
+	a, b, c []fr.Element
+}
 
+// newProverBuffers returns empty buffers for proofs on domain. If the twiddles
+// and coset tables of domain are not precomputed, they are computed here once
+// instead of at each FFT.
+func newProverBuffers(domain *fft.Domain) *proverBuffers {
+	if _, err := domain.CosetTable(); err != nil {
+		domain = fft.NewDomain(domain.Cardinality)
+	}
+	buffers := &proverBuffers{domain: domain}
+
+	var one fr.Element
+	one.SetOne()
+	buffers.den.Exp(domain.FrMultiplicativeGen, big.NewInt(int64(domain.Cardinality)))
+	buffers.den.Sub(&buffers.den, &one).Inverse(&buffers.den)
+
+	return buffers
+}
+
+// resize returns buf with length n, and reallocates it only if it is too small
+func resize(buf *[]fr.Element, n int) []fr.Element {
+	if cap(*buf) < n {
+		*buf = make([]fr.Element, n)
+	}
+	return (*buf)[:n]
+}
+
+// pad copies v in buf, resized to n, and sets the remaining entries to zero
+func pad(buf *[]fr.Element, v []fr.Element, n int) []fr.Element {
+	r := resize(buf, n)
+	copy(r, v)
+	clear(r[len(v):])
+	return r
+}
+
+// solve solves the r1cs with the full witness, and computes the commitments
+// to the committed wires and their proof of knowledge.
+func solve(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opt *backend.ProverConfig) (*Proof, *cs.R1CSSolution, error) {
 	commitmentInfo := r1cs.CommitmentInfo.(constraint.Groth16Commitments)
 
 	proof := &Proof{Commitments: make([]curve.G1Affine, len(commitmentInfo))}
@@ -111,31 +243,37 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 
 	_solution, err := r1cs.Solve(fullWitness, solverOpts...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	solution := _solution.(*cs.R1CSSolution)
 	wireValues := []fr.Element(solution.W)
 
-	start := time.Now()
-
 	commitmentsSerialized := make([]byte, fr.Bytes*len(commitmentInfo))
 	for i := range commitmentInfo {
 		copy(commitmentsSerialized[fr.Bytes*i:], wireValues[commitmentInfo[i].CommitmentIndex].Marshal())
 	}
 	challenge, err := fr.Hash(commitmentsSerialized, []byte("G16-BSB22"), 1)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if proof.CommitmentPok, err = pedersen.BatchProve(pk.CommitmentKeys, privateCommittedValues, challenge[0]); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
+	return proof, solution, nil
+}
+
+// prove computes the parts Ar, Bs and Krs of the proof from the solution.
+func prove(r1cs *cs.R1CS, pk *ProvingKey, proof *Proof, solution *cs.R1CSSolution, buffers *proverBuffers) error {
+	commitmentInfo := r1cs.CommitmentInfo.(constraint.Groth16Commitments)
+	wireValues := []fr.Element(solution.W)
+
 	// H (witness reduction / FFT part)
 	var h []fr.Element
 	chHDone := make(chan struct{}, 1)
 	go func() {
-		h = computeH(solution.A, solution.B, solution.C, &pk.Domain)
+		h = computeH(solution.A, solution.B, solution.C, buffers)
 		solution.A = nil
 		solution.B = nil
 		solution.C = nil
@@ -148,7 +286,7 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 	chWireValuesA, chWireValuesB := make(chan struct{}, 1), make(chan struct{}, 1)
 
 	go func() {
-		wireValuesA = make([]fr.Element, len(wireValues)-int(pk.NbInfinityA))
+		wireValuesA = resize(&buffers.wireValuesA, len(wireValues)-int(pk.NbInfinityA))
 		for i, j := 0, 0; j < len(wireValuesA); i++ {
 			if pk.InfinityA[i] {
 				continue
@@ -159,7 +297,7 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 		close(chWireValuesA)
 	}()
 	go func() {
-		wireValuesB = make([]fr.Element, len(wireValues)-int(pk.NbInfinityB))
+		wireValuesB = resize(&buffers.wireValuesB, len(wireValues)-int(pk.NbInfinityB))
 		for i, j := 0, 0; j < len(wireValuesB); i++ {
 			if pk.InfinityB[i] {
 				continue
@@ -174,10 +312,10 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 	var r, s big.Int
 	var _r, _s, _kr fr.Element
 	if _, err := _r.SetRandom(); err != nil {
-		return nil, err
+		return err
 	}
 	if _, err := _s.SetRandom(); err != nil {
-		return nil, err
+		return err
 	}
 	_kr.Mul(&_r, &_s).Neg(&_kr)
 
@@ -304,17 +442,80 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 	go computeAR1()
 	go computeBS1()
 	if err := computeBS2(); err != nil {
-		return nil, err
+		return err
 	}
 
 	// wait for all parts of the proof to be computed.
 	if err := <-chKrsDone; err != nil {
-		return nil, err
+		return err
 	}
 
-	log.Debug().Dur("took", time.Since(start)).Msg("prover done")
+	return nil
+}
 
-	return proof, nil
+// Rerandomize returns a new proof for the same statement as proof, which is
+// unlinkable to it. With random r₁ ≠ 0 and r₂ it computes
+//
+//	Ar' = r₁⁻¹·Ar
+//	Bs' = r₁·Bs + r₁r₂·[δ]₂
+//	Krs' = Krs + r₂·Ar
+//
+// so that e(Ar', Bs') = e(Ar, Bs)·e(r₂·Ar, [δ]₂) and the verification equation
+// still holds. The commitments and their proof of knowledge are copied as is:
+// they are hashed into the commitment wires of the public witness, so they can
+// not be changed without changing the statement. The proofs of circuits with
+// commitments therefore share the same D and proof of knowledge, which link
+// them: only Ar, Bs and Krs are unlinkable.
+//
+// Rerandomize does not verify the proof, a proof which does not verify gives a
+// proof which does not verify either.
+func Rerandomize(proof *Proof, vk *VerifyingKey) (*Proof, error) {
+	if !proof.isValid() {
+		return nil, errCorrectSubgroupCheckFailed
+	}
+
+	var r1, r2, r1Inv, r1r2 fr.Element
+	for r1.IsZero() {
+		if _, err := r1.SetRandom(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := r2.SetRandom(); err != nil {
+		return nil, err
+	}
+	r1Inv.Inverse(&r1)
+	r1r2.Mul(&r1, &r2)
+
+	var _r1, _r2, _r1Inv, _r1r2 big.Int
+	r1.BigInt(&_r1)
+	r2.BigInt(&_r2)
+	r1Inv.BigInt(&_r1Inv)
+	r1r2.BigInt(&_r1r2)
+
+	res := &Proof{
+		Commitments:   make([]curve.G1Affine, len(proof.Commitments)),
+		CommitmentPok: proof.CommitmentPok,
+	}
+	copy(res.Commitments, proof.Commitments)
+
+	var ar, krs, p1 curve.G1Jac
+	ar.FromAffine(&proof.Ar)
+	krs.FromAffine(&proof.Krs)
+	p1.ScalarMultiplication(&ar, &_r2)
+	krs.AddAssign(&p1)
+	ar.ScalarMultiplication(&ar, &_r1Inv)
+	res.Ar.FromJacobian(&ar)
+	res.Krs.FromJacobian(&krs)
+
+	var bs, delta curve.G2Jac
+	bs.FromAffine(&proof.Bs)
+	bs.ScalarMultiplication(&bs, &_r1)
+	delta.FromAffine(&vk.G2.Delta)
+	delta.ScalarMultiplication(&delta, &_r1r2)
+	bs.AddAssign(&delta)
+	res.Bs.FromJacobian(&bs)
+
+	return res, nil
 }
 
 // if len(toRemove) == 0, returns slice
@@ -346,21 +547,22 @@ func filterHeap(slice []fr.Element, sliceFirstIndex int, toRemove []int) (r []fr
 	return
 }
 
-func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
+// computeH returns h in the buffer buffers.a, which is overwritten by the next
+// call with the same buffers.
+func computeH(a, b, c []fr.Element, buffers *proverBuffers) []fr.Element {
 	// H part of Krs
 	// Compute H (hz=ab-c, where z=-2 on ker X^n+1 (z(x)=x^n-1))
 	// 	1 - _a = ifft(a), _b = ifft(b), _c = ifft(c)
 	// 	2 - ca = fft_coset(_a), ba = fft_coset(_b), cc = fft_coset(_c)
 	// 	3 - h = ifft_coset(ca o cb - cc)
 
-	n := len(a)
+	domain := buffers.domain
+	n := int(domain.Cardinality)
 
 	// add padding to ensure input length is domain cardinality
-	padding := make([]fr.Element, int(domain.Cardinality)-n)
-	a = append(a, padding...)
-	b = append(b, padding...)
-	c = append(c, padding...)
-	n = len(a)
+	a = pad(&buffers.a, a, n)
+	b = pad(&buffers.b, b, n)
+	c = pad(&buffers.c, c, n)
 
 	domain.FFTInverse(a, fft.DIF)
 	domain.FFTInverse(b, fft.DIF)
@@ -370,10 +572,7 @@ func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
 	domain.FFT(b, fft.DIT, fft.OnCoset())
 	domain.FFT(c, fft.DIT, fft.OnCoset())
 
-	var den, one fr.Element
-	one.SetOne()
-	den.Exp(domain.FrMultiplicativeGen, big.NewInt(int64(domain.Cardinality)))
-	den.Sub(&den, &one).Inverse(&den)
+	den := buffers.den
 
 	// h = ifft_coset(ca o cb - cc)
 	// reusing a to avoid unnecessary memory allocation
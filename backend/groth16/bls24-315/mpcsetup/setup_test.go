@@ -17,10 +17,13 @@
 package mpcsetup
 
 import (
+	"math/bits"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
 	curve "github.com/consensys/gnark-crypto/ecc/bls24-315"
 	"github.com/consensys/gnark-crypto/ecc/bls24-315/fr"
 	cs "github.com/consensys/gnark/constraint/bls24-315"
-	"testing"
 
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/frontend"
@@ -35,14 +38,42 @@ func TestSetupCircuit(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
 	}
+
+	// Build the witness
+	var preImage, hash fr.Element
+	{
+		m := native_mimc.NewMiMC()
+		m.Write(preImage.Marshal())
+		hash.SetBytes(m.Sum(nil))
+	}
+
+	testSetup(t, &Circuit{}, &Circuit{PreImage: preImage, Hash: hash})
+}
+
+func TestSetupCircuitWithCommitments(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	testSetup(t, &CircuitWithCommitments{}, &CircuitWithCommitments{X: 3, Y: 9, Z: 5})
+}
+
+// testSetup runs the MPC for circuit, and checks that the extracted keys
+// prove and verify the assignment
+func testSetup(t *testing.T, circuit, assignment frontend.Circuit) {
 	const (
 		nContributionsPhase1 = 3
 		nContributionsPhase2 = 3
-		power                = 9
 	)
 
 	assert := require.New(t)
 
+	// Compile the circuit
+	ccs, err := frontend.Compile(curve.ID.ScalarField(), r1cs.NewBuilder, circuit)
+	assert.NoError(err)
+
+	// the size of phase1 is the size of the domain of the circuit
+	power := bits.Len64(ecc.NextPowerOfTwo(uint64(ccs.GetNbConstraints()))) - 1
 	srs1 := InitPhase1(power)
 
 	// Make and verify contributions for phase1
@@ -55,11 +86,6 @@ func TestSetupCircuit(t *testing.T) {
 		assert.NoError(VerifyPhase1(&prev, &srs1))
 	}
 
-	// Compile the circuit
-	var myCircuit Circuit
-	ccs, err := frontend.Compile(curve.ID.ScalarField(), r1cs.NewBuilder, &myCircuit)
-	assert.NoError(err)
-
 	var evals Phase2Evaluations
 	r1cs := ccs.(*cs.R1CS)
 
@@ -79,15 +105,7 @@ func TestSetupCircuit(t *testing.T) {
 	// Extract the proving and verifying keys
 	pk, vk := ExtractKeys(&srs1, &srs2, &evals, ccs.GetNbConstraints())
 
-	// Build the witness
-	var preImage, hash fr.Element
-	{
-		m := native_mimc.NewMiMC()
-		m.Write(preImage.Marshal())
-		hash.SetBytes(m.Sum(nil))
-	}
-
-	witness, err := frontend.NewWitness(&Circuit{PreImage: preImage, Hash: hash}, curve.ID.ScalarField())
+	witness, err := frontend.NewWitness(assignment, curve.ID.ScalarField())
 	assert.NoError(err)
 
 	pubWitness, err := witness.Public()
@@ -101,6 +119,57 @@ func TestSetupCircuit(t *testing.T) {
 	assert.NoError(err)
 }
 
+func TestVerifyPhase2Sigma(t *testing.T) {
+	assert := require.New(t)
+
+	srs1 := InitPhase1(3)
+	srs1.Contribute()
+
+	ccs, err := frontend.Compile(curve.ID.ScalarField(), r1cs.NewBuilder, &CircuitWithCommitments{})
+	assert.NoError(err)
+
+	srs2, _ := InitPhase2(ccs.(*cs.R1CS), &srs1)
+	assert.Len(srs2.Parameters.G1.SigmaCKK, 2)
+	prev := srs2.clone()
+	srs2.Contribute()
+	assert.NoError(VerifyPhase2(&prev, &srs2))
+
+	// a commitment key which is not updated with σ is rejected
+	srs2.Parameters.G1.SigmaCKK[0][0] = prev.Parameters.G1.SigmaCKK[0][0]
+	srs2.Hash = srs2.hash()
+	assert.Error(VerifyPhase2(&prev, &srs2))
+}
+
+func TestContributeBeacon(t *testing.T) {
+	assert := require.New(t)
+	beacon := []byte("beacon")
+
+	// the contributions from a beacon are verified and can be recomputed
+	srs1 := InitPhase1(3)
+	srs1.Contribute()
+	prev1 := srs1.clone()
+	srs1.ContributeBeacon(beacon)
+	assert.NoError(VerifyPhase1(&prev1, &srs1))
+	recomputed1 := prev1.clone()
+	recomputed1.ContributeBeacon(beacon)
+	assert.Equal(srs1.Hash, recomputed1.Hash)
+
+	ccs, err := frontend.Compile(curve.ID.ScalarField(), r1cs.NewBuilder, &CircuitWithCommitments{})
+	assert.NoError(err)
+	srs2, _ := InitPhase2(ccs.(*cs.R1CS), &srs1)
+	srs2.Contribute()
+	prev2 := srs2.clone()
+	srs2.ContributeBeacon(beacon)
+	assert.NoError(VerifyPhase2(&prev2, &srs2))
+	recomputed2 := prev2.clone()
+	recomputed2.ContributeBeacon(beacon)
+	assert.Equal(srs2.Hash, recomputed2.Hash)
+
+	recomputed2 = prev2.clone()
+	recomputed2.ContributeBeacon([]byte("other beacon"))
+	assert.NotEqual(srs2.Hash, recomputed2.Hash)
+}
+
 func BenchmarkPhase1(b *testing.B) {
 	const power = 14
 
@@ -171,6 +240,28 @@ func (circuit *Circuit) Define(api frontend.API) error {
 	return nil
 }
 
+// CircuitWithCommitments commits twice to private and public variables
+type CircuitWithCommitments struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+	Z frontend.Variable
+}
+
+func (circuit *CircuitWithCommitments) Define(api frontend.API) error {
+	committer := api.(frontend.Committer)
+	api.AssertIsEqual(api.Mul(circuit.X, circuit.X), circuit.Y)
+	c1, err := committer.Commit(circuit.X, circuit.Y)
+	if err != nil {
+		return err
+	}
+	c2, err := committer.Commit(circuit.Z)
+	if err != nil {
+		return err
+	}
+	api.AssertIsDifferent(c1, c2)
+	return nil
+}
+
 func (phase1 *Phase1) clone() Phase1 {
 	r := Phase1{}
 	r.Parameters.G1.Tau = append(r.Parameters.G1.Tau, phase1.Parameters.G1.Tau...)
@@ -192,7 +283,13 @@ func (phase2 *Phase2) clone() Phase2 {
 	r.Parameters.G1.L = append(r.Parameters.G1.L, phase2.Parameters.G1.L...)
 	r.Parameters.G1.Z = append(r.Parameters.G1.Z, phase2.Parameters.G1.Z...)
 	r.Parameters.G2.Delta = phase2.Parameters.G2.Delta
+	r.Parameters.G1.SigmaCKK = make([][]curve.G1Affine, len(phase2.Parameters.G1.SigmaCKK))
+	for i := range r.Parameters.G1.SigmaCKK {
+		r.Parameters.G1.SigmaCKK[i] = append(r.Parameters.G1.SigmaCKK[i], phase2.Parameters.G1.SigmaCKK[i]...)
+	}
+	r.Parameters.G2.Sigma = phase2.Parameters.G2.Sigma
 	r.PublicKey = phase2.PublicKey
+	r.SigmaPublicKey = phase2.SigmaPublicKey
 	r.Hash = append(r.Hash, phase2.Hash...)
 
 	return r
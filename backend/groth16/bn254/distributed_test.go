@@ -0,0 +1,75 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+package groth16
+
+import (
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
+	"github.com/consensys/gnark/backend/groth16/distributed"
+	"github.com/consensys/gnark/io"
+	"github.com/stretchr/testify/require"
+
+	"testing"
+)
+
+func TestDistributedComputeH(t *testing.T) {
+	assert := require.New(t)
+
+	for _, nbWorkers := range []int{1, 3} {
+		workers := make([]distributed.Transport, nbWorkers)
+		for i := range workers {
+			workers[i] = distributed.Loopback(NewWorker(new(ProvingKeyShard)))
+		}
+		for _, n := range []int{1, 2, 5, 8, 33} {
+			domain := fft.NewDomain(uint64(n))
+			a, b, c := make([]fr.Element, n), make([]fr.Element, n), make([]fr.Element, n)
+			for i := range a {
+				a[i].SetRandom()
+				b[i].SetRandom()
+				c[i].Mul(&a[i], &b[i])
+			}
+			expected := computeH(a, b, c, newProverBuffers(domain))
+
+			co := coordinator{workers: workers, domain: domain}
+			h, err := co.computeH(a, b, c)
+			assert.NoError(err)
+			assert.Equal(expected, h, "%d workers, size %d", nbWorkers, n)
+		}
+	}
+}
+
+func TestProvingKeyShardSerialization(t *testing.T) {
+	assert := require.New(t)
+
+	_, _, g1, g2 := curve.Generators()
+	var pk ProvingKey
+	pk.G1.A = []curve.G1Affine{g1, g1, g1}
+	pk.G1.B = []curve.G1Affine{g1, g1}
+	pk.G1.K = []curve.G1Affine{g1, g1}
+	pk.G1.Z = []curve.G1Affine{g1, g1, g1, g1}
+	pk.G2.B = []curve.G2Affine{g2, g2}
+
+	_, shards, err := SplitProvingKey(&pk, 2)
+	assert.NoError(err)
+	assert.Len(shards, 2)
+	for _, shard := range shards {
+		assert.NoError(io.RoundTripCheck(shard, func() any { return new(ProvingKeyShard) }))
+	}
+	assert.Len(shards[1].G1.A, 2)
+	assert.Len(shards[0].G1.Z, 2)
+}
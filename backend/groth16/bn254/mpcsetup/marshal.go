@@ -18,6 +18,7 @@ package mpcsetup
 
 import (
 	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark/internal/utils"
 	"io"
 )
 
@@ -108,6 +109,10 @@ func (c *Phase2) writeTo(writer io.Writer) (int64, error) {
 		c.Parameters.G1.L,
 		c.Parameters.G1.Z,
 		&c.Parameters.G2.Delta,
+		&c.SigmaPublicKey.SG,
+		&c.SigmaPublicKey.SXG,
+		&c.SigmaPublicKey.XR,
+		&c.Parameters.G2.Sigma,
 	}
 
 	for _, v := range toEncode {
@@ -115,6 +120,9 @@ func (c *Phase2) writeTo(writer io.Writer) (int64, error) {
 			return enc.BytesWritten(), err
 		}
 	}
+	if err := encodeG1Slices(enc, c.Parameters.G1.SigmaCKK); err != nil {
+		return enc.BytesWritten(), err
+	}
 
 	return enc.BytesWritten(), nil
 }
@@ -130,6 +138,10 @@ func (c *Phase2) ReadFrom(reader io.Reader) (int64, error) {
 		&c.Parameters.G1.L,
 		&c.Parameters.G1.Z,
 		&c.Parameters.G2.Delta,
+		&c.SigmaPublicKey.SG,
+		&c.SigmaPublicKey.SXG,
+		&c.SigmaPublicKey.XR,
+		&c.Parameters.G2.Sigma,
 	}
 
 	for _, v := range toEncode {
@@ -137,6 +149,9 @@ func (c *Phase2) ReadFrom(reader io.Reader) (int64, error) {
 			return dec.BytesRead(), err
 		}
 	}
+	if err := decodeG1Slices(dec, &c.Parameters.G1.SigmaCKK); err != nil {
+		return dec.BytesRead(), err
+	}
 
 	c.Hash = make([]byte, 32)
 	n, err := reader.Read(c.Hash)
@@ -150,7 +165,9 @@ func (c *Phase2Evaluations) WriteTo(writer io.Writer) (int64, error) {
 	toEncode := []interface{}{
 		c.G1.A,
 		c.G1.B,
+		c.G1.VKK,
 		c.G2.B,
+		utils.IntSliceSliceToUint64SliceSlice(c.PublicAndCommitmentCommitted),
 	}
 
 	for _, v := range toEncode {
@@ -158,6 +175,9 @@ func (c *Phase2Evaluations) WriteTo(writer io.Writer) (int64, error) {
 			return enc.BytesWritten(), err
 		}
 	}
+	if err := encodeG1Slices(enc, c.G1.CKK); err != nil {
+		return enc.BytesWritten(), err
+	}
 
 	return enc.BytesWritten(), nil
 }
@@ -165,10 +185,13 @@ func (c *Phase2Evaluations) WriteTo(writer io.Writer) (int64, error) {
 // ReadFrom implements io.ReaderFrom
 func (c *Phase2Evaluations) ReadFrom(reader io.Reader) (int64, error) {
 	dec := curve.NewDecoder(reader)
+	var publicAndCommitmentCommitted [][]uint64
 	toEncode := []interface{}{
 		&c.G1.A,
 		&c.G1.B,
+		&c.G1.VKK,
 		&c.G2.B,
+		&publicAndCommitmentCommitted,
 	}
 
 	for _, v := range toEncode {
@@ -176,6 +199,38 @@ func (c *Phase2Evaluations) ReadFrom(reader io.Reader) (int64, error) {
 			return dec.BytesRead(), err
 		}
 	}
+	c.PublicAndCommitmentCommitted = utils.Uint64SliceSliceToIntSliceSlice(publicAndCommitmentCommitted)
+	if err := decodeG1Slices(dec, &c.G1.CKK); err != nil {
+		return dec.BytesRead(), err
+	}
 
 	return dec.BytesRead(), nil
 }
+
+// encodeG1Slices encodes the number of slices, followed by the slices
+func encodeG1Slices(enc *curve.Encoder, s [][]curve.G1Affine) error {
+	if err := enc.Encode(uint32(len(s))); err != nil {
+		return err
+	}
+	for i := range s {
+		if err := enc.Encode(s[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeG1Slices decodes slices encoded by encodeG1Slices
+func decodeG1Slices(dec *curve.Decoder, s *[][]curve.G1Affine) error {
+	var n uint32
+	if err := dec.Decode(&n); err != nil {
+		return err
+	}
+	*s = make([][]curve.G1Affine, n)
+	for i := range *s {
+		if err := dec.Decode(&(*s)[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -52,14 +52,15 @@ type Phase1 struct {
 func InitPhase1(power int) (phase1 Phase1) {
 	N := int(math.Pow(2, float64(power)))
 
-	// Generate key pairs
+	// Generate key pairs, deterministically so that anyone can recompute the
+	// initialization
 	var tau, alpha, beta fr.Element
 	tau.SetOne()
 	alpha.SetOne()
 	beta.SetOne()
-	phase1.PublicKeys.Tau = newPublicKey(tau, nil, 1)
-	phase1.PublicKeys.Alpha = newPublicKey(alpha, nil, 2)
-	phase1.PublicKeys.Beta = newPublicKey(beta, nil, 3)
+	phase1.PublicKeys.Tau = newPublicKey(tau, nil, 1, new(sampler))
+	phase1.PublicKeys.Alpha = newPublicKey(alpha, nil, 2, new(sampler))
+	phase1.PublicKeys.Beta = newPublicKey(beta, nil, 3, new(sampler))
 
 	// First contribution use generators
 	_, _, g1, g2 := curve.Generators()
@@ -87,16 +88,24 @@ func InitPhase1(power int) (phase1 Phase1) {
 
 // Contribute contributes randomness to the phase1 object. This mutates phase1.
 func (phase1 *Phase1) Contribute() {
+	phase1.contribute(nil)
+}
+
+// ContributeBeacon contributes to the phase1 object with secrets derived from
+// a random beacon, typically to end the phase once all the participants have
+// contributed. Anyone knowing the beacon can recompute the contribution.
+func (phase1 *Phase1) ContributeBeacon(beacon []byte) {
+	phase1.contribute(&sampler{beacon: beacon})
+}
+
+func (phase1 *Phase1) contribute(rand *sampler) {
 	N := len(phase1.Parameters.G2.Tau)
 
 	// Generate key pairs
-	var tau, alpha, beta fr.Element
-	tau.SetRandom()
-	alpha.SetRandom()
-	beta.SetRandom()
-	phase1.PublicKeys.Tau = newPublicKey(tau, phase1.Hash[:], 1)
-	phase1.PublicKeys.Alpha = newPublicKey(alpha, phase1.Hash[:], 2)
-	phase1.PublicKeys.Beta = newPublicKey(beta, phase1.Hash[:], 3)
+	tau, alpha, beta := rand.sample(), rand.sample(), rand.sample()
+	phase1.PublicKeys.Tau = newPublicKey(tau, phase1.Hash[:], 1, rand)
+	phase1.PublicKeys.Alpha = newPublicKey(alpha, phase1.Hash[:], 2, rand)
+	phase1.PublicKeys.Beta = newPublicKey(beta, phase1.Hash[:], 3, rand)
 
 	// Compute powers of τ, ατ, and βτ
 	taus := powers(tau, 2*N-1)
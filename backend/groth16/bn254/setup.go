@@ -26,6 +26,7 @@ import (
 	"github.com/consensys/gnark/backend/groth16/internal"
 	"github.com/consensys/gnark/constraint"
 	cs "github.com/consensys/gnark/constraint/bn254"
+	"github.com/consensys/gnark/internal/mmap"
 	"math/big"
 	"math/bits"
 )
@@ -55,6 +56,8 @@ type ProvingKey struct {
 	NbInfinityA, NbInfinityB uint64
 
 	CommitmentKeys []pedersen.ProvingKey
+
+	mapped *mmap.File // memory mapping of a key returned by Open
 }
 
 // VerifyingKey is used by a Groth16 verifier to verify the validity of a proof and a statement
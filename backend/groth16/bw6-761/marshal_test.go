@@ -196,6 +196,11 @@ func TestProvingKeySerialization(t *testing.T) {
 				t.Log(err)
 				return false
 			}
+
+			if err := io.MmapRoundTripCheck(&pk, func() any { return new(ProvingKey) }); err != nil {
+				t.Log(err)
+				return false
+			}
 			return true
 		},
 		GenG1(),
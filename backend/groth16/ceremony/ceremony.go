@@ -0,0 +1,515 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ceremony coordinates the MPC setup of Groth16 (see the mpcsetup
+// package of each curve) in a local directory.
+//
+// The coordinator initializes the phase 1, then participants contribute one
+// after the other: a participant downloads the last contribution (Next), adds
+// its own (Contribute) and drops the result in the queue directory, named
+// after the participant, along with its attestation: the signature of the
+// contribution with the key of the participant. The coordinator verifies the
+// queued contributions and their attestations on receipt (Process), and
+// records the accepted ones in the transcript. A random beacon, typically a
+// public value which can't be known before the last contribution, ends the
+// phase (ApplyBeacon). The phase 2 is then initialized for a circuit
+// (StartPhase2) and runs the same way, before the coordinator extracts the
+// proving and verifying keys (Finalize).
+//
+// Every step is recorded in a transcript signed by the coordinator, with the
+// attestations of the contributions, and Verify replays the whole ceremony
+// from the directory.
+//
+// The directory of a ceremony holds:
+//
+//	transcript.json          the signed transcript
+//	contributions/           the accepted contributions, and the initializations and beacons
+//	queue/                   the contributions waiting for verification, with their attestations
+//	rejected/                the contributions which failed verification
+//	circuit.r1cs             the constraint system of the phase 2
+//	provingkey.bin           the keys extracted at the end of the ceremony
+//	verifyingkey.bin
+package ceremony
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/internal/utils"
+)
+
+// directories of a ceremony where the participants drop their contributions,
+// and where the coordinator moves the invalid ones
+const (
+	QueueDir    = "queue"
+	RejectedDir = "rejected"
+)
+
+// AttestationExt is the extension of the file holding the attestation of a
+// queued contribution, which has the same name otherwise: alice.bin is queued
+// with alice.att.
+const AttestationExt = ".att"
+
+// files and directories of a ceremony
+const (
+	transcriptFile   = "transcript.json"
+	circuitFile      = "circuit.r1cs"
+	provingKeyFile   = "provingkey.bin"
+	verifyingKeyFile = "verifyingkey.bin"
+	contributionsDir = "contributions"
+)
+
+// Coordinator runs a ceremony in a directory.
+type Coordinator struct {
+	dir        string
+	key        ed25519.PrivateKey
+	transcript *Transcript
+	scheme     scheme
+}
+
+// Result is the outcome of the verification of a queued contribution.
+type Result struct {
+	Participant string
+	Err         error // nil if the contribution is accepted
+}
+
+// New initializes in dir a ceremony on the given curve, whose phase 1 has
+// size 2ᵖᵒʷᵉʳ: the number of constraints of the circuit of the phase 2 must be
+// at most 2ᵖᵒʷᵉʳ, and more than 2ᵖᵒʷᵉʳ⁻¹. The transcript is signed with key.
+func New(dir string, curveID ecc.ID, power int, key ed25519.PrivateKey) (*Coordinator, error) {
+	if _, err := os.Stat(filepath.Join(dir, transcriptFile)); err == nil {
+		return nil, fmt.Errorf("%s already holds a ceremony", dir)
+	}
+	if power < 1 || power > 31 {
+		return nil, fmt.Errorf("invalid power %d", power)
+	}
+	s, err := getScheme(curveID)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range []string{contributionsDir, QueueDir, RejectedDir} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0700); err != nil {
+			return nil, err
+		}
+	}
+
+	c := &Coordinator{
+		dir: dir,
+		key: key,
+		transcript: &Transcript{
+			Curve:     curveID.String(),
+			Power:     power,
+			PublicKey: key.Public().(ed25519.PublicKey),
+		},
+		scheme: s,
+	}
+	if err := c.record(Entry{Phase: 1, Kind: KindInit}, s.initPhase1(power), ""); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Open opens the ceremony in dir, whose transcript must be signed with key.
+func Open(dir string, key ed25519.PrivateKey) (*Coordinator, error) {
+	t, err := readTranscript(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !key.Public().(ed25519.PublicKey).Equal(t.PublicKey) {
+		return nil, errors.New("the transcript is signed with another key")
+	}
+	if len(t.Entries) == 0 {
+		return nil, errors.New("empty transcript")
+	}
+	curveID, err := ecc.IDFromString(t.Curve)
+	if err != nil {
+		return nil, err
+	}
+	s, err := getScheme(curveID)
+	if err != nil {
+		return nil, err
+	}
+	return &Coordinator{dir: dir, key: key, transcript: t, scheme: s}, nil
+}
+
+// Transcript returns the transcript of the ceremony.
+func (c *Coordinator) Transcript() *Transcript {
+	return c.transcript
+}
+
+// Phase returns the current phase of the ceremony, and whether it is closed
+// by a beacon (or by the keys for the phase 2).
+func (c *Coordinator) Phase() (phase int, closed bool) {
+	last := c.transcript.last()
+	return last.Phase, last.Kind == KindBeacon || last.Kind == KindKeys
+}
+
+// Next returns the path of the last contribution, on top of which the next
+// participant contributes.
+func (c *Coordinator) Next() (string, error) {
+	if _, closed := c.Phase(); closed {
+		return "", errors.New("the phase is closed")
+	}
+	return filepath.Join(c.dir, c.transcript.last().File), nil
+}
+
+// Process verifies the contributions in the queue directory, in the order of
+// their names, against the last contribution, and checks their attestations.
+// The accepted contributions are recorded in the transcript with their
+// attestations, and the others are moved to the rejected directory. The name
+// of a participant is the name of its file, without extension.
+func (c *Coordinator) Process() ([]Result, error) {
+	if _, closed := c.Phase(); closed {
+		return nil, errors.New("the phase is closed")
+	}
+	queue := filepath.Join(c.dir, QueueDir)
+	files, err := os.ReadDir(queue)
+	if err != nil {
+		return nil, err
+	}
+	var results []Result
+	for _, f := range files {
+		if f.IsDir() || strings.HasSuffix(f.Name(), ".tmp") || filepath.Ext(f.Name()) == AttestationExt {
+			continue
+		}
+		participant := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+		paths := []string{f.Name(), participant + AttestationExt}
+		if err := c.accept(participant, filepath.Join(queue, paths[0]), filepath.Join(queue, paths[1])); err != nil {
+			results = append(results, Result{participant, err})
+			for _, p := range paths {
+				if err := os.Rename(filepath.Join(queue, p), filepath.Join(c.dir, RejectedDir, p)); err != nil && !errors.Is(err, os.ErrNotExist) {
+					return results, err
+				}
+			}
+			continue
+		}
+		results = append(results, Result{Participant: participant})
+		for _, p := range paths {
+			if err := os.Remove(filepath.Join(queue, p)); err != nil {
+				return results, err
+			}
+		}
+	}
+	return results, nil
+}
+
+// accept verifies the contribution in path and its attestation in
+// attestationPath, and records them
+func (c *Coordinator) accept(participant, path, attestationPath string) error {
+	attestation, err := readAttestation(attestationPath)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	h := sha256.Sum256(data)
+	if err := attestation.verify(h[:]); err != nil {
+		return err
+	}
+
+	last := c.transcript.last()
+	prev, err := c.load(last)
+	if err != nil {
+		return err
+	}
+	next := c.newContribution(last.Phase)
+	if _, err := next.ReadFrom(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	// the coordinator records the contribution as it encodes it, which must be
+	// the attested file
+	if err := checkHash(next, &Entry{Kind: KindContribution, File: path, Hash: h[:]}); err != nil {
+		return err
+	}
+	if err := verify(c.verifier(last.Phase), prev, next); err != nil {
+		return err
+	}
+	return c.record(Entry{
+		Phase:          last.Phase,
+		Kind:           KindContribution,
+		Participant:    participant,
+		ParticipantKey: attestation.PublicKey,
+		Attestation:    attestation.Signature,
+	}, next, "")
+}
+
+// ApplyBeacon closes the current phase with a contribution derived from the
+// beacon, which must be a public value unknown to the participants, for
+// example the hash of a future block of a blockchain. The phase must have
+// received at least one contribution.
+func (c *Coordinator) ApplyBeacon(beacon []byte) error {
+	phase, closed := c.Phase()
+	if closed {
+		return errors.New("the phase is closed")
+	}
+	if c.transcript.last().Kind != KindContribution {
+		return errors.New("the phase has no contribution")
+	}
+	if len(beacon) == 0 {
+		return errors.New("empty beacon")
+	}
+	last, err := c.load(c.transcript.last())
+	if err != nil {
+		return err
+	}
+	last.ContributeBeacon(beacon)
+	return c.record(Entry{Phase: phase, Kind: KindBeacon, Beacon: beacon}, last, "")
+}
+
+// StartPhase2 initializes the phase 2 for the given circuit, once the phase 1
+// is closed.
+func (c *Coordinator) StartPhase2(r1cs constraint.ConstraintSystem) error {
+	if phase, closed := c.Phase(); phase != 1 || !closed {
+		return errors.New("the phase 1 must be closed by a beacon")
+	}
+	if err := checkCircuit(c.transcript, r1cs); err != nil {
+		return err
+	}
+	var circuit bytes.Buffer
+	if _, err := r1cs.WriteTo(&circuit); err != nil {
+		return err
+	}
+	phase1, err := c.load(c.transcript.last())
+	if err != nil {
+		return err
+	}
+	phase2, _, err := c.scheme.initPhase2(r1cs, phase1)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(c.dir, circuitFile), circuit.Bytes()); err != nil {
+		return err
+	}
+	h := sha256.Sum256(circuit.Bytes())
+	return c.record(Entry{Phase: 2, Kind: KindInit, Circuit: h[:]}, phase2, "")
+}
+
+// Finalize extracts the proving and verifying keys once the phase 2 is closed,
+// and records them in the directory of the ceremony.
+func (c *Coordinator) Finalize() (groth16.ProvingKey, groth16.VerifyingKey, error) {
+	if phase, closed := c.Phase(); phase != 2 || !closed || c.transcript.last().Kind != KindBeacon {
+		return nil, nil, errors.New("the phase 2 must be closed by a beacon")
+	}
+	r1cs, err := readCircuit(c.dir, c.transcript.Curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	var phase1 contribution
+	for i := range c.transcript.Entries {
+		if e := &c.transcript.Entries[i]; e.Phase == 1 && e.Kind == KindBeacon {
+			if phase1, err = c.load(e); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	phase2, err := c.load(c.transcript.last())
+	if err != nil {
+		return nil, nil, err
+	}
+	_, evals, err := c.scheme.initPhase2(r1cs, phase1)
+	if err != nil {
+		return nil, nil, err
+	}
+	pk, vk := c.scheme.extractKeys(phase1, phase2, evals, r1cs.GetNbConstraints())
+
+	if err := c.record(Entry{Phase: 2, Kind: KindKeys}, pk, provingKeyFile); err != nil {
+		return nil, nil, err
+	}
+	if err := c.record(Entry{Phase: 2, Kind: KindKeys}, vk, verifyingKeyFile); err != nil {
+		return nil, nil, err
+	}
+	return pk, vk, nil
+}
+
+// record writes the data of the entry to file, or to a new file of the
+// contributions directory if file is empty, and appends the entry to the
+// transcript.
+func (c *Coordinator) record(e Entry, data io.WriterTo, file string) error {
+	var buf bytes.Buffer
+	if _, err := data.WriteTo(&buf); err != nil {
+		return err
+	}
+	if file == "" {
+		file = filepath.Join(contributionsDir, fmt.Sprintf("phase%d-%04d.bin", e.Phase, len(c.transcript.Entries)))
+	}
+	if err := writeFile(filepath.Join(c.dir, file), buf.Bytes()); err != nil {
+		return err
+	}
+	h := sha256.Sum256(buf.Bytes())
+	e.File = filepath.ToSlash(file)
+	e.Hash = h[:]
+	c.transcript.append(e, c.key)
+	return c.transcript.write(c.dir)
+}
+
+// load reads the contribution of the entry
+func (c *Coordinator) load(e *Entry) (contribution, error) {
+	return readContribution(c.newContribution(e.Phase), filepath.Join(c.dir, e.File))
+}
+
+func (c *Coordinator) newContribution(phase int) contribution {
+	if phase == 1 {
+		return c.scheme.newPhase1()
+	}
+	return c.scheme.newPhase2()
+}
+
+func (c *Coordinator) verifier(phase int) func(prev, next contribution) error {
+	if phase == 1 {
+		return c.scheme.verifyPhase1
+	}
+	return c.scheme.verifyPhase2
+}
+
+// Contribute reads the last contribution of the given phase of a ceremony on
+// the given curve from r, adds a contribution with fresh randomness and writes
+// it to w, to be queued for the coordinator. It returns the attestation of the
+// contribution, signed with the key of the participant, to be queued with it
+// (see AttestationExt).
+func Contribute(curveID ecc.ID, phase int, r io.Reader, w io.Writer, key ed25519.PrivateKey) (*Attestation, error) {
+	s, err := getScheme(curveID)
+	if err != nil {
+		return nil, err
+	}
+	var c contribution
+	switch phase {
+	case 1:
+		c = s.newPhase1()
+	case 2:
+		c = s.newPhase2()
+	default:
+		return nil, fmt.Errorf("invalid phase %d", phase)
+	}
+	if _, err := c.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	c.Contribute()
+	h := sha256.New()
+	if _, err = c.WriteTo(io.MultiWriter(w, h)); err != nil {
+		return nil, err
+	}
+	return &Attestation{
+		PublicKey: key.Public().(ed25519.PublicKey),
+		Signature: ed25519.Sign(key, attested(h.Sum(nil))),
+	}, nil
+}
+
+// Attestation is the signature of a contribution by its participant, which
+// binds the contribution to the key of the participant in the transcript.
+type Attestation struct {
+	PublicKey ed25519.PublicKey
+	Signature []byte // of the sha256 of the contribution
+}
+
+// WriteFile writes the attestation to the file at path.
+func (a *Attestation) WriteFile(path string) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, b)
+}
+
+// verify checks the attestation of the contribution whose sha256 is hash
+func (a *Attestation) verify(hash []byte) error {
+	if len(a.PublicKey) != ed25519.PublicKeySize {
+		return errors.New("invalid public key of the participant")
+	}
+	if !ed25519.Verify(a.PublicKey, attested(hash), a.Signature) {
+		return errors.New("invalid attestation")
+	}
+	return nil
+}
+
+// attested returns the message signed by the attestation of the contribution
+// whose sha256 is hash, separated from the other uses of the key
+func attested(hash []byte) []byte {
+	return append([]byte("gnark groth16 ceremony contribution "), hash...)
+}
+
+func readAttestation(path string) (*Attestation, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("attestation: %w", err)
+	}
+	var a Attestation
+	if err := json.Unmarshal(b, &a); err != nil {
+		return nil, fmt.Errorf("attestation: %w", err)
+	}
+	return &a, nil
+}
+
+// verify runs the verification of a contribution, which may panic on
+// malformed contributions
+func verify(verifier func(prev, next contribution) error, prev, next contribution) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid contribution: %v", r)
+		}
+	}()
+	return verifier(prev, next)
+}
+
+// checkCircuit checks that the phase 1 of the transcript fits the circuit
+func checkCircuit(t *Transcript, r1cs constraint.ConstraintSystem) error {
+	if curveID := utils.FieldToCurve(r1cs.Field()); curveID.String() != t.Curve {
+		return fmt.Errorf("circuit on %s for a ceremony on %s", curveID, t.Curve)
+	}
+	if n := ecc.NextPowerOfTwo(uint64(r1cs.GetNbConstraints())); n != 1<<t.Power {
+		return fmt.Errorf("the circuit needs a phase 1 of size %d, not %d", n, 1<<t.Power)
+	}
+	return nil
+}
+
+func readContribution(c contribution, path string) (contribution, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := c.ReadFrom(f); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return c, nil
+}
+
+func readCircuit(dir, curve string) (constraint.ConstraintSystem, error) {
+	curveID, err := ecc.IDFromString(curve)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(dir, circuitFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r1cs := groth16.NewCS(curveID)
+	if _, err := r1cs.ReadFrom(f); err != nil {
+		return nil, err
+	}
+	return r1cs, nil
+}
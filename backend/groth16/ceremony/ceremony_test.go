@@ -0,0 +1,204 @@
+package ceremony
+
+import (
+	"crypto/ed25519"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/stretchr/testify/require"
+)
+
+type commitmentCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *commitmentCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(c.X, c.X), c.Y)
+	commitment, err := api.(frontend.Committer).Commit(c.X, c.Y)
+	if err != nil {
+		return err
+	}
+	api.AssertIsDifferent(commitment, 0)
+	return nil
+}
+
+func TestCeremony(t *testing.T) {
+	assert := require.New(t)
+	const curveID = ecc.BN254
+
+	ccs, err := frontend.Compile(curveID.ScalarField(), r1cs.NewBuilder, &commitmentCircuit{})
+	assert.NoError(err)
+	power := bits.Len64(ecc.NextPowerOfTwo(uint64(ccs.GetNbConstraints()))) - 1
+
+	dir := t.TempDir()
+	publicKey, key, err := ed25519.GenerateKey(nil)
+	assert.NoError(err)
+
+	c, err := New(dir, curveID, power, key)
+	assert.NoError(err)
+	_, err = New(dir, curveID, power, key)
+	assert.Error(err, "the directory already holds a ceremony")
+
+	// the participants sign their contributions with their own keys
+	keys := make(map[string]ed25519.PrivateKey)
+	for _, participant := range []string{"alice", "bob"} {
+		_, keys[participant], err = ed25519.GenerateKey(nil)
+		assert.NoError(err)
+	}
+
+	// contribute queues a contribution of the participant on top of the last
+	// one, and its attestation
+	contribute := func(participant string, phase int) {
+		path, err := c.Next()
+		assert.NoError(err)
+		in, err := os.Open(path)
+		assert.NoError(err)
+		defer in.Close()
+		out, err := os.Create(filepath.Join(dir, QueueDir, participant+".bin"))
+		assert.NoError(err)
+		defer out.Close()
+		attestation, err := Contribute(curveID, phase, in, out, keys[participant])
+		assert.NoError(err)
+		assert.NoError(attestation.WriteFile(filepath.Join(dir, QueueDir, participant+AttestationExt)))
+	}
+
+	// phase 1: bob contributes on the same contribution as alice and is
+	// rejected, and so is a malformed contribution
+	contribute("alice", 1)
+	contribute("bob", 1)
+	assert.NoError(os.WriteFile(filepath.Join(dir, QueueDir, "mallory.bin"), []byte("garbage"), 0600))
+	results, err := c.Process()
+	assert.NoError(err)
+	assert.Len(results, 3)
+	assert.Equal("alice", results[0].Participant)
+	assert.NoError(results[0].Err)
+	assert.Equal("bob", results[1].Participant)
+	assert.Error(results[1].Err)
+	assert.Equal("mallory", results[2].Participant)
+	assert.Error(results[2].Err)
+	assert.FileExists(filepath.Join(dir, RejectedDir, "bob.bin"))
+	assert.FileExists(filepath.Join(dir, RejectedDir, "bob"+AttestationExt))
+
+	// a contribution without attestation, or attested by another key, is
+	// rejected
+	contribute("bob", 1)
+	assert.NoError(os.Rename(filepath.Join(dir, QueueDir, "bob"+AttestationExt), filepath.Join(t.TempDir(), "bob"+AttestationExt)))
+	results, err = c.Process()
+	assert.NoError(err)
+	assert.Len(results, 1)
+	assert.ErrorContains(results[0].Err, "attestation")
+	contribute("bob", 1)
+	attestation, err := readAttestation(filepath.Join(dir, QueueDir, "bob"+AttestationExt))
+	assert.NoError(err)
+	attestation.PublicKey = keys["alice"].Public().(ed25519.PublicKey)
+	assert.NoError(attestation.WriteFile(filepath.Join(dir, QueueDir, "bob"+AttestationExt)))
+	results, err = c.Process()
+	assert.NoError(err)
+	assert.Len(results, 1)
+	assert.ErrorContains(results[0].Err, "invalid attestation")
+
+	contribute("bob", 1)
+	results, err = c.Process()
+	assert.NoError(err)
+	assert.Equal([]Result{{Participant: "bob"}}, results)
+	assert.NoFileExists(filepath.Join(dir, QueueDir, "bob"+AttestationExt))
+
+	assert.Error(c.StartPhase2(ccs), "the phase 1 is not closed")
+	assert.NoError(c.ApplyBeacon([]byte("beacon of phase 1")))
+	_, err = c.Process()
+	assert.Error(err, "the phase 1 is closed")
+
+	// phase 2, with the coordinator restarted in between
+	assert.NoError(c.StartPhase2(ccs))
+	contribute("alice", 2)
+	c, err = Open(dir, key)
+	assert.NoError(err)
+	results, err = c.Process()
+	assert.NoError(err)
+	assert.Equal([]Result{{Participant: "alice"}}, results)
+	_, _, err = c.Finalize()
+	assert.Error(err, "the phase 2 is not closed")
+	assert.NoError(c.ApplyBeacon([]byte("beacon of phase 2")))
+
+	pk, vk, err := c.Finalize()
+	assert.NoError(err)
+	witness, err := frontend.NewWitness(&commitmentCircuit{X: 3, Y: 9}, curveID.ScalarField())
+	assert.NoError(err)
+	publicWitness, err := witness.Public()
+	assert.NoError(err)
+	proof, err := groth16.Prove(ccs, pk, witness)
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(proof, vk, publicWitness))
+
+	// replay the ceremony
+	transcript, err := Verify(dir, publicKey)
+	assert.NoError(err)
+	assert.Len(transcript.Entries, 9)
+	otherPublicKey, otherKey, err := ed25519.GenerateKey(nil)
+	assert.NoError(err)
+	_, err = Verify(dir, otherPublicKey)
+	assert.Error(err)
+	_, err = Open(dir, otherKey)
+	assert.Error(err)
+
+	// a beacon which doesn't match its contribution is detected, even when the
+	// transcript is signed
+	assert.Equal(KindBeacon, transcript.Entries[3].Kind)
+	transcript.Entries[3].Beacon = []byte("another beacon")
+	resign(transcript, key)
+	assert.NoError(transcript.write(dir))
+	_, err = Verify(dir, publicKey)
+	assert.ErrorContains(err, "entry 3")
+
+	// as is any modification of the transcript without the key
+	transcript.Entries[3].Beacon = []byte("beacon of phase 1")
+	assert.NoError(transcript.write(dir))
+	_, err = Verify(dir, publicKey)
+	assert.ErrorContains(err, "signature")
+
+	// the coordinator can't attribute a contribution to another participant
+	resign(transcript, key)
+	assert.NoError(transcript.write(dir))
+	_, err = Verify(dir, publicKey)
+	assert.NoError(err)
+	assert.Equal("bob", transcript.Entries[2].Participant)
+	transcript.Entries[2].ParticipantKey = keys["alice"].Public().(ed25519.PublicKey)
+	resign(transcript, key)
+	assert.NoError(transcript.write(dir))
+	_, err = Verify(dir, publicKey)
+	assert.ErrorContains(err, "entry 2: contribution of bob: invalid attestation")
+}
+
+func TestBeaconWithoutContribution(t *testing.T) {
+	assert := require.New(t)
+	_, key, err := ed25519.GenerateKey(nil)
+	assert.NoError(err)
+
+	c, err := New(t.TempDir(), ecc.BN254, 2, key)
+	assert.NoError(err)
+	assert.Error(c.ApplyBeacon([]byte("beacon")), "the phase 1 has no contribution")
+
+	// a transcript with a beacon right after the initialization is rejected
+	init := &Entry{Phase: 1, Kind: KindInit}
+	assert.Error(checkOrder(init, &Entry{Phase: 1, Kind: KindBeacon}))
+	contribution := &Entry{Phase: 1, Kind: KindContribution}
+	assert.NoError(checkOrder(contribution, &Entry{Phase: 1, Kind: KindBeacon}))
+	init = &Entry{Phase: 2, Kind: KindInit}
+	assert.Error(checkOrder(init, &Entry{Phase: 2, Kind: KindBeacon}))
+}
+
+// resign signs again the entries of the transcript
+func resign(t *Transcript, key ed25519.PrivateKey) {
+	entries := t.Entries
+	t.Entries = nil
+	for _, e := range entries {
+		t.append(e, key)
+	}
+}
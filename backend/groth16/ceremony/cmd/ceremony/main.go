@@ -0,0 +1,301 @@
+// Command ceremony runs a Groth16 MPC setup ceremony in a local directory, see
+// the package github.com/consensys/gnark/backend/groth16/ceremony.
+//
+// The coordinator runs:
+//
+//	ceremony keygen -key coordinator.key
+//	ceremony init -dir ceremony -key coordinator.key -curve bn254 -power 20
+//	ceremony next -dir ceremony -key coordinator.key
+//	ceremony process -dir ceremony -key coordinator.key
+//	ceremony beacon -dir ceremony -key coordinator.key -beacon <hex>
+//	ceremony phase2 -dir ceremony -key coordinator.key -r1cs circuit.r1cs
+//	ceremony finalize -dir ceremony -key coordinator.key
+//
+// A participant generates its own key, contributes on top of the file given by
+// next, and sends the result and its attestation (alice.att, the signature of
+// the contribution) to the coordinator, which puts them in the queue directory:
+//
+//	ceremony keygen -key alice.key
+//	ceremony contribute -curve bn254 -phase 1 -key alice.key -in last.bin -out alice.bin
+//
+// Anyone can replay the ceremony:
+//
+//	ceremony verify -dir ceremony -pubkey <hex>
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/groth16/ceremony"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "keygen":
+		err = keygen(args)
+	case "init":
+		err = initCeremony(args)
+	case "next":
+		err = next(args)
+	case "contribute":
+		err = contribute(args)
+	case "process":
+		err = process(args)
+	case "beacon":
+		err = beacon(args)
+	case "phase2":
+		err = phase2(args)
+	case "finalize":
+		err = finalize(args)
+	case "verify":
+		err = verify(args)
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ceremony keygen|init|next|contribute|process|beacon|phase2|finalize|verify [flags]")
+	os.Exit(2)
+}
+
+// coordinatorFlags are the flags of the commands run by the coordinator
+type coordinatorFlags struct {
+	*flag.FlagSet
+	dir, key *string
+}
+
+func newCoordinatorFlags(name string) coordinatorFlags {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	return coordinatorFlags{
+		FlagSet: fs,
+		dir:     fs.String("dir", ".", "directory of the ceremony"),
+		key:     fs.String("key", "", "file of the private key of the coordinator"),
+	}
+}
+
+func (f coordinatorFlags) readKey() (ed25519.PrivateKey, error) {
+	return readKey(*f.key)
+}
+
+func readKey(path string) (ed25519.PrivateKey, error) {
+	if path == "" {
+		return nil, errors.New("missing -key")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("%s is not an ed25519 private key", path)
+	}
+	return ed25519.PrivateKey(b), nil
+}
+
+func (f coordinatorFlags) open(args []string) (*ceremony.Coordinator, error) {
+	if err := f.Parse(args); err != nil {
+		return nil, err
+	}
+	key, err := f.readKey()
+	if err != nil {
+		return nil, err
+	}
+	return ceremony.Open(*f.dir, key)
+}
+
+func keygen(args []string) error {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	path := fs.String("key", "coordinator.key", "file of the private key to create, of the coordinator or of a participant")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	publicKey, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(*path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(key); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Println(hex.EncodeToString(publicKey))
+	return nil
+}
+
+func initCeremony(args []string) error {
+	f := newCoordinatorFlags("init")
+	curve := f.String("curve", "bn254", "curve of the ceremony")
+	power := f.Int("power", 0, "the phase 1 has size 2^power, the number of constraints of the circuit rounded up to a power of 2")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	curveID, err := ecc.IDFromString(*curve)
+	if err != nil {
+		return err
+	}
+	key, err := f.readKey()
+	if err != nil {
+		return err
+	}
+	_, err = ceremony.New(*f.dir, curveID, *power, key)
+	return err
+}
+
+func next(args []string) error {
+	c, err := newCoordinatorFlags("next").open(args)
+	if err != nil {
+		return err
+	}
+	path, err := c.Next()
+	if err != nil {
+		return err
+	}
+	phase, _ := c.Phase()
+	fmt.Printf("phase %d: %s\n", phase, path)
+	return nil
+}
+
+func contribute(args []string) error {
+	fs := flag.NewFlagSet("contribute", flag.ExitOnError)
+	curve := fs.String("curve", "bn254", "curve of the ceremony")
+	phase := fs.Int("phase", 1, "phase of the ceremony")
+	key := fs.String("key", "", "file of the private key of the participant")
+	in := fs.String("in", "", "last contribution")
+	out := fs.String("out", "", "file of the new contribution, named after the participant")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	curveID, err := ecc.IDFromString(*curve)
+	if err != nil {
+		return err
+	}
+	participantKey, err := readKey(*key)
+	if err != nil {
+		return err
+	}
+	r, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	attestation, err := ceremony.Contribute(curveID, *phase, r, w, participantKey)
+	if err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return attestation.WriteFile(strings.TrimSuffix(*out, filepath.Ext(*out)) + ceremony.AttestationExt)
+}
+
+func process(args []string) error {
+	c, err := newCoordinatorFlags("process").open(args)
+	if err != nil {
+		return err
+	}
+	results, err := c.Process()
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Printf("%s: rejected: %v\n", r.Participant, r.Err)
+		} else {
+			fmt.Printf("%s: accepted\n", r.Participant)
+		}
+	}
+	return err
+}
+
+func beacon(args []string) error {
+	f := newCoordinatorFlags("beacon")
+	value := f.String("beacon", "", "value of the random beacon, in hexadecimal")
+	c, err := f.open(args)
+	if err != nil {
+		return err
+	}
+	b, err := hex.DecodeString(*value)
+	if err != nil {
+		return err
+	}
+	return c.ApplyBeacon(b)
+}
+
+func phase2(args []string) error {
+	f := newCoordinatorFlags("phase2")
+	path := f.String("r1cs", "", "file of the constraint system")
+	c, err := f.open(args)
+	if err != nil {
+		return err
+	}
+	curveID, err := ecc.IDFromString(c.Transcript().Curve)
+	if err != nil {
+		return err
+	}
+	r, err := os.Open(*path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	r1cs := groth16.NewCS(curveID)
+	if _, err := r1cs.ReadFrom(r); err != nil {
+		return err
+	}
+	return c.StartPhase2(r1cs)
+}
+
+func finalize(args []string) error {
+	c, err := newCoordinatorFlags("finalize").open(args)
+	if err != nil {
+		return err
+	}
+	_, _, err = c.Finalize()
+	return err
+}
+
+func verify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	dir := fs.String("dir", ".", "directory of the ceremony")
+	pubkey := fs.String("pubkey", "", "public key of the coordinator, in hexadecimal")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	publicKey, err := hex.DecodeString(*pubkey)
+	if err != nil {
+		return err
+	}
+	t, err := ceremony.Verify(*dir, publicKey)
+	if err != nil {
+		return err
+	}
+	for _, e := range t.Entries {
+		fmt.Printf("phase %d %-12s %-16s %-64x %x\n", e.Phase, e.Kind, e.Participant, []byte(e.ParticipantKey), e.Hash)
+	}
+	fmt.Println("ok")
+	return nil
+}
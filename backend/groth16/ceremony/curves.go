@@ -0,0 +1,100 @@
+package ceremony
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+
+	mpcsetup_bls12377 "github.com/consensys/gnark/backend/groth16/bls12-377/mpcsetup"
+	mpcsetup_bls12381 "github.com/consensys/gnark/backend/groth16/bls12-381/mpcsetup"
+	mpcsetup_bls24315 "github.com/consensys/gnark/backend/groth16/bls24-315/mpcsetup"
+	mpcsetup_bls24317 "github.com/consensys/gnark/backend/groth16/bls24-317/mpcsetup"
+	mpcsetup_bn254 "github.com/consensys/gnark/backend/groth16/bn254/mpcsetup"
+	mpcsetup_bw6633 "github.com/consensys/gnark/backend/groth16/bw6-633/mpcsetup"
+	mpcsetup_bw6761 "github.com/consensys/gnark/backend/groth16/bw6-761/mpcsetup"
+)
+
+// contribution is a Phase1 or a Phase2 of the mpcsetup package of a curve
+type contribution interface {
+	io.WriterTo
+	io.ReaderFrom
+	Contribute()
+	ContributeBeacon(beacon []byte)
+}
+
+// scheme gathers the functions of the mpcsetup package of a curve
+type scheme struct {
+	newPhase1    func() contribution
+	initPhase1   func(power int) contribution
+	verifyPhase1 func(prev, next contribution) error
+	newPhase2    func() contribution
+	initPhase2   func(r1cs constraint.ConstraintSystem, phase1 contribution) (phase2 contribution, evals any, err error)
+	verifyPhase2 func(prev, next contribution) error
+	extractKeys  func(phase1, phase2 contribution, evals any, nbConstraints int) (groth16.ProvingKey, groth16.VerifyingKey)
+}
+
+// newScheme returns the scheme of a curve from the functions of its mpcsetup
+// package.
+func newScheme[P1, P2, E, PK, VK any, R1CS constraint.ConstraintSystem](
+	initPhase1 func(int) P1,
+	verifyPhase1 func(*P1, *P1, ...*P1) error,
+	initPhase2 func(R1CS, *P1) (P2, E),
+	verifyPhase2 func(*P2, *P2, ...*P2) error,
+	extractKeys func(*P1, *P2, *E, int) (PK, VK),
+) scheme {
+	return scheme{
+		newPhase1: func() contribution {
+			return any(new(P1)).(contribution)
+		},
+		initPhase1: func(power int) contribution {
+			phase1 := initPhase1(power)
+			return any(&phase1).(contribution)
+		},
+		verifyPhase1: func(prev, next contribution) error {
+			return verifyPhase1(any(prev).(*P1), any(next).(*P1))
+		},
+		newPhase2: func() contribution {
+			return any(new(P2)).(contribution)
+		},
+		initPhase2: func(r1cs constraint.ConstraintSystem, phase1 contribution) (contribution, any, error) {
+			_r1cs, ok := r1cs.(R1CS)
+			if !ok {
+				return nil, nil, fmt.Errorf("expected a %T, got a %T", _r1cs, r1cs)
+			}
+			phase2, evals := initPhase2(_r1cs, any(phase1).(*P1))
+			return any(&phase2).(contribution), &evals, nil
+		},
+		verifyPhase2: func(prev, next contribution) error {
+			return verifyPhase2(any(prev).(*P2), any(next).(*P2))
+		},
+		extractKeys: func(phase1, phase2 contribution, evals any, nbConstraints int) (groth16.ProvingKey, groth16.VerifyingKey) {
+			pk, vk := extractKeys(any(phase1).(*P1), any(phase2).(*P2), evals.(*E), nbConstraints)
+			return any(&pk).(groth16.ProvingKey), any(&vk).(groth16.VerifyingKey)
+		},
+	}
+}
+
+// getScheme returns the scheme of the curve
+func getScheme(curveID ecc.ID) (scheme, error) {
+	switch curveID {
+	case ecc.BN254:
+		return newScheme(mpcsetup_bn254.InitPhase1, mpcsetup_bn254.VerifyPhase1, mpcsetup_bn254.InitPhase2, mpcsetup_bn254.VerifyPhase2, mpcsetup_bn254.ExtractKeys), nil
+	case ecc.BLS12_377:
+		return newScheme(mpcsetup_bls12377.InitPhase1, mpcsetup_bls12377.VerifyPhase1, mpcsetup_bls12377.InitPhase2, mpcsetup_bls12377.VerifyPhase2, mpcsetup_bls12377.ExtractKeys), nil
+	case ecc.BLS12_381:
+		return newScheme(mpcsetup_bls12381.InitPhase1, mpcsetup_bls12381.VerifyPhase1, mpcsetup_bls12381.InitPhase2, mpcsetup_bls12381.VerifyPhase2, mpcsetup_bls12381.ExtractKeys), nil
+	case ecc.BW6_761:
+		return newScheme(mpcsetup_bw6761.InitPhase1, mpcsetup_bw6761.VerifyPhase1, mpcsetup_bw6761.InitPhase2, mpcsetup_bw6761.VerifyPhase2, mpcsetup_bw6761.ExtractKeys), nil
+	case ecc.BLS24_317:
+		return newScheme(mpcsetup_bls24317.InitPhase1, mpcsetup_bls24317.VerifyPhase1, mpcsetup_bls24317.InitPhase2, mpcsetup_bls24317.VerifyPhase2, mpcsetup_bls24317.ExtractKeys), nil
+	case ecc.BLS24_315:
+		return newScheme(mpcsetup_bls24315.InitPhase1, mpcsetup_bls24315.VerifyPhase1, mpcsetup_bls24315.InitPhase2, mpcsetup_bls24315.VerifyPhase2, mpcsetup_bls24315.ExtractKeys), nil
+	case ecc.BW6_633:
+		return newScheme(mpcsetup_bw6633.InitPhase1, mpcsetup_bw6633.VerifyPhase1, mpcsetup_bw6633.InitPhase2, mpcsetup_bw6633.VerifyPhase2, mpcsetup_bw6633.ExtractKeys), nil
+	default:
+		return scheme{}, fmt.Errorf("curve %s not implemented", curveID)
+	}
+}
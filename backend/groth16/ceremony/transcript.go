@@ -0,0 +1,144 @@
+package ceremony
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// kinds of the entries of a transcript
+const (
+	KindInit         = "init"         // initialization of a phase
+	KindContribution = "contribution" // contribution of a participant
+	KindBeacon       = "beacon"       // contribution from the random beacon, ending a phase
+	KindKeys         = "keys"         // proving and verifying keys, ending the ceremony
+)
+
+// Transcript is the record of a ceremony, stored in the file transcript.json
+// of its directory. The entries are chained by their digests and signed by the
+// coordinator, so that anyone can replay the ceremony with Verify.
+type Transcript struct {
+	Curve     string
+	Power     int               // the size of the phase 1 is 2ᴾᵒʷᵉʳ
+	PublicKey ed25519.PublicKey // of the coordinator
+	Entries   []Entry
+}
+
+// Entry records a step of the ceremony and the file it produced.
+type Entry struct {
+	Phase          int // 1 or 2
+	Kind           string
+	Participant    string            `json:",omitempty"` // name of the participant for KindContribution
+	ParticipantKey ed25519.PublicKey `json:",omitempty"` // public key of the participant for KindContribution
+	Attestation    []byte            `json:",omitempty"` // signature of the Hash by the participant, see Attestation
+	Beacon         []byte            `json:",omitempty"` // value of the beacon for KindBeacon
+	Circuit        []byte            `json:",omitempty"` // sha256 of the constraint system for the KindInit of phase 2
+
+	File      string // path of the file, relative to the directory of the ceremony
+	Hash      []byte // sha256 of the file
+	Previous  []byte // digest of the previous entry, nil for the first one
+	Time      time.Time
+	Signature []byte // of the digest of the entry, by the coordinator
+}
+
+// Digest returns the sha256 of the entry without its signature.
+func (e *Entry) Digest() []byte {
+	unsigned := *e
+	unsigned.Signature = nil
+	b, err := json.Marshal(&unsigned)
+	if err != nil {
+		panic(err) // an entry is always serializable
+	}
+	h := sha256.Sum256(b)
+	return h[:]
+}
+
+// last returns the last entry of the transcript
+func (t *Transcript) last() *Entry {
+	return &t.Entries[len(t.Entries)-1]
+}
+
+// append signs e, chains it to the last entry and appends it to the transcript
+func (t *Transcript) append(e Entry, key ed25519.PrivateKey) {
+	if len(t.Entries) != 0 {
+		e.Previous = t.last().Digest()
+	}
+	e.Time = time.Now().UTC()
+	e.Signature = ed25519.Sign(key, e.Digest())
+	t.Entries = append(t.Entries, e)
+}
+
+// checkChain checks the signatures and the chaining of the entries, and the
+// hashes of their files in dir.
+func (t *Transcript) checkChain(dir string) error {
+	if len(t.PublicKey) != ed25519.PublicKeySize {
+		return errors.New("invalid public key of the coordinator")
+	}
+	if len(t.Entries) == 0 {
+		return errors.New("empty transcript")
+	}
+	var previous []byte
+	for i := range t.Entries {
+		e := &t.Entries[i]
+		if !bytes.Equal(e.Previous, previous) {
+			return fmt.Errorf("entry %d: not chained to the previous entry", i)
+		}
+		previous = e.Digest()
+		if !ed25519.Verify(t.PublicKey, previous, e.Signature) {
+			return fmt.Errorf("entry %d: invalid signature", i)
+		}
+		h, err := hashFile(filepath.Join(dir, e.File))
+		if err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+		if !bytes.Equal(h, e.Hash) {
+			return fmt.Errorf("entry %d: hash of %s doesn't match", i, e.File)
+		}
+	}
+	return nil
+}
+
+func readTranscript(dir string) (*Transcript, error) {
+	b, err := os.ReadFile(filepath.Join(dir, transcriptFile))
+	if err != nil {
+		return nil, err
+	}
+	var t Transcript
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, fmt.Errorf("transcript: %w", err)
+	}
+	return &t, nil
+}
+
+func (t *Transcript) write(dir string) error {
+	b, err := json.MarshalIndent(t, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, transcriptFile), b)
+}
+
+// writeFile writes the file atomically, so that an interrupted coordinator
+// doesn't leave the ceremony in an inconsistent state
+func writeFile(path string, b []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func hashFile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(b)
+	return h[:], nil
+}
@@ -0,0 +1,160 @@
+package ceremony
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+)
+
+// Verify replays the ceremony in dir: it checks the signatures of the
+// transcript with the public key of the coordinator, then recomputes the
+// initializations, the beacons and the keys, and verifies every contribution
+// and its attestation by the participant.
+// An unfinished ceremony is verified up to its last entry.
+func Verify(dir string, publicKey ed25519.PublicKey) (*Transcript, error) {
+	t, err := readTranscript(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !publicKey.Equal(t.PublicKey) {
+		return nil, errors.New("the transcript is signed with another key")
+	}
+	if err := t.checkChain(dir); err != nil {
+		return nil, err
+	}
+	curveID, err := ecc.IDFromString(t.Curve)
+	if err != nil {
+		return nil, err
+	}
+	s, err := getScheme(curveID)
+	if err != nil {
+		return nil, err
+	}
+	c := &Coordinator{dir: dir, transcript: t, scheme: s}
+
+	var (
+		current contribution // last contribution of the current phase
+		phase1  contribution // phase 1 closed by the beacon
+		r1cs    constraint.ConstraintSystem
+		evals   any
+		keys    []io.WriterTo // keys to be recorded
+	)
+	for i := range t.Entries {
+		e := &t.Entries[i]
+		var prev *Entry
+		if i > 0 {
+			prev = &t.Entries[i-1]
+		}
+		if err := checkOrder(prev, e); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+
+		switch e.Kind {
+		case KindInit:
+			if e.Phase == 1 {
+				current = s.initPhase1(t.Power)
+				break
+			}
+			phase1 = current
+			if r1cs, err = readCircuit(dir, t.Curve); err != nil {
+				return nil, fmt.Errorf("entry %d: %w", i, err)
+			}
+			if err = checkCircuit(t, r1cs); err != nil {
+				return nil, fmt.Errorf("entry %d: %w", i, err)
+			}
+			var circuit bytes.Buffer
+			if _, err = r1cs.WriteTo(&circuit); err != nil {
+				return nil, err
+			}
+			if h := sha256.Sum256(circuit.Bytes()); !bytes.Equal(h[:], e.Circuit) {
+				return nil, fmt.Errorf("entry %d: hash of the circuit doesn't match", i)
+			}
+			if current, evals, err = s.initPhase2(r1cs, phase1); err != nil {
+				return nil, fmt.Errorf("entry %d: %w", i, err)
+			}
+		case KindContribution:
+			attestation := Attestation{PublicKey: e.ParticipantKey, Signature: e.Attestation}
+			if err := attestation.verify(e.Hash); err != nil {
+				return nil, fmt.Errorf("entry %d: contribution of %s: %w", i, e.Participant, err)
+			}
+			next, err := c.load(e)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d: %w", i, err)
+			}
+			if err := verify(c.verifier(e.Phase), current, next); err != nil {
+				return nil, fmt.Errorf("entry %d: contribution of %s: %w", i, e.Participant, err)
+			}
+			current = next
+		case KindBeacon:
+			current.ContributeBeacon(e.Beacon)
+		case KindKeys:
+			if prev.Kind != KindKeys {
+				pk, vk := s.extractKeys(phase1, current, evals, r1cs.GetNbConstraints())
+				keys = []io.WriterTo{pk, vk}
+			}
+			if len(keys) == 0 {
+				return nil, fmt.Errorf("entry %d: unexpected keys", i)
+			}
+			if err := checkHash(keys[0], e); err != nil {
+				return nil, fmt.Errorf("entry %d: %w", i, err)
+			}
+			keys = keys[1:]
+			continue
+		}
+
+		// the recomputed data must match the file of the entry, contributions
+		// are checked too as they are re-encoded by the coordinator
+		if err := checkHash(current, e); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+	}
+
+	return t, nil
+}
+
+// checkOrder checks that the entry e can follow prev
+func checkOrder(prev, e *Entry) error {
+	if prev == nil {
+		if e.Phase != 1 || e.Kind != KindInit {
+			return errors.New("the ceremony must start with the initialization of the phase 1")
+		}
+		return nil
+	}
+	open := prev.Kind == KindInit || prev.Kind == KindContribution
+	var ok bool
+	switch e.Kind {
+	case KindInit:
+		ok = e.Phase == 2 && prev.Phase == 1 && prev.Kind == KindBeacon
+	case KindContribution:
+		ok = e.Phase == prev.Phase && open
+	case KindBeacon:
+		// a phase without contribution would only be randomized by the public
+		// beacon
+		ok = e.Phase == prev.Phase && prev.Kind == KindContribution
+	case KindKeys:
+		ok = e.Phase == 2 && prev.Phase == 2 && (prev.Kind == KindBeacon || prev.Kind == KindKeys)
+	}
+	if !ok {
+		return fmt.Errorf("unexpected %s of phase %d after %s of phase %d", e.Kind, e.Phase, prev.Kind, prev.Phase)
+	}
+	return nil
+}
+
+// checkHash checks that the encoding of data is the file of the entry
+func checkHash(data io.WriterTo, e *Entry) error {
+	h := sha256.New()
+	if _, err := data.WriteTo(h); err != nil {
+		return err
+	}
+	if !bytes.Equal(h.Sum(nil), e.Hash) {
+		return fmt.Errorf("%s doesn't match the recomputed %s", filepath.Base(e.File), e.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package distributed defines the transport between the coordinator and the
+// workers of the distributed Groth16 prover, see groth16.ProveDistributed.
+//
+// The coordinator sends requests to the workers through a Transport, and each
+// worker processes them with a Handler. The requests and the responses are
+// opaque bytes encoded by the prover, so that a transport is independent of
+// the curve. This package provides a transport over net/rpc (Serve and Dial),
+// and a Loopback transport calling a handler in the same process.
+package distributed
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+)
+
+// Transport sends the requests of the coordinator to a worker. Call may be
+// called concurrently.
+type Transport interface {
+	// Call sends the request to the worker, which processes it with the given
+	// method, and returns its response.
+	Call(method string, request []byte) ([]byte, error)
+
+	// Close closes the connection to the worker.
+	Close() error
+}
+
+// Handler processes the requests received by a worker. Handle may be called
+// concurrently.
+type Handler interface {
+	Handle(method string, request []byte) ([]byte, error)
+}
+
+// Loopback returns a Transport calling h directly, mostly for tests and for
+// workers running in the process of the coordinator.
+func Loopback(h Handler) Transport {
+	return loopback{h}
+}
+
+type loopback struct {
+	h Handler
+}
+
+func (t loopback) Call(method string, request []byte) ([]byte, error) {
+	return t.h.Handle(method, request)
+}
+
+func (t loopback) Close() error {
+	return nil
+}
+
+// serviceName is the name of the worker service registered in net/rpc
+const serviceName = "Worker"
+
+// Request is a request sent over net/rpc, it is exported for encoding/gob.
+type Request struct {
+	Method string
+	Data   []byte
+}
+
+type service struct {
+	h Handler
+}
+
+// Call processes a request received over net/rpc.
+func (s *service) Call(request *Request, response *[]byte) error {
+	res, err := s.h.Handle(request.Method, request.Data)
+	if err != nil {
+		return err
+	}
+	*response = res
+	return nil
+}
+
+// Serve processes with h the requests of the coordinators connected to l with
+// Dial. It blocks until l is closed.
+func Serve(l net.Listener, h Handler) error {
+	if h == nil {
+		return errors.New("nil handler")
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName(serviceName, &service{h}); err != nil {
+		return err
+	}
+	server.Accept(l)
+	return nil
+}
+
+// Dial connects to a worker served by Serve at the given network address.
+func Dial(network, address string) (Transport, error) {
+	client, err := rpc.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return rpcTransport{client}, nil
+}
+
+type rpcTransport struct {
+	client *rpc.Client
+}
+
+func (t rpcTransport) Call(method string, request []byte) ([]byte, error) {
+	var response []byte
+	if err := t.client.Call(serviceName+".Call", &Request{Method: method, Data: request}, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (t rpcTransport) Close() error {
+	return t.client.Close()
+}
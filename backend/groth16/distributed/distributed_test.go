@@ -0,0 +1,56 @@
+package distributed
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// reverser is a Handler reversing the requests of method "reverse"
+type reverser struct{}
+
+func (reverser) Handle(method string, request []byte) ([]byte, error) {
+	if method != "reverse" {
+		return nil, errors.New("unknown method")
+	}
+	res := bytes.Clone(request)
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res, nil
+}
+
+func TestTransports(t *testing.T) {
+	assert := require.New(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	chServed := make(chan error, 1)
+	go func() {
+		chServed <- Serve(l, reverser{})
+	}()
+
+	remote, err := Dial("tcp", l.Addr().String())
+	assert.NoError(err)
+
+	for _, transport := range []Transport{Loopback(reverser{}), remote} {
+		res, err := transport.Call("reverse", []byte{1, 2, 3})
+		assert.NoError(err)
+		assert.Equal([]byte{3, 2, 1}, res)
+
+		res, err = transport.Call("reverse", nil)
+		assert.NoError(err)
+		assert.Empty(res)
+
+		_, err = transport.Call("other", []byte{1})
+		assert.ErrorContains(err, "unknown method")
+
+		assert.NoError(transport.Close())
+	}
+
+	assert.NoError(l.Close())
+	assert.NoError(<-chServed)
+}
@@ -20,10 +20,13 @@
 package groth16
 
 import (
+	"fmt"
 	"io"
+	"strings"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16/distributed"
 	"github.com/consensys/gnark/backend/solidity"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
@@ -43,6 +46,7 @@ import (
 	fr_bw6633 "github.com/consensys/gnark-crypto/ecc/bw6-633/fr"
 	fr_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr"
 
+	"github.com/consensys/gnark/internal/mmap"
 	gnarkio "github.com/consensys/gnark/io"
 
 	groth16_bls12377 "github.com/consensys/gnark/backend/groth16/bls12-377"
@@ -200,6 +204,237 @@ func Prove(r1cs constraint.ConstraintSystem, pk ProvingKey, fullWitness witness.
 	}
 }
 
+// ProveBatch runs the groth16.Prove algorithm on several full witnesses of the
+// same constraint system. It reuses the memory of the prover between proofs and
+// solves the next witness while the current proof is computed, which is faster
+// than calling Prove for each witness.
+//
+// The proofs are returned in the order of the witnesses, errs[i] is the error of
+// the proof of fullWitnesses[i] (and proofs[i] is nil if errs[i] is not nil).
+func ProveBatch(r1cs constraint.ConstraintSystem, pk ProvingKey, fullWitnesses []witness.Witness, opts ...backend.ProverOption) (proofs []Proof, errs []error) {
+	proofs = make([]Proof, len(fullWitnesses))
+	errs = make([]error, len(fullWitnesses))
+	switch _r1cs := r1cs.(type) {
+	case *cs_bls12377.R1CS:
+		return toProofs(groth16_bls12377.ProveBatch(_r1cs, pk.(*groth16_bls12377.ProvingKey), fullWitnesses, opts...))
+
+	case *cs_bls12381.R1CS:
+		return toProofs(groth16_bls12381.ProveBatch(_r1cs, pk.(*groth16_bls12381.ProvingKey), fullWitnesses, opts...))
+
+	case *cs_bn254.R1CS:
+		if icicle_bn254.HasIcicle {
+			for i := range fullWitnesses {
+				proof, err := icicle_bn254.Prove(_r1cs, pk.(*icicle_bn254.ProvingKey), fullWitnesses[i], opts...)
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				proofs[i] = proof
+			}
+			return proofs, errs
+		}
+		return toProofs(groth16_bn254.ProveBatch(_r1cs, pk.(*groth16_bn254.ProvingKey), fullWitnesses, opts...))
+
+	case *cs_bw6761.R1CS:
+		return toProofs(groth16_bw6761.ProveBatch(_r1cs, pk.(*groth16_bw6761.ProvingKey), fullWitnesses, opts...))
+
+	case *cs_bls24317.R1CS:
+		return toProofs(groth16_bls24317.ProveBatch(_r1cs, pk.(*groth16_bls24317.ProvingKey), fullWitnesses, opts...))
+
+	case *cs_bls24315.R1CS:
+		return toProofs(groth16_bls24315.ProveBatch(_r1cs, pk.(*groth16_bls24315.ProvingKey), fullWitnesses, opts...))
+
+	case *cs_bw6633.R1CS:
+		return toProofs(groth16_bw6633.ProveBatch(_r1cs, pk.(*groth16_bw6633.ProvingKey), fullWitnesses, opts...))
+
+	default:
+		panic("unrecognized R1CS curve type")
+	}
+}
+
+// toProofs converts the curve typed proofs of ProveBatch to interfaces, and
+// keeps nil proofs untyped.
+func toProofs[P Proof](typed []P, errs []error) ([]Proof, []error) {
+	proofs := make([]Proof, len(typed))
+	for i := range typed {
+		if errs[i] == nil {
+			proofs[i] = typed[i]
+		}
+	}
+	return proofs, errs
+}
+
+// Rerandomize returns a fresh proof for the same statement as proof, which
+// verifies against the same verifying key and public witness but can not be
+// linked to the original proof.
+//
+// The Pedersen commitments (and their proof of knowledge) are part of the
+// statement and are left unchanged, so the proofs of circuits with commitments
+// remain linkable through them. An error is returned if vk is not of the curve
+// of the proof.
+func Rerandomize(proof Proof, vk VerifyingKey) (Proof, error) {
+	switch _proof := proof.(type) {
+	case *groth16_bls12377.Proof:
+		return rerandomize(groth16_bls12377.Rerandomize, _proof, vk)
+	case *groth16_bls12381.Proof:
+		return rerandomize(groth16_bls12381.Rerandomize, _proof, vk)
+	case *groth16_bn254.Proof:
+		return rerandomize(groth16_bn254.Rerandomize, _proof, vk)
+	case *groth16_bw6761.Proof:
+		return rerandomize(groth16_bw6761.Rerandomize, _proof, vk)
+	case *groth16_bls24317.Proof:
+		return rerandomize(groth16_bls24317.Rerandomize, _proof, vk)
+	case *groth16_bls24315.Proof:
+		return rerandomize(groth16_bls24315.Rerandomize, _proof, vk)
+	case *groth16_bw6633.Proof:
+		return rerandomize(groth16_bw6633.Rerandomize, _proof, vk)
+	default:
+		panic("unrecognized R1CS curve type")
+	}
+}
+
+// rerandomize calls the curve typed Rerandomize, once vk is checked to be of
+// the curve of the proof.
+func rerandomize[P Proof, VK VerifyingKey](rerandomize func(P, VK) (P, error), proof P, vk VerifyingKey) (Proof, error) {
+	_vk, ok := vk.(VK)
+	if !ok {
+		return nil, fmt.Errorf("verifying key of type %T for a proof of type %T", vk, proof)
+	}
+	res, err := rerandomize(proof, _vk)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ProvingKeyShard is the part of a ProvingKey held by a worker of the
+// distributed prover, see SplitProvingKey.
+type ProvingKeyShard interface {
+	groth16Object
+}
+
+// SplitProvingKey splits pk in nbShards shards, one for each worker of
+// ProveDistributed, and returns the proving key of the coordinator, which does
+// not hold the points of the shards. The shards are meant to be serialized and
+// sent to the workers.
+func SplitProvingKey(pk ProvingKey, nbShards int) (ProvingKey, []ProvingKeyShard, error) {
+	switch _pk := pk.(type) {
+	case *groth16_bls12377.ProvingKey:
+		return splitProvingKey(groth16_bls12377.SplitProvingKey(_pk, nbShards))
+	case *groth16_bls12381.ProvingKey:
+		return splitProvingKey(groth16_bls12381.SplitProvingKey(_pk, nbShards))
+	case *groth16_bn254.ProvingKey:
+		return splitProvingKey(groth16_bn254.SplitProvingKey(_pk, nbShards))
+	case *icicle_bn254.ProvingKey:
+		return splitProvingKey(groth16_bn254.SplitProvingKey(&_pk.ProvingKey, nbShards))
+	case *groth16_bw6761.ProvingKey:
+		return splitProvingKey(groth16_bw6761.SplitProvingKey(_pk, nbShards))
+	case *groth16_bls24317.ProvingKey:
+		return splitProvingKey(groth16_bls24317.SplitProvingKey(_pk, nbShards))
+	case *groth16_bls24315.ProvingKey:
+		return splitProvingKey(groth16_bls24315.SplitProvingKey(_pk, nbShards))
+	case *groth16_bw6633.ProvingKey:
+		return splitProvingKey(groth16_bw6633.SplitProvingKey(_pk, nbShards))
+	default:
+		panic("unrecognized ProvingKey curve type")
+	}
+}
+
+// splitProvingKey converts the result of a curve typed SplitProvingKey to
+// interfaces.
+func splitProvingKey[P ProvingKey, S ProvingKeyShard](pk P, typed []S, err error) (ProvingKey, []ProvingKeyShard, error) {
+	if err != nil {
+		return nil, nil, err
+	}
+	shards := make([]ProvingKeyShard, len(typed))
+	for i := range typed {
+		shards[i] = typed[i]
+	}
+	return pk, shards, nil
+}
+
+// NewProvingKeyShard instantiates a curve-typed ProvingKeyShard and returns an
+// interface object. This function exists for serialization purposes
+func NewProvingKeyShard(curveID ecc.ID) ProvingKeyShard {
+	switch curveID {
+	case ecc.BN254:
+		return &groth16_bn254.ProvingKeyShard{}
+	case ecc.BLS12_377:
+		return &groth16_bls12377.ProvingKeyShard{}
+	case ecc.BLS12_381:
+		return &groth16_bls12381.ProvingKeyShard{}
+	case ecc.BW6_761:
+		return &groth16_bw6761.ProvingKeyShard{}
+	case ecc.BLS24_317:
+		return &groth16_bls24317.ProvingKeyShard{}
+	case ecc.BLS24_315:
+		return &groth16_bls24315.ProvingKeyShard{}
+	case ecc.BW6_633:
+		return &groth16_bw6633.ProvingKeyShard{}
+	default:
+		panic("not implemented")
+	}
+}
+
+// NewWorker returns the handler of the requests of ProveDistributed for a
+// worker holding the given shard. It is typically served with
+// distributed.Serve.
+func NewWorker(shard ProvingKeyShard) distributed.Handler {
+	switch _shard := shard.(type) {
+	case *groth16_bls12377.ProvingKeyShard:
+		return groth16_bls12377.NewWorker(_shard)
+	case *groth16_bls12381.ProvingKeyShard:
+		return groth16_bls12381.NewWorker(_shard)
+	case *groth16_bn254.ProvingKeyShard:
+		return groth16_bn254.NewWorker(_shard)
+	case *groth16_bw6761.ProvingKeyShard:
+		return groth16_bw6761.NewWorker(_shard)
+	case *groth16_bls24317.ProvingKeyShard:
+		return groth16_bls24317.NewWorker(_shard)
+	case *groth16_bls24315.ProvingKeyShard:
+		return groth16_bls24315.NewWorker(_shard)
+	case *groth16_bw6633.ProvingKeyShard:
+		return groth16_bw6633.NewWorker(_shard)
+	default:
+		panic("unrecognized ProvingKeyShard curve type")
+	}
+}
+
+// ProveDistributed runs the groth16.Prove algorithm with the multi-exponentiations
+// and the FFTs computed by workers. pk is the proving key of the coordinator
+// returned by SplitProvingKey, and workers[i] must be connected to the worker
+// holding the shard i.
+func ProveDistributed(r1cs constraint.ConstraintSystem, pk ProvingKey, workers []distributed.Transport, fullWitness witness.Witness, opts ...backend.ProverOption) (Proof, error) {
+	switch _r1cs := r1cs.(type) {
+	case *cs_bls12377.R1CS:
+		return groth16_bls12377.ProveDistributed(_r1cs, pk.(*groth16_bls12377.ProvingKey), workers, fullWitness, opts...)
+
+	case *cs_bls12381.R1CS:
+		return groth16_bls12381.ProveDistributed(_r1cs, pk.(*groth16_bls12381.ProvingKey), workers, fullWitness, opts...)
+
+	case *cs_bn254.R1CS:
+		if _pk, ok := pk.(*icicle_bn254.ProvingKey); ok {
+			return groth16_bn254.ProveDistributed(_r1cs, &_pk.ProvingKey, workers, fullWitness, opts...)
+		}
+		return groth16_bn254.ProveDistributed(_r1cs, pk.(*groth16_bn254.ProvingKey), workers, fullWitness, opts...)
+
+	case *cs_bw6761.R1CS:
+		return groth16_bw6761.ProveDistributed(_r1cs, pk.(*groth16_bw6761.ProvingKey), workers, fullWitness, opts...)
+
+	case *cs_bls24317.R1CS:
+		return groth16_bls24317.ProveDistributed(_r1cs, pk.(*groth16_bls24317.ProvingKey), workers, fullWitness, opts...)
+
+	case *cs_bls24315.R1CS:
+		return groth16_bls24315.ProveDistributed(_r1cs, pk.(*groth16_bls24315.ProvingKey), workers, fullWitness, opts...)
+
+	case *cs_bw6633.R1CS:
+		return groth16_bw6633.ProveDistributed(_r1cs, pk.(*groth16_bw6633.ProvingKey), workers, fullWitness, opts...)
+
+	default:
+		panic("unrecognized R1CS curve type")
+	}
+}
+
 // Setup runs groth16.Setup with provided R1CS and outputs a key pair associated with the circuit.
 //
 // Note that careful consideration must be given to this step in a production environment.
@@ -330,6 +565,72 @@ func DummySetup(r1cs constraint.ConstraintSystem) (ProvingKey, error) {
 	}
 }
 
+// MappedProvingKey is a ProvingKey memory mapped by Open. Close releases the
+// mapping, the key must not be used afterwards.
+type MappedProvingKey interface {
+	ProvingKey
+	io.Closer
+}
+
+// Open memory maps a proving key written by WriteMmapTo in the file at path,
+// for any curve. Its points are loaded from the file when the prover accesses
+// them instead of being read on startup, and the processes opening the same
+// file share them. The key must be released with Close once it is not used.
+//
+// The curve typed proving keys of this package implement [gnarkio.Mapper],
+// which is not part of ProvingKey so that the keys implemented outside of gnark
+// don't need to support it:
+//
+//	_, err := pk.(gnarkio.Mapper).WriteMmapTo(w)
+func Open(path string) (MappedProvingKey, error) {
+	kind, err := mmap.ReadKind(path)
+	if err != nil {
+		return nil, err
+	}
+	scheme, curveName, _ := strings.Cut(kind, "/")
+	if scheme != "groth16" {
+		return nil, fmt.Errorf("file holds a %s proving key, not a groth16 one", scheme)
+	}
+	curveID, err := ecc.IDFromString(curveName)
+	if err != nil {
+		return nil, err
+	}
+	switch curveID {
+	case ecc.BN254:
+		pk, err := groth16_bn254.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		if icicle_bn254.HasIcicle {
+			return &icicle_bn254.ProvingKey{ProvingKey: *pk}, nil
+		}
+		return pk, nil
+	case ecc.BLS12_377:
+		return openProvingKey(groth16_bls12377.Open(path))
+	case ecc.BLS12_381:
+		return openProvingKey(groth16_bls12381.Open(path))
+	case ecc.BW6_761:
+		return openProvingKey(groth16_bw6761.Open(path))
+	case ecc.BLS24_317:
+		return openProvingKey(groth16_bls24317.Open(path))
+	case ecc.BLS24_315:
+		return openProvingKey(groth16_bls24315.Open(path))
+	case ecc.BW6_633:
+		return openProvingKey(groth16_bw6633.Open(path))
+	default:
+		return nil, fmt.Errorf("curve %s not implemented", curveID)
+	}
+}
+
+// openProvingKey returns the result of a curve typed Open without wrapping a nil
+// key in a non-nil interface.
+func openProvingKey[P MappedProvingKey](pk P, err error) (MappedProvingKey, error) {
+	if err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
 // NewProvingKey instantiates a curve-typed ProvingKey and returns an interface object
 // This function exists for serialization purposes
 func NewProvingKey(curveID ecc.ID) ProvingKey {
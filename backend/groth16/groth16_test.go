@@ -1,17 +1,25 @@
 package groth16_test
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"math/big"
+	"net"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/consensys/gnark"
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/groth16/distributed"
+	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
+	gnarkio "github.com/consensys/gnark/io"
 	"github.com/consensys/gnark/test"
 )
 
@@ -51,6 +59,214 @@ func TestCustomHashToField(t *testing.T) {
 	}
 }
 
+func TestRerandomize(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		assert.Run(func(assert *test.Assert) {
+			assert.Run(func(assert *test.Assert) {
+				ccs, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, &squareCircuit{})
+				assert.NoError(err)
+				checkRerandomize(assert, ccs, &squareCircuit{X: 3, Y: 9}, &squareCircuit{X: 3, Y: 10})
+			}, "no_commitment")
+			assert.Run(func(assert *test.Assert) {
+				ccs, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, &squareCommitmentCircuit{})
+				assert.NoError(err)
+				checkRerandomize(assert, ccs, &squareCommitmentCircuit{X: 3, Y: 9}, &squareCommitmentCircuit{X: 3, Y: 10})
+			}, "commitment")
+		}, curve.String())
+	}
+}
+
+func TestRerandomizeCurveMismatch(t *testing.T) {
+	assert := test.NewAssert(t)
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &squareCircuit{})
+	assert.NoError(err)
+	pk, _, err := groth16.Setup(ccs)
+	assert.NoError(err)
+	witness, err := frontend.NewWitness(&squareCircuit{X: 3, Y: 9}, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	proof, err := groth16.Prove(ccs, pk, witness)
+	assert.NoError(err)
+
+	otherCcs, err := frontend.Compile(ecc.BLS12_381.ScalarField(), r1cs.NewBuilder, &squareCircuit{})
+	assert.NoError(err)
+	_, otherVk, err := groth16.Setup(otherCcs)
+	assert.NoError(err)
+	_, err = groth16.Rerandomize(proof, otherVk)
+	assert.Error(err)
+}
+
+func checkRerandomize(assert *test.Assert, ccs constraint.ConstraintSystem, valid, wrong frontend.Circuit) {
+	field := ccs.Field()
+	pk, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+	witness, err := frontend.NewWitness(valid, field)
+	assert.NoError(err)
+	pubWitness, err := witness.Public()
+	assert.NoError(err)
+	wrongWitness, err := frontend.NewWitness(wrong, field, frontend.PublicOnly())
+	assert.NoError(err)
+
+	proof, err := groth16.Prove(ccs, pk, witness)
+	assert.NoError(err)
+
+	rerandomized, err := groth16.Rerandomize(proof, vk)
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(rerandomized, vk, pubWitness))
+	assert.Error(groth16.Verify(rerandomized, vk, wrongWitness))
+
+	var b1, b2 bytes.Buffer
+	_, err = proof.WriteTo(&b1)
+	assert.NoError(err)
+	_, err = rerandomized.WriteTo(&b2)
+	assert.NoError(err)
+	assert.False(bytes.Equal(b1.Bytes(), b2.Bytes()), "rerandomized proof is equal to the original one")
+
+	// the original proof is not mutated and rerandomizing twice gives another proof
+	assert.NoError(groth16.Verify(proof, vk, pubWitness))
+	again, err := groth16.Rerandomize(rerandomized, vk)
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(again, vk, pubWitness))
+	var b3 bytes.Buffer
+	_, err = again.WriteTo(&b3)
+	assert.NoError(err)
+	assert.False(bytes.Equal(b2.Bytes(), b3.Bytes()), "rerandomized proof is equal to the original one")
+}
+
+func TestProveBatch(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, &squareCommitmentCircuit{})
+			assert.NoError(err)
+			pk, vk, err := groth16.Setup(ccs)
+			assert.NoError(err)
+
+			assignments := []squareCommitmentCircuit{{X: 2, Y: 4}, {X: 3, Y: 9}, {X: 3, Y: 10}, {X: 4, Y: 16}, {X: 5, Y: 25}}
+			witnesses := make([]witness.Witness, len(assignments))
+			for i := range assignments {
+				witnesses[i], err = frontend.NewWitness(&assignments[i], curve.ScalarField())
+				assert.NoError(err)
+			}
+
+			proofs, errs := groth16.ProveBatch(ccs, pk, witnesses)
+			assert.Equal(len(witnesses), len(proofs))
+			assert.Equal(len(witnesses), len(errs))
+			for i := range witnesses {
+				if i == 2 {
+					assert.Error(errs[i])
+					assert.Nil(proofs[i])
+					continue
+				}
+				assert.NoError(errs[i], "witness %d", i)
+				pubWitness, err := witnesses[i].Public()
+				assert.NoError(err)
+				assert.NoError(groth16.Verify(proofs[i], vk, pubWitness), "witness %d", i)
+
+				// the proof is bound to its own witness
+				other, err := witnesses[(i+1)%len(witnesses)].Public()
+				assert.NoError(err)
+				assert.Error(groth16.Verify(proofs[i], vk, other), "witness %d", i)
+			}
+		}, curve.String())
+	}
+}
+
+func TestProveDistributed(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, &squareCommitmentCircuit{})
+			assert.NoError(err)
+			pk, vk, err := groth16.Setup(ccs)
+			assert.NoError(err)
+
+			coordinatorPk, shards, err := groth16.SplitProvingKey(pk, 3)
+			assert.NoError(err)
+
+			// the workers receive their serialized shard, the first one is
+			// served over net/rpc and the others are in process
+			workers := make([]distributed.Transport, len(shards))
+			for i, shard := range shards {
+				var buf bytes.Buffer
+				_, err := shard.WriteTo(&buf)
+				assert.NoError(err)
+				received := groth16.NewProvingKeyShard(curve)
+				_, err = received.ReadFrom(&buf)
+				assert.NoError(err)
+				worker := groth16.NewWorker(received)
+
+				if i > 0 {
+					workers[i] = distributed.Loopback(worker)
+					continue
+				}
+				l, err := net.Listen("tcp", "127.0.0.1:0")
+				assert.NoError(err)
+				defer l.Close()
+				go distributed.Serve(l, worker)
+				workers[i], err = distributed.Dial("tcp", l.Addr().String())
+				assert.NoError(err)
+				defer workers[i].Close()
+			}
+
+			fullWitness, err := frontend.NewWitness(&squareCommitmentCircuit{X: 3, Y: 9}, curve.ScalarField())
+			assert.NoError(err)
+			pubWitness, err := fullWitness.Public()
+			assert.NoError(err)
+
+			proof, err := groth16.ProveDistributed(ccs, coordinatorPk, workers, fullWitness)
+			assert.NoError(err)
+			assert.NoError(groth16.Verify(proof, vk, pubWitness))
+
+			// the workers must be given in the order of their shards
+			workers[1], workers[2] = workers[2], workers[1]
+			_, err = groth16.ProveDistributed(ccs, coordinatorPk, workers, fullWitness)
+			assert.Error(err)
+		}, curve.String())
+	}
+}
+
+func TestOpen(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, &squareCommitmentCircuit{})
+			assert.NoError(err)
+			pk, vk, err := groth16.Setup(ccs)
+			assert.NoError(err)
+
+			path := filepath.Join(t.TempDir(), "pk")
+			f, err := os.Create(path)
+			assert.NoError(err)
+			_, err = pk.(gnarkio.Mapper).WriteMmapTo(f)
+			assert.NoError(err)
+			assert.NoError(f.Close())
+
+			mapped, err := groth16.Open(path)
+			assert.NoError(err)
+
+			w, err := frontend.NewWitness(&squareCommitmentCircuit{X: 3, Y: 9}, curve.ScalarField())
+			assert.NoError(err)
+			pubWitness, err := w.Public()
+			assert.NoError(err)
+			proof, err := groth16.Prove(ccs, mapped, w)
+			assert.NoError(err)
+			assert.NoError(groth16.Verify(proof, vk, pubWitness))
+
+			assert.NoError(mapped.Close())
+			assert.NoError(mapped.Close())
+
+			// the regular encoding is not mapped
+			var buf bytes.Buffer
+			_, err = pk.WriteRawTo(&buf)
+			assert.NoError(err)
+			assert.NoError(os.WriteFile(path, buf.Bytes(), 0600))
+			_, err = groth16.Open(path)
+			assert.Error(err)
+		}, curve.String())
+	}
+}
+
 //--------------------//
 //     benches		  //
 //--------------------//
@@ -87,6 +303,42 @@ func BenchmarkProver(b *testing.B) {
 	}
 }
 
+// BenchmarkProveBatch compares ProveBatch against as many calls to Prove.
+func BenchmarkProveBatch(b *testing.B) {
+	const nbProofs = 4
+	for _, curve := range getCurves() {
+		r1cs, _solution := referenceCircuit(curve)
+		fullWitness, err := frontend.NewWitness(_solution, curve.ScalarField())
+		if err != nil {
+			b.Fatal(err)
+		}
+		pk, err := groth16.DummySetup(r1cs)
+		if err != nil {
+			b.Fatal(err)
+		}
+		fullWitnesses := make([]witness.Witness, nbProofs)
+		for i := range fullWitnesses {
+			fullWitnesses[i] = fullWitness
+		}
+		b.Run(curve.String()+"/prove", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, w := range fullWitnesses {
+					if _, err := groth16.Prove(r1cs, pk, w); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+		b.Run(curve.String()+"/batch", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, errs := groth16.ProveBatch(r1cs, pk, fullWitnesses); errors.Join(errs...) != nil {
+					b.Fatal(errors.Join(errs...))
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkVerifier(b *testing.B) {
 	for _, curve := range getCurves() {
 		b.Run(curve.String(), func(b *testing.B) {
@@ -168,6 +420,31 @@ func (c *commitmentCircuit) Define(api frontend.API) error {
 	return nil
 }
 
+type squareCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *squareCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(c.X, c.X), c.Y)
+	return nil
+}
+
+type squareCommitmentCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *squareCommitmentCircuit) Define(api frontend.API) error {
+	cmt, err := api.(frontend.Committer).Commit(c.X)
+	if err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	api.AssertIsDifferent(cmt, 0)
+	api.AssertIsEqual(api.Mul(c.X, c.X), c.Y)
+	return nil
+}
+
 type constantHash struct{}
 
 func (h constantHash) Write(p []byte) (n int, err error) { return len(p), nil }
@@ -0,0 +1,144 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+package mpcsetup
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"runtime"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bls12-377"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/internal/utils"
+)
+
+// PublicKey proves the knowledge of the secret x of a contribution
+type PublicKey struct {
+	SG  curve.G1Affine // [s]₁
+	SXG curve.G1Affine // [sx]₁
+	XR  curve.G2Affine // x·R where R is derived from SG, SXG and the previous contribution
+}
+
+func newPublicKey(x fr.Element, challenge []byte, dst byte, rand *sampler) PublicKey {
+	var pk PublicKey
+	_, _, g1, _ := curve.Generators()
+
+	var sBi big.Int
+	s := rand.sample()
+	s.BigInt(&sBi)
+	pk.SG.ScalarMultiplication(&g1, &sBi)
+
+	// compute x*sG1
+	var xBi big.Int
+	x.BigInt(&xBi)
+	pk.SXG.ScalarMultiplication(&pk.SG, &xBi)
+
+	// generate R based on sG1, sxG1, challenge, and domain separation tag
+	R := genR(pk.SG, pk.SXG, challenge, dst)
+
+	// compute x*spG2
+	pk.XR.ScalarMultiplication(&R, &xBi)
+	return pk
+}
+
+// sampler samples the secrets of a contribution, from crypto/rand or from a
+// random beacon so that anyone can recompute the contribution. A nil sampler
+// uses crypto/rand.
+type sampler struct {
+	beacon []byte
+	count  int // number of elements derived from the beacon
+}
+
+// sample returns the next secret
+func (s *sampler) sample() (x fr.Element) {
+	if s == nil {
+		x.SetRandom()
+		return
+	}
+	s.count++
+	res, err := fr.Hash(s.beacon, []byte(fmt.Sprintf("gnark kzg mpcsetup beacon %d", s.count)), 1)
+	if err != nil {
+		panic(err)
+	}
+	return res[0]
+}
+
+// Returns [1, a, a², ..., aⁿ⁻¹ ] in Montgomery form
+func powers(a fr.Element, n int) []fr.Element {
+	result := make([]fr.Element, n)
+	result[0] = fr.NewElement(1)
+	for i := 1; i < n; i++ {
+		result[i].Mul(&result[i-1], &a)
+	}
+	return result
+}
+
+// Returns [aᵢAᵢ, ...] in G1
+func scaleG1InPlace(A []curve.G1Affine, a []fr.Element) {
+	utils.Parallelize(len(A), func(start, end int) {
+		var tmp big.Int
+		for i := start; i < end; i++ {
+			a[i].BigInt(&tmp)
+			A[i].ScalarMultiplication(&A[i], &tmp)
+		}
+	})
+}
+
+// Check e(a₁, a₂) = e(b₁, b₂)
+func sameRatio(a1, b1 curve.G1Affine, a2, b2 curve.G2Affine) bool {
+	if !a1.IsInSubGroup() || !b1.IsInSubGroup() || !a2.IsInSubGroup() || !b2.IsInSubGroup() {
+		panic("invalid point not in subgroup")
+	}
+	var na2 curve.G2Affine
+	na2.Neg(&a2)
+	res, err := curve.PairingCheck(
+		[]curve.G1Affine{a1, b1},
+		[]curve.G2Affine{na2, b2})
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// L1 = ∑ rᵢAᵢ, L2 = ∑ rᵢAᵢ₊₁ in G1
+func linearCombinationG1(A []curve.G1Affine) (L1, L2 curve.G1Affine) {
+	nc := runtime.NumCPU()
+	n := len(A)
+	r := make([]fr.Element, n-1)
+	for i := 0; i < n-1; i++ {
+		r[i].SetRandom()
+	}
+	L1.MultiExp(A[:n-1], r, ecc.MultiExpConfig{NbTasks: nc / 2})
+	L2.MultiExp(A[1:], r, ecc.MultiExpConfig{NbTasks: nc / 2})
+	return
+}
+
+// Generate R in G₂ as Hash(gˢ, gˢˣ, challenge, dst)
+func genR(sG1, sxG1 curve.G1Affine, challenge []byte, dst byte) curve.G2Affine {
+	var buf bytes.Buffer
+	buf.Grow(len(challenge) + curve.SizeOfG1AffineUncompressed*2)
+	buf.Write(sG1.Marshal())
+	buf.Write(sxG1.Marshal())
+	buf.Write(challenge)
+	spG2, err := curve.HashToG2(buf.Bytes(), []byte{dst})
+	if err != nil {
+		panic(err)
+	}
+	return spG2
+}
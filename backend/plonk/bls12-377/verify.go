@@ -58,6 +58,25 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 		return errors.New("BSB22 Commitment number mismatch")
 	}
 
+	if len(vk.Qcg) != len(vk.CustomGates) {
+		return errors.New("custom gates number mismatch")
+	}
+
+	hasLookups := len(vk.Lookup) != 0
+	if hasLookups && len(vk.Lookup) != nb_lookup_polynomials {
+		return errors.New("lookup polynomials number mismatch")
+	}
+	if (hasLookups && len(proof.Lookup) != 2) || (!hasLookups && len(proof.Lookup) != 0) {
+		return errors.New("lookup commitments number mismatch")
+	}
+	if len(proof.BatchedProof.ClaimedValues) != 6+len(vk.Qcp)+len(vk.Lookup) {
+		return errors.New("batch opening claimed values number mismatch")
+	}
+	usesNextRow := vk.usesNextRow()
+	if (usesNextRow && len(proof.LROShiftedOpening.ClaimedValues) != 3) || (!usesNextRow && len(proof.LROShiftedOpening.ClaimedValues) != 0) {
+		return errors.New("shifted l, r, o claimed values number mismatch")
+	}
+
 	if len(publicWitness) != int(vk.NbPublicVariables) {
 		return errInvalidWitness
 	}
@@ -87,17 +106,33 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 	if !proof.ZShiftedOpening.H.IsInSubGroup() {
 		return errInvalidPoint
 	}
+	for i := 0; i < len(proof.Lookup); i++ {
+		if !proof.Lookup[i].IsInSubGroup() {
+			return errInvalidPoint
+		}
+	}
+	if hasLookups && !proof.LookupShiftedOpening.H.IsInSubGroup() {
+		return errInvalidPoint
+	}
+	if usesNextRow && !proof.LROShiftedOpening.H.IsInSubGroup() {
+		return errInvalidPoint
+	}
 
 	// transcript to derive the challenge
-	fs := fiatshamir.NewTranscript(cfg.ChallengeHash, "gamma", "beta", "alpha", "zeta")
+	fs := fiatshamir.NewTranscript(cfg.ChallengeHash, transcriptChallenges(vk)...)
 
 	// The first challenge is derived using the public data: the commitments to the permutation,
 	// the coefficients of the circuit, and the public inputs.
 	// derive gamma from the Comm(blinded cl), Comm(blinded cr), Comm(blinded co)
+	// and Comm(blinded m) if the circuit has lookups
 	if err := bindPublicData(fs, "gamma", vk, publicWitness); err != nil {
 		return err
 	}
-	gamma, err := deriveRandomness(fs, "gamma", &proof.LRO[0], &proof.LRO[1], &proof.LRO[2])
+	gammaDeps := []*curve.G1Affine{&proof.LRO[0], &proof.LRO[1], &proof.LRO[2]}
+	if hasLookups {
+		gammaDeps = append(gammaDeps, &proof.Lookup[0])
+	}
+	gamma, err := deriveRandomness(fs, "gamma", gammaDeps...)
 	if err != nil {
 		return err
 	}
@@ -108,12 +143,26 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 		return err
 	}
 
-	// derive alpha from Com(Z), Bsb22Commitments
+	// derive eta and lambda, the challenges of the lookup argument
+	var eta, lambda fr.Element
+	if hasLookups {
+		if eta, err = deriveRandomness(fs, "eta"); err != nil {
+			return err
+		}
+		if lambda, err = deriveRandomness(fs, "lambda"); err != nil {
+			return err
+		}
+	}
+
+	// derive alpha from Com(Z), Bsb22Commitments and Com(φ)
 	alphaDeps := make([]*curve.G1Affine, len(proof.Bsb22Commitments)+1)
 	for i := range proof.Bsb22Commitments {
 		alphaDeps[i] = &proof.Bsb22Commitments[i]
 	}
 	alphaDeps[len(alphaDeps)-1] = &proof.Z
+	if hasLookups {
+		alphaDeps = append(alphaDeps, &proof.Lookup[1])
+	}
 	alpha, err := deriveRandomness(fs, "alpha", alphaDeps...)
 	if err != nil {
 		return err
@@ -218,7 +267,26 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 	constLin.Mul(&tmp, &constLin).Mul(&constLin, &alpha).Mul(&constLin, &zu) // α(l(ζ)+β*s1(ζ)+γ)(r(ζ)+β*s2(ζ)+γ)(o(ζ)+γ)*z(ωζ)
 
 	constLin.Sub(&constLin, &alphaSquarelagrangeZero).Add(&constLin, &pi) // PI(ζ) - α²*L₁(ζ) + α(l(ζ)+β*s1(ζ)+γ)(r(ζ)+β*s2(ζ)+γ)(o(ζ)+γ)*z(ωζ)
-	constLin.Neg(&constLin)                                               // -[PI(ζ) - α²*L₁(ζ) + α(l(ζ)+β*s1(ζ)+γ)(r(ζ)+β*s2(ζ)+γ)(o(ζ)+γ)*z(ωζ)]
+
+	// lookup argument, f = qtag + η*l + η²*r + η³*o and t = ttag + η*t₀ + η²*t₁ + η³*t₂
+	var alphaCubeLookupF, lookupT fr.Element
+	if hasLookups {
+		lk := proof.BatchedProof.ClaimedValues[6+len(vk.Qcp):]
+		alphaCubeLookupF = lookupCompress(eta, &lk[lookup_Qtag], &l, &r, &o)
+		alphaCubeLookupF.Add(&alphaCubeLookupF, &lambda)
+		lookupT = lookupCompress(eta, &lk[lookup_Ttag], &lk[lookup_T0], &lk[lookup_T1], &lk[lookup_T2])
+		lookupT.Add(&lookupT, &lambda)
+		tmp.Square(&alpha).Mul(&tmp, &alpha)
+		alphaCubeLookupF.Mul(&alphaCubeLookupF, &tmp) // α³(λ+f(ζ))
+
+		// α³*(φ(ωζ)*(λ+f(ζ))*(λ+t(ζ)) - qlk(ζ)*(λ+t(ζ)))
+		var lookupConst fr.Element
+		lookupConst.Mul(&alphaCubeLookupF, &proof.LookupShiftedOpening.ClaimedValue).Mul(&lookupConst, &lookupT)
+		tmp.Mul(&tmp, &lk[lookup_Qlk]).Mul(&tmp, &lookupT)
+		lookupConst.Sub(&lookupConst, &tmp)
+		constLin.Add(&constLin, &lookupConst)
+	}
+	constLin.Neg(&constLin) // -[PI(ζ) - α²*L₁(ζ) + α(l(ζ)+β*s1(ζ)+γ)(r(ζ)+β*s2(ζ)+γ)(o(ζ)+γ)*z(ωζ) + α³*(φ(ωζ)*(λ+f(ζ))-qlk(ζ))*(λ+t(ζ))]
 
 	// check that the opening of the linearised polynomial is equal to -constLin
 	openingLinPol := proof.BatchedProof.ClaimedValues[0]
@@ -229,8 +297,10 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 	// computing the linearised polynomial digest
 	// α²*L₁(ζ)*[Z] +
 	// _s1*[s3]+_s2*[Z] + l(ζ)*[Ql] +
-	// l(ζ)r(ζ)*[Qm] + r(ζ)*[Qr] + o(ζ)*[Qo] + [Qk] + ∑ᵢQcp_(ζ)[Pi_i] -
-	// Z_{H}(ζ)*(([H₀] + ζᵐ⁺²*[H₁] + ζ²⁽ᵐ⁺²⁾*[H₂])
+	// l(ζ)r(ζ)*[Qm] + r(ζ)*[Qr] + o(ζ)*[Qo] + [Qk] + ∑ᵢQcp_(ζ)[Pi_i] +
+	// ∑ᵢGᵢ(l(ζ), r(ζ), o(ζ), l(ωζ), r(ωζ), o(ωζ))*[Qcg_i] +
+	// α³*(λ+f(ζ))*([m] - (λ+t(ζ))*[φ]) -
+	// Z_{H}(ζ)*(([H₀] + ζᵐ*[H₁] + ζ²ᵐ*[H₂])
 	// where
 	// _s1 =  α*(l(ζ)+β*s1(ζ)+γ)*(r(ζ)+β*s2(ζ)+γ)*β*Z(μζ)
 	// _s2 = -α*(l(ζ)+β*ζ+γ)*(r(ζ)+β*u*ζ+γ)*(o(ζ)+β*u²*ζ+γ)
@@ -255,13 +325,18 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 	var rl fr.Element
 	rl.Mul(&l, &r)
 
-	// -ζⁿ⁺²*(ζⁿ-1), -ζ²⁽ⁿ⁺²⁾*(ζⁿ-1), -(ζⁿ-1)
-	nPlusTwo := big.NewInt(int64(vk.Size) + 2)
-	var zetaNPlusTwoZh, zetaNPlusTwoSquareZh, zh fr.Element
-	zetaNPlusTwoZh.Exp(zeta, nPlusTwo)
-	zetaNPlusTwoSquareZh.Mul(&zetaNPlusTwoZh, &zetaNPlusTwoZh)                          // ζ²⁽ⁿ⁺²⁾
-	zetaNPlusTwoZh.Mul(&zetaNPlusTwoZh, &zhZeta).Neg(&zetaNPlusTwoZh)                   // -ζⁿ⁺²*(ζⁿ-1)
-	zetaNPlusTwoSquareZh.Mul(&zetaNPlusTwoSquareZh, &zhZeta).Neg(&zetaNPlusTwoSquareZh) // -ζ²⁽ⁿ⁺²⁾*(ζⁿ-1)
+	// -ζᵐ*(ζⁿ-1), -ζ²ᵐ*(ζⁿ-1), -(ζⁿ-1), where m is the size of the shards of
+	// the quotient, k*(n+2) for the shard factor k of the custom gates, and
+	// k*n for the proofs without zero knowledge
+	m := new(big.Int).SetUint64(vk.quotientShardFactor() * (vk.Size + 2))
+	if cfg.NoZeroKnowledge {
+		m.SetUint64(vk.quotientShardFactor() * vk.Size)
+	}
+	var zetaMZh, zetaMSquareZh, zh fr.Element
+	zetaMZh.Exp(zeta, m)
+	zetaMSquareZh.Mul(&zetaMZh, &zetaMZh)                          // ζ²ᵐ
+	zetaMZh.Mul(&zetaMZh, &zhZeta).Neg(&zetaMZh)                   // -ζᵐ*(ζⁿ-1)
+	zetaMSquareZh.Mul(&zetaMSquareZh, &zhZeta).Neg(&zetaMSquareZh) // -ζ²ᵐ*(ζⁿ-1)
 	zh.Neg(&zhZeta)
 
 	var linearizedPolynomialDigest curve.G1Affine
@@ -270,6 +345,7 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 		vk.S[2], proof.Z,
 		proof.H[0], proof.H[1], proof.H[2],
 	)
+	points = append(points, vk.Qcg...)
 
 	qC := make([]fr.Element, len(proof.Bsb22Commitments))
 	copy(qC, proof.BatchedProof.ClaimedValues[6:])
@@ -277,8 +353,19 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 	scalars := append(qC,
 		l, r, rl, o, one,
 		_s1, coeffZ,
-		zh, zetaNPlusTwoZh, zetaNPlusTwoSquareZh,
+		zh, zetaMZh, zetaMSquareZh,
 	)
+	var shiftedLRO [3]fr.Element
+	copy(shiftedLRO[:], proof.LROShiftedOpening.ClaimedValues)
+	for i := range vk.CustomGates {
+		scalars = append(scalars, vk.CustomGates[i].Evaluate(l, r, o, shiftedLRO[0], shiftedLRO[1], shiftedLRO[2])) // Gᵢ(l(ζ), r(ζ), o(ζ), l(ωζ), r(ωζ), o(ωζ))
+	}
+	if hasLookups {
+		var coeffPhi fr.Element
+		coeffPhi.Mul(&alphaCubeLookupF, &lookupT).Neg(&coeffPhi) // -α³*(λ+f(ζ))*(λ+t(ζ))
+		points = append(points, proof.Lookup[0], proof.Lookup[1])
+		scalars = append(scalars, alphaCubeLookupF, coeffPhi)
+	}
 	if _, err := linearizedPolynomialDigest.MultiExp(points, scalars, ecc.MultiExpConfig{}); err != nil {
 		return err
 	}
@@ -292,12 +379,20 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 	digestsToFold[3] = proof.LRO[2]
 	digestsToFold[4] = vk.S[0]
 	digestsToFold[5] = vk.S[1]
+	dataTranscript := [][]byte{zu.Marshal()}
+	if hasLookups {
+		digestsToFold = append(digestsToFold, vk.Lookup...)
+		dataTranscript = append(dataTranscript, proof.LookupShiftedOpening.ClaimedValue.Marshal())
+	}
+	for i := range proof.LROShiftedOpening.ClaimedValues {
+		dataTranscript = append(dataTranscript, proof.LROShiftedOpening.ClaimedValues[i].Marshal())
+	}
 	foldedProof, foldedDigest, err := kzg.FoldProof(
 		digestsToFold,
 		&proof.BatchedProof,
 		zeta,
 		cfg.KZGFoldingHash,
-		zu.Marshal(),
+		dataTranscript...,
 	)
 	if err != nil {
 		return err
@@ -306,26 +401,67 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 	// Batch verify
 	var shiftedZeta fr.Element
 	shiftedZeta.Mul(&zeta, &vk.Generator)
-	err = kzg.BatchVerifyMultiPoints([]kzg.Digest{
-		foldedDigest,
-		proof.Z,
-	},
-		[]kzg.OpeningProof{
-			foldedProof,
-			proof.ZShiftedOpening,
-		},
-		[]fr.Element{
-			zeta,
+	digests := []kzg.Digest{foldedDigest, proof.Z}
+	openings := []kzg.OpeningProof{foldedProof, proof.ZShiftedOpening}
+	openingPoints := []fr.Element{zeta, shiftedZeta}
+	if hasLookups {
+		digests = append(digests, proof.Lookup[1])
+		openings = append(openings, proof.LookupShiftedOpening)
+		openingPoints = append(openingPoints, shiftedZeta)
+	}
+	if usesNextRow {
+		// fold the opening of l, r, o at ωζ
+		foldedLROProof, foldedLRODigest, err := kzg.FoldProof(
+			proof.LRO[:],
+			&proof.LROShiftedOpening,
 			shiftedZeta,
-		},
-		vk.Kzg,
-	)
+			cfg.KZGFoldingHash,
+		)
+		if err != nil {
+			return err
+		}
+		digests = append(digests, foldedLRODigest)
+		openings = append(openings, foldedLROProof)
+		openingPoints = append(openingPoints, shiftedZeta)
+	}
+	err = kzg.BatchVerifyMultiPoints(digests, openings, openingPoints, vk.Kzg)
 
 	log.Debug().Dur("took", time.Since(start)).Msg("verifier done")
 
 	return err
 }
 
+// bind binds the custom gate polynomial to the transcript: its number of terms,
+// then the coefficient and the exponents l+2⁸r+2¹⁶o+2²⁴l'+2³²r'+2⁴⁰o' of every
+// term, each encoded as a scalar.
+func (g *CustomGate) bind(fs *fiatshamir.Transcript, challenge string) error {
+	var e fr.Element
+	e.SetUint64(uint64(len(g.Coefficients)))
+	if err := fs.Bind(challenge, e.Marshal()); err != nil {
+		return err
+	}
+	for i := range g.Coefficients {
+		if err := fs.Bind(challenge, g.Coefficients[i].Marshal()); err != nil {
+			return err
+		}
+		e.SetUint64(g.packedExponents(i))
+		if err := fs.Bind(challenge, e.Marshal()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// packedExponents returns the exponents of the i-th term of g packed in
+// l+2⁸r+2¹⁶o+2²⁴l'+2³²r'+2⁴⁰o'.
+func (g *CustomGate) packedExponents(i int) uint64 {
+	var res uint64
+	for j := range g.Exponents[i] {
+		res |= uint64(g.Exponents[i][j]) << (8 * j)
+	}
+	return res
+}
+
 func bindPublicData(fs *fiatshamir.Transcript, challenge string, vk *VerifyingKey, publicInputs []fr.Element) error {
 
 	// permutation
@@ -360,6 +496,21 @@ func bindPublicData(fs *fiatshamir.Transcript, challenge string, vk *VerifyingKe
 			return err
 		}
 	}
+	for i := range vk.Qcg {
+		if err := fs.Bind(challenge, vk.Qcg[i].Marshal()); err != nil {
+			return err
+		}
+	}
+	for i := range vk.CustomGates {
+		if err := vk.CustomGates[i].bind(fs, challenge); err != nil {
+			return err
+		}
+	}
+	for i := range vk.Lookup {
+		if err := fs.Bind(challenge, vk.Lookup[i].Marshal()); err != nil {
+			return err
+		}
+	}
 
 	// public inputs
 	for i := 0; i < len(publicInputs); i++ {
@@ -372,6 +523,22 @@ func bindPublicData(fs *fiatshamir.Transcript, challenge string, vk *VerifyingKe
 
 }
 
+// transcriptChallenges returns the names of the challenges of the transcript,
+// eta and lambda are only derived if the circuit has lookups.
+func transcriptChallenges(vk *VerifyingKey) []string {
+	if len(vk.Lookup) == 0 {
+		return []string{"gamma", "beta", "alpha", "zeta"}
+	}
+	return []string{"gamma", "beta", "eta", "lambda", "alpha", "zeta"}
+}
+
+// lookupCompress returns tag + η*a + η²*b + η³*c.
+func lookupCompress(eta fr.Element, tag, a, b, c *fr.Element) fr.Element {
+	var res fr.Element
+	res.Mul(c, &eta).Add(&res, b).Mul(&res, &eta).Add(&res, a).Mul(&res, &eta).Add(&res, tag)
+	return res
+}
+
 func deriveRandomness(fs *fiatshamir.Transcript, challenge string, points ...*curve.G1Affine) (fr.Element, error) {
 
 	var buf [curve.SizeOfG1AffineUncompressed]byte
@@ -19,8 +19,14 @@ package plonk
 import (
 	curve "github.com/consensys/gnark-crypto/ecc/bls24-315"
 
+	"github.com/consensys/gnark-crypto/ecc/bls24-315/fr"
+
+	"errors"
 	"github.com/consensys/gnark-crypto/ecc/bls24-315/kzg"
 	"io"
+
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/internal/mmap"
 )
 
 // WriteRawTo writes binary encoding of Proof to w without point compression
@@ -49,6 +55,11 @@ func (proof *Proof) writeTo(w io.Writer, options ...func(*curve.Encoder)) (int64
 		&proof.ZShiftedOpening.H,
 		&proof.ZShiftedOpening.ClaimedValue,
 		proof.Bsb22Commitments,
+		proof.Lookup,
+		&proof.LookupShiftedOpening.H,
+		&proof.LookupShiftedOpening.ClaimedValue,
+		&proof.LROShiftedOpening.H,
+		proof.LROShiftedOpening.ClaimedValues,
 	}
 
 	for _, v := range toEncode {
@@ -76,6 +87,11 @@ func (proof *Proof) ReadFrom(r io.Reader) (int64, error) {
 		&proof.ZShiftedOpening.H,
 		&proof.ZShiftedOpening.ClaimedValue,
 		&proof.Bsb22Commitments,
+		&proof.Lookup,
+		&proof.LookupShiftedOpening.H,
+		&proof.LookupShiftedOpening.ClaimedValue,
+		&proof.LROShiftedOpening.H,
+		&proof.LROShiftedOpening.ClaimedValues,
 	}
 
 	for _, v := range toDecode {
@@ -87,6 +103,12 @@ func (proof *Proof) ReadFrom(r io.Reader) (int64, error) {
 	if proof.Bsb22Commitments == nil {
 		proof.Bsb22Commitments = []kzg.Digest{}
 	}
+	if proof.Lookup == nil {
+		proof.Lookup = []kzg.Digest{}
+	}
+	if proof.LROShiftedOpening.ClaimedValues == nil {
+		proof.LROShiftedOpening.ClaimedValues = []fr.Element{}
+	}
 
 	return dec.BytesRead(), nil
 }
@@ -172,6 +194,76 @@ func (pk *ProvingKey) readFrom(r io.Reader, withSubgroupChecks bool) (int64, err
 	return n, err
 }
 
+// mmapKind identifies the proving keys of this package in the files written by WriteMmapTo
+var mmapKind = "plonk/" + curve.ID.String()
+
+// WriteMmapTo writes the proving key in a versioned layout where the KZG points
+// are stored raw and aligned, so that Open can map them in memory instead of
+// reading them. The layout is platform dependent.
+func (pk *ProvingKey) WriteMmapTo(w io.Writer) (int64, error) {
+	mw, err := mmap.NewWriter(w, mmapKind)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := pk.Vk.WriteRawTo(mw); err != nil {
+		return mw.BytesWritten(), err
+	}
+	if err := mmap.WriteVector(mw, pk.Kzg.G1); err != nil {
+		return mw.BytesWritten(), err
+	}
+	if err := mmap.WriteVector(mw, pk.KzgLagrange.G1); err != nil {
+		return mw.BytesWritten(), err
+	}
+	return mw.BytesWritten(), nil
+}
+
+// ReadMmap reads a proving key written by WriteMmapTo from data without copying
+// the KZG points: they point into data, which must stay valid and unmodified
+// while the key is used. The points are not checked.
+func (pk *ProvingKey) ReadMmap(data []byte) error {
+	r, err := mmap.NewReader(data, mmapKind)
+	if err != nil {
+		return err
+	}
+	pk.Vk = &VerifyingKey{}
+	if _, err := pk.Vk.UnsafeReadFrom(r); err != nil {
+		return err
+	}
+	if pk.Kzg.G1, err = mmap.ReadVector[curve.G1Affine](r); err != nil {
+		return err
+	}
+	pk.KzgLagrange.G1, err = mmap.ReadVector[curve.G1Affine](r)
+	return err
+}
+
+// Open memory maps the proving key written by WriteMmapTo in the file at path.
+// The KZG points are not read: the operating system loads them from the file
+// when the prover accesses them, and shares them with the other processes
+// mapping the same file. The key must be released with Close.
+func Open(path string) (*ProvingKey, error) {
+	f, err := mmap.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	pk := new(ProvingKey)
+	if err := pk.ReadMmap(f.Bytes()); err != nil {
+		return nil, errors.Join(err, f.Close())
+	}
+	pk.mapped = f
+	return pk, nil
+}
+
+// Close unmaps a proving key returned by Open, which must not be used anymore.
+// It is a no-op on the other proving keys.
+func (pk *ProvingKey) Close() error {
+	if pk.mapped == nil {
+		return nil
+	}
+	err := pk.mapped.Close()
+	*pk = ProvingKey{}
+	return err
+}
+
 // WriteTo writes binary encoding of VerifyingKey to w
 func (vk *VerifyingKey) WriteTo(w io.Writer) (n int64, err error) {
 	return vk.writeTo(w)
@@ -184,6 +276,7 @@ func (vk *VerifyingKey) WriteRawTo(w io.Writer) (int64, error) {
 
 func (vk *VerifyingKey) writeTo(w io.Writer, options ...func(*curve.Encoder)) (n int64, err error) {
 	enc := curve.NewEncoder(w)
+	gatesCoefficients, gatesExponents := vk.encodeCustomGates()
 
 	toEncode := []interface{}{
 		vk.Size,
@@ -205,6 +298,10 @@ func (vk *VerifyingKey) writeTo(w io.Writer, options ...func(*curve.Encoder)) (n
 		&vk.Kzg.G2[1],
 		&vk.Kzg.Lines,
 		vk.CommitmentConstraintIndexes,
+		vk.Qcg,
+		gatesCoefficients,
+		gatesExponents,
+		vk.Lookup,
 	}
 
 	for _, v := range toEncode {
@@ -225,6 +322,8 @@ func (vk *VerifyingKey) UnsafeReadFrom(r io.Reader) (int64, error) {
 // ReadFrom reads from binary representation in r into VerifyingKey
 func (vk *VerifyingKey) ReadFrom(r io.Reader) (int64, error) {
 	dec := curve.NewDecoder(r)
+	var gatesCoefficients [][]fr.Element
+	var gatesExponents [][]uint64
 	toDecode := []interface{}{
 		&vk.Size,
 		&vk.SizeInv,
@@ -245,6 +344,10 @@ func (vk *VerifyingKey) ReadFrom(r io.Reader) (int64, error) {
 		&vk.Kzg.G2[1],
 		&vk.Kzg.Lines,
 		&vk.CommitmentConstraintIndexes,
+		&vk.Qcg,
+		&gatesCoefficients,
+		&gatesExponents,
+		&vk.Lookup,
 	}
 
 	for _, v := range toDecode {
@@ -256,6 +359,57 @@ func (vk *VerifyingKey) ReadFrom(r io.Reader) (int64, error) {
 	if vk.Qcp == nil {
 		vk.Qcp = []kzg.Digest{}
 	}
+	if vk.Qcg == nil {
+		vk.Qcg = []kzg.Digest{}
+	}
+	if vk.Lookup == nil {
+		vk.Lookup = []kzg.Digest{}
+	}
+	if err := vk.decodeCustomGates(gatesCoefficients, gatesExponents); err != nil {
+		return dec.BytesRead(), err
+	}
 
 	return dec.BytesRead(), nil
 }
+
+// encodeCustomGates returns the coefficients of the custom gates and the
+// flattened exponents of their terms.
+func (vk *VerifyingKey) encodeCustomGates() ([][]fr.Element, [][]uint64) {
+	coefficients := make([][]fr.Element, len(vk.CustomGates))
+	exponents := make([][]uint64, len(vk.CustomGates))
+	for i, g := range vk.CustomGates {
+		coefficients[i] = g.Coefficients
+		exponents[i] = make([]uint64, 0, 6*len(g.Exponents))
+		for _, e := range g.Exponents {
+			for k := range e {
+				exponents[i] = append(exponents[i], uint64(e[k]))
+			}
+		}
+	}
+	return coefficients, exponents
+}
+
+// decodeCustomGates is the inverse of encodeCustomGates.
+func (vk *VerifyingKey) decodeCustomGates(coefficients [][]fr.Element, exponents [][]uint64) error {
+	if len(coefficients) != len(exponents) {
+		return errors.New("invalid custom gates encoding")
+	}
+	vk.CustomGates = make([]CustomGate, len(coefficients))
+	for i := range coefficients {
+		if 6*len(coefficients[i]) != len(exponents[i]) {
+			return errors.New("invalid custom gates encoding")
+		}
+		vk.CustomGates[i].Coefficients = make([]fr.Element, len(coefficients[i]))
+		copy(vk.CustomGates[i].Coefficients, coefficients[i])
+		vk.CustomGates[i].Exponents = make([][6]uint8, len(coefficients[i]))
+		for j := range vk.CustomGates[i].Exponents {
+			for k := 0; k < 6; k++ {
+				if exponents[i][6*j+k] > constraint.MaxCustomGateDegree {
+					return errors.New("invalid custom gates encoding")
+				}
+				vk.CustomGates[i].Exponents[j][k] = uint8(exponents[i][6*j+k])
+			}
+		}
+	}
+	return nil
+}
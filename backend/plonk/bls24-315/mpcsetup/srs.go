@@ -0,0 +1,212 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+package mpcsetup
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	curve "github.com/consensys/gnark-crypto/ecc/bls24-315"
+	"github.com/consensys/gnark-crypto/ecc/bls24-315/fr"
+	"github.com/consensys/gnark-crypto/ecc/bls24-315/kzg"
+	"math/big"
+	"math/bits"
+)
+
+// PowersOfTau is the state of a universal setup ceremony for KZG, as used by
+// PLONK: the powers of a secret τ, updated by each contribution. Unlike the
+// Groth16 MPC, it doesn't depend on the circuit, and the resulting SRS can be
+// used by any circuit up to its size.
+type PowersOfTau struct {
+	Parameters struct {
+		G1 struct {
+			Tau []curve.G1Affine // {[τ⁰]₁, [τ¹]₁, [τ²]₁, …, [τᴺ⁻¹]₁}
+		}
+		G2 struct {
+			Tau curve.G2Affine // [τ]₂
+		}
+	}
+	PublicKey PublicKey // proof of knowledge of the secret of the last contribution
+	Hash      []byte    // sha256 hash
+}
+
+// InitPowersOfTau initializes a ceremony producing size powers of τ. This is
+// called once by the coordinator before any randomness contribution is made
+// (see Contribute()).
+//
+// PLONK needs a canonical SRS of size n+3 and a Lagrange SRS of size n, where n
+// is the number of constraints and public inputs rounded up to a power of 2.
+// Circuits with custom gates of degree more than 3 need a larger canonical
+// SRS, see plonk.SRSSize.
+func InitPowersOfTau(size uint64) (*PowersOfTau, error) {
+	if size < 2 {
+		return nil, kzg.ErrMinSRSSize
+	}
+	var p PowersOfTau
+
+	// the initial public key is computed deterministically so that anyone can
+	// recompute the initialization
+	var tau fr.Element
+	tau.SetOne()
+	p.PublicKey = newPublicKey(tau, nil, 1, new(sampler))
+
+	// First contribution use generators
+	_, _, g1, g2 := curve.Generators()
+	p.Parameters.G1.Tau = make([]curve.G1Affine, size)
+	for i := range p.Parameters.G1.Tau {
+		p.Parameters.G1.Tau[i].Set(&g1)
+	}
+	p.Parameters.G2.Tau.Set(&g2)
+
+	// Compute hash of Contribution
+	p.Hash = p.hash()
+
+	return &p, nil
+}
+
+// Contribute contributes randomness to the powers of τ. This mutates p.
+func (p *PowersOfTau) Contribute() {
+	p.contribute(nil)
+}
+
+// ContributeBeacon contributes to the powers of τ with a secret derived from a
+// random beacon, typically to end the ceremony once all the participants have
+// contributed. Anyone knowing the beacon can recompute the contribution.
+func (p *PowersOfTau) ContributeBeacon(beacon []byte) {
+	p.contribute(&sampler{beacon: beacon})
+}
+
+func (p *PowersOfTau) contribute(rand *sampler) {
+	// Generate key pair
+	tau := rand.sample()
+	p.PublicKey = newPublicKey(tau, p.Hash[:], 1, rand)
+
+	// Update using previous parameters
+	scaleG1InPlace(p.Parameters.G1.Tau, powers(tau, len(p.Parameters.G1.Tau)))
+	var tauBI big.Int
+	tau.BigInt(&tauBI)
+	p.Parameters.G2.Tau.ScalarMultiplication(&p.Parameters.G2.Tau, &tauBI)
+
+	// Compute hash of Contribution
+	p.Hash = p.hash()
+}
+
+// VerifyPowersOfTau checks that each contribution is based on the previous one.
+func VerifyPowersOfTau(c0, c1 *PowersOfTau, c ...*PowersOfTau) error {
+	contribs := append([]*PowersOfTau{c0, c1}, c...)
+	for i := 0; i < len(contribs)-1; i++ {
+		if err := verifyPowersOfTau(contribs[i], contribs[i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyPowersOfTau checks that a contribution is based on a known previous state.
+func verifyPowersOfTau(current, contribution *PowersOfTau) error {
+	if len(current.Parameters.G1.Tau) < 2 {
+		return kzg.ErrMinSRSSize
+	}
+	if len(contribution.Parameters.G1.Tau) != len(current.Parameters.G1.Tau) {
+		return errors.New("the contribution doesn't have the size of the previous one")
+	}
+
+	// Compute R for τ
+	tauR := genR(contribution.PublicKey.SG, contribution.PublicKey.SXG, current.Hash[:], 1)
+
+	// Check for knowledge of toxic parameters
+	if !sameRatio(contribution.PublicKey.SG, contribution.PublicKey.SXG, contribution.PublicKey.XR, tauR) {
+		return errors.New("couldn't verify public key of τ")
+	}
+
+	// Check for valid updates using previous parameters
+	_, _, g1, g2 := curve.Generators()
+	if !contribution.Parameters.G1.Tau[0].Equal(&g1) {
+		return errors.New("[τ⁰]₁ must be the generator of G₁")
+	}
+	if contribution.Parameters.G1.Tau[1].IsInfinity() {
+		return errors.New("[τ]₁ is the point at infinity")
+	}
+	if !sameRatio(contribution.Parameters.G1.Tau[1], current.Parameters.G1.Tau[1], tauR, contribution.PublicKey.XR) {
+		return errors.New("couldn't verify that [τ]₁ is based on previous contribution")
+	}
+	if !sameRatio(contribution.PublicKey.SG, contribution.PublicKey.SXG, contribution.Parameters.G2.Tau, current.Parameters.G2.Tau) {
+		return errors.New("couldn't verify that [τ]₂ is based on previous contribution")
+	}
+
+	// Check for valid updates using powers of τ
+	tauL1, tauL2 := linearCombinationG1(contribution.Parameters.G1.Tau)
+	if !sameRatio(tauL1, tauL2, contribution.Parameters.G2.Tau, g2) {
+		return errors.New("couldn't verify valid powers of τ in G₁")
+	}
+
+	// Check hash of the contribution
+	h := contribution.hash()
+	for i := 0; i < len(h); i++ {
+		if h[i] != contribution.Hash[i] {
+			return errors.New("couldn't verify hash of contribution")
+		}
+	}
+
+	return nil
+}
+
+// SRS returns the KZG SRS in canonical form: {[τ⁰]₁, [τ¹]₁, …, [τᴺ⁻¹]₁}, with
+// [τ]₂ for the verifying key.
+func (p *PowersOfTau) SRS() *kzg.SRS {
+	var srs kzg.SRS
+	srs.Pk.G1 = make([]curve.G1Affine, len(p.Parameters.G1.Tau))
+	copy(srs.Pk.G1, p.Parameters.G1.Tau)
+	p.setVerifyingKey(&srs.Vk)
+	return &srs
+}
+
+// LagrangeSRS returns the KZG SRS in Lagrange form on the domain of the given
+// size, which must be a power of 2 no greater than the number of powers of τ:
+// {[L₀(τ)]₁, [L₁(τ)]₁, …, [Lₙ₋₁(τ)]₁}.
+func (p *PowersOfTau) LagrangeSRS(size uint64) (*kzg.SRS, error) {
+	if bits.OnesCount64(size) != 1 {
+		return nil, fmt.Errorf("the size of the Lagrange SRS must be a power of 2, got %d", size)
+	}
+	if size > uint64(len(p.Parameters.G1.Tau)) {
+		return nil, fmt.Errorf("the size of the Lagrange SRS is %d but there are only %d powers of τ", size, len(p.Parameters.G1.Tau))
+	}
+	var (
+		srs kzg.SRS
+		err error
+	)
+	if srs.Pk.G1, err = kzg.ToLagrangeG1(p.Parameters.G1.Tau[:size]); err != nil {
+		return nil, err
+	}
+	p.setVerifyingKey(&srs.Vk)
+	return &srs, nil
+}
+
+func (p *PowersOfTau) setVerifyingKey(vk *kzg.VerifyingKey) {
+	_, _, g1, g2 := curve.Generators()
+	vk.G1 = g1
+	vk.G2[0] = g2
+	vk.G2[1] = p.Parameters.G2.Tau
+	vk.Lines[0] = curve.PrecomputeLines(vk.G2[0])
+	vk.Lines[1] = curve.PrecomputeLines(vk.G2[1])
+}
+
+func (p *PowersOfTau) hash() []byte {
+	sha := sha256.New()
+	p.writeTo(sha)
+	return sha.Sum(nil)
+}
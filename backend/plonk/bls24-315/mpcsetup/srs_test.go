@@ -0,0 +1,150 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+package mpcsetup
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bls24-315"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	gnarkio "github.com/consensys/gnark/io"
+	"github.com/stretchr/testify/require"
+)
+
+type Circuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *Circuit) Define(api frontend.API) error {
+	x3 := api.Mul(c.X, c.X, c.X)
+	api.AssertIsEqual(api.Add(x3, c.X, 5), c.Y)
+	return nil
+}
+
+func TestPowersOfTau(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	const nContributions = 3
+
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(curve.ID.ScalarField(), scs.NewBuilder, &Circuit{})
+	assert.NoError(err)
+	size := ecc.NextPowerOfTwo(uint64(ccs.GetNbConstraints() + ccs.GetNbPublicVariables()))
+
+	// Make and verify the contributions, the last one from a random beacon
+	p, err := InitPowersOfTau(size + 3)
+	assert.NoError(err)
+	contributions := []*PowersOfTau{clone(t, p)}
+	for i := 0; i < nContributions; i++ {
+		// participants receive the serialized last contribution, and send back
+		// their own
+		p = clone(t, p)
+		if i == nContributions-1 {
+			p.ContributeBeacon([]byte("beacon"))
+		} else {
+			p.Contribute()
+		}
+		assert.NoError(VerifyPowersOfTau(contributions[len(contributions)-1], p))
+		contributions = append(contributions, p)
+	}
+	assert.NoError(VerifyPowersOfTau(contributions[0], contributions[1], contributions[2:]...))
+
+	// the beacon contribution can be recomputed
+	replayed := clone(t, contributions[nContributions-1])
+	replayed.ContributeBeacon([]byte("beacon"))
+	assert.Equal(p.Hash, replayed.Hash)
+
+	// Convert to a kzg SRS and prove
+	srs := p.SRS()
+	srsLagrange, err := p.LagrangeSRS(size)
+	assert.NoError(err)
+	_, err = p.LagrangeSRS(size + 1)
+	assert.Error(err, "not a power of 2")
+	_, err = p.LagrangeSRS(2 * size)
+	assert.Error(err, "too many powers")
+
+	pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+	assert.NoError(err)
+	witness, err := frontend.NewWitness(&Circuit{X: 3, Y: 35}, curve.ID.ScalarField())
+	assert.NoError(err)
+	publicWitness, err := witness.Public()
+	assert.NoError(err)
+	proof, err := plonk.Prove(ccs, pk, witness)
+	assert.NoError(err)
+	assert.NoError(plonk.Verify(proof, vk, publicWitness))
+}
+
+func TestVerifyPowersOfTau(t *testing.T) {
+	assert := require.New(t)
+
+	p, err := InitPowersOfTau(8)
+	assert.NoError(err)
+	prev := clone(t, p)
+	p.Contribute()
+	assert.NoError(VerifyPowersOfTau(prev, p))
+
+	// a contribution based on another state
+	other := clone(t, prev)
+	other.Contribute()
+	other.Contribute()
+	assert.Error(VerifyPowersOfTau(prev, other))
+
+	// tampered powers of τ, with a valid hash
+	tampered := clone(t, p)
+	tampered.Parameters.G1.Tau[2], tampered.Parameters.G1.Tau[3] = tampered.Parameters.G1.Tau[3], tampered.Parameters.G1.Tau[2]
+	tampered.Hash = tampered.hash()
+	assert.Error(VerifyPowersOfTau(prev, tampered))
+
+	// tampered hash
+	tampered = clone(t, p)
+	tampered.Hash[0] ^= 1
+	assert.Error(VerifyPowersOfTau(prev, tampered))
+
+	// a contribution of another size
+	other, err = InitPowersOfTau(16)
+	assert.NoError(err)
+	other.Contribute()
+	assert.Error(VerifyPowersOfTau(prev, other))
+}
+
+func TestPowersOfTauSerialization(t *testing.T) {
+	assert := require.New(t)
+
+	p, err := InitPowersOfTau(16)
+	assert.NoError(err)
+	p.Contribute()
+
+	assert.NoError(gnarkio.RoundTripCheck(p, func() interface{} { return new(PowersOfTau) }))
+}
+
+// clone returns a copy of p through its serialization
+func clone(t *testing.T, p *PowersOfTau) *PowersOfTau {
+	var buf bytes.Buffer
+	_, err := p.WriteTo(&buf)
+	require.NoError(t, err)
+	var res PowersOfTau
+	_, err = res.ReadFrom(&buf)
+	require.NoError(t, err)
+	return &res
+}
@@ -42,6 +42,7 @@ func TestProvingKeySerialization(t *testing.T) {
 	pk.randomize()
 
 	assert.NoError(t, io.RoundTripCheck(&pk, func() interface{} { return new(ProvingKey) }))
+	assert.NoError(t, io.MmapRoundTripCheck(&pk, func() interface{} { return new(ProvingKey) }))
 }
 
 func TestVerifyingKeySerialization(t *testing.T) {
@@ -89,6 +90,17 @@ func (vk *VerifyingKey) randomize() {
 	vk.Qo = randomG1Point()
 	vk.Qk = randomG1Point()
 	vk.Qcp = randomG1Points(rand.Intn(4)) //#nosec G404 weak rng is fine here
+	vk.Qcg = randomG1Points(rand.Intn(4)) //#nosec G404 weak rng is fine here
+	vk.CustomGates = make([]CustomGate, len(vk.Qcg))
+	for i := range vk.CustomGates {
+		nbTerms := 1 + rand.Intn(4) //#nosec G404 weak rng is fine here
+		vk.CustomGates[i].Coefficients = randomScalars(nbTerms)
+		vk.CustomGates[i].Exponents = make([][6]uint8, nbTerms)
+		for j := range vk.CustomGates[i].Exponents {
+			vk.CustomGates[i].Exponents[j][rand.Intn(6)] = uint8(1 + rand.Intn(3)) //#nosec G404 weak rng is fine here
+		}
+	}
+	vk.Lookup = randomG1Points(nb_lookup_polynomials * rand.Intn(2)) //#nosec G404 weak rng is fine here
 }
 
 func (proof *Proof) randomize() {
@@ -104,6 +116,11 @@ func (proof *Proof) randomize() {
 	proof.ZShiftedOpening.H = randomG1Point()
 	proof.ZShiftedOpening.ClaimedValue.SetRandom()
 	proof.Bsb22Commitments = randomG1Points(rand.Intn(4)) //#nosec G404 weak rng is fine here
+	proof.Lookup = randomG1Points(2 * rand.Intn(2))       //#nosec G404 weak rng is fine here
+	proof.LookupShiftedOpening.H = randomG1Point()
+	proof.LookupShiftedOpening.ClaimedValue.SetRandom()
+	proof.LROShiftedOpening.H = randomG1Point()
+	proof.LROShiftedOpening.ClaimedValues = randomScalars(3 * rand.Intn(2)) //#nosec G404 weak rng is fine here
 }
 
 func randomG2Point() curve.G2Affine {
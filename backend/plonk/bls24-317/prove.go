@@ -24,6 +24,7 @@ import (
 	"math/big"
 	"math/bits"
 	"runtime"
+	"slices"
 	"sync"
 	"time"
 
@@ -48,6 +49,7 @@ import (
 	cs "github.com/consensys/gnark/constraint/bls24-317"
 	"github.com/consensys/gnark/constraint/solver"
 	fcs "github.com/consensys/gnark/frontend/cs"
+	"github.com/consensys/gnark/internal/mmap"
 	"github.com/consensys/gnark/internal/utils"
 	"github.com/consensys/gnark/logger"
 )
@@ -68,7 +70,7 @@ const (
 	id_S3
 	id_ID
 	id_LOne
-	id_Qci // [ .. , Qc_i, Pi_i, ...]
+	id_Qci // [ .. , Qc_i, Pi_i, ...] followed by the custom gates selectors [ .. , Qcg_i, ...]
 )
 
 // blinding factors
@@ -77,15 +79,30 @@ const (
 	id_Br
 	id_Bo
 	id_Bz
+	id_Bm
+	id_Bphi
 	nb_blinding_polynomials
 )
 
 // blinding orders (-1 to deactivate)
 const (
-	order_blinding_L = 1
-	order_blinding_R = 1
-	order_blinding_O = 1
-	order_blinding_Z = 2
+	order_blinding_L   = 1
+	order_blinding_R   = 1
+	order_blinding_O   = 1
+	order_blinding_Z   = 2
+	order_blinding_M   = 1
+	order_blinding_Phi = 2
+	// L, R, O when they are also opened at ωζ, see VerifyingKey.usesNextRow
+	order_blinding_LRO_shifted = 2
+)
+
+// indices in x of the polynomials of the lookup argument, relative to
+// idLookup(0). The fixed polynomials come first, see lookup_Qlk, .., lookup_T2.
+const (
+	lookup_M = nb_lookup_polynomials + iota
+	lookup_Phi
+	lookup_PhiS
+	nb_lookup_ids
 )
 
 type Proof struct {
@@ -97,6 +114,7 @@ type Proof struct {
 	Z kzg.Digest
 
 	// Commitments to h1, h2, h3 such that h = h1 + Xⁿ⁺²*h2 + X²⁽ⁿ⁺²⁾*h3 is the quotient polynomial
+	// (h = h1 + Xⁿ*h2 + X²ⁿ*h3 without zero knowledge, see backend.WithoutZeroKnowledge)
 	H [3]kzg.Digest
 
 	Bsb22Commitments []kzg.Digest
@@ -106,6 +124,17 @@ type Proof struct {
 
 	// Opening proof of Z at zeta*mu
 	ZShiftedOpening kzg.OpeningProof
+
+	// Commitments to the multiplicities m and the running sum φ of the lookup
+	// argument, empty if the circuit has no lookup.
+	Lookup []kzg.Digest
+
+	// Opening proof of φ at zeta*mu, if the circuit has lookups
+	LookupShiftedOpening kzg.OpeningProof
+
+	// Batch opening proof of l, r, o at zeta*mu, if a custom gate uses the
+	// next row. Otherwise the list of claimed values is empty.
+	LROShiftedOpening kzg.BatchOpeningProof
 }
 
 func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...backend.ProverOption) (*Proof, error) {
@@ -124,45 +153,163 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness witness.Witness, opts
 	start := time.Now()
 
 	// init instance
-	g, ctx := errgroup.WithContext(context.Background())
-	instance, err := newInstance(ctx, spr, pk, fullWitness, &opt)
+	instance, err := newInstance(spr, pk, fullWitness, &opt, newProverSetup(spr, &opt))
 	if err != nil {
 		return nil, fmt.Errorf("new instance: %w", err)
 	}
 
+	proof, err := instance.prove()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug().Dur("took", time.Since(start)).Msg("prover done")
+	return proof, nil
+}
+
+// ProveBatch generates the proofs of several full witnesses of the same
+// constraint system. The fft domains and the trace of the circuit are computed
+// once for the batch, the memory of the trace is reused from one proof to the
+// next, and the witness i+1 is solved while the proof of the witness i is
+// computed.
+//
+// In low memory mode (see backend.WithLowMemory), each instance copies the
+// trace in its own arena instead.
+//
+// The proofs are returned in the order of the witnesses. If the proof of a
+// witness fails, the proof is nil and the error is set at the same index of
+// errs; errs only contains nil errors if all the proofs succeed.
+func ProveBatch(spr *cs.SparseR1CS, pk *ProvingKey, fullWitnesses []witness.Witness, opts ...backend.ProverOption) (proofs []*Proof, errs []error) {
+	proofs = make([]*Proof, len(fullWitnesses))
+	errs = make([]error, len(fullWitnesses))
+	if len(fullWitnesses) == 0 {
+		return
+	}
+
+	log := logger.Logger().With().
+		Str("curve", spr.CurveID().String()).
+		Int("nbConstraints", spr.GetNbConstraints()).
+		Int("nbProofs", len(fullWitnesses)).
+		Str("backend", "plonk").Logger()
+
+	opt, err := backend.NewProverConfig(opts...)
+	if err != nil {
+		for i := range errs {
+			errs[i] = fmt.Errorf("get prover options: %w", err)
+		}
+		return
+	}
+	// the hash to field function is shared by the solvers, which run one
+	// after the other
+	if opt.HashToFieldFn == nil {
+		opt.HashToFieldFn = hash_to_field.New([]byte("BSB22-Plonk"))
+	}
+
+	start := time.Now()
+
+	setup := newProverSetup(spr, &opt)
+
+	// the next instance is created and solved in its own go routine, while the
+	// current instance computes its proof. The trace of the instances is copied
+	// from the one of the setup, in the memory of the previous proof if any.
+	type solved struct {
+		instance *instance
+		err      error
+	}
+	solve := func(fullWitness witness.Witness, recycled *Trace) <-chan solved {
+		ch := make(chan solved, 1)
+		go func() {
+			// each instance has its own copy of the options, as it adds its
+			// hint to the solver options
+			opt := opt
+			opt.SolverOpts = opt.SolverOpts[:len(opt.SolverOpts):len(opt.SolverOpts)]
+			instanceSetup := setup
+			if !opt.LowMemory {
+				instanceSetup = setup.withTrace(recycled)
+			}
+			instance, err := newInstance(spr, pk, fullWitness, &opt, instanceSetup)
+			if err != nil {
+				ch <- solved{err: fmt.Errorf("new instance: %w", err)}
+				return
+			}
+			ch <- solved{instance, instance.solve()}
+		}()
+		return ch
+	}
+
+	var recycled *Trace
+	next := solve(fullWitnesses[0], nil)
+	for i := range fullWitnesses {
+		current := <-next
+		if i+1 < len(fullWitnesses) {
+			next = solve(fullWitnesses[i+1], recycled)
+			recycled = nil
+		}
+		if current.err != nil {
+			errs[i] = current.err
+			if current.instance != nil {
+				current.instance.arena.Close()
+			}
+			continue
+		}
+		if proofs[i], errs[i] = current.instance.prove(); errs[i] != nil {
+			proofs[i] = nil
+		}
+		// all the steps of the proof are done, the trace can be reused. In
+		// low memory mode, it was released with the arena of the instance.
+		if !opt.LowMemory {
+			recycled = current.instance.trace
+		}
+	}
+
+	log.Debug().Dur("took", time.Since(start)).Msg("batch prover done")
+	return
+}
+
+// prove runs the steps of the prover, the constraints are solved first if
+// the instance is not solved yet.
+func (s *instance) prove() (*Proof, error) {
+	g, ctx := errgroup.WithContext(context.Background())
+	s.ctx = ctx
+	defer func() {
+		s.background.Wait()
+		s.arena.Close()
+	}()
+
 	// solve constraints
-	g.Go(instance.solveConstraints)
+	g.Go(s.solveConstraints)
 
 	// complete qk
-	g.Go(instance.completeQk)
+	g.Go(s.completeQk)
 
 	// init blinding polynomials
-	g.Go(instance.initBlindingPolynomials)
+	g.Go(s.initBlindingPolynomials)
 
 	// derive gamma, beta (copy constraint)
-	g.Go(instance.deriveGammaAndBeta)
+	g.Go(s.deriveGammaAndBeta)
 
 	// compute accumulating ratio for the copy constraint
-	g.Go(instance.buildRatioCopyConstraint)
+	g.Go(s.buildRatioCopyConstraint)
+
+	// compute the multiplicities and the running sum of the lookup argument
+	g.Go(s.buildLookupRunningSum)
 
 	// compute h
-	g.Go(instance.computeQuotient)
+	g.Go(s.computeQuotient)
 
 	// open Z (blinded) at ωζ (proof.ZShiftedOpening)
-	g.Go(instance.openZ)
+	g.Go(s.openZ)
 
 	// linearized polynomial
-	g.Go(instance.computeLinearizedPolynomial)
+	g.Go(s.computeLinearizedPolynomial)
 
 	// Batch opening
-	g.Go(instance.batchOpening)
+	g.Go(s.batchOpening)
 
 	if err := g.Wait(); err != nil {
 		return nil, err
 	}
-
-	log.Debug().Dur("took", time.Since(start)).Msg("prover done")
-	return instance.proof, nil
+	return s.proof, nil
 }
 
 // represents a Prover instance
@@ -183,12 +330,15 @@ type instance struct {
 	bp                        []*iop.Polynomial // blinding polynomials
 	h                         *iop.Polynomial   // h is the quotient polynomial
 	blindedZ                  []fr.Element      // blindedZ is the blinded version of Z
+	blindedPhi                []fr.Element      // blindedPhi is the blinded version of φ
+	blindedLRO                [3][]fr.Element   // blinded versions of l, r, o, if they are opened at ωζ
 	quotientShardsRandomizers [2]fr.Element     // random elements for blinding the shards of the quotient
 
 	linearizedPolynomial       []fr.Element
 	linearizedPolynomialDigest kzg.Digest
 
 	fullWitness witness.Witness
+	solved      bool // the constraints are solved, see solve
 
 	// bsb22 commitment stuff
 	commitmentInfo constraint.PlonkCommitments
@@ -196,7 +346,7 @@ type instance struct {
 	cCommitments   []*iop.Polynomial
 
 	// challenges
-	gamma, beta, alpha, zeta fr.Element
+	gamma, beta, eta, lambda, alpha, zeta fr.Element
 
 	// channel to wait for the steps
 	chLRO,
@@ -207,26 +357,39 @@ type instance struct {
 	chRestoreLRO,
 	chZOpening,
 	chLinearizedPolynomial,
+	chM,
+	chPhi,
 	chGammaBeta chan struct{}
 
 	domain0, domain1 *fft.Domain
 
 	trace *Trace
+
+	// arena of the large vectors in low memory mode, nil otherwise
+	arena *mmap.Arena
+
+	// go routines which outlive their step, and may still use the arena
+	background sync.WaitGroup
 }
 
-func newInstance(ctx context.Context, spr *cs.SparseR1CS, pk *ProvingKey, fullWitness witness.Witness, opts *backend.ProverConfig) (*instance, error) {
+func newInstance(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness witness.Witness, opts *backend.ProverConfig, setup *proverSetup) (*instance, error) {
 	if opts.HashToFieldFn == nil {
 		opts.HashToFieldFn = hash_to_field.New([]byte("BSB22-Plonk"))
 	}
+	// the blinded polynomials are of degree n+2, and their openings need n+3
+	// points, or more for the larger shards of the quotient of the custom
+	// gates of high degree
+	if n := int(pk.Vk.quotientShardFactor() * (setup.domain0.Cardinality + 2)); !opts.NoZeroKnowledge && len(pk.Kzg.G1) < n+1 {
+		return nil, fmt.Errorf("kzg srs is too small for a zero knowledge proof: got %d, need %d", len(pk.Kzg.G1), n+1)
+	}
 	s := instance{
-		ctx:                    ctx,
 		pk:                     pk,
 		proof:                  &Proof{},
 		spr:                    spr,
 		opt:                    opts,
 		fullWitness:            fullWitness,
 		bp:                     make([]*iop.Polynomial, nb_blinding_polynomials),
-		fs:                     fiatshamir.NewTranscript(opts.ChallengeHash, "gamma", "beta", "alpha", "zeta"),
+		fs:                     fiatshamir.NewTranscript(opts.ChallengeHash, transcriptChallenges(pk.Vk)...),
 		kzgFoldingHash:         opts.KZGFoldingHash,
 		htfFunc:                opts.HashToFieldFn,
 		chLRO:                  make(chan struct{}, 1),
@@ -238,14 +401,13 @@ func newInstance(ctx context.Context, spr *cs.SparseR1CS, pk *ProvingKey, fullWi
 		chZOpening:             make(chan struct{}, 1),
 		chLinearizedPolynomial: make(chan struct{}, 1),
 		chRestoreLRO:           make(chan struct{}, 1),
+		chM:                    make(chan struct{}, 1),
+		chPhi:                  make(chan struct{}, 1),
+		domain0:                setup.domain0,
+		domain1:                setup.domain1,
+		trace:                  setup.trace,
 	}
 	s.initBSB22Commitments()
-	s.x = make([]*iop.Polynomial, id_Qci+2*len(s.commitmentInfo))
-
-	// init fft domains
-	nbConstraints := spr.GetNbConstraints()
-	sizeSystem := uint64(nbConstraints + len(spr.Public)) // len(spr.Public) is for the placeholder constraints
-	s.domain0 = fft.NewDomain(sizeSystem)
 
 	// sampling random numbers for blinding the quotient
 	if opts.StatisticalZK {
@@ -253,26 +415,221 @@ func newInstance(ctx context.Context, spr *cs.SparseR1CS, pk *ProvingKey, fullWi
 		s.quotientShardsRandomizers[1].SetRandom()
 	}
 
+	// the proofs without lookup have an empty, not nil, list of commitments, as
+	// after a round trip through ReadFrom
+	s.proof.Lookup = []kzg.Digest{}
+	s.proof.LROShiftedOpening.ClaimedValues = []fr.Element{}
+	if s.hasLookups() {
+		s.proof.Lookup = make([]kzg.Digest, 2)
+	}
+	nbX := s.idLROShifted(0)
+	if s.usesNextRow() {
+		nbX += 3
+	}
+	s.x = make([]*iop.Polynomial, nbX)
+
+	if opts.LowMemory {
+		s.arena = mmap.NewArena(opts.MemoryBudget, opts.TempDir)
+		if err := s.spillTrace(); err != nil {
+			s.arena.Close()
+			return nil, err
+		}
+	}
+
+	return &s, nil
+}
+
+// proverSetup holds the data of the prover which only depend on the circuit.
+type proverSetup struct {
+	domain0, domain1 *fft.Domain
+	trace            *Trace
+}
+
+func newProverSetup(spr *cs.SparseR1CS, opt *backend.ProverConfig) *proverSetup {
+	var setup proverSetup
+
+	// init fft domains
+	nbConstraints := spr.GetNbConstraints()
+	sizeSystem := uint64(nbConstraints + len(spr.Public)) // len(spr.Public) is for the placeholder constraints
+	setup.domain0 = fft.NewDomain(sizeSystem)
+
 	// h, the quotient polynomial is of degree 3(n+1)+2, so it's in a 3(n+2) dim vector space,
-	// the domain is the next power of 2 superior to 3(n+2). 4*domainNum is enough in all cases
-	// except when n<6.
-	if sizeSystem < 6 {
-		s.domain1 = fft.NewDomain(8*sizeSystem, fft.WithoutPrecompute())
+	// the domain is the next power of 2 superior to 3(n+2). Without blinding, h is of degree
+	// less than 3n. The custom gates of degree more than 3 multiply the size of the space by
+	// the shard factor k, see constraint.QuotientShardFactor.
+	k := uint64(spr.GetQuotientShardFactor())
+	if opt.NoZeroKnowledge {
+		setup.domain1 = fft.NewDomain(3*k*setup.domain0.Cardinality, fft.WithoutPrecompute())
 	} else {
-		s.domain1 = fft.NewDomain(4*sizeSystem, fft.WithoutPrecompute())
+		setup.domain1 = fft.NewDomain(3*k*(setup.domain0.Cardinality+2), fft.WithoutPrecompute())
 	}
 
 	// build trace
-	s.trace = NewTrace(spr, s.domain0)
+	setup.trace = NewTrace(spr, setup.domain0)
 
-	return &s, nil
+	return &setup
+}
+
+// withTrace returns a setup with the same domains and a copy of the trace, as
+// the prover modifies the polynomials of the trace. The copy reuses the memory
+// of buf if it is not nil.
+func (setup *proverSetup) withTrace(buf *Trace) *proverSetup {
+	res := *setup
+	res.trace = setup.trace.cloneInto(buf)
+	return &res
+}
+
+// cloneInto returns a deep copy of t, which reuses the memory of the
+// polynomials of buf if it is not nil. buf must be a copy of t.
+func (t *Trace) cloneInto(buf *Trace) *Trace {
+	res := t.shallowCopy()
+	src, dst := t.polynomials(), res.polynomials()
+	var bufs []**iop.Polynomial
+	if buf != nil {
+		bufs = buf.polynomials()
+	}
+	for i := range src {
+		var b *iop.Polynomial
+		if bufs != nil {
+			b = *bufs[i]
+		}
+		*dst[i] = clonePolynomial(*src[i], b)
+	}
+	return res
+}
+
+// shallowCopy returns a trace with the same permutation as t, and nil
+// polynomials.
+func (t *Trace) shallowCopy() *Trace {
+	return &Trace{
+		Qcp:    make([]*iop.Polynomial, len(t.Qcp)),
+		Qcg:    make([]*iop.Polynomial, len(t.Qcg)),
+		Lookup: make([]*iop.Polynomial, len(t.Lookup)),
+		S:      t.S, // read only
+	}
+}
+
+// polynomials returns pointers to the polynomials of the trace
+func (t *Trace) polynomials() []**iop.Polynomial {
+	res := []**iop.Polynomial{&t.Ql, &t.Qr, &t.Qm, &t.Qo, &t.Qk, &t.S1, &t.S2, &t.S3}
+	for i := range t.Qcp {
+		res = append(res, &t.Qcp[i])
+	}
+	for i := range t.Qcg {
+		res = append(res, &t.Qcg[i])
+	}
+	for i := range t.Lookup {
+		res = append(res, &t.Lookup[i])
+	}
+	return res
+}
+
+// clonePolynomial returns a deep copy of p, in the memory of buf if it is not nil.
+func clonePolynomial(p, buf *iop.Polynomial) *iop.Polynomial {
+	if buf == nil {
+		return p.Clone()
+	}
+	coefficients := append(buf.Coefficients()[:0], p.Coefficients()...)
+	return iop.NewPolynomial(&coefficients, p.Form)
+}
+
+// spillTrace copies the trace in the arena, the trace of the setup is not
+// modified.
+func (s *instance) spillTrace() (err error) {
+	res := s.trace.shallowCopy()
+	src, dst := s.trace.polynomials(), res.polynomials()
+	for i := range src {
+		if *dst[i], err = s.clone(*src[i]); err != nil {
+			return err
+		}
+	}
+	s.trace = res
+	return nil
+}
+
+// alloc returns a zeroed vector of size n, in the arena in low memory mode.
+func (s *instance) alloc(n int) ([]fr.Element, error) {
+	return mmap.Alloc[fr.Element](s.arena, n)
+}
+
+// free releases v, returned by alloc, in low memory mode.
+func (s *instance) free(v []fr.Element) {
+	// v is not used anymore, an error only leaks its memory
+	_ = mmap.Free(s.arena, v)
+}
+
+// clone returns a copy of p, in the arena in low memory mode. p must not be
+// shifted.
+func (s *instance) clone(p *iop.Polynomial) (*iop.Polynomial, error) {
+	if s.arena == nil {
+		return p.Clone(), nil
+	}
+	c, err := s.alloc(len(p.Coefficients()))
+	if err != nil {
+		return nil, err
+	}
+	copy(c, p.Coefficients())
+	return iop.NewPolynomial(&c, p.Form), nil
+}
+
+// spill returns p, or a copy of p in the arena in low memory mode so that the
+// memory of p can be released.
+func (s *instance) spill(p *iop.Polynomial) (*iop.Polynomial, error) {
+	if s.arena == nil {
+		return p, nil
+	}
+	return s.clone(p)
+}
+
+// idQcg returns the index in x of the selector of the i-th custom gate.
+func (s *instance) idQcg(i int) int {
+	return id_Qci + 2*len(s.commitmentInfo) + i
+}
+
+// idLookup returns the index in x of the i-th polynomial of the lookup
+// argument, see lookup_Qlk and lookup_M.
+func (s *instance) idLookup(i int) int {
+	return s.idQcg(len(s.trace.Qcg)) + i
+}
+
+func (s *instance) hasLookups() bool {
+	return len(s.trace.Lookup) != 0
+}
+
+// idLROShifted returns the index in x of l(ωX), r(ωX), o(ωX) for i = 0, 1, 2,
+// if a custom gate uses the next row.
+func (s *instance) idLROShifted(i int) int {
+	res := s.idLookup(0)
+	if s.hasLookups() {
+		res += nb_lookup_ids
+	}
+	return res + i
+}
+
+func (s *instance) usesNextRow() bool {
+	return s.pk.Vk.usesNextRow()
 }
 
 func (s *instance) initBlindingPolynomials() error {
-	s.bp[id_Bl] = getRandomPolynomial(order_blinding_L)
-	s.bp[id_Br] = getRandomPolynomial(order_blinding_R)
-	s.bp[id_Bo] = getRandomPolynomial(order_blinding_O)
+	if s.opt.NoZeroKnowledge {
+		for i := range s.bp {
+			s.bp[i] = getRandomPolynomial(-1)
+		}
+		close(s.chbp)
+		return nil
+	}
+	if s.usesNextRow() {
+		s.bp[id_Bl] = getRandomPolynomial(order_blinding_LRO_shifted)
+		s.bp[id_Br] = getRandomPolynomial(order_blinding_LRO_shifted)
+		s.bp[id_Bo] = getRandomPolynomial(order_blinding_LRO_shifted)
+	} else {
+		s.bp[id_Bl] = getRandomPolynomial(order_blinding_L)
+		s.bp[id_Br] = getRandomPolynomial(order_blinding_R)
+		s.bp[id_Bo] = getRandomPolynomial(order_blinding_O)
+	}
 	s.bp[id_Bz] = getRandomPolynomial(order_blinding_Z)
+	s.bp[id_Bm] = getRandomPolynomial(order_blinding_M)
+	s.bp[id_Bphi] = getRandomPolynomial(order_blinding_Phi)
 	close(s.chbp)
 	return nil
 }
@@ -297,16 +654,21 @@ func (s *instance) bsb22Hint(_ *big.Int, ins, outs []*big.Int) error {
 	res := &s.commitmentVal[commDepth]
 
 	commitmentInfo := s.spr.CommitmentInfo.(constraint.PlonkCommitments)[commDepth]
-	committedValues := make([]fr.Element, s.domain0.Cardinality)
+	committedValues, err := s.alloc(int(s.domain0.Cardinality))
+	if err != nil {
+		return err
+	}
 	offset := s.spr.GetNbPublicVariables()
 	for i := range ins {
 		committedValues[offset+commitmentInfo.Committed[i]].SetBigInt(ins[i])
 	}
-	if _, err = committedValues[offset+commitmentInfo.CommitmentIndex].SetRandom(); err != nil { // Commitment injection constraint has qcp = 0. Safe to use for blinding.
-		return err
-	}
-	if _, err = committedValues[offset+s.spr.GetNbConstraints()-1].SetRandom(); err != nil { // Last constraint has qcp = 0. Safe to use for blinding
-		return err
+	if !s.opt.NoZeroKnowledge {
+		if _, err = committedValues[offset+commitmentInfo.CommitmentIndex].SetRandom(); err != nil { // Commitment injection constraint has qcp = 0. Safe to use for blinding.
+			return err
+		}
+		if _, err = committedValues[offset+s.spr.GetNbConstraints()-1].SetRandom(); err != nil { // Last constraint has qcp = 0. Safe to use for blinding
+			return err
+		}
 	}
 	s.cCommitments[commDepth] = iop.NewPolynomial(&committedValues, iop.Form{Basis: iop.Lagrange, Layout: iop.Regular})
 	if s.proof.Bsb22Commitments[commDepth], err = kzg.Commit(s.cCommitments[commDepth].Coefficients(), s.pk.KzgLagrange); err != nil {
@@ -326,9 +688,25 @@ func (s *instance) bsb22Hint(_ *big.Int, ins, outs []*big.Int) error {
 	return nil
 }
 
-// solveConstraints computes the evaluation of the polynomials L, R, O
-// and sets x[id_L], x[id_R], x[id_O] in Lagrange form
+// solveConstraints solves the constraints if needed, and commits to l, r, o
 func (s *instance) solveConstraints() error {
+	if !s.solved {
+		if err := s.solve(); err != nil {
+			return err
+		}
+	}
+
+	// commit to l, r, o and add blinding factors
+	if err := s.commitToLRO(); err != nil {
+		return err
+	}
+	close(s.chLRO)
+	return nil
+}
+
+// solve computes the evaluation of the polynomials L, R, O
+// and sets x[id_L], x[id_R], x[id_O] in Lagrange form
+func (s *instance) solve() error {
 	_solution, err := s.spr.Solve(s.fullWitness, s.opt.SolverOpts...)
 	if err != nil {
 		return err
@@ -352,16 +730,22 @@ func (s *instance) solveConstraints() error {
 
 	wg.Wait()
 
-	// commit to l, r, o and add blinding factors
-	if err := s.commitToLRO(); err != nil {
-		return err
+	// in low memory mode, the solution is moved to the arena
+	for _, id := range []int{id_L, id_R, id_O} {
+		if s.x[id], err = s.spill(s.x[id]); err != nil {
+			return err
+		}
 	}
-	close(s.chLRO)
+	s.solved = true
+
 	return nil
 }
 
 func (s *instance) completeQk() error {
-	qk := s.trace.Qk.Clone()
+	qk, err := s.clone(s.trace.Qk)
+	if err != nil {
+		return err
+	}
 	qkCoeffs := qk.Coefficients()
 
 	wWitness, ok := s.fullWitness.Vector().(fr.Vector)
@@ -434,7 +818,18 @@ func (s *instance) deriveGammaAndBeta() error {
 	case <-s.chLRO:
 	}
 
-	gamma, err := deriveRandomness(s.fs, "gamma", &s.proof.LRO[0], &s.proof.LRO[1], &s.proof.LRO[2])
+	gammaDeps := []*curve.G1Affine{&s.proof.LRO[0], &s.proof.LRO[1], &s.proof.LRO[2]}
+	if s.hasLookups() {
+		// wait for the multiplicities to be committed
+		select {
+		case <-s.ctx.Done():
+			return errContextDone
+		case <-s.chM:
+		}
+		gammaDeps = append(gammaDeps, &s.proof.Lookup[0])
+	}
+
+	gamma, err := deriveRandomness(s.fs, "gamma", gammaDeps...)
 	if err != nil {
 		return err
 	}
@@ -446,6 +841,15 @@ func (s *instance) deriveGammaAndBeta() error {
 	s.gamma = gamma
 	s.beta.SetBytes(bbeta)
 
+	if s.hasLookups() {
+		if s.eta, err = deriveRandomness(s.fs, "eta"); err != nil {
+			return err
+		}
+		if s.lambda, err = deriveRandomness(s.fs, "lambda"); err != nil {
+			return err
+		}
+	}
+
 	close(s.chGammaBeta)
 
 	return nil
@@ -473,6 +877,9 @@ func (s *instance) deriveAlpha() (err error) {
 		alphaDeps[i] = &s.proof.Bsb22Commitments[i]
 	}
 	alphaDeps[len(alphaDeps)-1] = &s.proof.Z
+	if s.hasLookups() {
+		alphaDeps = append(alphaDeps, &s.proof.Lookup[1])
+	}
 	s.alpha, err = deriveRandomness(s.fs, "alpha", alphaDeps...)
 	return err
 }
@@ -495,9 +902,18 @@ func (s *instance) computeQuotient() (err error) {
 	for i := 0; i < len(s.commitmentInfo); i++ {
 		s.x[id_Qci+2*i] = s.trace.Qcp[i]
 	}
+	for i := range s.trace.Qcg {
+		s.x[s.idQcg(i)] = s.trace.Qcg[i]
+	}
+	for i := range s.trace.Lookup {
+		s.x[s.idLookup(i)] = s.trace.Lookup[i]
+	}
 
 	n := s.domain0.Cardinality
-	lone := make([]fr.Element, n)
+	lone, err := s.alloc(int(n))
+	if err != nil {
+		return err
+	}
 	lone[0].SetOne()
 
 	// wait for solver to be done
@@ -518,26 +934,46 @@ func (s *instance) computeQuotient() (err error) {
 	case <-s.chZ:
 	}
 
+	// wait for φ to be committed or context done
+	select {
+	case <-s.ctx.Done():
+		return errContextDone
+	case <-s.chPhi:
+	}
+
 	// derive alpha
 	if err = s.deriveAlpha(); err != nil {
 		return err
 	}
 
 	// TODO complete waste of memory find another way to do that
-	identity := make([]fr.Element, n)
+	identity, err := s.alloc(int(n))
+	if err != nil {
+		return err
+	}
 	identity[1].Set(&s.beta)
 
 	s.x[id_ID] = iop.NewPolynomial(&identity, iop.Form{Basis: iop.Canonical, Layout: iop.Regular})
 	s.x[id_LOne] = iop.NewPolynomial(&lone, iop.Form{Basis: iop.Lagrange, Layout: iop.Regular})
 	s.x[id_ZS] = s.x[id_Z].ShallowClone().Shift(1)
+	if s.hasLookups() {
+		s.x[s.idLookup(lookup_PhiS)] = s.x[s.idLookup(lookup_Phi)].ShallowClone().Shift(1)
+	}
+	if s.usesNextRow() {
+		for i, id := range [3]int{id_L, id_R, id_O} {
+			s.x[s.idLROShifted(i)] = s.x[id].ShallowClone().Shift(1)
+		}
+	}
 
 	numerator, err := s.computeNumerator()
 	if err != nil {
 		return err
 	}
 
-	s.h, err = divideByZH(numerator, [2]*fft.Domain{s.domain0, s.domain1})
-	if err != nil {
+	if s.arena != nil {
+		// the numerator was divided by Z_H and interpolated coset by coset
+		s.h = numerator
+	} else if s.h, err = divideByZH(numerator, [2]*fft.Domain{s.domain0, s.domain1}); err != nil {
 		return err
 	}
 
@@ -587,6 +1023,9 @@ func (s *instance) buildRatioCopyConstraint() (err error) {
 	if err != nil {
 		return err
 	}
+	if s.x[id_Z], err = s.spill(s.x[id_Z]); err != nil {
+		return err
+	}
 
 	// commit to the blinded version of z
 	s.proof.Z, err = s.commitToPolyAndBlinding(s.x[id_Z], s.bp[id_Bz])
@@ -596,7 +1035,110 @@ func (s *instance) buildRatioCopyConstraint() (err error) {
 	return
 }
 
-// open Z (blinded) at ωζ
+// buildLookupRunningSum computes and commits to the multiplicities m of the
+// rows of the lookup tables, then to the running sum φ of the log-derivative
+// argument, such that φ(1) = 0 and
+//
+//	φ(ωX) = φ(X) + qlk(X)/(λ+f(X)) - m(X)/(λ+t(X))
+//
+// where f = qtag + η*l + η²*r + η³*o and t = ttag + η*t₀ + η²*t₁ + η³*t₂.
+func (s *instance) buildLookupRunningSum() (err error) {
+	if !s.hasLookups() {
+		close(s.chM)
+		close(s.chPhi)
+		return nil
+	}
+
+	// wait for the solver to be done
+	select {
+	case <-s.ctx.Done():
+		return errContextDone
+	case <-s.chLRO:
+	}
+
+	n := int(s.domain0.Cardinality)
+	l := s.x[id_L].Coefficients()
+	r := s.x[id_R].Coefficients()
+	o := s.x[id_O].Coefficients()
+	lk := coefficients(s.trace.Lookup)
+
+	// the padding rows of the tables have a zero tag and are never looked up
+	rows := make(map[[4]fr.Element]int)
+	for j := 0; j < n; j++ {
+		if lk[lookup_Ttag][j].IsZero() {
+			continue
+		}
+		row := [4]fr.Element{lk[lookup_Ttag][j], lk[lookup_T0][j], lk[lookup_T1][j], lk[lookup_T2][j]}
+		if _, ok := rows[row]; !ok {
+			rows[row] = j
+		}
+	}
+	one := fr.One()
+	m, err := s.alloc(n)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if lk[lookup_Qlk][i].IsZero() {
+			continue
+		}
+		j, ok := rows[[4]fr.Element{lk[lookup_Qtag][i], l[i], r[i], o[i]}]
+		if !ok {
+			return fmt.Errorf("lookup of constraint %d is not in the table", i)
+		}
+		m[j].Add(&m[j], &one)
+	}
+	s.x[s.idLookup(lookup_M)] = iop.NewPolynomial(&m, iop.Form{Basis: iop.Lagrange, Layout: iop.Regular})
+	if s.proof.Lookup[0], err = s.commitToPolyAndBlinding(s.x[s.idLookup(lookup_M)], s.bp[id_Bm]); err != nil {
+		return err
+	}
+	close(s.chM)
+
+	// wait for η and λ to be derived (or ctx.Done())
+	select {
+	case <-s.ctx.Done():
+		return errContextDone
+	case <-s.chGammaBeta:
+	}
+
+	// [λ+f₀, .., λ+fₙ₋₁, λ+t₀, .., λ+tₙ₋₁]
+	dens, err := s.alloc(2 * n)
+	if err != nil {
+		return err
+	}
+	utils.Parallelize(n, func(start, end int) {
+		for i := start; i < end; i++ {
+			dens[i] = lookupCompress(s.eta, &lk[lookup_Qtag][i], &l[i], &r[i], &o[i])
+			dens[i].Add(&dens[i], &s.lambda)
+			dens[n+i] = lookupCompress(s.eta, &lk[lookup_Ttag][i], &lk[lookup_T0][i], &lk[lookup_T1][i], &lk[lookup_T2][i])
+			dens[n+i].Add(&dens[n+i], &s.lambda)
+		}
+	})
+	invDens := fr.BatchInvert(dens)
+	s.free(dens)
+
+	phi, err := s.alloc(n)
+	if err != nil {
+		return err
+	}
+	var t fr.Element
+	for i := 0; i < n-1; i++ {
+		t.Mul(&lk[lookup_Qlk][i], &invDens[i])
+		phi[i+1].Add(&phi[i], &t)
+		t.Mul(&m[i], &invDens[n+i])
+		phi[i+1].Sub(&phi[i+1], &t)
+	}
+	s.x[s.idLookup(lookup_Phi)] = iop.NewPolynomial(&phi, iop.Form{Basis: iop.Lagrange, Layout: iop.Regular})
+	if s.proof.Lookup[1], err = s.commitToPolyAndBlinding(s.x[s.idLookup(lookup_Phi)], s.bp[id_Bphi]); err != nil {
+		return err
+	}
+	close(s.chPhi)
+
+	return nil
+}
+
+// open Z (blinded) at ωζ, φ (blinded) if the circuit has lookups, and l, r, o
+// (blinded) if a custom gate uses the next row
 func (s *instance) openZ() (err error) {
 	// wait for H to be committed and zeta to be derived (or ctx.Done())
 	select {
@@ -612,42 +1154,88 @@ func (s *instance) openZ() (err error) {
 	if err != nil {
 		return err
 	}
+	if s.hasLookups() {
+		s.blindedPhi = getBlindedCoefficients(s.x[s.idLookup(lookup_Phi)], s.bp[id_Bphi])
+		s.proof.LookupShiftedOpening, err = kzg.Open(s.blindedPhi, zetaShifted, s.pk.Kzg)
+		if err != nil {
+			return err
+		}
+	}
+	if s.usesNextRow() {
+		// l, r, o are also evaluated at ζ while they are opened, their blinded
+		// versions are built in new vectors
+		for i, id := range [3]int{id_L, id_R, id_O} {
+			p := s.x[id].Coefficients()
+			bp := s.bp[id_Bl+i]
+			c, err := s.alloc(len(p) + bp.Size())
+			if err != nil {
+				return err
+			}
+			c = c[:len(p)]
+			copy(c, p)
+			s.blindedLRO[i] = getBlindedCoefficients(iop.NewPolynomial(&c, s.x[id].Form), bp)
+		}
+		s.proof.LROShiftedOpening, err = kzg.BatchOpenSinglePoint(
+			s.blindedLRO[:],
+			s.proof.LRO[:],
+			zetaShifted,
+			s.kzgFoldingHash,
+			s.pk.Kzg,
+		)
+		if err != nil {
+			return err
+		}
+	}
 	close(s.chZOpening)
 	return nil
 }
 
+// shardSize returns the size m of the shards of the quotient h = h1 + Xᵐ*h2 + X²ᵐ*h3,
+// which is n+2 as h is of degree 3(n+1)+2, or n without blinding, times the
+// shard factor of the custom gates of high degree.
+func (s *instance) shardSize() uint64 {
+	k := s.pk.Vk.quotientShardFactor()
+	if s.opt.NoZeroKnowledge {
+		return k * s.domain0.Cardinality
+	}
+	return k * (s.domain0.Cardinality + 2)
+}
+
 func (s *instance) h1() []fr.Element {
+	m := s.shardSize()
 	var h1 []fr.Element
 	if !s.opt.StatisticalZK {
-		h1 = s.h.Coefficients()[:s.domain0.Cardinality+2]
+		h1 = s.h.Coefficients()[:m]
 	} else {
-		h1 = make([]fr.Element, s.domain0.Cardinality+3)
-		copy(h1, s.h.Coefficients()[:s.domain0.Cardinality+2])
-		h1[s.domain0.Cardinality+2].Set(&s.quotientShardsRandomizers[0])
+		h1 = make([]fr.Element, m+1)
+		copy(h1, s.h.Coefficients()[:m])
+		h1[m].Set(&s.quotientShardsRandomizers[0])
 	}
 	return h1
 }
 
 func (s *instance) h2() []fr.Element {
+	m := s.shardSize()
 	var h2 []fr.Element
 	if !s.opt.StatisticalZK {
-		h2 = s.h.Coefficients()[s.domain0.Cardinality+2 : 2*(s.domain0.Cardinality+2)]
+		h2 = s.h.Coefficients()[m : 2*m]
 	} else {
-		h2 = make([]fr.Element, s.domain0.Cardinality+3)
-		copy(h2, s.h.Coefficients()[s.domain0.Cardinality+2:2*(s.domain0.Cardinality+2)])
+		h2 = make([]fr.Element, m+1)
+		copy(h2, s.h.Coefficients()[m:2*m])
 		h2[0].Sub(&h2[0], &s.quotientShardsRandomizers[0])
-		h2[s.domain0.Cardinality+2].Set(&s.quotientShardsRandomizers[1])
+		h2[m].Set(&s.quotientShardsRandomizers[1])
 	}
 	return h2
 }
 
 func (s *instance) h3() []fr.Element {
+	m := s.shardSize()
 	var h3 []fr.Element
 	if !s.opt.StatisticalZK {
-		h3 = s.h.Coefficients()[2*(s.domain0.Cardinality+2) : 3*(s.domain0.Cardinality+2)]
+		h3 = s.h.Coefficients()[2*m : 3*m]
 	} else {
-		h3 = make([]fr.Element, s.domain0.Cardinality+2)
-		copy(h3, s.h.Coefficients()[2*(s.domain0.Cardinality+2):3*(s.domain0.Cardinality+2)])
+		h3 = make([]fr.Element, m)
+		copy(h3, s.h.Coefficients()[2*m:3*m])
 		h3[0].Sub(&h3[0], &s.quotientShardsRandomizers[1])
 	}
 	return h3
@@ -714,6 +1302,10 @@ func (s *instance) computeLinearizedPolynomial() error {
 		s.pk,
 	)
 
+	// the quotient is not needed anymore
+	s.free(s.h.Coefficients())
+	s.h = nil
+
 	var err error
 	s.linearizedPolynomialDigest, err = kzg.Commit(s.linearizedPolynomial, s.pk.Kzg, runtime.NumCPU()*2)
 	if err != nil {
@@ -737,9 +1329,13 @@ func (s *instance) batchOpening() error {
 	copy(polysToOpen[6:], polysQcp)
 
 	polysToOpen[0] = s.linearizedPolynomial
-	polysToOpen[1] = getBlindedCoefficients(s.x[id_L], s.bp[id_Bl])
-	polysToOpen[2] = getBlindedCoefficients(s.x[id_R], s.bp[id_Br])
-	polysToOpen[3] = getBlindedCoefficients(s.x[id_O], s.bp[id_Bo])
+	if s.usesNextRow() {
+		copy(polysToOpen[1:4], s.blindedLRO[:])
+	} else {
+		polysToOpen[1] = getBlindedCoefficients(s.x[id_L], s.bp[id_Bl])
+		polysToOpen[2] = getBlindedCoefficients(s.x[id_R], s.bp[id_Br])
+		polysToOpen[3] = getBlindedCoefficients(s.x[id_O], s.bp[id_Bo])
+	}
 	polysToOpen[4] = s.trace.S1.Coefficients()
 	polysToOpen[5] = s.trace.S2.Coefficients()
 
@@ -753,6 +1349,16 @@ func (s *instance) batchOpening() error {
 	digestsToOpen[4] = s.pk.Vk.S[0]
 	digestsToOpen[5] = s.pk.Vk.S[1]
 
+	dataTranscript := [][]byte{s.proof.ZShiftedOpening.ClaimedValue.Marshal()}
+	if s.hasLookups() {
+		polysToOpen = append(polysToOpen, coefficients(s.trace.Lookup)...)
+		digestsToOpen = append(digestsToOpen, s.pk.Vk.Lookup...)
+		dataTranscript = append(dataTranscript, s.proof.LookupShiftedOpening.ClaimedValue.Marshal())
+	}
+	for i := range s.proof.LROShiftedOpening.ClaimedValues {
+		dataTranscript = append(dataTranscript, s.proof.LROShiftedOpening.ClaimedValues[i].Marshal())
+	}
+
 	var err error
 	s.proof.BatchedProof, err = kzg.BatchOpenSinglePoint(
 		polysToOpen,
@@ -760,7 +1366,7 @@ func (s *instance) batchOpening() error {
 		s.zeta,
 		s.kzgFoldingHash,
 		s.pk.Kzg,
-		s.proof.ZShiftedOpening.ClaimedValue.Marshal(),
+		dataTranscript...,
 	)
 
 	return err
@@ -795,10 +1401,16 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 	}
 
 	nbBsbGates := len(s.proof.Bsb22Commitments)
+	customGates := s.pk.Vk.CustomGates
+	usesNextRow := s.usesNextRow()
+	idLS, idRS, idOS := s.idLROShifted(0), s.idLROShifted(1), s.idLROShifted(2)
 
 	gateConstraint := func(u ...fr.Element) fr.Element {
 
-		var ic, tmp fr.Element
+		var ic, tmp, ls, rs, os fr.Element
+		if usesNextRow {
+			ls, rs, os = u[idLS], u[idRS], u[idOS]
+		}
 
 		ic.Mul(&u[id_Ql], &u[id_L])
 		tmp.Mul(&u[id_Qr], &u[id_R])
@@ -811,6 +1423,11 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 			tmp.Mul(&u[id_Qci+2*i], &u[id_Qci+2*i+1])
 			ic.Add(&ic, &tmp)
 		}
+		for i := range customGates {
+			tmp = customGates[i].Evaluate(u[id_L], u[id_R], u[id_O], ls, rs, os)
+			tmp.Mul(&tmp, &u[s.idQcg(i)])
+			ic.Add(&ic, &tmp)
+		}
 
 		return ic
 	}
@@ -848,6 +1465,36 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 		return res
 	}
 
+	hasLookups := s.hasLookups()
+	var shifted []int
+	idQlk, idQtag, idTtag, idT0, idT1, idT2 := s.idLookup(lookup_Qlk), s.idLookup(lookup_Qtag), s.idLookup(lookup_Ttag), s.idLookup(lookup_T0), s.idLookup(lookup_T1), s.idLookup(lookup_T2)
+	idM, idPhi, idPhiS := s.idLookup(lookup_M), s.idLookup(lookup_Phi), s.idLookup(lookup_PhiS)
+	if hasLookups {
+		shifted = append(shifted, idPhiS)
+	}
+	if usesNextRow {
+		shifted = append(shifted, idLS, idRS, idOS)
+	}
+
+	// (φ(ωX)-φ(X))*(λ+f(X))*(λ+t(X)) - qlk(X)*(λ+t(X)) + m(X)*(λ+f(X))
+	lookupConstraint := func(u ...fr.Element) fr.Element {
+
+		var res, tmp fr.Element
+
+		f := lookupCompress(s.eta, &u[idQtag], &u[id_L], &u[id_R], &u[id_O])
+		f.Add(&f, &s.lambda)
+		t := lookupCompress(s.eta, &u[idTtag], &u[idT0], &u[idT1], &u[idT2])
+		t.Add(&t, &s.lambda)
+
+		res.Sub(&u[idPhiS], &u[idPhi]).Mul(&res, &f).Mul(&res, &t)
+		tmp.Mul(&u[idQlk], &t)
+		res.Sub(&res, &tmp)
+		tmp.Mul(&u[idM], &f)
+		res.Add(&res, &tmp)
+
+		return res
+	}
+
 	rho := int(s.domain1.Cardinality / n)
 	shifters := make([]fr.Element, rho)
 	shifters[0].Set(&s.domain1.FrMultiplicativeGen)
@@ -868,9 +1515,19 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 		return nil, err
 	}
 
-	// init the result polynomial & buffer
-	cres := make([]fr.Element, s.domain1.Cardinality)
-	buf := make([]fr.Element, n)
+	// init the result polynomial & buffer. In low memory mode, the
+	// evaluations on each coset are written in place in cres, see
+	// interpolateCoset.
+	cres, err := s.alloc(int(s.domain1.Cardinality))
+	if err != nil {
+		return nil, err
+	}
+	var buf []fr.Element
+	if s.arena == nil {
+		if buf, err = s.alloc(int(n)); err != nil {
+			return nil, err
+		}
+	}
 	var wgBuf sync.WaitGroup
 
 	allConstraints := func(i int, u ...fr.Element) fr.Element {
@@ -894,9 +1551,32 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 		y = s.bp[id_Bz].Evaluate(twiddles0[(i+1)%int(n)])
 		u[id_ZS].Add(&u[id_ZS], &y)
 
+		if usesNextRow {
+			// blind LS, RS, OS, shifted by 1 as ZS
+			y = s.bp[id_Bl].Evaluate(twiddles0[(i+1)%int(n)])
+			u[idLS].Add(&u[idLS], &y)
+			y = s.bp[id_Br].Evaluate(twiddles0[(i+1)%int(n)])
+			u[idRS].Add(&u[idRS], &y)
+			y = s.bp[id_Bo].Evaluate(twiddles0[(i+1)%int(n)])
+			u[idOS].Add(&u[idOS], &y)
+		}
+
 		a := gateConstraint(u...)
 		b := orderingConstraint(u...)
 		c := ratioLocalConstraint(u...)
+		if hasLookups {
+			// blind M, Phi, PhiS
+			y = s.bp[id_Bm].Evaluate(twiddles0[i])
+			u[idM].Add(&u[idM], &y)
+			y = s.bp[id_Bphi].Evaluate(twiddles0[i])
+			u[idPhi].Add(&u[idPhi], &y)
+			y = s.bp[id_Bphi].Evaluate(twiddles0[(i+1)%int(n)])
+			u[idPhiS].Add(&u[idPhiS], &y)
+
+			d := lookupConstraint(u...)
+			d.Mul(&d, &s.alpha).Add(&d, &c)
+			c = d
+		}
 		c.Mul(&c, &s.alpha).Add(&c, &b).Mul(&c, &s.alpha).Add(&c, &a)
 		return c
 	}
@@ -940,7 +1620,7 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 
 		// we do **a lot** of FFT here, but on the small domain.
 		// note that for all the polynomials in the proving key
-		// (Ql, Qr, Qm, Qo, S1, S2, S3, Qcp, Qc) and ID, LOne
+		// (Ql, Qr, Qm, Qo, S1, S2, S3, Qcp, Qc, Qcg, Lookup) and ID, LOne
 		// we could pre-compute these rho*2 FFTs and store them
 		// at the cost of a huge memory footprint.
 		batchApply(s.x, func(p *iop.Polynomial) {
@@ -965,25 +1645,38 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 
 			// fft in the correct coset
 			p.ToLagrange(s.domain0, nbTasks).ToRegular()
-		})
-
-		wgBuf.Wait()
-		if _, err := iop.Evaluate(
-			allConstraints,
-			buf,
-			iop.Form{Basis: iop.Lagrange, Layout: iop.Regular},
-			s.x...,
-		); err != nil {
-			return nil, err
-		}
-		wgBuf.Add(1)
-		go func(i int) {
-			for j := 0; j < int(n); j++ {
-				// we build the polynomial in bit reverse order
-				cres[bits.Reverse64(uint64(rho*j+i))>>mm] = buf[j]
+		}, shifted...)
+
+		if s.arena != nil {
+			chunk := cres[i*int(n) : (i+1)*int(n)]
+			if _, err := iop.Evaluate(
+				allConstraints,
+				chunk,
+				iop.Form{Basis: iop.Lagrange, Layout: iop.Regular},
+				s.x...,
+			); err != nil {
+				return nil, err
 			}
-			wgBuf.Done()
-		}(i)
+			s.interpolateCoset(chunk, coset, tmp)
+		} else {
+			wgBuf.Wait()
+			if _, err := iop.Evaluate(
+				allConstraints,
+				buf,
+				iop.Form{Basis: iop.Lagrange, Layout: iop.Regular},
+				s.x...,
+			); err != nil {
+				return nil, err
+			}
+			wgBuf.Add(1)
+			go func(i int) {
+				for j := 0; j < int(n); j++ {
+					// we build the polynomial in bit reverse order
+					cres[bits.Reverse64(uint64(rho*j+i))>>mm] = buf[j]
+				}
+				wgBuf.Done()
+			}(i)
+		}
 
 		tmp.Inverse(&tmp)
 		// bl <- bl *( (s*ωⁱ)ⁿ-1 )s
@@ -996,11 +1689,21 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 	}
 
 	// scale everything back
+	s.background.Add(1)
 	go func() {
+		defer s.background.Done()
+		s.free(s.x[id_ID].Coefficients())
+		s.free(s.x[id_LOne].Coefficients())
 		s.x[id_ID] = nil
 		s.x[id_LOne] = nil
 		s.x[id_ZS] = nil
 		s.x[id_Qk] = nil
+		if hasLookups {
+			s.x[idPhiS] = nil
+		}
+		if usesNextRow {
+			s.x[idLS], s.x[idRS], s.x[idOS] = nil, nil, nil
+		}
 
 		var cs fr.Element
 		cs.Set(&shifters[0])
@@ -1015,7 +1718,7 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 			}
 			p.ToCanonical(s.domain0, 8).ToRegular()
 			scalePowers(p, cs)
-		})
+		}, shifted...)
 
 		for _, q := range s.bp {
 			scalePowers(q, cs)
@@ -1026,6 +1729,12 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 
 	// ensure all the goroutines are done
 	wgBuf.Wait()
+	s.free(buf)
+
+	if s.arena != nil {
+		s.combineCosets(cres)
+		return iop.NewPolynomial(&cres, iop.Form{Basis: iop.Canonical, Layout: iop.Regular}), nil
+	}
 
 	res := iop.NewPolynomial(&cres, iop.Form{Basis: iop.LagrangeCoset, Layout: iop.BitReverse})
 
@@ -1033,6 +1742,76 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 
 }
 
+// interpolateCoset divides in place the evaluations of the numerator on the
+// coset cH of the small domain H by Z_H(cH) = zh = cⁿ-1, and interpolates them,
+// so that chunk[r] = ∑ₖ h_{kn+r}*cᵏⁿ where h is the quotient. The quotient is
+// then recovered by combineCosets, once all the cosets are interpolated. Unlike
+// divideByZH, it only goes through vectors of size n.
+func (s *instance) interpolateCoset(chunk []fr.Element, c, zh fr.Element) {
+	var zhInv fr.Element
+	zhInv.Inverse(&zh)
+	utils.Parallelize(len(chunk), func(start, end int) {
+		for j := start; j < end; j++ {
+			chunk[j].Mul(&chunk[j], &zhInv)
+		}
+	})
+	s.domain0.FFTInverse(chunk, fft.DIF)
+	fft.BitReverse(chunk)
+
+	// the coefficients are those of h(cX) mod Xⁿ-1, unscale them by c⁻ʳ
+	var cInv fr.Element
+	cInv.Inverse(&c)
+	scalePowers(iop.NewPolynomial(&chunk, iop.Form{Basis: iop.Canonical, Layout: iop.Regular}), cInv)
+}
+
+// combineCosets recovers in place the coefficients of the quotient h from the
+// ρ chunks of size n of q computed by interpolateCoset on the cosets gωⁱH,
+// where g is the multiplicative generator and ω the generator of the large
+// domain. For every r, the i-th chunk holds ∑ₖ h_{kn+r}*βᵢᵏ with
+// βᵢ = gⁿ*(ωⁿ)ⁱ, so (h_{kn+r})ₖ is the inverse transform of size ρ of
+// (q[in+r])ᵢ on the coset gⁿ<ωⁿ>.
+func (s *instance) combineCosets(q []fr.Element) {
+	n := int(s.domain0.Cardinality)
+	rho := len(q) / n
+
+	// m[k][i] = (gⁿ)⁻ᵏ*(ωⁿ)⁻ⁱᵏ/ρ
+	var gn, wn, rhoInv fr.Element
+	bn := big.NewInt(int64(n))
+	gn.Exp(s.domain1.FrMultiplicativeGen, bn).Inverse(&gn)
+	wn.Exp(s.domain1.Generator, bn).Inverse(&wn)
+	rhoInv.SetUint64(uint64(rho)).Inverse(&rhoInv)
+	m := make([][]fr.Element, rho)
+	var gk fr.Element
+	gk.Set(&rhoInv)
+	for k := range m {
+		m[k] = make([]fr.Element, rho)
+		var wk fr.Element
+		wk.Exp(wn, big.NewInt(int64(k)))
+		m[k][0].Set(&gk)
+		for i := 1; i < rho; i++ {
+			m[k][i].Mul(&m[k][i-1], &wk)
+		}
+		gk.Mul(&gk, &gn)
+	}
+
+	utils.Parallelize(n, func(start, end int) {
+		d := make([]fr.Element, rho)
+		var t fr.Element
+		for r := start; r < end; r++ {
+			for i := range d {
+				d[i] = q[i*n+r]
+			}
+			for k := range m {
+				q[k*n+r].SetZero()
+				for i := range d {
+					t.Mul(&m[k][i], &d[i])
+					q[k*n+r].Add(&q[k*n+r], &t)
+				}
+			}
+		}
+	})
+}
+
 func calculateNbTasks(n int) int {
 	nbAvailableCPU := runtime.NumCPU() - n
 	if nbAvailableCPU < 0 {
@@ -1042,11 +1821,12 @@ func calculateNbTasks(n int) int {
 	return nbTasks
 }
 
-// batchApply executes fn on all polynomials in x except x[id_ZS] in parallel.
-func batchApply(x []*iop.Polynomial, fn func(*iop.Polynomial)) {
+// batchApply executes fn on all polynomials in x except x[id_ZS] and the other
+// shifted polynomials in parallel.
+func batchApply(x []*iop.Polynomial, fn func(*iop.Polynomial), shifted ...int) {
 	var wg sync.WaitGroup
 	for i := 0; i < len(x); i++ {
-		if i == id_ZS {
+		if i == id_ZS || slices.Contains(shifted, i) {
 			continue
 		}
 		wg.Add(1)
@@ -1114,6 +1894,9 @@ func getBlindedCoefficients(p, bp *iop.Polynomial) []fr.Element {
 func commitBlindingFactor(n int, b *iop.Polynomial, key kzg.ProvingKey) curve.G1Affine {
 	cp := b.Coefficients()
 	np := b.Size()
+	if np == 0 {
+		return curve.G1Affine{}
+	}
 
 	// lo
 	var tmp curve.G1Affine
@@ -1126,12 +1909,12 @@ func commitBlindingFactor(n int, b *iop.Polynomial, key kzg.ProvingKey) curve.G1
 	return res
 }
 
-// return a random polynomial of degree n, if n==-1 cancel the blinding
+// return a random polynomial of degree n, if n==-1 cancel the blinding: the
+// polynomial is empty
 func getRandomPolynomial(n int) *iop.Polynomial {
 	var a []fr.Element
 	if n == -1 {
-		a := make([]fr.Element, 1)
-		a[0].SetZero()
+		a = []fr.Element{}
 	} else {
 		a = make([]fr.Element, n+1)
 		for i := 0; i <= n; i++ {
@@ -1241,9 +2024,13 @@ func evaluateXnMinusOneDomainBigCoset(domains [2]*fft.Domain) []fr.Element {
 // α²*L₁(ζ)*Z(X)
 // + α*( (l(ζ)+β*s1(ζ)+γ)*(r(ζ)+β*s2(ζ)+γ)*(β*s3(X))*Z(μζ) - Z(X)*(l(ζ)+β*id1(ζ)+γ)*(r(ζ)+β*id2(ζ)+γ)*(o(ζ)+β*id3(ζ)+γ))
 // + l(ζ)*Ql(X) + l(ζ)r(ζ)*Qm(X) + r(ζ)*Qr(X) + o(ζ)*Qo(X) + Qk(X) + ∑ᵢQcp_(ζ)Pi_(X)
-// - Z_{H}(ζ)*((H₀(X) + ζᵐ⁺²*H₁(X) + ζ²⁽ᵐ⁺²⁾*H₂(X))
+// + ∑ᵢGᵢ(l(ζ), r(ζ), o(ζ), l(ωζ), r(ωζ), o(ωζ))*Qcg_i(X)
+// + α³*(λ+f(ζ))*(m(X) - (λ+t(ζ))*φ(X))
+// - Z_{H}(ζ)*((H₀(X) + ζᵐ*H₁(X) + ζ²ᵐ*H₂(X))
+//
+// where m is the size of the shards of the quotient, see shardSize.
 //
-// /!\ blindedZCanonical is modified
+// /!\ blindedZCanonical is modified, and extended if the shards are longer
 func (s *instance) innerComputeLinearizedPoly(lZeta, rZeta, oZeta, alpha, beta, gamma, zeta, zu fr.Element, qcpZeta, blindedZCanonical []fr.Element, pi2Canonical [][]fr.Element, pk *ProvingKey) []fr.Element {
 
 	// l(ζ)r(ζ)
@@ -1255,8 +2042,9 @@ func (s *instance) innerComputeLinearizedPoly(lZeta, rZeta, oZeta, alpha, beta,
 	// the linearised polynomial is
 	// α²*L₁(ζ)*Z(X) +
 	// s1*s3(X)+s2*Z(X) + l(ζ)*Ql(X) +
-	// l(ζ)r(ζ)*Qm(X) + r(ζ)*Qr(X) + o(ζ)*Qo(X) + Qk(X) + ∑ᵢQcp_(ζ)Pi_(X) -
-	// Z_{H}(ζ)*((H₀(X) + ζᵐ⁺²*H₁(X) + ζ²⁽ᵐ⁺²⁾*H₂(X))
+	// l(ζ)r(ζ)*Qm(X) + r(ζ)*Qr(X) + o(ζ)*Qo(X) + Qk(X) + ∑ᵢQcp_(ζ)Pi_(X) +
+	// ∑ᵢGᵢ(l(ζ), r(ζ), o(ζ), l(ωζ), r(ωζ), o(ωζ))*Qcg_i(X) -
+	// Z_{H}(ζ)*((H₀(X) + ζᵐ*H₁(X) + ζ²ᵐ*H₂(X))
 	var s1, s2 fr.Element
 	chS1 := make(chan struct{}, 1)
 	go func() {
@@ -1281,14 +2069,20 @@ func (s *instance) innerComputeLinearizedPoly(lZeta, rZeta, oZeta, alpha, beta,
 	s2.Mul(&s2, &tmp)                                           // (l(ζ)+β*ζ+γ)*(r(ζ)+β*u*ζ+γ)*(o(ζ)+β*u²*ζ+γ)
 	s2.Neg(&s2).Mul(&s2, &alpha)
 
-	// Z_h(ζ), ζⁿ⁺², L₁(ζ)*α²*Z
-	var zhZeta, zetaNPlusTwo, alphaSquareLagrangeZero, one, den, frNbElmt fr.Element
+	// Z_h(ζ), ζᵐ, L₁(ζ)*α²*Z
+	var zhZeta, zetaM, alphaSquareLagrangeZero, one, den, frNbElmt fr.Element
 	one.SetOne()
 	nbElmt := int64(s.domain0.Cardinality)
 	alphaSquareLagrangeZero.Set(&zeta).Exp(alphaSquareLagrangeZero, big.NewInt(nbElmt)) // ζⁿ
-	zetaNPlusTwo.Mul(&alphaSquareLagrangeZero, &zeta).Mul(&zetaNPlusTwo, &zeta)         // ζⁿ⁺²
-	alphaSquareLagrangeZero.Sub(&alphaSquareLagrangeZero, &one)                         // ζⁿ - 1
-	zhZeta.Set(&alphaSquareLagrangeZero)                                                // Z_h(ζ) = ζⁿ - 1
+	zetaM.Set(&alphaSquareLagrangeZero)
+	if !s.opt.NoZeroKnowledge {
+		zetaM.Mul(&zetaM, &zeta).Mul(&zetaM, &zeta) // ζⁿ⁺², or ζⁿ without blinding
+	}
+	if k := pk.Vk.quotientShardFactor(); k != 1 {
+		zetaM.Exp(zetaM, new(big.Int).SetUint64(k)) // ζᵐ = (ζⁿ⁺²)ᵏ for the custom gates of high degree
+	}
+	alphaSquareLagrangeZero.Sub(&alphaSquareLagrangeZero, &one) // ζⁿ - 1
+	zhZeta.Set(&alphaSquareLagrangeZero)                        // Z_h(ζ) = ζⁿ - 1
 	frNbElmt.SetUint64(uint64(nbElmt))
 	den.Sub(&zeta, &one).Inverse(&den)                           // 1/(ζ-1)
 	alphaSquareLagrangeZero.Mul(&alphaSquareLagrangeZero, &den). // L₁ = (ζⁿ - 1)/(ζ-1)
@@ -1298,6 +2092,32 @@ func (s *instance) innerComputeLinearizedPoly(lZeta, rZeta, oZeta, alpha, beta,
 
 	s3canonical := s.trace.S3.Coefficients()
 
+	// Gᵢ(l(ζ), r(ζ), o(ζ), l(ωζ), r(ωζ), o(ωζ)) for the custom gates
+	var shiftedLRO [3]fr.Element
+	copy(shiftedLRO[:], s.proof.LROShiftedOpening.ClaimedValues)
+	customGatesZeta := make([]fr.Element, len(pk.Vk.CustomGates))
+	for i := range customGatesZeta {
+		customGatesZeta[i] = pk.Vk.CustomGates[i].Evaluate(lZeta, rZeta, oZeta, shiftedLRO[0], shiftedLRO[1], shiftedLRO[2])
+	}
+	cqcg := coefficients(s.trace.Qcg)
+
+	// α³*(λ+f(ζ)) and -α³*(λ+f(ζ))*(λ+t(ζ)), the coefficients of m(X) and φ(X)
+	var coeffM, coeffPhi fr.Element
+	var blindedM []fr.Element
+	if s.hasLookups() {
+		var lk [nb_lookup_polynomials]fr.Element
+		for i := range lk {
+			lk[i] = s.trace.Lookup[i].Evaluate(zeta)
+		}
+		f := lookupCompress(s.eta, &lk[lookup_Qtag], &lZeta, &rZeta, &oZeta)
+		f.Add(&f, &s.lambda)
+		t := lookupCompress(s.eta, &lk[lookup_Ttag], &lk[lookup_T0], &lk[lookup_T1], &lk[lookup_T2])
+		t.Add(&t, &s.lambda)
+		coeffM.Square(&alpha).Mul(&coeffM, &alpha).Mul(&coeffM, &f)
+		coeffPhi.Mul(&coeffM, &t).Neg(&coeffPhi)
+		blindedM = getBlindedCoefficients(s.x[s.idLookup(lookup_M)], s.bp[id_Bm])
+	}
+
 	s.trace.Qk.ToCanonical(s.domain0).ToRegular()
 
 	// len(h1)=len(h2)=len(blindedZCanonical)=len(h3)+1 when Statistical ZK is activated
@@ -1306,6 +2126,11 @@ func (s *instance) innerComputeLinearizedPoly(lZeta, rZeta, oZeta, alpha, beta,
 	h2 := s.h2()
 	h3 := s.h3()
 
+	// the shards of the quotient are longer than Z with the custom gates of high degree
+	if len(h1) > len(blindedZCanonical) {
+		blindedZCanonical = append(blindedZCanonical, make([]fr.Element, len(h1)-len(blindedZCanonical))...)
+	}
+
 	// at this stage we have
 	// s1 =  α*(l(ζ)+β*s1(β)+γ)*(r(ζ)+β*s2(β)+γ)*β*Z(μζ)
 	// s2 = -α*(l(ζ)+β*ζ+γ)*(r(ζ)+β*u*ζ+γ)*(o(ζ)+β*u²*ζ+γ)
@@ -1339,6 +2164,19 @@ func (s *instance) innerComputeLinearizedPoly(lZeta, rZeta, oZeta, alpha, beta,
 					t0.Mul(&pi2Canonical[j][i], &qcpZeta[j])
 					t.Add(&t, &t0)
 				}
+				for j := range customGatesZeta { // linPol += ∑ᵢGᵢ(l(ζ), r(ζ), o(ζ), l(ωζ), r(ωζ), o(ωζ))*Qcg_i(X)
+					t0.Mul(&cqcg[j][i], &customGatesZeta[j])
+					t.Add(&t, &t0)
+				}
+			}
+
+			if i < len(blindedM) {
+				t0.Mul(&blindedM[i], &coeffM) // α³*(λ+f(ζ))*m(X)
+				t.Add(&t, &t0)
+			}
+			if i < len(s.blindedPhi) {
+				t0.Mul(&s.blindedPhi[i], &coeffPhi) // -α³*(λ+f(ζ))*(λ+t(ζ))*φ(X)
+				t.Add(&t, &t0)
 			}
 
 			t0.Mul(&blindedZCanonical[i], &alphaSquareLagrangeZero) // α²L₁(ζ)Z(X)
@@ -1347,18 +2185,18 @@ func (s *instance) innerComputeLinearizedPoly(lZeta, rZeta, oZeta, alpha, beta,
 			// if statistical zeroknowledge is deactivated, len(h1)=len(h2)=len(h3)=len(blindedZ)-1.
 			// Else len(h1)=len(h2)=len(blindedZCanonical)=len(h3)+1
 			if i < len(h3) {
-				t.Mul(&h3[i], &zetaNPlusTwo).
+				t.Mul(&h3[i], &zetaM).
 					Add(&t, &h2[i]).
-					Mul(&t, &zetaNPlusTwo).
+					Mul(&t, &zetaM).
 					Add(&t, &h1[i]).
 					Mul(&t, &zhZeta)
-				blindedZCanonical[i].Sub(&blindedZCanonical[i], &t) // linPol -= Z_h(ζ)*(H₀(X) + ζᵐ⁺²*H₁(X) + ζ²⁽ᵐ⁺²⁾*H₂(X))
+				blindedZCanonical[i].Sub(&blindedZCanonical[i], &t) // linPol -= Z_h(ζ)*(H₀(X) + ζᵐ*H₁(X) + ζ²ᵐ*H₂(X))
 			} else {
 				if s.opt.StatisticalZK {
-					t.Mul(&h2[i], &zetaNPlusTwo).
+					t.Mul(&h2[i], &zetaM).
 						Add(&t, &h1[i]).
 						Mul(&t, &zhZeta)
-					blindedZCanonical[i].Sub(&blindedZCanonical[i], &t) // linPol -= Z_h(ζ)*(H₀(X) + ζᵐ⁺²*H₁(X) + ζ²⁽ᵐ⁺²⁾*H₂(X))
+					blindedZCanonical[i].Sub(&blindedZCanonical[i], &t) // linPol -= Z_h(ζ)*(H₀(X) + ζᵐ*H₁(X) + ζ²ᵐ*H₂(X))
 				}
 			}
 		}
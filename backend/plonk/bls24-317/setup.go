@@ -22,9 +22,11 @@ import (
 	"github.com/consensys/gnark-crypto/ecc/bls24-317/fr/fft"
 	"github.com/consensys/gnark-crypto/ecc/bls24-317/fr/iop"
 	"github.com/consensys/gnark-crypto/ecc/bls24-317/kzg"
+	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/plonk/internal"
 	"github.com/consensys/gnark/constraint"
 	cs "github.com/consensys/gnark/constraint/bls24-317"
+	"github.com/consensys/gnark/internal/mmap"
 )
 
 // VerifyingKey stores the data needed to verify a proof:
@@ -54,6 +56,85 @@ type VerifyingKey struct {
 	Qcp                []kzg.Digest
 
 	CommitmentConstraintIndexes []uint64
+
+	// Commitments to the selectors of the custom gates and the custom gates
+	// polynomials. The i-th selector is one at the rows of the i-th custom
+	// gate and zero elsewhere.
+	Qcg         []kzg.Digest
+	CustomGates []CustomGate
+
+	// Commitments to the fixed polynomials of the lookup argument, indexed by
+	// lookup_Qlk, .., lookup_T2. Empty if the circuit has no lookup.
+	Lookup []kzg.Digest
+}
+
+// indices of the fixed polynomials of the lookup argument in Trace.Lookup and
+// VerifyingKey.Lookup. The lookup constraints enforce
+//
+//	qtag + η*l + η²*r + η³*o ∈ { ttag + η*t₀ + η²*t₁ + η³*t₂ }
+//
+// on the rows where qlk is one, where qtag and ttag are the index+1 of the
+// tables and t₀, t₁, t₂ the columns of the concatenated tables.
+const (
+	lookup_Qlk = iota
+	lookup_Qtag
+	lookup_Ttag
+	lookup_T0
+	lookup_T1
+	lookup_T2
+	nb_lookup_polynomials
+)
+
+// CustomGate is a custom gate polynomial
+//
+//	G(l, r, o, l', r', o') = ∑ᵢ Coefficients[i]*l^Exponents[i][0]*r^Exponents[i][1]*o^Exponents[i][2]*
+//	                               l'^Exponents[i][3]*r'^Exponents[i][4]*o'^Exponents[i][5]
+//
+// of total degree at most constraint.MaxCustomGateDegree, where l', r', o' are
+// the wires of the next row.
+type CustomGate struct {
+	Coefficients []fr.Element
+	Exponents    [][6]uint8
+}
+
+// degree returns the maximal total degree of the terms of G.
+func (g *CustomGate) degree() int {
+	res := 0
+	for _, e := range g.Exponents {
+		d := 0
+		for j := range e {
+			d += int(e[j])
+		}
+		res = max(res, d)
+	}
+	return res
+}
+
+// usesNextRow returns true if G depends on the wires of the next row.
+func (g *CustomGate) usesNextRow() bool {
+	for _, e := range g.Exponents {
+		if e[3] != 0 || e[4] != 0 || e[5] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate returns G(l, r, o, l', r', o'). l', r', o' are ignored if G does
+// not use the next row.
+func (g *CustomGate) Evaluate(l, r, o, ls, rs, os fr.Element) fr.Element {
+	var res, t fr.Element
+	wires := [6]fr.Element{l, r, o, ls, rs, os}
+	for i := range g.Coefficients {
+		t.Set(&g.Coefficients[i])
+		for j := range wires {
+			for k := uint8(0); k < g.Exponents[i][j]; k++ {
+				t.Mul(&t, &wires[j])
+			}
+		}
+		res.Add(&res, &t)
+	}
+	return res
 }
 
 // Trace stores a plonk trace as columns
@@ -66,6 +147,13 @@ type Trace struct {
 	Ql, Qr, Qm, Qo, Qk *iop.Polynomial
 	Qcp                []*iop.Polynomial
 
+	// Qcg are the selectors of the custom gates
+	Qcg []*iop.Polynomial
+
+	// Lookup are the fixed polynomials of the lookup argument, empty if the
+	// circuit has no lookup. See lookup_Qlk, .., lookup_T2.
+	Lookup []*iop.Polynomial
+
 	// Polynomials representing the splitted permutation. The full permutation's support is 3*N where N=nb wires.
 	// The set of interpolation is <g> of size N, so to represent the permutation S we let S acts on the
 	// set A=(<g>, u*<g>, u^{2}*<g>) of size 3*N, where u is outside <g> (its use is to shift the set <g>).
@@ -83,9 +171,15 @@ type ProvingKey struct {
 
 	// Verifying Key is embedded into the proving key (needed by Prove)
 	Vk *VerifyingKey
+
+	mapped *mmap.File // memory mapping of a key returned by Open
 }
 
-func Setup(spr *cs.SparseR1CS, srs, srsLagrange kzg.SRS) (*ProvingKey, *VerifyingKey, error) {
+func Setup(spr *cs.SparseR1CS, srs, srsLagrange kzg.SRS, opts ...backend.SetupOption) (*ProvingKey, *VerifyingKey, error) {
+	cfg, err := backend.NewSetupConfig(opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("setup config: %w", err)
+	}
 
 	var pk ProvingKey
 	var vk VerifyingKey
@@ -98,9 +192,22 @@ func Setup(spr *cs.SparseR1CS, srs, srsLagrange kzg.SRS) (*ProvingKey, *Verifyin
 		return nil, nil, fmt.Errorf("circuit has only %d constraints; unsupported by the current implementation", len(spr.Public)+spr.GetNbConstraints())
 	}
 
-	// check the size of the kzg srs.
-	if len(srs.Pk.G1) < (int(domain.Cardinality) + 3) { // + 3 for the kzg.Open of blinded poly
-		return nil, nil, fmt.Errorf("kzg srs is too small: got %d, need %d", len(srs.Pk.G1), domain.Cardinality+3)
+	// the lookup tables are interpolated on the same domain as the constraints
+	if nbRows := nbLookupRows(spr); nbRows > int(domain.Cardinality) {
+		return nil, nil, fmt.Errorf("lookup tables have %d rows, larger than the domain size %d", nbRows, domain.Cardinality)
+	}
+
+	// check the size of the kzg srs: + 3 for the kzg.Open of blinded poly,
+	// unless the key is only used without zero knowledge, times the shard
+	// factor of the quotient for the custom gates of high degree or using the
+	// next row
+	k := spr.GetQuotientShardFactor()
+	nbG1 := k*(int(domain.Cardinality)+2) + 1
+	if cfg.NoZeroKnowledge {
+		nbG1 = k * int(domain.Cardinality)
+	}
+	if len(srs.Pk.G1) < nbG1 {
+		return nil, nil, fmt.Errorf("kzg srs is too small: got %d, need %d", len(srs.Pk.G1), nbG1)
 	}
 
 	// same for the lagrange form
@@ -115,9 +222,10 @@ func Setup(spr *cs.SparseR1CS, srs, srsLagrange kzg.SRS) (*ProvingKey, *Verifyin
 	vk.Generator.Set(&domain.Generator)
 	vk.NbPublicVariables = uint64(len(spr.Public))
 
-	pk.Kzg.G1 = srs.Pk.G1[:int(vk.Size)+3]
+	pk.Kzg.G1 = srs.Pk.G1[:min(len(srs.Pk.G1), k*(int(vk.Size)+2)+1)]
 	pk.KzgLagrange.G1 = srsLagrange.Pk.G1
 	vk.Kzg = srs.Vk
+	vk.CustomGates = customGates(spr)
 
 	// step 2: ql, qr, qm, qo, qk, qcp in Lagrange Basis
 	// step 3: build the permutation and build the polynomials S1, S2, S3 to encode the permutation.
@@ -135,6 +243,28 @@ func Setup(spr *cs.SparseR1CS, srs, srsLagrange kzg.SRS) (*ProvingKey, *Verifyin
 	return &pk, &vk, nil
 }
 
+// quotientShardFactor returns the factor k such that the shards of the quotient
+// are of size k*(n+2), or k*n without zero knowledge, see
+// constraint.QuotientShardFactor.
+func (vk *VerifyingKey) quotientShardFactor() uint64 {
+	degree := 0
+	for i := range vk.CustomGates {
+		degree = max(degree, vk.CustomGates[i].degree())
+	}
+	return uint64(constraint.QuotientShardFactor(degree, vk.usesNextRow()))
+}
+
+// usesNextRow returns true if a custom gate depends on the wires of the next
+// row. l, r, o are then also opened at ωζ, see Proof.LROShiftedOpening.
+func (vk *VerifyingKey) usesNextRow() bool {
+	for i := range vk.CustomGates {
+		if vk.CustomGates[i].usesNextRow() {
+			return true
+		}
+	}
+	return false
+}
+
 // NbPublicWitness returns the expected public witness size (number of field elements)
 func (vk *VerifyingKey) NbPublicWitness() int {
 	return int(vk.NbPublicVariables)
@@ -162,6 +292,18 @@ func NewTrace(spr *cs.SparseR1CS, domain *fft.Domain) *Trace {
 	qo := make([]fr.Element, size)
 	qk := make([]fr.Element, size)
 	qcp := make([][]fr.Element, len(commitmentInfo))
+	qcg := make([][]fr.Element, len(spr.GetCustomGates()))
+	for i := range qcg {
+		qcg[i] = make([]fr.Element, size)
+	}
+	tables := spr.GetLookupTables()
+	var lookup [][]fr.Element
+	if len(tables) != 0 {
+		lookup = make([][]fr.Element, nb_lookup_polynomials)
+		for i := range lookup {
+			lookup[i] = make([]fr.Element, size)
+		}
+	}
 
 	for i := 0; i < len(spr.Public); i++ { // placeholders (-PUB_INPUT_i + qk_i = 0) TODO should return error if size is inconsistent
 		ql[i].SetOne().Neg(&ql[i])
@@ -180,9 +322,29 @@ func NewTrace(spr *cs.SparseR1CS, domain *fft.Domain) *Trace {
 		qm[offset+j].Set(&spr.Coefficients[c.QM])
 		qo[offset+j].Set(&spr.Coefficients[c.QO])
 		qk[offset+j].Set(&spr.Coefficients[c.QC])
+		if c.CustomGate != 0 {
+			qcg[c.CustomGate-1][offset+j].SetOne()
+		}
+		if c.Lookup != 0 {
+			lookup[lookup_Qlk][offset+j].SetOne()
+			lookup[lookup_Qtag][offset+j].SetUint64(uint64(c.Lookup))
+		}
 		j++
 	}
 
+	// concatenate the tables, the remaining rows are zero and can not match
+	// a lookup since the tags start at one.
+	row := 0
+	for _, t := range tables {
+		for _, r := range t.Rows {
+			lookup[lookup_Ttag][row].SetUint64(uint64(t.Index + 1))
+			lookup[lookup_T0][row].Set(&spr.Coefficients[r[0]])
+			lookup[lookup_T1][row].Set(&spr.Coefficients[r[1]])
+			lookup[lookup_T2][row].Set(&spr.Coefficients[r[2]])
+			row++
+		}
+	}
+
 	lagReg := iop.Form{Basis: iop.Lagrange, Layout: iop.Regular}
 
 	trace.Ql = iop.NewPolynomial(&ql, lagReg)
@@ -200,6 +362,16 @@ func NewTrace(spr *cs.SparseR1CS, domain *fft.Domain) *Trace {
 		trace.Qcp[i] = iop.NewPolynomial(&qcp[i], lagReg)
 	}
 
+	trace.Qcg = make([]*iop.Polynomial, len(qcg))
+	for i := range qcg {
+		trace.Qcg[i] = iop.NewPolynomial(&qcg[i], lagReg)
+	}
+
+	trace.Lookup = make([]*iop.Polynomial, len(lookup))
+	for i := range lookup {
+		trace.Lookup[i] = iop.NewPolynomial(&lookup[i], lagReg)
+	}
+
 	// build the permutation and build the polynomials S1, S2, S3 to encode the permutation.
 	// Note: at this stage, the permutation takes in account the placeholders
 	nbVariables := spr.NbInternalVariables + len(spr.Public) + len(spr.Secret)
@@ -223,6 +395,18 @@ func (vk *VerifyingKey) commitTrace(trace *Trace, domain *fft.Domain, srsPk kzg.
 			return err
 		}
 	}
+	vk.Qcg = make([]kzg.Digest, len(trace.Qcg))
+	for i := range trace.Qcg {
+		if vk.Qcg[i], err = kzg.Commit(trace.Qcg[i].Coefficients(), srsPk); err != nil {
+			return err
+		}
+	}
+	vk.Lookup = make([]kzg.Digest, len(trace.Lookup))
+	for i := range trace.Lookup {
+		if vk.Lookup[i], err = kzg.Commit(trace.Lookup[i].Coefficients(), srsPk); err != nil {
+			return err
+		}
+	}
 	if vk.Ql, err = kzg.Commit(trace.Ql.Coefficients(), srsPk); err != nil {
 		return err
 	}
@@ -250,6 +434,31 @@ func (vk *VerifyingKey) commitTrace(trace *Trace, domain *fft.Domain, srsPk kzg.
 	return nil
 }
 
+// customGates returns the custom gates polynomials of the constraint system.
+func customGates(spr *cs.SparseR1CS) []CustomGate {
+	gates := spr.GetCustomGates()
+	res := make([]CustomGate, len(gates))
+	for i, g := range gates {
+		res[i].Coefficients = make([]fr.Element, len(g.Terms))
+		res[i].Exponents = make([][6]uint8, len(g.Terms))
+		for j, t := range g.Terms {
+			res[i].Coefficients[j].Set(&spr.Coefficients[t.CID])
+			res[i].Exponents[j] = t.Exponents
+		}
+	}
+	return res
+}
+
+// nbLookupRows returns the total number of rows of the lookup tables of the
+// constraint system.
+func nbLookupRows(spr *cs.SparseR1CS) int {
+	res := 0
+	for _, t := range spr.GetLookupTables() {
+		res += len(t.Rows)
+	}
+	return res
+}
+
 func initFFTDomain(spr *cs.SparseR1CS) *fft.Domain {
 	nbConstraints := spr.GetNbConstraints()
 	sizeSystem := uint64(nbConstraints + len(spr.Public)) // len(spr.Public) is for the placeholder constraints
@@ -0,0 +1,71 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+package mpcsetup
+
+import (
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"io"
+)
+
+// WriteTo implements io.WriterTo
+func (p *PowersOfTau) WriteTo(writer io.Writer) (int64, error) {
+	n, err := p.writeTo(writer)
+	if err != nil {
+		return n, err
+	}
+	nBytes, err := writer.Write(p.Hash)
+	return int64(nBytes) + n, err
+}
+
+func (p *PowersOfTau) writeTo(writer io.Writer) (int64, error) {
+	toEncode := []interface{}{
+		&p.PublicKey.SG,
+		&p.PublicKey.SXG,
+		&p.PublicKey.XR,
+		p.Parameters.G1.Tau,
+		&p.Parameters.G2.Tau,
+	}
+
+	enc := curve.NewEncoder(writer)
+	for _, v := range toEncode {
+		if err := enc.Encode(v); err != nil {
+			return enc.BytesWritten(), err
+		}
+	}
+	return enc.BytesWritten(), nil
+}
+
+// ReadFrom implements io.ReaderFrom
+func (p *PowersOfTau) ReadFrom(reader io.Reader) (int64, error) {
+	toEncode := []interface{}{
+		&p.PublicKey.SG,
+		&p.PublicKey.SXG,
+		&p.PublicKey.XR,
+		&p.Parameters.G1.Tau,
+		&p.Parameters.G2.Tau,
+	}
+
+	dec := curve.NewDecoder(reader)
+	for _, v := range toEncode {
+		if err := dec.Decode(v); err != nil {
+			return dec.BytesRead(), err
+		}
+	}
+	p.Hash = make([]byte, 32)
+	nBytes, err := io.ReadFull(reader, p.Hash)
+	return dec.BytesRead() + int64(nBytes), err
+}
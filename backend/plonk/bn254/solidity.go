@@ -37,6 +37,8 @@ contract PlonkVerifier {
   // ----------------------- vk ---------------------
   uint256 private constant VK_NB_PUBLIC_INPUTS = {{ .Vk.NbPublicVariables }};
   uint256 private constant VK_DOMAIN_SIZE = {{ .Vk.Size }};
+  // size m of the shards of the quotient, n+2 unless the custom gates are of degree more than 3
+  uint256 private constant VK_QUOTIENT_SHARD_SIZE = {{ shardSize }};
   uint256 private constant VK_INV_DOMAIN_SIZE = {{ (frstr .Vk.SizeInv) }};
   uint256 private constant VK_OMEGA = {{ (frstr .Vk.Generator) }};
   uint256 private constant VK_QL_COM_X = {{ (fpstr .Vk.Ql.X) }};
@@ -64,6 +66,13 @@ contract PlonkVerifier {
   uint256 private constant VK_INDEX_COMMIT_API_{{ $index }} = {{ $element }};
   {{ end -}}
   uint256 private constant VK_NB_CUSTOM_GATES = {{ len .Vk.CommitmentConstraintIndexes }};
+  {{ range $index, $element := .Vk.Qcg }}
+  uint256 private constant VK_QCG_{{ $index }}_X = {{ (fpstr $element.X) }};
+  uint256 private constant VK_QCG_{{ $index }}_Y = {{ (fpstr $element.Y) }};
+  {{- range $j, $c := (index $.Vk.CustomGates $index).Coefficients }}
+  uint256 private constant VK_QCG_{{ $index }}_COEFF_{{ $j }} = {{ (frstr $c) }};
+  {{- end }}
+  {{ end }}
 
   // ------------------------------------------------
 
@@ -79,7 +88,7 @@ contract PlonkVerifier {
   uint256 private constant PROOF_O_COM_X = {{ hex $offset }};{{ $offset = add $offset 0x20}}
   uint256 private constant PROOF_O_COM_Y = {{ hex $offset }};{{ $offset = add $offset 0x20}}
 
-  // h = h_0 + x^{n+2}h_1 + x^{2(n+2)}h_2
+  // h = h_0 + x^{m}h_1 + x^{2m}h_2, where m = VK_QUOTIENT_SHARD_SIZE
   uint256 private constant PROOF_H_0_COM_X = {{ hex $offset }};{{ $offset = add $offset 0x20}}
   uint256 private constant PROOF_H_0_COM_Y = {{ hex $offset }};{{ $offset = add $offset 0x20}}
   uint256 private constant PROOF_H_1_COM_X = {{ hex $offset }};{{ $offset = add $offset 0x20}}
@@ -402,6 +411,9 @@ contract PlonkVerifier {
       /// * the word "gamma" in ascii, equal to [0x67,0x61,0x6d, 0x6d, 0x61] and encoded as a uint256.
       /// * the commitments to the permutation polynomials S1, S2, S3, where we concatenate the coordinates of those points
       /// * the commitments of Ql, Qr, Qm, Qo, Qk
+      /// * the commitments of the selectors Qcp of the BSB22 commitments and Qcg of the custom gates
+      /// * for each custom gate, its number of terms then the coefficient and the exponents l+2⁸r+2¹⁶o
+      /// of each term, each encoded as a uint256
       /// * the public inputs
       /// * the commitments of the wires related to the custom gates (commitments_wires_commit_api)
       /// * commitments to L, R, O (proof_<l,r,o>_com_<x,y>)
@@ -437,8 +449,15 @@ contract PlonkVerifier {
         mstore(add(mPtr, {{ hex $offset }}), VK_QCP_{{ $index }}_X) {{ $offset = add $offset 0x20}}
         mstore(add(mPtr, {{ hex $offset }}), VK_QCP_{{ $index }}_Y) {{ $offset = add $offset 0x20}}
         {{ end }}
+        {{- range $index, $element := .Vk.Qcg}}
+        mstore(add(mPtr, {{ hex $offset }}), VK_QCG_{{ $index }}_X) {{ $offset = add $offset 0x20}}
+        mstore(add(mPtr, {{ hex $offset }}), VK_QCG_{{ $index }}_Y) {{ $offset = add $offset 0x20}}
+        {{ end }}
+        {{- range $w := customGatesTranscript }}
+        mstore(add(mPtr, {{ hex $offset }}), {{ $w }}) {{ $offset = add $offset 0x20}}
+        {{- end }}
         // public inputs
-        let _mPtr := add(mPtr, {{ hex (add (mul (len .Vk.CommitmentConstraintIndexes) 64) 544) }})
+        let _mPtr := add(mPtr, {{ hex $offset }})
         let size_pi_in_bytes := mul(nb_pi, 0x20)
         calldatacopy(_mPtr, pi, size_pi_in_bytes)
         _mPtr := add(_mPtr, size_pi_in_bytes)
@@ -452,10 +471,14 @@ contract PlonkVerifier {
         // sizegamma(=0x5) + 11*64(=0x2c0)
         // + nb_public_inputs*0x20
         // + nb_custom gates*0x40
+        // + nb_qcg*0x40 + the custom gates polynomials
         let size := add(0x2c5, size_pi_in_bytes)
         {{ if (gt (len .Vk.CommitmentConstraintIndexes) 0 )}}
         size := add(size, mul(VK_NB_CUSTOM_GATES, 0x40))
         {{ end -}}
+        {{ if (gt (len .Vk.Qcg) 0 ) -}}
+        size := add(size, {{ hex (add (mul (len .Vk.Qcg) 64) (mul (len customGatesTranscript) 32)) }})
+        {{ end -}}
         let l_success := staticcall(gas(), SHA2, add(mPtr, 0x1b), size, mPtr, 0x20) //0x1b -> 000.."gamma"
         if iszero(l_success) {
           error_verify()
@@ -1024,6 +1047,43 @@ contract PlonkVerifier {
         mstore(add(state, STATE_GAMMA_KZG), mod(mload(add(state, STATE_GAMMA_KZG)), R_MOD))
       }
 
+      {{ if (gt (len .Vk.Qcg) 0 ) -}}
+      /// @notice dst <- dst + Σₖ Gₖ(l(ζ), r(ζ), o(ζ))[Qcgₖ] where Gₖ is the polynomial
+      /// of the k-th custom gate and [Qcgₖ] the commitment to its selector
+      /// @param aproof pointer to the proof
+      /// @param dst pointer accumulator point storing the result
+      /// @param mPtr free memory
+      function accumulate_custom_gates(aproof, dst, mPtr) {
+        let l := calldataload(add(aproof, PROOF_L_AT_ZETA))
+        let r := calldataload(add(aproof, PROOF_R_AT_ZETA))
+        let o := calldataload(add(aproof, PROOF_O_AT_ZETA))
+        let eval
+        {{ range $index, $gate := .Vk.CustomGates -}}
+        eval := 0
+        {{ range $j, $e := $gate.Exponents -}}
+        eval := addmod(eval, mulmod(VK_QCG_{{ $index }}_COEFF_{{ $j }}, monomial(l, r, o, {{ index $e 0 }}, {{ index $e 1 }}, {{ index $e 2 }}), R_MOD), R_MOD)
+        {{ end -}}
+        mstore(mPtr, VK_QCG_{{ $index }}_X)
+        mstore(add(mPtr, 0x20), VK_QCG_{{ $index }}_Y)
+        point_acc_mul(dst, mPtr, eval, add(mPtr, 0x40))
+        {{ end }}
+      }
+
+      /// @return res lᵃ*rᵇ*oᶜ
+      function monomial(l, r, o, a, b, c)->res {
+        res := 1
+        for {let i := 0} lt(i, a) {i := add(i, 1)} {
+          res := mulmod(res, l, R_MOD)
+        }
+        for {let i := 0} lt(i, b) {i := add(i, 1)} {
+          res := mulmod(res, r, R_MOD)
+        }
+        for {let i := 0} lt(i, c) {i := add(i, 1)} {
+          res := mulmod(res, o, R_MOD)
+        }
+      }
+      {{ end -}}
+
       function compute_commitment_linearised_polynomial_ec(aproof, s1, s2) {
         
         let state := mload(0x40)
@@ -1090,6 +1150,9 @@ contract PlonkVerifier {
           bsb_commitments := add(bsb_commitments, 0x40)
         }
         {{ end }}
+        {{- if (gt (len .Vk.Qcg) 0 ) }}
+        accumulate_custom_gates(aproof, add(state, STATE_LINEARISED_POLYNOMIAL_X), mPtr)
+        {{ end }}
 
         mstore(mPtr, VK_S3_COM_X)
         mstore(add(mPtr, 0x20), VK_S3_COM_Y)
@@ -1107,10 +1170,11 @@ contract PlonkVerifier {
       }
 
       /// @notice Compute the commitment to the linearized polynomial equal to
-      ///	L(ζ)[Qₗ]+r(ζ)[Qᵣ]+R(ζ)L(ζ)[Qₘ]+O(ζ)[Qₒ]+[Qₖ]+Σᵢqc'ᵢ(ζ)[BsbCommitmentᵢ] +
+      ///	L(ζ)[Qₗ]+r(ζ)[Qᵣ]+R(ζ)L(ζ)[Qₘ]+O(ζ)[Qₒ]+[Qₖ]+Σᵢqc'ᵢ(ζ)[BsbCommitmentᵢ]+Σₖ Gₖ(L(ζ),R(ζ),O(ζ))[Qcgₖ] +
       ///	α*( Z(μζ)(L(ζ)+β*S₁(ζ)+γ)*(R(ζ)+β*S₂(ζ)+γ)[S₃]-[Z](L(ζ)+β*id_{1}(ζ)+γ)*(R(ζ)+β*id_{2}(ζ)+γ)*(O(ζ)+β*id_{3}(ζ)+γ) ) +
-      ///	α²*L₁(ζ)[Z] - Z_{H}(ζ)*(([H₀] + ζᵐ⁺²*[H₁] + ζ²⁽ᵐ⁺²⁾*[H₂])
+      ///	α²*L₁(ζ)[Z] - Z_{H}(ζ)*(([H₀] + ζᵐ*[H₁] + ζ²ᵐ*[H₂])
       /// where
+      /// * m = VK_QUOTIENT_SHARD_SIZE is the size of the shards of the quotient
       /// * id_1 = id, id_2 = vk_coset_shift*id, id_3 = vk_coset_shift^{2}*id
       /// * the [] means that it's a commitment (i.e. a point on Bn254(F_p))
       /// * Z_{H}(ζ) = ζ^n-1
@@ -1161,17 +1225,16 @@ contract PlonkVerifier {
         compute_commitment_linearised_polynomial_ec(aproof, s1, s2)
       }
 
-      /// @notice compute -z_h(ζ)*([H₁] + ζⁿ⁺²[H₂] + ζ²⁽ⁿ⁺²⁾[H₃]) and store the result at
-      /// state + state_folded_h
+      /// @notice compute -z_h(ζ)*([H₁] + ζᵐ[H₂] + ζ²ᵐ[H₃]) and store the result at
+      /// state + state_folded_h, where m = VK_QUOTIENT_SHARD_SIZE
       /// @param aproof pointer to the proof
       function fold_h(aproof) {
         let state := mload(0x40)
-        let n_plus_two := add(VK_DOMAIN_SIZE, 2)
         let mPtr := add(mload(0x40), STATE_LAST_MEM)
-        let zeta_power_n_plus_two := pow(mload(add(state, STATE_ZETA)), n_plus_two, mPtr)
-        point_mul_calldata(add(state, STATE_FOLDED_H_X), add(aproof, PROOF_H_2_COM_X), zeta_power_n_plus_two, mPtr)
+        let zeta_power_m := pow(mload(add(state, STATE_ZETA)), VK_QUOTIENT_SHARD_SIZE, mPtr)
+        point_mul_calldata(add(state, STATE_FOLDED_H_X), add(aproof, PROOF_H_2_COM_X), zeta_power_m, mPtr)
         point_add_calldata(add(state, STATE_FOLDED_H_X), add(state, STATE_FOLDED_H_X), add(aproof, PROOF_H_1_COM_X), mPtr)
-        point_mul(add(state, STATE_FOLDED_H_X), add(state, STATE_FOLDED_H_X), zeta_power_n_plus_two, mPtr)
+        point_mul(add(state, STATE_FOLDED_H_X), add(state, STATE_FOLDED_H_X), zeta_power_m, mPtr)
         point_add_calldata(add(state, STATE_FOLDED_H_X), add(state, STATE_FOLDED_H_X), add(aproof, PROOF_H_0_COM_X), mPtr)
           point_mul(add(state, STATE_FOLDED_H_X), add(state, STATE_FOLDED_H_X), mload(add(state, STATE_ZETA_POWER_N_MINUS_ONE)), mPtr)
         let folded_h_y := mload(add(state, STATE_FOLDED_H_Y))
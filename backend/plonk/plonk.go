@@ -20,7 +20,9 @@
 package plonk
 
 import (
+	"fmt"
 	"io"
+	"strings"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/kzg"
@@ -61,6 +63,7 @@ import (
 	kzg_bw6633 "github.com/consensys/gnark-crypto/ecc/bw6-633/kzg"
 	kzg_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/kzg"
 
+	"github.com/consensys/gnark/internal/mmap"
 	gnarkio "github.com/consensys/gnark/io"
 )
 
@@ -81,6 +84,7 @@ type ProvingKey interface {
 	io.ReaderFrom
 	gnarkio.WriterRawTo
 	gnarkio.UnsafeReaderFrom
+
 	VerifyingKey() interface{}
 }
 
@@ -102,23 +106,26 @@ type VerifyingKey interface {
 // The kzg SRS must be provided in canonical and lagrange form.
 // For test purposes, see test/unsafekzg package. With an existing SRS generated through MPC in canonical form,
 // gnark-crypto offers the ToLagrangeG1 method to convert it to lagrange form.
-func Setup(ccs constraint.ConstraintSystem, srs, srsLagrange kzg.SRS) (ProvingKey, VerifyingKey, error) {
+//
+// The canonical SRS must have 3 more points than the lagrange one, unless the
+// key is set up with backend.WithSetupWithoutZeroKnowledge.
+func Setup(ccs constraint.ConstraintSystem, srs, srsLagrange kzg.SRS, opts ...backend.SetupOption) (ProvingKey, VerifyingKey, error) {
 
 	switch tccs := ccs.(type) {
 	case *cs_bn254.SparseR1CS:
-		return plonk_bn254.Setup(tccs, *srs.(*kzg_bn254.SRS), *srsLagrange.(*kzg_bn254.SRS))
+		return plonk_bn254.Setup(tccs, *srs.(*kzg_bn254.SRS), *srsLagrange.(*kzg_bn254.SRS), opts...)
 	case *cs_bls12381.SparseR1CS:
-		return plonk_bls12381.Setup(tccs, *srs.(*kzg_bls12381.SRS), *srsLagrange.(*kzg_bls12381.SRS))
+		return plonk_bls12381.Setup(tccs, *srs.(*kzg_bls12381.SRS), *srsLagrange.(*kzg_bls12381.SRS), opts...)
 	case *cs_bls12377.SparseR1CS:
-		return plonk_bls12377.Setup(tccs, *srs.(*kzg_bls12377.SRS), *srsLagrange.(*kzg_bls12377.SRS))
+		return plonk_bls12377.Setup(tccs, *srs.(*kzg_bls12377.SRS), *srsLagrange.(*kzg_bls12377.SRS), opts...)
 	case *cs_bw6761.SparseR1CS:
-		return plonk_bw6761.Setup(tccs, *srs.(*kzg_bw6761.SRS), *srsLagrange.(*kzg_bw6761.SRS))
+		return plonk_bw6761.Setup(tccs, *srs.(*kzg_bw6761.SRS), *srsLagrange.(*kzg_bw6761.SRS), opts...)
 	case *cs_bls24317.SparseR1CS:
-		return plonk_bls24317.Setup(tccs, *srs.(*kzg_bls24317.SRS), *srsLagrange.(*kzg_bls24317.SRS))
+		return plonk_bls24317.Setup(tccs, *srs.(*kzg_bls24317.SRS), *srsLagrange.(*kzg_bls24317.SRS), opts...)
 	case *cs_bls24315.SparseR1CS:
-		return plonk_bls24315.Setup(tccs, *srs.(*kzg_bls24315.SRS), *srsLagrange.(*kzg_bls24315.SRS))
+		return plonk_bls24315.Setup(tccs, *srs.(*kzg_bls24315.SRS), *srsLagrange.(*kzg_bls24315.SRS), opts...)
 	case *cs_bw6633.SparseR1CS:
-		return plonk_bw6633.Setup(tccs, *srs.(*kzg_bw6633.SRS), *srsLagrange.(*kzg_bw6633.SRS))
+		return plonk_bw6633.Setup(tccs, *srs.(*kzg_bw6633.SRS), *srsLagrange.(*kzg_bw6633.SRS), opts...)
 	default:
 		panic("unrecognized SparseR1CS curve type")
 	}
@@ -160,6 +167,55 @@ func Prove(ccs constraint.ConstraintSystem, pk ProvingKey, fullWitness witness.W
 	}
 }
 
+// ProveBatch generates PLONK proofs for several full witnesses of the same
+// constraint system. It computes the circuit dependent data of the prover once,
+// reuses the memory of the prover between proofs and solves the next witness
+// while the current proof is computed, which is faster than calling Prove for
+// each witness.
+//
+// The proofs are returned in the order of the witnesses, errs[i] is the error of
+// the proof of fullWitnesses[i] (and proofs[i] is nil if errs[i] is not nil).
+func ProveBatch(ccs constraint.ConstraintSystem, pk ProvingKey, fullWitnesses []witness.Witness, opts ...backend.ProverOption) (proofs []Proof, errs []error) {
+
+	switch tccs := ccs.(type) {
+	case *cs_bn254.SparseR1CS:
+		return toProofs(plonk_bn254.ProveBatch(tccs, pk.(*plonk_bn254.ProvingKey), fullWitnesses, opts...))
+
+	case *cs_bls12381.SparseR1CS:
+		return toProofs(plonk_bls12381.ProveBatch(tccs, pk.(*plonk_bls12381.ProvingKey), fullWitnesses, opts...))
+
+	case *cs_bls12377.SparseR1CS:
+		return toProofs(plonk_bls12377.ProveBatch(tccs, pk.(*plonk_bls12377.ProvingKey), fullWitnesses, opts...))
+
+	case *cs_bw6761.SparseR1CS:
+		return toProofs(plonk_bw6761.ProveBatch(tccs, pk.(*plonk_bw6761.ProvingKey), fullWitnesses, opts...))
+
+	case *cs_bw6633.SparseR1CS:
+		return toProofs(plonk_bw6633.ProveBatch(tccs, pk.(*plonk_bw6633.ProvingKey), fullWitnesses, opts...))
+
+	case *cs_bls24317.SparseR1CS:
+		return toProofs(plonk_bls24317.ProveBatch(tccs, pk.(*plonk_bls24317.ProvingKey), fullWitnesses, opts...))
+
+	case *cs_bls24315.SparseR1CS:
+		return toProofs(plonk_bls24315.ProveBatch(tccs, pk.(*plonk_bls24315.ProvingKey), fullWitnesses, opts...))
+
+	default:
+		panic("unrecognized SparseR1CS curve type")
+	}
+}
+
+// toProofs converts the curve typed proofs of ProveBatch to interfaces, and
+// keeps nil proofs untyped.
+func toProofs[P Proof](typed []P, errs []error) ([]Proof, []error) {
+	proofs := make([]Proof, len(typed))
+	for i := range typed {
+		if errs[i] == nil {
+			proofs[i] = typed[i]
+		}
+	}
+	return proofs, errs
+}
+
 // Verify verifies a PLONK proof, from the proof, preprocessed public data, and public witness.
 func Verify(proof Proof, vk VerifyingKey, publicWitness witness.Witness, opts ...backend.VerifierOption) error {
 
@@ -244,6 +300,65 @@ func NewCS(curveID ecc.ID) constraint.ConstraintSystem {
 	return r1cs
 }
 
+// MappedProvingKey is a ProvingKey memory mapped by Open. Close releases the
+// mapping, the key must not be used afterwards.
+type MappedProvingKey interface {
+	ProvingKey
+	io.Closer
+}
+
+// Open memory maps a proving key written by WriteMmapTo in the file at path,
+// for any curve. Its points are loaded from the file when the prover accesses
+// them instead of being read on startup, and the processes opening the same
+// file share them. The key must be released with Close once it is not used.
+//
+// The curve typed proving keys of this package implement [gnarkio.Mapper],
+// which is not part of ProvingKey so that the keys implemented outside of gnark
+// don't need to support it:
+//
+//	_, err := pk.(gnarkio.Mapper).WriteMmapTo(w)
+func Open(path string) (MappedProvingKey, error) {
+	kind, err := mmap.ReadKind(path)
+	if err != nil {
+		return nil, err
+	}
+	scheme, curveName, _ := strings.Cut(kind, "/")
+	if scheme != "plonk" {
+		return nil, fmt.Errorf("file holds a %s proving key, not a plonk one", scheme)
+	}
+	curveID, err := ecc.IDFromString(curveName)
+	if err != nil {
+		return nil, err
+	}
+	switch curveID {
+	case ecc.BN254:
+		return openProvingKey(plonk_bn254.Open(path))
+	case ecc.BLS12_377:
+		return openProvingKey(plonk_bls12377.Open(path))
+	case ecc.BLS12_381:
+		return openProvingKey(plonk_bls12381.Open(path))
+	case ecc.BW6_761:
+		return openProvingKey(plonk_bw6761.Open(path))
+	case ecc.BLS24_317:
+		return openProvingKey(plonk_bls24317.Open(path))
+	case ecc.BLS24_315:
+		return openProvingKey(plonk_bls24315.Open(path))
+	case ecc.BW6_633:
+		return openProvingKey(plonk_bw6633.Open(path))
+	default:
+		return nil, fmt.Errorf("curve %s not implemented", curveID)
+	}
+}
+
+// openProvingKey returns the result of a curve typed Open without wrapping a nil
+// key in a non-nil interface.
+func openProvingKey[P MappedProvingKey](pk P, err error) (MappedProvingKey, error) {
+	if err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
 // NewProvingKey instantiates a curve-typed ProvingKey and returns an interface
 // This function exists for serialization purposes
 func NewProvingKey(curveID ecc.ID) ProvingKey {
@@ -324,13 +439,19 @@ func NewVerifyingKey(curveID ecc.ID) VerifyingKey {
 
 // SRSSize returns the required size of the kzg SRS for a given constraint system
 // Note that the SRS size in Lagrange form is a power of 2,
-// and the SRS size in canonical form need few extra elements (3) to account for the blinding factors
+// and the SRS size in canonical form need few extra elements (3) to account for the blinding factors,
+// or more to commit to the larger shards of the quotient when the circuit has
+// custom gates of degree more than 3, see [constraint.QuotientShardFactor].
 func SRSSize(ccs constraint.ConstraintSystem) (sizeCanonical, sizeLagrange int) {
 	nbConstraints := ccs.GetNbConstraints()
 	sizeSystem := nbConstraints + ccs.GetNbPublicVariables()
 
 	sizeLagrange = int(ecc.NextPowerOfTwo(uint64(sizeSystem)))
-	sizeCanonical = sizeLagrange + 3
+	k := 1
+	if spr, ok := ccs.(constraint.SparseR1CS); ok {
+		k = spr.GetQuotientShardFactor()
+	}
+	sizeCanonical = k*(sizeLagrange+2) + 1
 
 	return
 }
@@ -4,16 +4,25 @@ import (
 	"bytes"
 	"fmt"
 	"math/big"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/consensys/gnark"
 	"github.com/consensys/gnark-crypto/ecc"
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/kzg"
 	"github.com/consensys/gnark-crypto/kzg"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/scs"
+	gnarkio "github.com/consensys/gnark/io"
+	"github.com/consensys/gnark/std/rangecheck"
 	"github.com/consensys/gnark/test"
 	"github.com/consensys/gnark/test/unsafekzg"
 	"github.com/stretchr/testify/require"
@@ -194,6 +203,687 @@ func TestCustomKZGFoldingHash(t *testing.T) {
 	}
 }
 
+func TestCustomGates(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		curve := curve
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, &customGateCircuit{})
+			assert.NoError(err)
+			// the gates take a single row each. Additionally, we have the
+			// equality assertion, the subtraction and the constant input 7.
+			assert.Equal(5, ccs.GetNbConstraints())
+			srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+			assert.NoError(err)
+			pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+			assert.NoError(err)
+
+			w, err := frontend.NewWitness(&customGateCircuit{X: 3, Y: 3 * 3 * 3, Z: 27 + 112}, curve.ScalarField())
+			assert.NoError(err)
+			pw, err := w.Public()
+			assert.NoError(err)
+			proof, err := plonk.Prove(ccs, pk, w)
+			assert.NoError(err)
+			assert.NoError(plonk.Verify(proof, vk, pw))
+
+			if curve == ecc.BN254 {
+				var buf bytes.Buffer
+				assert.NoError(vk.ExportSolidity(&buf))
+			}
+
+			w, err = frontend.NewWitness(&customGateCircuit{X: 3, Y: 3 * 3 * 3, Z: 27 + 113}, curve.ScalarField())
+			assert.NoError(err)
+			_, err = plonk.Prove(ccs, pk, w)
+			assert.Error(err)
+		}, curve.String())
+	}
+	assert.CheckCircuit(&customGateCircuit{},
+		test.WithValidAssignment(&customGateCircuit{X: 2, Y: 8, Z: 8 + 63}),
+		test.WithInvalidAssignment(&customGateCircuit{X: 2, Y: 9, Z: 8 + 63}),
+	)
+}
+
+type customGateCircuit struct {
+	X    frontend.Variable
+	Y, Z frontend.Variable `gnark:",public"`
+}
+
+func (c *customGateCircuit) Define(api frontend.API) error {
+	// x³ - o = 0
+	cube := frontend.CustomGate{Name: "cube", Terms: []frontend.CustomGateTerm{
+		{Coeff: big.NewInt(1), A: 3},
+		{Coeff: big.NewInt(-1), C: 1},
+	}}
+	// l²r + 2lr + r - o = 0
+	poly := frontend.CustomGate{Name: "poly", Terms: []frontend.CustomGateTerm{
+		{Coeff: big.NewInt(1), A: 2, B: 1},
+		{Coeff: big.NewInt(2), A: 1, B: 1},
+		{Coeff: big.NewInt(1), B: 1},
+		{Coeff: big.NewInt(-1), C: 1},
+	}}
+	cg, ok := api.(frontend.CustomGater)
+	if !ok {
+		api.AssertIsEqual(cube.Evaluate(api, c.X, 0, c.Y), 0)
+		api.AssertIsEqual(poly.Evaluate(api, c.X, 7, api.Sub(c.Z, c.Y)), 0)
+		return nil
+	}
+	cubeID, err := cg.DefineCustomGate(cube)
+	if err != nil {
+		return err
+	}
+	polyID, err := cg.DefineCustomGate(poly)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(cg.EvaluateCustomGate(cubeID, c.X, c.X), c.Y)
+	cg.AssertCustomGate(polyID, c.X, 7, api.Sub(c.Z, c.Y))
+	return nil
+}
+
+type customGateDegreeCircuit struct {
+	X frontend.Variable
+}
+
+func (c *customGateDegreeCircuit) Define(api frontend.API) error {
+	_, err := api.(frontend.CustomGater).DefineCustomGate(frontend.CustomGate{Name: "too_large", Terms: []frontend.CustomGateTerm{
+		{Coeff: big.NewInt(1), A: constraint.MaxCustomGateDegree + 1},
+	}})
+	return err
+}
+
+func TestCustomGatesHighDegree(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		curve := curve
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, &customGateHighDegreeCircuit{})
+			assert.NoError(err)
+			// the gates of degree 5 double the size of the shards of the quotient
+			sizeCanonical, sizeLagrange := plonk.SRSSize(ccs)
+			assert.Equal(2*(sizeLagrange+2)+1, sizeCanonical)
+			srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+			assert.NoError(err)
+			pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+			assert.NoError(err)
+
+			valid, err := frontend.NewWitness(&customGateHighDegreeCircuit{X: 3, Y: 2, Z: 243 + 2*9*8}, curve.ScalarField())
+			assert.NoError(err)
+			invalid, err := frontend.NewWitness(&customGateHighDegreeCircuit{X: 3, Y: 2, Z: 243 + 2*9*8 + 1}, curve.ScalarField())
+			assert.NoError(err)
+			pubWitness, err := valid.Public()
+			assert.NoError(err)
+
+			for _, opts := range [][]backend.ProverOption{
+				nil,
+				{backend.WithStatisticalZeroKnowledge()},
+				{backend.WithLowMemory(0, t.TempDir())},
+			} {
+				proof, err := plonk.Prove(ccs, pk, valid, opts...)
+				assert.NoError(err)
+				assert.NoError(plonk.Verify(proof, vk, pubWitness))
+
+				_, err = plonk.Prove(ccs, pk, invalid, opts...)
+				assert.Error(err)
+			}
+			proof, err := plonk.Prove(ccs, pk, valid, backend.WithoutZeroKnowledge())
+			assert.NoError(err)
+			assert.NoError(plonk.Verify(proof, vk, pubWitness, backend.WithVerifierWithoutZeroKnowledge()))
+
+			// the srs of a circuit without custom gates of high degree is too small
+			small := srs
+			switch s := srs.(type) {
+			case *kzg_bn254.SRS:
+				c := *s
+				c.Pk.G1 = c.Pk.G1[:sizeLagrange+3]
+				small = &c
+			}
+			if small != srs {
+				_, _, err = plonk.Setup(ccs, small, srsLagrange)
+				assert.Error(err)
+			}
+		}, curve.String())
+	}
+	assert.CheckCircuit(&customGateHighDegreeCircuit{},
+		test.WithValidAssignment(&customGateHighDegreeCircuit{X: 2, Y: 3, Z: 32 + 2*4*27}),
+		test.WithInvalidAssignment(&customGateHighDegreeCircuit{X: 2, Y: 3, Z: 32 + 2*4*27 + 1}),
+	)
+}
+
+type customGateHighDegreeCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (c *customGateHighDegreeCircuit) Define(api frontend.API) error {
+	// the S-box of Poseidon and a mixed term of degree 5: l⁵ + 2l²r³ - o = 0
+	sbox := frontend.CustomGate{Name: "sbox", Terms: []frontend.CustomGateTerm{
+		{Coeff: big.NewInt(1), A: 5},
+		{Coeff: big.NewInt(2), A: 2, B: 3},
+		{Coeff: big.NewInt(-1), C: 1},
+	}}
+	cg, ok := api.(frontend.CustomGater)
+	if !ok {
+		api.AssertIsEqual(sbox.Evaluate(api, c.X, c.Y, c.Z), 0)
+		return nil
+	}
+	sboxID, err := cg.DefineCustomGate(sbox)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(cg.EvaluateCustomGate(sboxID, c.X, c.Y), c.Z)
+	return nil
+}
+
+func TestCustomGatesNextRow(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		curve := curve
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, &customGateNextRowCircuit{})
+			assert.NoError(err)
+			// l, r, o are opened at ωζ, their blinding doubles the size of the
+			// shards of the quotient
+			sizeCanonical, sizeLagrange := plonk.SRSSize(ccs)
+			assert.Equal(2*(sizeLagrange+2)+1, sizeCanonical)
+			srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+			assert.NoError(err)
+			pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+			assert.NoError(err)
+
+			valid, err := frontend.NewWitness(&customGateNextRowCircuit{X: 2, Y: 3, Z: 5, V: 46}, curve.ScalarField())
+			assert.NoError(err)
+			invalid, err := frontend.NewWitness(&customGateNextRowCircuit{X: 2, Y: 3, Z: 5, V: 47}, curve.ScalarField())
+			assert.NoError(err)
+			pubWitness, err := valid.Public()
+			assert.NoError(err)
+
+			for _, opts := range [][]backend.ProverOption{
+				nil,
+				{backend.WithStatisticalZeroKnowledge()},
+				{backend.WithLowMemory(0, t.TempDir())},
+			} {
+				proof, err := plonk.Prove(ccs, pk, valid, opts...)
+				assert.NoError(err)
+				assert.NoError(plonk.Verify(proof, vk, pubWitness))
+
+				_, err = plonk.Prove(ccs, pk, invalid, opts...)
+				assert.Error(err)
+			}
+			proof, err := plonk.Prove(ccs, pk, valid, backend.WithoutZeroKnowledge())
+			assert.NoError(err)
+			assert.NoError(plonk.Verify(proof, vk, pubWitness, backend.WithVerifierWithoutZeroKnowledge()))
+
+			wrong, err := frontend.NewWitness(&customGateNextRowCircuit{Z: 5, V: 47}, curve.ScalarField(), frontend.PublicOnly())
+			assert.NoError(err)
+			assert.Error(plonk.Verify(proof, vk, wrong))
+
+			if curve == ecc.BN254 {
+				var buf bytes.Buffer
+				assert.Error(vk.ExportSolidity(&buf))
+			}
+		}, curve.String())
+	}
+	assert.CheckCircuit(&customGateNextRowCircuit{},
+		test.WithValidAssignment(&customGateNextRowCircuit{X: 2, Y: 3, Z: 5, V: 46}),
+		test.WithInvalidAssignment(&customGateNextRowCircuit{X: 2, Y: 3, Z: 5, V: 47}),
+	)
+}
+
+type customGateNextRowCircuit struct {
+	X, Y frontend.Variable
+	Z, V frontend.Variable `gnark:",public"`
+}
+
+func (c *customGateNextRowCircuit) Define(api frontend.API) error {
+	// lr + o + r'o' - l' = 0
+	step := frontend.CustomGate{Name: "step", Terms: []frontend.CustomGateTerm{
+		{Coeff: big.NewInt(1), A: 1, B: 1},
+		{Coeff: big.NewInt(1), C: 1},
+		{Coeff: big.NewInt(1), NextB: 1, NextC: 1},
+		{Coeff: big.NewInt(-1), NextA: 1},
+	}}
+	// w = 2xy + z, v = wx + y + y²
+	w := api.Add(api.Mul(2, c.X, c.Y), c.Z)
+	cg, ok := api.(frontend.CustomGater)
+	if !ok {
+		api.AssertIsEqual(step.Evaluate(api, c.X, c.Y, c.Z, w, c.X, c.Y), 0)
+		api.AssertIsEqual(step.Evaluate(api, w, c.X, c.Y, c.V, c.Y, c.Y), 0)
+		return nil
+	}
+	stepID, err := cg.DefineCustomGate(step)
+	if err != nil {
+		return err
+	}
+	cg.AssertCustomGate(stepID, c.X, c.Y, c.Z, w, c.X, c.Y)
+	cg.AssertCustomGate(stepID, w, c.X, c.Y, c.V, c.Y, c.Y)
+	return nil
+}
+
+func TestCustomGateDegree(t *testing.T) {
+	assert := require.New(t)
+	_, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &customGateDegreeCircuit{})
+	assert.ErrorIs(err, constraint.ErrCustomGateDegree)
+	err = test.IsSolved(&customGateDegreeCircuit{}, &customGateDegreeCircuit{X: 1}, ecc.BN254.ScalarField())
+	assert.ErrorIs(err, constraint.ErrCustomGateDegree)
+}
+
+func TestLookup(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		curve := curve
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, &lookupCircuit{})
+			assert.NoError(err)
+			// the tables are interpolated on the evaluation domain of the constraints
+			assert.GreaterOrEqual(ccs.GetNbConstraints()+ccs.GetNbPublicVariables(), 256+16+8)
+			srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+			assert.NoError(err)
+			pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+			assert.NoError(err)
+
+			w, err := frontend.NewWitness(&lookupCircuit{X: 11, Y: 6, Z: 11 ^ 6, XSq: 121, W: 5}, curve.ScalarField())
+			assert.NoError(err)
+			pw, err := w.Public()
+			assert.NoError(err)
+			proof, err := plonk.Prove(ccs, pk, w)
+			assert.NoError(err)
+			assert.NoError(plonk.Verify(proof, vk, pw))
+
+			wrong, err := frontend.NewWitness(&lookupCircuit{X: 11, Y: 6, Z: 11 ^ 7, XSq: 121, W: 5}, curve.ScalarField(), frontend.PublicOnly())
+			assert.NoError(err)
+			assert.Error(plonk.Verify(proof, vk, wrong))
+
+			if curve == ecc.BN254 {
+				var buf bytes.Buffer
+				assert.Error(vk.ExportSolidity(&buf))
+			}
+
+			w, err = frontend.NewWitness(&lookupCircuit{X: 11, Y: 6, Z: 11 ^ 6, XSq: 121, W: 8}, curve.ScalarField())
+			assert.NoError(err)
+			_, err = plonk.Prove(ccs, pk, w)
+			assert.Error(err)
+		}, curve.String())
+	}
+	assert.CheckCircuit(&lookupCircuit{},
+		test.WithValidAssignment(&lookupCircuit{X: 3, Y: 15, Z: 3 ^ 15, XSq: 9, W: 7}),
+		test.WithInvalidAssignment(&lookupCircuit{X: 3, Y: 15, Z: 3 ^ 14, XSq: 9, W: 7}),
+		test.WithInvalidAssignment(&lookupCircuit{X: 3, Y: 15, Z: 3 ^ 15, XSq: 10, W: 7}),
+		test.WithInvalidAssignment(&lookupCircuit{X: 3, Y: 15, Z: 3 ^ 15, XSq: 9, W: 8}),
+	)
+}
+
+type rangeCheckCircuit struct {
+	X frontend.Variable
+}
+
+func (c *rangeCheckCircuit) Define(api frontend.API) error {
+	rangecheck.New(api).Check(c.X, 16)
+	return nil
+}
+
+// TestRangeCheckSolidity checks that the std gadgets use native lookups by
+// default, which the Solidity verifier doesn't support, and that the circuits
+// compiled without them can be exported to Solidity.
+func TestRangeCheckSolidity(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &rangeCheckCircuit{})
+	assert.NoError(err)
+	assert.NotEmpty(ccs.(constraint.SparseR1CS).GetLookupTables())
+	srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+	assert.NoError(err)
+	_, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+	assert.NoError(err)
+	var buf bytes.Buffer
+	assert.Error(vk.ExportSolidity(&buf))
+
+	ccs, err = frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &rangeCheckCircuit{}, frontend.WithoutNativeLookups())
+	assert.NoError(err)
+	assert.Empty(ccs.(constraint.SparseR1CS).GetLookupTables())
+	srs, srsLagrange, err = unsafekzg.NewSRS(ccs)
+	assert.NoError(err)
+	_, vk, err = plonk.Setup(ccs, srs, srsLagrange)
+	assert.NoError(err)
+	buf.Reset()
+	assert.NoError(vk.ExportSolidity(&buf))
+}
+
+func TestProveBatch(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		curve := curve
+		assert.Run(func(assert *test.Assert) {
+			assert.Run(func(assert *test.Assert) {
+				checkProveBatch(assert, curve, &squareCommitmentCircuit{}, []frontend.Circuit{
+					&squareCommitmentCircuit{X: 2, Y: 4},
+					&squareCommitmentCircuit{X: 3, Y: 9},
+					&squareCommitmentCircuit{X: 3, Y: 10},
+					&squareCommitmentCircuit{X: 4, Y: 16},
+					&squareCommitmentCircuit{X: 5, Y: 25},
+				})
+			}, "commitment")
+			assert.Run(func(assert *test.Assert) {
+				checkProveBatch(assert, curve, &lookupCircuit{}, []frontend.Circuit{
+					&lookupCircuit{X: 11, Y: 6, Z: 11 ^ 6, XSq: 121, W: 5},
+					&lookupCircuit{X: 11, Y: 6, Z: 11 ^ 6, XSq: 121, W: 8},
+					&lookupCircuit{X: 3, Y: 15, Z: 3 ^ 15, XSq: 9, W: 7},
+					&lookupCircuit{X: 1, Y: 2, Z: 1 ^ 2, XSq: 1, W: 0},
+				})
+			}, "lookup")
+		}, curve.String())
+	}
+}
+
+func TestLowMemory(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		curve := curve
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, &lookupCircuit{})
+			assert.NoError(err)
+			srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+			assert.NoError(err)
+			pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+			assert.NoError(err)
+
+			valid, err := frontend.NewWitness(&lookupCircuit{X: 11, Y: 6, Z: 11 ^ 6, XSq: 121, W: 5}, curve.ScalarField())
+			assert.NoError(err)
+			invalid, err := frontend.NewWitness(&lookupCircuit{X: 11, Y: 6, Z: 11 ^ 6, XSq: 121, W: 8}, curve.ScalarField())
+			assert.NoError(err)
+			pubWitness, err := valid.Public()
+			assert.NoError(err)
+
+			// the budget is exhausted right away or after a few vectors
+			for _, budget := range []uint64{0, 4 * uint64(ccs.GetNbConstraints()) * uint64(ccs.Field().BitLen()/8+8)} {
+				dir := t.TempDir()
+				for _, opts := range [][]backend.ProverOption{
+					{backend.WithLowMemory(budget, dir)},
+					{backend.WithLowMemory(budget, dir), backend.WithStatisticalZeroKnowledge()},
+				} {
+					proof, err := plonk.Prove(ccs, pk, valid, opts...)
+					assert.NoError(err)
+					assert.NoError(plonk.Verify(proof, vk, pubWitness))
+
+					_, err = plonk.Prove(ccs, pk, invalid, opts...)
+					assert.Error(err)
+				}
+
+				proofs, errs := plonk.ProveBatch(ccs, pk, []witness.Witness{valid, invalid, valid, valid}, backend.WithLowMemory(budget, dir))
+				for i := range proofs {
+					if i == 1 {
+						assert.Error(errs[i])
+						continue
+					}
+					assert.NoError(errs[i])
+					assert.NoError(plonk.Verify(proofs[i], vk, pubWitness))
+				}
+			}
+
+			// the quotient of the small circuits is evaluated on 8 cosets,
+			// and on 4 without zero knowledge
+			ccs, err = frontend.Compile(curve.ScalarField(), scs.NewBuilder, &smallCircuit{})
+			assert.NoError(err)
+			srs, srsLagrange, err = unsafekzg.NewSRS(ccs)
+			assert.NoError(err)
+			pk, vk, err = plonk.Setup(ccs, srs, srsLagrange)
+			assert.NoError(err)
+			valid, err = frontend.NewWitness(&smallCircuit{X: 1}, curve.ScalarField())
+			assert.NoError(err)
+			pubWitness, err = valid.Public()
+			assert.NoError(err)
+			proof, err := plonk.Prove(ccs, pk, valid, backend.WithLowMemory(0, t.TempDir()))
+			assert.NoError(err)
+			assert.NoError(plonk.Verify(proof, vk, pubWitness))
+			proof, err = plonk.Prove(ccs, pk, valid, backend.WithLowMemory(0, t.TempDir()), backend.WithoutZeroKnowledge())
+			assert.NoError(err)
+			assert.NoError(plonk.Verify(proof, vk, pubWitness, backend.WithVerifierWithoutZeroKnowledge()))
+		}, curve.String())
+	}
+}
+
+func TestWithoutZeroKnowledge(t *testing.T) {
+	assert := test.NewAssert(t)
+	type testCase struct {
+		name           string
+		circuit, valid frontend.Circuit
+		invalid        frontend.Circuit
+	}
+	testCases := []testCase{
+		{"commitment", &squareCommitmentCircuit{}, &squareCommitmentCircuit{X: 3, Y: 9}, &squareCommitmentCircuit{X: 3, Y: 8}},
+		{"custom_gates", &customGateCircuit{}, &customGateCircuit{X: 3, Y: 27, Z: 27 + 112}, &customGateCircuit{X: 3, Y: 27, Z: 27 + 113}},
+		{"lookup", &lookupCircuit{}, &lookupCircuit{X: 11, Y: 6, Z: 11 ^ 6, XSq: 121, W: 5}, &lookupCircuit{X: 11, Y: 6, Z: 11 ^ 6, XSq: 121, W: 8}},
+	}
+	for _, curve := range getCurves() {
+		curve := curve
+		for _, tc := range testCases {
+			tc := tc
+			assert.Run(func(assert *test.Assert) {
+				ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, tc.circuit)
+				assert.NoError(err)
+				srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+				assert.NoError(err)
+				pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+				assert.NoError(err)
+
+				valid, err := frontend.NewWitness(tc.valid, curve.ScalarField())
+				assert.NoError(err)
+				invalid, err := frontend.NewWitness(tc.invalid, curve.ScalarField())
+				assert.NoError(err)
+				pubWitness, err := valid.Public()
+				assert.NoError(err)
+
+				proof, err := plonk.Prove(ccs, pk, valid, backend.WithoutZeroKnowledge())
+				assert.NoError(err)
+				assert.NoError(plonk.Verify(proof, vk, pubWitness, backend.WithVerifierWithoutZeroKnowledge()))
+				assert.Error(plonk.Verify(proof, vk, pubWitness))
+
+				// without blinding, the proofs of a witness are all the same
+				other, err := plonk.Prove(ccs, pk, valid, backend.WithoutZeroKnowledge())
+				assert.NoError(err)
+				assert.Equal(proof, other)
+
+				zkProof, err := plonk.Prove(ccs, pk, valid)
+				assert.NoError(err)
+				assert.Error(plonk.Verify(zkProof, vk, pubWitness, backend.WithVerifierWithoutZeroKnowledge()))
+
+				_, err = plonk.Prove(ccs, pk, invalid, backend.WithoutZeroKnowledge())
+				assert.Error(err)
+
+				proofs, errs := plonk.ProveBatch(ccs, pk, []witness.Witness{valid, invalid}, backend.WithoutZeroKnowledge())
+				assert.NoError(errs[0])
+				assert.Error(errs[1])
+				assert.NoError(plonk.Verify(proofs[0], vk, pubWitness, backend.WithVerifierWithoutZeroKnowledge()))
+
+				_, err = plonk.Prove(ccs, pk, valid, backend.WithoutZeroKnowledge(), backend.WithStatisticalZeroKnowledge())
+				assert.Error(err)
+			}, curve.String(), tc.name)
+		}
+	}
+}
+
+func TestWithoutZeroKnowledgeSmallSRS(t *testing.T) {
+	assert := test.NewAssert(t)
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &lookupCircuit{})
+	assert.NoError(err)
+	srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+	assert.NoError(err)
+
+	// the canonical srs has as many points as the lagrange one, instead of 3 more
+	small := *srs.(*kzg_bn254.SRS)
+	small.Pk.G1 = small.Pk.G1[:len(srsLagrange.(*kzg_bn254.SRS).Pk.G1)]
+	_, _, err = plonk.Setup(ccs, &small, srsLagrange)
+	assert.Error(err, "the zero knowledge setup needs 3 more points")
+	pk, vk, err := plonk.Setup(ccs, &small, srsLagrange, backend.WithSetupWithoutZeroKnowledge())
+	assert.NoError(err)
+
+	w, err := frontend.NewWitness(&lookupCircuit{X: 11, Y: 6, Z: 11 ^ 6, XSq: 121, W: 5}, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	pubWitness, err := w.Public()
+	assert.NoError(err)
+
+	proof, err := plonk.Prove(ccs, pk, w, backend.WithoutZeroKnowledge())
+	assert.NoError(err)
+	assert.NoError(plonk.Verify(proof, vk, pubWitness, backend.WithVerifierWithoutZeroKnowledge()))
+
+	_, err = plonk.Prove(ccs, pk, w)
+	assert.Error(err)
+}
+
+func TestOpen(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		curve := curve
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, &lookupCircuit{})
+			assert.NoError(err)
+			srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+			assert.NoError(err)
+			pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+			assert.NoError(err)
+
+			path := filepath.Join(t.TempDir(), "pk")
+			f, err := os.Create(path)
+			assert.NoError(err)
+			_, err = pk.(gnarkio.Mapper).WriteMmapTo(f)
+			assert.NoError(err)
+			assert.NoError(f.Close())
+
+			mapped, err := plonk.Open(path)
+			assert.NoError(err)
+
+			w, err := frontend.NewWitness(&lookupCircuit{X: 11, Y: 6, Z: 11 ^ 6, XSq: 121, W: 5}, curve.ScalarField())
+			assert.NoError(err)
+			pubWitness, err := w.Public()
+			assert.NoError(err)
+			proof, err := plonk.Prove(ccs, mapped, w)
+			assert.NoError(err)
+			assert.NoError(plonk.Verify(proof, vk, pubWitness))
+
+			assert.NoError(mapped.Close())
+			assert.NoError(mapped.Close())
+
+			// the regular encoding is not mapped
+			var buf bytes.Buffer
+			_, err = pk.WriteRawTo(&buf)
+			assert.NoError(err)
+			assert.NoError(os.WriteFile(path, buf.Bytes(), 0600))
+			_, err = plonk.Open(path)
+			assert.Error(err)
+		}, curve.String())
+	}
+}
+
+// checkProveBatch checks that the proofs of a batch verify if and only if the
+// assignment is valid.
+func checkProveBatch(assert *test.Assert, curve ecc.ID, circuit frontend.Circuit, assignments []frontend.Circuit) {
+	ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, circuit)
+	assert.NoError(err)
+	srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+	assert.NoError(err)
+	pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+	assert.NoError(err)
+
+	witnesses := make([]witness.Witness, len(assignments))
+	for i := range assignments {
+		witnesses[i], err = frontend.NewWitness(assignments[i], curve.ScalarField())
+		assert.NoError(err)
+	}
+
+	proofs, errs := plonk.ProveBatch(ccs, pk, witnesses)
+	assert.Equal(len(witnesses), len(proofs))
+	assert.Equal(len(witnesses), len(errs))
+	for i := range witnesses {
+		_, err := plonk.Prove(ccs, pk, witnesses[i])
+		if err != nil {
+			assert.Error(errs[i], "witness %d", i)
+			assert.Nil(proofs[i], "witness %d", i)
+			continue
+		}
+		assert.NoError(errs[i], "witness %d", i)
+		pubWitness, err := witnesses[i].Public()
+		assert.NoError(err)
+		assert.NoError(plonk.Verify(proofs[i], vk, pubWitness), "witness %d", i)
+
+		// the proof is bound to its own witness
+		j := (i + 1) % len(witnesses)
+		other, err := witnesses[j].Public()
+		assert.NoError(err)
+		assert.Error(plonk.Verify(proofs[i], vk, other), "witness %d", i)
+	}
+}
+
+type squareCommitmentCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *squareCommitmentCircuit) Define(api frontend.API) error {
+	cmt, err := api.(frontend.Committer).Commit(c.X)
+	if err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	api.AssertIsDifferent(cmt, 0)
+	api.AssertIsEqual(api.Mul(c.X, c.X), c.Y)
+	return nil
+}
+
+type lookupCircuit struct {
+	X, Y, XSq frontend.Variable
+	Z, W      frontend.Variable `gnark:",public"`
+}
+
+func (c *lookupCircuit) Define(api frontend.API) error {
+	lk, ok := api.(frontend.Lookuper)
+	if !ok {
+		x, y := api.ToBinary(c.X, 4), api.ToBinary(c.Y, 4)
+		z := make([]frontend.Variable, 4)
+		for i := range z {
+			z[i] = api.Xor(x[i], y[i])
+		}
+		api.AssertIsEqual(api.FromBinary(z...), c.Z)
+		api.AssertIsEqual(api.Mul(c.X, c.X), c.XSq)
+		api.ToBinary(c.W, 3)
+		return nil
+	}
+	xor := make([][]*big.Int, 0, 256)
+	for i := int64(0); i < 16; i++ {
+		for j := int64(0); j < 16; j++ {
+			xor = append(xor, []*big.Int{big.NewInt(i), big.NewInt(j), big.NewInt(i ^ j)})
+		}
+	}
+	square := make([][]*big.Int, 16)
+	for i := range square {
+		square[i] = []*big.Int{big.NewInt(int64(i)), big.NewInt(int64(i * i))}
+	}
+	bits3 := make([][]*big.Int, 8)
+	for i := range bits3 {
+		bits3[i] = []*big.Int{big.NewInt(int64(i))}
+	}
+	xorID, err := lk.DefineLookupTable("xor4", xor)
+	if err != nil {
+		return err
+	}
+	squareID, err := lk.DefineLookupTable("square4", square)
+	if err != nil {
+		return err
+	}
+	bits3ID, err := lk.DefineLookupTable("range3", bits3)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(lk.Lookup(xorID, c.X, c.Y), c.Z)
+	lk.AssertLookup(squareID, c.X, c.XSq)
+	lk.AssertLookup(bits3ID, c.W)
+
+	// mix with a BSB22 commitment
+	cmt, err := api.(frontend.Committer).Commit(c.X, c.Y)
+	if err != nil {
+		return err
+	}
+	api.AssertIsDifferent(cmt, 0)
+	return nil
+}
+
 func BenchmarkSetup(b *testing.B) {
 	for _, curve := range getCurves() {
 		b.Run(curve.String(), func(b *testing.B) {
@@ -226,6 +916,83 @@ func BenchmarkProver(b *testing.B) {
 	}
 }
 
+// BenchmarkLowMemory reports the peak resident set size of the process during
+// the proof, with and without the low memory mode. Unlike the heap statistics
+// of the runtime, it includes the pages of the memory mapped files. It is only
+// supported on Linux, where the peak can be reset, see proc(5).
+func BenchmarkLowMemory(b *testing.B) {
+	if err := resetPeakRSS(); err != nil {
+		b.Skip("can't reset the peak resident set size:", err)
+	}
+	for _, curve := range getCurves() {
+		ccs, _solution, srs, srsLagrange := referenceCircuit(curve)
+		fullWitness, err := frontend.NewWitness(_solution, curve.ScalarField())
+		if err != nil {
+			b.Fatal(err)
+		}
+		pk, _, err := plonk.Setup(ccs, srs, srsLagrange)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, c := range []struct {
+			name string
+			opts []backend.ProverOption
+		}{
+			{"default", nil},
+			{"lowmemory", []backend.ProverOption{backend.WithLowMemory(0, b.TempDir())}},
+		} {
+			b.Run(curve.String()+"/"+c.name, func(b *testing.B) {
+				var peak uint64
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					debug.FreeOSMemory()
+					if err := resetPeakRSS(); err != nil {
+						b.Fatal(err)
+					}
+					start, err := readRSS("VmRSS")
+					if err != nil {
+						b.Fatal(err)
+					}
+					b.StartTimer()
+					if _, err := plonk.Prove(ccs, pk, fullWitness, c.opts...); err != nil {
+						b.Fatal(err)
+					}
+					b.StopTimer()
+					end, err := readRSS("VmHWM")
+					if err != nil {
+						b.Fatal(err)
+					}
+					peak = max(peak, end-min(start, end))
+					b.StartTimer()
+				}
+				b.ReportMetric(float64(peak)/(1<<20), "peak-rss-MiB")
+			})
+		}
+	}
+}
+
+// resetPeakRSS resets the peak resident set size of the process.
+func resetPeakRSS() error {
+	return os.WriteFile("/proc/self/clear_refs", []byte("5"), 0)
+}
+
+// readRSS returns the resident set size of the process in bytes, currently
+// (VmRSS) or at its peak (VmHWM).
+func readRSS(field string) (uint64, error) {
+	status, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(status), "\n") {
+		if v, ok := strings.CutPrefix(line, field+":"); ok {
+			kB, err := strconv.ParseUint(strings.TrimSpace(strings.TrimSuffix(v, "kB")), 10, 64)
+			return kB << 10, err
+		}
+	}
+	return 0, fmt.Errorf("%s not found", field)
+}
+
 func BenchmarkVerifier(b *testing.B) {
 	for _, curve := range getCurves() {
 		b.Run(curve.String(), func(b *testing.B) {
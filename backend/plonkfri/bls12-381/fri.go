@@ -0,0 +1,426 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+package plonkfri
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"math/bits"
+	"strconv"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/fft"
+	fiatshamir "github.com/consensys/gnark-crypto/fiat-shamir"
+)
+
+var (
+	errMerklePath   = errors.New("invalid merkle path")
+	errFriFolding   = errors.New("fri: inconsistent folding")
+	errOpeningShape = errors.New("invalid opening size")
+)
+
+// saltSize is the size in bytes of the random salts of the leaves of the
+// oracles holding secret data.
+const saltSize = 32
+
+// Digest is the root of a Merkle tree.
+type Digest []byte
+
+// Opening is the opening of the leaf of an oracle: the evaluations of its
+// polynomials at x and -x, the salt of the leaf and the Merkle path.
+type Opening struct {
+	// Values are p₀(x), p₀(-x), p₁(x), p₁(-x), ..
+	Values []fr.Element
+	// Salt is empty for the oracles of public polynomials
+	Salt []byte
+	// Path are the siblings of the leaf, from the leaf to the root
+	Path [][]byte
+}
+
+// merkleTree is a Merkle tree on a power of two number of leaves, storing all
+// the nodes: nodes[1] is the root and nodes[i] is the parent of nodes[2i] and
+// nodes[2i+1].
+type merkleTree struct {
+	nodes [][]byte
+}
+
+// hashLeaf and hashNode are domain separated so that a node can not be
+// opened as a leaf.
+func hashLeaf(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func hashNode(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{1})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+func newMerkleTree(leaves [][]byte) *merkleTree {
+	n := len(leaves)
+	t := &merkleTree{nodes: make([][]byte, 2*n)}
+	for i, l := range leaves {
+		t.nodes[n+i] = hashLeaf(l)
+	}
+	for i := n - 1; i > 0; i-- {
+		t.nodes[i] = hashNode(t.nodes[2*i], t.nodes[2*i+1])
+	}
+	return t
+}
+
+func (t *merkleTree) root() Digest {
+	return t.nodes[1]
+}
+
+// path returns the siblings of the leaf i, from the leaf to the root.
+func (t *merkleTree) path(i int) [][]byte {
+	n := len(t.nodes) / 2
+	res := make([][]byte, 0, bits.TrailingZeros(uint(n)))
+	for i += n; i > 1; i /= 2 {
+		res = append(res, t.nodes[i^1])
+	}
+	return res
+}
+
+// verifyMerklePath checks that the leaf i of a tree with nbLeaves leaves and
+// the given root is data.
+func verifyMerklePath(root Digest, data []byte, i, nbLeaves int, path [][]byte) error {
+	if 1<<len(path) != nbLeaves || i < 0 || i >= nbLeaves {
+		return errMerklePath
+	}
+	h := hashLeaf(data)
+	for _, sibling := range path {
+		if i%2 == 0 {
+			h = hashNode(h, sibling)
+		} else {
+			h = hashNode(sibling, h)
+		}
+		i /= 2
+	}
+	if !bytes.Equal(h, root) {
+		return errMerklePath
+	}
+	return nil
+}
+
+// leafData returns the data of a leaf: the salt followed by the values.
+func leafData(salt []byte, values []fr.Element) []byte {
+	res := make([]byte, 0, len(salt)+len(values)*fr.Bytes)
+	res = append(res, salt...)
+	for i := range values {
+		b := values[i].Bytes()
+		res = append(res, b[:]...)
+	}
+	return res
+}
+
+// oracle is the commitment to polynomials evaluated on the coset L = g⟨ω⟩ of
+// size N. The leaf j < N/2 holds the evaluations at x = gωʲ and -x = gωʲ⁺ᴺᐟ²,
+// which are needed together by the FRI folding.
+type oracle struct {
+	evaluations [][]fr.Element
+	salts       [][]byte
+	tree        *merkleTree
+}
+
+// newOracle commits to the evaluations, in natural order, of polynomials on
+// L. The leaves are salted if the polynomials hold secret data.
+func newOracle(evaluations [][]fr.Element, salted bool) (*oracle, error) {
+	n := len(evaluations[0]) / 2
+	o := &oracle{evaluations: evaluations}
+	if salted {
+		o.salts = make([][]byte, n)
+		buf := make([]byte, n*saltSize)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		for j := range o.salts {
+			o.salts[j] = buf[j*saltSize : (j+1)*saltSize]
+		}
+	}
+	leaves := make([][]byte, n)
+	values := make([]fr.Element, 2*len(evaluations))
+	for j := range leaves {
+		o.values(j, values)
+		var salt []byte
+		if salted {
+			salt = o.salts[j]
+		}
+		leaves[j] = leafData(salt, values)
+	}
+	o.tree = newMerkleTree(leaves)
+	return o, nil
+}
+
+func (o *oracle) values(j int, values []fr.Element) {
+	n := len(o.evaluations[0]) / 2
+	for i, e := range o.evaluations {
+		values[2*i].Set(&e[j])
+		values[2*i+1].Set(&e[j+n])
+	}
+}
+
+func (o *oracle) open(j int) Opening {
+	res := Opening{
+		Values: make([]fr.Element, 2*len(o.evaluations)),
+		Path:   o.tree.path(j),
+	}
+	o.values(j, res.Values)
+	if o.salts != nil {
+		res.Salt = o.salts[j]
+	}
+	return res
+}
+
+// verifyOpening checks the opening of the leaf j of the oracle of nbPolynomials
+// polynomials on a domain of size 2*nbLeaves.
+func verifyOpening(root Digest, opening *Opening, j, nbLeaves, nbPolynomials int, salted bool) error {
+	if len(opening.Values) != 2*nbPolynomials || (salted && len(opening.Salt) != saltSize) || (!salted && len(opening.Salt) != 0) {
+		return errOpeningShape
+	}
+	return verifyMerklePath(root, leafData(opening.Salt, opening.Values), j, nbLeaves, opening.Path)
+}
+
+// friParameters are the parameters of the FRI proximity test of a function
+// on L = g⟨ω⟩ of size N to the polynomials of degree < D.
+type friParameters struct {
+	// domain is L, with the coset shift g
+	domain *fft.Domain
+	// nbRounds is log₂(D)
+	nbRounds  int
+	nbQueries int
+}
+
+// FRIProof is the proof of proximity of the function f₀, whose evaluations are
+// opened by the caller at the query positions.
+type FRIProof struct {
+	// Layers are the roots of the oracles of the folded functions f₁..f_{R-1}
+	Layers []Digest
+	// Final is the value of the constant function f_R
+	Final fr.Element
+}
+
+// challenges returns the names of the challenges of the FRI rounds and of
+// the queries.
+func (p *friParameters) challenges() []string {
+	res := make([]string, p.nbRounds+1)
+	for i := 0; i < p.nbRounds; i++ {
+		res[i] = "fri" + strconv.Itoa(i)
+	}
+	res[p.nbRounds] = "queries"
+	return res
+}
+
+// fold returns f(y) for y = x² from f(x) and f(-x), that is
+// (f(x) + f(-x))/2 + β(f(x) - f(-x))/(2x), with xInv = 1/x.
+func fold(fx, fmx, beta, xInv *fr.Element) fr.Element {
+	var sum, diff fr.Element
+	sum.Add(fx, fmx)
+	diff.Sub(fx, fmx).Mul(&diff, xInv).Mul(&diff, beta)
+	sum.Add(&sum, &diff).Mul(&sum, &twoInv)
+	return sum
+}
+
+var twoInv fr.Element
+
+func init() {
+	twoInv.SetUint64(2).Inverse(&twoInv)
+}
+
+// prove commits to the successive foldings of f, the evaluations on L of a
+// polynomial of degree < D in natural order, and returns the proof, the query
+// positions in [0, N/2) and the oracles of the layers to open.
+func (p *friParameters) prove(fs *fiatshamir.Transcript, f []fr.Element) (FRIProof, []int, []*oracle, error) {
+	proof := FRIProof{Layers: make([]Digest, 0, p.nbRounds-1)}
+	names := p.challenges()
+	layers := make([]*oracle, 0, p.nbRounds-1)
+
+	var shiftInv, generatorInv fr.Element
+	shiftInv.Set(&p.domain.FrMultiplicativeGenInv)
+	generatorInv.Set(&p.domain.GeneratorInv)
+	for r := 0; r < p.nbRounds; r++ {
+		if r > 0 {
+			layer, err := newOracle([][]fr.Element{f}, false)
+			if err != nil {
+				return proof, nil, nil, err
+			}
+			layers = append(layers, layer)
+			proof.Layers = append(proof.Layers, layer.tree.root())
+			if err := fs.Bind(names[r], layer.tree.root()); err != nil {
+				return proof, nil, nil, err
+			}
+		}
+		beta, err := deriveChallenge(fs, names[r])
+		if err != nil {
+			return proof, nil, nil, err
+		}
+
+		// f_{r+1}(x²) from f_r(x) and f_r(-x), x = gωᵖ
+		half := len(f) / 2
+		next := make([]fr.Element, half)
+		xInv := shiftInv
+		for j := 0; j < half; j++ {
+			next[j] = fold(&f[j], &f[j+half], &beta, &xInv)
+			xInv.Mul(&xInv, &generatorInv)
+		}
+		f = next
+		shiftInv.Square(&shiftInv)
+		generatorInv.Square(&generatorInv)
+	}
+
+	// f_R is constant if the degree was < D
+	proof.Final.Set(&f[0])
+	for j := range f {
+		if !f[j].Equal(&proof.Final) {
+			return proof, nil, nil, errors.New("fri: the function is not of low degree")
+		}
+	}
+	queries, err := p.deriveQueries(fs, &proof.Final)
+	return proof, queries, layers, err
+}
+
+// openLayers returns the openings of the layers for the query j.
+func openLayers(layers []*oracle, j int) []Opening {
+	res := make([]Opening, len(layers))
+	for r, layer := range layers {
+		half := len(layer.evaluations[0]) / 2
+		j %= 2 * half
+		res[r] = layer.open(j % half)
+	}
+	return res
+}
+
+// deriveQueries derives the query positions in [0, N/2) from the transcript.
+func (p *friParameters) deriveQueries(fs *fiatshamir.Transcript, final *fr.Element) ([]int, error) {
+	names := p.challenges()
+	if err := fs.Bind(names[p.nbRounds], final.Marshal()); err != nil {
+		return nil, err
+	}
+	seed, err := fs.ComputeChallenge(names[p.nbRounds])
+	if err != nil {
+		return nil, err
+	}
+	half := new(big.Int).SetUint64(p.domain.Cardinality / 2)
+	res := make([]int, p.nbQueries)
+	var b big.Int
+	for i := range res {
+		h := sha256.New()
+		h.Write(seed)
+		h.Write([]byte{byte(i >> 24), byte(i >> 16), byte(i >> 8), byte(i)})
+		res[i] = int(b.SetBytes(h.Sum(nil)).Mod(&b, half).Int64())
+	}
+	return res, nil
+}
+
+// replay replays the transcript of the FRI proof and returns the folding
+// challenges and the query positions.
+func (p *friParameters) replay(fs *fiatshamir.Transcript, proof *FRIProof) ([]fr.Element, []int, error) {
+	if len(proof.Layers) != p.nbRounds-1 {
+		return nil, nil, errOpeningShape
+	}
+	names := p.challenges()
+	betas := make([]fr.Element, p.nbRounds)
+	for r := 0; r < p.nbRounds; r++ {
+		if r > 0 {
+			if err := fs.Bind(names[r], proof.Layers[r-1]); err != nil {
+				return nil, nil, err
+			}
+		}
+		var err error
+		if betas[r], err = deriveChallenge(fs, names[r]); err != nil {
+			return nil, nil, err
+		}
+	}
+	queries, err := p.deriveQueries(fs, &proof.Final)
+	if err != nil {
+		return nil, nil, err
+	}
+	return betas, queries, nil
+}
+
+// verify checks the FRI proof given the challenges and query positions
+// returned by replay, and for each query j, the values f₀(x) and f₀(-x) at
+// x = gωʲ computed by the caller from the openings of the oracles, and the
+// openings of the layers.
+func (p *friParameters) verify(proof *FRIProof, betas []fr.Element, queries []int, f0 [][2]fr.Element, layers [][]Opening) error {
+	if len(f0) != len(queries) || len(layers) != len(queries) {
+		return errOpeningShape
+	}
+
+	n := int(p.domain.Cardinality)
+	for q, j := range queries {
+		if len(layers[q]) != p.nbRounds-1 {
+			return errOpeningShape
+		}
+		var shift, generator fr.Element
+		shift.Set(&p.domain.FrMultiplicativeGen)
+		generator.Set(&p.domain.Generator)
+		pair := f0[q]
+		size := n
+		for r := 0; r < p.nbRounds; r++ {
+			// x = shift⋅generatorʲ and -x are the fiber of x²
+			var xInv fr.Element
+			xInv.Exp(generator, big.NewInt(int64(j)))
+			xInv.Mul(&xInv, &shift).Inverse(&xInv)
+			v := fold(&pair[0], &pair[1], &betas[r], &xInv)
+
+			size /= 2
+			shift.Square(&shift)
+			generator.Square(&generator)
+			if r == p.nbRounds-1 {
+				if !v.Equal(&proof.Final) {
+					return errFriFolding
+				}
+				break
+			}
+
+			// v = f_{r+1}[j] is in the leaf j mod size/2 of the layer r+1
+			opening := &layers[q][r]
+			half := size / 2
+			if err := verifyOpening(proof.Layers[r], opening, j%half, half, 1, false); err != nil {
+				return err
+			}
+			if !opening.Values[j/half].Equal(&v) {
+				return errFriFolding
+			}
+			pair = [2]fr.Element{opening.Values[0], opening.Values[1]}
+			j %= half
+		}
+	}
+	return nil
+}
+
+// deriveChallenge computes the challenge name of the transcript as a field
+// element.
+func deriveChallenge(fs *fiatshamir.Transcript, name string) (fr.Element, error) {
+	b, err := fs.ComputeChallenge(name)
+	if err != nil {
+		return fr.Element{}, err
+	}
+	var res fr.Element
+	res.SetBytes(b)
+	return res, nil
+}
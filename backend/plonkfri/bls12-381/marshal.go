@@ -0,0 +1,408 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+package plonkfri
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/fft"
+)
+
+// maxLength bounds the lengths read from an encoding, so that a corrupted
+// input can not trigger huge allocations
+const maxLength = 1 << 32
+
+var errInvalidEncoding = errors.New("invalid encoding")
+
+// WriteRawTo writes binary encoding of Proof to w, the proof has no points so
+// it is the same as WriteTo
+func (proof *Proof) WriteRawTo(w io.Writer) (int64, error) {
+	return proof.WriteTo(w)
+}
+
+// WriteTo writes binary encoding of Proof to w
+func (proof *Proof) WriteTo(w io.Writer) (int64, error) {
+	enc := encoder{w: w}
+	enc.uint64(uint64(len(proof.Bsb22Commitments)))
+	for _, c := range proof.Bsb22Commitments {
+		enc.bytes(c)
+	}
+	enc.bytes(proof.Wires)
+	enc.bytes(proof.Z)
+	enc.bytes(proof.H)
+	enc.elements(proof.ClaimedValues)
+	enc.element(&proof.ZShiftedValue)
+	enc.uint64(uint64(len(proof.FRI.Layers)))
+	for _, l := range proof.FRI.Layers {
+		enc.bytes(l)
+	}
+	enc.element(&proof.FRI.Final)
+	enc.uint64(uint64(len(proof.Queries)))
+	for i := range proof.Queries {
+		q := &proof.Queries[i]
+		for _, o := range []*Opening{&q.Preprocessed, &q.Wires, &q.Z, &q.H} {
+			enc.opening(o)
+		}
+		// the openings of the commitments are only there if there are any
+		if len(proof.Bsb22Commitments) != 0 {
+			if len(q.Bsb22) != len(proof.Bsb22Commitments) {
+				return enc.n, errInvalidEncoding
+			}
+			enc.opening(&q.Qcp)
+			for j := range q.Bsb22 {
+				enc.opening(&q.Bsb22[j])
+			}
+		}
+		enc.uint64(uint64(len(q.Layers)))
+		for j := range q.Layers {
+			enc.opening(&q.Layers[j])
+		}
+	}
+	return enc.n, enc.err
+}
+
+// ReadFrom reads binary representation of Proof from r
+func (proof *Proof) ReadFrom(r io.Reader) (int64, error) {
+	dec := decoder{r: r}
+	proof.Bsb22Commitments = make([]Digest, 0, 4)
+	for l := dec.length(); l > 0 && dec.err == nil; l-- {
+		proof.Bsb22Commitments = append(proof.Bsb22Commitments, dec.bytes())
+	}
+	proof.Wires = dec.bytes()
+	proof.Z = dec.bytes()
+	proof.H = dec.bytes()
+	proof.ClaimedValues = dec.elements()
+	dec.element(&proof.ZShiftedValue)
+	proof.FRI.Layers = make([]Digest, 0, 64)
+	for l := dec.length(); l > 0 && dec.err == nil; l-- {
+		proof.FRI.Layers = append(proof.FRI.Layers, dec.bytes())
+	}
+	dec.element(&proof.FRI.Final)
+	proof.Queries = make([]Query, 0, 64)
+	for l := dec.length(); l > 0 && dec.err == nil; l-- {
+		q := Query{Layers: make([]Opening, 0, 64)}
+		for _, o := range []*Opening{&q.Preprocessed, &q.Wires, &q.Z, &q.H} {
+			dec.opening(o)
+		}
+		q.Bsb22 = make([]Opening, len(proof.Bsb22Commitments))
+		if len(q.Bsb22) != 0 {
+			dec.opening(&q.Qcp)
+			for j := range q.Bsb22 {
+				dec.opening(&q.Bsb22[j])
+			}
+		}
+		for l := dec.length(); l > 0 && dec.err == nil; l-- {
+			var o Opening
+			dec.opening(&o)
+			q.Layers = append(q.Layers, o)
+		}
+		proof.Queries = append(proof.Queries, q)
+	}
+	return dec.n, dec.err
+}
+
+// WriteTo writes binary encoding of ProvingKey to w
+func (pk *ProvingKey) WriteTo(w io.Writer) (int64, error) {
+	n, err := pk.Vk.WriteTo(w)
+	if err != nil {
+		return n, err
+	}
+	enc := encoder{w: w, n: n}
+	for i := range pk.Preprocessed {
+		enc.elements(pk.Preprocessed[i])
+	}
+	enc.uint64(uint64(len(pk.Permutation)))
+	for _, p := range pk.Permutation {
+		enc.uint64(uint64(p))
+	}
+	enc.uint64(uint64(len(pk.Qcp)))
+	for i := range pk.Qcp {
+		enc.elements(pk.Qcp[i])
+	}
+	return enc.n, enc.err
+}
+
+// WriteRawTo writes binary encoding of ProvingKey to w, the key has no points
+// so it is the same as WriteTo
+func (pk *ProvingKey) WriteRawTo(w io.Writer) (int64, error) {
+	return pk.WriteTo(w)
+}
+
+// ReadFrom reads from binary representation in r into ProvingKey, and checks
+// that the preprocessed polynomials match the commitment of the verifying key
+func (pk *ProvingKey) ReadFrom(r io.Reader) (int64, error) {
+	return pk.readFrom(r, true)
+}
+
+// UnsafeReadFrom reads from binary representation in r into ProvingKey without
+// checking the preprocessed polynomials against the verifying key
+func (pk *ProvingKey) UnsafeReadFrom(r io.Reader) (int64, error) {
+	return pk.readFrom(r, false)
+}
+
+func (pk *ProvingKey) readFrom(r io.Reader, withChecks bool) (int64, error) {
+	pk.Vk = &VerifyingKey{}
+	n, err := pk.Vk.ReadFrom(r)
+	if err != nil {
+		return n, err
+	}
+	dec := decoder{r: r, n: n}
+	for i := range pk.Preprocessed {
+		pk.Preprocessed[i] = dec.elements()
+		if dec.err == nil && len(pk.Preprocessed[i]) != int(pk.Vk.Size) {
+			return dec.n, errInvalidEncoding
+		}
+	}
+	l := dec.length()
+	if l != 3*int(pk.Vk.Size) {
+		return dec.n, errInvalidEncoding
+	}
+	pk.Permutation = make([]int64, l)
+	for i := range pk.Permutation {
+		pk.Permutation[i] = int64(dec.uint64())
+		if dec.err == nil && uint64(pk.Permutation[i]) >= 3*pk.Vk.Size {
+			return dec.n, errInvalidEncoding
+		}
+	}
+	if l := dec.length(); dec.err == nil && l != len(pk.Vk.CommitmentConstraintIndexes) {
+		return dec.n, errInvalidEncoding
+	}
+	pk.Qcp = make([][]fr.Element, len(pk.Vk.CommitmentConstraintIndexes))
+	for i := range pk.Qcp {
+		pk.Qcp[i] = dec.elements()
+		if dec.err == nil && len(pk.Qcp[i]) != int(pk.Vk.Size) {
+			return dec.n, errInvalidEncoding
+		}
+	}
+	if dec.err != nil {
+		return dec.n, dec.err
+	}
+
+	// the oracles are not serialized
+	if err := pk.computeOracle(); err != nil {
+		return dec.n, err
+	}
+	if withChecks && !bytes.Equal(pk.oracle.tree.root(), pk.Vk.Preprocessed) {
+		return dec.n, errors.New("preprocessed polynomials do not match the verifying key")
+	}
+	if withChecks && pk.qcpOracle != nil && !bytes.Equal(pk.qcpOracle.tree.root(), pk.Vk.Qcp) {
+		return dec.n, errors.New("commitment selectors do not match the verifying key")
+	}
+	return dec.n, nil
+}
+
+// WriteTo writes binary encoding of VerifyingKey to w
+func (vk *VerifyingKey) WriteTo(w io.Writer) (int64, error) {
+	enc := encoder{w: w}
+	enc.uint64(vk.Size)
+	enc.uint64(vk.NbPublicVariables)
+	enc.uint64(vk.RateLog)
+	enc.uint64(vk.NbQueries)
+	enc.bytes(vk.Preprocessed)
+	enc.uint64(uint64(len(vk.CommitmentConstraintIndexes)))
+	for _, cci := range vk.CommitmentConstraintIndexes {
+		enc.uint64(cci)
+	}
+	enc.bytes(vk.Qcp)
+	return enc.n, enc.err
+}
+
+// WriteRawTo writes binary encoding of VerifyingKey to w, the key has no
+// points so it is the same as WriteTo
+func (vk *VerifyingKey) WriteRawTo(w io.Writer) (int64, error) {
+	return vk.WriteTo(w)
+}
+
+// UnsafeReadFrom reads from binary representation in r into VerifyingKey,
+// the key has no points so it is the same as ReadFrom
+func (vk *VerifyingKey) UnsafeReadFrom(r io.Reader) (int64, error) {
+	return vk.ReadFrom(r)
+}
+
+// ReadFrom reads from binary representation in r into VerifyingKey
+func (vk *VerifyingKey) ReadFrom(r io.Reader) (int64, error) {
+	dec := decoder{r: r}
+	vk.Size = dec.uint64()
+	vk.NbPublicVariables = dec.uint64()
+	vk.RateLog = dec.uint64()
+	vk.NbQueries = dec.uint64()
+	vk.Preprocessed = dec.bytes()
+	vk.CommitmentConstraintIndexes = make([]uint64, 0, 4)
+	for l := dec.length(); l > 0 && dec.err == nil; l-- {
+		vk.CommitmentConstraintIndexes = append(vk.CommitmentConstraintIndexes, dec.uint64())
+	}
+	vk.Qcp = dec.bytes()
+	if dec.err != nil {
+		return dec.n, dec.err
+	}
+	if _, err := vk.parameters(); err != nil {
+		return dec.n, err
+	}
+	if vk.NbPublicVariables > vk.Size {
+		return dec.n, errInvalidEncoding
+	}
+	for _, cci := range vk.CommitmentConstraintIndexes {
+		if cci >= vk.Size-vk.NbPublicVariables {
+			return dec.n, errInvalidEncoding
+		}
+	}
+
+	// the derived values are recomputed
+	domain := fft.NewDomain(vk.Size, fft.WithoutPrecompute())
+	vk.SizeInv.Set(&domain.CardinalityInv)
+	vk.Generator.Set(&domain.Generator)
+	vk.CosetShift.Set(&domain.FrMultiplicativeGen)
+	return dec.n, nil
+}
+
+// encoder writes big endian integers, length prefixed byte slices and field
+// elements, and keeps the first error
+type encoder struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (enc *encoder) write(b []byte) {
+	if enc.err != nil {
+		return
+	}
+	var n int
+	n, enc.err = enc.w.Write(b)
+	enc.n += int64(n)
+}
+
+func (enc *encoder) uint64(v uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	enc.write(buf[:])
+}
+
+func (enc *encoder) bytes(b []byte) {
+	enc.uint64(uint64(len(b)))
+	enc.write(b)
+}
+
+func (enc *encoder) element(e *fr.Element) {
+	b := e.Bytes()
+	enc.write(b[:])
+}
+
+func (enc *encoder) elements(v []fr.Element) {
+	enc.uint64(uint64(len(v)))
+	for i := range v {
+		enc.element(&v[i])
+	}
+}
+
+func (enc *encoder) opening(o *Opening) {
+	enc.elements(o.Values)
+	enc.bytes(o.Salt)
+	enc.uint64(uint64(len(o.Path)))
+	for _, p := range o.Path {
+		enc.bytes(p)
+	}
+}
+
+// decoder reads the encoding of encoder, and keeps the first error
+type decoder struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+func (dec *decoder) read(b []byte) {
+	if dec.err != nil {
+		return
+	}
+	var n int
+	n, dec.err = io.ReadFull(dec.r, b)
+	dec.n += int64(n)
+}
+
+func (dec *decoder) uint64() uint64 {
+	var buf [8]byte
+	dec.read(buf[:])
+	return binary.BigEndian.Uint64(buf[:])
+}
+
+// length reads a length, which is 0 after an error
+func (dec *decoder) length() int {
+	l := dec.uint64()
+	if dec.err == nil && l >= maxLength {
+		dec.err = errInvalidEncoding
+	}
+	if dec.err != nil {
+		return 0
+	}
+	return int(l)
+}
+
+func (dec *decoder) bytes() []byte {
+	l := dec.length()
+	if l == 0 {
+		return nil
+	}
+	res := make([]byte, 0, min(l, 1<<16))
+	var buf [1 << 12]byte
+	for l > 0 && dec.err == nil {
+		chunk := buf[:min(l, len(buf))]
+		dec.read(chunk)
+		res = append(res, chunk...)
+		l -= len(chunk)
+	}
+	return res
+}
+
+func (dec *decoder) element(e *fr.Element) {
+	var buf [fr.Bytes]byte
+	dec.read(buf[:])
+	if dec.err != nil {
+		return
+	}
+	dec.err = e.SetBytesCanonical(buf[:])
+}
+
+func (dec *decoder) elements() []fr.Element {
+	l := dec.length()
+	res := make([]fr.Element, 0, min(l, 1<<16))
+	for ; l > 0 && dec.err == nil; l-- {
+		var e fr.Element
+		dec.element(&e)
+		res = append(res, e)
+	}
+	return res
+}
+
+func (dec *decoder) opening(o *Opening) {
+	o.Values = dec.elements()
+	o.Salt = dec.bytes()
+	l := dec.length()
+	if l > 64 {
+		dec.err = errInvalidEncoding
+		l = 0
+	}
+	o.Path = make([][]byte, 0, l)
+	for ; l > 0 && dec.err == nil; l-- {
+		o.Path = append(o.Path, dec.bytes())
+	}
+}
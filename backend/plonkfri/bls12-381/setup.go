@@ -0,0 +1,385 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+package plonkfri
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/consensys/gnark-crypto/ecc"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/fft"
+	"github.com/consensys/gnark/constraint"
+
+	cs "github.com/consensys/gnark/constraint/bls12-381"
+)
+
+// indices of the preprocessed polynomials in ProvingKey.Preprocessed and in
+// the preprocessed oracle
+const (
+	id_Ql = iota
+	id_Qr
+	id_Qm
+	id_Qo
+	id_Qk
+	id_S1
+	id_S2
+	id_S3
+	nb_preprocessed
+)
+
+// VerifyingKey stores the data needed to verify a proof:
+// * the size of the circuit and the number of public inputs
+// * the parameters of the FRI commitment scheme
+// * the root of the oracle of the preprocessed polynomials ql, qr, qm, qo, qk
+// (without the public inputs) and s1, s2, s3
+// * the indexes of the constraints defining the BSB22 commitments and the root
+// of the oracle of their selectors qcp
+type VerifyingKey struct {
+	// Size circuit, that is the closest power of 2 bounding above
+	// number of constraints+number of public inputs
+	Size              uint64
+	SizeInv           fr.Element
+	Generator         fr.Element
+	NbPublicVariables uint64
+
+	// cosetShift generator of the coset on the small domain
+	CosetShift fr.Element
+
+	// RateLog is log₂ of the blowup factor of the Reed-Solomon code
+	RateLog uint64
+	// NbQueries is the number of FRI queries
+	NbQueries uint64
+
+	// Preprocessed is the root of the oracle of ql, qr, qm, qo, qk, s1, s2, s3
+	Preprocessed Digest
+
+	// CommitmentConstraintIndexes are the indexes of the constraints whose qk
+	// is the value of a commitment, as for a public input
+	CommitmentConstraintIndexes []uint64
+	// Qcp is the root of the oracle of the selectors of the committed wires,
+	// empty if the circuit has no commitment
+	Qcp Digest
+}
+
+// ProvingKey stores the data needed to generate a proof
+type ProvingKey struct {
+	// Verifying Key is embedded into the proving key (needed by Prove)
+	Vk *VerifyingKey
+
+	// Preprocessed are ql, qr, qm, qo, qk, s1, s2, s3 in canonical basis. qk
+	// is zero on the rows of the public inputs, it is completed by the prover.
+	Preprocessed [nb_preprocessed][]fr.Element
+
+	// Permutation position -> permuted position, in [0, 3*Size)
+	Permutation []int64
+
+	// Qcp are the selectors of the committed wires of each commitment in
+	// canonical basis, qcpᵢ is one on the constraints of the committed wires
+	Qcp [][]fr.Element
+
+	// oracles of the preprocessed polynomials and of the selectors qcp,
+	// computed from Preprocessed and Qcp
+	oracle, qcpOracle *oracle
+}
+
+// parameters are the sizes derived from the verifying key
+type parameters struct {
+	// n is the size of the circuit and k the number of random coefficients of
+	// the blinding polynomials
+	n, k int
+	// pieceSize is the size of the pieces of the quotient, and a bound on the
+	// degree of the blinded polynomials
+	pieceSize int
+	// smallDomain is ⟨ω⟩ of size n, bigDomain the coset of size ≥ 4(n+k) on
+	// which the quotient is computed
+	smallDomain, bigDomain *fft.Domain
+	fri                    friParameters
+}
+
+func (vk *VerifyingKey) parameters() (*parameters, error) {
+	if vk.Size < 2 || vk.Size&(vk.Size-1) != 0 || vk.NbQueries == 0 || vk.RateLog == 0 || vk.RateLog > 16 {
+		return nil, errors.New("invalid verifying key parameters")
+	}
+	var p parameters
+	p.n = int(vk.Size)
+	// the blinding polynomials mask the openings at ζ (and ωζ for z) and at
+	// the 2 points of each query
+	p.k = 2*int(vk.NbQueries) + 2
+	p.pieceSize = p.n + p.k
+	degreeBound := ecc.NextPowerOfTwo(uint64(p.pieceSize + p.k))
+	p.smallDomain = fft.NewDomain(vk.Size)
+	p.bigDomain = fft.NewDomain(ecc.NextPowerOfTwo(uint64(4 * p.pieceSize)))
+	p.fri = friParameters{
+		domain:    fft.NewDomain(degreeBound << vk.RateLog),
+		nbRounds:  bits.TrailingZeros64(degreeBound),
+		nbQueries: int(vk.NbQueries),
+	}
+	return &p, nil
+}
+
+// Setup sets proving and verifying keys. The proofs are built with a FRI
+// commitment of blowup factor 2^rateLog and nbQueries queries.
+func Setup(spr *cs.SparseR1CS, rateLog, nbQueries int) (*ProvingKey, *VerifyingKey, error) {
+	if rateLog < 1 || rateLog > 16 {
+		return nil, nil, fmt.Errorf("blowup factor must be a power of two between 2 and 2^16")
+	}
+	if nbQueries < 1 {
+		return nil, nil, fmt.Errorf("number of queries must be positive")
+	}
+	if err := checkConstraintSystem(spr); err != nil {
+		return nil, nil, err
+	}
+	commitmentInfo := spr.CommitmentInfo.(constraint.PlonkCommitments)
+
+	var pk ProvingKey
+	var vk VerifyingKey
+	pk.Vk = &vk
+
+	// fft domain
+	sizeSystem := uint64(spr.GetNbConstraints() + len(spr.Public)) // len(spr.Public) is for the placeholder constraints
+	domain := fft.NewDomain(sizeSystem)
+	if domain.Cardinality < 2 {
+		return nil, nil, fmt.Errorf("circuit has only %d constraints; unsupported by the current implementation", sizeSystem)
+	}
+	vk.Size = domain.Cardinality
+	vk.SizeInv.SetUint64(vk.Size).Inverse(&vk.SizeInv)
+	vk.Generator.Set(&domain.Generator)
+	vk.NbPublicVariables = uint64(len(spr.Public))
+	vk.CosetShift.Set(&domain.FrMultiplicativeGen)
+	vk.RateLog = uint64(rateLog)
+	vk.NbQueries = uint64(nbQueries)
+	vk.CommitmentConstraintIndexes = make([]uint64, len(commitmentInfo))
+	for i := range commitmentInfo {
+		vk.CommitmentConstraintIndexes[i] = uint64(commitmentInfo[i].CommitmentIndex)
+	}
+
+	// public polynomials corresponding to constraints: [ placeholders | constraints | padding ]
+	n := int(vk.Size)
+	for i := range pk.Preprocessed {
+		pk.Preprocessed[i] = make([]fr.Element, n)
+	}
+	for i := 0; i < len(spr.Public); i++ { // placeholders (-PUB_INPUT_i + qk_i = 0)
+		pk.Preprocessed[id_Ql][i].SetOne().Neg(&pk.Preprocessed[id_Ql][i])
+	}
+	offset := len(spr.Public)
+	j := 0
+	it := spr.GetSparseR1CIterator()
+	for c := it.Next(); c != nil; c = it.Next() {
+		pk.Preprocessed[id_Ql][offset+j].Set(&spr.Coefficients[c.QL])
+		pk.Preprocessed[id_Qr][offset+j].Set(&spr.Coefficients[c.QR])
+		pk.Preprocessed[id_Qm][offset+j].Set(&spr.Coefficients[c.QM])
+		pk.Preprocessed[id_Qo][offset+j].Set(&spr.Coefficients[c.QO])
+		pk.Preprocessed[id_Qk][offset+j].Set(&spr.Coefficients[c.QC])
+		j++
+	}
+
+	// permutation and s1, s2, s3 in Lagrange basis
+	buildPermutation(spr, &pk)
+	support := getSupportPermutation(domain)
+	for i := 0; i < n; i++ {
+		pk.Preprocessed[id_S1][i].Set(&support[pk.Permutation[i]])
+		pk.Preprocessed[id_S2][i].Set(&support[pk.Permutation[n+i]])
+		pk.Preprocessed[id_S3][i].Set(&support[pk.Permutation[2*n+i]])
+	}
+
+	for i := range pk.Preprocessed {
+		toCanonical(domain, pk.Preprocessed[i])
+	}
+
+	// qcpᵢ in Lagrange basis, one on the constraints of the committed wires
+	pk.Qcp = make([][]fr.Element, len(commitmentInfo))
+	for i := range commitmentInfo {
+		pk.Qcp[i] = make([]fr.Element, n)
+		for _, committed := range commitmentInfo[i].Committed {
+			pk.Qcp[i][offset+committed].SetOne()
+		}
+		toCanonical(domain, pk.Qcp[i])
+	}
+
+	// commit to the preprocessed polynomials
+	if err := pk.computeOracle(); err != nil {
+		return nil, nil, err
+	}
+	vk.Preprocessed = pk.oracle.tree.root()
+	if pk.qcpOracle != nil {
+		vk.Qcp = pk.qcpOracle.tree.root()
+	}
+
+	return &pk, &vk, nil
+}
+
+// checkConstraintSystem returns an error if the constraint system uses
+// features not supported by the backend.
+func checkConstraintSystem(spr *cs.SparseR1CS) error {
+	it := spr.GetInstructionIterator()
+	for blueprint, _, ok := it.Next(); ok; blueprint, _, ok = it.Next() {
+		switch blueprint.(type) {
+		case constraint.BlueprintSparseR1C, constraint.BlueprintHint:
+		default:
+			if blueprint.NbConstraints() != 0 {
+				return fmt.Errorf("blueprint %T is not supported by the FRI backend", blueprint)
+			}
+		}
+	}
+	its := spr.GetSparseR1CIterator()
+	for c := its.Next(); c != nil; c = its.Next() {
+		if c.CustomGate != 0 || c.Lookup != 0 {
+			return errors.New("custom gates and lookups are not supported by the FRI backend")
+		}
+	}
+	return nil
+}
+
+// computeOracle evaluates the preprocessed polynomials and the selectors qcp
+// on the FRI domain and commits to them.
+func (pk *ProvingKey) computeOracle() error {
+	p, err := pk.Vk.parameters()
+	if err != nil {
+		return err
+	}
+	evaluations := make([][]fr.Element, nb_preprocessed)
+	for i := range evaluations {
+		evaluations[i] = evaluateOnCoset(p.fri.domain, pk.Preprocessed[i])
+	}
+	if pk.oracle, err = newOracle(evaluations, false); err != nil {
+		return err
+	}
+	pk.qcpOracle = nil
+	if len(pk.Qcp) == 0 {
+		return nil
+	}
+	evaluations = make([][]fr.Element, len(pk.Qcp))
+	for i := range evaluations {
+		evaluations[i] = evaluateOnCoset(p.fri.domain, pk.Qcp[i])
+	}
+	pk.qcpOracle, err = newOracle(evaluations, false)
+	return err
+}
+
+// toCanonical converts p from Lagrange basis on domain to canonical basis, in
+// place.
+func toCanonical(domain *fft.Domain, p []fr.Element) {
+	domain.FFTInverse(p, fft.DIF)
+	fft.BitReverse(p)
+}
+
+// evaluateOnCoset returns the evaluations, in natural order, of the polynomial
+// of canonical coefficients p on the coset of domain.
+func evaluateOnCoset(domain *fft.Domain, p []fr.Element) []fr.Element {
+	res := make([]fr.Element, domain.Cardinality)
+	copy(res, p)
+	domain.FFT(res, fft.DIF, fft.OnCoset())
+	fft.BitReverse(res)
+	return res
+}
+
+// buildPermutation builds the Permutation associated with a circuit.
+//
+// The permutation s is composed of cycles of maximum length such that
+//
+//	s. (l∥r∥o) = (l∥r∥o)
+//
+// , where l∥r∥o is the concatenation of the indices of l, r, o in
+// ql.l+qr.r+qm.l.r+qo.O+k = 0.
+//
+// The permutation is encoded as a slice s of size 3*size(l), where the
+// i-th entry of l∥r∥o is sent to the s[i]-th entry, so it acts on a tab
+// like this: for i in tab: tab[i] = tab[permutation[i]]
+func buildPermutation(spr *cs.SparseR1CS, pk *ProvingKey) {
+
+	nbVariables := spr.NbInternalVariables + len(spr.Public) + len(spr.Secret)
+	sizeSolution := int(pk.Vk.Size)
+
+	// init permutation
+	pk.Permutation = make([]int64, 3*sizeSolution)
+	for i := 0; i < len(pk.Permutation); i++ {
+		pk.Permutation[i] = -1
+	}
+
+	// init LRO position -> variable_ID
+	lro := make([]int, 3*sizeSolution) // position -> variable_ID
+	for i := 0; i < len(spr.Public); i++ {
+		lro[i] = i // IDs of LRO associated to placeholders (only L needs to be taken care of)
+	}
+
+	offset := len(spr.Public)
+
+	j := 0
+	it := spr.GetSparseR1CIterator()
+	for c := it.Next(); c != nil; c = it.Next() {
+		lro[offset+j] = int(c.XA)
+		lro[sizeSolution+offset+j] = int(c.XB)
+		lro[2*sizeSolution+offset+j] = int(c.XC)
+		j++
+	}
+
+	// init cycle:
+	// map ID -> last position the ID was seen
+	cycle := make([]int64, nbVariables)
+	for i := 0; i < len(cycle); i++ {
+		cycle[i] = -1
+	}
+
+	for i := 0; i < len(lro); i++ {
+		if cycle[lro[i]] != -1 {
+			// if != -1, it means we already encountered this value
+			// so we need to set the corresponding permutation index.
+			pk.Permutation[i] = cycle[lro[i]]
+		}
+		cycle[lro[i]] = int64(i)
+	}
+
+	// complete the Permutation by filling the first IDs encountered
+	for i := 0; i < len(pk.Permutation); i++ {
+		if pk.Permutation[i] == -1 {
+			pk.Permutation[i] = cycle[lro[i]]
+		}
+	}
+}
+
+// getSupportPermutation returns the support on which the permutation acts, it is
+// <g> || u<g> || u^{2}<g>
+func getSupportPermutation(domain *fft.Domain) []fr.Element {
+
+	res := make([]fr.Element, 3*domain.Cardinality)
+
+	res[0].SetOne()
+	res[domain.Cardinality].Set(&domain.FrMultiplicativeGen)
+	res[2*domain.Cardinality].Square(&domain.FrMultiplicativeGen)
+
+	for i := uint64(1); i < domain.Cardinality; i++ {
+		res[i].Mul(&res[i-1], &domain.Generator)
+		res[domain.Cardinality+i].Mul(&res[domain.Cardinality+i-1], &domain.Generator)
+		res[2*domain.Cardinality+i].Mul(&res[2*domain.Cardinality+i-1], &domain.Generator)
+	}
+
+	return res
+}
+
+// NbPublicWitness returns the expected public witness size (number of field elements)
+func (vk *VerifyingKey) NbPublicWitness() int {
+	return int(vk.NbPublicVariables)
+}
+
+// VerifyingKey returns pk.Vk
+func (pk *ProvingKey) VerifyingKey() interface{} {
+	return pk.Vk
+}
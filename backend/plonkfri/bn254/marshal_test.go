@@ -0,0 +1,80 @@
+// Copyright 2020 ConsenSys Software Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by gnark DO NOT EDIT
+
+package plonkfri
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+
+	cs "github.com/consensys/gnark/constraint/bn254"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/io"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type circuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *circuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Add(api.Mul(c.X, c.X, c.X), c.X, 5), c.Y)
+	return nil
+}
+
+type commitmentCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *commitmentCircuit) Define(api frontend.API) error {
+	cmt, err := api.(frontend.Committer).Commit(c.X)
+	if err != nil {
+		return err
+	}
+	api.AssertIsDifferent(api.Add(cmt, c.X), c.Y)
+	return nil
+}
+
+func TestSerialization(t *testing.T) {
+	for _, tc := range []struct {
+		circuit, assignment frontend.Circuit
+	}{
+		{&circuit{}, &circuit{X: 3, Y: 35}},
+		{&commitmentCircuit{}, &commitmentCircuit{X: 3, Y: 35}},
+	} {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, tc.circuit)
+		require.NoError(t, err)
+		spr := ccs.(*cs.SparseR1CS)
+
+		for _, rateLog := range []int{1, 3} {
+			pk, vk, err := Setup(spr, rateLog, 2)
+			require.NoError(t, err)
+			w, err := frontend.NewWitness(tc.assignment, ecc.BN254.ScalarField())
+			require.NoError(t, err)
+			proof, err := Prove(spr, pk, w)
+			require.NoError(t, err)
+
+			assert.NoError(t, io.RoundTripCheck(pk, func() interface{} { return new(ProvingKey) }))
+			assert.NoError(t, io.RoundTripCheck(vk, func() interface{} { return new(VerifyingKey) }))
+			assert.NoError(t, io.RoundTripCheck(proof, func() interface{} { return new(Proof) }))
+		}
+	}
+}
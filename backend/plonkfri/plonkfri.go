@@ -0,0 +1,347 @@
+// Package plonkfri implements the PLONK Zero Knowledge Proof system with a
+// FRI polynomial commitment scheme.
+//
+// Unlike [github.com/consensys/gnark/backend/plonk], the setup is transparent:
+// it does not need a KZG SRS, and the polynomials are committed with Merkle
+// trees of their evaluations on a Reed-Solomon code. The soundness of the
+// commitments depends on the blowup factor of the code and on the number of
+// queries (see [WithBlowupFactor] and [WithNbQueries]), and the proofs are
+// much larger than KZG based PLONK proofs.
+//
+// The BSB22 commitments of [github.com/consensys/gnark/frontend.Committer] are
+// supported, the committed polynomials are committed as the other oracles. The
+// constraint systems with custom gates or lookups are rejected by [Setup].
+//
+// # See also
+//
+// https://eprint.iacr.org/2019/953
+// https://eprint.iacr.org/2019/1020
+package plonkfri
+
+import (
+	"errors"
+	"io"
+	"math/bits"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	cs_bls12377 "github.com/consensys/gnark/constraint/bls12-377"
+	cs_bls12381 "github.com/consensys/gnark/constraint/bls12-381"
+	cs_bls24315 "github.com/consensys/gnark/constraint/bls24-315"
+	cs_bls24317 "github.com/consensys/gnark/constraint/bls24-317"
+	cs_bn254 "github.com/consensys/gnark/constraint/bn254"
+	cs_bw6633 "github.com/consensys/gnark/constraint/bw6-633"
+	cs_bw6761 "github.com/consensys/gnark/constraint/bw6-761"
+
+	plonkfri_bls12377 "github.com/consensys/gnark/backend/plonkfri/bls12-377"
+	plonkfri_bls12381 "github.com/consensys/gnark/backend/plonkfri/bls12-381"
+	plonkfri_bls24315 "github.com/consensys/gnark/backend/plonkfri/bls24-315"
+	plonkfri_bls24317 "github.com/consensys/gnark/backend/plonkfri/bls24-317"
+	plonkfri_bn254 "github.com/consensys/gnark/backend/plonkfri/bn254"
+	plonkfri_bw6633 "github.com/consensys/gnark/backend/plonkfri/bw6-633"
+	plonkfri_bw6761 "github.com/consensys/gnark/backend/plonkfri/bw6-761"
+
+	fr_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	fr_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	fr_bls24315 "github.com/consensys/gnark-crypto/ecc/bls24-315/fr"
+	fr_bls24317 "github.com/consensys/gnark-crypto/ecc/bls24-317/fr"
+	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	fr_bw6633 "github.com/consensys/gnark-crypto/ecc/bw6-633/fr"
+	fr_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr"
+
+	gnarkio "github.com/consensys/gnark/io"
+)
+
+// Proof represents a PLONK proof with FRI commitments generated by plonkfri.Prove
+//
+// it's underlying implementation is curve specific (see gnark/backend/plonkfri)
+type Proof interface {
+	io.WriterTo
+	io.ReaderFrom
+	gnarkio.WriterRawTo
+}
+
+// ProvingKey represents a plonkfri ProvingKey
+//
+// it's underlying implementation is strongly typed with the curve (see gnark/backend/plonkfri)
+type ProvingKey interface {
+	io.WriterTo
+	io.ReaderFrom
+	gnarkio.WriterRawTo
+	gnarkio.UnsafeReaderFrom
+	VerifyingKey() interface{}
+}
+
+// VerifyingKey represents a plonkfri VerifyingKey
+//
+// it's underlying implementation is strongly typed with the curve (see gnark/backend/plonkfri)
+type VerifyingKey interface {
+	io.WriterTo
+	io.ReaderFrom
+	gnarkio.WriterRawTo
+	gnarkio.UnsafeReaderFrom
+	NbPublicWitness() int
+}
+
+// SetupOption defines option for altering the parameters of the FRI
+// commitment scheme in Setup.
+type SetupOption func(*setupConfig) error
+
+type setupConfig struct {
+	blowupFactor int
+	nbQueries    int
+}
+
+// WithBlowupFactor sets the blowup factor of the Reed-Solomon code on which
+// the polynomials are committed, which must be a power of two ≥ 2 (default 8).
+// A larger factor gives more security per query, at the cost of a slower
+// prover.
+func WithBlowupFactor(blowupFactor int) SetupOption {
+	return func(cfg *setupConfig) error {
+		if blowupFactor < 2 || blowupFactor&(blowupFactor-1) != 0 {
+			return errors.New("blowup factor must be a power of two ≥ 2")
+		}
+		cfg.blowupFactor = blowupFactor
+		return nil
+	}
+}
+
+// WithNbQueries sets the number of FRI queries (default 40). Each query gives
+// about log₂ of the blowup factor bits of security, so the default parameters
+// target 120 bits of conjectured security.
+func WithNbQueries(nbQueries int) SetupOption {
+	return func(cfg *setupConfig) error {
+		if nbQueries < 1 {
+			return errors.New("number of queries must be positive")
+		}
+		cfg.nbQueries = nbQueries
+		return nil
+	}
+}
+
+// Setup prepares the public data associated to a circuit + public inputs.
+// No trusted setup is needed.
+func Setup(ccs constraint.ConstraintSystem, opts ...SetupOption) (ProvingKey, VerifyingKey, error) {
+	cfg := setupConfig{blowupFactor: 8, nbQueries: 40}
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return nil, nil, err
+		}
+	}
+	rateLog := bits.TrailingZeros(uint(cfg.blowupFactor))
+
+	switch tccs := ccs.(type) {
+	case *cs_bn254.SparseR1CS:
+		return plonkfri_bn254.Setup(tccs, rateLog, cfg.nbQueries)
+	case *cs_bls12381.SparseR1CS:
+		return plonkfri_bls12381.Setup(tccs, rateLog, cfg.nbQueries)
+	case *cs_bls12377.SparseR1CS:
+		return plonkfri_bls12377.Setup(tccs, rateLog, cfg.nbQueries)
+	case *cs_bw6761.SparseR1CS:
+		return plonkfri_bw6761.Setup(tccs, rateLog, cfg.nbQueries)
+	case *cs_bls24317.SparseR1CS:
+		return plonkfri_bls24317.Setup(tccs, rateLog, cfg.nbQueries)
+	case *cs_bls24315.SparseR1CS:
+		return plonkfri_bls24315.Setup(tccs, rateLog, cfg.nbQueries)
+	case *cs_bw6633.SparseR1CS:
+		return plonkfri_bw6633.Setup(tccs, rateLog, cfg.nbQueries)
+	default:
+		panic("unrecognized SparseR1CS curve type")
+	}
+
+}
+
+// Prove generates a PLONK proof with FRI commitments from a circuit, associated
+// preprocessed public data, and the witness
+func Prove(ccs constraint.ConstraintSystem, pk ProvingKey, fullWitness witness.Witness, opts ...backend.ProverOption) (Proof, error) {
+
+	switch tccs := ccs.(type) {
+	case *cs_bn254.SparseR1CS:
+		return plonkfri_bn254.Prove(tccs, pk.(*plonkfri_bn254.ProvingKey), fullWitness, opts...)
+
+	case *cs_bls12381.SparseR1CS:
+		return plonkfri_bls12381.Prove(tccs, pk.(*plonkfri_bls12381.ProvingKey), fullWitness, opts...)
+
+	case *cs_bls12377.SparseR1CS:
+		return plonkfri_bls12377.Prove(tccs, pk.(*plonkfri_bls12377.ProvingKey), fullWitness, opts...)
+
+	case *cs_bw6761.SparseR1CS:
+		return plonkfri_bw6761.Prove(tccs, pk.(*plonkfri_bw6761.ProvingKey), fullWitness, opts...)
+
+	case *cs_bls24317.SparseR1CS:
+		return plonkfri_bls24317.Prove(tccs, pk.(*plonkfri_bls24317.ProvingKey), fullWitness, opts...)
+
+	case *cs_bls24315.SparseR1CS:
+		return plonkfri_bls24315.Prove(tccs, pk.(*plonkfri_bls24315.ProvingKey), fullWitness, opts...)
+
+	case *cs_bw6633.SparseR1CS:
+		return plonkfri_bw6633.Prove(tccs, pk.(*plonkfri_bw6633.ProvingKey), fullWitness, opts...)
+
+	default:
+		panic("unrecognized SparseR1CS curve type")
+	}
+}
+
+// Verify verifies a PLONK proof with FRI commitments, from the proof, preprocessed public data, and public witness.
+func Verify(proof Proof, vk VerifyingKey, publicWitness witness.Witness, opts ...backend.VerifierOption) error {
+
+	switch _proof := proof.(type) {
+
+	case *plonkfri_bn254.Proof:
+		w, ok := publicWitness.Vector().(fr_bn254.Vector)
+		if !ok {
+			return witness.ErrInvalidWitness
+		}
+		return plonkfri_bn254.Verify(_proof, vk.(*plonkfri_bn254.VerifyingKey), w, opts...)
+
+	case *plonkfri_bls12381.Proof:
+		w, ok := publicWitness.Vector().(fr_bls12381.Vector)
+		if !ok {
+			return witness.ErrInvalidWitness
+		}
+		return plonkfri_bls12381.Verify(_proof, vk.(*plonkfri_bls12381.VerifyingKey), w, opts...)
+
+	case *plonkfri_bls12377.Proof:
+		w, ok := publicWitness.Vector().(fr_bls12377.Vector)
+		if !ok {
+			return witness.ErrInvalidWitness
+		}
+		return plonkfri_bls12377.Verify(_proof, vk.(*plonkfri_bls12377.VerifyingKey), w, opts...)
+
+	case *plonkfri_bw6761.Proof:
+		w, ok := publicWitness.Vector().(fr_bw6761.Vector)
+		if !ok {
+			return witness.ErrInvalidWitness
+		}
+		return plonkfri_bw6761.Verify(_proof, vk.(*plonkfri_bw6761.VerifyingKey), w, opts...)
+
+	case *plonkfri_bls24317.Proof:
+		w, ok := publicWitness.Vector().(fr_bls24317.Vector)
+		if !ok {
+			return witness.ErrInvalidWitness
+		}
+		return plonkfri_bls24317.Verify(_proof, vk.(*plonkfri_bls24317.VerifyingKey), w, opts...)
+
+	case *plonkfri_bls24315.Proof:
+		w, ok := publicWitness.Vector().(fr_bls24315.Vector)
+		if !ok {
+			return witness.ErrInvalidWitness
+		}
+		return plonkfri_bls24315.Verify(_proof, vk.(*plonkfri_bls24315.VerifyingKey), w, opts...)
+
+	case *plonkfri_bw6633.Proof:
+		w, ok := publicWitness.Vector().(fr_bw6633.Vector)
+		if !ok {
+			return witness.ErrInvalidWitness
+		}
+		return plonkfri_bw6633.Verify(_proof, vk.(*plonkfri_bw6633.VerifyingKey), w, opts...)
+
+	default:
+		panic("unrecognized proof type")
+	}
+}
+
+// NewCS instantiate a concrete curved-typed SparseR1CS and return a ConstraintSystem interface
+// This method exists for (de)serialization purposes
+func NewCS(curveID ecc.ID) constraint.ConstraintSystem {
+	var r1cs constraint.ConstraintSystem
+	switch curveID {
+	case ecc.BN254:
+		r1cs = &cs_bn254.SparseR1CS{}
+	case ecc.BLS12_381:
+		r1cs = &cs_bls12381.SparseR1CS{}
+	case ecc.BLS12_377:
+		r1cs = &cs_bls12377.SparseR1CS{}
+	case ecc.BW6_761:
+		r1cs = &cs_bw6761.SparseR1CS{}
+	case ecc.BLS24_317:
+		r1cs = &cs_bls24317.SparseR1CS{}
+	case ecc.BLS24_315:
+		r1cs = &cs_bls24315.SparseR1CS{}
+	case ecc.BW6_633:
+		r1cs = &cs_bw6633.SparseR1CS{}
+	default:
+		panic("not implemented")
+	}
+	return r1cs
+}
+
+// NewProvingKey instantiates a curve-typed ProvingKey and returns an interface
+// This function exists for serialization purposes
+func NewProvingKey(curveID ecc.ID) ProvingKey {
+	var pk ProvingKey
+	switch curveID {
+	case ecc.BN254:
+		pk = &plonkfri_bn254.ProvingKey{}
+	case ecc.BLS12_381:
+		pk = &plonkfri_bls12381.ProvingKey{}
+	case ecc.BLS12_377:
+		pk = &plonkfri_bls12377.ProvingKey{}
+	case ecc.BW6_761:
+		pk = &plonkfri_bw6761.ProvingKey{}
+	case ecc.BLS24_317:
+		pk = &plonkfri_bls24317.ProvingKey{}
+	case ecc.BLS24_315:
+		pk = &plonkfri_bls24315.ProvingKey{}
+	case ecc.BW6_633:
+		pk = &plonkfri_bw6633.ProvingKey{}
+	default:
+		panic("not implemented")
+	}
+
+	return pk
+}
+
+// NewProof instantiates a curve-typed Proof and returns an interface
+// This function exists for serialization purposes
+func NewProof(curveID ecc.ID) Proof {
+	var proof Proof
+	switch curveID {
+	case ecc.BN254:
+		proof = &plonkfri_bn254.Proof{}
+	case ecc.BLS12_381:
+		proof = &plonkfri_bls12381.Proof{}
+	case ecc.BLS12_377:
+		proof = &plonkfri_bls12377.Proof{}
+	case ecc.BW6_761:
+		proof = &plonkfri_bw6761.Proof{}
+	case ecc.BLS24_317:
+		proof = &plonkfri_bls24317.Proof{}
+	case ecc.BLS24_315:
+		proof = &plonkfri_bls24315.Proof{}
+	case ecc.BW6_633:
+		proof = &plonkfri_bw6633.Proof{}
+	default:
+		panic("not implemented")
+	}
+
+	return proof
+}
+
+// NewVerifyingKey instantiates a curve-typed VerifyingKey and returns an interface
+// This function exists for serialization purposes
+func NewVerifyingKey(curveID ecc.ID) VerifyingKey {
+	var vk VerifyingKey
+	switch curveID {
+	case ecc.BN254:
+		vk = &plonkfri_bn254.VerifyingKey{}
+	case ecc.BLS12_381:
+		vk = &plonkfri_bls12381.VerifyingKey{}
+	case ecc.BLS12_377:
+		vk = &plonkfri_bls12377.VerifyingKey{}
+	case ecc.BW6_761:
+		vk = &plonkfri_bw6761.VerifyingKey{}
+	case ecc.BLS24_317:
+		vk = &plonkfri_bls24317.VerifyingKey{}
+	case ecc.BLS24_315:
+		vk = &plonkfri_bls24315.VerifyingKey{}
+	case ecc.BW6_633:
+		vk = &plonkfri_bw6633.VerifyingKey{}
+	default:
+		panic("not implemented")
+	}
+
+	return vk
+}
@@ -0,0 +1,286 @@
+package plonkfri_test
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/plonkfri"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/rangecheck"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProver(t *testing.T) {
+
+	for _, curve := range getCurves() {
+		t.Run(curve.String(), func(t *testing.T) {
+			var b1, b2 bytes.Buffer
+			assert := require.New(t)
+
+			ccs, _solution := referenceCircuit(curve)
+			fullWitness, err := frontend.NewWitness(_solution, curve.ScalarField())
+			assert.NoError(err)
+
+			publicWitness, err := fullWitness.Public()
+			assert.NoError(err)
+
+			pk, vk, err := plonkfri.Setup(ccs)
+			assert.NoError(err)
+
+			// write the PK to ensure it is not mutated
+			_, err = pk.WriteTo(&b1)
+			assert.NoError(err)
+
+			proof, err := plonkfri.Prove(ccs, pk, fullWitness)
+			assert.NoError(err)
+
+			// check pk
+			_, err = pk.WriteTo(&b2)
+			assert.NoError(err)
+
+			assert.True(bytes.Equal(b1.Bytes(), b2.Bytes()), "plonkfri prover mutated the proving key")
+
+			err = plonkfri.Verify(proof, vk, publicWitness)
+			assert.NoError(err)
+
+			// testing with full witness should output a clear error.
+			err = plonkfri.Verify(proof, vk, fullWitness)
+			assert.Error(err)
+			assert.Contains(err.Error(), "witness length is invalid")
+
+			// testing with another public input
+			_solution.(*refCircuit).Y = 42
+			wrongWitness, err := frontend.NewWitness(_solution, curve.ScalarField(), frontend.PublicOnly())
+			assert.NoError(err)
+			assert.Error(plonkfri.Verify(proof, vk, wrongWitness))
+		})
+
+	}
+}
+
+func TestSetupOptions(t *testing.T) {
+	assert := require.New(t)
+	ccs, _solution := referenceCircuit(ecc.BN254)
+	fullWitness, err := frontend.NewWitness(_solution, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+
+	pk, vk, err := plonkfri.Setup(ccs, plonkfri.WithBlowupFactor(2), plonkfri.WithNbQueries(10))
+	assert.NoError(err)
+	proof, err := plonkfri.Prove(ccs, pk, fullWitness)
+	assert.NoError(err)
+	assert.NoError(plonkfri.Verify(proof, vk, publicWitness))
+
+	// the proof is bound to the parameters
+	_, vk, err = plonkfri.Setup(ccs, plonkfri.WithBlowupFactor(4), plonkfri.WithNbQueries(10))
+	assert.NoError(err)
+	assert.Error(plonkfri.Verify(proof, vk, publicWitness))
+
+	_, _, err = plonkfri.Setup(ccs, plonkfri.WithBlowupFactor(3))
+	assert.Error(err)
+	_, _, err = plonkfri.Setup(ccs, plonkfri.WithNbQueries(0))
+	assert.Error(err)
+}
+
+func TestCustomHashToField(t *testing.T) {
+	assert := test.NewAssert(t)
+	assignment := &commitmentCircuit{X: 1}
+	for _, curve := range getCurves() {
+		curve := curve
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, &commitmentCircuit{}, frontend.WithoutNativeLookups())
+			assert.NoError(err)
+
+			pk, vk, err := plonkfri.Setup(ccs)
+			assert.NoError(err)
+			witness, err := frontend.NewWitness(assignment, curve.ScalarField())
+			assert.NoError(err)
+			pubWitness, err := witness.Public()
+			assert.NoError(err)
+			assert.Run(func(assert *test.Assert) {
+				proof, err := plonkfri.Prove(ccs, pk, witness, backend.WithProverHashToFieldFunction(constantHash{}))
+				assert.NoError(err)
+				err = plonkfri.Verify(proof, vk, pubWitness, backend.WithVerifierHashToFieldFunction(constantHash{}))
+				assert.NoError(err)
+			}, "prover_verifier")
+			assert.Run(func(assert *test.Assert) {
+				proof, err := plonkfri.Prove(ccs, pk, witness, backend.WithProverHashToFieldFunction(constantHash{}))
+				assert.NoError(err)
+				err = plonkfri.Verify(proof, vk, pubWitness)
+				assert.Error(err)
+			}, "prover_only")
+			assert.Run(func(assert *test.Assert) {
+				_, err := plonkfri.Prove(ccs, pk, witness)
+				assert.Error(err)
+			}, "verifier_only")
+		}, curve.String())
+	}
+}
+
+func TestRangeCheck(t *testing.T) {
+	assert := test.NewAssert(t)
+	assert.CheckCircuit(&rangeCheckCircuit{},
+		test.WithValidAssignment(&rangeCheckCircuit{X: 200}),
+		test.WithInvalidAssignment(&rangeCheckCircuit{X: 300}),
+		test.WithBackends(backend.PLONKFRI),
+		test.WithCurves(ecc.BN254),
+	)
+}
+
+func TestCustomChallengeHash(t *testing.T) {
+	assert := test.NewAssert(t)
+	assignment := &smallCircuit{X: 1}
+	for _, curve := range getCurves() {
+		curve := curve
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, &smallCircuit{})
+			assert.NoError(err)
+
+			pk, vk, err := plonkfri.Setup(ccs)
+			assert.NoError(err)
+			witness, err := frontend.NewWitness(assignment, curve.ScalarField())
+			assert.NoError(err)
+			pubWitness, err := witness.Public()
+			assert.NoError(err)
+			assert.Run(func(assert *test.Assert) {
+				proof, err := plonkfri.Prove(ccs, pk, witness, backend.WithProverChallengeHashFunction(constantHash{}))
+				assert.NoError(err)
+				err = plonkfri.Verify(proof, vk, pubWitness, backend.WithVerifierChallengeHashFunction(constantHash{}))
+				assert.NoError(err)
+			}, "prover_verifier")
+			assert.Run(func(assert *test.Assert) {
+				proof, err := plonkfri.Prove(ccs, pk, witness, backend.WithProverChallengeHashFunction(constantHash{}))
+				assert.NoError(err)
+				err = plonkfri.Verify(proof, vk, pubWitness)
+				assert.Error(err)
+			}, "prover_only")
+			assert.Run(func(assert *test.Assert) {
+				proof, err := plonkfri.Prove(ccs, pk, witness)
+				assert.NoError(err)
+				err = plonkfri.Verify(proof, vk, pubWitness, backend.WithVerifierChallengeHashFunction(constantHash{}))
+				assert.Error(err)
+			}, "verifier_only")
+		}, curve.String())
+	}
+}
+
+func TestAssert(t *testing.T) {
+	assert := test.NewAssert(t)
+	assert.CheckCircuit(&refCircuit{nbConstraints: 3},
+		test.WithValidAssignment(&refCircuit{X: 2, Y: 256}),
+		test.WithInvalidAssignment(&refCircuit{X: 2, Y: 255}),
+		test.WithBackends(backend.PLONKFRI))
+}
+
+func BenchmarkProver(b *testing.B) {
+	ccs, _solution := referenceCircuit(ecc.BN254)
+	fullWitness, err := frontend.NewWitness(_solution, ecc.BN254.ScalarField())
+	if err != nil {
+		b.Fatal(err)
+	}
+	pk, _, err := plonkfri.Setup(ccs)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err = plonkfri.Prove(ccs, pk, fullWitness)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type refCircuit struct {
+	nbConstraints int
+	X             frontend.Variable
+	Y             frontend.Variable `gnark:",public"`
+}
+
+func (circuit *refCircuit) Define(api frontend.API) error {
+	for i := 0; i < circuit.nbConstraints; i++ {
+		circuit.X = api.Mul(circuit.X, circuit.X)
+	}
+	api.AssertIsEqual(circuit.X, circuit.Y)
+	return nil
+}
+
+func referenceCircuit(curve ecc.ID) (constraint.ConstraintSystem, frontend.Circuit) {
+	const nbConstraints = (1 << 8) - 3
+	circuit := refCircuit{
+		nbConstraints: nbConstraints,
+	}
+	ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, &circuit)
+	if err != nil {
+		panic(err)
+	}
+
+	var good refCircuit
+	good.X = 2
+
+	// compute expected Y
+	expectedY := new(big.Int).SetUint64(2)
+	exp := big.NewInt(1)
+	exp.Lsh(exp, nbConstraints)
+	expectedY.Exp(expectedY, exp, curve.ScalarField())
+
+	good.Y = expectedY
+	return ccs, &good
+}
+
+type commitmentCircuit struct {
+	X frontend.Variable
+}
+
+func (c *commitmentCircuit) Define(api frontend.API) error {
+	cmt, err := api.(frontend.Committer).Commit(c.X)
+	if err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	api.AssertIsEqual(cmt, "0xaabbcc")
+	return nil
+}
+
+type rangeCheckCircuit struct {
+	X frontend.Variable
+}
+
+func (c *rangeCheckCircuit) Define(api frontend.API) error {
+	rangecheck.New(api).Check(c.X, 8)
+	return nil
+}
+
+type smallCircuit struct {
+	X frontend.Variable
+}
+
+func (c *smallCircuit) Define(api frontend.API) error {
+	res := api.Mul(c.X, c.X)
+	api.AssertIsEqual(c.X, res)
+	return nil
+}
+
+type constantHash struct{}
+
+func (h constantHash) Write(p []byte) (n int, err error) { return len(p), nil }
+func (h constantHash) Sum(b []byte) []byte               { return []byte{0xaa, 0xbb, 0xcc} }
+func (h constantHash) Reset()                            {}
+func (h constantHash) Size() int                         { return 3 }
+func (h constantHash) BlockSize() int                    { return 32 }
+
+func getCurves() []ecc.ID {
+	if testing.Short() {
+		return []ecc.ID{ecc.BN254}
+	}
+	return gnark.Curves()
+}
@@ -0,0 +1,1413 @@
+// Package analyzer detects the under-constrained wires of a compiled
+// constraint system.
+//
+// [constraint.ConstraintSystem.CheckUnconstrainedWires] only detects the wires
+// which do not appear in any constraint. The analyzer detects the internal
+// wires whose value is not uniquely determined by the public and secret inputs,
+// for example a hint output which is only constrained by a quadratic equation.
+//
+// Starting from the inputs, the analysis propagates the determined wires
+// through the constraints in solving order: a wire solved by a constraint is
+// determined if the constraint is linear in it, with a non-zero coefficient,
+// and all the other wires of the constraint are determined. The constraints
+// solving no wire are used as equations on the remaining wires, with the
+// following rules:
+//   - a linear combination with a single undetermined wire determines it;
+//   - a linear combination of boolean or range checked wires with distinct
+//     powers of two as coefficients determines them (bit decompositions);
+//   - a*m == 0 and m == c - a*x determine m (zero tests);
+//   - a wire used in a single constraint, multiplied by a determined factor, is
+//     determined when the factor is not zero and irrelevant otherwise;
+//   - the value of a row of a native lookup in a functional table is
+//     determined by its keys, and the wires of the rows are bounded by the
+//     columns of the table;
+//   - the log-derivative arguments of the std gadgets, checked at a challenge
+//     derived from a commitment to their queries, bound the queries by the
+//     table and determine the multiplicities once the queries are determined.
+//
+// A full width bit decomposition is only unique if its value is less than the
+// modulus: it is considered determined when its bits are compared to a
+// constant or to the bits of another value, as done by bits.ToBinary and
+// frontend.API.AssertIsLessOrEqual. The boolean wires are the ones constrained
+// by a quadratic equation and the functions of a few boolean wires, as the
+// selections computing these comparisons.
+//
+// The reported wires are the sources of the under-constraint: the undetermined
+// wires which are solved from determined wires, then the ones solved from the
+// reported ones once these are assumed determined, and so on. For example, the
+// result of an emulated multiplication is only unique modulo the emulated
+// modulus: the outputs of its hint are reported, but not their range checks.
+//
+// The analysis is not complete: a reported wire may be determined by the
+// constraints through reasoning not covered by the rules, for example the
+// identities between polynomials checked at a challenge. The wires computed by
+// custom gates or GKR are considered undetermined.
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/consensys/gnark/constraint"
+)
+
+// Wire is an under-constrained internal wire.
+type Wire struct {
+	// ID of the wire in the constraint system.
+	ID int
+	// Hint is true if the wire is an output of a hint.
+	Hint bool
+	// Stack is the call stack of the constraint solving or using the wire, if
+	// the constraint system has debug information for it.
+	Stack []string
+}
+
+// Report is the result of [Analyze].
+type Report struct {
+	// Wires are the undetermined wires which are not computed from other
+	// undetermined wires, that is the sources of the under-constraint.
+	Wires []Wire
+	// NbUndetermined is the total number of undetermined internal wires,
+	// including the wires computed from the reported ones.
+	NbUndetermined int
+}
+
+// maxReportedWires is the maximum number of wires listed by Report.Err.
+const maxReportedWires = 20
+
+// Err returns an error listing the under-constrained wires, or nil if there
+// are none.
+func (r Report) Err() error {
+	if len(r.Wires) == 0 {
+		return nil
+	}
+	var sbb strings.Builder
+	fmt.Fprintf(&sbb, "%d under-constrained wires (%d undetermined wires in total)", len(r.Wires), r.NbUndetermined)
+	for i, w := range r.Wires {
+		if i == maxReportedWires {
+			fmt.Fprintf(&sbb, "\n...")
+			break
+		}
+		if w.Hint {
+			fmt.Fprintf(&sbb, "\nhint output %d", w.ID)
+		} else {
+			fmt.Fprintf(&sbb, "\nwire %d", w.ID)
+		}
+		for _, s := range w.Stack {
+			sbb.WriteString("\n\t")
+			sbb.WriteString(s)
+		}
+	}
+	return errors.New(sbb.String())
+}
+
+// Analyze returns the under-constrained wires of cs.
+func Analyze(cs constraint.ConstraintSystem) Report {
+	a := newAnalyzer(cs)
+	a.build(cs)
+	a.propagate()
+	return a.report(cs)
+}
+
+// constantWire is the wire of the constant terms.
+const constantWire = -1
+
+type term struct {
+	wire  int
+	coeff *big.Int
+}
+
+// lin is a linear expression with at most one term per wire and non-zero
+// coefficients.
+type lin []term
+
+// quad is the constraint a⋅b + c == 0.
+type quad struct {
+	a, b, c lin
+	cID     int
+	// solved is the wire solved by the constraint, or -1.
+	solved int
+}
+
+type kind uint8
+
+const (
+	kindInput      kind = iota
+	kindHint            // output of a hint
+	kindOpaque          // solved by a blueprint the analyzer does not know
+	kindFunctional      // computed from the other wires of its constraint
+	kindGuarded         // computed from the other wires if a factor is not zero
+	kindFree            // solved by a non-linear constraint
+	kindLookup          // solved by a lookup in a functional table
+)
+
+type wireInfo struct {
+	kind       kind
+	solved     bool
+	determined bool
+	// def is the constraint solving the wire, or -1
+	def int
+	// uses are the constraints using the wire
+	uses []int
+	// bits is such that the wire is less than 2^bits in any solution, or 0
+	bits int
+	// committed is true if the wire is committed to
+	committed bool
+	// nonZero is true if the wire is not zero in any solution
+	nonZero bool
+	// compared is true if the wire is a bit compared to a constant
+	compared bool
+	// challenge is true if the wire is computed from commitments only
+	challenge bool
+	// nbLookups is the number of lookup rows using the wire
+	nbLookups int
+	// inputs are the wires given to the hint computing the wire
+	inputs []int
+	// source is true if the wire is reported, see analyzer.sources
+	source bool
+}
+
+type analyzer struct {
+	q        *big.Int
+	bitLen   int
+	r1cs     bool
+	nbInputs int
+
+	wires       []wireInfo
+	constraints []quad
+	checks      []int
+
+	// powers maps ±2ᵉ to e, for |e| < bitLen
+	powers map[string]int
+	// forms memoizes the expressions of the wires in terms of undetermined
+	// base wires
+	forms map[int]map[int]*big.Int
+
+	// native lookups, see lookup.go
+	lookups    []lookupRow
+	lookupDefs map[int]int
+	tables     map[int]*lookupTable
+
+	// log-derivative arguments, see lookup.go
+	commitments  []int
+	fracs        map[int]*fraction
+	withFraction map[int]bool
+	args         []*argument
+
+	// boolLins records the boolean linear expressions, by linKey, and
+	// linKeys memoizes the keys of the sides of the constraints
+	boolLins map[string]bool
+	linKeys  map[[2]int]*linKeys
+}
+
+func newAnalyzer(cs constraint.ConstraintSystem) *analyzer {
+	a := &analyzer{
+		q:        cs.Field(),
+		bitLen:   cs.FieldBitLen(),
+		nbInputs: cs.GetNbPublicVariables() + cs.GetNbSecretVariables(),
+		powers:   make(map[string]int),
+
+		lookupDefs:   make(map[int]int),
+		tables:       make(map[int]*lookupTable),
+		fracs:        make(map[int]*fraction),
+		withFraction: make(map[int]bool),
+		boolLins:     make(map[string]bool),
+		linKeys:      make(map[[2]int]*linKeys),
+	}
+	_, a.r1cs = cs.(constraint.R1CS)
+
+	a.wires = make([]wireInfo, a.nbInputs+cs.GetNbInternalVariables())
+	for i := range a.wires {
+		a.wires[i].def = -1
+		if i < a.nbInputs {
+			a.wires[i].solved, a.wires[i].determined = true, true
+		}
+	}
+
+	two := big.NewInt(2)
+	inv := new(big.Int).ModInverse(two, a.q)
+	p, n := big.NewInt(1), big.NewInt(1)
+	for e := 0; e < a.bitLen; e++ {
+		a.powers[p.String()] = e
+		a.powers[new(big.Int).Sub(a.q, p).String()] = e
+		a.powers[n.String()] = -e
+		a.powers[new(big.Int).Sub(a.q, n).String()] = -e
+		p.Mul(p, two).Mod(p, a.q)
+		n.Mul(n, inv).Mod(n, a.q)
+	}
+	return a
+}
+
+// build decodes the instructions of cs into quadratic constraints and records
+// the wires they solve.
+func (a *analyzer) build(cs constraint.ConstraintSystem) {
+	// the commitments are challenges derived from the committed wires
+	var commitments []int
+	switch c := cs.GetCommitments().(type) {
+	case constraint.Groth16Commitments:
+		commitments = c.CommitmentIndexes()
+		for _, committed := range c.GetPrivateCommitted() {
+			for _, w := range committed {
+				a.wires[w].committed = true
+			}
+		}
+	}
+
+	var (
+		r1c    constraint.R1C
+		sparse constraint.SparseR1C
+		hint   constraint.HintMapping
+		tree   = instructionTree{a: a}
+	)
+	it := cs.GetInstructionIterator()
+	for blueprint, inst, ok := it.Next(); ok; blueprint, inst, ok = it.Next() {
+		tree.outputs = tree.outputs[:0]
+		blueprint.UpdateInstructionTree(inst, &tree)
+		outputs := tree.outputs
+
+		switch b := blueprint.(type) {
+		case constraint.BlueprintR1C:
+			b.DecompressR1C(&r1c, inst)
+			a.addConstraint(quad{
+				a:   a.lin(cs, r1c.L),
+				b:   a.lin(cs, r1c.R),
+				c:   a.neg(a.lin(cs, r1c.O)),
+				cID: int(inst.ConstraintOffset),
+			}, outputs)
+		case constraint.BlueprintSparseR1C:
+			b.DecompressSparseR1C(&sparse, inst)
+			if lb, ok := b.(*constraint.BlueprintLookup); ok {
+				a.addLookup(cs, lb, &sparse, int(inst.ConstraintOffset), outputs)
+				continue
+			}
+			if _, ok := b.(*constraint.BlueprintCustomGateNextRow); ok {
+				// the wires are constrained by the preceding custom gate
+				continue
+			}
+			if sparse.CustomGate != 0 || sparse.Lookup != 0 {
+				a.setKind(outputs, kindOpaque)
+				continue
+			}
+			switch sparse.Commitment {
+			case constraint.COMMITMENT:
+				commitments = append(commitments, int(sparse.XA))
+				continue
+			case constraint.COMMITTED:
+				a.wires[sparse.XA].committed = true
+				continue
+			}
+			a.addConstraint(a.sparseQuad(cs, &sparse, int(inst.ConstraintOffset)), outputs)
+		case constraint.BlueprintHint:
+			b.DecompressHint(&hint, inst)
+			var inputs []int
+			for _, l := range hint.Inputs {
+				for _, t := range a.lin(cs, l) {
+					if t.wire != constantWire {
+						inputs = append(inputs, t.wire)
+					}
+				}
+			}
+			for w := hint.OutputRange.Start; w < hint.OutputRange.End; w++ {
+				a.wires[w].kind = kindHint
+				a.wires[w].solved = true
+				a.wires[w].inputs = inputs
+			}
+			a.setKind(outputs, kindHint)
+		default:
+			a.setKind(outputs, kindOpaque)
+		}
+	}
+
+	for _, w := range commitments {
+		a.wires[w].kind = kindInput
+		a.wires[w].determined = true
+	}
+	a.commitments = commitments
+}
+
+func (a *analyzer) setKind(wires []int, k kind) {
+	for _, w := range wires {
+		a.wires[w].kind = k
+	}
+}
+
+// addConstraint records the constraint q, solving the given wires.
+func (a *analyzer) addConstraint(q quad, solved []int) {
+	q.solved = -1
+	if rel, ok := a.linearize(&q); ok && len(rel) == 0 {
+		// tautology, for example the constraint added to commit to a mask
+		a.setKind(solved, kindFree)
+		return
+	}
+	id := len(a.constraints)
+	for _, l := range []lin{q.a, q.b, q.c} {
+		for _, t := range l {
+			if t.wire == constantWire {
+				continue
+			}
+			uses := a.wires[t.wire].uses
+			if len(uses) == 0 || uses[len(uses)-1] != id {
+				a.wires[t.wire].uses = append(uses, id)
+			}
+		}
+	}
+
+	switch len(solved) {
+	case 0:
+		a.checks = append(a.checks, id)
+	case 1:
+		w := solved[0]
+		q.solved = w
+		a.wires[w].def = id
+		a.wires[w].kind = a.solvedKind(&q, w)
+	default:
+		for _, w := range solved {
+			a.wires[w].def = id
+			a.wires[w].kind = kindFree
+		}
+	}
+	a.constraints = append(a.constraints, q)
+}
+
+// solvedKind returns how the wire w is computed from the constraint q.
+func (a *analyzer) solvedKind(q *quad, w int) kind {
+	inA, inB, inC := coeff(q.a, w) != nil, coeff(q.b, w) != nil, coeff(q.c, w) != nil
+	switch {
+	case inC && !inA && !inB:
+		return kindFunctional
+	case inA && !inB && !inC:
+		if a.isNonZeroConstant(q.b) || a.isNonZeroConstant(q.c) {
+			return kindFunctional
+		}
+		return kindGuarded
+	case inB && !inA && !inC:
+		if a.isNonZeroConstant(q.a) || a.isNonZeroConstant(q.c) {
+			return kindFunctional
+		}
+		return kindGuarded
+	default:
+		return kindFree
+	}
+}
+
+// propagate computes the determined wires.
+func (a *analyzer) propagate() {
+	a.booleans()
+	a.nonZeros()
+	a.lookupRanges()
+	a.challenges(a.commitments)
+	a.fractions()
+	a.withFractions()
+	a.arguments()
+	a.compared()
+	a.ranges()
+	a.fixpoint()
+}
+
+// fixpoint determines the wires until no rule applies.
+func (a *analyzer) fixpoint() {
+	for changed := true; changed; {
+		changed = false
+		for w := a.nbInputs; w < len(a.wires); w++ {
+			if !a.wires[w].determined && a.isDetermined(w) {
+				a.wires[w].determined = true
+				changed = true
+			}
+		}
+		a.forms = make(map[int]map[int]*big.Int)
+		for _, id := range a.checks {
+			if a.zeroTest(&a.constraints[id]) || a.relation(&a.constraints[id]) {
+				changed = true
+			}
+		}
+		if a.lookupChecks() {
+			changed = true
+		}
+		for _, arg := range a.args {
+			if a.argumentDetermined(arg) {
+				changed = true
+			}
+		}
+	}
+}
+
+// sources marks the reported wires, by rounds: the undetermined wires whose
+// inputs are determined are the sources of the under-constraint, and are then
+// assumed determined to find the wires they determine, such as their bit
+// decompositions, which are not reported.
+func (a *analyzer) sources() {
+	for {
+		var round []int
+		first := -1
+		for w := a.nbInputs; w < len(a.wires); w++ {
+			info := &a.wires[w]
+			if info.determined || info.kind == kindFunctional {
+				continue
+			}
+			if first < 0 {
+				first = w
+			}
+			if a.inputsDetermined(w) {
+				round = append(round, w)
+			}
+		}
+		if first < 0 {
+			return
+		}
+		if len(round) == 0 {
+			round = append(round, first)
+		}
+		for _, w := range round {
+			a.wires[w].source, a.wires[w].determined = true, true
+		}
+		a.fixpoint()
+	}
+}
+
+// inputsDetermined returns true if the wires from which w is solved are
+// determined.
+func (a *analyzer) inputsDetermined(w int) bool {
+	info := &a.wires[w]
+	switch {
+	case info.kind == kindHint:
+		for _, v := range info.inputs {
+			if !a.wires[v].determined {
+				return false
+			}
+		}
+		return true
+	case info.kind == kindLookup:
+		return a.keysDetermined(&a.lookups[a.lookupDefs[w]])
+	case info.def >= 0:
+		return a.othersDetermined(&a.constraints[info.def], w)
+	}
+	return true
+}
+
+// isDetermined returns true if the wire w is determined by its defining
+// constraint, or irrelevant.
+func (a *analyzer) isDetermined(w int) bool {
+	info := &a.wires[w]
+	switch info.kind {
+	case kindFunctional:
+		return a.othersDetermined(&a.constraints[info.def], w)
+	case kindLookup:
+		return a.keysDetermined(&a.lookups[a.lookupDefs[w]])
+	case kindGuarded, kindHint, kindFree:
+		if a.fracs[w] != nil && a.othersDetermined(&a.constraints[info.def], w) {
+			// the denominator depends on a challenge
+			return true
+		}
+		if info.kind == kindGuarded {
+			// w⋅b + c == 0, or a⋅w + c == 0, with a non-zero factor
+			q := &a.constraints[info.def]
+			factor := q.b
+			if coeff(q.a, w) == nil {
+				factor = q.a
+			}
+			if len(factor) == 1 && factor[0].wire != constantWire && a.wires[factor[0].wire].nonZero && a.othersDetermined(q, w) {
+				return true
+			}
+		}
+		if info.nbLookups != 0 {
+			return false
+		}
+		if info.committed && len(info.uses) == 0 {
+			// only used to mask a commitment
+			return true
+		}
+		if len(info.uses) != 1 {
+			return false
+		}
+		// w is only used in a constraint where it is multiplied by a
+		// determined factor: it is determined when the factor is not zero, and
+		// does not change anything otherwise.
+		q := &a.constraints[info.uses[0]]
+		if !a.othersDetermined(q, w) || coeff(q.c, w) != nil {
+			return false
+		}
+		inA, inB := coeff(q.a, w) != nil, coeff(q.b, w) != nil
+		return inA != inB
+	}
+	return false
+}
+
+// othersDetermined returns true if all the wires of q but w are determined.
+func (a *analyzer) othersDetermined(q *quad, w int) bool {
+	for _, l := range []lin{q.a, q.b, q.c} {
+		for _, t := range l {
+			if t.wire != w && t.wire != constantWire && !a.wires[t.wire].determined {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// zeroTest applies the zero test rule to the check x⋅m == 0, where x is
+// determined and m is computed by a constraint y⋅z + c(m) == 0 with y ∝ x:
+// if x ≠ 0 then m = 0, otherwise m is the root of c.
+func (a *analyzer) zeroTest(q *quad) bool {
+	if len(q.c) != 0 {
+		return false
+	}
+	for _, s := range [2][2]lin{{q.a, q.b}, {q.b, q.a}} {
+		x, m := s[0], s[1]
+		if len(m) != 1 || m[0].wire == constantWire || a.wires[m[0].wire].determined || !a.determined(x) {
+			continue
+		}
+		w := m[0].wire
+		if a.wires[w].kind != kindFunctional {
+			continue
+		}
+		def := &a.constraints[a.wires[w].def]
+		if !a.proportional(def.a, x) && !a.proportional(def.b, x) {
+			continue
+		}
+		determined := true
+		for _, t := range def.c {
+			if t.wire != w && t.wire != constantWire && !a.wires[t.wire].determined {
+				determined = false
+			}
+		}
+		if determined {
+			a.wires[w].determined = true
+			return true
+		}
+	}
+	return false
+}
+
+// relation applies the linear rules to the check q.
+func (a *analyzer) relation(q *quad) bool {
+	rel, ok := a.linearize(q)
+	if !ok {
+		return false
+	}
+	comb := make(map[int]*big.Int)
+	for _, t := range rel {
+		if t.wire == constantWire || a.wires[t.wire].determined {
+			continue
+		}
+		a.addScaled(comb, a.form(t.wire, 0), t.coeff)
+	}
+	wires := make([]int, 0, len(comb))
+	for w, c := range comb {
+		if c.Sign() != 0 && !a.wires[w].determined {
+			wires = append(wires, w)
+		}
+	}
+	sort.Ints(wires)
+
+	if len(wires) == 0 || (len(wires) > 1 && !a.injective(wires, comb)) {
+		return false
+	}
+	for _, w := range wires {
+		a.wires[w].determined = true
+	}
+	return true
+}
+
+// maxFormSize bounds the size of the linear forms of the wires.
+const maxFormSize = 1 << 12
+
+// form returns the expression of the undetermined wire w as a linear
+// combination of undetermined base wires, that is wires which are not
+// computed linearly from other wires.
+func (a *analyzer) form(w int, depth int) map[int]*big.Int {
+	if f, ok := a.forms[w]; ok {
+		return f
+	}
+	f := map[int]*big.Int{w: big.NewInt(1)}
+	info := &a.wires[w]
+	if info.kind == kindFunctional && depth < maxFormSize {
+		q := &a.constraints[info.def]
+		if rel, ok := a.linearize(q); ok {
+			// w = -(rel - c⋅w) / c
+			var c *big.Int
+			for _, t := range rel {
+				if t.wire == w {
+					c = t.coeff
+				}
+			}
+			if c == nil {
+				a.forms[w] = f
+				return f
+			}
+			s := new(big.Int).ModInverse(c, a.q)
+			s.Neg(s).Mod(s, a.q)
+			res := make(map[int]*big.Int)
+			for _, t := range rel {
+				if t.wire == w || t.wire == constantWire || a.wires[t.wire].determined {
+					continue
+				}
+				a.addScaled(res, a.form(t.wire, depth+1), new(big.Int).Mul(t.coeff, s))
+				if len(res) > maxFormSize {
+					res = nil
+					break
+				}
+			}
+			if res != nil {
+				f = res
+			}
+		}
+	}
+	a.forms[w] = f
+	return f
+}
+
+func (a *analyzer) addScaled(res, f map[int]*big.Int, s *big.Int) {
+	for w, c := range f {
+		if a.wires[w].determined {
+			continue
+		}
+		v, ok := res[w]
+		if !ok {
+			v = new(big.Int)
+			res[w] = v
+		}
+		v.Add(v, new(big.Int).Mul(c, s)).Mod(v, a.q)
+	}
+}
+
+// injective returns true if the linear combination of the wires is injective
+// given their range facts: the coefficients are, up to a common factor, ±2ᵉ
+// with disjoint bit ranges which do not overflow the field.
+func (a *analyzer) injective(wires []int, comb map[int]*big.Int) bool {
+	inv := new(big.Int).ModInverse(comb[wires[0]], a.q)
+	type interval struct {
+		start, end int
+		wire       int
+		positive   bool
+	}
+	intervals := make([]interval, len(wires))
+	minE := 0
+	for i, w := range wires {
+		if a.wires[w].bits == 0 {
+			return false
+		}
+		r := new(big.Int).Mul(comb[w], inv)
+		r.Mod(r, a.q)
+		e, ok := a.powers[r.String()]
+		if !ok {
+			return false
+		}
+		intervals[i] = interval{e, e + a.wires[w].bits, w, e >= 0 && r.Cmp(new(big.Int).Lsh(big.NewInt(1), uint(e))) == 0}
+		if e < minE {
+			minE = e
+		}
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start < intervals[j].start })
+	for i := 1; i < len(intervals); i++ {
+		if intervals[i].start < intervals[i-1].end {
+			return false
+		}
+	}
+	width := intervals[len(intervals)-1].end - minE
+	if width+1 < a.bitLen {
+		return true
+	}
+	if width != a.bitLen || minE != 0 {
+		return false
+	}
+
+	// a full width decomposition Σ 2ⁱ⋅bᵢ is unique if it is less than the
+	// modulus, which is checked by comparing the bits to q-1, see
+	// [frontend.Builder.MustBeLessOrEqCst]: the bits at the positions of the
+	// zero bits of q-1 must be compared.
+	bound := new(big.Int).Sub(a.q, big.NewInt(1))
+	for _, i := range intervals {
+		if !i.positive || i.end != i.start+1 || (bound.Bit(i.start) == 0 && !a.wires[i.wire].compared) {
+			return false
+		}
+	}
+	return true
+}
+
+// compared records the boolean wires b used in the checks x⋅b == 0, where x
+// depends on other wires, as done by the comparisons of bit decompositions to a
+// constant.
+func (a *analyzer) compared() {
+	for _, id := range a.checks {
+		q := &a.constraints[id]
+		if len(q.c) != 0 {
+			continue
+		}
+		for _, s := range [2][2]lin{{q.a, q.b}, {q.b, q.a}} {
+			b, x := s[0], s[1]
+			if len(b) != 1 || b[0].wire == constantWire || a.wires[b[0].wire].bits != 1 {
+				continue
+			}
+			// x must depend on the previous bits, not only on b
+			for _, t := range x {
+				if t.wire != constantWire && t.wire != b[0].wire {
+					a.wires[b[0].wire].compared = true
+				}
+			}
+		}
+	}
+}
+
+// maxBooleanInputs bounds the number of boolean wires enumerated by booleans,
+// and maxBooleanDepth the number of definitions expanded to express a wire in
+// terms of boolean wires.
+const (
+	maxBooleanInputs = 3
+	maxBooleanDepth  = 4
+)
+
+// booleans records the boolean wires and linear expressions, until a fixpoint:
+//   - the constraints with a single wire w which is not boolean, for example
+//     k⋅(w² - w) == 0: w is boolean if the roots of the constraint in w are 0
+//     or 1 for all the boolean values of the other wires;
+//   - the wires and the sides of the constraints which are functions of a few
+//     boolean wires or expressions, for example w == x⋅y or the selections
+//     w == y + b⋅(x - y) with b, x and y boolean, whose values are 0 or 1 for
+//     all the boolean values of their inputs.
+func (a *analyzer) booleans() {
+	queue := make([]int, len(a.constraints))
+	queued := make([]bool, len(a.constraints))
+	for i := range queue {
+		queue[i], queued[i] = i, true
+	}
+	// a new boolean wire may make the constraints using it, or using the wires
+	// defined from it, boolean
+	push := func(w int) {
+		wires := []int{w}
+		seen := map[int]bool{w: true}
+		for depth := 0; depth <= maxBooleanDepth && len(wires) != 0; depth++ {
+			var next []int
+			for _, v := range wires {
+				for _, id := range a.wires[v].uses {
+					if !queued[id] {
+						queue, queued[id] = append(queue, id), true
+					}
+					if s := a.constraints[id].solved; s >= 0 && !seen[s] && a.wires[s].bits != 1 {
+						seen[s] = true
+						next = append(next, s)
+					}
+				}
+			}
+			wires = next
+		}
+	}
+	for len(queue) != 0 {
+		id := queue[0]
+		queue, queued[id] = queue[1:], false
+		q := &a.constraints[id]
+		for i, l := range []lin{q.a, q.b} {
+			if len(l) < 2 {
+				continue
+			}
+			k := a.sideKeys(id, i, l)
+			if !a.boolLins[k.l] && a.isBooleanExpr(func(leaves *[]string) (boolExpr, bool) {
+				return a.boolLin(l, k, leaves, maxBooleanDepth)
+			}) {
+				a.boolLins[k.l] = true
+				// the expressions extending l are in the constraints using its
+				// last wire
+				push(l[k.last].wire)
+			}
+		}
+		marked := constantWire
+		if w := q.solved; w >= 0 && a.wires[w].bits != 1 && a.isBooleanExpr(func(leaves *[]string) (boolExpr, bool) {
+			return a.boolWire(w, leaves, maxBooleanDepth)
+		}) {
+			marked = w
+		} else if w, ok := a.singleBoolean(q); ok {
+			marked = w
+		}
+		if marked != constantWire {
+			a.wires[marked].bits = 1
+			push(marked)
+			if d := a.wires[marked].def; d >= 0 && !queued[d] {
+				queue, queued[d] = append(queue, d), true
+			}
+		}
+	}
+}
+
+// singleBoolean returns the wire w if q, with the linear definitions of the
+// wires which are not boolean inlined, has a single wire w which is not
+// boolean, and the roots in w of q are 0 or 1.
+func (a *analyzer) singleBoolean(q *quad) (int, bool) {
+	if r := a.inlined(q); r != nil {
+		q = r
+	}
+	w := constantWire
+	var others []int
+	for _, l := range []lin{q.a, q.b, q.c} {
+		for _, t := range l {
+			switch {
+			case t.wire == constantWire || t.wire == w || slices.Contains(others, t.wire):
+			case a.wires[t.wire].bits == 1:
+				others = append(others, t.wire)
+			case w == constantWire:
+				w = t.wire
+			default:
+				return 0, false
+			}
+		}
+	}
+	if w == constantWire || len(others) > maxBooleanInputs || !a.isBoolean(q, w, others) {
+		return 0, false
+	}
+	return w, true
+}
+
+// inlined returns the small constraint q where the wires which are not boolean
+// are replaced by their linear definitions, as the check x⋅(1 - x - y) == 0
+// of PLONK where 1 - x - y is a wire, or nil.
+func (a *analyzer) inlined(q *quad) *quad {
+	if len(q.a)+len(q.b)+len(q.c) > 2*maxBooleanInputs {
+		return nil
+	}
+	inline := func(l lin) lin {
+		var res lin
+		for _, t := range l {
+			if t.wire != constantWire && a.wires[t.wire].bits != 1 {
+				if def, ok := a.linearDef(t.wire); ok {
+					res = a.add(res, a.scale(def, t.coeff))
+					continue
+				}
+			}
+			res = a.add(res, lin{t})
+		}
+		return res
+	}
+	return &quad{a: inline(q.a), b: inline(q.b), c: inline(q.c), cID: q.cID, solved: q.solved}
+}
+
+// linKeys are the canonical representations of a linear expression l, of l
+// without its last wire and of its opposite, see boolLin.
+type linKeys struct {
+	l, rest, negRest string
+	// last is the index in l of its last wire
+	last int
+}
+
+// sideKeys returns the keys of the side i of the constraint id, which is l.
+func (a *analyzer) sideKeys(id, i int, l lin) *linKeys {
+	if k, ok := a.linKeys[[2]int{id, i}]; ok {
+		return k
+	}
+	k := a.keys(l)
+	a.linKeys[[2]int{id, i}] = k
+	return k
+}
+
+func (a *analyzer) keys(l lin) *linKeys {
+	k := &linKeys{l: a.linKey(l, false)}
+	for i := range l {
+		if l[i].wire > l[k.last].wire {
+			k.last = i
+		}
+	}
+	if len(l) > 2 {
+		rest := append(slices.Clone(l[:k.last]), l[k.last+1:]...)
+		k.rest, k.negRest = a.linKey(rest, false), a.linKey(rest, true)
+	}
+	return k
+}
+
+// boolExpr evaluates an expression for the values of its boolean leaves.
+type boolExpr func(values []int64) *big.Int
+
+// isBooleanExpr returns true if the expression compiled by build only takes the
+// values 0 and 1.
+func (a *analyzer) isBooleanExpr(build func(leaves *[]string) (boolExpr, bool)) bool {
+	var leaves []string
+	e, ok := build(&leaves)
+	if !ok {
+		return false
+	}
+	values := make([]int64, len(leaves))
+	for m := 0; m < 1<<len(leaves); m++ {
+		for i := range values {
+			values[i] = int64(m>>i) & 1
+		}
+		if e(values).Cmp(big.NewInt(1)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// boolLeaf returns the leaf of key k, if there are at most maxBooleanInputs
+// leaves.
+func boolLeaf(k string, leaves *[]string) (boolExpr, bool) {
+	i := slices.Index(*leaves, k)
+	if i < 0 {
+		if len(*leaves) == maxBooleanInputs {
+			return nil, false
+		}
+		i = len(*leaves)
+		*leaves = append(*leaves, k)
+	}
+	return func(values []int64) *big.Int { return big.NewInt(values[i]) }, true
+}
+
+// boolLin compiles l, of keys k or nil, in terms of boolean leaves: the
+// boolean wires, the boolean linear expressions, and l = ±l' + c⋅w where l' is
+// boolean and w is the last wire of l, for the chains of selections.
+func (a *analyzer) boolLin(l lin, k *linKeys, leaves *[]string, depth int) (boolExpr, bool) {
+	if len(l) > 1 {
+		if k == nil {
+			k = a.keys(l)
+		}
+		if a.boolLins[k.l] {
+			return boolLeaf(k.l, leaves)
+		}
+		for _, rest := range []string{k.rest, k.negRest} {
+			if rest == "" || !a.boolLins[rest] {
+				continue
+			}
+			r, ok := boolLeaf(rest, leaves)
+			if !ok {
+				return nil, false
+			}
+			w, ok := a.boolWire(l[k.last].wire, leaves, depth)
+			if !ok {
+				return nil, false
+			}
+			c, neg := l[k.last].coeff, rest == k.negRest
+			return func(values []int64) *big.Int {
+				res := new(big.Int).Mul(c, w(values))
+				if neg {
+					return a.mod(res.Sub(res, r(values)))
+				}
+				return a.mod(res.Add(res, r(values)))
+			}, true
+		}
+	}
+	terms := make([]boolExpr, len(l))
+	for i, t := range l {
+		c := t.coeff
+		if t.wire == constantWire {
+			terms[i] = func([]int64) *big.Int { return c }
+			continue
+		}
+		w, ok := a.boolWire(t.wire, leaves, depth)
+		if !ok {
+			return nil, false
+		}
+		terms[i] = func(values []int64) *big.Int { return new(big.Int).Mul(c, w(values)) }
+	}
+	return func(values []int64) *big.Int {
+		res := new(big.Int)
+		for _, t := range terms {
+			res.Add(res, t(values))
+		}
+		return a.mod(res)
+	}, true
+}
+
+// boolWire compiles the wire w in terms of boolean leaves, expanding at most
+// depth definitions w = -(a⋅b + c)/k where w is only in the term k⋅w of c.
+func (a *analyzer) boolWire(w int, leaves *[]string, depth int) (boolExpr, bool) {
+	if a.wires[w].bits == 1 {
+		return boolLeaf(a.linKey(lin{{wire: w, coeff: big.NewInt(1)}}, false), leaves)
+	}
+	id := a.wires[w].def
+	if depth == 0 || id < 0 {
+		return nil, false
+	}
+	q := &a.constraints[id]
+	k := coeff(q.c, w)
+	if q.solved != w || k == nil || index(q.a, w) >= 0 || index(q.b, w) >= 0 {
+		return nil, false
+	}
+	ea, ok := a.boolLin(q.a, a.sideKeys(id, 0, q.a), leaves, depth-1)
+	if !ok {
+		return nil, false
+	}
+	eb, ok := a.boolLin(q.b, a.sideKeys(id, 1, q.b), leaves, depth-1)
+	if !ok {
+		return nil, false
+	}
+	ec, ok := a.boolLin(slices.DeleteFunc(slices.Clone(q.c), func(t term) bool { return t.wire == w }), nil, leaves, depth-1)
+	if !ok {
+		return nil, false
+	}
+	kInv := new(big.Int).ModInverse(k, a.q)
+	kInv.Neg(kInv)
+	return func(values []int64) *big.Int {
+		res := new(big.Int).Mul(ea(values), eb(values))
+		res.Add(res, ec(values))
+		return a.mod(res.Mul(res, kInv))
+	}, true
+}
+
+// linKey returns a canonical representation of l, or of -l if neg is set.
+func (a *analyzer) linKey(l lin, neg bool) string {
+	m := make(map[int]*big.Int, len(l))
+	for _, t := range l {
+		m[t.wire] = t.coeff
+		if neg {
+			m[t.wire] = new(big.Int).Sub(a.q, t.coeff)
+		}
+	}
+	return mapKey(m)
+}
+
+// isBoolean returns true if the roots in w of the constraint q are 0 or 1 for
+// all the boolean values of the other wires.
+func (a *analyzer) isBoolean(q *quad, w int, others []int) bool {
+	values := make(map[int]int64, len(others))
+	eval := func(l lin) (*big.Int, *big.Int) {
+		c1, c0 := new(big.Int), new(big.Int)
+		for _, t := range l {
+			switch {
+			case t.wire == w:
+				c1.Add(c1, t.coeff)
+			case t.wire == constantWire:
+				c0.Add(c0, t.coeff)
+			default:
+				c0.Add(c0, new(big.Int).Mul(t.coeff, big.NewInt(values[t.wire])))
+			}
+		}
+		return a.mod(c1), a.mod(c0)
+	}
+	for m := 0; m < 1<<len(others); m++ {
+		for i, v := range others {
+			values[v] = int64(m>>i) & 1
+		}
+		// q = (a1⋅w + a0)(b1⋅w + b0) + c1⋅w + c0 = p2⋅w² + p1⋅w + p0
+		a1, a0 := eval(q.a)
+		b1, b0 := eval(q.b)
+		c1, c0 := eval(q.c)
+		p2 := a.mod(new(big.Int).Mul(a1, b1))
+		p1 := a.mod(new(big.Int).Add(new(big.Int).Mul(a1, b0), new(big.Int).Add(new(big.Int).Mul(a0, b1), c1)))
+		p0 := a.mod(new(big.Int).Add(new(big.Int).Mul(a0, b0), c0))
+		p01 := a.mod(new(big.Int).Add(p0, p1))
+		switch {
+		case p2.Sign() == 0 && p1.Sign() == 0:
+			// no constraint on w, or no solution
+			if p0.Sign() == 0 {
+				return false
+			}
+		case p2.Sign() == 0:
+			// the root -p0/p1 is 0 or 1
+			if p0.Sign() != 0 && p01.Sign() != 0 {
+				return false
+			}
+		case p0.Sign() == 0:
+			// the roots are 0 and -p1/p2
+			if p1.Sign() != 0 && a.mod(new(big.Int).Add(p1, p2)).Sign() != 0 {
+				return false
+			}
+		default:
+			// the roots are 1 and p0/p2, if 1 is a root
+			if a.mod(new(big.Int).Add(p01, p2)).Sign() != 0 || p0.Cmp(p2) != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// nonZeros records the non-zero facts of the constraints a⋅b == c where c is
+// a non-zero constant, for example the constraints of the inverses.
+func (a *analyzer) nonZeros() {
+	for i := range a.constraints {
+		q := &a.constraints[i]
+		if !a.isNonZeroConstant(q.c) {
+			continue
+		}
+		for _, l := range []lin{q.a, q.b} {
+			if len(l) == 1 && l[0].wire != constantWire {
+				a.wires[l[0].wire].nonZero = true
+			}
+		}
+	}
+}
+
+// ranges propagates the range facts through the linear definitions and checks
+// w = Σ 2ᵉⁱ⋅wᵢ with disjoint bit ranges.
+func (a *analyzer) ranges() {
+	for changed := true; changed; {
+		changed = false
+		for w := a.nbInputs; w < len(a.wires); w++ {
+			info := &a.wires[w]
+			if info.bits != 0 || info.kind != kindFunctional {
+				continue
+			}
+			if rel, ok := a.linearize(&a.constraints[info.def]); ok && a.setBits(w, a.decompositionBits(rel, w)) {
+				changed = true
+			}
+		}
+		for _, id := range a.checks {
+			rel, ok := a.linearize(&a.constraints[id])
+			if !ok {
+				continue
+			}
+			// the single wire without range fact, as for the checks of the
+			// decompositions of the range checks
+			w := constantWire
+			for _, t := range rel {
+				if t.wire == constantWire || a.wires[t.wire].bits != 0 {
+					continue
+				}
+				if w != constantWire {
+					w = constantWire
+					break
+				}
+				w = t.wire
+			}
+			if w != constantWire && a.setBits(w, a.decompositionBits(rel, w)) {
+				changed = true
+			}
+		}
+	}
+}
+
+// decompositionBits returns the range of w if rel == 0 is w = Σ 2ᵉⁱ⋅wᵢ with
+// disjoint bit ranges, or 0.
+func (a *analyzer) decompositionBits(rel lin, w int) int {
+	c := coeff(rel, w)
+	if c == nil {
+		return 0
+	}
+	s := new(big.Int).ModInverse(c, a.q)
+	s.Neg(s).Mod(s, a.q)
+	type interval struct{ start, end int }
+	var intervals []interval
+	for _, t := range rel {
+		if t.wire == w {
+			continue
+		}
+		r := a.mod(new(big.Int).Mul(t.coeff, s))
+		e, ok := a.powers[r.String()]
+		if t.wire == constantWire || a.wires[t.wire].bits == 0 || !ok || e < 0 || r.Bit(e) != 1 || r.BitLen() != e+1 {
+			return 0
+		}
+		intervals = append(intervals, interval{e, e + a.wires[t.wire].bits})
+	}
+	if len(intervals) == 0 {
+		return 0
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start < intervals[j].start })
+	for i := 1; i < len(intervals); i++ {
+		if intervals[i].start < intervals[i-1].end {
+			return 0
+		}
+	}
+	if end := intervals[len(intervals)-1].end; end+1 < a.bitLen {
+		return end
+	}
+	return 0
+}
+
+// linearize returns q as a linear expression if one of the factors of its
+// product is constant.
+func (a *analyzer) linearize(q *quad) (lin, bool) {
+	var res lin
+	switch {
+	case a.isConstant(q.a):
+		res = a.add(a.scale(q.b, a.constant(q.a)), q.c)
+	case a.isConstant(q.b):
+		res = a.add(a.scale(q.a, a.constant(q.b)), q.c)
+	default:
+		return nil, false
+	}
+	return res, true
+}
+
+// report returns the undetermined internal wires.
+func (a *analyzer) report(cs constraint.ConstraintSystem) Report {
+	var r Report
+	for w := a.nbInputs; w < len(a.wires); w++ {
+		if !a.wires[w].determined {
+			r.NbUndetermined++
+		}
+	}
+	a.sources()
+	for w := a.nbInputs; w < len(a.wires); w++ {
+		info := &a.wires[w]
+		if !info.source {
+			continue
+		}
+		wire := Wire{ID: w, Hint: info.kind == kindHint}
+		if info.def >= 0 {
+			wire.Stack = cs.GetDebugInfoStack(a.constraints[info.def].cID)
+		}
+		for _, id := range info.uses {
+			if wire.Stack != nil {
+				break
+			}
+			wire.Stack = cs.GetDebugInfoStack(a.constraints[id].cID)
+		}
+		r.Wires = append(r.Wires, wire)
+	}
+	return r
+}
+
+// instructionTree records the wires solved by an instruction.
+type instructionTree struct {
+	a       *analyzer
+	outputs []int
+}
+
+func (t *instructionTree) InsertWire(wire uint32, level constraint.Level) {
+	t.a.wires[wire].solved = true
+	t.outputs = append(t.outputs, int(wire))
+}
+
+func (t *instructionTree) HasWire(wire uint32) bool {
+	return int(wire) >= t.a.nbInputs && int(wire) < len(t.a.wires)
+}
+
+func (t *instructionTree) GetWireLevel(wire uint32) constraint.Level {
+	if t.a.wires[wire].solved {
+		return 0
+	}
+	return constraint.LevelUnset
+}
+
+// lin returns the linear expression l with the constant wire of R1CS mapped to
+// constantWire.
+func (a *analyzer) lin(cs constraint.ConstraintSystem, l constraint.LinearExpression) lin {
+	res := make(lin, 0, len(l))
+	for _, t := range l {
+		w := int(t.VID)
+		if t.IsConstant() || (a.r1cs && w == 0) {
+			w = constantWire
+		}
+		res = a.add(res, lin{{wire: w, coeff: cs.ToBigInt(cs.GetCoefficient(int(t.CID)))}})
+	}
+	return res
+}
+
+// sparseQuad returns the constraint qL⋅xa + qR⋅xb + qO⋅xc + qM⋅(xa⋅xb) + qC == 0.
+func (a *analyzer) sparseQuad(cs constraint.ConstraintSystem, c *constraint.SparseR1C, cID int) quad {
+	t := func(cID, wire uint32) lin {
+		return a.add(nil, lin{{wire: int(wire), coeff: cs.ToBigInt(cs.GetCoefficient(int(cID)))}})
+	}
+	q := quad{
+		a:   t(c.QM, c.XA),
+		c:   a.add(a.add(t(c.QL, c.XA), t(c.QR, c.XB)), a.add(t(c.QO, c.XC), t(c.QC, math.MaxUint32))),
+		cID: cID,
+	}
+	if len(q.a) != 0 {
+		q.b = lin{{wire: int(c.XB), coeff: big.NewInt(1)}}
+	}
+	return q
+}
+
+func (a *analyzer) mod(x *big.Int) *big.Int {
+	return x.Mod(x, a.q)
+}
+
+// add returns l1 + l2, with one term per wire and no zero coefficient.
+func (a *analyzer) add(l1, l2 lin) lin {
+	res := make(lin, 0, len(l1)+len(l2))
+	for _, l := range []lin{l1, l2} {
+		for _, t := range l {
+			if t.wire == int(math.MaxUint32) {
+				t.wire = constantWire
+			}
+			if i := index(res, t.wire); i >= 0 {
+				res[i].coeff = a.mod(new(big.Int).Add(res[i].coeff, t.coeff))
+			} else {
+				res = append(res, term{wire: t.wire, coeff: a.mod(new(big.Int).Set(t.coeff))})
+			}
+		}
+	}
+	n := 0
+	for _, t := range res {
+		if t.coeff.Sign() != 0 {
+			res[n] = t
+			n++
+		}
+	}
+	return res[:n]
+}
+
+func (a *analyzer) scale(l lin, s *big.Int) lin {
+	res := make(lin, 0, len(l))
+	for _, t := range l {
+		res = append(res, term{wire: t.wire, coeff: a.mod(new(big.Int).Mul(t.coeff, s))})
+	}
+	return a.add(nil, res)
+}
+
+func (a *analyzer) neg(l lin) lin {
+	return a.scale(l, big.NewInt(-1))
+}
+
+func (a *analyzer) isConstant(l lin) bool {
+	return len(l) == 0 || (len(l) == 1 && l[0].wire == constantWire)
+}
+
+// constant returns the value of the constant expression l.
+func (a *analyzer) constant(l lin) *big.Int {
+	if len(l) == 0 {
+		return new(big.Int)
+	}
+	return l[0].coeff
+}
+
+func (a *analyzer) isNonZeroConstant(l lin) bool {
+	return len(l) == 1 && l[0].wire == constantWire
+}
+
+// determined returns true if all the wires of l are determined.
+func (a *analyzer) determined(l lin) bool {
+	for _, t := range l {
+		if t.wire != constantWire && !a.wires[t.wire].determined {
+			return false
+		}
+	}
+	return true
+}
+
+// proportional returns true if l1 = k⋅l2 for a non-zero k.
+func (a *analyzer) proportional(l1, l2 lin) bool {
+	if len(l1) != len(l2) || len(l1) == 0 {
+		return false
+	}
+	k := new(big.Int).ModInverse(l2[0].coeff, a.q)
+	k = a.mod(k.Mul(k, coeff(l1, l2[0].wire)))
+	for _, t := range l2 {
+		c := coeff(l1, t.wire)
+		if c == nil || a.mod(new(big.Int).Mul(t.coeff, k)).Cmp(c) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// split returns the coefficient of w in l and the constant term of l, where l
+// only has the wires w and constantWire.
+func (a *analyzer) split(l lin, w int) (*big.Int, *big.Int) {
+	c1, c0 := new(big.Int), new(big.Int)
+	if c := coeff(l, w); c != nil {
+		c1.Set(c)
+	}
+	if c := coeff(l, constantWire); c != nil {
+		c0.Set(c)
+	}
+	return c1, c0
+}
+
+// coeff returns the coefficient of w in l, or nil.
+func coeff(l lin, w int) *big.Int {
+	if i := index(l, w); i >= 0 {
+		return l[i].coeff
+	}
+	return nil
+}
+
+func index(l lin, w int) int {
+	for i := range l {
+		if l[i].wire == w {
+			return i
+		}
+	}
+	return -1
+}
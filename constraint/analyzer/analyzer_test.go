@@ -0,0 +1,187 @@
+package analyzer_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/constraint/analyzer"
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/std/rangecheck"
+	"github.com/stretchr/testify/require"
+)
+
+type determinedCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (c *determinedCircuit) Define(api frontend.API) error {
+	bits := api.ToBinary(c.X, 16)
+	x := api.FromBinary(bits...)
+	isZero := api.IsZero(api.Sub(x, c.Z))
+	inv := api.Inverse(c.Y)
+	s := api.Select(isZero, inv, api.Div(c.X, c.Y))
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	h.Write(s, api.Mul(c.X, c.Y))
+	api.AssertIsDifferent(h.Sum(), c.Z)
+	// full width decomposition, unique with the modulus check
+	api.AssertIsEqual(api.ToBinary(c.Y)[0], 1)
+	return nil
+}
+
+func squareRoot(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	if outputs[0].ModSqrt(inputs[0], inputs[1]) == nil {
+		outputs[0].SetUint64(0)
+	}
+	return nil
+}
+
+func init() {
+	solver.RegisterHint(squareRoot)
+}
+
+type squareRootCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *squareRootCircuit) Define(api frontend.API) error {
+	// the hint output is constrained, but both square roots are valid
+	res, err := api.Compiler().NewHint(squareRoot, 1, c.X, api.Compiler().Field())
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(api.Mul(res[0], res[0]), c.X)
+	api.AssertIsDifferent(c.X, c.Y)
+	return nil
+}
+
+type overflowCircuit struct {
+	X frontend.Variable
+}
+
+func (c *overflowCircuit) Define(api frontend.API) error {
+	// without the modulus check, X and X+q may have valid decompositions
+	b := bits.ToBinary(api, c.X, bits.OmitModulusCheck())
+	api.AssertIsEqual(b[0], 0)
+	return nil
+}
+
+type rangeCheckCircuit struct {
+	X, Y frontend.Variable
+}
+
+func (c *rangeCheckCircuit) Define(api frontend.API) error {
+	rangecheck.New(api).Check(c.X, 10)
+	api.AssertIsLessOrEqual(c.X, c.Y)
+	return nil
+}
+
+type uintsCircuit struct {
+	X, Y uints.U32
+}
+
+func (c *uintsCircuit) Define(api frontend.API) error {
+	bf, err := uints.New[uints.U32](api)
+	if err != nil {
+		return err
+	}
+	z := bf.Add(c.X, c.Y)
+	bf.AssertEq(bf.Xor(z, c.X), c.Y)
+	return nil
+}
+
+type emulatedCircuit struct {
+	X, Y emulated.Element[emulated.Secp256k1Fp]
+}
+
+func (c *emulatedCircuit) Define(api frontend.API) error {
+	f, err := emulated.NewField[emulated.Secp256k1Fp](api)
+	if err != nil {
+		return err
+	}
+	f.AssertIsEqual(f.Mul(&c.X, &c.Y), &c.X)
+	return nil
+}
+
+func compile(t *testing.T, circuit frontend.Circuit) []constraint.ConstraintSystem {
+	var res []constraint.ConstraintSystem
+	for _, builder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), builder, circuit)
+		require.NoError(t, err)
+		res = append(res, ccs)
+	}
+	// the std gadgets without the native lookups of PLONK
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, circuit, frontend.WithoutNativeLookups())
+	require.NoError(t, err)
+	return append(res, ccs)
+}
+
+func TestDetermined(t *testing.T) {
+	for _, ccs := range compile(t, &determinedCircuit{}) {
+		report := analyzer.Analyze(ccs)
+		require.NoError(t, report.Err())
+		require.Zero(t, report.NbUndetermined)
+	}
+}
+
+func TestUnderconstrainedHint(t *testing.T) {
+	for _, ccs := range compile(t, &squareRootCircuit{}) {
+		report := analyzer.Analyze(ccs)
+		require.Error(t, report.Err())
+		require.Len(t, report.Wires, 1)
+		require.True(t, report.Wires[0].Hint)
+	}
+}
+
+func TestUnderconstrainedDecomposition(t *testing.T) {
+	for _, ccs := range compile(t, &overflowCircuit{}) {
+		report := analyzer.Analyze(ccs)
+		require.NotEmpty(t, report.Wires)
+		for _, w := range report.Wires {
+			require.True(t, w.Hint)
+		}
+	}
+}
+
+func TestDeterminedRangeCheck(t *testing.T) {
+	for _, ccs := range compile(t, &rangeCheckCircuit{}) {
+		report := analyzer.Analyze(ccs)
+		require.NoError(t, report.Err())
+		require.Zero(t, report.NbUndetermined)
+	}
+}
+
+func TestDeterminedUints(t *testing.T) {
+	for _, ccs := range compile(t, &uintsCircuit{}) {
+		report := analyzer.Analyze(ccs)
+		require.NoError(t, report.Err())
+		require.Zero(t, report.NbUndetermined)
+	}
+}
+
+func TestUnderconstrainedEmulated(t *testing.T) {
+	for _, ccs := range compile(t, &emulatedCircuit{}) {
+		// the result of the multiplication is only unique modulo the emulated
+		// modulus: the outputs of its hint are reported, but not the range
+		// checks and the arguments computed from them
+		report := analyzer.Analyze(ccs)
+		require.NotEmpty(t, report.Wires)
+		require.Less(t, 10*len(report.Wires), report.NbUndetermined)
+		for _, w := range report.Wires {
+			require.True(t, w.Hint)
+		}
+	}
+}
@@ -0,0 +1,526 @@
+package analyzer
+
+import (
+	"container/heap"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/consensys/gnark/constraint"
+)
+
+// lookupRow is the constraint (wires...) ∈ table of a native lookup.
+type lookupRow struct {
+	wires []int
+	table *lookupTable
+	cID   int
+	// solved is true if the last wire is solved by the lookup
+	solved bool
+}
+
+type lookupTable struct {
+	// functional is true if the last column is a function of the others
+	functional bool
+	// bits[j] is such that the entries of the column j are less than 2^bits[j], or 0
+	bits []int
+}
+
+// addLookup records the lookup row c of the table b, solving the given wires.
+func (a *analyzer) addLookup(cs constraint.ConstraintSystem, b *constraint.BlueprintLookup, c *constraint.SparseR1C, cID int, solved []int) {
+	t, ok := a.tables[b.Index]
+	if !ok {
+		t = &lookupTable{functional: b.IsFunctional(), bits: make([]int, b.Width)}
+		for j := range t.bits {
+			for _, r := range b.Rows {
+				if l := cs.ToBigInt(cs.GetCoefficient(int(r[j]))).BitLen(); l > t.bits[j] {
+					t.bits[j] = l
+				}
+			}
+			if t.bits[j]+1 >= a.bitLen {
+				t.bits[j] = 0
+			}
+		}
+		a.tables[b.Index] = t
+	}
+	row := lookupRow{
+		wires:  []int{int(c.XA), int(c.XB), int(c.XC)}[:b.Width],
+		table:  t,
+		cID:    cID,
+		solved: len(solved) != 0,
+	}
+	for _, w := range row.wires {
+		a.wires[w].nbLookups++
+	}
+	for _, w := range solved {
+		a.wires[w].kind = kindLookup
+		a.lookupDefs[w] = len(a.lookups)
+	}
+	a.lookups = append(a.lookups, row)
+}
+
+// lookupRanges records the range facts of the wires of the lookup rows, given
+// by the entries of the columns of the tables.
+func (a *analyzer) lookupRanges() {
+	for _, row := range a.lookups {
+		for j, w := range row.wires {
+			a.setBits(w, row.table.bits[j])
+		}
+	}
+}
+
+// setBits records that w is less than 2^bits, if it is a tighter bound.
+func (a *analyzer) setBits(w, bits int) bool {
+	if bits == 0 || w == constantWire || (a.wires[w].bits != 0 && a.wires[w].bits <= bits) {
+		return false
+	}
+	a.wires[w].bits = bits
+	return true
+}
+
+// keysDetermined returns true if the wires of the row but the last one are
+// determined.
+func (a *analyzer) keysDetermined(row *lookupRow) bool {
+	for _, w := range row.wires[:len(row.wires)-1] {
+		if !a.wires[w].determined {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupChecks determines the last wire of the lookup rows in functional
+// tables which are not solved by the lookup, once the keys are determined.
+func (a *analyzer) lookupChecks() bool {
+	changed := false
+	for i := range a.lookups {
+		row := &a.lookups[i]
+		last := row.wires[len(row.wires)-1]
+		if row.solved || !row.table.functional || len(row.wires) < 2 || a.wires[last].determined {
+			continue
+		}
+		if a.keysDetermined(row) {
+			a.wires[last].determined = true
+			changed = true
+		}
+	}
+	return changed
+}
+
+// challenges records the wires computed from the commitments only, which are
+// random values the prover does not choose.
+func (a *analyzer) challenges(commitments []int) {
+	for _, w := range commitments {
+		a.wires[w].challenge = true
+	}
+	for changed := len(commitments) != 0; changed; {
+		changed = false
+		for w := a.nbInputs; w < len(a.wires); w++ {
+			info := &a.wires[w]
+			if info.challenge || info.kind != kindFunctional {
+				continue
+			}
+			q := &a.constraints[info.def]
+			challenge := true
+			for _, l := range []lin{q.a, q.b, q.c} {
+				for _, t := range l {
+					if t.wire != w && t.wire != constantWire && !a.wires[t.wire].challenge {
+						challenge = false
+					}
+				}
+			}
+			if challenge {
+				info.challenge = true
+				changed = true
+			}
+		}
+	}
+}
+
+// fraction is the decomposition of a wire f constrained by f⋅(k⋅x + e) = n,
+// where x is a challenge and e and n are bound before the challenge. The
+// denominator is not zero with high probability, so that f is computed from
+// the other wires.
+type fraction struct {
+	x    int
+	k    *big.Int
+	e, n map[int]*big.Int
+}
+
+// fractions records the wires which are fractions.
+func (a *analyzer) fractions() {
+	bound := func(w int) bool {
+		return w < a.nbInputs || (a.wires[w].committed && !a.wires[w].challenge)
+	}
+	for w := a.nbInputs; w < len(a.wires); w++ {
+		if k := a.wires[w].kind; k != kindGuarded && k != kindFunctional {
+			continue
+		}
+		q := &a.constraints[a.wires[w].def]
+		var factor lin
+		switch {
+		case len(q.a) == 1 && q.a[0].wire == w:
+			factor = a.scale(q.b, q.a[0].coeff)
+		case len(q.b) == 1 && q.b[0].wire == w:
+			factor = a.scale(q.a, q.b[0].coeff)
+		default:
+			continue
+		}
+		if coeff(q.c, w) != nil {
+			continue
+		}
+		f := fraction{x: constantWire}
+		e, ok := a.expand(factor, func(v int) bool {
+			if a.wires[v].challenge {
+				if f.x != constantWire {
+					return false
+				}
+				f.x = v
+				return true
+			}
+			return bound(v)
+		})
+		if !ok || f.x == constantWire {
+			continue
+		}
+		f.k, f.e = e[f.x], e
+		delete(f.e, f.x)
+		if f.n, ok = a.expand(a.neg(q.c), bound); ok {
+			a.fracs[w] = &f
+		}
+	}
+}
+
+// argument is a log-derivative argument Σ sᵢ⋅nᵢ/(kᵢ⋅x + eᵢ) == 0, that is
+// Σ wᵢ/(x - rᵢ) == 0 with the roots rᵢ = -eᵢ/kᵢ and the weights wᵢ = sᵢ⋅nᵢ/kᵢ.
+// As x is a challenge, the identity holds for the rational functions of x: the
+// weights of the equal roots sum to zero.
+//
+// The terms with constant roots form the table, with the multiplicities as
+// weights. The other ones are the queries, with a common constant weight, so
+// that the roots of the queries are in the table.
+type argument struct {
+	table   []*root
+	queries []*root
+}
+
+type root struct {
+	value  map[int]*big.Int
+	weight map[int]*big.Int
+}
+
+// arguments records the log-derivative arguments among the checks and the
+// range facts of their queries.
+func (a *analyzer) arguments() {
+	if len(a.fracs) == 0 {
+		return
+	}
+	for _, id := range a.checks {
+		rel, ok := a.linearize(&a.constraints[id])
+		if !ok || !a.hasFraction(rel) {
+			continue
+		}
+		terms, ok := a.expand(rel, func(w int) bool { return a.fracs[w] != nil })
+		if !ok || len(terms) == 0 {
+			continue
+		}
+		if arg := a.argument(terms); arg != nil {
+			a.args = append(a.args, arg)
+		}
+	}
+}
+
+// hasFraction returns true if the expansion of l may contain fractions.
+func (a *analyzer) hasFraction(l lin) bool {
+	for _, t := range l {
+		if t.wire != constantWire && (a.fracs[t.wire] != nil || a.withFraction[t.wire]) {
+			return true
+		}
+	}
+	return false
+}
+
+// argument returns the log-derivative argument Σ cᵢ⋅fᵢ == 0 where fᵢ are
+// fractions, or nil if it is not one.
+func (a *analyzer) argument(terms map[int]*big.Int) *argument {
+	x := constantWire
+	roots := make(map[string]*root)
+	var w0 *big.Int
+	for w, c := range terms {
+		if w == constantWire {
+			return nil
+		}
+		f := a.fracs[w]
+		if x != constantWire && f.x != x {
+			return nil
+		}
+		x = f.x
+		kInv := new(big.Int).ModInverse(f.k, a.q)
+		r := a.scaleMap(f.e, a.mod(new(big.Int).Neg(kInv)))
+		weight := a.scaleMap(f.n, a.mod(new(big.Int).Mul(c, kInv)))
+		if !isConstantMap(r) {
+			// the weights of the queries are all equal to w0
+			if !isConstantMap(weight) {
+				return nil
+			}
+			if w0 == nil {
+				w0 = constantOf(weight)
+			}
+			if w0.Sign() == 0 || constantOf(weight).Cmp(w0) != 0 {
+				return nil
+			}
+		}
+		key := mapKey(r)
+		rt, ok := roots[key]
+		if !ok {
+			rt = &root{value: r, weight: make(map[int]*big.Int)}
+			roots[key] = rt
+		}
+		a.accumulate(rt.weight, weight, big.NewInt(1))
+	}
+
+	var arg argument
+	keys := make([]string, 0, len(roots))
+	for k := range roots {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		rt := roots[k]
+		if isConstantMap(rt.value) {
+			arg.table = append(arg.table, rt)
+		} else {
+			arg.queries = append(arg.queries, rt)
+		}
+	}
+	if len(arg.queries) == 0 || len(arg.table) == 0 {
+		return nil
+	}
+
+	// the queries with a single wire are in the range of the table
+	for _, rt := range arg.queries {
+		w, alpha, n := constantWire, (*big.Int)(nil), 0
+		for v, c := range rt.value {
+			if v != constantWire {
+				w, alpha = v, c
+				n++
+			}
+		}
+		if n != 1 {
+			continue
+		}
+		beta := constantOf(rt.value)
+		inv := new(big.Int).ModInverse(alpha, a.q)
+		bits := 0
+		for _, t := range arg.table {
+			v := new(big.Int).Sub(constantOf(t.value), beta)
+			if l := a.mod(v.Mul(v, inv)).BitLen(); l > bits {
+				bits = l
+			}
+		}
+		if bits+1 < a.bitLen {
+			a.setBits(w, bits)
+		}
+	}
+	return &arg
+}
+
+// argumentDetermined determines the multiplicities of the table of the
+// argument when the queries are determined.
+func (a *analyzer) argumentDetermined(arg *argument) bool {
+	for _, rt := range arg.queries {
+		for w := range rt.value {
+			if w != constantWire && !a.wires[w].determined {
+				return false
+			}
+		}
+	}
+	changed := false
+	for _, rt := range arg.table {
+		w := constantWire
+		for v, c := range rt.weight {
+			if v == constantWire || c.Sign() == 0 || a.wires[v].determined {
+				continue
+			}
+			if w != constantWire {
+				w = constantWire
+				break
+			}
+			w = v
+		}
+		if w != constantWire && !a.wires[w].determined {
+			a.wires[w].determined = true
+			changed = true
+		}
+	}
+	return changed
+}
+
+// withFractions records the wires computed linearly from fractions.
+func (a *analyzer) withFractions() {
+	for w := a.nbInputs; w < len(a.wires); w++ {
+		def, ok := a.linearDef(w)
+		if !ok {
+			continue
+		}
+		for _, t := range def {
+			if t.wire != constantWire && (a.fracs[t.wire] != nil || a.withFraction[t.wire]) {
+				a.withFraction[w] = true
+				break
+			}
+		}
+	}
+}
+
+// maxExpandSize bounds the number of wires substituted by expand.
+const maxExpandSize = 1 << 20
+
+// expand returns l as a linear combination of leaves, substituting the wires
+// computed linearly from other wires, except the committed wires. It returns false if a leaf w is not accepted by leaf(w), or if the
+// expression is too large.
+func (a *analyzer) expand(l lin, leaf func(w int) bool) (map[int]*big.Int, bool) {
+	res := make(map[int]*big.Int)
+	pending := make(map[int]*big.Int)
+	var h wireHeap
+	push := func(w int, c *big.Int) {
+		if w == constantWire {
+			a.accumulate(res, map[int]*big.Int{constantWire: c}, big.NewInt(1))
+			return
+		}
+		if v, ok := pending[w]; ok {
+			a.mod(v.Add(v, c))
+			return
+		}
+		pending[w] = a.mod(new(big.Int).Set(c))
+		heap.Push(&h, w)
+	}
+	for _, t := range l {
+		push(t.wire, t.coeff)
+	}
+	// the wires are substituted from the last one, so that the coefficient of
+	// a wire is complete when it is popped
+	for steps := 0; h.Len() != 0; steps++ {
+		if steps == maxExpandSize {
+			return nil, false
+		}
+		w := heap.Pop(&h).(int)
+		c := pending[w]
+		delete(pending, w)
+		if c.Sign() == 0 {
+			continue
+		}
+		if def, ok := a.linearDef(w); ok && !a.wires[w].committed {
+			for _, t := range def {
+				push(t.wire, new(big.Int).Mul(t.coeff, c))
+			}
+			continue
+		}
+		if !leaf(w) {
+			return nil, false
+		}
+		a.accumulate(res, map[int]*big.Int{w: c}, big.NewInt(1))
+	}
+	for w, c := range res {
+		if c.Sign() == 0 {
+			delete(res, w)
+		}
+	}
+	return res, true
+}
+
+// linearDef returns the expression of w as a linear combination of the other
+// wires of its defining constraint, if it is linear.
+func (a *analyzer) linearDef(w int) (lin, bool) {
+	info := &a.wires[w]
+	if info.kind != kindFunctional {
+		return nil, false
+	}
+	rel, ok := a.linearize(&a.constraints[info.def])
+	if !ok {
+		return nil, false
+	}
+	c := coeff(rel, w)
+	if c == nil {
+		return nil, false
+	}
+	s := new(big.Int).ModInverse(c, a.q)
+	s.Neg(s).Mod(s, a.q)
+	res := make(lin, 0, len(rel)-1)
+	for _, t := range rel {
+		if t.wire != w {
+			res = append(res, term{wire: t.wire, coeff: a.mod(new(big.Int).Mul(t.coeff, s))})
+		}
+	}
+	return res, true
+}
+
+// accumulate adds s⋅m to res.
+func (a *analyzer) accumulate(res, m map[int]*big.Int, s *big.Int) {
+	for w, c := range m {
+		v, ok := res[w]
+		if !ok {
+			v = new(big.Int)
+			res[w] = v
+		}
+		v.Add(v, new(big.Int).Mul(c, s)).Mod(v, a.q)
+	}
+}
+
+func (a *analyzer) scaleMap(m map[int]*big.Int, s *big.Int) map[int]*big.Int {
+	res := make(map[int]*big.Int, len(m))
+	for w, c := range m {
+		res[w] = a.mod(new(big.Int).Mul(c, s))
+	}
+	return res
+}
+
+// isConstantMap returns true if the expression m has no wire.
+func isConstantMap(m map[int]*big.Int) bool {
+	for w, c := range m {
+		if w != constantWire && c.Sign() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// constantOf returns the constant term of the expression m.
+func constantOf(m map[int]*big.Int) *big.Int {
+	if c, ok := m[constantWire]; ok {
+		return c
+	}
+	return new(big.Int)
+}
+
+// mapKey returns a canonical representation of the expression m.
+func mapKey(m map[int]*big.Int) string {
+	wires := make([]int, 0, len(m))
+	for w, c := range m {
+		if c.Sign() != 0 {
+			wires = append(wires, w)
+		}
+	}
+	sort.Ints(wires)
+	var sbb strings.Builder
+	for _, w := range wires {
+		sbb.WriteString(strconv.Itoa(w))
+		sbb.WriteByte(':')
+		sbb.WriteString(m[w].String())
+		sbb.WriteByte(';')
+	}
+	return sbb.String()
+}
+
+// wireHeap is a max-heap of wires.
+type wireHeap []int
+
+func (h wireHeap) Len() int           { return len(h) }
+func (h wireHeap) Less(i, j int) bool { return h[i] > h[j] }
+func (h wireHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *wireHeap) Push(x any)        { *h = append(*h, x.(int)) }
+func (h *wireHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -75,6 +75,21 @@ type BlueprintStateful interface {
 	Reset()
 }
 
+// BlueprintComposite indicates that an instruction of the blueprint encodes
+// several instructions of other blueprints, see [BlueprintComponent].
+type BlueprintComposite interface {
+	Blueprint
+
+	// NbInstructions returns the number of instructions encoded by an
+	// instruction of the blueprint.
+	NbInstructions() int
+
+	// Instruction returns the i-th instruction encoded by inst and its
+	// blueprint. The calldata of the returned instruction is stored in buf and
+	// is only valid until the next call.
+	Instruction(inst Instruction, i int, buf *[]uint32) (Blueprint, Instruction)
+}
+
 // Compressible represent an object that knows how to encode itself as a []uint32.
 type Compressible interface {
 	// Compress interprets the objects as a LinearExpression and encodes it as a []uint32.
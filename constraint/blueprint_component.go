@@ -0,0 +1,202 @@
+package constraint
+
+import (
+	"fmt"
+	"math"
+)
+
+// BlueprintComponent implements Blueprint and BlueprintComposite.
+//
+// It encodes all the constraints and hints of a sub-circuit compiled once as a
+// template. An instruction of the blueprint is an instance of the sub-circuit:
+// its calldata are the input wires of the instance and its output wires are the
+// internal wires of the template.
+//
+// The wires of the template are mapped to the wires of an instance as follows:
+//   - wire 0 is mapped to wire 0 (the constant wire in R1CS, unused in PLONK);
+//   - wires 1 to NbInputs are mapped to the input wires;
+//   - the following wires are mapped to the output wires of the instance.
+type BlueprintComponent struct {
+	Name     string
+	NbInputs int
+	// NbWires is the number of internal wires of the template, allocated for
+	// every instance.
+	NbWires int
+	// Outputs are the template wires returned by the sub-circuit.
+	Outputs []uint32
+
+	// Blueprints, Instructions and CallData are the instructions of the
+	// template, with coefficient ids of the instantiating system.
+	Blueprints   []Blueprint
+	Instructions []PackedInstruction
+	CallData     []uint32
+
+	NbTemplateConstraints int
+}
+
+// NewBlueprintComponent returns a new component blueprint from the template
+// system. The first NbInputs secret wires of template are the inputs of the
+// component. The coefficients and hints of template are registered in cs,
+// which must be of the same type as template.
+//
+// The template can only contain generic constraints and hints; commitments,
+// custom gates and lookups are not supported. The logs and debug information
+// of the template are not kept.
+func NewBlueprintComponent(name string, template ConstraintSystem, nbInputs int, outputs []int, cs ConstraintSystem) (*BlueprintComponent, error) {
+	t, ok := template.(coreSystem)
+	if !ok {
+		return nil, fmt.Errorf("component %q: unsupported constraint system %T", name, template)
+	}
+	c, ok := cs.(coreSystem)
+	if !ok {
+		return nil, fmt.Errorf("component %q: unsupported constraint system %T", name, cs)
+	}
+	tSystem, system := t.core(), c.core()
+	if tSystem.Type != system.Type {
+		return nil, fmt.Errorf("component %q: template type does not match the system type", name)
+	}
+	if tSystem.GetNbPublicVariables()+tSystem.GetNbSecretVariables() != nbInputs+1 {
+		return nil, fmt.Errorf("component %q: template must have exactly one public wire and %d secret wires", name, nbInputs)
+	}
+	if len(tSystem.CommitmentInfo.CommitmentIndexes()) != 0 || tSystem.GkrInfo.Is() {
+		return nil, fmt.Errorf("component %q: commitments and GKR are not supported in components", name)
+	}
+
+	b := &BlueprintComponent{
+		Name:                  name,
+		NbInputs:              nbInputs,
+		NbWires:               tSystem.NbInternalVariables,
+		Outputs:               make([]uint32, len(outputs)),
+		NbTemplateConstraints: tSystem.NbConstraints,
+	}
+	for i, o := range outputs {
+		b.Outputs[i] = uint32(o)
+	}
+
+	// the coefficient ids of the template are mapped to the ones of cs
+	cIDs := make(map[uint32]uint32)
+	coeff := func(cID uint32) uint32 {
+		if r, ok := cIDs[cID]; ok {
+			return r
+		}
+		r := cs.AddCoeff(template.GetCoefficient(int(cID)))
+		cIDs[cID] = r
+		return r
+	}
+	bIDs := make(map[BlueprintID]BlueprintID)
+
+	var scratch []uint32
+	for _, pi := range tSystem.Instructions {
+		blueprint := tSystem.Blueprints[pi.BlueprintID]
+		if !isRemappable(blueprint) {
+			return nil, fmt.Errorf("component %q: unsupported blueprint %T in component", name, blueprint)
+		}
+		if err := remapInstruction(blueprint, pi.Unpack(tSystem), identity, coeff, keepHint, &scratch); err != nil {
+			return nil, fmt.Errorf("component %q: %w", name, err)
+		}
+
+		bID, ok := bIDs[pi.BlueprintID]
+		if !ok {
+			bID = BlueprintID(len(b.Blueprints))
+			b.Blueprints = append(b.Blueprints, blueprint)
+			bIDs[pi.BlueprintID] = bID
+		}
+		b.Instructions = append(b.Instructions, PackedInstruction{
+			BlueprintID:      bID,
+			ConstraintOffset: pi.ConstraintOffset,
+			WireOffset:       pi.WireOffset,
+			StartCallData:    uint64(len(b.CallData)),
+		})
+		b.CallData = append(b.CallData, scratch...)
+	}
+	for id, name := range tSystem.MHintsDependencies {
+		system.MHintsDependencies[id] = name
+	}
+
+	return b, nil
+}
+
+func (b *BlueprintComponent) CalldataSize() int {
+	return b.NbInputs
+}
+
+func (b *BlueprintComponent) NbConstraints() int {
+	return b.NbTemplateConstraints
+}
+
+func (b *BlueprintComponent) NbOutputs(inst Instruction) int {
+	return b.NbWires
+}
+
+func (b *BlueprintComponent) NbInstructions() int {
+	return len(b.Instructions)
+}
+
+// OutputWires returns the wires of the instance inst corresponding to the
+// outputs of the sub-circuit.
+func (b *BlueprintComponent) OutputWires(inst Instruction) []uint32 {
+	res := make([]uint32, len(b.Outputs))
+	for i, w := range b.Outputs {
+		res[i] = b.InstanceWire(inst, w)
+	}
+	return res
+}
+
+// UpdateInstructionTree solves the whole instance at the same level, after all
+// its inputs.
+func (b *BlueprintComponent) UpdateInstructionTree(inst Instruction, tree InstructionTree) Level {
+	maxLevel := LevelUnset
+	for _, w := range inst.Calldata {
+		if !tree.HasWire(w) {
+			continue
+		}
+		if level := tree.GetWireLevel(w); level > maxLevel {
+			maxLevel = level
+		}
+	}
+	outputLevel := maxLevel + 1
+	for i := 0; i < b.NbWires; i++ {
+		tree.InsertWire(inst.WireOffset+uint32(i), outputLevel)
+	}
+	return outputLevel
+}
+
+// Instruction returns the i-th instruction of the instance inst, with the
+// template wires mapped to the instance wires, and its blueprint. The calldata
+// of the returned instruction is stored in buf.
+func (b *BlueprintComponent) Instruction(inst Instruction, i int, buf *[]uint32) (Blueprint, Instruction) {
+	pi := b.Instructions[i]
+	blueprint := b.Blueprints[pi.BlueprintID]
+	cSize := blueprint.CalldataSize()
+	if cSize < 0 {
+		cSize = int(b.CallData[pi.StartCallData])
+	}
+	tInst := Instruction{
+		ConstraintOffset: pi.ConstraintOffset,
+		WireOffset:       pi.WireOffset,
+		Calldata:         b.CallData[pi.StartCallData : pi.StartCallData+uint64(cSize)],
+	}
+
+	// the blueprints of the template are checked by NewBlueprintComponent
+	_ = remapInstruction(blueprint, tInst, func(w uint32) uint32 { return b.InstanceWire(inst, w) }, identity, keepHint, buf)
+
+	return blueprint, Instruction{
+		ConstraintOffset: inst.ConstraintOffset + pi.ConstraintOffset,
+		WireOffset:       b.InstanceWire(inst, pi.WireOffset),
+		Calldata:         *buf,
+	}
+}
+
+// InstanceWire returns the wire of the instance inst corresponding to the
+// template wire w.
+func (b *BlueprintComponent) InstanceWire(inst Instruction, w uint32) uint32 {
+	switch {
+	case w == 0 || w == math.MaxUint32:
+		// constant
+		return w
+	case w <= uint32(b.NbInputs):
+		return inst.Calldata[w-1]
+	default:
+		return inst.WireOffset + w - uint32(b.NbInputs) - 1
+	}
+}
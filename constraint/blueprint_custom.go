@@ -0,0 +1,308 @@
+package constraint
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxCustomGateDegree is the maximal total degree of a term of a custom gate.
+// The quotient of the PLONK prover grows with the degree of the gates, see
+// [QuotientShardFactor].
+const MaxCustomGateDegree = 17
+
+// ErrCustomGateDegree is returned when defining a custom gate with a term of
+// total degree larger than [MaxCustomGateDegree].
+var ErrCustomGateDegree = errors.New("custom gate degree too large")
+
+var errCustomGateNotSolvable = errors.New("custom gate is not solvable for the output wire")
+
+// CustomGateTerm is a monomial coeff⋅xa^Exponents[0]⋅xb^Exponents[1]⋅xc^Exponents[2]⋅
+// xa'^Exponents[3]⋅xb'^Exponents[4]⋅xc'^Exponents[5] of a custom gate polynomial,
+// where CID is the coefficient id and xa', xb', xc' are the wires of the next
+// row.
+type CustomGateTerm struct {
+	CID       uint32
+	Exponents [6]uint8
+}
+
+// Degree returns the total degree of the term.
+func (t CustomGateTerm) Degree() int {
+	res := 0
+	for _, e := range t.Exponents {
+		res += int(e)
+	}
+	return res
+}
+
+// UsesNextRow returns true if the term depends on the wires of the next row.
+func (t CustomGateTerm) UsesNextRow() bool {
+	return t.Exponents[3] != 0 || t.Exponents[4] != 0 || t.Exponents[5] != 0
+}
+
+// QuotientShardFactor returns the factor k such that the quotient of the PLONK
+// prover is split in three shards of size k⋅(n+2) (k⋅n without zero knowledge)
+// when the custom gates are of total degree at most degree. A gate of degree d
+// adds a term of degree d⋅(n+1)-1 to the quotient, which fits in the three
+// shards of size n+2 for d ≤ 3, so that k = ⌈d/3⌉.
+//
+// If a gate uses the next row, the wires are opened at two points and blinded
+// by polynomials of degree 2, so that the wires are of degree n+2 and the
+// permutation argument adds a term of degree 3n+8 to the quotient: k ≥ 2.
+func QuotientShardFactor(degree int, nextRow bool) int {
+	if nextRow {
+		degree = max(degree, 4)
+	}
+	return max(1, (degree+2)/3)
+}
+
+// BlueprintCustomGate implements Blueprint, BlueprintSolvable and BlueprintSparseR1C.
+// Encodes
+//
+//	Σᵢ qᵢ⋅xa^aᵢ⋅xb^bᵢ⋅xc^cᵢ⋅xa'^a'ᵢ⋅xb'^b'ᵢ⋅xc'^c'ᵢ == 0
+//
+// where the terms are fixed for all the instructions of the blueprint. In the
+// PLONK backend every custom gate gets its own selector polynomial, the
+// decompressed SparseR1C has all the standard selectors set to zero.
+//
+// If the gate uses the wires xa', xb', xc' of the next row, the instruction
+// holds the six wires and must be immediately followed by an instruction of
+// [BlueprintCustomGateNextRow] placing xa', xb', xc' in the next row.
+type BlueprintCustomGate struct {
+	Name string
+	// Index is the index of the gate in the list of the custom gates of the
+	// system, see [System.GetCustomGates].
+	Index int
+	Terms []CustomGateTerm
+}
+
+// NewBlueprintCustomGate returns a new custom gate blueprint. It returns an
+// error wrapping [ErrCustomGateDegree] if the total degree of some term exceeds
+// [MaxCustomGateDegree].
+func NewBlueprintCustomGate(name string, index int, terms []CustomGateTerm) (*BlueprintCustomGate, error) {
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("custom gate %q has no terms", name)
+	}
+	for _, t := range terms {
+		if t.Degree() > MaxCustomGateDegree {
+			return nil, fmt.Errorf("custom gate %q has a term of degree %d > %d: %w", name, t.Degree(), MaxCustomGateDegree, ErrCustomGateDegree)
+		}
+	}
+	return &BlueprintCustomGate{Name: name, Index: index, Terms: terms}, nil
+}
+
+// UsesNextRow returns true if some term of the gate depends on the wires of
+// the next row.
+func (b *BlueprintCustomGate) UsesNextRow() bool {
+	for _, t := range b.Terms {
+		if t.UsesNextRow() {
+			return true
+		}
+	}
+	return false
+}
+
+// Degree returns the maximal total degree of the terms of the gate.
+func (b *BlueprintCustomGate) Degree() int {
+	res := 0
+	for _, t := range b.Terms {
+		res = max(res, t.Degree())
+	}
+	return res
+}
+
+func (b *BlueprintCustomGate) CalldataSize() int {
+	if b.UsesNextRow() {
+		return 6
+	}
+	return 3
+}
+func (b *BlueprintCustomGate) NbConstraints() int {
+	return 1
+}
+func (b *BlueprintCustomGate) NbOutputs(inst Instruction) int {
+	return 0
+}
+
+func (b *BlueprintCustomGate) UpdateInstructionTree(inst Instruction, tree InstructionTree) Level {
+	return updateInstructionTree(inst.Calldata, tree)
+}
+
+// CompressSparseR1C appends the wires of c. The wires of the next row are
+// appended by the builder, see [BlueprintCustomGate].
+func (b *BlueprintCustomGate) CompressSparseR1C(c *SparseR1C, to *[]uint32) {
+	*to = append(*to, c.XA, c.XB, c.XC)
+}
+
+func (b *BlueprintCustomGate) DecompressSparseR1C(c *SparseR1C, inst Instruction) {
+	c.Clear()
+	c.XA = inst.Calldata[0]
+	c.XB = inst.Calldata[1]
+	c.XC = inst.Calldata[2]
+	c.CustomGate = uint32(b.Index) + 1
+}
+
+// OutputTerm returns the index of the term qᵢ⋅xc if it is the only term
+// depending on xc. Otherwise it returns -1 and the gate can not be used to
+// compute xc.
+func (b *BlueprintCustomGate) OutputTerm() int {
+	res := -1
+	for i, t := range b.Terms {
+		if t.Exponents[2] == 0 {
+			continue
+		}
+		if res != -1 || t.Exponents != [6]uint8{0, 0, 1, 0, 0, 0} {
+			return -1
+		}
+		res = i
+	}
+	return res
+}
+
+func (b *BlueprintCustomGate) Solve(s Solver, inst Instruction) error {
+	wires := inst.Calldata[:b.CalldataSize()]
+	xc := wires[2]
+	if !s.IsSolved(xc) {
+		// qₒ⋅xc + Σ_{i≠o} qᵢ⋅xa^aᵢ⋅xb^bᵢ⋅xa'^a'ᵢ⋅xb'^b'ᵢ⋅xc'^c'ᵢ == 0
+		o := b.OutputTerm()
+		if o == -1 || !b.isSolved(s, wires, 2) {
+			return errCustomGateNotSolvable
+		}
+		den, ok := s.Inverse(s.GetCoeff(b.Terms[o].CID))
+		if !ok {
+			return errDivideByZero
+		}
+		var acc Element
+		for i, t := range b.Terms {
+			if i == o {
+				continue
+			}
+			acc = s.Add(acc, b.evaluateTerm(s, t, wires))
+		}
+		s.SetValue(xc, s.Neg(s.Mul(acc, den)))
+		return nil
+	}
+	if !b.isSolved(s, wires, -1) {
+		return errCustomGateNotSolvable
+	}
+	var acc Element
+	for _, t := range b.Terms {
+		acc = s.Add(acc, b.evaluateTerm(s, t, wires))
+	}
+	if !acc.IsZero() {
+		return fmt.Errorf("custom gate %q not satisfied: %s != 0", b.Name, s.String(acc))
+	}
+	return nil
+}
+
+// isSolved returns true if all the wires but the skip-th one are solved.
+func (b *BlueprintCustomGate) isSolved(s Solver, wires []uint32, skip int) bool {
+	for j, w := range wires {
+		if j != skip && !s.IsSolved(w) {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluateTerm returns qᵢ⋅xa^aᵢ⋅xb^bᵢ⋅xc^cᵢ⋅xa'^a'ᵢ⋅xb'^b'ᵢ⋅xc'^c'ᵢ.
+func (b *BlueprintCustomGate) evaluateTerm(s Solver, t CustomGateTerm, wires []uint32) Element {
+	res := s.GetCoeff(t.CID)
+	for j, w := range wires {
+		if t.Exponents[j] == 0 {
+			continue
+		}
+		v := s.GetValue(CoeffIdOne, w)
+		for k := uint8(0); k < t.Exponents[j]; k++ {
+			res = s.Mul(res, v)
+		}
+	}
+	return res
+}
+
+// String formats the gate polynomial using the resolver for the coefficients.
+func (b *BlueprintCustomGate) String(r Resolver) string {
+	var sbb strings.Builder
+	for i, t := range b.Terms {
+		if i > 0 {
+			sbb.WriteString(" + ")
+		}
+		sbb.WriteString(r.CoeffToString(int(t.CID)))
+		for j, w := range [6]string{"xa", "xb", "xc", "xa'", "xb'", "xc'"} {
+			if t.Exponents[j] == 0 {
+				continue
+			}
+			sbb.WriteString("⋅")
+			sbb.WriteString(w)
+			if t.Exponents[j] > 1 {
+				sbb.WriteString(fmt.Sprintf("^%d", t.Exponents[j]))
+			}
+		}
+	}
+	return sbb.String()
+}
+
+// BlueprintCustomGateNextRow implements Blueprint and BlueprintSparseR1C. It
+// places the wires xa', xb', xc' of the next row of a [BlueprintCustomGate]
+// using the next row. The decompressed SparseR1C has all its selectors set to
+// zero, the wires are constrained by the preceding custom gate.
+type BlueprintCustomGateNextRow struct{}
+
+func (b *BlueprintCustomGateNextRow) CalldataSize() int {
+	return 3
+}
+func (b *BlueprintCustomGateNextRow) NbConstraints() int {
+	return 1
+}
+func (b *BlueprintCustomGateNextRow) NbOutputs(inst Instruction) int {
+	return 0
+}
+
+func (b *BlueprintCustomGateNextRow) UpdateInstructionTree(inst Instruction, tree InstructionTree) Level {
+	return updateInstructionTree(inst.Calldata[0:3], tree)
+}
+
+func (b *BlueprintCustomGateNextRow) CompressSparseR1C(c *SparseR1C, to *[]uint32) {
+	*to = append(*to, c.XA, c.XB, c.XC)
+}
+
+func (b *BlueprintCustomGateNextRow) DecompressSparseR1C(c *SparseR1C, inst Instruction) {
+	c.Clear()
+	c.XA = inst.Calldata[0]
+	c.XB = inst.Calldata[1]
+	c.XC = inst.Calldata[2]
+}
+
+// GetCustomGates returns the custom gate blueprints of the system ordered by
+// their index.
+func (system *System) GetCustomGates() []*BlueprintCustomGate {
+	var res []*BlueprintCustomGate
+	for _, b := range system.Blueprints {
+		if g, ok := b.(*BlueprintCustomGate); ok {
+			res = append(res, g)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Index < res[j].Index })
+	return res
+}
+
+// GetQuotientShardFactor returns the shard factor of the quotient of the PLONK
+// prover for the custom gates of the system, see [QuotientShardFactor].
+func (system *System) GetQuotientShardFactor() int {
+	nextRow := false
+	for _, g := range system.GetCustomGates() {
+		nextRow = nextRow || g.UsesNextRow()
+	}
+	return QuotientShardFactor(system.GetCustomGatesDegree(), nextRow)
+}
+
+// GetCustomGatesDegree returns the maximal degree of the custom gates of the
+// system, or 0 if the system has no custom gate.
+func (system *System) GetCustomGatesDegree() int {
+	res := 0
+	for _, g := range system.GetCustomGates() {
+		res = max(res, g.Degree())
+	}
+	return res
+}
@@ -0,0 +1,168 @@
+package constraint
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MaxLookupTableWidth is the maximal number of columns of a lookup table. The
+// columns are mapped to the xa, xb and xc wires of the constraint.
+const MaxLookupTableWidth = 3
+
+var errLookupNotSolvable = errors.New("lookup is not solvable for the output wire")
+
+// BlueprintLookup implements Blueprint, BlueprintSolvable and BlueprintSparseR1C.
+// Encodes
+//
+//	(xa, xb, xc) ∈ Table
+//
+// where the table is fixed at compile time. The entries of the table are stored
+// as coefficient ids. If the table has less than [MaxLookupTableWidth] columns,
+// the last column is repeated, i.e. a lookup in a table of width 2 is encoded
+// as (xa, xb, xb) ∈ {(t₀, t₁, t₁)}.
+//
+// In the PLONK backend the tables of all the blueprints are concatenated and
+// the decompressed SparseR1C has all the standard selectors set to zero.
+type BlueprintLookup struct {
+	Name string
+	// Index is the index of the table in the list of the lookup tables of
+	// the system, see [System.GetLookupTables].
+	Index int
+	// Width is the number of columns of the table.
+	Width int
+	// Rows are the coefficient ids of the entries of the table.
+	Rows [][MaxLookupTableWidth]uint32
+
+	// solver lookups, lazily built from the coefficients
+	once sync.Once
+	rows map[[MaxLookupTableWidth]Element]struct{}
+	keys map[[MaxLookupTableWidth - 1]Element]Element
+}
+
+// NewBlueprintLookup returns a new lookup blueprint. Rows must be non-empty
+// and have width at most [MaxLookupTableWidth]; the entries of rows narrower
+// than the table width are padded by repeating the last column.
+func NewBlueprintLookup(name string, index, width int, rows [][MaxLookupTableWidth]uint32) (*BlueprintLookup, error) {
+	if width < 1 || width > MaxLookupTableWidth {
+		return nil, fmt.Errorf("lookup table %q has invalid width %d", name, width)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("lookup table %q has no rows", name)
+	}
+	return &BlueprintLookup{Name: name, Index: index, Width: width, Rows: rows}, nil
+}
+
+func (b *BlueprintLookup) CalldataSize() int {
+	return 3
+}
+func (b *BlueprintLookup) NbConstraints() int {
+	return 1
+}
+func (b *BlueprintLookup) NbOutputs(inst Instruction) int {
+	return 0
+}
+
+func (b *BlueprintLookup) UpdateInstructionTree(inst Instruction, tree InstructionTree) Level {
+	return updateInstructionTree(inst.Calldata[0:3], tree)
+}
+
+func (b *BlueprintLookup) CompressSparseR1C(c *SparseR1C, to *[]uint32) {
+	*to = append(*to, c.XA, c.XB, c.XC)
+}
+
+func (b *BlueprintLookup) DecompressSparseR1C(c *SparseR1C, inst Instruction) {
+	c.Clear()
+	c.XA = inst.Calldata[0]
+	c.XB = inst.Calldata[1]
+	c.XC = inst.Calldata[2]
+	c.Lookup = uint32(b.Index) + 1
+}
+
+// IsFunctional returns true if the last column of the table is a function of
+// the other columns, in which case the corresponding wire can be computed by
+// the solver.
+func (b *BlueprintLookup) IsFunctional() bool {
+	if b.Width < 2 {
+		return false
+	}
+	seen := make(map[[MaxLookupTableWidth - 1]uint32]uint32, len(b.Rows))
+	for _, r := range b.Rows {
+		var key [MaxLookupTableWidth - 1]uint32
+		copy(key[:], r[:b.Width-1])
+		if v, ok := seen[key]; ok && v != r[b.Width-1] {
+			return false
+		}
+		seen[key] = r[b.Width-1]
+	}
+	return true
+}
+
+func (b *BlueprintLookup) init(s Solver) {
+	b.once.Do(func() {
+		b.rows = make(map[[MaxLookupTableWidth]Element]struct{}, len(b.Rows))
+		if b.Width > 1 {
+			b.keys = make(map[[MaxLookupTableWidth - 1]Element]Element, len(b.Rows))
+		}
+		for _, r := range b.Rows {
+			var row [MaxLookupTableWidth]Element
+			for j := range row {
+				row[j] = s.GetCoeff(r[j])
+			}
+			b.rows[row] = struct{}{}
+			if b.keys != nil {
+				var key [MaxLookupTableWidth - 1]Element
+				copy(key[:], row[:b.Width-1])
+				b.keys[key] = row[b.Width-1]
+			}
+		}
+	})
+}
+
+func (b *BlueprintLookup) Solve(s Solver, inst Instruction) error {
+	b.init(s)
+	wires := inst.Calldata[0:3]
+	if out := wires[b.Width-1]; !s.IsSolved(out) {
+		if b.keys == nil {
+			return errLookupNotSolvable
+		}
+		var key [MaxLookupTableWidth - 1]Element
+		for j := 0; j < b.Width-1; j++ {
+			if !s.IsSolved(wires[j]) {
+				return errLookupNotSolvable
+			}
+			key[j] = s.GetValue(CoeffIdOne, wires[j])
+		}
+		v, ok := b.keys[key]
+		if !ok {
+			return fmt.Errorf("lookup table %q has no entry for the inputs", b.Name)
+		}
+		s.SetValue(out, v)
+		return nil
+	}
+	var row [MaxLookupTableWidth]Element
+	for j := range row {
+		if !s.IsSolved(wires[j]) {
+			return errLookupNotSolvable
+		}
+		row[j] = s.GetValue(CoeffIdOne, wires[j])
+	}
+	if _, ok := b.rows[row]; !ok {
+		return fmt.Errorf("lookup table %q does not contain (%s, %s, %s)", b.Name, s.String(row[0]), s.String(row[1]), s.String(row[2]))
+	}
+	return nil
+}
+
+// GetLookupTables returns the lookup table blueprints of the system ordered by
+// their index.
+func (system *System) GetLookupTables() []*BlueprintLookup {
+	var res []*BlueprintLookup
+	for _, b := range system.Blueprints {
+		if t, ok := b.(*BlueprintLookup); ok {
+			res = append(res, t)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Index < res[j].Index })
+	return res
+}
@@ -84,6 +84,7 @@ func TestSerialization(t *testing.T) {
 						"CoeffTable.mCoeffs",
 						"System.lbWireLevel",
 						"System.genericHint",
+						"System.nbForkedWires",
 						"System.SymbolTable",
 						"System.bitLen")); diff != "" {
 					t.Fatalf("round trip mismatch (-want +got):\n%s", diff)
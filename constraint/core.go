@@ -127,6 +127,10 @@ type System struct {
 	GkrInfo        GkrInfo
 
 	genericHint BlueprintID
+
+	// number of internal wires of the system this system was forked from, see
+	// [Fork]. They are inputs of this system and are not in the level builder.
+	nbForkedWires int
 }
 
 // NewSystem initialize the common structure among constraint system
@@ -229,7 +233,7 @@ func (system *System) AddInternalVariable() (idx int) {
 	system.NbInternalVariables++
 	// also grow the level slice
 	system.lbWireLevel = append(system.lbWireLevel, LevelUnset)
-	if debug.Debug && len(system.lbWireLevel) != system.NbInternalVariables {
+	if debug.Debug && len(system.lbWireLevel) != system.NbInternalVariables-system.nbForkedWires {
 		panic("internal error")
 	}
 	return idx
@@ -327,6 +331,23 @@ func (system *System) AttachDebugInfo(debugInfo DebugInfo, constraintID []int) {
 	}
 }
 
+// GetDebugInfoStack returns the call stack, as "function file:line" entries,
+// of the debug information attached to the constraint cID, or nil if there is
+// none.
+func (system *System) GetDebugInfoStack(cID int) []string {
+	dID, ok := system.MDebug[cID]
+	if !ok {
+		return nil
+	}
+	stack := make([]string, len(system.DebugInfo[dID].Stack))
+	for i, lID := range system.DebugInfo[dID].Stack {
+		location := system.SymbolTable.Locations[lID]
+		function := system.SymbolTable.Functions[location.FunctionID]
+		stack[i] = fmt.Sprintf("%s %s:%d", function.Name, function.Filename, location.Line)
+	}
+	return stack
+}
+
 // VariableToString implements Resolver
 func (system *System) VariableToString(vID int) string {
 	nbPublic := system.GetNbPublicVariables()
@@ -432,11 +453,68 @@ func (cs *System) CheckUnconstrainedWires() error {
 }
 
 func (cs *System) GetR1CIterator() R1CIterator {
-	return R1CIterator{cs: cs}
+	return R1CIterator{instructionIterator: instructionIterator{cs: cs}}
 }
 
 func (cs *System) GetSparseR1CIterator() SparseR1CIterator {
-	return SparseR1CIterator{cs: cs}
+	return SparseR1CIterator{instructionIterator: instructionIterator{cs: cs}}
+}
+
+// GetInstructionIterator returns an iterator over the instructions of the
+// system and their blueprints.
+func (cs *System) GetInstructionIterator() InstructionIterator {
+	return InstructionIterator{instructionIterator: instructionIterator{cs: cs}}
+}
+
+// InstructionIterator iterates through the instructions of a system and their
+// blueprints, replacing the instructions of a BlueprintComposite by the
+// instructions they encode.
+type InstructionIterator struct {
+	instructionIterator
+}
+
+// Next returns the next instruction and its blueprint, or false if end. The
+// calldata of the instruction may be reused by the following calls.
+func (it *InstructionIterator) Next() (Blueprint, Instruction, bool) {
+	return it.next()
+}
+
+// instructionIterator iterates through the instructions of a system, replacing
+// the instructions of a BlueprintComposite by the instructions they encode.
+type instructionIterator struct {
+	cs *System
+	n  int
+
+	// current composite instruction
+	composite BlueprintComposite
+	inst      Instruction
+	i         int
+	buf       []uint32
+}
+
+// next returns the next instruction and its blueprint, or false if end.
+func (it *instructionIterator) next() (Blueprint, Instruction, bool) {
+	for {
+		if it.composite != nil {
+			if it.i < it.composite.NbInstructions() {
+				it.i++
+				blueprint, inst := it.composite.Instruction(it.inst, it.i-1, &it.buf)
+				return blueprint, inst, true
+			}
+			it.composite = nil
+		}
+		if it.n >= it.cs.GetNbInstructions() {
+			return nil, Instruction{}, false
+		}
+		pi := it.cs.Instructions[it.n]
+		it.n++
+		blueprint := it.cs.Blueprints[pi.BlueprintID]
+		if bc, ok := blueprint.(BlueprintComposite); ok {
+			it.composite, it.inst, it.i = bc, pi.Unpack(it.cs), 0
+			continue
+		}
+		return blueprint, pi.Unpack(it.cs), true
+	}
 }
 
 func (cs *System) GetCommitments() Commitments {
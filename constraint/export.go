@@ -0,0 +1,572 @@
+package constraint
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONSystem is the JSON representation of a constraint system written by
+// [WriteJSON]. It is meant for reviews and external tools, and can not be read
+// back into a constraint system.
+//
+// The wires are numbered as in the constraint system: the public wires first,
+// then the secret wires and the internal wires. In R1CS, the public wire 0 is
+// the constant 1. The coefficients are decimal strings of field elements,
+// possibly negative for readability (-1 is the modulus minus 1).
+type JSONSystem struct {
+	// Type is "r1cs" or "plonk".
+	Type string `json:"type"`
+	// Field is the modulus of the scalar field, in decimal.
+	Field       string           `json:"field"`
+	Wires       []JSONWire       `json:"wires"`
+	Constraints []JSONConstraint `json:"constraints"`
+	Hints       []JSONHint       `json:"hints"`
+	Commitments []JSONCommitment `json:"commitments"`
+	// Instructions are the instructions of blueprints which are neither
+	// constraints nor hints, for example GKR.
+	Instructions []JSONInstruction `json:"instructions,omitempty"`
+}
+
+// JSONWire is a wire of a [JSONSystem].
+type JSONWire struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Visibility is "public", "secret" or "internal".
+	Visibility string `json:"visibility"`
+}
+
+// JSONTerm is the term Coeff⋅Wire of a linear expression. Wire is -1 for a
+// constant term.
+type JSONTerm struct {
+	Wire  int    `json:"wire"`
+	Coeff string `json:"coeff"`
+}
+
+// JSONConstraint is a constraint of a [JSONSystem].
+//
+// A R1CS constraint L⋅R == O has the fields L, R and O. A PLONK constraint
+// qL⋅xa + qR⋅xb + qO⋅xc + qM⋅(xa⋅xb) + qC == 0 has the fields XA to QC, and
+// optionally Commitment, CustomGate and Lookup.
+type JSONConstraint struct {
+	ID int `json:"id"`
+	// Blueprint is the type of the blueprint of the constraint, for example
+	// "BlueprintGenericR1C".
+	Blueprint string `json:"blueprint"`
+	// String is the constraint formatted with the names of the wires.
+	String string `json:"string"`
+
+	L []JSONTerm `json:"l,omitempty"`
+	R []JSONTerm `json:"r,omitempty"`
+	O []JSONTerm `json:"o,omitempty"`
+
+	XA *int   `json:"xa,omitempty"`
+	XB *int   `json:"xb,omitempty"`
+	XC *int   `json:"xc,omitempty"`
+	QL string `json:"qL,omitempty"`
+	QR string `json:"qR,omitempty"`
+	QO string `json:"qO,omitempty"`
+	QM string `json:"qM,omitempty"`
+	QC string `json:"qC,omitempty"`
+	// Commitment is "committed" for a committed value xa, or "commitment" for
+	// a commitment xa.
+	Commitment string `json:"commitment,omitempty"`
+	// CustomGate is the index of the custom gate enforced by the constraint.
+	CustomGate *int `json:"customGate,omitempty"`
+	// Lookup is the index of the lookup table containing (xa, xb, xc).
+	Lookup *int `json:"lookup,omitempty"`
+
+	// Stack is the call stack of the debug information attached to the
+	// constraint, as "function file:line" entries.
+	Stack []string `json:"stack,omitempty"`
+}
+
+// JSONHint is a hint call of a [JSONSystem]: the wires Outputs[0] to Outputs[1]
+// excluded are computed by the hint Name from the Inputs.
+type JSONHint struct {
+	ID      uint32       `json:"id"`
+	Name    string       `json:"name"`
+	Inputs  [][]JSONTerm `json:"inputs"`
+	Outputs [2]int       `json:"outputs"`
+}
+
+// JSONCommitment is a commitment of a [JSONSystem].
+//
+// For Groth16, Committed are the committed private and internal wires,
+// PublicCommitted the committed public and commitment wires and Commitment the
+// commitment wire. For PLONK, Committed are the constraints defining the
+// committed values and Commitment the constraint defining the commitment.
+type JSONCommitment struct {
+	Committed       []int `json:"committed"`
+	PublicCommitted []int `json:"publicCommitted,omitempty"`
+	Commitment      int   `json:"commitment"`
+}
+
+// JSONInstruction is an instruction of a blueprint which is neither a
+// constraint nor a hint.
+type JSONInstruction struct {
+	Blueprint string   `json:"blueprint"`
+	Calldata  []uint32 `json:"calldata"`
+	// Inputs are the wires the instruction depends on and Outputs the wires it
+	// computes.
+	Inputs  []int `json:"inputs"`
+	Outputs []int `json:"outputs"`
+}
+
+// WriteJSON writes the constraint system cs to w as a [JSONSystem].
+func WriteJSON(w io.Writer, cs ConstraintSystem) error {
+	c, ok := cs.(coreSystem)
+	if !ok {
+		return fmt.Errorf("export: unsupported constraint system %T", cs)
+	}
+	system := c.core()
+
+	res := JSONSystem{
+		Field:       cs.Field().String(),
+		Wires:       make([]JSONWire, 0, system.GetNbPublicVariables()+system.GetNbSecretVariables()+system.NbInternalVariables),
+		Constraints: make([]JSONConstraint, 0, system.NbConstraints),
+		Hints:       []JSONHint{},
+		Commitments: []JSONCommitment{},
+	}
+	switch system.Type {
+	case SystemR1CS:
+		res.Type = "r1cs"
+	case SystemSparseR1CS:
+		res.Type = "plonk"
+	default:
+		return errors.New("export: unknown constraint system type")
+	}
+
+	nbPublic, nbSecret := system.GetNbPublicVariables(), system.GetNbSecretVariables()
+	for i := 0; i < nbPublic+nbSecret+system.NbInternalVariables; i++ {
+		visibility := "internal"
+		if i < nbPublic {
+			visibility = "public"
+		} else if i < nbPublic+nbSecret {
+			visibility = "secret"
+		}
+		res.Wires = append(res.Wires, JSONWire{ID: i, Name: cs.VariableToString(i), Visibility: visibility})
+	}
+
+	terms := func(l LinearExpression) []JSONTerm {
+		r := make([]JSONTerm, len(l))
+		for i, t := range l {
+			r[i] = JSONTerm{Wire: int(t.VID), Coeff: cs.CoeffToString(int(t.CID))}
+			if t.IsConstant() {
+				r[i].Wire = -1
+			}
+		}
+		return r
+	}
+	wire := func(w uint32) *int {
+		r := int(w)
+		return &r
+	}
+
+	var (
+		r1c    R1C
+		sparse SparseR1C
+		hint   HintMapping
+		tree   = newWireRecorder(system)
+	)
+	it := system.GetInstructionIterator()
+	for blueprint, inst, ok := it.Next(); ok; blueprint, inst, ok = it.Next() {
+		tree.record(blueprint, inst)
+		switch b := blueprint.(type) {
+		case BlueprintR1C:
+			b.DecompressR1C(&r1c, inst)
+			res.Constraints = append(res.Constraints, JSONConstraint{
+				ID:        int(inst.ConstraintOffset),
+				Blueprint: blueprintName(blueprint),
+				String:    r1c.String(cs),
+				L:         terms(r1c.L),
+				R:         terms(r1c.R),
+				O:         terms(r1c.O),
+				Stack:     cs.GetDebugInfoStack(int(inst.ConstraintOffset)),
+			})
+		case BlueprintSparseR1C:
+			b.DecompressSparseR1C(&sparse, inst)
+			jc := JSONConstraint{
+				ID:        int(inst.ConstraintOffset),
+				Blueprint: blueprintName(blueprint),
+				String:    sparse.String(cs),
+				XA:        wire(sparse.XA),
+				XB:        wire(sparse.XB),
+				XC:        wire(sparse.XC),
+				QL:        cs.CoeffToString(int(sparse.QL)),
+				QR:        cs.CoeffToString(int(sparse.QR)),
+				QO:        cs.CoeffToString(int(sparse.QO)),
+				QM:        cs.CoeffToString(int(sparse.QM)),
+				QC:        cs.CoeffToString(int(sparse.QC)),
+				Stack:     cs.GetDebugInfoStack(int(inst.ConstraintOffset)),
+			}
+			switch sparse.Commitment {
+			case COMMITTED:
+				jc.Commitment = "committed"
+			case COMMITMENT:
+				jc.Commitment = "commitment"
+			}
+			if sparse.CustomGate != 0 {
+				jc.CustomGate = wire(sparse.CustomGate - 1)
+			}
+			if sparse.Lookup != 0 {
+				jc.Lookup = wire(sparse.Lookup - 1)
+			}
+			res.Constraints = append(res.Constraints, jc)
+		case BlueprintHint:
+			b.DecompressHint(&hint, inst)
+			jh := JSONHint{
+				ID:      uint32(hint.HintID),
+				Name:    system.MHintsDependencies[hint.HintID],
+				Inputs:  make([][]JSONTerm, len(hint.Inputs)),
+				Outputs: [2]int{int(hint.OutputRange.Start), int(hint.OutputRange.End)},
+			}
+			for i := range hint.Inputs {
+				jh.Inputs[i] = terms(hint.Inputs[i])
+			}
+			res.Hints = append(res.Hints, jh)
+		default:
+			res.Instructions = append(res.Instructions, JSONInstruction{
+				Blueprint: blueprintName(blueprint),
+				Calldata:  append([]uint32(nil), inst.Calldata...),
+				Inputs:    append([]int{}, tree.inputs...),
+				Outputs:   append([]int{}, tree.outputs...),
+			})
+		}
+	}
+
+	switch commitments := system.CommitmentInfo.(type) {
+	case Groth16Commitments:
+		for _, c := range commitments {
+			res.Commitments = append(res.Commitments, JSONCommitment{
+				Committed:       c.PrivateCommitted,
+				PublicCommitted: c.PublicAndCommitmentCommitted,
+				Commitment:      c.CommitmentIndex,
+			})
+		}
+	case PlonkCommitments:
+		for _, c := range commitments {
+			res.Commitments = append(res.Commitments, JSONCommitment{
+				Committed:  c.Committed,
+				Commitment: c.CommitmentIndex,
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(res)
+}
+
+// DOTOption restricts the graph written by [WriteDOT].
+type DOTOption func(*dotConfig)
+
+type dotConfig struct {
+	roots   []int
+	regions []string
+}
+
+// WithDOTRoot restricts the graph to the wire and the wires it depends on. It
+// can be given several times to keep the union of the sub-graphs.
+func WithDOTRoot(wire int) DOTOption {
+	return func(c *dotConfig) {
+		c.roots = append(c.roots, wire)
+	}
+}
+
+// WithDOTRegion restricts the graph to the instructions whose debug stack
+// contains a function or file name containing region, for example a gadget
+// name. Only the constraints with debug information, for example assertions
+// compiled with the debug build tag, can be matched.
+func WithDOTRegion(region string) DOTOption {
+	return func(c *dotConfig) {
+		c.regions = append(c.regions, region)
+	}
+}
+
+// WriteDOT writes the wire dependency graph of the constraint system cs to w in
+// the Graphviz DOT format.
+//
+// The wires are the nodes of the graph, with the public and secret inputs at
+// the top. An edge a → b means that the wire b is computed from the wire a,
+// the edges of the wires computed by hints being labelled with the hint name.
+// The constraints which do not compute a wire are the box nodes, with edges
+// from the wires they check.
+func WriteDOT(w io.Writer, cs ConstraintSystem, opts ...DOTOption) error {
+	c, ok := cs.(coreSystem)
+	if !ok {
+		return fmt.Errorf("export: unsupported constraint system %T", cs)
+	}
+	system := c.core()
+	var config dotConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	type node struct {
+		inputs []int
+		label  string
+		// check is the constraint checking the inputs, or -1
+		check int
+		// hint is true for the hint calls, which have no debug information
+		hint   bool
+		region bool
+	}
+	var (
+		nodes []*node
+		// producers[w] is the instruction computing the wire w
+		producers = make(map[int]*node)
+		tree      = newWireRecorder(system)
+		hint      HintMapping
+	)
+	it := system.GetInstructionIterator()
+	for blueprint, inst, ok := it.Next(); ok; blueprint, inst, ok = it.Next() {
+		tree.record(blueprint, inst)
+		n := &node{
+			inputs: append([]int{}, tree.inputs...),
+			check:  -1,
+			region: len(config.regions) == 0,
+		}
+		if b, ok := blueprint.(BlueprintHint); ok {
+			b.DecompressHint(&hint, inst)
+			n.hint = true
+			n.label = system.MHintsDependencies[hint.HintID]
+			if i := strings.LastIndexByte(n.label, '.'); i >= 0 {
+				n.label = n.label[i+1:]
+			}
+		}
+		if blueprint.NbConstraints() > 0 {
+			if len(tree.outputs) == 0 {
+				n.check = int(inst.ConstraintOffset)
+			}
+			if !n.region {
+				n.region = inRegion(cs.GetDebugInfoStack(int(inst.ConstraintOffset)), config.regions)
+			}
+		}
+		if len(tree.outputs) == 0 && n.check == -1 {
+			continue
+		}
+		for _, o := range tree.outputs {
+			producers[o] = n
+		}
+		nodes = append(nodes, n)
+	}
+
+	// the hints of a region are the ones computing wires used in the region
+	var hints []*node
+	for _, n := range nodes {
+		if !n.region {
+			continue
+		}
+		for _, in := range n.inputs {
+			if p := producers[in]; p != nil && p.hint && !p.region {
+				hints = append(hints, p)
+			}
+		}
+	}
+	for _, n := range hints {
+		n.region = true
+	}
+	var (
+		wireNodes = make(map[int]*node)
+		checks    []*node
+	)
+	for w, n := range producers {
+		if n.region {
+			wireNodes[w] = n
+		}
+	}
+	for _, n := range nodes {
+		if n.region && n.check != -1 {
+			checks = append(checks, n)
+		}
+	}
+	// the wires to draw
+	var keep map[int]bool
+	if len(config.roots) != 0 {
+		keep = make(map[int]bool)
+		stack := append([]int{}, config.roots...)
+		for len(stack) != 0 {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if keep[w] {
+				continue
+			}
+			keep[w] = true
+			if n, ok := wireNodes[w]; ok {
+				stack = append(stack, n.inputs...)
+			}
+		}
+	}
+	kept := func(w int) bool {
+		return keep == nil || keep[w]
+	}
+
+	bw := bufio.NewWriter(w)
+	nbInputs := system.GetNbPublicVariables() + system.GetNbSecretVariables()
+	used := make(map[int]bool)
+	var edges strings.Builder
+	for _, wire := range sortedKeys(wireNodes) {
+		if !kept(wire) {
+			continue
+		}
+		n := wireNodes[wire]
+		used[wire] = true
+		for _, in := range n.inputs {
+			used[in] = true
+			fmt.Fprintf(&edges, "  w%d -> w%d", in, wire)
+			if n.label != "" {
+				fmt.Fprintf(&edges, " [label=%s]", strconv.Quote(n.label))
+			}
+			edges.WriteString(";\n")
+		}
+	}
+	var checkNodes strings.Builder
+	for _, n := range checks {
+		all := true
+		for _, in := range n.inputs {
+			all = all && kept(in)
+		}
+		if !all || len(n.inputs) == 0 {
+			continue
+		}
+		fmt.Fprintf(&checkNodes, "  c%d [shape=box];\n", n.check)
+		for _, in := range n.inputs {
+			used[in] = true
+			fmt.Fprintf(&edges, "  w%d -> c%d;\n", in, n.check)
+		}
+	}
+
+	bw.WriteString("digraph constraints {\n")
+	bw.WriteString("  node [shape=ellipse];\n")
+	bw.WriteString("  { rank=source;\n")
+	for _, wire := range sortedKeys(used) {
+		if wire < nbInputs {
+			shape := "house"
+			if wire < system.GetNbPublicVariables() {
+				shape = "doubleoctagon"
+			}
+			fmt.Fprintf(bw, "    w%d [label=%s, shape=%s];\n", wire, strconv.Quote(cs.VariableToString(wire)), shape)
+		}
+	}
+	bw.WriteString("  }\n")
+	for _, wire := range sortedKeys(used) {
+		if wire >= nbInputs {
+			fmt.Fprintf(bw, "  w%d [label=%s];\n", wire, strconv.Quote(cs.VariableToString(wire)))
+		}
+	}
+	bw.WriteString(checkNodes.String())
+	bw.WriteString(edges.String())
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// blueprintName returns the name of the type of b, for example
+// "BlueprintGenericR1C".
+func blueprintName(b Blueprint) string {
+	t := reflect.TypeOf(b)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// inRegion returns true if an entry of the stack contains one of the regions.
+func inRegion(stack []string, regions []string) bool {
+	for _, s := range stack {
+		for _, r := range regions {
+			if strings.Contains(s, r) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// wireRecorder is an InstructionTree recording the input and output wires of
+// the instructions, which must be recorded in order.
+type wireRecorder struct {
+	inputs, outputs []int
+	seen            map[int]bool
+	solved          []bool
+	r1cs            bool
+}
+
+func newWireRecorder(system *System) *wireRecorder {
+	nbInputs := system.GetNbPublicVariables() + system.GetNbSecretVariables()
+	t := &wireRecorder{
+		seen:   make(map[int]bool),
+		solved: make([]bool, nbInputs+system.NbInternalVariables),
+		r1cs:   system.Type == SystemR1CS,
+	}
+	for i := 0; i < nbInputs; i++ {
+		t.solved[i] = true
+	}
+	return t
+}
+
+// record records the wires of the instruction inst of the blueprint b.
+func (t *wireRecorder) record(b Blueprint, inst Instruction) {
+	t.inputs, t.outputs = t.inputs[:0], t.outputs[:0]
+	clear(t.seen)
+	b.UpdateInstructionTree(inst, t)
+	// the wires solved by the instruction are queried as inputs first
+	n := 0
+	for _, w := range t.inputs {
+		if t.solved[w] && !t.isOutput(w) {
+			t.inputs[n] = w
+			n++
+		}
+	}
+	t.inputs = t.inputs[:n]
+}
+
+func (t *wireRecorder) isOutput(w int) bool {
+	for _, o := range t.outputs {
+		if o == w {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *wireRecorder) InsertWire(wire uint32, level Level) {
+	t.solved[wire] = true
+	t.outputs = append(t.outputs, int(wire))
+}
+
+// HasWire records the wire as an input, except the constants: the constant
+// wire of R1CS is not a dependency.
+func (t *wireRecorder) HasWire(wire uint32) bool {
+	if wire == math.MaxUint32 || int(wire) >= len(t.solved) || (t.r1cs && wire == 0) {
+		return false
+	}
+	if !t.seen[int(wire)] {
+		t.seen[int(wire)] = true
+		t.inputs = append(t.inputs, int(wire))
+	}
+	return true
+}
+
+func (t *wireRecorder) GetWireLevel(wire uint32) Level {
+	if t.solved[wire] {
+		return 0
+	}
+	return LevelUnset
+}
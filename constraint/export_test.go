@@ -0,0 +1,85 @@
+package constraint_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/debug"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/stretchr/testify/require"
+)
+
+type exportCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *exportCircuit) Define(api frontend.API) error {
+	x, err := api.Compiler().NewHint(idHint, 1, api.Mul(c.X, c.X))
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(api.Add(x[0], c.X), c.Y)
+	api.AssertIsBoolean(api.Sub(c.X, 2))
+	return nil
+}
+
+func TestWriteJSON(t *testing.T) {
+	for _, builder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), builder, &exportCircuit{})
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, constraint.WriteJSON(&buf, ccs))
+		var res constraint.JSONSystem
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &res))
+
+		require.Equal(t, ecc.BN254.ScalarField().String(), res.Field)
+		require.Len(t, res.Wires, ccs.GetNbInternalVariables()+ccs.GetNbPublicVariables()+ccs.GetNbSecretVariables())
+		require.Len(t, res.Constraints, ccs.GetNbConstraints())
+		require.Len(t, res.Hints, 1)
+		require.Contains(t, res.Hints[0].Name, "idHint")
+		require.Len(t, res.Hints[0].Inputs, 1)
+		for _, c := range res.Constraints {
+			require.NotEmpty(t, c.String)
+			if res.Type == "r1cs" {
+				require.Equal(t, "BlueprintGenericR1C", c.Blueprint)
+				require.NotEmpty(t, c.O)
+			} else {
+				require.NotNil(t, c.XA)
+			}
+		}
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	for _, builder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), builder, &exportCircuit{})
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, constraint.WriteDOT(&buf, ccs))
+		graph := buf.String()
+		require.Contains(t, graph, "digraph")
+		require.Contains(t, graph, `label="idHint"`)
+		require.Contains(t, graph, `label="X"`)
+		require.Contains(t, graph, `label="Y"`)
+
+		// the public input Y is not a dependency of X²
+		xx := ccs.GetNbPublicVariables() + ccs.GetNbSecretVariables()
+		buf.Reset()
+		require.NoError(t, constraint.WriteDOT(&buf, ccs, constraint.WithDOTRoot(xx)))
+		require.Contains(t, buf.String(), `label="X"`)
+		require.NotContains(t, buf.String(), `label="Y"`)
+
+		// the debug information is only attached with the debug tag
+		buf.Reset()
+		require.NoError(t, constraint.WriteDOT(&buf, ccs, constraint.WithDOTRegion("exportCircuit")))
+		require.Equal(t, debug.Debug, bytes.Contains(buf.Bytes(), []byte("->")))
+	}
+}
@@ -0,0 +1,316 @@
+package constraint
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// Fork prepares the empty system sub to be built independently of cs, for
+// example concurrently, before being appended to cs with [Join]. The wires of
+// cs can be used in sub, where they are inputs, and the new wires of sub are
+// numbered after the wires of cs.
+//
+// cs must not be modified until sub is joined, except by joining other systems
+// forked from it.
+func Fork(cs, sub ConstraintSystem) error {
+	c, ok := cs.(coreSystem)
+	if !ok {
+		return fmt.Errorf("fork: unsupported constraint system %T", cs)
+	}
+	s, ok := sub.(coreSystem)
+	if !ok {
+		return fmt.Errorf("fork: unsupported constraint system %T", sub)
+	}
+	system, subSystem := c.core(), s.core()
+	if system.Type != subSystem.Type {
+		return errors.New("fork: the systems are not of the same type")
+	}
+	if len(subSystem.Instructions) != 0 || subSystem.NbInternalVariables != 0 {
+		return errors.New("fork: the forked system is not empty")
+	}
+
+	subSystem.Public = system.Public[:len(system.Public):len(system.Public)]
+	subSystem.Secret = system.Secret[:len(system.Secret):len(system.Secret)]
+	subSystem.NbInternalVariables = system.NbInternalVariables
+	subSystem.nbForkedWires = system.NbInternalVariables
+	return nil
+}
+
+// Join appends to cs the instructions of sub, which must have been forked from
+// cs with [Fork], along with their coefficients, hints, logs and debug
+// information. It returns the mapping from the wires of sub to the wires of
+// cs: the wires of cs at the time of the fork are kept and the new wires of
+// sub are shifted after the current wires of cs.
+//
+// The commitments of sub are appended to the commitments of cs, and the
+// commitment index given as first input to their hints is shifted
+// accordingly. In a R1CS, the private wires committed in sub must not be
+// committed in cs or in another system forked from it.
+//
+// sub can only contain generic constraints, hints and commitments; GKR,
+// custom gates and lookups are not supported.
+func Join(cs, sub ConstraintSystem) (func(wire int) int, error) {
+	c, ok := cs.(coreSystem)
+	if !ok {
+		return nil, fmt.Errorf("join: unsupported constraint system %T", cs)
+	}
+	s, ok := sub.(coreSystem)
+	if !ok {
+		return nil, fmt.Errorf("join: unsupported constraint system %T", sub)
+	}
+	system, subSystem := c.core(), s.core()
+	if system.Type != subSystem.Type {
+		return nil, errors.New("join: the systems are not of the same type")
+	}
+	if len(subSystem.Public) != len(system.Public) || len(subSystem.Secret) != len(system.Secret) ||
+		subSystem.nbForkedWires > system.NbInternalVariables {
+		return nil, errors.New("join: the system was not forked from this system")
+	}
+	if subSystem.GkrInfo.Is() {
+		return nil, errors.New("join: GKR is not supported in forked systems")
+	}
+	for id, name := range subSystem.MHintsDependencies {
+		if registered, ok := system.MHintsDependencies[id]; ok && registered != name {
+			return nil, fmt.Errorf("join: hint %s registered with the same id as %s", name, registered)
+		}
+	}
+
+	base := subSystem.internalWireOffset()
+	shift := uint32(system.NbInternalVariables - subSystem.nbForkedWires)
+	wire := func(w uint32) uint32 {
+		if w < base || w == math.MaxUint32 {
+			return w
+		}
+		return w + shift
+	}
+
+	// check the blueprints before modifying cs
+	bIDs := make([]BlueprintID, len(subSystem.Blueprints))
+	for i, b := range subSystem.Blueprints {
+		if !isRemappable(b) {
+			return nil, fmt.Errorf("join: unsupported blueprint %T", b)
+		}
+		bIDs[i] = system.blueprintOfType(b)
+	}
+
+	cIDs := make(map[uint32]uint32)
+	coeff := func(cID uint32) uint32 {
+		if r, ok := cIDs[cID]; ok {
+			return r
+		}
+		r := cs.AddCoeff(sub.GetCoefficient(int(cID)))
+		cIDs[cID] = r
+		return r
+	}
+
+	for i := subSystem.nbForkedWires; i < subSystem.NbInternalVariables; i++ {
+		system.AddInternalVariable()
+	}
+	for id, name := range subSystem.MHintsDependencies {
+		system.MHintsDependencies[id] = name
+	}
+
+	// the hints computing the commitments of sub take the index of the
+	// commitment as first input
+	nbCommitments := len(system.CommitmentInfo.CommitmentIndexes())
+	commitmentHints := make(map[uint32]bool)
+	for w := range subSystem.commitmentWires() {
+		commitmentHints[wire(w)] = true
+	}
+	hint := func(h *HintMapping) error {
+		if nbCommitments == 0 || !commitmentHints[h.OutputRange.Start] {
+			return nil
+		}
+		if len(h.Inputs) == 0 || len(h.Inputs[0]) != 1 {
+			return errors.New("invalid commitment hint")
+		}
+		depth, ok := cs.Uint64(cs.GetCoefficient(int(h.Inputs[0][0].CID)))
+		if !ok {
+			return errors.New("invalid commitment hint")
+		}
+		h.Inputs[0][0].CID = cs.AddCoeff(cs.FromInterface(depth + uint64(nbCommitments)))
+		return nil
+	}
+
+	constraintOffset := system.NbConstraints
+	calldata := getBuffer()
+	for _, pi := range subSystem.Instructions {
+		inst := pi.Unpack(subSystem)
+		if err := remapInstruction(subSystem.Blueprints[pi.BlueprintID], inst, wire, coeff, hint, calldata); err != nil {
+			putBuffer(calldata)
+			return nil, fmt.Errorf("join: %w", err)
+		}
+		system.AddInstruction(bIDs[pi.BlueprintID], *calldata)
+	}
+	putBuffer(calldata)
+
+	switch c := subSystem.CommitmentInfo.(type) {
+	case Groth16Commitments:
+		for _, ci := range c {
+			r := Groth16Commitment{
+				PublicAndCommitmentCommitted: make([]int, len(ci.PublicAndCommitmentCommitted)),
+				PrivateCommitted:             make([]int, len(ci.PrivateCommitted)),
+				CommitmentIndex:              int(wire(uint32(ci.CommitmentIndex))),
+				NbPublicCommitted:            ci.NbPublicCommitted,
+			}
+			for i, w := range ci.PublicAndCommitmentCommitted {
+				r.PublicAndCommitmentCommitted[i] = int(wire(uint32(w)))
+			}
+			for i, w := range ci.PrivateCommitted {
+				r.PrivateCommitted[i] = int(wire(uint32(w)))
+			}
+			if err := system.AddCommitment(r); err != nil {
+				return nil, fmt.Errorf("join: %w", err)
+			}
+		}
+	case PlonkCommitments:
+		for _, ci := range c {
+			r := PlonkCommitment{
+				Committed:       make([]int, len(ci.Committed)),
+				CommitmentIndex: ci.CommitmentIndex + constraintOffset,
+			}
+			for i, cID := range ci.Committed {
+				r.Committed[i] = cID + constraintOffset
+			}
+			if err := system.AddCommitment(r); err != nil {
+				return nil, fmt.Errorf("join: %w", err)
+			}
+		}
+	}
+
+	// logs and debug information
+	locations := system.SymbolTable.Import(&subSystem.SymbolTable)
+	entry := func(l LogEntry) LogEntry {
+		r := LogEntry{Caller: l.Caller, Format: l.Format}
+		r.ToResolve = make([]LinearExpression, len(l.ToResolve))
+		for i, le := range l.ToResolve {
+			r.ToResolve[i] = make(LinearExpression, len(le))
+			for j := range le {
+				r.ToResolve[i][j] = Term{CID: coeff(le[j].CID), VID: wire(le[j].VID)}
+			}
+		}
+		r.Stack = make([]int, len(l.Stack))
+		for i, id := range l.Stack {
+			r.Stack[i] = locations[id]
+		}
+		return r
+	}
+	for _, l := range subSystem.Logs {
+		system.Logs = append(system.Logs, entry(l))
+	}
+	debugOffset := len(system.DebugInfo)
+	for _, l := range subSystem.DebugInfo {
+		system.DebugInfo = append(system.DebugInfo, entry(l))
+	}
+	for cID, dID := range subSystem.MDebug {
+		system.MDebug[cID+constraintOffset] = dID + debugOffset
+	}
+
+	return func(w int) int { return int(wire(uint32(w))) }, nil
+}
+
+// blueprintOfType returns the id of the first blueprint of system of the same
+// type as b, adding b if there is none. It is only meant for the stateless
+// blueprints.
+func (system *System) blueprintOfType(b Blueprint) BlueprintID {
+	t := reflect.TypeOf(b)
+	for i := range system.Blueprints {
+		if reflect.TypeOf(system.Blueprints[i]) == t {
+			return BlueprintID(i)
+		}
+	}
+	return system.AddBlueprint(b)
+}
+
+// isRemappable returns true if the instructions of b can be moved to another
+// system with remapInstruction.
+func isRemappable(b Blueprint) bool {
+	switch b.(type) {
+	case *BlueprintGenericR1C, *BlueprintGenericSparseR1C, *BlueprintSparseR1CAdd, *BlueprintSparseR1CMul, *BlueprintSparseR1CBool, *BlueprintGenericHint:
+		return true
+	}
+	return false
+}
+
+func identity(x uint32) uint32 {
+	return x
+}
+
+func keepHint(*HintMapping) error {
+	return nil
+}
+
+// commitmentWires returns the output wires of the hints computing the
+// commitments of system.
+func (system *System) commitmentWires() map[uint32]bool {
+	res := make(map[uint32]bool)
+	switch c := system.CommitmentInfo.(type) {
+	case Groth16Commitments:
+		for i := range c {
+			res[uint32(c[i].CommitmentIndex)] = true
+		}
+	case PlonkCommitments:
+		if len(c) == 0 {
+			break
+		}
+		// the commitment constraints are -commitment = 0
+		var sc SparseR1C
+		for _, pi := range system.Instructions {
+			bs, ok := system.Blueprints[pi.BlueprintID].(BlueprintSparseR1C)
+			if !ok {
+				continue
+			}
+			bs.DecompressSparseR1C(&sc, pi.Unpack(system))
+			if sc.Commitment == COMMITMENT {
+				res[sc.XA] = true
+			}
+		}
+	}
+	return res
+}
+
+// remapInstruction compresses in buf the instruction inst of the blueprint b,
+// with its wires mapped by wire and its coefficient ids mapped by coeff. The
+// remapped hint mappings are then passed to hint. The
+// blueprint must be remappable, see isRemappable.
+func remapInstruction(b Blueprint, inst Instruction, wire, coeff func(uint32) uint32, hint func(*HintMapping) error, buf *[]uint32) error {
+	*buf = (*buf)[:0]
+	switch bc := b.(type) {
+	case *BlueprintGenericR1C:
+		var r1c R1C
+		bc.DecompressR1C(&r1c, inst)
+		for _, l := range []LinearExpression{r1c.L, r1c.R, r1c.O} {
+			for i := range l {
+				l[i].VID, l[i].CID = wire(l[i].VID), coeff(l[i].CID)
+			}
+		}
+		bc.CompressR1C(&r1c, buf)
+	case *BlueprintGenericSparseR1C, *BlueprintSparseR1CAdd, *BlueprintSparseR1CMul, *BlueprintSparseR1CBool:
+		bs := bc.(BlueprintSparseR1C)
+		var c SparseR1C
+		bs.DecompressSparseR1C(&c, inst)
+		c.XA, c.XB, c.XC = wire(c.XA), wire(c.XB), wire(c.XC)
+		c.QL, c.QR, c.QO = coeff(c.QL), coeff(c.QR), coeff(c.QO)
+		c.QM, c.QC = coeff(c.QM), coeff(c.QC)
+		bs.CompressSparseR1C(&c, buf)
+	case *BlueprintGenericHint:
+		var h HintMapping
+		bc.DecompressHint(&h, inst)
+		for _, l := range h.Inputs {
+			for i := range l {
+				l[i].VID, l[i].CID = wire(l[i].VID), coeff(l[i].CID)
+			}
+		}
+		start := wire(h.OutputRange.Start)
+		h.OutputRange.Start, h.OutputRange.End = start, start+h.OutputRange.End-h.OutputRange.Start
+		if err := hint(&h); err != nil {
+			return err
+		}
+		bc.CompressHint(h, buf)
+	default:
+		return fmt.Errorf("unsupported blueprint %T", b)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+// Package iden3 exports gnark R1CS and witnesses to the iden3 binary formats
+// used by circom and snarkjs.
+//
+// The .r1cs file (see [WriteR1CS]) holds the constraints, the .sym file (see
+// [WriteSym]) the names of the wires and the .wtns file (see [WriteWitness])
+// the values of all the wires, so that a gnark circuit can be analyzed or
+// proved by the tools of the circom ecosystem.
+//
+// The wires keep their gnark indexes: the wire 0 is the constant 1, followed by
+// the public inputs, the secret inputs and the internal wires, which matches
+// the circom layout with the gnark public inputs as circom public inputs and no
+// public outputs.
+//
+// Only the BN254 and BLS12-381 scalar fields, supported by snarkjs, are
+// supported. The constraint systems with commitments, or with instructions
+// which are neither R1C constraints nor hints (for example lookups or GKR), can
+// not be expressed as a plain R1CS and are rejected.
+package iden3
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+)
+
+// section types of the .r1cs file
+const (
+	sectionHeader      = 1
+	sectionConstraints = 2
+	sectionWireToLabel = 3
+)
+
+// WriteR1CS writes cs to w in the iden3 .r1cs format (version 1). Every wire is
+// its own label: the label of the wire i is i in the .sym file.
+func WriteR1CS(w io.Writer, cs constraint.R1CS) error {
+	n8, err := check(cs)
+	if err != nil {
+		return err
+	}
+	nbPublic, nbSecret, nbInternal := cs.GetNbPublicVariables(), cs.GetNbSecretVariables(), cs.GetNbInternalVariables()
+	nbWires := nbPublic + nbSecret + nbInternal
+
+	bw := bufio.NewWriter(w)
+	writeFileHeader(bw, "r1cs", 1, 3)
+
+	// header
+	writeSectionHeader(bw, sectionHeader, uint64(4+n8+4*4+8+4))
+	writeUint32(bw, uint32(n8))
+	writeElement(bw, n8, cs.Field())
+	writeUint32(bw, uint32(nbWires))
+	writeUint32(bw, 0) // public outputs
+	writeUint32(bw, uint32(nbPublic-1))
+	writeUint32(bw, uint32(nbSecret))
+	writeUint64(bw, uint64(nbWires))
+	writeUint32(bw, uint32(cs.GetNbConstraints()))
+
+	// constraints, A⋅B - C == 0
+	r1cs := cs.GetR1Cs()
+	var size uint64
+	for _, r1c := range r1cs {
+		for _, l := range []constraint.LinearExpression{r1c.L, r1c.R, r1c.O} {
+			size += 4 + uint64(len(l))*uint64(4+n8)
+		}
+	}
+	writeSectionHeader(bw, sectionConstraints, size)
+	for _, r1c := range r1cs {
+		for _, l := range []constraint.LinearExpression{r1c.L, r1c.R, r1c.O} {
+			writeUint32(bw, uint32(len(l)))
+			for _, t := range l {
+				// the constant terms are on the wire 0
+				wire := t.WireID()
+				if t.IsConstant() {
+					wire = 0
+				}
+				writeUint32(bw, uint32(wire))
+				writeElement(bw, n8, cs.ToBigInt(cs.GetCoefficient(int(t.CID))))
+			}
+		}
+	}
+
+	// wire to label map, the identity
+	writeSectionHeader(bw, sectionWireToLabel, uint64(8*nbWires))
+	for i := 0; i < nbWires; i++ {
+		writeUint64(bw, uint64(i))
+	}
+
+	return bw.Flush()
+}
+
+// WriteSym writes the names of the wires of cs to w in the circom .sym format,
+// one "label,wire,component,name" line per wire. The inputs are named after
+// the circuit schema, for example "main.X" or "main.A_0". The internal wires
+// are named "main.v<i>", prefixed by the function of the first constraint
+// using them when it has debug information (attached when compiling with the
+// debug tag), for example "main.bits.toBinary.v12".
+func WriteSym(w io.Writer, cs constraint.R1CS) error {
+	if _, err := check(cs); err != nil {
+		return err
+	}
+	nbPublic, nbSecret, nbInternal := cs.GetNbPublicVariables(), cs.GetNbSecretVariables(), cs.GetNbInternalVariables()
+	nbInputs := nbPublic + nbSecret
+
+	// the functions of the internal wires
+	functions := make([]string, nbInternal)
+	it := cs.GetR1CIterator()
+	for cID := 0; ; cID++ {
+		r1c := it.Next()
+		if r1c == nil {
+			break
+		}
+		function := debugFunction(cs.GetDebugInfoStack(cID))
+		if function == "" {
+			continue
+		}
+		for _, l := range []constraint.LinearExpression{r1c.L, r1c.R, r1c.O} {
+			for _, t := range l {
+				if i := t.WireID() - nbInputs; !t.IsConstant() && i >= 0 && functions[i] == "" {
+					functions[i] = function
+				}
+			}
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	for i := 0; i < nbInputs+nbInternal; i++ {
+		var name string
+		switch {
+		case i == 0:
+			name = "one"
+		case i < nbInputs:
+			name = sanitize(cs.VariableToString(i))
+		case functions[i-nbInputs] != "":
+			name = functions[i-nbInputs] + "." + cs.VariableToString(i)
+		default:
+			name = cs.VariableToString(i)
+		}
+		fmt.Fprintf(bw, "%d,%d,-1,main.%s\n", i, i, name)
+	}
+	return bw.Flush()
+}
+
+// debugFunction returns the innermost function of the debug stack which is not
+// in the frontend.
+func debugFunction(stack []string) string {
+	for _, entry := range stack {
+		function, file, _ := strings.Cut(entry, " ")
+		if strings.Contains(file, "frontend/cs/") || strings.HasPrefix(function, "frontend.") {
+			continue
+		}
+		// type parameters
+		if i := strings.IndexByte(function, '['); i >= 0 {
+			function = function[:i]
+		}
+		return sanitize(function)
+	}
+	return ""
+}
+
+// sanitize removes the separators of the .sym format from a name.
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ',' || r == '\n' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// check returns the size in bytes of the field elements of cs, or an error if
+// cs can not be exported.
+func check(cs constraint.ConstraintSystem) (int, error) {
+	field := cs.Field()
+	if field.Cmp(ecc.BN254.ScalarField()) != 0 && field.Cmp(ecc.BLS12_381.ScalarField()) != 0 {
+		return 0, errors.New("iden3: only the BN254 and BLS12-381 scalar fields are supported")
+	}
+	if cs.GetNbPublicVariables() == 0 {
+		return 0, errors.New("iden3: expected a R1CS, with the constant wire")
+	}
+	if len(cs.GetCommitments().CommitmentIndexes()) != 0 {
+		return 0, errors.New("iden3: the commitments are not supported")
+	}
+	it := cs.GetInstructionIterator()
+	for blueprint, _, ok := it.Next(); ok; blueprint, _, ok = it.Next() {
+		switch blueprint.(type) {
+		case constraint.BlueprintR1C, constraint.BlueprintHint:
+		default:
+			if blueprint.NbConstraints() != 0 {
+				return 0, fmt.Errorf("iden3: unsupported blueprint %T", blueprint)
+			}
+		}
+	}
+	return (field.BitLen() + 63) / 64 * 8, nil
+}
+
+func writeFileHeader(w *bufio.Writer, magic string, version, nbSections uint32) {
+	w.WriteString(magic)
+	writeUint32(w, version)
+	writeUint32(w, nbSections)
+}
+
+func writeSectionHeader(w *bufio.Writer, typ uint32, size uint64) {
+	writeUint32(w, typ)
+	writeUint64(w, size)
+}
+
+func writeUint32(w *bufio.Writer, v uint32) {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	w.Write(buf[:])
+}
+
+func writeUint64(w *bufio.Writer, v uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	w.Write(buf[:])
+}
+
+// writeElement writes the field element v, in regular form, on n8 little
+// endian bytes.
+func writeElement(w *bufio.Writer, n8 int, v *big.Int) {
+	writeLittleEndian(w, n8, v.Bytes())
+}
+
+// writeLittleEndian writes the big endian integer b on n8 little endian bytes.
+func writeLittleEndian(w *bufio.Writer, n8 int, b []byte) {
+	buf := make([]byte, n8)
+	for i := range b {
+		buf[len(b)-1-i] = b[i]
+	}
+	w.Write(buf)
+}
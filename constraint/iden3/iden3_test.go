@@ -0,0 +1,167 @@
+package iden3_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/constraint/iden3"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/stretchr/testify/require"
+)
+
+type circuit struct {
+	X [2]frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *circuit) Define(api frontend.API) error {
+	bits := api.ToBinary(c.X[0], 8)
+	api.AssertIsEqual(api.Add(api.FromBinary(bits...), api.Div(c.X[1], c.X[0]), 5), c.Y)
+	return nil
+}
+
+// reader reads the iden3 sections.
+type reader struct {
+	t *testing.T
+	r io.Reader
+}
+
+func (r reader) uint32() uint32 {
+	var v uint32
+	require.NoError(r.t, binary.Read(r.r, binary.LittleEndian, &v))
+	return v
+}
+
+func (r reader) uint64() uint64 {
+	var v uint64
+	require.NoError(r.t, binary.Read(r.r, binary.LittleEndian, &v))
+	return v
+}
+
+func (r reader) element(n8 int) *big.Int {
+	buf := make([]byte, n8)
+	_, err := io.ReadFull(r.r, buf)
+	require.NoError(r.t, err)
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	return new(big.Int).SetBytes(buf)
+}
+
+// header reads the file header and the header section, and returns the size
+// of the elements and the modulus.
+func (r reader) header(magic string, version uint32) (int, *big.Int) {
+	buf := make([]byte, 4)
+	_, err := io.ReadFull(r.r, buf)
+	require.NoError(r.t, err)
+	require.Equal(r.t, magic, string(buf))
+	require.Equal(r.t, version, r.uint32())
+	r.uint32() // nbSections
+	require.Equal(r.t, uint32(1), r.uint32())
+	r.uint64()
+	n8 := int(r.uint32())
+	return n8, r.element(n8)
+}
+
+func TestExport(t *testing.T) {
+	for _, curve := range []ecc.ID{ecc.BN254, ecc.BLS12_381} {
+		ccs, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, &circuit{})
+		require.NoError(t, err)
+		w, err := frontend.NewWitness(&circuit{X: [2]frontend.Variable{3, 12}, Y: 12}, curve.ScalarField())
+		require.NoError(t, err)
+
+		var bR1CS, bWitness, bSym bytes.Buffer
+		require.NoError(t, iden3.WriteR1CS(&bR1CS, ccs.(constraint.R1CS)))
+		require.NoError(t, iden3.WriteWitness(&bWitness, ccs.(constraint.R1CS), w))
+		require.NoError(t, iden3.WriteSym(&bSym, ccs.(constraint.R1CS)))
+
+		// the witness values
+		r := reader{t, &bWitness}
+		n8, q := r.header("wtns", 2)
+		require.Equal(t, curve.ScalarField(), q)
+		values := make([]*big.Int, r.uint32())
+		require.Equal(t, uint32(2), r.uint32())
+		require.Equal(t, uint64(len(values)*n8), r.uint64())
+		for i := range values {
+			values[i] = r.element(n8)
+		}
+		require.Equal(t, int64(1), values[0].Int64())
+		require.Equal(t, int64(12), values[1].Int64())
+		require.Equal(t, int64(3), values[2].Int64())
+
+		// the constraints are satisfied by the witness
+		r = reader{t, &bR1CS}
+		_, q = r.header("r1cs", 1)
+		require.Equal(t, curve.ScalarField(), q)
+		require.Len(t, values, int(r.uint32()))
+		require.Equal(t, uint32(0), r.uint32())
+		require.Equal(t, uint32(1), r.uint32())
+		require.Equal(t, uint32(2), r.uint32())
+		require.Equal(t, uint64(len(values)), r.uint64())
+		nbConstraints := int(r.uint32())
+		require.Equal(t, ccs.GetNbConstraints(), nbConstraints)
+		require.Equal(t, uint32(2), r.uint32())
+		r.uint64()
+		for i := 0; i < nbConstraints; i++ {
+			var abc [3]big.Int
+			for j := range abc {
+				for k := r.uint32(); k > 0; k-- {
+					wire := r.uint32()
+					abc[j].Add(&abc[j], new(big.Int).Mul(r.element(n8), values[wire]))
+				}
+				abc[j].Mod(&abc[j], q)
+			}
+			abc[0].Mul(&abc[0], &abc[1]).Mod(&abc[0], q)
+			require.Equal(t, 0, abc[0].Cmp(&abc[2]), "constraint %d", i)
+		}
+		require.Equal(t, uint32(3), r.uint32())
+		require.Equal(t, uint64(8*len(values)), r.uint64())
+		for i := range values {
+			require.Equal(t, uint64(i), r.uint64())
+		}
+
+		// the names of the wires
+		scanner := bufio.NewScanner(&bSym)
+		var lines []string
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		require.Len(t, lines, len(values))
+		require.Equal(t, "0,0,-1,main.one", lines[0])
+		require.Equal(t, "1,1,-1,main.Y", lines[1])
+		require.Equal(t, "2,2,-1,main.X_0", lines[2])
+		// prefixed by the function with the debug tag
+		require.True(t, strings.HasPrefix(lines[4], "4,4,-1,main.") && strings.HasSuffix(lines[4], ".v0"))
+	}
+}
+
+func TestExportUnsupported(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BLS12_377.ScalarField(), r1cs.NewBuilder, &circuit{})
+	require.NoError(t, err)
+	require.Error(t, iden3.WriteR1CS(io.Discard, ccs.(constraint.R1CS)))
+
+	ccs, err = frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &commitmentCircuit{})
+	require.NoError(t, err)
+	require.ErrorContains(t, iden3.WriteR1CS(io.Discard, ccs.(constraint.R1CS)), "commitments")
+}
+
+type commitmentCircuit struct {
+	X frontend.Variable
+}
+
+func (c *commitmentCircuit) Define(api frontend.API) error {
+	commitment, err := api.(frontend.Committer).Commit(c.X)
+	if err != nil {
+		return err
+	}
+	api.AssertIsDifferent(commitment, c.X)
+	return nil
+}
@@ -0,0 +1,63 @@
+package iden3
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	cs_bls12381 "github.com/consensys/gnark/constraint/bls12-381"
+	cs_bn254 "github.com/consensys/gnark/constraint/bn254"
+	"github.com/consensys/gnark/constraint/solver"
+)
+
+// section types of the .wtns file
+const (
+	sectionWitnessHeader = 1
+	sectionWitnessValues = 2
+)
+
+// WriteWitness solves cs with the full witness fullWitness and writes the
+// values of all the wires, inputs and internal wires, to w in the iden3 .wtns
+// format (version 2), matching the wires of [WriteR1CS].
+func WriteWitness(w io.Writer, cs constraint.R1CS, fullWitness witness.Witness, opts ...solver.Option) error {
+	n8, err := check(cs)
+	if err != nil {
+		return err
+	}
+	solution, err := cs.Solve(fullWitness, opts...)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	writeValues := func(n int, value func(i int) []byte) {
+		writeFileHeader(bw, "wtns", 2, 2)
+		writeSectionHeader(bw, sectionWitnessHeader, uint64(4+n8+4))
+		writeUint32(bw, uint32(n8))
+		writeElement(bw, n8, cs.Field())
+		writeUint32(bw, uint32(n))
+		writeSectionHeader(bw, sectionWitnessValues, uint64(n*n8))
+		for i := 0; i < n; i++ {
+			writeLittleEndian(bw, n8, value(i))
+		}
+	}
+
+	// the regular form of the values, in big endian
+	switch s := solution.(type) {
+	case *cs_bn254.R1CSSolution:
+		writeValues(len(s.W), func(i int) []byte {
+			b := s.W[i].Bytes()
+			return b[:]
+		})
+	case *cs_bls12381.R1CSSolution:
+		writeValues(len(s.W), func(i int) []byte {
+			b := s.W[i].Bytes()
+			return b[:]
+		})
+	default:
+		return fmt.Errorf("iden3: unsupported solution %T", solution)
+	}
+	return bw.Flush()
+}
@@ -58,7 +58,8 @@ func (system *System) InsertWire(wireID uint32, level Level) {
 	system.lbWireLevel[wireID] = level
 }
 
-// internalWireOffset returns the position of the first internal wire in the wireIDs.
+// internalWireOffset returns the position of the first internal wire in the wireIDs,
+// the wires of the system it was forked from being inputs.
 func (system *System) internalWireOffset() uint32 {
-	return uint32(system.GetNbPublicVariables() + system.GetNbSecretVariables())
+	return uint32(system.GetNbPublicVariables() + system.GetNbSecretVariables() + system.nbForkedWires)
 }
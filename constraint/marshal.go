@@ -358,6 +358,10 @@ func getTagSet() cbor.TagSet {
 	addType(reflect.TypeOf(BlueprintLookupHint{}))
 	addType(reflect.TypeOf(Groth16Commitments{}))
 	addType(reflect.TypeOf(PlonkCommitments{}))
+	addType(reflect.TypeOf(BlueprintCustomGate{}))
+	addType(reflect.TypeOf(BlueprintLookup{}))
+	addType(reflect.TypeOf(BlueprintComponent{}))
+	addType(reflect.TypeOf(BlueprintCustomGateNextRow{}))
 
 	return ts
 }
@@ -33,24 +33,22 @@ type R1CS interface {
 // R1CIterator facilitates iterating through R1C constraints.
 type R1CIterator struct {
 	R1C
-	cs *System
-	n  int
+	instructionIterator
 }
 
 // Next returns the next R1C or nil if end. Caller must not store the result since the
 // same memory space is re-used for subsequent calls to Next.
 func (it *R1CIterator) Next() *R1C {
-	if it.n >= it.cs.GetNbInstructions() {
-		return nil
+	for {
+		blueprint, inst, ok := it.next()
+		if !ok {
+			return nil
+		}
+		if bc, ok := blueprint.(BlueprintR1C); ok {
+			bc.DecompressR1C(&it.R1C, inst)
+			return &it.R1C
+		}
 	}
-	inst := it.cs.Instructions[it.n]
-	it.n++
-	blueprint := it.cs.Blueprints[inst.BlueprintID]
-	if bc, ok := blueprint.(BlueprintR1C); ok {
-		bc.DecompressR1C(&it.R1C, inst.Unpack(it.cs))
-		return &it.R1C
-	}
-	return it.Next()
 }
 
 // // IsValid perform post compilation checks on the Variables
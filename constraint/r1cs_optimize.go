@@ -0,0 +1,660 @@
+package constraint
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/consensys/gnark/constraint/solver"
+)
+
+// R1CSOptimizationStats reports the effect of [OptimizeR1CS] on a constraint
+// system.
+type R1CSOptimizationStats struct {
+	NbConstraintsBefore, NbConstraintsAfter             int
+	NbInternalVariablesBefore, NbInternalVariablesAfter int
+
+	// NbSubstituted is the number of linear constraints removed by
+	// substituting the wire they define in the rest of the system.
+	NbSubstituted int
+	// NbRedundant is the number of constraints removed because they are
+	// trivially satisfied or duplicate another constraint.
+	NbRedundant int
+	// NbDead is the number of constraints removed because they only define
+	// an internal wire which is not used anywhere else.
+	NbDead int
+}
+
+// ErrUnsupportedBlueprint is returned by [OptimizeR1CS] when the system
+// contains an instruction it does not know how to rewrite.
+var ErrUnsupportedBlueprint = errors.New("R1CS optimizer: unsupported blueprint")
+
+// coreSystem is implemented by the curve typed constraint systems embedding a
+// [System].
+type coreSystem interface {
+	core() *System
+}
+
+func (system *System) core() *System {
+	return system
+}
+
+// OptimizeR1CS rewrites the constraint system in place to remove redundant
+// constraints and wires while keeping the same set of valid witnesses:
+//
+//   - a constraint which is linear (L or R is constant) and defines an internal
+//     wire is removed, and the wire is substituted by the corresponding linear
+//     expression in the rest of the system, if it has at most 32 terms;
+//   - constraints which are trivially satisfied or duplicate an earlier
+//     constraint are removed;
+//   - constraints L⋅R == O which only define an internal wire in O which is not
+//     used anywhere else are removed;
+//   - the remaining internal wires are renumbered, preserving their order.
+//
+// The debug info, logs, hint mappings, lookup hints and commitment info are
+// updated accordingly. Committed wires and the outputs of hints are never
+// removed. The GKR sub-circuits are solved and proven by hints, whose wires are
+// renumbered as any other hint, and the GKR info only references these hints
+// by ID, so it is kept as is.
+//
+// It returns [ErrUnsupportedBlueprint] (and leaves the system untouched) if the
+// system contains instructions from blueprints other than the R1C, hint and
+// lookup hint blueprints.
+func OptimizeR1CS(cs R1CS) (R1CSOptimizationStats, error) {
+	c, ok := cs.(coreSystem)
+	if !ok {
+		return R1CSOptimizationStats{}, fmt.Errorf("R1CS optimizer: unsupported constraint system %T", cs)
+	}
+	o := newR1CSOptimizer(cs, c.core())
+	if err := o.check(); err != nil {
+		return R1CSOptimizationStats{}, err
+	}
+	o.stats.NbConstraintsBefore = o.sys.NbConstraints
+	o.stats.NbInternalVariablesBefore = o.sys.NbInternalVariables
+
+	o.eliminate()
+	o.substituteLate()
+	o.removeDead()
+	o.renumber()
+	o.rebuild()
+
+	o.stats.NbConstraintsAfter = o.sys.NbConstraints
+	o.stats.NbInternalVariablesAfter = o.sys.NbInternalVariables
+	return o.stats, nil
+}
+
+// maxSubstitutionLength bounds the length of the linear expressions
+// substituted to wires. Longer expressions are kept behind a constraint, as
+// their substitution would grow the linear expressions of the system
+// quadratically (which the compress threshold of the frontend avoids).
+const maxSubstitutionLength = 32
+
+// optTerm is a term with the coefficient value instead of its id.
+type optTerm struct {
+	vid uint32
+	c   Element
+}
+
+// optExpr is a linear expression sorted by wire id, without duplicate wires
+// or zero coefficients.
+type optExpr []optTerm
+
+const (
+	optR1C = iota
+	optHint
+	optLookupHint
+)
+
+// optInstruction is a decoded instruction of the system.
+type optInstruction struct {
+	pi   PackedInstruction
+	kind int
+
+	// R1C
+	l, r, o  optExpr
+	solves   uint32 // wire defined by the constraint in O, if any
+	hasSolve bool
+
+	// hint or lookup hint
+	hintID    solver.HintID
+	inputs    []optExpr
+	nbEntries uint32
+	outStart  uint32
+	outEnd    uint32
+
+	removed bool
+}
+
+type r1csOptimizer struct {
+	cs  R1CS
+	sys *System
+
+	nbInputs uint32
+	nbWires  int
+	one      Element
+
+	instructions []optInstruction
+	subst        []optExpr // subst[w] != nil if the wire w is substituted
+	solved       []bool
+	protected    []bool
+	output       []bool // outputs of hints, never removed
+	refs         []int
+	newID        []uint32
+
+	entries map[*BlueprintLookupHint][]optExpr
+	logs    [][]optExpr
+	debug   [][]optExpr
+
+	stats R1CSOptimizationStats
+}
+
+func newR1CSOptimizer(cs R1CS, sys *System) *r1csOptimizer {
+	nbInputs := sys.GetNbPublicVariables() + sys.GetNbSecretVariables()
+	nbWires := nbInputs + sys.NbInternalVariables
+	o := &r1csOptimizer{
+		cs:        cs,
+		sys:       sys,
+		nbInputs:  uint32(nbInputs),
+		nbWires:   nbWires,
+		one:       cs.One(),
+		subst:     make([]optExpr, nbWires),
+		solved:    make([]bool, nbWires),
+		protected: make([]bool, nbWires),
+		output:    make([]bool, nbWires),
+		refs:      make([]int, nbWires),
+		entries:   make(map[*BlueprintLookupHint][]optExpr),
+	}
+	for i := 0; i < nbInputs; i++ {
+		o.solved[i] = true
+	}
+	if commitments, ok := sys.CommitmentInfo.(Groth16Commitments); ok {
+		for _, c := range commitments {
+			for _, w := range c.PublicAndCommitmentCommitted {
+				o.protected[w] = true
+			}
+			for _, w := range c.PrivateCommitted {
+				o.protected[w] = true
+			}
+			o.protected[c.CommitmentIndex] = true
+		}
+	}
+	return o
+}
+
+// check returns an error if the system can not be optimized.
+func (o *r1csOptimizer) check() error {
+	if o.sys.Type != SystemR1CS {
+		return errors.New("R1CS optimizer: not a R1CS")
+	}
+	for _, pi := range o.sys.Instructions {
+		switch b := o.sys.Blueprints[pi.BlueprintID]; b.(type) {
+		case BlueprintR1C, BlueprintHint, *BlueprintLookupHint:
+		default:
+			return fmt.Errorf("%w %T", ErrUnsupportedBlueprint, b)
+		}
+	}
+	return nil
+}
+
+// eliminate decodes the instructions in order, substitutes the wires defined
+// by linear constraints and removes the redundant constraints.
+func (o *r1csOptimizer) eliminate() {
+	o.instructions = make([]optInstruction, 0, len(o.sys.Instructions))
+	seen := make(map[string]struct{})
+	var (
+		r1c R1C
+		hm  HintMapping
+		key []byte
+	)
+	for _, pi := range o.sys.Instructions {
+		inst := pi.Unpack(o.sys)
+		oi := optInstruction{pi: pi}
+		switch b := o.sys.Blueprints[pi.BlueprintID].(type) {
+		case BlueprintR1C:
+			oi.kind = optR1C
+			b.DecompressR1C(&r1c, inst)
+			oi.l, oi.r, oi.o = o.expr(r1c.L), o.expr(r1c.R), o.expr(r1c.O)
+			if !o.r1c(&oi) {
+				o.stats.NbSubstituted++
+				continue
+			}
+			if oi.hasSolve {
+				break
+			}
+			// the constraint is a check, remove it if it is trivial or duplicated
+			if len(oi.o) == 0 && (len(oi.l) == 0 || len(oi.r) == 0) {
+				o.stats.NbRedundant++
+				continue
+			}
+			key = oi.key(key[:0])
+			if _, ok := seen[string(key)]; ok {
+				o.stats.NbRedundant++
+				continue
+			}
+			seen[string(key)] = struct{}{}
+		case BlueprintHint:
+			oi.kind = optHint
+			b.DecompressHint(&hm, inst)
+			oi.hintID = hm.HintID
+			oi.inputs = make([]optExpr, len(hm.Inputs))
+			for i := range hm.Inputs {
+				oi.inputs[i] = o.expr(hm.Inputs[i])
+			}
+			oi.outStart, oi.outEnd = hm.OutputRange.Start, hm.OutputRange.End
+			o.setOutputs(oi.outStart, oi.outEnd)
+		case *BlueprintLookupHint:
+			oi.kind = optLookupHint
+			oi.nbEntries = inst.Calldata[1]
+			oi.inputs = o.exprs(inst.Calldata[3:], int(inst.Calldata[2]))
+			oi.outStart = pi.WireOffset
+			oi.outEnd = pi.WireOffset + inst.Calldata[2]
+			o.setOutputs(oi.outStart, oi.outEnd)
+		}
+		o.instructions = append(o.instructions, oi)
+	}
+}
+
+// r1c processes a constraint whose linear expressions are already substituted.
+// It returns false if the constraint defines a wire which is substituted.
+func (o *r1csOptimizer) r1c(oi *optInstruction) bool {
+	var e optExpr
+	if c, ok := o.constant(oi.l); ok {
+		e = o.add(o.scale(oi.r, c), o.scale(oi.o, o.cs.Neg(o.one)))
+	} else if c, ok := o.constant(oi.r); ok {
+		e = o.add(o.scale(oi.l, c), o.scale(oi.o, o.cs.Neg(o.one)))
+	} else {
+		// the solver solves at most one wire per constraint
+		for _, side := range [...]optExpr{oi.l, oi.r, oi.o} {
+			for _, t := range side {
+				if !o.isSolved(t.vid) {
+					o.solved[t.vid] = true
+					oi.solves, oi.hasSolve = t.vid, true
+				}
+			}
+		}
+		return true
+	}
+
+	unsolved, nbUnsolved := 0, 0
+	for i, t := range e {
+		if !o.isSolved(t.vid) {
+			unsolved = i
+			nbUnsolved++
+		}
+	}
+	if nbUnsolved == 1 && !o.protected[e[unsolved].vid] && len(e) <= maxSubstitutionLength+1 {
+		// w = -(e - c⋅w) / c
+		w := e[unsolved]
+		inv, _ := o.cs.Inverse(w.c)
+		rest := append(append(optExpr{}, e[:unsolved]...), e[unsolved+1:]...)
+		o.subst[w.vid] = o.scale(rest, o.cs.Neg(inv))
+		o.solved[w.vid] = true
+		return false
+	}
+	for _, t := range e {
+		if !o.isSolved(t.vid) {
+			o.solved[t.vid] = true
+			oi.solves, oi.hasSolve = t.vid, true
+		}
+	}
+	// normalize the linear constraint as e ⋅ 1 == 0
+	if len(e) != 0 && !oi.hasSolve {
+		inv, _ := o.cs.Inverse(e[0].c)
+		e = o.scale(e, inv)
+	}
+	oi.l, oi.r, oi.o = e, optExpr{{vid: 0, c: o.one}}, nil
+	return true
+}
+
+// substituteLate substitutes the wires in the expressions which may reference
+// a wire before the constraint defining it: the lookup table entries, the logs
+// and the debug info.
+func (o *r1csOptimizer) substituteLate() {
+	for _, b := range o.sys.Blueprints {
+		if b, ok := b.(*BlueprintLookupHint); ok {
+			var entries []optExpr
+			for j := 0; j < len(b.EntriesCalldata); {
+				e, n := o.readExpr(b.EntriesCalldata[j:])
+				entries = append(entries, e)
+				j += n
+			}
+			o.entries[b] = entries
+		}
+	}
+	o.logs = o.logEntries(o.sys.Logs)
+	o.debug = o.logEntries(o.sys.DebugInfo)
+}
+
+func (o *r1csOptimizer) logEntries(entries []LogEntry) [][]optExpr {
+	res := make([][]optExpr, len(entries))
+	for i := range entries {
+		res[i] = make([]optExpr, len(entries[i].ToResolve))
+		for j, l := range entries[i].ToResolve {
+			res[i][j] = o.expr(l)
+		}
+	}
+	return res
+}
+
+// removeDead removes the constraints defining only an unused internal wire.
+func (o *r1csOptimizer) removeDead() {
+	ref := func(e optExpr, delta int) {
+		for _, t := range e {
+			if t.vid != math.MaxUint32 {
+				o.refs[t.vid] += delta
+			}
+		}
+	}
+	refInstruction := func(oi *optInstruction, delta int) {
+		ref(oi.l, delta)
+		ref(oi.r, delta)
+		ref(oi.o, delta)
+		for _, e := range oi.inputs {
+			ref(e, delta)
+		}
+	}
+	for i := range o.instructions {
+		refInstruction(&o.instructions[i], 1)
+	}
+	for _, entries := range o.entries {
+		for _, e := range entries {
+			ref(e, 1)
+		}
+	}
+	for _, entries := range [...][][]optExpr{o.logs, o.debug} {
+		for i := range entries {
+			for _, e := range entries[i] {
+				ref(e, 1)
+			}
+		}
+	}
+
+	definer := make(map[uint32]int)
+	var queue []int
+	for i := range o.instructions {
+		if oi := &o.instructions[i]; oi.hasSolve {
+			definer[oi.solves] = i
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) != 0 {
+		i := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		oi := &o.instructions[i]
+		if oi.removed || !o.isDead(oi) {
+			continue
+		}
+		oi.removed = true
+		o.stats.NbDead++
+		refInstruction(oi, -1)
+		for _, side := range [...]optExpr{oi.l, oi.r, oi.o} {
+			for _, t := range side {
+				if j, ok := definer[t.vid]; ok && o.refs[t.vid] == 1 {
+					queue = append(queue, j)
+				}
+			}
+		}
+	}
+}
+
+// isDead returns true if the constraint L⋅R == O can always be satisfied by
+// setting the wire it defines, and that wire is not used anywhere else.
+func (o *r1csOptimizer) isDead(oi *optInstruction) bool {
+	w := oi.solves
+	if w < o.nbInputs || o.protected[w] || o.refs[w] != 1 {
+		return false
+	}
+	for _, t := range oi.o {
+		if t.vid == w {
+			return true
+		}
+	}
+	return false
+}
+
+// renumber computes the new ids of the wires which are still referenced.
+func (o *r1csOptimizer) renumber() {
+	o.newID = make([]uint32, o.nbWires)
+	next := o.nbInputs
+	for w := 0; w < o.nbWires; w++ {
+		switch {
+		case uint32(w) < o.nbInputs:
+			o.newID[w] = uint32(w)
+		case o.refs[w] > 0 || o.output[w] || o.protected[w]:
+			o.newID[w] = next
+			next++
+		default:
+			o.newID[w] = math.MaxUint32
+		}
+	}
+}
+
+// rebuild replaces the instructions of the system with the optimized ones.
+func (o *r1csOptimizer) rebuild() {
+	sys := o.sys
+	mDebug := sys.MDebug
+
+	sys.Instructions = make([]PackedInstruction, 0, len(o.instructions))
+	sys.CallData = make([]uint32, 0, len(sys.CallData))
+	sys.Levels = make([][]uint32, 0, len(sys.Levels))
+	sys.lbWireLevel = make([]Level, 0, len(sys.lbWireLevel))
+	sys.NbConstraints = 0
+	sys.NbInternalVariables = 0
+	sys.MDebug = make(map[int]int, len(mDebug))
+
+	for b, entries := range o.entries {
+		b.EntriesCalldata = b.EntriesCalldata[:0]
+		for _, e := range entries {
+			o.le(e).Compress(&b.EntriesCalldata)
+		}
+		b.maxLevel, b.maxLevelPosition, b.maxLevelOffset = 0, 0, 0
+	}
+
+	// allocate the kept internal wires in their original order
+	cursor := o.nbInputs
+	allocate := func(upTo uint32) {
+		for ; cursor < upTo; cursor++ {
+			if o.newID[cursor] != math.MaxUint32 {
+				sys.AddInternalVariable()
+			}
+		}
+	}
+
+	calldata := getBuffer()
+	for i := range o.instructions {
+		oi := &o.instructions[i]
+		if oi.removed {
+			continue
+		}
+		allocate(oi.pi.WireOffset)
+		*calldata = (*calldata)[:0]
+		blueprint := sys.Blueprints[oi.pi.BlueprintID]
+		switch oi.kind {
+		case optR1C:
+			r1c := R1C{L: o.le(oi.l), R: o.le(oi.r), O: o.le(oi.o)}
+			blueprint.(BlueprintR1C).CompressR1C(&r1c, calldata)
+			sys.AddInstruction(oi.pi.BlueprintID, *calldata)
+			if dID, ok := mDebug[int(oi.pi.ConstraintOffset)]; ok {
+				sys.MDebug[sys.NbConstraints-1] = dID
+			}
+		case optHint:
+			hm := HintMapping{HintID: oi.hintID, Inputs: make([]LinearExpression, len(oi.inputs))}
+			for j := range oi.inputs {
+				hm.Inputs[j] = o.le(oi.inputs[j])
+			}
+			hm.OutputRange.Start = o.newID[oi.outStart]
+			hm.OutputRange.End = o.newID[oi.outEnd-1] + 1
+			blueprint.(BlueprintHint).CompressHint(hm, calldata)
+			sys.AddInstruction(oi.pi.BlueprintID, *calldata)
+		case optLookupHint:
+			*calldata = append(*calldata, 0, oi.nbEntries, uint32(len(oi.inputs)))
+			for _, e := range oi.inputs {
+				o.le(e).Compress(calldata)
+			}
+			(*calldata)[0] = uint32(len(*calldata))
+			// the outputs are allocated by the instruction
+			sys.AddInstruction(oi.pi.BlueprintID, *calldata)
+			cursor = oi.outEnd
+		}
+	}
+	putBuffer(calldata)
+	allocate(uint32(o.nbWires))
+
+	for i := range sys.Logs {
+		sys.Logs[i].ToResolve = o.les(o.logs[i])
+	}
+	for i := range sys.DebugInfo {
+		sys.DebugInfo[i].ToResolve = o.les(o.debug[i])
+	}
+	if commitments, ok := sys.CommitmentInfo.(Groth16Commitments); ok {
+		remap := func(wires []int) []int {
+			res := make([]int, len(wires))
+			for i, w := range wires {
+				res[i] = int(o.newID[w])
+			}
+			return res
+		}
+		for i := range commitments {
+			commitments[i].PublicAndCommitmentCommitted = remap(commitments[i].PublicAndCommitmentCommitted)
+			commitments[i].PrivateCommitted = remap(commitments[i].PrivateCommitted)
+			commitments[i].CommitmentIndex = int(o.newID[commitments[i].CommitmentIndex])
+		}
+	}
+}
+
+func (o *r1csOptimizer) setOutputs(start, end uint32) {
+	for w := start; w < end; w++ {
+		o.solved[w] = true
+		o.output[w] = true
+	}
+}
+
+func (o *r1csOptimizer) isSolved(vid uint32) bool {
+	return vid == math.MaxUint32 || o.solved[vid]
+}
+
+// constant returns the value of e if it doesn't depend on a wire other than
+// the ONE wire.
+func (o *r1csOptimizer) constant(e optExpr) (Element, bool) {
+	var res Element
+	for _, t := range e {
+		if t.vid != 0 && t.vid != math.MaxUint32 {
+			return res, false
+		}
+		res = o.cs.Add(res, t.c)
+	}
+	return res, true
+}
+
+// expr returns the substituted expression corresponding to l.
+func (o *r1csOptimizer) expr(l LinearExpression) optExpr {
+	res := make(optExpr, 0, len(l))
+	for _, t := range l {
+		if t.CID == CoeffIdZero {
+			continue
+		}
+		c := o.cs.GetCoefficient(int(t.CID))
+		if t.VID != math.MaxUint32 && o.subst[t.VID] != nil {
+			for _, s := range o.subst[t.VID] {
+				res = append(res, optTerm{vid: s.vid, c: o.cs.Mul(c, s.c)})
+			}
+			continue
+		}
+		res = append(res, optTerm{vid: t.VID, c: c})
+	}
+	return o.reduce(res)
+}
+
+// exprs reads n linear expressions from calldata.
+func (o *r1csOptimizer) exprs(calldata []uint32, n int) []optExpr {
+	res := make([]optExpr, n)
+	for i, j := 0, 0; i < n; i++ {
+		e, delta := o.readExpr(calldata[j:])
+		res[i] = e
+		j += delta
+	}
+	return res
+}
+
+// readExpr reads a linear expression compressed in calldata and returns the
+// number of words read.
+func (o *r1csOptimizer) readExpr(calldata []uint32) (optExpr, int) {
+	n := int(calldata[0])
+	l := make(LinearExpression, n)
+	for k := 0; k < n; k++ {
+		l[k] = Term{CID: calldata[1+2*k], VID: calldata[2+2*k]}
+	}
+	return o.expr(l), 1 + 2*n
+}
+
+// reduce sorts e by wire id, merges the terms with the same wire and removes
+// the zero terms.
+func (o *r1csOptimizer) reduce(e optExpr) optExpr {
+	slices.SortFunc(e, func(a, b optTerm) int { return cmp.Compare(a.vid, b.vid) })
+	res := e[:0]
+	for _, t := range e {
+		if n := len(res); n != 0 && res[n-1].vid == t.vid {
+			res[n-1].c = o.cs.Add(res[n-1].c, t.c)
+			continue
+		}
+		res = append(res, t)
+	}
+	j := 0
+	for _, t := range res {
+		if !t.c.IsZero() {
+			res[j] = t
+			j++
+		}
+	}
+	return res[:j]
+}
+
+func (o *r1csOptimizer) scale(e optExpr, c Element) optExpr {
+	res := make(optExpr, len(e))
+	for i, t := range e {
+		res[i] = optTerm{vid: t.vid, c: o.cs.Mul(t.c, c)}
+	}
+	return o.reduce(res)
+}
+
+func (o *r1csOptimizer) add(a, b optExpr) optExpr {
+	return o.reduce(append(append(optExpr{}, a...), b...))
+}
+
+// le returns the linear expression of e with the renumbered wires.
+func (o *r1csOptimizer) le(e optExpr) LinearExpression {
+	res := make(LinearExpression, len(e))
+	for i, t := range e {
+		res[i].CID = o.cs.AddCoeff(t.c)
+		res[i].VID = t.vid
+		if t.vid != math.MaxUint32 {
+			res[i].VID = o.newID[t.vid]
+		}
+	}
+	return res
+}
+
+func (o *r1csOptimizer) les(e []optExpr) []LinearExpression {
+	res := make([]LinearExpression, len(e))
+	for i := range e {
+		res[i] = o.le(e[i])
+	}
+	return res
+}
+
+// key appends a binary representation of the constraint to buf.
+func (oi *optInstruction) key(buf []byte) []byte {
+	for _, side := range [...]optExpr{oi.l, oi.r, oi.o} {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(side)))
+		for _, t := range side {
+			buf = binary.LittleEndian.AppendUint32(buf, t.vid)
+			for _, w := range t.c {
+				buf = binary.LittleEndian.AppendUint64(buf, w)
+			}
+		}
+	}
+	return buf
+}
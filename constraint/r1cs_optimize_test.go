@@ -0,0 +1,249 @@
+package constraint_test
+
+import (
+	"hash"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	mimc_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+	cs_bn254 "github.com/consensys/gnark/constraint/bn254"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/std/gkr"
+	stdhash "github.com/consensys/gnark/std/hash"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/lookup/logderivlookup"
+)
+
+type optimizeLinearCircuit struct {
+	X [8]frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *optimizeLinearCircuit) Define(api frontend.API) error {
+	// with a small compress threshold, the sum is split in linear constraints
+	s := c.X[0]
+	for i := 1; i < len(c.X); i++ {
+		s = api.Add(s, api.Mul(c.X[i], i+1))
+	}
+	p := api.Mul(s, c.X[1])
+	api.AssertIsEqual(p, c.Y)
+	api.AssertIsEqual(p, c.Y)
+	api.AssertIsEqual(api.Sub(p, c.Y), 0)
+
+	// unused
+	api.Mul(c.X[2], c.X[3])
+
+	// hints
+	bits := api.ToBinary(s, api.Compiler().FieldBitLen())
+	api.AssertIsEqual(api.FromBinary(bits...), s)
+	api.Println("s", s)
+	return nil
+}
+
+type optimizeCommitCircuit struct {
+	X [4]frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *optimizeCommitCircuit) Define(api frontend.API) error {
+	s := api.Add(c.X[0], c.X[1], c.X[2], c.X[3])
+	t := api.Add(s, c.X[0], c.X[1])
+	cmt, err := api.(frontend.Committer).Commit(s, c.Y)
+	if err != nil {
+		return err
+	}
+	api.AssertIsDifferent(cmt, 0)
+	api.AssertIsEqual(api.Mul(t, cmt), api.Mul(api.Add(s, c.X[0], c.X[1]), cmt))
+	api.AssertIsEqual(api.Mul(t, t), c.Y)
+	return nil
+}
+
+type optimizeLookupCircuit struct {
+	Entries [6]frontend.Variable
+	Queries [3]frontend.Variable
+	Results [3]frontend.Variable
+}
+
+func (c *optimizeLookupCircuit) Define(api frontend.API) error {
+	t := logderivlookup.New(api)
+	for i := range c.Entries {
+		t.Insert(api.Add(c.Entries[i], c.Entries[0], c.Entries[1], c.Entries[2]))
+	}
+	res := t.Lookup(c.Queries[:]...)
+	for i := range res {
+		api.AssertIsEqual(res[i], c.Results[i])
+	}
+	return nil
+}
+
+func TestOptimizeR1CS(t *testing.T) {
+	x := [8]frontend.Variable{1, 2, 3, 4, 5, 6, 7, 8}
+	s := 1 + 2*2 + 3*3 + 4*4 + 5*5 + 6*6 + 7*7 + 8*8
+	entries := [6]frontend.Variable{1, 2, 3, 4, 5, 6}
+	for _, tc := range []struct {
+		name             string
+		circuit          frontend.Circuit
+		valid, invalid   frontend.Circuit
+		compressTreshold int
+	}{
+		{
+			name:             "linear",
+			circuit:          &optimizeLinearCircuit{},
+			valid:            &optimizeLinearCircuit{X: x, Y: s * 2},
+			invalid:          &optimizeLinearCircuit{X: x, Y: s*2 + 1},
+			compressTreshold: 3,
+		},
+		{
+			name:             "commit",
+			circuit:          &optimizeCommitCircuit{},
+			valid:            &optimizeCommitCircuit{X: [4]frontend.Variable{1, 2, 3, 4}, Y: 13 * 13},
+			invalid:          &optimizeCommitCircuit{X: [4]frontend.Variable{1, 2, 3, 4}, Y: 13*13 + 1},
+			compressTreshold: 3,
+		},
+		{
+			name:             "lookup",
+			circuit:          &optimizeLookupCircuit{},
+			valid:            &optimizeLookupCircuit{Entries: entries, Queries: [3]frontend.Variable{0, 5, 3}, Results: [3]frontend.Variable{7, 12, 10}},
+			invalid:          &optimizeLookupCircuit{Entries: entries, Queries: [3]frontend.Variable{0, 5, 3}, Results: [3]frontend.Variable{7, 12, 11}},
+			compressTreshold: 3,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			field := ecc.BN254.ScalarField()
+			ccs, err := frontend.Compile(field, r1cs.NewBuilder, tc.circuit, frontend.WithCompressThreshold(tc.compressTreshold))
+			if err != nil {
+				t.Fatal(err)
+			}
+			optimized, err := frontend.Compile(field, r1cs.NewBuilder, tc.circuit, frontend.WithCompressThreshold(tc.compressTreshold), frontend.WithR1CSOptimization())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if optimized.GetNbConstraints() >= ccs.GetNbConstraints() {
+				t.Fatalf("optimized system has %d constraints, expected less than %d", optimized.GetNbConstraints(), ccs.GetNbConstraints())
+			}
+			if optimized.GetNbInternalVariables() >= ccs.GetNbInternalVariables() {
+				t.Fatalf("optimized system has %d internal variables, expected less than %d", optimized.GetNbInternalVariables(), ccs.GetNbInternalVariables())
+			}
+
+			valid, err := frontend.NewWitness(tc.valid, field)
+			if err != nil {
+				t.Fatal(err)
+			}
+			invalid, err := frontend.NewWitness(tc.invalid, field)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := optimized.Solve(valid); err != nil {
+				t.Fatalf("valid witness: %v", err)
+			}
+			if _, err := optimized.Solve(invalid); err == nil {
+				t.Fatal("invalid witness solved")
+			}
+
+			pk, vk, err := groth16.Setup(optimized)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proof, err := groth16.Prove(optimized, pk, valid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			public, err := valid.Public()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := groth16.Verify(proof, vk, public); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestOptimizeR1CSStats(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &optimizeLinearCircuit{}, frontend.WithCompressThreshold(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := ccs.GetNbConstraints()
+	stats, err := constraint.OptimizeR1CS(ccs.(constraint.R1CS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.NbConstraintsBefore != before || stats.NbConstraintsAfter != ccs.GetNbConstraints() {
+		t.Fatalf("unexpected constraint counts %+v", stats)
+	}
+	if stats.NbSubstituted == 0 || stats.NbRedundant < 2 || stats.NbDead != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	removed := stats.NbSubstituted + stats.NbRedundant + stats.NbDead
+	if removed != before-stats.NbConstraintsAfter {
+		t.Fatalf("removed %d constraints, stats report %d", before-stats.NbConstraintsAfter, removed)
+	}
+
+	// a second pass does not change anything
+	stats, err = constraint.OptimizeR1CS(ccs.(constraint.R1CS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.NbConstraintsBefore != stats.NbConstraintsAfter || stats.NbInternalVariablesBefore != stats.NbInternalVariablesAfter {
+		t.Fatalf("second pass changed the system %+v", stats)
+	}
+}
+
+type optimizeGkrCircuit struct {
+	X [2]frontend.Variable
+}
+
+func (c *optimizeGkrCircuit) Define(api frontend.API) error {
+	g := gkr.NewApi()
+	x, err := g.Import(c.X[:])
+	if err != nil {
+		return err
+	}
+	z := g.Add(x, x)
+	solution, err := g.Solve(api)
+	if err != nil {
+		return err
+	}
+	Z := solution.Export(z)
+	for i := range Z {
+		api.AssertIsEqual(Z[i], api.Mul(2, c.X[i]))
+	}
+	return solution.Verify("mimc")
+}
+
+func TestOptimizeR1CSGkr(t *testing.T) {
+	cs_bn254.RegisterHashBuilder("mimc", func() hash.Hash {
+		return mimc_bn254.NewMiMC()
+	})
+	stdhash.Register("mimc", func(api frontend.API) (stdhash.FieldHasher, error) {
+		m, err := mimc.NewMiMC(api)
+		return &m, err
+	})
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &optimizeGkrCircuit{}, frontend.WithR1CSOptimization())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := frontend.NewWitness(&optimizeGkrCircuit{X: [2]frontend.Variable{1, 2}}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pw, err := w.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(ccs, pk, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = groth16.Verify(proof, vk, pw); err != nil {
+		t.Fatal(err)
+	}
+}
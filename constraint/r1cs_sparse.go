@@ -14,6 +14,8 @@
 
 package constraint
 
+import "strconv"
+
 type SparseR1CS interface {
 	ConstraintSystem
 
@@ -27,29 +29,40 @@ type SparseR1CS interface {
 
 	// GetSparseR1CIterator returns an SparseR1CIterator to iterate on the SparseR1C constraints of the system.
 	GetSparseR1CIterator() SparseR1CIterator
+
+	// GetCustomGates returns the custom gates of the system ordered by their index.
+	GetCustomGates() []*BlueprintCustomGate
+
+	// GetCustomGatesDegree returns the maximal degree of the custom gates.
+	GetCustomGatesDegree() int
+
+	// GetQuotientShardFactor returns the shard factor of the quotient of the
+	// PLONK prover, see [QuotientShardFactor].
+	GetQuotientShardFactor() int
+
+	// GetLookupTables returns the lookup tables of the system ordered by their index.
+	GetLookupTables() []*BlueprintLookup
 }
 
 // SparseR1CIterator facilitates iterating through SparseR1C constraints.
 type SparseR1CIterator struct {
 	SparseR1C
-	cs *System
-	n  int
+	instructionIterator
 }
 
 // Next returns the next SparseR1C or nil if end. Caller must not store the result since the
 // same memory space is re-used for subsequent calls to Next.
 func (it *SparseR1CIterator) Next() *SparseR1C {
-	if it.n >= it.cs.GetNbInstructions() {
-		return nil
-	}
-	inst := it.cs.Instructions[it.n]
-	it.n++
-	blueprint := it.cs.Blueprints[inst.BlueprintID]
-	if bc, ok := blueprint.(BlueprintSparseR1C); ok {
-		bc.DecompressSparseR1C(&it.SparseR1C, inst.Unpack(it.cs))
-		return &it.SparseR1C
+	for {
+		blueprint, inst, ok := it.next()
+		if !ok {
+			return nil
+		}
+		if bc, ok := blueprint.(BlueprintSparseR1C); ok {
+			bc.DecompressSparseR1C(&it.SparseR1C, inst)
+			return &it.SparseR1C
+		}
 	}
-	return it.Next()
 }
 
 // func (system *SparseR1CSCore) CheckUnconstrainedWires() error {
@@ -144,6 +157,12 @@ type SparseR1C struct {
 	XA, XB, XC         uint32
 	QL, QR, QO, QM, QC uint32
 	Commitment         CommitmentConstraint
+	// CustomGate is the index+1 of the custom gate (see [BlueprintCustomGate])
+	// enforced by the constraint, or 0 for the standard PLONK gate.
+	CustomGate uint32
+	// Lookup is the index+1 of the lookup table (see [BlueprintLookup])
+	// containing (xa, xb, xc), or 0 if the constraint is not a lookup.
+	Lookup uint32
 }
 
 func (c *SparseR1C) Clear() {
@@ -153,6 +172,29 @@ func (c *SparseR1C) Clear() {
 // String formats the constraint as qL⋅xa + qR⋅xb + qO⋅xc + qM⋅(xaxb) + qC == 0
 func (c *SparseR1C) String(r Resolver) string {
 	sbb := NewStringBuilder(r)
+	if c.CustomGate != 0 {
+		sbb.WriteString("customGate#")
+		sbb.WriteString(strconv.Itoa(int(c.CustomGate) - 1))
+		sbb.WriteByte('(')
+		sbb.WriteString(sbb.VariableToString(int(c.XA)))
+		sbb.WriteString(", ")
+		sbb.WriteString(sbb.VariableToString(int(c.XB)))
+		sbb.WriteString(", ")
+		sbb.WriteString(sbb.VariableToString(int(c.XC)))
+		sbb.WriteString(") == 0")
+		return sbb.String()
+	}
+	if c.Lookup != 0 {
+		sbb.WriteByte('(')
+		sbb.WriteString(sbb.VariableToString(int(c.XA)))
+		sbb.WriteString(", ")
+		sbb.WriteString(sbb.VariableToString(int(c.XB)))
+		sbb.WriteString(", ")
+		sbb.WriteString(sbb.VariableToString(int(c.XC)))
+		sbb.WriteString(") ∈ table#")
+		sbb.WriteString(strconv.Itoa(int(c.Lookup) - 1))
+		return sbb.String()
+	}
 	sbb.WriteTerm(Term{CID: c.QL, VID: c.XA})
 	sbb.WriteString(" + ")
 	sbb.WriteTerm(Term{CID: c.QR, VID: c.XB})
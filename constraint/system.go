@@ -77,6 +77,14 @@ type ConstraintSystem interface {
 
 	GetInstruction(int) Instruction
 
+	// GetInstructionIterator returns an iterator over the instructions of the
+	// system and their blueprints.
+	GetInstructionIterator() InstructionIterator
+
+	// GetDebugInfoStack returns the call stack of the debug information
+	// attached to the constraint cID, or nil if there is none.
+	GetDebugInfoStack(cID int) []string
+
 	GetCoefficient(i int) Element
 }
 
@@ -384,6 +384,23 @@ func (solver *solver) processInstruction(pi constraint.PackedInstruction, scratc
 	// fetch the blueprint
 	blueprint := solver.Blueprints[pi.BlueprintID]
 	inst := pi.Unpack(&solver.System)
+
+	// blueprint encodes several instructions, we process them in order.
+	if bc, ok := blueprint.(constraint.BlueprintComposite); ok {
+		for i := 0; i < bc.NbInstructions(); i++ {
+			subBlueprint, subInst := bc.Instruction(inst, i, &scratch.calldata)
+			if err := solver.solveInstruction(subBlueprint, subInst, scratch); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return solver.solveInstruction(blueprint, inst, scratch)
+}
+
+// solveInstruction executes the blueprint-defined logic of the unpacked instruction.
+func (solver *solver) solveInstruction(blueprint constraint.Blueprint, inst constraint.Instruction, scratch *scratch) error {
 	cID := inst.ConstraintOffset // here we have 1 constraint in the instruction only
 
 	if solver.Type == constraint.SystemR1CS {
@@ -643,6 +660,7 @@ func (solver *solver) wrapErrWithDebugInfo(cID uint32, err error) *UnsatisfiedCo
 
 // temporary variables to avoid memallocs in hotloop
 type scratch struct {
-	tR1C  constraint.R1C
-	tHint constraint.HintMapping
+	tR1C     constraint.R1C
+	tHint    constraint.HintMapping
+	calldata []uint32
 }
@@ -18,6 +18,7 @@ package cs
 
 import (
 	"io"
+	"slices"
 	"time"
 
 	"github.com/consensys/gnark/backend/witness"
@@ -125,13 +126,14 @@ func (cs *system) IsSolved(witness witness.Witness, opts ...csolver.Option) erro
 func (cs *system) GetR1Cs() []constraint.R1C {
 	toReturn := make([]constraint.R1C, 0, cs.GetNbConstraints())
 
-	for _, inst := range cs.Instructions {
-		blueprint := cs.Blueprints[inst.BlueprintID]
-		if bc, ok := blueprint.(constraint.BlueprintR1C); ok {
-			var r1c constraint.R1C
-			bc.DecompressR1C(&r1c, inst.Unpack(&cs.System))
-			toReturn = append(toReturn, r1c)
-		}
+	it := cs.GetR1CIterator()
+	for r1c := it.Next(); r1c != nil; r1c = it.Next() {
+		// the iterator re-uses the memory of the linear expressions
+		toReturn = append(toReturn, constraint.R1C{
+			L: slices.Clone(r1c.L),
+			R: slices.Clone(r1c.R),
+			O: slices.Clone(r1c.O),
+		})
 	}
 	return toReturn
 }
@@ -156,13 +158,9 @@ func (cs *system) GetSparseR1Cs() []constraint.SparseR1C {
 
 	toReturn := make([]constraint.SparseR1C, 0, cs.GetNbConstraints())
 
-	for _, inst := range cs.Instructions {
-		blueprint := cs.Blueprints[inst.BlueprintID]
-		if bc, ok := blueprint.(constraint.BlueprintSparseR1C); ok {
-			var sparseR1C constraint.SparseR1C
-			bc.DecompressSparseR1C(&sparseR1C, inst.Unpack(&cs.System))
-			toReturn = append(toReturn, sparseR1C)
-		}
+	it := cs.GetSparseR1CIterator()
+	for c := it.Next(); c != nil; c = it.Next() {
+		toReturn = append(toReturn, *c)
 	}
 	return toReturn
 }
@@ -190,18 +188,13 @@ func evaluateLROSmallDomain(cs *system, solution []fr.Element) ([]fr.Element, []
 	offset := len(cs.Public)
 	nbConstraints := cs.GetNbConstraints()
 
-	var sparseR1C constraint.SparseR1C
 	j := 0
-	for _, inst := range cs.Instructions {
-		blueprint := cs.Blueprints[inst.BlueprintID]
-		if bc, ok := blueprint.(constraint.BlueprintSparseR1C); ok {
-			bc.DecompressSparseR1C(&sparseR1C, inst.Unpack(&cs.System))
-
-			l[offset+j] = solution[sparseR1C.XA]
-			r[offset+j] = solution[sparseR1C.XB]
-			o[offset+j] = solution[sparseR1C.XC]
-			j++
-		}
+	it := cs.GetSparseR1CIterator()
+	for sparseR1C := it.Next(); sparseR1C != nil; sparseR1C = it.Next() {
+		l[offset+j] = solution[sparseR1C.XA]
+		r[offset+j] = solution[sparseR1C.XB]
+		o[offset+j] = solution[sparseR1C.XC]
+		j++
 	}
 
 	offset += nbConstraints
@@ -111,3 +111,34 @@ func (st *SymbolTable) locationID(frame *runtime.Frame) int {
 
 	return lID
 }
+
+// Import adds the locations of other to st and returns, for each location id
+// in other, the corresponding location id in st.
+func (st *SymbolTable) Import(other *SymbolTable) []int {
+	ids := make([]int, len(other.Locations))
+	// the program counters are not serialized
+	pcs := make([]uint64, len(other.Locations))
+	withPCs := len(other.mLocations) == len(other.Locations)
+	for pc, id := range other.mLocations {
+		pcs[id] = pc
+	}
+	for id, l := range other.Locations {
+		if lID, ok := st.mLocations[pcs[id]]; ok && withPCs {
+			ids[id] = lID
+			continue
+		}
+		f := other.Functions[l.FunctionID]
+		fID, ok := st.mFunctions[f.Filename+f.SystemName]
+		if !ok {
+			st.Functions = append(st.Functions, f)
+			fID = len(st.Functions) - 1
+			st.mFunctions[f.Filename+f.SystemName] = fID
+		}
+		st.Locations = append(st.Locations, Location{FunctionID: fID, Line: l.Line})
+		ids[id] = len(st.Locations) - 1
+		if withPCs {
+			st.mLocations[pcs[id]] = ids[id]
+		}
+	}
+	return ids
+}
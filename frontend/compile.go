@@ -30,6 +30,7 @@ import (
 //  3. finally, it converts that to a ConstraintSystem.
 //     if zkpID == backend.GROTH16	→ R1CS
 //     if zkpID == backend.PLONK 	→ SparseR1CS
+//     if zkpID == backend.PLONKFRI	→ SparseR1CS
 //
 // initialCapacity is an optional parameter that reserves memory in slices
 // it should be set to the estimated number of constraints in the circuit, if known.
@@ -154,6 +155,11 @@ type CompileConfig struct {
 	Capacity                  int
 	IgnoreUnconstrainedInputs bool
 	CompressThreshold         int
+	OptimizeR1CS              bool
+	NoNativeLookups           bool
+
+	CommonSubexpressionElimination bool
+	CSEReport                      *CSEReport
 }
 
 // WithCapacity is a compile option that specifies the estimated capacity needed
@@ -203,6 +209,73 @@ func WithCompressThreshold(threshold int) CompileOption {
 	}
 }
 
+// WithR1CSOptimization is a compile option which runs an optimization pass on
+// the compiled R1CS. The pass substitutes the wires defined by linear
+// constraints, removes the duplicated and unused constraints and renumbers the
+// internal wires. See [constraint.OptimizeR1CS] for details.
+//
+// The optimized constraint system accepts the same witnesses but the internal
+// wires (and so the solution of the solver) are different. This option does
+// not change the compile behaviour for other arithmetisations.
+func WithR1CSOptimization() CompileOption {
+	return func(opt *CompileConfig) error {
+		opt.OptimizeR1CS = true
+		return nil
+	}
+}
+
+// WithoutNativeLookups is a compile option which makes the std gadgets (range
+// checks in std/rangecheck, precomputed tables in std/math/uints and
+// std/math/emulated) use the log-derivative argument built on commitments
+// instead of the native lookup argument of the builder, see [NativeLookups].
+//
+// The PLONK builder uses native lookups by default, but the Solidity verifier,
+// the in-circuit PLONK verifier of std/recursion and the FRI based PLONK
+// backend don't support them: the circuits whose proofs are verified by them
+// must be compiled with this option. The option does not change the compile
+// behaviour for builders not implementing [Lookuper].
+func WithoutNativeLookups() CompileOption {
+	return func(opt *CompileConfig) error {
+		opt.NoNativeLookups = true
+		return nil
+	}
+}
+
+// CSEReport reports the effect of the common subexpression elimination, see
+// [WithCommonSubexpressionElimination].
+type CSEReport struct {
+	// NbConstraintsSaved is the number of constraints which were not added
+	// because an equivalent computation or constraint already existed. It
+	// does not include the constraints skipped by the deduplications the
+	// builders always do (for example boolean constraints on the outputs of a
+	// merged hint), so the actual reduction may be larger.
+	NbConstraintsSaved int
+	// NbHintsSaved is the number of hint calls replaced by the outputs of a
+	// previous call to the same hint with the same inputs.
+	NbHintsSaved int
+}
+
+// WithCommonSubexpressionElimination is a compile option which enables the
+// global hash-consing of the computations in the builders. The products,
+// divisions, inverses, additions (for PLONK), hint calls and constraints are
+// canonicalized and the wires of a previous equivalent computation are reused
+// instead of adding new constraints. This helps when identical subcomputations
+// are done in different parts of the circuit, for example repeated hashes of
+// the same input.
+//
+// As hint calls with the same inputs return the same outputs, hints must be
+// deterministic; hints without inputs are never merged.
+//
+// If report is not nil, it is filled with the number of constraints saved when
+// the compilation succeeds.
+func WithCommonSubexpressionElimination(report *CSEReport) CompileOption {
+	return func(opt *CompileConfig) error {
+		opt.CommonSubexpressionElimination = true
+		opt.CSEReport = report
+		return nil
+	}
+}
+
 var tVariable reflect.Type
 
 func init() {
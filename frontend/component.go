@@ -0,0 +1,75 @@
+package frontend
+
+import "fmt"
+
+// ComponentFunc defines the sub-circuit of a [Component]: it returns the
+// outputs of the component computed from its inputs.
+type ComponentFunc func(api API, inputs []Variable) ([]Variable, error)
+
+// Component is a reusable sub-circuit with a fixed number of inputs and
+// outputs, for example a hash or a signature verification called many times
+// in a circuit.
+type Component struct {
+	Name     string
+	NbInputs int
+	Define   ComponentFunc
+}
+
+// Call returns the outputs of the component by calling Define on api. It can
+// be used as a fallback when the builder does not implement [Componenter].
+func (c Component) Call(api API, inputs ...Variable) ([]Variable, error) {
+	if len(inputs) != c.NbInputs {
+		return nil, fmt.Errorf("component %q: expected %d inputs, got %d", c.Name, c.NbInputs, len(inputs))
+	}
+	return c.Define(api, inputs)
+}
+
+// ComponentID identifies a component defined with
+// [Componenter.DefineComponent].
+type ComponentID int
+
+// Componenter is implemented by the builders supporting compiled components,
+// i.e. the R1CS and PLONK builders and the test engine.
+//
+// The sub-circuit of a component is traced and compiled once into a template
+// stored as a single blueprint of the constraint system. Every call adds a
+// single instruction referencing the input wires, and the solver solves the
+// independent calls in parallel. Compared to calling Define directly, this
+// reduces the compilation time and the size of the serialized constraint
+// system, but not the number of constraints. The inputs and outputs which are
+// not a single wire, for example linear expressions, cost one constraint each
+// to be stored in a new wire.
+//
+// As the sub-circuit is compiled independently of its inputs, constant inputs
+// are not propagated and the components can not use commitments, deferred
+// callbacks, custom gates or lookups. The checks deferred with
+// [ComponentDeferrer] are the exception: they are done by the calling circuit
+// on the variables of every instance. Among the std gadgets, the range checks
+// of std/rangecheck and the gadgets only relying on them, as
+// std/math/bitslice, can be used in components, but not the ones with their
+// own deferred checks, as std/math/emulated, std/math/uints,
+// std/lookup/logderivlookup or std/multicommit. The logs and debug information
+// of the sub-circuit are not kept.
+type Componenter interface {
+	// DefineComponent compiles the sub-circuit of the component and returns
+	// its identifier.
+	DefineComponent(c Component) (ComponentID, error)
+
+	// CallComponent returns the outputs of the component for the inputs.
+	CallComponent(id ComponentID, inputs ...Variable) ([]Variable, error)
+}
+
+// ComponentDeferrer is implemented by the builder passed to the sub-circuit of
+// a component by the R1CS and PLONK builders.
+//
+// The gadgets deferring checks to the end of the circuit, which is not
+// possible in a component, can instead defer them to the calling circuit. For
+// example std/rangecheck checks the variables of all the instances of the
+// components in the calling circuit, with a single commitment.
+type ComponentDeferrer interface {
+	// DeferInstance registers cb to be called on the builder of the calling
+	// circuit after every call of the component. The function instance
+	// returns the variable of the call corresponding to a variable of the
+	// sub-circuit.
+	DeferInstance(cb func(api API, instance func(Variable) Variable) error)
+}
@@ -0,0 +1,202 @@
+package frontend_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/lookup/logderivlookup"
+	"github.com/consensys/gnark/test"
+	"github.com/consensys/gnark/test/unsafekzg"
+	"github.com/stretchr/testify/require"
+)
+
+var hashComponent = frontend.Component{
+	Name:     "hash",
+	NbInputs: 2,
+	Define: func(api frontend.API, inputs []frontend.Variable) ([]frontend.Variable, error) {
+		h, err := mimc.NewMiMC(api)
+		if err != nil {
+			return nil, err
+		}
+		h.Write(inputs...)
+		bits := api.ToBinary(api.Add(inputs[1], 1), 8)
+		return []frontend.Variable{h.Sum(), api.Mul(bits[0], bits[1])}, nil
+	},
+}
+
+type componentCircuit struct {
+	X      [8]frontend.Variable
+	Y      frontend.Variable `gnark:",public"`
+	inline bool
+}
+
+func (c *componentCircuit) Define(api frontend.API) error {
+	call := func(inputs ...frontend.Variable) ([]frontend.Variable, error) {
+		return hashComponent.Call(api, inputs...)
+	}
+	if !c.inline {
+		id, err := api.(frontend.Componenter).DefineComponent(hashComponent)
+		if err != nil {
+			return err
+		}
+		call = func(inputs ...frontend.Variable) ([]frontend.Variable, error) {
+			return api.(frontend.Componenter).CallComponent(id, inputs...)
+		}
+	}
+	acc := c.Y
+	for i := range c.X {
+		res, err := call(acc, c.X[i])
+		if err != nil {
+			return err
+		}
+		api.AssertIsBoolean(res[1])
+		acc = res[0]
+	}
+	api.AssertIsDifferent(acc, 0)
+	return nil
+}
+
+func componentWitnesses(assert *require.Assertions) (valid, invalid witness.Witness) {
+	field := ecc.BN254.ScalarField()
+	var assignment componentCircuit
+	for i := range assignment.X {
+		assignment.X[i] = i * 17
+	}
+	assignment.Y = 42
+	valid, err := frontend.NewWitness(&assignment, field)
+	assert.NoError(err)
+	// the input of the bit decomposition does not fit on 8 bits
+	assignment.X[3] = 300
+	invalid, err = frontend.NewWitness(&assignment, field)
+	assert.NoError(err)
+	return valid, invalid
+}
+
+func TestComponent(t *testing.T) {
+	field := ecc.BN254.ScalarField()
+	for _, tc := range []struct {
+		name    string
+		builder frontend.NewBuilder
+		// nbStored is the number of outputs of a call which are not a single
+		// wire, and cost a constraint to be stored in a new one
+		nbStored int
+		newCS    func(ecc.ID) constraint.ConstraintSystem
+		prove    func(*require.Assertions, constraint.ConstraintSystem, witness.Witness)
+	}{
+		// the MiMC digest is a linear expression in R1CS
+		{"r1cs", r1cs.NewBuilder, 1, groth16.NewCS, proveGroth16},
+		{"scs", scs.NewBuilder, 0, plonk.NewCS, provePlonk},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			inline, err := frontend.Compile(field, tc.builder, &componentCircuit{inline: true})
+			assert.NoError(err)
+			ccs, err := frontend.Compile(field, tc.builder, &componentCircuit{})
+			assert.NoError(err)
+			nbStored := tc.nbStored * len(componentCircuit{}.X)
+			assert.Equal(inline.GetNbConstraints()+nbStored, ccs.GetNbConstraints())
+			assert.Equal(inline.GetNbInternalVariables()+nbStored, ccs.GetNbInternalVariables())
+			assert.Less(ccs.GetNbInstructions(), inline.GetNbInstructions())
+
+			valid, invalid := componentWitnesses(assert)
+			_, err = ccs.Solve(valid)
+			assert.NoError(err)
+			_, err = ccs.Solve(invalid)
+			assert.Error(err)
+
+			// the component blueprint is serialized with the system
+			var buf bytes.Buffer
+			_, err = ccs.WriteTo(&buf)
+			assert.NoError(err)
+			decoded := tc.newCS(ecc.BN254)
+			_, err = decoded.ReadFrom(&buf)
+			assert.NoError(err)
+			assert.Equal(ccs.GetNbConstraints(), decoded.GetNbConstraints())
+
+			tc.prove(assert, decoded, valid)
+		})
+	}
+}
+
+func proveGroth16(assert *require.Assertions, ccs constraint.ConstraintSystem, valid witness.Witness) {
+	pk, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+	proof, err := groth16.Prove(ccs, pk, valid)
+	assert.NoError(err)
+	publicWitness, err := valid.Public()
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(proof, vk, publicWitness))
+}
+
+func provePlonk(assert *require.Assertions, ccs constraint.ConstraintSystem, valid witness.Witness) {
+	srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+	assert.NoError(err)
+	pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+	assert.NoError(err)
+	proof, err := plonk.Prove(ccs, pk, valid)
+	assert.NoError(err)
+	publicWitness, err := valid.Public()
+	assert.NoError(err)
+	assert.NoError(plonk.Verify(proof, vk, publicWitness))
+}
+
+func TestComponentTestEngine(t *testing.T) {
+	var assignment componentCircuit
+	for i := range assignment.X {
+		assignment.X[i] = i * 17
+	}
+	assignment.Y = 42
+	require.NoError(t, test.IsSolved(&componentCircuit{}, &assignment, ecc.BN254.ScalarField()))
+	assignment.X[3] = 300
+	require.Error(t, test.IsSolved(&componentCircuit{}, &assignment, ecc.BN254.ScalarField()))
+}
+
+func TestComponentErrors(t *testing.T) {
+	for _, builder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		_, err := frontend.Compile(ecc.BN254.ScalarField(), builder, &componentErrorCircuit{})
+		require.ErrorContains(t, err, "expected 2 inputs, got 1")
+		// the gadgets with their own deferred checks are rejected
+		_, err = frontend.Compile(ecc.BN254.ScalarField(), builder, &componentLookupCircuit{})
+		require.ErrorContains(t, err, "deferred callbacks are not supported in components")
+	}
+}
+
+type componentLookupCircuit struct {
+	X frontend.Variable
+}
+
+func (c *componentLookupCircuit) Define(api frontend.API) error {
+	_, err := api.(frontend.Componenter).DefineComponent(frontend.Component{
+		Name:     "lookup",
+		NbInputs: 1,
+		Define: func(api frontend.API, inputs []frontend.Variable) ([]frontend.Variable, error) {
+			t := logderivlookup.New(api)
+			t.Insert(1)
+			return t.Lookup(inputs[0]), nil
+		},
+	})
+	return err
+}
+
+type componentErrorCircuit struct {
+	X frontend.Variable
+}
+
+func (c *componentErrorCircuit) Define(api frontend.API) error {
+	id, err := api.(frontend.Componenter).DefineComponent(hashComponent)
+	if err != nil {
+		return err
+	}
+	_, err = api.(frontend.Componenter).CallComponent(id, c.X)
+	return err
+}
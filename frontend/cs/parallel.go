@@ -0,0 +1,78 @@
+package cs
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/circuitdefer"
+)
+
+// Parallel builds the sections concurrently, each in a builder returned by
+// fork whose constraint system is forked from cs, and joins the systems of the
+// sections to cs in order. The outputs of each section are passed to join with
+// the function renumbering the wires of the section, so that the caller can
+// update them in place, and are returned. It is the implementation of
+// [frontend.Paralleler] shared by the R1CS and PLONK builders.
+func Parallel[B frontend.API](cs constraint.ConstraintSystem, sections []frontend.Section,
+	fork func() (B, constraint.ConstraintSystem),
+	join func(b B, wire func(int) int, outputs []frontend.Variable)) ([][]frontend.Variable, error) {
+
+	type forked struct {
+		builder B
+		cs      constraint.ConstraintSystem
+		outputs []frontend.Variable
+		err     error
+	}
+	forks := make([]forked, len(sections))
+	for i := range forks {
+		forks[i].builder, forks[i].cs = fork()
+		if err := constraint.Fork(cs, forks[i].cs); err != nil {
+			return nil, err
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(forks))
+	for i := range forks {
+		go func(f *forked, define frontend.Section) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					f.err = fmt.Errorf("%v\n%s", r, debug.Stack())
+				}
+			}()
+			if f.outputs, f.err = define(f.builder); f.err == nil {
+				f.err = callDeferred(f.builder)
+			}
+		}(&forks[i], sections[i])
+	}
+	wg.Wait()
+
+	outputs := make([][]frontend.Variable, len(forks))
+	for i, f := range forks {
+		if f.err != nil {
+			return nil, fmt.Errorf("section %d: %w", i, f.err)
+		}
+		wire, err := constraint.Join(cs, f.cs)
+		if err != nil {
+			return nil, fmt.Errorf("section %d: %w", i, err)
+		}
+		join(f.builder, wire, f.outputs)
+		outputs[i] = f.outputs
+	}
+	return outputs, nil
+}
+
+// callDeferred calls the callbacks deferred in a section, which is then
+// complete when joined to the parent builder.
+func callDeferred(api frontend.API) error {
+	for i := 0; i < len(circuitdefer.GetAll[func(frontend.API) error](api)); i++ {
+		if err := circuitdefer.GetAll[func(frontend.API) error](api)[i](api); err != nil {
+			return fmt.Errorf("defer fn %d: %w", i, err)
+		}
+	}
+	return nil
+}
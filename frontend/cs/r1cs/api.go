@@ -33,6 +33,7 @@ import (
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/constraint/solver"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/internal/cse"
 	"github.com/consensys/gnark/frontend/internal/expr"
 	"github.com/consensys/gnark/frontend/schema"
 	"github.com/consensys/gnark/std/math/bits"
@@ -59,8 +60,7 @@ func (builder *builder) MulAcc(a, b, c frontend.Variable) frontend.Variable {
 
 		// v1 and v2 are both unknown, this is the only case we add a constraint
 		if !v1Constant && !v2Constant {
-			res := builder.newInternalVariable()
-			builder.cs.AddR1C(builder.newR1C(b, c, res), builder.genericGate)
+			res := builder.mul(builder.toVariable(b), builder.toVariable(c))
 			builder.mbuf1 = append(builder.mbuf1, res...)
 			return
 		}
@@ -206,9 +206,7 @@ func (builder *builder) Mul(i1, i2 frontend.Variable, in ...frontend.Variable) f
 
 		// v1 and v2 are both unknown, this is the only case we add a constraint
 		if !v1Constant && !v2Constant {
-			res := builder.newInternalVariable()
-			builder.cs.AddR1C(builder.newR1C(v1, v2, res), builder.genericGate)
-			return res
+			return builder.mul(v1, v2)
 		}
 
 		// v1 and v2 are constants, we multiply big.Int values and return resulting constant
@@ -258,9 +256,18 @@ func (builder *builder) DivUnchecked(i1, i2 frontend.Variable) frontend.Variable
 	n2, v2Constant := builder.constantValue(v2)
 
 	if !v2Constant {
+		s, found := builder.cseDiv(cse.OpDivUnchecked, v1, v2)
+		if found {
+			if res, ok := builder.cseGet(s, 1); ok {
+				return res
+			}
+		}
 		res := builder.newInternalVariable()
+		if found {
+			builder.csePut(s, res)
+		}
 		// note that here we don't ensure that divisor is != 0
-		cID := builder.cs.AddR1C(builder.newR1C(v2, res, v1), builder.genericGate)
+		cID := builder.addR1C(builder.newR1C(v2, res, v1))
 		if debug.Debug {
 			debug := builder.newDebugInfo("div", v1, "/", v2, " == ", res)
 			builder.cs.AttachDebugInfo(debug, []int{cID})
@@ -294,11 +301,20 @@ func (builder *builder) Div(i1, i2 frontend.Variable) frontend.Variable {
 	n2, v2Constant := builder.constantValue(v2)
 
 	if !v2Constant {
+		s, found := builder.cseDiv(cse.OpDiv, v1, v2)
+		if found {
+			if res, ok := builder.cseGet(s, 2); ok {
+				return res
+			}
+		}
 		res := builder.newInternalVariable()
+		if found {
+			builder.csePut(s, res)
+		}
 		v2Inv := builder.newInternalVariable()
 		// note that here we ensure that v2 can't be 0, but it costs us one extra constraint
-		c1 := builder.cs.AddR1C(builder.newR1C(v2, v2Inv, builder.cstOne()), builder.genericGate)
-		c2 := builder.cs.AddR1C(builder.newR1C(v1, v2Inv, res), builder.genericGate)
+		c1 := builder.addR1C(builder.newR1C(v2, v2Inv, builder.cstOne()))
+		c2 := builder.addR1C(builder.newR1C(v1, v2Inv, res))
 		if debug.Debug {
 			debug := builder.newDebugInfo("div", v1, "/", v2, " == ", res)
 			builder.cs.AttachDebugInfo(debug, []int{c1, c2})
@@ -334,10 +350,24 @@ func (builder *builder) Inverse(i1 frontend.Variable) frontend.Variable {
 		return expr.NewLinearExpression(0, c)
 	}
 
+	var s constraint.Element
+	n, c, found := builder.cseNormalize(vars[0])
+	if found {
+		builder.cseKey.Reset(cse.OpInverse)
+		builder.cseKey.WriteLinearExpression(n)
+		s, _ = builder.cs.Inverse(c)
+		if res, ok := builder.cseGet(s, 1); ok {
+			return res
+		}
+	}
+
 	// allocate resulting frontend.Variable
 	res := builder.newInternalVariable()
+	if found {
+		builder.csePut(s, res)
+	}
 
-	cID := builder.cs.AddR1C(builder.newR1C(res, vars[0], builder.cstOne()), builder.genericGate)
+	cID := builder.addR1C(builder.newR1C(res, vars[0], builder.cstOne()))
 	if debug.Debug {
 		debug := builder.newDebugInfo("inverse", vars[0], "*", res, " == 1")
 		builder.cs.AttachDebugInfo(debug, []int{cID})
@@ -417,7 +447,7 @@ func (builder *builder) Or(_a, _b frontend.Variable) frontend.Variable {
 
 	c = append(c, a...)
 	c = append(c, b...)
-	builder.cs.AddR1C(builder.newR1C(a, b, c), builder.genericGate)
+	builder.addR1C(builder.newR1C(a, b, c))
 
 	return res
 }
@@ -559,10 +589,10 @@ func (builder *builder) IsZero(i1 frontend.Variable) frontend.Variable {
 	}
 
 	// m = -a*x + 1         // constrain m to be 1 if a == 0
-	c1 := builder.cs.AddR1C(builder.newR1C(builder.Neg(a), x[0], builder.Sub(m, 1)), builder.genericGate)
+	c1 := builder.addR1C(builder.newR1C(builder.Neg(a), x[0], builder.Sub(m, 1)))
 
 	// a * m = 0            // constrain m to be 0 if a != 0
-	c2 := builder.cs.AddR1C(builder.newR1C(a, m, builder.cstZero()), builder.genericGate)
+	c2 := builder.addR1C(builder.newR1C(a, m, builder.cstZero()))
 
 	if debug.Debug {
 		debug := builder.newDebugInfo("isZero", a)
@@ -697,10 +727,16 @@ func (builder *builder) Commit(v ...frontend.Variable) (frontend.Variable, error
 			return nil, err
 		}
 		vCp[len(v)] = mask[0]
-		builder.cs.AddR1C(builder.newR1C(mask[0], builder.eOne, mask[0]), builder.genericGate) // the variable needs to be involved in a constraint otherwise it will not affect the commitment
+		builder.addR1C(builder.newR1C(mask[0], builder.eOne, mask[0])) // the variable needs to be involved in a constraint otherwise it will not affect the commitment
 		v = vCp
 	}
 
+	// in a section, the private wires of the parent builder may be committed
+	// elsewhere, so we commit to copies of them
+	if builder.nbForkedWires != 0 {
+		v = builder.copyForkedWires(v)
+	}
+
 	commitments := builder.cs.GetCommitments().(constraint.Groth16Commitments)
 	existingCommitmentIndexes := commitments.CommitmentIndexes()
 	privateCommittedSeeker := utils.MultiListSeeker(commitments.GetPrivateCommitted())
@@ -817,6 +853,29 @@ func (builder *builder) Commit(v ...frontend.Variable) (frontend.Variable, error
 	return res, nil
 }
 
+// copyForkedWires returns v where the variables depending on private wires of
+// the parent builder are replaced by new internal variables constrained to be
+// equal to them.
+func (builder *builder) copyForkedWires(v []frontend.Variable) []frontend.Variable {
+	res := make([]frontend.Variable, len(v))
+	for i := range v {
+		res[i] = v[i]
+		if _, ok := builder.constantValue(v[i]); ok {
+			continue
+		}
+		l := builder.toVariable(v[i])
+		for _, t := range l {
+			if t.VID >= builder.cs.GetNbPublicVariables() && t.VID < builder.nbForkedWires {
+				c := builder.newInternalVariable()
+				builder.addR1C(builder.newR1C(l, builder.eOne, c))
+				res[i] = c
+				break
+			}
+		}
+	}
+	return res
+}
+
 func (builder *builder) wireIDsToVars(wireIDs ...[]int) []frontend.Variable {
 	n := 0
 	for i := range wireIDs {
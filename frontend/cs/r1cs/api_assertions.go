@@ -41,7 +41,7 @@ func (builder *builder) AssertIsEqual(i1, i2 frontend.Variable) {
 	r := builder.getLinearExpression(builder.toVariable(i1))
 	o := builder.getLinearExpression(builder.toVariable(i2))
 
-	cID := builder.cs.AddR1C(builder.newR1C(builder.cstOne(), r, o), builder.genericGate)
+	cID := builder.addR1C(builder.newR1C(builder.cstOne(), r, o))
 
 	if debug.Debug {
 		debug := builder.newDebugInfo("assertIsEqual", r, " == ", o)
@@ -83,7 +83,7 @@ func (builder *builder) AssertIsBoolean(i1 frontend.Variable) {
 
 	V := builder.getLinearExpression(v)
 
-	cID := builder.cs.AddR1C(builder.newR1C(V, _v, o), builder.genericGate)
+	cID := builder.addR1C(builder.newR1C(V, _v, o))
 	if debug.Debug {
 		debug := builder.newDebugInfo("assertIsBoolean", V, " == (0|1)")
 		builder.cs.AttachDebugInfo(debug, []int{cID})
@@ -161,7 +161,7 @@ func (builder *builder) mustBeLessOrEqVar(a, bound frontend.Variable) {
 
 		// (1 - t - ai) * ai == 0
 		l := builder.Sub(builder.cstOne(), t, aBits[i])
-		added = append(added, builder.cs.AddR1C(builder.newR1C(l, builder.Mul(aBits[i], builder.cstOne()), zero), builder.genericGate))
+		added = append(added, builder.addR1C(builder.newR1C(l, builder.Mul(aBits[i], builder.cstOne()), zero)))
 	}
 
 	if debug.Debug {
@@ -222,7 +222,7 @@ func (builder *builder) MustBeLessOrEqCst(aBits []frontend.Variable, bound *big.
 			l := builder.Sub(1, p[i+1])
 			l = builder.Sub(l, aBits[i])
 
-			added = append(added, builder.cs.AddR1C(builder.newR1C(l, aBits[i], builder.cstZero()), builder.genericGate))
+			added = append(added, builder.addR1C(builder.newR1C(l, aBits[i], builder.cstZero())))
 		} else {
 			builder.AssertIsBoolean(aBits[i])
 		}
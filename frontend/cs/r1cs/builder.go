@@ -26,6 +26,7 @@ import (
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/debug"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/internal/cse"
 	"github.com/consensys/gnark/frontend/internal/expr"
 	"github.com/consensys/gnark/frontend/schema"
 	"github.com/consensys/gnark/internal/circuitdefer"
@@ -72,6 +73,16 @@ type builder struct {
 	mbuf2 expr.LinearExpression
 
 	genericGate constraint.BlueprintID
+
+	// components defined with DefineComponent
+	components []component
+
+	// common subexpression elimination, nil if disabled
+	cse    *cse.Table
+	cseKey cse.Key
+
+	// number of wires of the parent builder of a section, see Parallel
+	nbForkedWires int
 }
 
 // initialCapacity has quite some impact on frontend performance, especially on large circuits size
@@ -89,6 +100,9 @@ func newBuilder(field *big.Int, config frontend.CompileConfig) *builder {
 		mbuf2:      make(expr.LinearExpression, 0, macCapacity),
 		Store:      kvstore.New(),
 	}
+	if config.CommonSubexpressionElimination {
+		builder.cse = cse.NewTable()
+	}
 
 	// by default the circuit is given a public wire equal to 1
 
@@ -284,6 +298,28 @@ func (builder *builder) Compile() (constraint.ConstraintSystem, error) {
 		}
 	}
 
+	if builder.config.OptimizeR1CS {
+		stats, err := constraint.OptimizeR1CS(builder.cs)
+		if err != nil {
+			return nil, err
+		}
+		log.Info().
+			Int("nbConstraints", stats.NbConstraintsAfter).
+			Int("nbRemoved", stats.NbConstraintsBefore-stats.NbConstraintsAfter).
+			Msg("optimized constraint system")
+	}
+
+	if builder.cse != nil {
+		if builder.config.CSEReport != nil {
+			builder.config.CSEReport.NbConstraintsSaved = builder.cse.NbConstraintsSaved
+			builder.config.CSEReport.NbHintsSaved = builder.cse.NbHintsSaved
+		}
+		log.Info().
+			Int("nbConstraintsSaved", builder.cse.NbConstraintsSaved).
+			Int("nbHintsSaved", builder.cse.NbHintsSaved).
+			Msg("common subexpression elimination")
+	}
+
 	return builder.cs, nil
 }
 
@@ -396,10 +432,38 @@ func (builder *builder) newHint(f solver.Hint, id solver.HintID, nbOutputs int,
 		}
 	}
 
+	// hints are deterministic, a call with the same inputs as a previous one
+	// returns the same outputs.
+	dedup := builder.cse != nil && len(inputs) > 0
+	if dedup {
+		k := &builder.cseKey
+		k.Reset(cse.OpHint)
+		k.WriteInt(int(id))
+		k.WriteInt(nbOutputs)
+		for _, in := range hintInputs {
+			writeCompressed(k, in)
+		}
+		if r, ok := builder.cse.Get(k); ok {
+			builder.cse.NbHintsSaved++
+			res := make([]frontend.Variable, len(r.Wires))
+			for i, idx := range r.Wires {
+				res[i] = expr.NewLinearExpression(idx, builder.tOne)
+			}
+			return res, nil
+		}
+	}
+
 	internalVariables, err := builder.cs.AddSolverHint(f, id, hintInputs, nbOutputs)
 	if err != nil {
 		return nil, err
 	}
+	if dedup {
+		wires := make([]int, len(internalVariables))
+		for i, idx := range internalVariables {
+			wires[i] = int(idx)
+		}
+		builder.cse.Put(&builder.cseKey, cse.Result{Wires: wires})
+	}
 
 	// make the variables
 	res := make([]frontend.Variable, len(internalVariables))
@@ -467,9 +531,23 @@ func (builder *builder) compress(le expr.LinearExpression) expr.LinearExpression
 		return le
 	}
 
+	var s constraint.Element
+	n, c, found := builder.cseNormalize(le)
+	if found {
+		builder.cseKey.Reset(cse.OpLinear)
+		builder.cseKey.WriteLinearExpression(n)
+		s = c
+		if res, ok := builder.cseGet(s, 1); ok {
+			return res
+		}
+	}
+
 	one := builder.cstOne()
 	t := builder.newInternalVariable()
-	builder.cs.AddR1C(builder.newR1C(le, one, t), builder.genericGate)
+	if found {
+		builder.csePut(s, t)
+	}
+	builder.addR1C(builder.newR1C(le, one, t))
 	return t
 }
 
@@ -0,0 +1,145 @@
+package r1cs
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/internal/expr"
+	"github.com/consensys/gnark/frontend/schema"
+	"github.com/consensys/gnark/internal/circuitdefer"
+)
+
+type component struct {
+	id        constraint.BlueprintID
+	blueprint *constraint.BlueprintComponent
+	// deferred are the callbacks registered with DeferInstance by the
+	// sub-circuit, called for every instance
+	deferred []instanceCallback
+}
+
+type instanceCallback = func(api frontend.API, instance func(frontend.Variable) frontend.Variable) error
+
+// template is the builder of the sub-circuit of a component.
+type template struct {
+	*builder
+	deferred []instanceCallback
+}
+
+// DeferInstance implements [frontend.ComponentDeferrer].
+func (t *template) DeferInstance(cb instanceCallback) {
+	t.deferred = append(t.deferred, cb)
+}
+
+// CallComponent adds an instance of the component, whose deferred checks are
+// registered on the template. See [frontend.Componenter].
+func (t *template) CallComponent(id frontend.ComponentID, inputs ...frontend.Variable) ([]frontend.Variable, error) {
+	return t.builder.callComponent(t, id, inputs)
+}
+
+// DefineComponent compiles the sub-circuit of the component into a new
+// blueprint. See [frontend.Componenter].
+func (builder *builder) DefineComponent(c frontend.Component) (frontend.ComponentID, error) {
+	config := builder.config
+	config.Capacity = 0
+	sub := &template{builder: newBuilder(builder.Field(), config)}
+
+	// the inputs of the template are its secret wires 1 to NbInputs
+	inputs := make([]frontend.Variable, c.NbInputs)
+	for i := range inputs {
+		name := "in" + strconv.Itoa(i)
+		inputs[i] = sub.SecretVariable(schema.LeafInfo{FullName: func() string { return name }, Visibility: schema.Secret})
+	}
+	outputs, err := c.Define(sub, inputs)
+	if err != nil {
+		return 0, fmt.Errorf("component %q: %w", c.Name, err)
+	}
+	if len(circuitdefer.GetAll[func(frontend.API) error](sub)) != 0 {
+		return 0, fmt.Errorf("component %q: deferred callbacks are not supported in components", c.Name)
+	}
+
+	wires := make([]int, len(outputs))
+	for i := range outputs {
+		wires[i] = sub.toWire(outputs[i])
+	}
+	blueprint, err := constraint.NewBlueprintComponent(c.Name, sub.cs, c.NbInputs, wires, builder.cs)
+	if err != nil {
+		return 0, err
+	}
+	builder.components = append(builder.components, component{
+		id:        builder.cs.AddBlueprint(blueprint),
+		blueprint: blueprint,
+		deferred:  sub.deferred,
+	})
+	return frontend.ComponentID(len(builder.components) - 1), nil
+}
+
+// CallComponent adds an instance of the component. See [frontend.Componenter].
+func (builder *builder) CallComponent(id frontend.ComponentID, inputs ...frontend.Variable) ([]frontend.Variable, error) {
+	return builder.callComponent(builder, id, inputs)
+}
+
+// callComponent adds an instance of the component and calls its deferred
+// callbacks on api, the builder of the calling circuit.
+func (builder *builder) callComponent(api frontend.API, id frontend.ComponentID, inputs []frontend.Variable) ([]frontend.Variable, error) {
+	if id < 0 || int(id) >= len(builder.components) {
+		return nil, fmt.Errorf("unknown component %d", id)
+	}
+	c := builder.components[id]
+	if len(inputs) != c.blueprint.NbInputs {
+		return nil, fmt.Errorf("component %q: expected %d inputs, got %d", c.blueprint.Name, c.blueprint.NbInputs, len(inputs))
+	}
+
+	calldata := make([]uint32, len(inputs))
+	for i := range inputs {
+		calldata[i] = uint32(builder.toWire(inputs[i]))
+	}
+	builder.cs.AddInstruction(c.id, calldata)
+
+	inst := builder.cs.GetInstruction(builder.cs.GetNbInstructions() - 1)
+	wires := c.blueprint.OutputWires(inst)
+	res := make([]frontend.Variable, len(wires))
+	for i, w := range wires {
+		res[i] = expr.NewLinearExpression(int(w), builder.tOne)
+	}
+
+	instance := func(v frontend.Variable) frontend.Variable {
+		return builder.instanceVariable(c.blueprint, inst, v)
+	}
+	for i, cb := range c.deferred {
+		if err := cb(api, instance); err != nil {
+			return nil, fmt.Errorf("component %q: deferred fn %d: %w", c.blueprint.Name, i, err)
+		}
+	}
+	return res, nil
+}
+
+// instanceVariable returns the variable of the instance inst of the component
+// b corresponding to the variable v of its sub-circuit.
+func (builder *builder) instanceVariable(b *constraint.BlueprintComponent, inst constraint.Instruction, v frontend.Variable) frontend.Variable {
+	l, ok := v.(expr.LinearExpression)
+	if !ok {
+		// constant
+		return v
+	}
+	// the wires of the instance are not in the order of the template wires and
+	// two inputs can be the same wire, the terms are sorted and merged by add
+	terms := make([]expr.LinearExpression, len(l))
+	for i, t := range l {
+		terms[i] = expr.NewLinearExpression(int(b.InstanceWire(inst, uint32(t.VID))), t.Coeff)
+	}
+	return builder.add(terms, false, len(l), nil)
+}
+
+// toWire returns the id of a wire equal to v, adding a constraint if v is not
+// a single wire.
+func (builder *builder) toWire(v frontend.Variable) int {
+	l := builder.toVariable(v)
+	if len(l) == 1 && l[0].Coeff == builder.tOne {
+		return l[0].VID
+	}
+	res := builder.newInternalVariable()
+	builder.addR1C(builder.newR1C(l, builder.cstOne(), res))
+	return res[0].VID
+}
@@ -0,0 +1,158 @@
+package r1cs
+
+import (
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend/internal/cse"
+	"github.com/consensys/gnark/frontend/internal/expr"
+)
+
+// addR1C adds the constraint to the system and returns its id. With the common
+// subexpression elimination, a constraint equal to a previous one is not added
+// and the id of the previous constraint is returned.
+func (builder *builder) addR1C(r1c constraint.R1C) int {
+	if builder.cse == nil {
+		return builder.cs.AddR1C(r1c, builder.genericGate)
+	}
+
+	// L⋅R == O, with L and R commuting; and 1⋅R == O with R and O commuting.
+	k := &builder.cseKey
+	k.Reset(cse.OpConstraint)
+	a, b, c := r1c.L, r1c.R, r1c.O
+	if isOne(a) {
+		a, b, c = b, c, nil
+		k.WriteInt(1)
+	} else {
+		k.WriteInt(0)
+	}
+	if compareCompressed(a, b) > 0 {
+		a, b = b, a
+	}
+	writeCompressed(k, a)
+	writeCompressed(k, b)
+	writeCompressed(k, c)
+
+	if r, ok := builder.cse.Get(k); ok {
+		builder.cse.NbConstraintsSaved++
+		return r.ID
+	}
+	cID := builder.cs.AddR1C(r1c, builder.genericGate)
+	builder.cse.Put(k, cse.Result{ID: cID})
+	return cID
+}
+
+// mul returns v1⋅v2 for non constant v1 and v2.
+func (builder *builder) mul(v1, v2 expr.LinearExpression) expr.LinearExpression {
+	n1, c1, ok1 := builder.cseNormalize(v1)
+	n2, c2, ok2 := builder.cseNormalize(v2)
+	if !ok1 || !ok2 {
+		res := builder.newInternalVariable()
+		builder.addR1C(builder.newR1C(v1, v2, res))
+		return res
+	}
+	if compareLinearExpressions(n1, n2) > 0 {
+		n1, n2 = n2, n1
+	}
+	builder.cseKey.Reset(cse.OpMul)
+	builder.cseKey.WriteLinearExpression(n1)
+	builder.cseKey.WriteLinearExpression(n2)
+	s := builder.cs.Mul(c1, c2)
+	if res, ok := builder.cseGet(s, 1); ok {
+		return res
+	}
+	res := builder.newInternalVariable()
+	builder.csePut(s, res)
+	builder.addR1C(builder.newR1C(v1, v2, res))
+	return res
+}
+
+// cseNormalize returns the normalized linear expression l, see
+// [cse.Normalize]. It returns false if the common subexpression elimination is
+// disabled.
+func (builder *builder) cseNormalize(l expr.LinearExpression) (expr.LinearExpression, constraint.Element, bool) {
+	if builder.cse == nil {
+		return nil, constraint.Element{}, false
+	}
+	return cse.Normalize(builder.cs, l)
+}
+
+// cseDiv sets builder.cseKey to the canonical representation of v1/v2 and
+// returns the ratio between v1/v2 and the canonical computation. It returns
+// false if the common subexpression elimination is disabled or if v1 or v2
+// are zero.
+func (builder *builder) cseDiv(op cse.Op, v1, v2 expr.LinearExpression) (constraint.Element, bool) {
+	n1, c1, ok1 := builder.cseNormalize(v1)
+	n2, c2, ok2 := builder.cseNormalize(v2)
+	if !ok1 || !ok2 {
+		return constraint.Element{}, false
+	}
+	builder.cseKey.Reset(op)
+	builder.cseKey.WriteLinearExpression(n1)
+	builder.cseKey.WriteLinearExpression(n2)
+	inv, _ := builder.cs.Inverse(c2)
+	return builder.cs.Mul(c1, inv), true
+}
+
+// cseGet returns the result recorded for builder.cseKey, if any, scaled by s.
+// nbConstraints is the number of constraints saved.
+func (builder *builder) cseGet(s constraint.Element, nbConstraints int) (expr.LinearExpression, bool) {
+	r, ok := builder.cse.Get(&builder.cseKey)
+	if !ok {
+		return nil, false
+	}
+	builder.cse.NbConstraintsSaved += nbConstraints
+	return expr.NewLinearExpression(r.Wires[0], builder.cs.Mul(s, r.Coeff)), true
+}
+
+// csePut records res, equal to s times the canonical computation of
+// builder.cseKey.
+func (builder *builder) csePut(s constraint.Element, res expr.LinearExpression) {
+	inv, _ := builder.cs.Inverse(s)
+	builder.cse.Put(&builder.cseKey, cse.Result{Wires: []int{res[0].VID}, Coeff: inv})
+}
+
+func isOne(l constraint.LinearExpression) bool {
+	return len(l) == 1 && l[0].VID == 0 && l[0].CID == constraint.CoeffIdOne
+}
+
+func writeCompressed(k *cse.Key, l constraint.LinearExpression) {
+	k.WriteInt(len(l))
+	for _, t := range l {
+		k.WriteInt(int(t.CID))
+		k.WriteInt(int(t.VID))
+	}
+}
+
+func compareCompressed(a, b constraint.LinearExpression) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	for i := range a {
+		if a[i].VID != b[i].VID {
+			return int(a[i].VID) - int(b[i].VID)
+		}
+		if a[i].CID != b[i].CID {
+			return int(a[i].CID) - int(b[i].CID)
+		}
+	}
+	return 0
+}
+
+func compareLinearExpressions(a, b expr.LinearExpression) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	for i := range a {
+		if a[i].VID != b[i].VID {
+			return a[i].VID - b[i].VID
+		}
+		for j := range a[i].Coeff {
+			if a[i].Coeff[j] != b[i].Coeff[j] {
+				if a[i].Coeff[j] < b[i].Coeff[j] {
+					return -1
+				}
+				return 1
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,43 @@
+package r1cs
+
+import (
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs"
+	"github.com/consensys/gnark/frontend/internal/expr"
+)
+
+// Parallel builds the sections concurrently in builders forked from builder.
+// See [frontend.Paralleler].
+func (builder *builder) Parallel(sections ...frontend.Section) ([][]frontend.Variable, error) {
+	return cs.Parallel(builder.cs, sections, builder.fork, builder.join)
+}
+
+// fork returns a builder to build a section of the circuit.
+func (builder *builder) fork() (*builder, constraint.ConstraintSystem) {
+	internal, secret, public := builder.cs.GetNbVariables()
+	config := builder.config
+	config.Capacity = 0
+	b := newBuilder(builder.Field(), config)
+	b.nbForkedWires = internal + secret + public
+	return b, b.cs
+}
+
+// join renumbers the wires of the outputs of the section built by b, once its
+// system is joined to the system of builder, and accounts for its common
+// subexpression elimination.
+func (builder *builder) join(b *builder, wire func(int) int, outputs []frontend.Variable) {
+	for j, v := range outputs {
+		if l, ok := v.(expr.LinearExpression); ok {
+			res := make(expr.LinearExpression, len(l))
+			for k := range l {
+				res[k] = expr.NewTerm(wire(l[k].VID), l[k].Coeff)
+			}
+			outputs[j] = res
+		}
+	}
+	if builder.cse != nil {
+		builder.cse.NbConstraintsSaved += b.cse.NbConstraintsSaved
+		builder.cse.NbHintsSaved += b.cse.NbHintsSaved
+	}
+}
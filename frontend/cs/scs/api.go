@@ -29,6 +29,7 @@ import (
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/constraint/solver"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/internal/cse"
 	"github.com/consensys/gnark/frontend/internal/expr"
 	"github.com/consensys/gnark/frontend/schema"
 	"github.com/consensys/gnark/internal/frontendtype"
@@ -182,8 +183,21 @@ func (builder *builder) DivUnchecked(i1, i2 frontend.Variable) frontend.Variable
 		return builder.mulConstant(res.(expr.Term), c1)
 	}
 
-	// res * i2 == i1
+	// res * i2 == i1, that is res = (c1/c2) * (x1/x2) with ti = ci * xi
+	t1, t2 := i1.(expr.Term), i2.(expr.Term)
+	var s constraint.Element
+	c2, found := builder.cseTerm(cse.OpDivUnchecked, t2, t1)
+	if found {
+		s, _ = builder.cs.Inverse(c2)
+		s = builder.cs.Mul(s, t1.Coeff)
+		if res, ok := builder.cseGet(s, 1); ok {
+			return res
+		}
+	}
 	res := builder.newInternalVariable()
+	if found {
+		builder.csePut(s, res)
+	}
 	builder.addPlonkConstraint(sparseR1C{
 		xa: res.VID,
 		xb: i2.(expr.Term).VID,
@@ -213,7 +227,18 @@ func (builder *builder) Inverse(i1 frontend.Variable) frontend.Variable {
 		return builder.cs.ToBigInt(c)
 	}
 	t := i1.(expr.Term)
+	var s constraint.Element
+	c, found := builder.cseTerm(cse.OpInverse, t)
+	if found {
+		s, _ = builder.cs.Inverse(c)
+		if res, ok := builder.cseGet(s, 1); ok {
+			return res
+		}
+	}
 	res := builder.newInternalVariable()
+	if found {
+		builder.csePut(s, res)
+	}
 
 	// res * i1 - 1 == 0
 	constraint := sparseR1C{
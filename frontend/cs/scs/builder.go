@@ -26,6 +26,7 @@ import (
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/debug"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/internal/cse"
 	"github.com/consensys/gnark/frontend/internal/expr"
 	"github.com/consensys/gnark/frontend/schema"
 	"github.com/consensys/gnark/internal/circuitdefer"
@@ -71,9 +72,26 @@ type builder struct {
 	genericGate                constraint.BlueprintID
 	mulGate, addGate, boolGate constraint.BlueprintID
 
+	// custom gates defined with DefineCustomGate
+	customGates []customGate
+	// blueprint of the rows following the custom gates using the next row,
+	// nil until such a gate is defined
+	customGateNextRow *constraint.BlueprintID
+
+	// lookup tables defined with DefineLookupTable and their total number of rows
+	lookupTables []lookupTable
+	nbLookupRows int
+
 	// used to avoid repeated allocations
 	bufL expr.LinearExpression
 	bufH []constraint.LinearExpression
+
+	// components defined with DefineComponent
+	components []component
+
+	// common subexpression elimination, nil if disabled
+	cse    *cse.Table
+	cseKey cse.Key
 }
 
 // initialCapacity has quite some impact on frontend performance, especially on large circuits size
@@ -87,6 +105,9 @@ func newBuilder(field *big.Int, config frontend.CompileConfig) *builder {
 		Store:            kvstore.New(),
 		bufL:             make(expr.LinearExpression, 20),
 	}
+	if config.CommonSubexpressionElimination {
+		b.cse = cse.NewTable()
+	}
 	// init hint buffer.
 	_ = b.hintBuffer(256)
 
@@ -202,6 +223,9 @@ func (builder *builder) addPlonkConstraint(c sparseR1C, debugInfo ...constraint.
 		log := logger.Logger()
 		log.Warn().Msg("adding a plonk constraint with qM set but xa or xb == 0 (wire 0)")
 	}
+	if builder.plonkConstraintExist(&c) {
+		return
+	}
 	QL := builder.cs.AddCoeff(c.qL)
 	QR := builder.cs.AddCoeff(c.qR)
 	QO := builder.cs.AddCoeff(c.qO)
@@ -297,6 +321,8 @@ func (builder *builder) Compile() (constraint.ConstraintSystem, error) {
 		Int("nbConstraints", builder.cs.GetNbConstraints()).
 		Msg("building constraint builder")
 
+	builder.padLookupTables()
+
 	// ensure all inputs and hints are constrained
 	err := builder.cs.CheckUnconstrainedWires()
 	if err != nil {
@@ -306,6 +332,17 @@ func (builder *builder) Compile() (constraint.ConstraintSystem, error) {
 		}
 	}
 
+	if builder.cse != nil {
+		if builder.config.CSEReport != nil {
+			builder.config.CSEReport.NbConstraintsSaved = builder.cse.NbConstraintsSaved
+			builder.config.CSEReport.NbHintsSaved = builder.cse.NbHintsSaved
+		}
+		log.Info().
+			Int("nbConstraintsSaved", builder.cse.NbConstraintsSaved).
+			Int("nbHintsSaved", builder.cse.NbHintsSaved).
+			Msg("common subexpression elimination")
+	}
+
 	return builder.cs, nil
 }
 
@@ -372,10 +409,38 @@ func (builder *builder) newHint(f solver.Hint, id solver.HintID, nbOutputs int,
 		}
 	}
 
+	// hints are deterministic, a call with the same inputs as a previous one
+	// returns the same outputs.
+	dedup := builder.cse != nil && len(inputs) > 0
+	if dedup {
+		k := &builder.cseKey
+		k.Reset(cse.OpHint)
+		k.WriteInt(int(id))
+		k.WriteInt(nbOutputs)
+		for _, in := range hintInputs {
+			writeCompressed(k, in)
+		}
+		if r, ok := builder.cse.Get(k); ok {
+			builder.cse.NbHintsSaved++
+			res := make([]frontend.Variable, len(r.Wires))
+			for i, idx := range r.Wires {
+				res[i] = expr.NewTerm(idx, builder.tOne)
+			}
+			return res, nil
+		}
+	}
+
 	internalVariables, err := builder.cs.AddSolverHint(f, id, hintInputs, nbOutputs)
 	if err != nil {
 		return nil, err
 	}
+	if dedup {
+		wires := make([]int, len(internalVariables))
+		for i, idx := range internalVariables {
+			wires[i] = int(idx)
+		}
+		builder.cse.Put(&builder.cseKey, cse.Result{Wires: wires})
+	}
 
 	// make the variables
 	res := make([]frontend.Variable, len(internalVariables))
@@ -436,13 +501,7 @@ func (builder *builder) splitSum(acc expr.Term, r expr.LinearExpression, k *cons
 	if len(r) == 0 {
 		if k != nil {
 			// we need to return acc + k
-			o, found := builder.addConstraintExist(acc, expr.Term{}, *k)
-			if !found {
-				o = builder.newInternalVariable()
-				builder.addAddGate(acc, expr.Term{}, uint32(o.VID), *k)
-			}
-
-			return o
+			return builder.add(acc, expr.Term{}, *k)
 		}
 		return acc
 	}
@@ -452,11 +511,7 @@ func (builder *builder) splitSum(acc expr.Term, r expr.LinearExpression, k *cons
 	if k != nil {
 		qC = *k
 	}
-	o, found := builder.addConstraintExist(acc, r[0], qC)
-	if !found {
-		o = builder.newInternalVariable()
-		builder.addAddGate(acc, r[0], uint32(o.VID), qC)
-	}
+	o := builder.add(acc, r[0], qC)
 
 	return builder.splitSum(o, r[1:], nil)
 }
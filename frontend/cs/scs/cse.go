@@ -0,0 +1,118 @@
+package scs
+
+import (
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend/internal/cse"
+	"github.com/consensys/gnark/frontend/internal/expr"
+)
+
+// add returns a + b + k, reusing the output of a previous equivalent addition
+// gate if possible.
+func (builder *builder) add(a, b expr.Term, k constraint.Element) expr.Term {
+	s, keyed := builder.cseAdd(a, b, k)
+	if keyed {
+		if o, ok := builder.cseGet(s, 1); ok {
+			return o
+		}
+	}
+	o, found := builder.addConstraintExist(a, b, k)
+	if !found {
+		o = builder.newInternalVariable()
+		builder.addAddGate(a, b, uint32(o.VID), k)
+	}
+	if keyed {
+		builder.csePut(s, o)
+	}
+	return o
+}
+
+// cseAdd sets builder.cseKey to the canonical representation of a + b + k,
+// that is the sum divided by the coefficient of the wire with the largest id,
+// and returns that coefficient. It returns false if the common subexpression
+// elimination is disabled.
+func (builder *builder) cseAdd(a, b expr.Term, k constraint.Element) (constraint.Element, bool) {
+	if builder.cse == nil {
+		return constraint.Element{}, false
+	}
+	if a.VID < b.VID {
+		a, b = b, a
+	}
+	if a.Coeff.IsZero() {
+		return constraint.Element{}, false
+	}
+	inv, _ := builder.cs.Inverse(a.Coeff)
+	builder.cseKey.Reset(cse.OpAdd)
+	builder.cseKey.WriteInt(a.VID)
+	builder.cseKey.WriteTerm(expr.NewTerm(b.VID, builder.cs.Mul(b.Coeff, inv)))
+	builder.cseKey.WriteElement(builder.cs.Mul(k, inv))
+	return a.Coeff, true
+}
+
+// cseTerm sets builder.cseKey to the canonical representation of op(t), that
+// is op applied to the wire of t, and returns the coefficient of t. It returns
+// false if the common subexpression elimination is disabled.
+func (builder *builder) cseTerm(op cse.Op, t ...expr.Term) (constraint.Element, bool) {
+	if builder.cse == nil {
+		return constraint.Element{}, false
+	}
+	builder.cseKey.Reset(op)
+	for i := range t {
+		builder.cseKey.WriteInt(t[i].VID)
+	}
+	return t[0].Coeff, true
+}
+
+// cseGet returns the result recorded for builder.cseKey, if any, scaled by s.
+// nbConstraints is the number of constraints saved.
+func (builder *builder) cseGet(s constraint.Element, nbConstraints int) (expr.Term, bool) {
+	r, ok := builder.cse.Get(&builder.cseKey)
+	if !ok {
+		return expr.Term{}, false
+	}
+	builder.cse.NbConstraintsSaved += nbConstraints
+	return expr.NewTerm(r.Wires[0], builder.cs.Mul(s, r.Coeff)), true
+}
+
+// csePut records res, equal to s times the canonical computation of
+// builder.cseKey.
+func (builder *builder) csePut(s constraint.Element, res expr.Term) {
+	inv, _ := builder.cs.Inverse(s)
+	builder.cse.Put(&builder.cseKey, cse.Result{Wires: []int{res.VID}, Coeff: builder.cs.Mul(res.Coeff, inv)})
+}
+
+// plonkConstraintExist returns true if the constraint c was already added. If
+// not, it records it, assuming the caller adds it right after the call.
+// Constraints involved in a commitment are never merged.
+func (builder *builder) plonkConstraintExist(c *sparseR1C) bool {
+	if builder.cse == nil || c.commitment != constraint.NOT {
+		return false
+	}
+	xa, xb, qL, qR := c.xa, c.xb, c.qL, c.qR
+	if xa > xb {
+		xa, xb, qL, qR = xb, xa, qR, qL
+	}
+	k := &builder.cseKey
+	k.Reset(cse.OpConstraint)
+	k.WriteInt(xa)
+	k.WriteInt(xb)
+	k.WriteInt(c.xc)
+	k.WriteElement(qL)
+	k.WriteElement(qR)
+	k.WriteElement(c.qO)
+	k.WriteElement(c.qM)
+	k.WriteElement(c.qC)
+	if _, ok := builder.cse.Get(k); ok {
+		builder.cse.NbConstraintsSaved++
+		return true
+	}
+	builder.cse.Put(k, cse.Result{})
+	return false
+}
+
+func writeCompressed(k *cse.Key, l constraint.LinearExpression) {
+	k.WriteInt(len(l))
+	for _, t := range l {
+		k.WriteInt(int(t.CID))
+		k.WriteInt(int(t.VID))
+	}
+}
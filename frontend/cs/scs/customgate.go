@@ -0,0 +1,143 @@
+package scs
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/debug"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/internal/expr"
+)
+
+// DefineCustomGate registers the custom gate polynomial as a new blueprint. See
+// [frontend.CustomGater].
+func (builder *builder) DefineCustomGate(gate frontend.CustomGate) (frontend.CustomGateID, error) {
+	terms := make([]constraint.CustomGateTerm, len(gate.Terms))
+	for i, t := range gate.Terms {
+		terms[i].CID = builder.cs.AddCoeff(builder.cs.FromInterface(t.Coeff))
+		for j, e := range t.Exponents() {
+			if e < 0 || e > math.MaxUint8 {
+				return 0, fmt.Errorf("custom gate %q: invalid exponents %v", gate.Name, t.Exponents())
+			}
+			terms[i].Exponents[j] = uint8(e)
+		}
+	}
+	blueprint, err := constraint.NewBlueprintCustomGate(gate.Name, len(builder.customGates), terms)
+	if err != nil {
+		return 0, err
+	}
+	if blueprint.UsesNextRow() && builder.customGateNextRow == nil {
+		id := builder.cs.AddBlueprint(&constraint.BlueprintCustomGateNextRow{})
+		builder.customGateNextRow = &id
+	}
+	builder.customGates = append(builder.customGates, customGate{
+		id:        builder.cs.AddBlueprint(blueprint),
+		blueprint: blueprint,
+		gate:      gate,
+	})
+	return frontend.CustomGateID(len(builder.customGates) - 1), nil
+}
+
+// EvaluateCustomGate returns c such that G(a, b, c, a', b', c') == 0. See
+// [frontend.CustomGater].
+func (builder *builder) EvaluateCustomGate(id frontend.CustomGateID, a, b frontend.Variable, next ...frontend.Variable) frontend.Variable {
+	g := builder.customGate(id)
+	g.gate.CheckNextRow(next)
+	if g.blueprint.OutputTerm() == -1 {
+		panic(fmt.Sprintf("custom gate %q can not be used to compute the output wire", g.blueprint.Name))
+	}
+	ta, tb, tNext := builder.toUnitTerm(a), builder.toUnitTerm(b), builder.toUnitTerms(next)
+	res := builder.newInternalVariable()
+	builder.addCustomGate(g, ta, tb, res, tNext)
+	return res
+}
+
+// AssertCustomGate asserts that G(a, b, c, a', b', c') == 0. See
+// [frontend.CustomGater].
+func (builder *builder) AssertCustomGate(id frontend.CustomGateID, a, b, c frontend.Variable, next ...frontend.Variable) {
+	g := builder.customGate(id)
+	g.gate.CheckNextRow(next)
+	ta, tb, tc, tNext := builder.toUnitTerm(a), builder.toUnitTerm(b), builder.toUnitTerm(c), builder.toUnitTerms(next)
+	cID := builder.addCustomGate(g, ta, tb, tc, tNext)
+	if debug.Debug {
+		debugInfo := builder.newDebugInfo("assertCustomGate", g.blueprint.Name, "(", a, ", ", b, ", ", c, ") == 0")
+		builder.cs.AttachDebugInfo(debugInfo, []int{cID})
+	}
+}
+
+type customGate struct {
+	id        constraint.BlueprintID
+	blueprint *constraint.BlueprintCustomGate
+	gate      frontend.CustomGate
+}
+
+func (builder *builder) customGate(id frontend.CustomGateID) customGate {
+	if id < 0 || int(id) >= len(builder.customGates) {
+		panic(fmt.Sprintf("unknown custom gate %d", id))
+	}
+	return builder.customGates[id]
+}
+
+// addCustomGate adds the row of the custom gate and returns its constraint id.
+// If the gate uses the next row, the row holding next is added right after it.
+func (builder *builder) addCustomGate(g customGate, a, b, c expr.Term, next []expr.Term) int {
+	if len(next) == 0 {
+		return builder.cs.AddSparseR1C(constraint.SparseR1C{
+			XA: uint32(a.VID),
+			XB: uint32(b.VID),
+			XC: uint32(c.VID),
+		}, g.id)
+	}
+	cID := builder.cs.GetNbConstraints()
+	builder.cs.AddInstruction(g.id, []uint32{
+		uint32(a.VID), uint32(b.VID), uint32(c.VID),
+		uint32(next[0].VID), uint32(next[1].VID), uint32(next[2].VID),
+	})
+	builder.cs.AddSparseR1C(constraint.SparseR1C{
+		XA: uint32(next[0].VID),
+		XB: uint32(next[1].VID),
+		XC: uint32(next[2].VID),
+	}, *builder.customGateNextRow)
+	return cID
+}
+
+// toUnitTerms returns the terms with coefficient 1 equal to vs, see
+// [builder.toUnitTerm].
+func (builder *builder) toUnitTerms(vs []frontend.Variable) []expr.Term {
+	res := make([]expr.Term, len(vs))
+	for i := range vs {
+		res[i] = builder.toUnitTerm(vs[i])
+	}
+	return res
+}
+
+// toUnitTerm returns a term with coefficient 1 equal to v. As the custom gates
+// are not linear in their inputs, we can not fold the coefficients of the terms
+// into the gate and need to add a constraint for the constants and the terms
+// with a non-unit coefficient.
+func (builder *builder) toUnitTerm(v frontend.Variable) expr.Term {
+	if c, ok := builder.constantValue(v); ok {
+		res := builder.newInternalVariable()
+		// c == res
+		builder.addPlonkConstraint(sparseR1C{
+			xc: res.VID,
+			qO: builder.tMinusOne,
+			qC: c,
+		})
+		return res
+	}
+	t := v.(expr.Term)
+	if t.Coeff == builder.tOne {
+		return t
+	}
+	res := builder.newInternalVariable()
+	// qL⋅xa == res
+	builder.addPlonkConstraint(sparseR1C{
+		xa: t.VID,
+		xc: res.VID,
+		qL: t.Coeff,
+		qO: builder.tMinusOne,
+	})
+	return res
+}
@@ -0,0 +1,129 @@
+package scs
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/debug"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/internal/expr"
+)
+
+// NativeLookupsEnabled returns true unless the circuit is compiled with
+// [frontend.WithoutNativeLookups]. See [frontend.NativeLookups].
+func (builder *builder) NativeLookupsEnabled() bool {
+	return !builder.config.NoNativeLookups
+}
+
+// DefineLookupTable registers the table as a new lookup blueprint. See
+// [frontend.Lookuper].
+func (builder *builder) DefineLookupTable(name string, rows [][]*big.Int) (frontend.LookupTableID, error) {
+	if len(rows) == 0 {
+		return 0, fmt.Errorf("lookup table %q has no rows", name)
+	}
+	width := len(rows[0])
+	if width < 1 || width > constraint.MaxLookupTableWidth {
+		return 0, fmt.Errorf("lookup table %q has invalid width %d", name, width)
+	}
+	table := make([][constraint.MaxLookupTableWidth]uint32, len(rows))
+	for i, r := range rows {
+		if len(r) != width {
+			return 0, fmt.Errorf("lookup table %q: row %d has %d entries, expected %d", name, i, len(r), width)
+		}
+		for j := range table[i] {
+			table[i][j] = uint32(builder.cs.AddCoeff(builder.cs.FromInterface(r[min(j, width-1)])))
+		}
+	}
+	blueprint, err := constraint.NewBlueprintLookup(name, len(builder.lookupTables), width, table)
+	if err != nil {
+		return 0, err
+	}
+	builder.lookupTables = append(builder.lookupTables, lookupTable{
+		id:         builder.cs.AddBlueprint(blueprint),
+		blueprint:  blueprint,
+		functional: blueprint.IsFunctional(),
+	})
+	builder.nbLookupRows += len(rows)
+	return frontend.LookupTableID(len(builder.lookupTables) - 1), nil
+}
+
+// Lookup returns v such that (keys..., v) is a row of the table. See
+// [frontend.Lookuper].
+func (builder *builder) Lookup(id frontend.LookupTableID, keys ...frontend.Variable) frontend.Variable {
+	t := builder.lookupTable(id)
+	if !t.functional {
+		panic(fmt.Sprintf("lookup table %q can not be used to compute the last column", t.blueprint.Name))
+	}
+	if len(keys) != t.blueprint.Width-1 {
+		panic(fmt.Sprintf("lookup table %q expects %d keys, got %d", t.blueprint.Name, t.blueprint.Width-1, len(keys)))
+	}
+	wires := make([]expr.Term, 0, constraint.MaxLookupTableWidth)
+	for _, k := range keys {
+		wires = append(wires, builder.toUnitTerm(k))
+	}
+	res := builder.newInternalVariable()
+	wires = append(wires, res)
+	builder.addLookup(t, wires)
+	return res
+}
+
+// AssertLookup asserts that (vals...) is a row of the table. See
+// [frontend.Lookuper].
+func (builder *builder) AssertLookup(id frontend.LookupTableID, vals ...frontend.Variable) {
+	t := builder.lookupTable(id)
+	if len(vals) != t.blueprint.Width {
+		panic(fmt.Sprintf("lookup table %q expects %d values, got %d", t.blueprint.Name, t.blueprint.Width, len(vals)))
+	}
+	wires := make([]expr.Term, len(vals), constraint.MaxLookupTableWidth)
+	for i := range vals {
+		wires[i] = builder.toUnitTerm(vals[i])
+	}
+	cID := builder.addLookup(t, wires)
+	if debug.Debug {
+		in := []interface{}{"("}
+		for i := range vals {
+			if i > 0 {
+				in = append(in, ", ")
+			}
+			in = append(in, vals[i])
+		}
+		in = append(in, ") ∈ ", t.blueprint.Name)
+		debugInfo := builder.newDebugInfo("assertLookup", in...)
+		builder.cs.AttachDebugInfo(debugInfo, []int{cID})
+	}
+}
+
+type lookupTable struct {
+	id         constraint.BlueprintID
+	blueprint  *constraint.BlueprintLookup
+	functional bool
+}
+
+func (builder *builder) lookupTable(id frontend.LookupTableID) lookupTable {
+	if id < 0 || int(id) >= len(builder.lookupTables) {
+		panic(fmt.Sprintf("unknown lookup table %d", id))
+	}
+	return builder.lookupTables[id]
+}
+
+// addLookup adds the lookup constraint, the last wire is repeated for the
+// tables of width less than 3 (see [constraint.BlueprintLookup]).
+func (builder *builder) addLookup(t lookupTable, wires []expr.Term) int {
+	for len(wires) < constraint.MaxLookupTableWidth {
+		wires = append(wires, wires[len(wires)-1])
+	}
+	return builder.cs.AddSparseR1C(constraint.SparseR1C{
+		XA: uint32(wires[0].VID),
+		XB: uint32(wires[1].VID),
+		XC: uint32(wires[2].VID),
+	}, t.id)
+}
+
+// padLookupTables adds empty constraints so that the tables of the lookups fit
+// in the evaluation domain of the constraints.
+func (builder *builder) padLookupTables() {
+	for builder.cs.GetNbConstraints()+builder.cs.GetNbPublicVariables() < builder.nbLookupRows {
+		builder.cs.AddSparseR1C(constraint.SparseR1C{}, builder.genericGate)
+	}
+}
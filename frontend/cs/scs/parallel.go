@@ -0,0 +1,40 @@
+package scs
+
+import (
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs"
+	"github.com/consensys/gnark/frontend/internal/expr"
+)
+
+// Parallel builds the sections concurrently in builders forked from builder.
+// See [frontend.Paralleler].
+func (builder *builder) Parallel(sections ...frontend.Section) ([][]frontend.Variable, error) {
+	return cs.Parallel(builder.cs, sections, builder.fork, builder.join)
+}
+
+// fork returns a builder to build a section of the circuit. The lookup tables
+// can't be joined, so the std gadgets use the log-derivative argument in the
+// sections.
+func (builder *builder) fork() (*builder, constraint.ConstraintSystem) {
+	config := builder.config
+	config.Capacity = 0
+	config.NoNativeLookups = true
+	b := newBuilder(builder.Field(), config)
+	return b, b.cs
+}
+
+// join renumbers the wires of the outputs of the section built by b, once its
+// system is joined to the system of builder, and accounts for its common
+// subexpression elimination.
+func (builder *builder) join(b *builder, wire func(int) int, outputs []frontend.Variable) {
+	for j, v := range outputs {
+		if t, ok := v.(expr.Term); ok {
+			outputs[j] = expr.NewTerm(wire(t.VID), t.Coeff)
+		}
+	}
+	if builder.cse != nil {
+		builder.cse.NbConstraintsSaved += b.cse.NbConstraintsSaved
+		builder.cse.NbHintsSaved += b.cse.NbHintsSaved
+	}
+}
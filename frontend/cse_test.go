@@ -0,0 +1,79 @@
+package frontend_test
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/stretchr/testify/require"
+)
+
+type cseCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *cseCircuit) Define(api frontend.API) error {
+	var h [2]frontend.Variable
+	for i := range h {
+		hsh, err := mimc.NewMiMC(api)
+		if err != nil {
+			return err
+		}
+		hsh.Write(c.X)
+		h[i] = hsh.Sum()
+	}
+	api.AssertIsEqual(h[0], h[1])
+
+	a := api.Div(c.Y, c.X)
+	b := api.Div(api.Mul(c.Y, 2), api.Mul(c.X, 2))
+	api.AssertIsEqual(a, b)
+	api.AssertIsEqual(api.Mul(a, c.X), c.Y)
+	api.AssertIsEqual(api.Mul(api.Mul(c.X, c.Y), a), api.Mul(api.Mul(c.Y, c.X), a))
+
+	api.AssertIsEqual(api.Mul(api.Inverse(api.Mul(c.X, 3)), 3), api.Inverse(c.X))
+	api.AssertIsEqual(api.IsZero(c.X), api.IsZero(c.X))
+
+	bits1 := api.ToBinary(c.X, 8)
+	bits2 := api.ToBinary(c.X, 8)
+	api.AssertIsEqual(api.FromBinary(bits1...), api.FromBinary(bits2...))
+	return nil
+}
+
+func TestCommonSubexpressionElimination(t *testing.T) {
+	field := ecc.BN254.ScalarField()
+	for _, tc := range []struct {
+		name    string
+		builder frontend.NewBuilder
+	}{
+		{"r1cs", r1cs.NewBuilder},
+		{"scs", scs.NewBuilder},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			ccs, err := frontend.Compile(field, tc.builder, &cseCircuit{})
+			assert.NoError(err)
+			var report frontend.CSEReport
+			optimized, err := frontend.Compile(field, tc.builder, &cseCircuit{}, frontend.WithCommonSubexpressionElimination(&report))
+			assert.NoError(err)
+
+			assert.Less(optimized.GetNbConstraints(), ccs.GetNbConstraints())
+			assert.Less(optimized.GetNbInternalVariables(), ccs.GetNbInternalVariables())
+			assert.Positive(report.NbConstraintsSaved)
+			assert.Positive(report.NbHintsSaved)
+
+			valid, err := frontend.NewWitness(&cseCircuit{X: 5, Y: 10}, field)
+			assert.NoError(err)
+			_, err = optimized.Solve(valid)
+			assert.NoError(err)
+			invalid, err := frontend.NewWitness(&cseCircuit{X: 300, Y: 10}, field)
+			assert.NoError(err)
+			_, err = optimized.Solve(invalid)
+			assert.Error(err)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package frontend
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// CustomGateTerm is a monomial Coeff⋅a^A⋅b^B⋅c^C⋅a'^NextA⋅b'^NextB⋅c'^NextC
+// of a custom gate polynomial.
+type CustomGateTerm struct {
+	Coeff               *big.Int
+	A, B, C             int
+	NextA, NextB, NextC int
+}
+
+// Exponents returns the exponents of a, b, c, a', b' and c' in the term.
+func (t CustomGateTerm) Exponents() [6]int {
+	return [6]int{t.A, t.B, t.C, t.NextA, t.NextB, t.NextC}
+}
+
+// CustomGate is a custom gate polynomial
+//
+//	G(a, b, c, a', b', c') = Σᵢ Coeffᵢ⋅a^Aᵢ⋅b^Bᵢ⋅c^Cᵢ⋅a'^NextAᵢ⋅b'^NextBᵢ⋅c'^NextCᵢ
+//
+// over the three wires a, b and c of a row of the constraint system and the
+// three wires a', b' and c' of the next row. The total degree of every term
+// must be at most [constraint.MaxCustomGateDegree]. The gates of degree more
+// than 3 enlarge the quotient of the PLONK prover and its SRS, see
+// [constraint.QuotientShardFactor].
+//
+// A gate using a', b' or c' takes two rows of the constraint system, the
+// second one only holding a', b' and c'. Such gates are not supported by the
+// Solidity verifier of PLONK.
+type CustomGate struct {
+	Name  string
+	Terms []CustomGateTerm
+}
+
+// UsesNextRow returns true if some term of the gate depends on the wires a',
+// b' or c' of the next row.
+func (g CustomGate) UsesNextRow() bool {
+	for _, t := range g.Terms {
+		if t.NextA != 0 || t.NextB != 0 || t.NextC != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate returns G(a, b, c, a', b', c') using the generic API, where next
+// holds a', b' and c' if the gate uses the next row (see [CustomGate.UsesNextRow])
+// and is empty otherwise. It can be used as a fallback when the builder does
+// not implement [CustomGater].
+func (g CustomGate) Evaluate(api API, a, b, c Variable, next ...Variable) Variable {
+	g.CheckNextRow(next)
+	wires := []Variable{a, b, c, 0, 0, 0}
+	copy(wires[3:], next)
+	var res Variable = 0
+	for _, t := range g.Terms {
+		var m Variable = t.Coeff
+		for j, exp := range t.Exponents() {
+			for k := 0; k < exp; k++ {
+				m = api.Mul(m, wires[j])
+			}
+		}
+		res = api.Add(res, m)
+	}
+	return res
+}
+
+// CheckNextRow panics if next does not hold the three wires a', b', c' of the
+// next row while the gate uses them, or is not empty while the gate does not.
+func (g CustomGate) CheckNextRow(next []Variable) {
+	if g.UsesNextRow() {
+		if len(next) != 3 {
+			panic(fmt.Sprintf("custom gate %q uses the next row and expects 3 wires a', b', c', got %d", g.Name, len(next)))
+		}
+	} else if len(next) != 0 {
+		panic(fmt.Sprintf("custom gate %q does not use the next row, got %d wires a', b', c'", g.Name, len(next)))
+	}
+}
+
+// CustomGateID identifies a custom gate defined with
+// [CustomGater.DefineCustomGate].
+type CustomGateID int
+
+// CustomGater is implemented by the builders supporting custom gates, i.e. the
+// PLONK builder (where every gate gets a dedicated selector polynomial) and the
+// test engine. A custom gate constraint takes a single row of the constraint
+// system regardless of the number of terms of the gate, or two rows if the gate
+// uses the next row.
+type CustomGater interface {
+	// DefineCustomGate registers the gate polynomial and returns its
+	// identifier. It returns an error wrapping
+	// [constraint.ErrCustomGateDegree] if the degree of a term of the gate
+	// exceeds [constraint.MaxCustomGateDegree].
+	DefineCustomGate(gate CustomGate) (CustomGateID, error)
+
+	// EvaluateCustomGate returns c such that G(a, b, c, a', b', c') == 0,
+	// where next holds a', b' and c' if the gate uses the next row and is
+	// empty otherwise. The gate must be linear in c, i.e. c must only appear
+	// in a single term of the form Coeff⋅c.
+	EvaluateCustomGate(id CustomGateID, a, b Variable, next ...Variable) Variable
+
+	// AssertCustomGate asserts that G(a, b, c, a', b', c') == 0, where next
+	// holds a', b' and c' if the gate uses the next row and is empty
+	// otherwise.
+	AssertCustomGate(id CustomGateID, a, b, c Variable, next ...Variable)
+}
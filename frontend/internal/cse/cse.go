@@ -0,0 +1,127 @@
+// Package cse implements the hash-consing table used by the builders for the
+// common subexpression elimination, see
+// [frontend.WithCommonSubexpressionElimination].
+//
+// The builders compute a canonical [Key] for the computations which allocate
+// new wires (products, inverses, hint calls, ...) and for the constraints,
+// and reuse the result of a previous computation with the same key instead of
+// adding new constraints.
+package cse
+
+import (
+	"encoding/binary"
+
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend/internal/expr"
+)
+
+// Op identifies the kind of computation of a [Key].
+type Op byte
+
+const (
+	OpMul Op = iota
+	OpDiv
+	OpDivUnchecked
+	OpInverse
+	OpAdd
+	OpLinear
+	OpHint
+	OpConstraint
+)
+
+// Key is the canonical representation of a computation.
+type Key struct {
+	buf []byte
+}
+
+// Reset resets the key to represent a new computation of kind op.
+func (k *Key) Reset(op Op) {
+	k.buf = append(k.buf[:0], byte(op))
+}
+
+// WriteInt appends v to the key.
+func (k *Key) WriteInt(v int) {
+	k.buf = binary.LittleEndian.AppendUint64(k.buf, uint64(v))
+}
+
+// WriteElement appends e to the key.
+func (k *Key) WriteElement(e constraint.Element) {
+	for _, w := range e {
+		k.buf = binary.LittleEndian.AppendUint64(k.buf, w)
+	}
+}
+
+// WriteTerm appends t to the key.
+func (k *Key) WriteTerm(t expr.Term) {
+	k.WriteInt(t.VID)
+	k.WriteElement(t.Coeff)
+}
+
+// WriteLinearExpression appends l to the key. l is expected to be sorted by
+// wire id, as the linear expressions built by the R1CS builder.
+func (k *Key) WriteLinearExpression(l expr.LinearExpression) {
+	k.WriteInt(len(l))
+	for _, t := range l {
+		k.WriteTerm(t)
+	}
+}
+
+// Result is the result of a computation.
+type Result struct {
+	// Wires are the wires allocated by the computation.
+	Wires []int
+	// Coeff is the inverse of the coefficient of the first wire in the
+	// canonical representation, i.e. the first wire is equal to the canonical
+	// computation divided by Coeff.
+	Coeff constraint.Element
+	// ID is the constraint or instruction id of the computation.
+	ID int
+}
+
+// Table maps the keys of the computations to their results and counts the
+// reused results.
+type Table struct {
+	results map[string]Result
+
+	// NbConstraintsSaved is the number of constraints which were not added
+	// because of a previous equivalent computation.
+	NbConstraintsSaved int
+	// NbHintsSaved is the number of hint calls replaced by the outputs of a
+	// previous call with the same inputs.
+	NbHintsSaved int
+}
+
+// NewTable returns an empty table.
+func NewTable() *Table {
+	return &Table{results: make(map[string]Result)}
+}
+
+// Get returns the result recorded for k.
+func (t *Table) Get(k *Key) (Result, bool) {
+	r, ok := t.results[string(k.buf)]
+	return r, ok
+}
+
+// Put records the result of the computation k.
+func (t *Table) Put(k *Key, r Result) {
+	t.results[string(k.buf)] = r
+}
+
+// Normalize returns the linear expression l divided by the coefficient of its
+// first term, and that coefficient. It returns false if l is empty or its
+// first coefficient is zero.
+func Normalize(f constraint.Field, l expr.LinearExpression) (expr.LinearExpression, constraint.Element, bool) {
+	if len(l) == 0 || l[0].Coeff.IsZero() {
+		return nil, constraint.Element{}, false
+	}
+	c := l[0].Coeff
+	if f.IsOne(c) {
+		return l, c, true
+	}
+	inv, _ := f.Inverse(c)
+	res := make(expr.LinearExpression, len(l))
+	for i := range l {
+		res[i] = expr.NewTerm(l[i].VID, f.Mul(l[i].Coeff, inv))
+	}
+	return res, c, true
+}
@@ -0,0 +1,47 @@
+package frontend
+
+import "math/big"
+
+// LookupTableID identifies a lookup table defined with
+// [Lookuper.DefineLookupTable].
+type LookupTableID int
+
+// Lookuper is implemented by the builders supporting native lookup
+// arguments, i.e. the PLONK builder (where the tables are fixed polynomials of
+// the setup and the lookups are proven with a log-derivative argument) and the
+// test engine. A lookup takes a single row of the constraint system.
+//
+// The tables of a circuit are concatenated, so the size of the constraint
+// system is at least the total number of rows of the tables.
+//
+// The std gadgets use native lookups unless the circuit is compiled with
+// [WithoutNativeLookups], see [NativeLookups].
+type Lookuper interface {
+	// DefineLookupTable registers a fixed table and returns its identifier.
+	// All the rows must have the same number of entries, between 1 and 3.
+	DefineLookupTable(name string, rows [][]*big.Int) (LookupTableID, error)
+
+	// Lookup returns v such that (keys..., v) is a row of the table. The
+	// number of keys must be the width of the table minus one and the last
+	// column of the table must be a function of the other ones.
+	Lookup(id LookupTableID, keys ...Variable) Variable
+
+	// AssertLookup asserts that (vals...) is a row of the table. The number
+	// of values must be the width of the table.
+	AssertLookup(id LookupTableID, vals ...Variable)
+}
+
+// NativeLookups returns api as a [Lookuper] if the gadgets should use native
+// lookups, that is if the builder supports them and the circuit is not
+// compiled with [WithoutNativeLookups]. The PLONK builder supports them, so
+// the gadgets transparently use native lookups on PLONK circuits.
+func NativeLookups(api API) (Lookuper, bool) {
+	lk, ok := api.(interface {
+		Lookuper
+		NativeLookupsEnabled() bool
+	})
+	if !ok || !lk.NativeLookupsEnabled() {
+		return nil, false
+	}
+	return lk, true
+}
@@ -0,0 +1,44 @@
+package frontend
+
+import "fmt"
+
+// Section is a part of a circuit built by [Parallel]. It can use the variables
+// defined before the call to Parallel and returns the variables it defines
+// which are used after it.
+type Section func(api API) ([]Variable, error)
+
+// Paralleler is implemented by the builders building the sections of a
+// circuit concurrently, i.e. the R1CS and PLONK builders. Not all compilers
+// implement this interface, users should call [Parallel] which falls back to
+// building the sections one after the other.
+type Paralleler interface {
+	// Parallel builds the sections concurrently, each in its own builder, and
+	// appends their constraints to the circuit in order. It returns the
+	// outputs of the sections.
+	//
+	// The variables defined in a section are only valid in the circuit once
+	// returned as its outputs. The callbacks deferred in a section are called
+	// at its end, in the section, so that the gadgets of the std library
+	// (range checks, lookups through log-derivative arguments, ...) are
+	// instantiated once per section, with the log-derivative argument. The
+	// sections can use commitments, but not GKR, custom gates, native lookups
+	// or components, and their boolean variables are not known outside of
+	// them.
+	Parallel(sections ...Section) ([][]Variable, error)
+}
+
+// Parallel builds the sections with api if it implements [Paralleler], and
+// one after the other otherwise. It returns the outputs of the sections.
+func Parallel(api API, sections ...Section) ([][]Variable, error) {
+	if p, ok := api.(Paralleler); ok {
+		return p.Parallel(sections...)
+	}
+	outputs := make([][]Variable, len(sections))
+	for i := range sections {
+		var err error
+		if outputs[i], err = sections[i](api); err != nil {
+			return nil, fmt.Errorf("section %d: %w", i, err)
+		}
+	}
+	return outputs, nil
+}
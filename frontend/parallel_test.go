@@ -0,0 +1,178 @@
+package frontend_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/rangecheck"
+	"github.com/consensys/gnark/test"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+type parallelCircuit struct {
+	X          [4]frontend.Variable
+	Y          frontend.Variable `gnark:",public"`
+	sequential bool
+}
+
+func (c *parallelCircuit) Define(api frontend.API) error {
+	y := api.Mul(c.Y, c.Y)
+	sections := make([]frontend.Section, len(c.X))
+	for i := range c.X {
+		x := c.X[i]
+		sections[i] = func(api frontend.API) ([]frontend.Variable, error) {
+			h, err := mimc.NewMiMC(api)
+			if err != nil {
+				return nil, err
+			}
+			h.Write(x, y)
+			api.Println("section input", x)
+			bits := api.ToBinary(x, 8)
+			return []frontend.Variable{h.Sum(), api.Mul(bits[0], bits[1])}, nil
+		}
+	}
+
+	var outputs [][]frontend.Variable
+	if c.sequential {
+		for i := range sections {
+			res, err := sections[i](api)
+			if err != nil {
+				return err
+			}
+			outputs = append(outputs, res)
+		}
+	} else {
+		var err error
+		if outputs, err = frontend.Parallel(api, sections...); err != nil {
+			return err
+		}
+	}
+
+	acc := frontend.Variable(1)
+	for i := range outputs {
+		api.AssertIsBoolean(outputs[i][1])
+		acc = api.Mul(acc, outputs[i][0])
+	}
+	api.AssertIsDifferent(acc, 0)
+	return nil
+}
+
+var parallelBuilders = []struct {
+	name    string
+	builder frontend.NewBuilder
+	prove   func(*require.Assertions, constraint.ConstraintSystem, witness.Witness)
+}{
+	{"r1cs", r1cs.NewBuilder, proveGroth16},
+	{"scs", scs.NewBuilder, provePlonk},
+}
+
+func parallelAssignment() *parallelCircuit {
+	return &parallelCircuit{X: [4]frontend.Variable{3, 5, 7, 11}, Y: 42}
+}
+
+func TestParallel(t *testing.T) {
+	field := ecc.BN254.ScalarField()
+	for _, tc := range parallelBuilders {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			sequential, err := frontend.Compile(field, tc.builder, &parallelCircuit{sequential: true})
+			assert.NoError(err)
+			ccs, err := frontend.Compile(field, tc.builder, &parallelCircuit{})
+			assert.NoError(err)
+			assert.Equal(sequential.GetNbConstraints(), ccs.GetNbConstraints())
+			assert.Equal(sequential.GetNbInternalVariables(), ccs.GetNbInternalVariables())
+
+			// the logs of the sections are kept
+			var logs bytes.Buffer
+			valid, err := frontend.NewWitness(parallelAssignment(), field)
+			assert.NoError(err)
+			_, err = ccs.Solve(valid, solver.WithLogger(zerolog.New(&logs)))
+			assert.NoError(err)
+			assert.Contains(logs.String(), "parallel_test.go")
+			assert.Contains(logs.String(), "section input 11")
+
+			assignment := parallelAssignment()
+			assignment.X[2] = 300
+			invalid, err := frontend.NewWitness(assignment, field)
+			assert.NoError(err)
+			_, err = ccs.Solve(invalid)
+			assert.Error(err)
+
+			tc.prove(assert, ccs, valid)
+		})
+	}
+
+	// the test engine builds the sections one after the other
+	require.NoError(t, test.IsSolved(&parallelCircuit{}, parallelAssignment(), field))
+}
+
+// rangeCheckCircuit uses in its sections the range checker, which defers a
+// log-derivative argument and thus a commitment, along with commitments to the
+// variables of the parent circuit.
+type rangeCheckCircuit struct {
+	X [4]frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *rangeCheckCircuit) Define(api frontend.API) error {
+	sections := make([]frontend.Section, len(c.X))
+	for i := range c.X {
+		x := c.X[i]
+		sections[i] = func(api frontend.API) ([]frontend.Variable, error) {
+			rangecheck.New(api).Check(x, 8)
+			cmt, err := api.Compiler().(frontend.Committer).Commit(x, c.X[0], c.Y)
+			if err != nil {
+				return nil, err
+			}
+			return []frontend.Variable{api.Mul(x, cmt)}, nil
+		}
+	}
+	outputs, err := frontend.Parallel(api, sections...)
+	if err != nil {
+		return err
+	}
+
+	rangecheck.New(api).Check(c.Y, 16)
+	cmt, err := api.Compiler().(frontend.Committer).Commit(c.X[0], c.X[1], outputs[0][0])
+	if err != nil {
+		return err
+	}
+	api.AssertIsDifferent(api.Add(cmt, outputs[1][0], outputs[2][0], outputs[3][0]), 0)
+	return nil
+}
+
+func TestParallelRangeCheck(t *testing.T) {
+	field := ecc.BN254.ScalarField()
+	for _, tc := range parallelBuilders {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := require.New(t)
+
+			ccs, err := frontend.Compile(field, tc.builder, &rangeCheckCircuit{})
+			assert.NoError(err)
+
+			assignment := &rangeCheckCircuit{X: [4]frontend.Variable{3, 5, 7, 11}, Y: 42}
+			valid, err := frontend.NewWitness(assignment, field)
+			assert.NoError(err)
+			_, err = ccs.Solve(valid)
+			assert.NoError(err)
+
+			assignment.X[2] = 300
+			invalid, err := frontend.NewWitness(assignment, field)
+			assert.NoError(err)
+			_, err = ccs.Solve(invalid)
+			assert.Error(err)
+
+			tc.prove(assert, ccs, valid)
+		})
+	}
+}
@@ -105,6 +105,8 @@ func main() {
 				groth16Dir         = strings.Replace(d.RootPath, "{?}", "groth16", 1)
 				groth16MpcSetupDir = filepath.Join(groth16Dir, "mpcsetup")
 				plonkDir           = strings.Replace(d.RootPath, "{?}", "plonk", 1)
+				plonkMpcSetupDir   = filepath.Join(plonkDir, "mpcsetup")
+				plonkFriDir        = strings.Replace(d.RootPath, "{?}", "plonkfri", 1)
 			)
 
 			if err := os.MkdirAll(groth16Dir, 0700); err != nil {
@@ -154,6 +156,9 @@ func main() {
 			if err := os.MkdirAll(plonkDir, 0700); err != nil {
 				panic(err)
 			}
+			if err := os.MkdirAll(plonkFriDir, 0700); err != nil {
+				panic(err)
+			}
 
 			entries = []bavard.Entry{
 				{File: filepath.Join(groth16Dir, "verify.go"), Templates: []string{"groth16/groth16.verify.go.tmpl", importCurve}},
@@ -161,6 +166,8 @@ func main() {
 				{File: filepath.Join(groth16Dir, "setup.go"), Templates: []string{"groth16/groth16.setup.go.tmpl", importCurve}},
 				{File: filepath.Join(groth16Dir, "marshal.go"), Templates: []string{"groth16/groth16.marshal.go.tmpl", importCurve}},
 				{File: filepath.Join(groth16Dir, "marshal_test.go"), Templates: []string{"groth16/tests/groth16.marshal.go.tmpl", importCurve}},
+				{File: filepath.Join(groth16Dir, "distributed.go"), Templates: []string{"groth16/groth16.distributed.go.tmpl", importCurve}},
+				{File: filepath.Join(groth16Dir, "distributed_test.go"), Templates: []string{"groth16/tests/groth16.distributed.go.tmpl", importCurve}},
 			}
 			if err := bgen.Generate(d, "groth16", "./template/zkpschemes/", entries...); err != nil {
 				panic(err) // TODO handle
@@ -201,6 +208,30 @@ func main() {
 				panic(err)
 			}
 
+			// plonk mpcsetup
+			entries = []bavard.Entry{
+				{File: filepath.Join(plonkMpcSetupDir, "marshal.go"), Templates: []string{"plonk/mpcsetup/marshal.go.tmpl", importCurve}},
+				{File: filepath.Join(plonkMpcSetupDir, "srs.go"), Templates: []string{"plonk/mpcsetup/srs.go.tmpl", importCurve}},
+				{File: filepath.Join(plonkMpcSetupDir, "srs_test.go"), Templates: []string{"plonk/mpcsetup/srs_test.go.tmpl", importCurve}},
+				{File: filepath.Join(plonkMpcSetupDir, "utils.go"), Templates: []string{"plonk/mpcsetup/utils.go.tmpl", importCurve}},
+			}
+			if err := bgen.Generate(d, "mpcsetup", "./template/zkpschemes/", entries...); err != nil {
+				panic(err)
+			}
+
+			// plonk with FRI
+			entries = []bavard.Entry{
+				{File: filepath.Join(plonkFriDir, "verify.go"), Templates: []string{"plonkfri/plonk.verify.go.tmpl", importCurve}},
+				{File: filepath.Join(plonkFriDir, "prove.go"), Templates: []string{"plonkfri/plonk.prove.go.tmpl", importCurve}},
+				{File: filepath.Join(plonkFriDir, "setup.go"), Templates: []string{"plonkfri/plonk.setup.go.tmpl", importCurve}},
+				{File: filepath.Join(plonkFriDir, "fri.go"), Templates: []string{"plonkfri/plonk.fri.go.tmpl", importCurve}},
+				{File: filepath.Join(plonkFriDir, "marshal.go"), Templates: []string{"plonkfri/plonk.marshal.go.tmpl", importCurve}},
+				{File: filepath.Join(plonkFriDir, "marshal_test.go"), Templates: []string{"plonkfri/tests/marshal.go.tmpl", importCurve}},
+			}
+			if err := bgen.Generate(d, "plonkfri", "./template/zkpschemes/", entries...); err != nil {
+				panic(err)
+			}
+
 		}(d)
 
 	}
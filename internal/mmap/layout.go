@@ -0,0 +1,200 @@
+package mmap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"unsafe"
+)
+
+// The files written by Writer start with a header:
+//
+//	magic [8]byte | version uint32 | len(kind) uint32 | kind | marker uint64
+//
+// where the integers are little endian, excepted the marker which is in the
+// byte order of the platform which wrote the file. The header is followed by
+// the values encoded by the caller, in which the vectors are written as
+//
+//	len uint64 | size of an element uint64 | padding | elements
+//
+// with the padding aligning the elements on Alignment bytes from the start of
+// the file, so that they are used in place once the file is mapped in memory.
+const (
+	magic = "gnarkmap"
+
+	// Version of the layout written by Writer.
+	Version = 1
+
+	// Alignment of the vectors in the file.
+	Alignment = 64
+
+	marker uint64 = 0xdeadbeef
+)
+
+var (
+	errMagic    = errors.New("not a memory mappable file")
+	errPlatform = errors.New("file was written on an incompatible platform")
+)
+
+// Writer writes a file in the layout read by Reader. The vectors are written
+// raw, with the memory representation of the platform.
+type Writer struct {
+	w io.Writer
+	n int64
+}
+
+// NewWriter writes the header of a file holding an object of the given kind
+// and returns a Writer for its content.
+func NewWriter(w io.Writer, kind string) (*Writer, error) {
+	res := &Writer{w: w}
+	header := make([]byte, 0, len(magic)+16+len(kind))
+	header = append(header, magic...)
+	header = binary.LittleEndian.AppendUint32(header, Version)
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(kind)))
+	header = append(header, kind...)
+	header = binary.NativeEndian.AppendUint64(header, marker)
+	if _, err := res.Write(header); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Write implements io.Writer, for the values encoded between the vectors.
+func (w *Writer) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// BytesWritten returns the number of bytes written, header included.
+func (w *Writer) BytesWritten() int64 {
+	return w.n
+}
+
+// WriteVector writes the length of v and its elements, aligned. T must not
+// contain pointers.
+func WriteVector[T any](w *Writer, v []T) error {
+	var zero T
+	size := int(unsafe.Sizeof(zero))
+
+	var buf [16 + Alignment]byte
+	binary.LittleEndian.PutUint64(buf[:8], uint64(len(v)))
+	binary.LittleEndian.PutUint64(buf[8:16], uint64(size))
+	n := 16 + padding(w.n+16)
+	if _, err := w.Write(buf[:n]); err != nil {
+		return err
+	}
+	if len(v) == 0 {
+		return nil
+	}
+	_, err := w.Write(unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(v))), len(v)*size))
+	return err
+}
+
+// Reader reads a file written by Writer from its content, typically mapped in
+// memory.
+type Reader struct {
+	data []byte
+	off  int
+}
+
+// NewReader checks the header of data, which must hold an object of the given
+// kind, and returns a Reader for its content.
+func NewReader(data []byte, kind string) (*Reader, error) {
+	r := &Reader{data: data}
+	k, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if k != kind {
+		return nil, fmt.Errorf("file holds a %s, not a %s", k, kind)
+	}
+	return r, nil
+}
+
+// Read implements io.Reader, for the values encoded between the vectors.
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.off >= len(r.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data[r.off:])
+	r.off += n
+	return n, nil
+}
+
+// ReadVector reads a vector written by WriteVector. The vector points into the
+// data of r when it is correctly aligned in memory, and is copied otherwise.
+func ReadVector[T any](r *Reader) ([]T, error) {
+	var zero T
+	size := int(unsafe.Sizeof(zero))
+
+	var buf [16]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, err
+	}
+	n := binary.LittleEndian.Uint64(buf[:8])
+	if s := binary.LittleEndian.Uint64(buf[8:]); s != uint64(size) {
+		return nil, fmt.Errorf("elements of %d bytes, expected %d", s, size)
+	}
+	r.off += padding(int64(r.off))
+	if r.off > len(r.data) || n > uint64(len(r.data)-r.off)/uint64(max(size, 1)) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if n == 0 {
+		return []T{}, nil
+	}
+	b := r.data[r.off : r.off+int(n)*size]
+	r.off += len(b)
+
+	if uintptr(unsafe.Pointer(&b[0]))%unsafe.Alignof(zero) != 0 {
+		res := make([]T, n)
+		copy(unsafe.Slice((*byte)(unsafe.Pointer(&res[0])), len(b)), b)
+		return res, nil
+	}
+	return unsafe.Slice((*T)(unsafe.Pointer(&b[0])), n), nil
+}
+
+// ReadKind returns the kind of the object held by the file at path.
+func ReadKind(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return readHeader(f)
+}
+
+func readHeader(r io.Reader) (string, error) {
+	var buf [len(magic) + 8]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return "", errMagic
+	}
+	if string(buf[:len(magic)]) != magic {
+		return "", errMagic
+	}
+	if v := binary.LittleEndian.Uint32(buf[len(magic):]); v != Version {
+		return "", fmt.Errorf("unsupported layout version %d", v)
+	}
+	n := binary.LittleEndian.Uint32(buf[len(magic)+4:])
+	if n > 256 {
+		return "", errMagic
+	}
+	kind := make([]byte, n)
+	if _, err := io.ReadFull(r, kind); err != nil {
+		return "", err
+	}
+	if _, err := io.ReadFull(r, buf[:8]); err != nil {
+		return "", err
+	}
+	if binary.NativeEndian.Uint64(buf[:8]) != marker {
+		return "", errPlatform
+	}
+	return string(kind), nil
+}
+
+// padding returns the number of bytes to add after offset to align it.
+func padding(offset int64) int {
+	return int((Alignment - offset%Alignment) % Alignment)
+}
@@ -0,0 +1,113 @@
+package mmap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayout(t *testing.T) {
+	assert := require.New(t)
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "test")
+	assert.NoError(err)
+	_, err = w.Write([]byte{1, 2, 3})
+	assert.NoError(err)
+	assert.NoError(WriteVector(w, []uint64{4, 5, 6}))
+	assert.NoError(WriteVector(w, []bool{}))
+	assert.NoError(WriteVector(w, []bool{true, false, true}))
+	assert.NoError(WriteVector(w, [][2]uint32{{7, 8}}))
+	assert.Equal(int64(buf.Len()), w.BytesWritten())
+
+	path := filepath.Join(t.TempDir(), "file")
+	assert.NoError(os.WriteFile(path, buf.Bytes(), 0600))
+	kind, err := ReadKind(path)
+	assert.NoError(err)
+	assert.Equal("test", kind)
+
+	f, err := Open(path)
+	assert.NoError(err)
+	defer f.Close()
+	data := f.Bytes()
+
+	_, err = NewReader(data, "other")
+	assert.Error(err)
+	r, err := NewReader(data, "test")
+	assert.NoError(err)
+	var b [3]byte
+	_, err = r.Read(b[:])
+	assert.NoError(err)
+	assert.Equal([3]byte{1, 2, 3}, b)
+
+	u, err := ReadVector[uint64](r)
+	assert.NoError(err)
+	assert.Equal([]uint64{4, 5, 6}, u)
+	// the vectors are used in place
+	assert.Equal(uintptr(unsafe.Pointer(&data[0]))+uintptr(r.off-24), uintptr(unsafe.Pointer(&u[0])))
+	assert.Zero(uintptr(unsafe.Pointer(&u[0])) % Alignment)
+
+	e, err := ReadVector[bool](r)
+	assert.NoError(err)
+	assert.Empty(e)
+	v, err := ReadVector[bool](r)
+	assert.NoError(err)
+	assert.Equal([]bool{true, false, true}, v)
+
+	// the size of the elements is checked
+	_, err = ReadVector[uint32](r)
+	assert.Error(err)
+	r.off -= 16
+	x, err := ReadVector[[2]uint32](r)
+	assert.NoError(err)
+	assert.Equal([][2]uint32{{7, 8}}, x)
+
+	_, err = ReadVector[uint64](r)
+	assert.Error(err)
+
+	// truncated vectors are rejected
+	r, err = NewReader(data[:len(data)-1], "test")
+	assert.NoError(err)
+	_, err = r.Read(b[:])
+	assert.NoError(err)
+	_, err = ReadVector[uint64](r)
+	assert.NoError(err)
+	_, err = ReadVector[bool](r)
+	assert.NoError(err)
+	_, err = ReadVector[bool](r)
+	assert.NoError(err)
+	_, err = ReadVector[[2]uint32](r)
+	assert.Error(err)
+}
+
+func TestLayoutHeader(t *testing.T) {
+	assert := require.New(t)
+
+	var buf bytes.Buffer
+	_, err := NewWriter(&buf, "test")
+	assert.NoError(err)
+	header := buf.Bytes()
+
+	_, err = NewReader(header, "test")
+	assert.NoError(err)
+
+	_, err = NewReader([]byte("not a mapped file"), "test")
+	assert.Error(err)
+
+	// unknown version
+	other := bytes.Clone(header)
+	binary.LittleEndian.PutUint32(other[len(magic):], Version+1)
+	_, err = NewReader(other, "test")
+	assert.ErrorContains(err, "version")
+
+	// other byte order
+	other = bytes.Clone(header)
+	binary.NativeEndian.PutUint64(other[len(other)-8:], 0xefbeadde00000000)
+	_, err = NewReader(other, "test")
+	assert.ErrorIs(err, errPlatform)
+}
@@ -0,0 +1,154 @@
+// Package mmap maps files in memory, and implements an arena allocating
+// vectors in memory mapped temporary files once a memory budget is exhausted.
+// It also defines a versioned file layout whose vectors are used in place once
+// the file is mapped, see Writer and Reader.
+//
+// On systems without mmap, the files are read in memory and the arena
+// allocates on the heap.
+package mmap
+
+import (
+	"errors"
+	"sync"
+	"unsafe"
+)
+
+// File is a file mapped in memory.
+type File struct {
+	data  []byte
+	unmap func() error
+}
+
+// Bytes returns the content of the file. It must not be used after Close.
+func (f *File) Bytes() []byte {
+	return f.data
+}
+
+// Close unmaps the file.
+func (f *File) Close() error {
+	if f.unmap == nil {
+		return nil
+	}
+	err := f.unmap()
+	f.data, f.unmap = nil, nil
+	return err
+}
+
+// Arena allocates vectors on the heap while their total size is below a
+// budget, and in memory mapped temporary files above it, so that the operating
+// system can write them back to the storage instead of running out of memory.
+//
+// A nil *Arena allocates on the heap.
+type Arena struct {
+	budget uint64
+	dir    string
+
+	lock   sync.Mutex
+	heap   uint64                  // number of bytes allocated on the heap
+	allocs map[uintptr]*allocation // vectors allocated and not freed, by address
+}
+
+// allocation is a vector allocated by an arena, in a file or on the heap if
+// file is nil.
+type allocation struct {
+	size uint64
+	file *File
+}
+
+// ErrNotAllocated is returned by Free when the vector was not allocated by the
+// arena, or was already freed.
+var ErrNotAllocated = errors.New("vector not allocated by the arena")
+
+// NewArena returns an arena allocating at most budget bytes on the heap, and
+// creating its temporary files in dir (os.TempDir() if empty).
+func NewArena(budget uint64, dir string) *Arena {
+	return &Arena{
+		budget: budget,
+		dir:    dir,
+		allocs: make(map[uintptr]*allocation),
+	}
+}
+
+// Alloc returns a zeroed vector of n elements of type T, with capacity n. T
+// must not contain pointers, as the garbage collector does not scan the memory
+// mapped files.
+func Alloc[T any](a *Arena, n int) ([]T, error) {
+	if a == nil || n == 0 {
+		return make([]T, n), nil
+	}
+	var zero T
+	size := uint64(n) * uint64(unsafe.Sizeof(zero))
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.allocs == nil {
+		return nil, errors.New("arena is closed")
+	}
+	if a.heap+size <= a.budget {
+		v := make([]T, n)
+		start := uintptr(unsafe.Pointer(&v[0]))
+		// the vectors dropped without being freed were collected, and their
+		// memory may be reused by v
+		for key, alloc := range a.allocs {
+			if alloc.file == nil && key < start+uintptr(size) && start < key+uintptr(alloc.size) {
+				delete(a.allocs, key)
+				a.heap -= alloc.size
+			}
+		}
+		a.heap += size
+		a.allocs[start] = &allocation{size: size}
+		return v, nil
+	}
+	f, err := Create(a.dir, int(size))
+	if err != nil {
+		return nil, err
+	}
+	data := f.Bytes()
+	a.allocs[uintptr(unsafe.Pointer(&data[0]))] = &allocation{size: size, file: f}
+	return unsafe.Slice((*T)(unsafe.Pointer(&data[0])), n), nil
+}
+
+// Free releases the vector returned by Alloc on a which v is a part of, even if
+// v was resliced from the front. The vector must not be used anymore. Free
+// returns ErrNotAllocated if v is not part of such a vector, and is a no-op
+// if cap(v) is 0.
+func Free[T any](a *Arena, v []T) error {
+	if a == nil || cap(v) == 0 {
+		return nil
+	}
+	p := uintptr(unsafe.Pointer(unsafe.SliceData(v)))
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for key, alloc := range a.allocs {
+		if key <= p && p < key+uintptr(alloc.size) {
+			delete(a.allocs, key)
+			if alloc.file != nil {
+				return alloc.file.Close()
+			}
+			// the vector is on the heap, the garbage collector releases it
+			a.heap -= alloc.size
+			return nil
+		}
+	}
+	return ErrNotAllocated
+}
+
+// Close releases the files of the arena, the vectors allocated by the arena
+// must not be used anymore. Close is a no-op on a nil arena.
+func (a *Arena) Close() error {
+	if a == nil {
+		return nil
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	var err error
+	for _, alloc := range a.allocs {
+		if alloc.file != nil {
+			err = errors.Join(err, alloc.file.Close())
+		}
+	}
+	a.allocs = nil
+	a.heap = 0
+	return err
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package mmap
+
+import "os"
+
+// Create returns a zeroed buffer of size bytes, as the memory mapped files are
+// not supported on this system.
+func Create(dir string, size int) (*File, error) {
+	return &File{data: make([]byte, size)}, nil
+}
+
+// Open reads the file at path in memory, as the memory mapped files are not
+// supported on this system.
+func Open(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &File{data: data}, nil
+}
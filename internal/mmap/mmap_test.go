@@ -0,0 +1,136 @@
+package mmap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreate(t *testing.T) {
+	assert := require.New(t)
+	dir := t.TempDir()
+
+	f, err := Create(dir, 1<<16)
+	assert.NoError(err)
+	data := f.Bytes()
+	assert.Len(data, 1<<16)
+	for i := range data {
+		assert.Zero(data[i])
+		data[i] = byte(i)
+	}
+	assert.Equal(byte(42), data[42])
+
+	// the file is removed from the directory right away
+	entries, err := os.ReadDir(dir)
+	assert.NoError(err)
+	assert.Empty(entries)
+
+	assert.NoError(f.Close())
+	assert.Nil(f.Bytes())
+	assert.NoError(f.Close())
+}
+
+func TestOpen(t *testing.T) {
+	assert := require.New(t)
+	path := filepath.Join(t.TempDir(), "file")
+	assert.NoError(os.WriteFile(path, []byte("memory mapped"), 0600))
+
+	f, err := Open(path)
+	assert.NoError(err)
+	assert.Equal("memory mapped", string(f.Bytes()))
+	assert.NoError(f.Close())
+
+	_, err = Open(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(err)
+}
+
+func TestArena(t *testing.T) {
+	assert := require.New(t)
+
+	a := NewArena(3*8*10, t.TempDir())
+	v := make([][]uint64, 4)
+	for i := range v {
+		var err error
+		v[i], err = Alloc[uint64](a, 10)
+		assert.NoError(err)
+		assert.Len(v[i], 10)
+		assert.Equal(10, cap(v[i]))
+		for j := range v[i] {
+			assert.Zero(v[i][j])
+			v[i][j] = uint64(i*10 + j)
+		}
+	}
+	// the last vector exceeds the budget
+	assert.Equal(1, nbFiles(a))
+	assert.Equal(uint64(3*8*10), a.heap)
+
+	for i := range v {
+		for j := range v[i] {
+			assert.Equal(uint64(i*10+j), v[i][j])
+		}
+	}
+
+	assert.NoError(Free(a, v[3]))
+	assert.Zero(nbFiles(a))
+	assert.NoError(Free(a, v[0]))
+	assert.Equal(uint64(2*8*10), a.heap)
+
+	// the released budget is available again
+	w, err := Alloc[uint64](a, 10)
+	assert.NoError(err)
+	assert.Zero(nbFiles(a))
+	w, err = Alloc[uint64](a, 10)
+	assert.NoError(err)
+	assert.Equal(1, nbFiles(a))
+	w[9] = 1
+
+	assert.NoError(a.Close())
+	_, err = Alloc[uint64](a, 10)
+	assert.Error(err)
+
+	// a nil arena allocates on the heap
+	var nilArena *Arena
+	w, err = Alloc[uint64](nilArena, 10)
+	assert.NoError(err)
+	assert.Len(w, 10)
+	assert.NoError(Free(nilArena, w))
+	assert.NoError(nilArena.Close())
+}
+
+func TestArenaFreeResliced(t *testing.T) {
+	assert := require.New(t)
+
+	a := NewArena(8*10, t.TempDir())
+	onHeap, err := Alloc[uint64](a, 10)
+	assert.NoError(err)
+	inFile, err := Alloc[uint64](a, 10)
+	assert.NoError(err)
+	assert.Equal(1, nbFiles(a))
+
+	// the vectors are found from any of their parts
+	assert.NoError(Free(a, inFile[3:5]))
+	assert.Zero(nbFiles(a))
+	assert.NoError(Free(a, onHeap[9:]))
+	assert.Zero(a.heap)
+
+	// they can't be freed twice, and the vectors of other allocators are
+	// rejected
+	assert.ErrorIs(Free(a, onHeap), ErrNotAllocated)
+	assert.ErrorIs(Free(a, inFile), ErrNotAllocated)
+	assert.ErrorIs(Free(a, make([]uint64, 10)), ErrNotAllocated)
+	assert.Zero(a.heap)
+
+	assert.NoError(a.Close())
+}
+
+func nbFiles(a *Arena) int {
+	n := 0
+	for _, alloc := range a.allocs {
+		if alloc.file != nil {
+			n++
+		}
+	}
+	return n
+}
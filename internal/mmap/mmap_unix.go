@@ -0,0 +1,59 @@
+//go:build unix
+
+package mmap
+
+import (
+	"os"
+	"syscall"
+)
+
+// Create creates a temporary file of size bytes in dir (os.TempDir() if empty)
+// and maps it in memory for reading and writing. The file is removed from dir
+// right away, its storage is released when it is closed or when the process
+// exits.
+func Create(dir string, size int) (*File, error) {
+	f, err := os.CreateTemp(dir, "gnark-*.mmap")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		return &File{}, nil
+	}
+	if err := f.Truncate(int64(size)); err != nil {
+		return nil, err
+	}
+	return mmap(f, size, syscall.PROT_READ|syscall.PROT_WRITE)
+}
+
+// Open maps the file at path in memory, read only.
+func Open(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() == 0 {
+		return &File{}, nil
+	}
+	return mmap(f, int(info.Size()), syscall.PROT_READ)
+}
+
+// mmap maps the first size bytes of f, the mapping stays valid once f is closed
+func mmap(f *os.File, size int, prot int) (*File, error) {
+	data, err := syscall.Mmap(int(f.Fd()), 0, size, prot, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	return &File{
+		data:  data,
+		unmap: func() error { return syscall.Munmap(data) },
+	}, nil
+}
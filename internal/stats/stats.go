@@ -45,7 +45,7 @@ func init() {
 
 func NewGlobalStats() *globalStats {
 	return &globalStats{
-		Stats: make(map[string][backend.PLONK + 1][nbCurves + 1]snippetStats),
+		Stats: make(map[string][backend.PLONKFRI + 1][nbCurves + 1]snippetStats),
 	}
 }
 
@@ -75,17 +75,21 @@ func (s *globalStats) Load(path string) error {
 
 func NewSnippetStats(curve ecc.ID, backendID backend.ID, circuit frontend.Circuit) (snippetStats, error) {
 	var newCompiler frontend.NewBuilder
+	opts := []frontend.CompileOption{frontend.IgnoreUnconstrainedInputs()}
 
 	switch backendID {
 	case backend.GROTH16:
 		newCompiler = r1cs.NewBuilder
 	case backend.PLONK:
 		newCompiler = scs.NewBuilder
+	case backend.PLONKFRI:
+		newCompiler = scs.NewBuilder
+		opts = append(opts, frontend.WithoutNativeLookups())
 	default:
 		panic("not implemented")
 	}
 
-	ccs, err := frontend.Compile(curve.ScalarField(), newCompiler, circuit, frontend.IgnoreUnconstrainedInputs())
+	ccs, err := frontend.Compile(curve.ScalarField(), newCompiler, circuit, opts...)
 	if err != nil {
 		return snippetStats{}, err
 	}
@@ -112,7 +116,7 @@ type Circuit struct {
 
 type globalStats struct {
 	sync.RWMutex
-	Stats map[string][backend.PLONK + 1][nbCurves + 1]snippetStats
+	Stats map[string][backend.PLONKFRI + 1][nbCurves + 1]snippetStats
 }
 
 type snippetStats struct {
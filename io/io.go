@@ -47,3 +47,13 @@ type BinaryDumper interface {
 	WriteDump(w io.Writer) error
 	ReadDump(r io.Reader) error
 }
+
+// Mapper is the interface that wraps the WriteMmapTo and ReadMmap methods.
+//
+// WriteMmapTo writes the object to w in a layout where its large slices are
+// stored raw and aligned. ReadMmap reads the object from data, typically a file
+// mapped in memory, without copying those slices: they point into data.
+type Mapper interface {
+	WriteMmapTo(w io.Writer) (int64, error)
+	ReadMmap(data []byte) error
+}
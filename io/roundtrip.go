@@ -90,3 +90,26 @@ func DumpRoundTripCheck(from any, to func() any) error {
 	}
 	return nil
 }
+
+// MmapRoundTripCheck is a helper to check that WriteMmapTo and ReadMmap are
+// consistent, see Mapper.
+func MmapRoundTripCheck(from any, to func() any) error {
+	var buf bytes.Buffer
+
+	written, err := from.(Mapper).WriteMmapTo(&buf)
+	if err != nil {
+		return err
+	}
+	if written != int64(buf.Len()) {
+		return errors.New("bytes written don't match")
+	}
+
+	r := to().(Mapper)
+	if err := r.ReadMmap(buf.Bytes()); err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(from, r) {
+		return errors.New("reconstructed object don't match original (ReadMmap)")
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+// Package bytes implements variable-length byte strings in circuit.
+//
+// A byte string [Bytes] has a fixed capacity (the length of the underlying
+// slice), which is known at circuit compile time, and a variable length, which
+// is part of the witness. The bytes after the length are ignored by all the
+// operations.
+//
+// Most of the operations compute their results non-deterministically using
+// hints and then check the results using a polynomial identity: we interpret
+// the first n bytes of a string s as the polynomial s(X) = Σ_{i<n} s_i X^i and
+// evaluate it at a random point obtained from [multicommit]. For example, for
+// concatenation we check that out(r) = a(r) + r^{len(a)} b(r). By the
+// Schwartz-Zippel lemma the check fails with overwhelming probability if the
+// result is incorrect.
+package bytes
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/std/multicommit"
+	"github.com/consensys/gnark/std/rangecheck"
+)
+
+// Bytes is a variable-length byte string. The capacity of the string is
+// len(Data) and the actual length is given by Length. The bytes after Length
+// are ignored.
+type Bytes struct {
+	Data   []uints.U8
+	Length frontend.Variable
+}
+
+// Cap returns the capacity of the byte string.
+func (b Bytes) Cap() int {
+	return len(b.Data)
+}
+
+// Placeholder returns a byte string with the given capacity to be used in the
+// circuit definition.
+func Placeholder(capacity int) Bytes {
+	return Bytes{Data: make([]uints.U8, capacity)}
+}
+
+// Assignment returns the witness assignment for the byte string v in a string
+// of given capacity. It panics if the capacity is smaller than len(v).
+func Assignment(v []byte, capacity int) Bytes {
+	if len(v) > capacity {
+		panic("byte string longer than capacity")
+	}
+	data := make([]byte, capacity)
+	copy(data, v)
+	return Bytes{Data: uints.NewU8Array(data), Length: len(v)}
+}
+
+// API implements operations on variable-length byte strings.
+type API struct {
+	api      frontend.API
+	rchecker frontend.Rangechecker
+}
+
+// New returns a new [API] for operating on variable-length byte strings.
+func New(api frontend.API) (*API, error) {
+	return &API{api: api, rchecker: rangecheck.New(api)}, nil
+}
+
+// Constant returns the constant byte string v.
+func (b *API) Constant(v []byte) Bytes {
+	return Bytes{Data: uints.NewU8Array(v), Length: len(v)}
+}
+
+// Pack packs the bytes of a into native field elements. Every element contains
+// at most (FieldBitLen-1)/8 bytes in big-endian order and the bytes after the
+// length of a are set to zero. The length is not included in the packing, so
+// for injectivity the caller has to additionally use the length.
+//
+// Pack asserts that every byte of a is in range [0, 255].
+func (b *API) Pack(a Bytes) []frontend.Variable {
+	m, _ := b.mask(a.Length, a.Cap())
+	nbBytes := (b.api.Compiler().FieldBitLen() - 1) / 8
+	res := make([]frontend.Variable, 0, (a.Cap()+nbBytes-1)/nbBytes)
+	for i := 0; i < a.Cap(); i += nbBytes {
+		var v frontend.Variable = 0
+		for j := i; j < min(i+nbBytes, a.Cap()); j++ {
+			b.rchecker.Check(a.Data[j].Val, 8)
+			v = b.api.Add(b.api.Mul(v, 256), b.api.Mul(m[j], a.Data[j].Val))
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
+// mask returns the mask m of length n where m[i] = 1 iff i < length and the
+// indicator eq of length n+1 where eq[i] = 1 iff i == length. It asserts that
+// 0 <= length <= n.
+func (b *API) mask(length frontend.Variable, n int) (m, eq []frontend.Variable) {
+	api := b.api
+	m = make([]frontend.Variable, n)
+	eq = make([]frontend.Variable, n+1)
+	if lc, ok := api.Compiler().ConstantValue(length); ok {
+		if !lc.IsInt64() || lc.Int64() < 0 || lc.Int64() > int64(n) {
+			panic("constant length out of range")
+		}
+		for i := range eq {
+			eq[i] = 0
+			if i < n {
+				m[i] = 0
+				if int64(i) < lc.Int64() {
+					m[i] = 1
+				}
+			}
+		}
+		eq[lc.Int64()] = 1
+		return m, eq
+	}
+	var seen frontend.Variable = 0
+	for i := range eq {
+		eq[i] = api.IsZero(api.Sub(i, length))
+		seen = api.Add(seen, eq[i])
+		if i < n {
+			m[i] = api.Sub(1, seen)
+		}
+	}
+	api.AssertIsEqual(seen, 1)
+	return m, eq
+}
+
+// powers returns the powers 1, r, ..., r^(n-1).
+func powers(api frontend.API, r frontend.Variable, n int) []frontend.Variable {
+	pw := make([]frontend.Variable, n)
+	if n == 0 {
+		return pw
+	}
+	pw[0] = 1
+	for i := 1; i < n; i++ {
+		pw[i] = api.Mul(pw[i-1], r)
+	}
+	return pw
+}
+
+// eval returns Σ_i m_i a_i r^i where pw are the powers of r.
+func eval(api frontend.API, a []uints.U8, m, pw []frontend.Variable) frontend.Variable {
+	var res frontend.Variable = 0
+	for i := range a {
+		res = api.Add(res, api.Mul(m[i], a[i].Val, pw[i]))
+	}
+	return res
+}
+
+// selectAt returns Σ_i eq_i v_i for the indicator eq.
+func selectAt(api frontend.API, eq, v []frontend.Variable) frontend.Variable {
+	var res frontend.Variable = 0
+	for i := range eq {
+		res = api.Add(res, api.Mul(eq[i], v[i]))
+	}
+	return res
+}
+
+// withCommitment schedules cb to be called with a random challenge after
+// committing to the variables vars. We defer the scheduling itself, so that
+// the lookup tables and range checks created during the circuit definition
+// (which use commitments internally) are built before the commitment is
+// computed.
+func (b *API) withCommitment(cb multicommit.WithCommitmentFn, vars ...frontend.Variable) {
+	b.api.Compiler().Defer(func(api frontend.API) error {
+		multicommit.WithCommitment(api, cb, vars...)
+		return nil
+	})
+}
+
+// committed returns the non-constant variables of the byte strings and the
+// additional variables to be committed to.
+func (b *API) committed(strs []Bytes, vars ...frontend.Variable) []frontend.Variable {
+	var res []frontend.Variable
+	add := func(v frontend.Variable) {
+		if _, ok := b.api.Compiler().ConstantValue(v); !ok {
+			res = append(res, v)
+		}
+	}
+	for _, s := range strs {
+		add(s.Length)
+		for i := range s.Data {
+			add(s.Data[i].Val)
+		}
+	}
+	for _, v := range vars {
+		add(v)
+	}
+	return res
+}
+
+// hintInputs returns the length, capacity and bytes of a as hint inputs.
+func hintInputs(a Bytes) []frontend.Variable {
+	res := make([]frontend.Variable, 0, a.Cap()+2)
+	res = append(res, a.Length, a.Cap())
+	for i := range a.Data {
+		res = append(res, a.Data[i].Val)
+	}
+	return res
+}
+
+// hintBytes parses the length-prefixed byte string from the hint inputs and
+// returns the bytes up to the length and the remaining inputs.
+func hintBytes(inputs []*big.Int) ([]byte, []*big.Int) {
+	length, capacity := int(inputs[0].Int64()), int(inputs[1].Int64())
+	data := make([]byte, 0, length)
+	for i := 0; i < length && i < capacity; i++ {
+		data = append(data, byte(inputs[2+i].Uint64()))
+	}
+	return data, inputs[2+capacity:]
+}
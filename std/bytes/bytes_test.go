@@ -0,0 +1,142 @@
+package bytes
+
+import (
+	stdbytes "bytes"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+const testCap = 12
+
+type opsCircuit struct {
+	A, B, C            Bytes
+	Start, End         frontend.Variable
+	Concat, Sliced     Bytes
+	Equal, HasPrefix   frontend.Variable
+	IndexOf, IndexOfHi frontend.Variable
+	Packed             []frontend.Variable
+}
+
+func (c *opsCircuit) Define(api frontend.API) error {
+	b, err := New(api)
+	if err != nil {
+		return err
+	}
+	b.AssertIsEqual(b.Concat(c.A, c.B, c.C), c.Concat)
+	b.AssertIsEqual(b.Slice(c.A, c.Start, c.End), c.Sliced)
+	api.AssertIsEqual(b.Equal(c.A, c.B), c.Equal)
+	api.AssertIsEqual(b.HasPrefix(c.A, c.B), c.HasPrefix)
+	api.AssertIsEqual(b.IndexOf(c.A, c.B), c.IndexOf)
+	api.AssertIsEqual(b.IndexOf(c.A, b.Constant([]byte("hi"))), c.IndexOfHi)
+	packed := b.Pack(c.A)
+	for i := range packed {
+		api.AssertIsEqual(packed[i], c.Packed[i])
+	}
+	return nil
+}
+
+func opsAssignment(a, bb, cc []byte, start, end int) *opsCircuit {
+	bool2int := func(v bool) int {
+		if v {
+			return 1
+		}
+		return 0
+	}
+	concat := append(append(append([]byte{}, a...), bb...), cc...)
+	idx := func(sub []byte) frontend.Variable {
+		i := stdbytes.Index(a, sub)
+		if i < 0 {
+			return new(big.Int).Sub(ecc.BN254.ScalarField(), big.NewInt(1))
+		}
+		return i
+	}
+	return &opsCircuit{
+		A:         Assignment(a, testCap),
+		B:         Assignment(bb, testCap),
+		C:         Assignment(cc, testCap),
+		Start:     start,
+		End:       end,
+		Concat:    Assignment(concat, 3*testCap),
+		Sliced:    Assignment(a[start:end], testCap),
+		Equal:     bool2int(stdbytes.Equal(a, bb)),
+		HasPrefix: bool2int(stdbytes.HasPrefix(a, bb)),
+		IndexOf:   idx(bb),
+		IndexOfHi: idx([]byte("hi")),
+		// the bytes after the length are zero in the packing
+		Packed: []frontend.Variable{new(big.Int).SetBytes(append(append([]byte{}, a...), make([]byte, testCap-len(a))...))},
+	}
+}
+
+func opsPlaceholder() *opsCircuit {
+	return &opsCircuit{
+		A:      Placeholder(testCap),
+		B:      Placeholder(testCap),
+		C:      Placeholder(testCap),
+		Concat: Placeholder(3 * testCap),
+		Sliced: Placeholder(testCap),
+		Packed: make([]frontend.Variable, 1),
+	}
+}
+
+func TestOperations(t *testing.T) {
+	assert := test.NewAssert(t)
+	cases := []struct {
+		a, b, c    string
+		start, end int
+	}{
+		{"hello world", "world", "!", 2, 7},
+		{"hello world", "hello", "", 0, 11},
+		{"hello", "hello", "abc", 5, 5},
+		{"", "", "", 0, 0},
+		{"abababhi", "bab", "xyz", 1, 3},
+		{"abc", "abcd", "hi", 0, 1},
+		{"aaaaaaaaaaaa", "aaaaaaaaaaaa", "aaaaaaaaaaaa", 0, 12},
+		{"xyz", "", "hi", 3, 3},
+	}
+	for _, tc := range cases {
+		err := test.IsSolved(opsPlaceholder(), opsAssignment([]byte(tc.a), []byte(tc.b), []byte(tc.c), tc.start, tc.end), ecc.BN254.ScalarField())
+		assert.NoError(err, "a=%q b=%q c=%q", tc.a, tc.b, tc.c)
+	}
+}
+
+func TestInvalidResults(t *testing.T) {
+	assert := test.NewAssert(t)
+	a, bb, cc := []byte("xhello hi"), []byte("hi"), []byte("!")
+	valid := opsAssignment(a, bb, cc, 1, 6)
+	invalid := make([]*opsCircuit, 0)
+	// wrong concatenation
+	w := opsAssignment(a, bb, cc, 1, 6)
+	w.Concat = Assignment([]byte("xhello hi!hi"), 3*testCap)
+	invalid = append(invalid, w)
+	// wrong slice
+	w = opsAssignment(a, bb, cc, 1, 6)
+	w.Sliced = Assignment([]byte("hell"), testCap)
+	invalid = append(invalid, w)
+	// slice out of range
+	w = opsAssignment(a, bb, cc, 1, 6)
+	w.End = 10
+	w.Sliced = Assignment(append(append([]byte{}, a[1:]...), 0), testCap)
+	invalid = append(invalid, w)
+	// not the first occurrence
+	w = opsAssignment([]byte("hi hi"), bb, cc, 1, 2)
+	w.IndexOf = 3
+	invalid = append(invalid, w)
+	// claim not found
+	w = opsAssignment(a, bb, cc, 1, 6)
+	w.IndexOfHi = new(big.Int).Sub(ecc.BN254.ScalarField(), big.NewInt(1))
+	invalid = append(invalid, w)
+	// wrong equality
+	w = opsAssignment(a, bb, cc, 1, 6)
+	w.HasPrefix = 1
+	invalid = append(invalid, w)
+
+	opts := []test.TestingOption{test.WithValidAssignment(valid), test.WithCurves(ecc.BN254)}
+	for _, w := range invalid {
+		opts = append(opts, test.WithInvalidAssignment(w))
+	}
+	assert.CheckCircuit(opsPlaceholder(), opts...)
+}
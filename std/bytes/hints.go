@@ -0,0 +1,70 @@
+package bytes
+
+import (
+	stdbytes "bytes"
+	"math/big"
+
+	"github.com/consensys/gnark/constraint/solver"
+)
+
+func init() {
+	solver.RegisterHint(GetHints()...)
+}
+
+// GetHints returns all hint functions used in the package.
+func GetHints() []solver.Hint {
+	return []solver.Hint{
+		concatHint,
+		sliceHint,
+		hasPrefixHint,
+		indexOfHint,
+	}
+}
+
+func concatHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	nbStrs := int(inputs[0].Int64())
+	inputs = inputs[1:]
+	var res []byte
+	for i := 0; i < nbStrs; i++ {
+		var s []byte
+		s, inputs = hintBytes(inputs)
+		res = append(res, s...)
+	}
+	for i := range outputs {
+		outputs[i].SetUint64(0)
+		if i < len(res) {
+			outputs[i].SetUint64(uint64(res[i]))
+		}
+	}
+	return nil
+}
+
+func sliceHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	start, end := int(inputs[0].Int64()), int(inputs[1].Int64())
+	a, _ := hintBytes(inputs[2:])
+	for i := range outputs {
+		outputs[i].SetUint64(0)
+		if start+i < end && start+i < len(a) {
+			outputs[i].SetUint64(uint64(a[start+i]))
+		}
+	}
+	return nil
+}
+
+func hasPrefixHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	a, rest := hintBytes(inputs)
+	prefix, _ := hintBytes(rest)
+	outputs[0].SetUint64(0)
+	if stdbytes.HasPrefix(a, prefix) {
+		outputs[0].SetUint64(1)
+	}
+	return nil
+}
+
+func indexOfHint(mod *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	a, rest := hintBytes(inputs)
+	sub, _ := hintBytes(rest)
+	outputs[0].SetInt64(int64(stdbytes.Index(a, sub)))
+	outputs[0].Mod(outputs[0], mod)
+	return nil
+}
@@ -0,0 +1,95 @@
+package bytes
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/uints"
+)
+
+// Concat returns the concatenation of the byte strings. The capacity of the
+// result is the sum of the capacities of the inputs.
+func (b *API) Concat(strs ...Bytes) Bytes {
+	api := b.api
+	nbOut := 0
+	inputs := []frontend.Variable{len(strs)}
+	var length frontend.Variable = 0
+	for _, s := range strs {
+		nbOut += s.Cap()
+		inputs = append(inputs, hintInputs(s)...)
+		length = api.Add(length, s.Length)
+	}
+	if nbOut == 0 {
+		return Bytes{Length: 0}
+	}
+	outs, err := api.Compiler().NewHint(concatHint, nbOut, inputs...)
+	if err != nil {
+		panic(fmt.Sprintf("concat hint: %v", err))
+	}
+	res := Bytes{Data: make([]uints.U8, nbOut), Length: length}
+	for i := range outs {
+		res.Data[i] = uints.U8{Val: outs[i]}
+	}
+	ms := make([][]frontend.Variable, len(strs))
+	eqs := make([][]frontend.Variable, len(strs))
+	for i, s := range strs {
+		ms[i], eqs[i] = b.mask(s.Length, s.Cap())
+	}
+	mOut, _ := b.mask(res.Length, res.Cap())
+	b.withCommitment(func(api frontend.API, r frontend.Variable) error {
+		pw := powers(api, r, nbOut+1)
+		// out(r) = Σ_k r^{len(s_0)+...+len(s_{k-1})} s_k(r)
+		var expected frontend.Variable = 0
+		var shift frontend.Variable = 1
+		for i, s := range strs {
+			expected = api.Add(expected, api.Mul(shift, eval(api, s.Data, ms[i], pw)))
+			shift = api.Mul(shift, selectAt(api, eqs[i], pw))
+		}
+		api.AssertIsEqual(eval(api, res.Data, mOut, pw), expected)
+		return nil
+	}, b.committed(append([]Bytes{res}, strs...))...)
+	return res
+}
+
+// Slice returns the substring of a in range [start, end). The capacity of the
+// result is the capacity of a. It asserts that 0 <= start <= end <= a.Length.
+func (b *API) Slice(a Bytes, start, end frontend.Variable) Bytes {
+	api := b.api
+	if a.Cap() == 0 {
+		api.AssertIsEqual(start, 0)
+		api.AssertIsEqual(end, 0)
+		return Bytes{Length: 0}
+	}
+	inputs := append([]frontend.Variable{start, end}, hintInputs(a)...)
+	outs, err := api.Compiler().NewHint(sliceHint, a.Cap(), inputs...)
+	if err != nil {
+		panic(fmt.Sprintf("slice hint: %v", err))
+	}
+	res := Bytes{Data: make([]uints.U8, a.Cap()), Length: api.Sub(end, start)}
+	for i := range outs {
+		res.Data[i] = uints.U8{Val: outs[i]}
+	}
+	mA, _ := b.mask(a.Length, a.Cap())
+	mStart, eqStart := b.mask(start, a.Cap())
+	mEnd, _ := b.mask(end, a.Cap())
+	mOut, _ := b.mask(res.Length, res.Cap())
+	// start <= end <= a.Length
+	for i := 0; i < a.Cap(); i++ {
+		api.AssertIsEqual(api.Mul(mStart[i], api.Sub(1, mEnd[i])), 0)
+		api.AssertIsEqual(api.Mul(mEnd[i], api.Sub(1, mA[i])), 0)
+	}
+	// the mask for range [start, end)
+	m := make([]frontend.Variable, a.Cap())
+	for i := range m {
+		m[i] = api.Sub(mEnd[i], mStart[i])
+	}
+	b.withCommitment(func(api frontend.API, r frontend.Variable) error {
+		pw := powers(api, r, a.Cap()+1)
+		// Σ_{start<=i<end} a_i r^i = r^start out(r)
+		lhs := eval(api, a.Data, m, pw)
+		rhs := api.Mul(selectAt(api, eqStart, pw), eval(api, res.Data, mOut, pw))
+		api.AssertIsEqual(lhs, rhs)
+		return nil
+	}, b.committed([]Bytes{a, res}, start, end)...)
+	return res
+}
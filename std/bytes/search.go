@@ -0,0 +1,139 @@
+package bytes
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/lookup/logderivlookup"
+	"github.com/consensys/gnark/std/math/cmp"
+)
+
+// Equal returns 1 if a and c are equal and 0 otherwise. The byte strings are
+// equal if they have the same length and the same bytes up to the length.
+func (b *API) Equal(a, c Bytes) frontend.Variable {
+	return b.api.Mul(b.api.IsZero(b.api.Sub(a.Length, c.Length)), b.HasPrefix(a, c))
+}
+
+// AssertIsEqual asserts that a and c have the same length and the same bytes up
+// to the length.
+func (b *API) AssertIsEqual(a, c Bytes) {
+	api := b.api
+	api.AssertIsEqual(a.Length, c.Length)
+	mA, _ := b.mask(a.Length, a.Cap())
+	mC, _ := b.mask(c.Length, c.Cap())
+	b.withCommitment(func(api frontend.API, r frontend.Variable) error {
+		pw := powers(api, r, max(a.Cap(), c.Cap()))
+		api.AssertIsEqual(eval(api, a.Data, mA, pw), eval(api, c.Data, mC, pw))
+		return nil
+	}, b.committed([]Bytes{a, c})...)
+}
+
+// HasPrefix returns 1 if prefix is a prefix of a and 0 otherwise.
+func (b *API) HasPrefix(a, prefix Bytes) frontend.Variable {
+	api := b.api
+	inputs := append(hintInputs(a), hintInputs(prefix)...)
+	res, err := api.Compiler().NewHint(hasPrefixHint, 1, inputs...)
+	if err != nil {
+		panic(fmt.Sprintf("has prefix hint: %v", err))
+	}
+	api.AssertIsBoolean(res[0])
+	mA, _ := b.mask(a.Length, a.Cap())
+	mP, eqP := b.mask(prefix.Length, prefix.Cap())
+	// prefix.Length <= a.Length iff prefix.Length == i for some i <=
+	// a.Length, i.e. i == 0 or i-1 < a.Length.
+	lengthOk := eqP[0]
+	for i := 1; i <= min(prefix.Cap(), a.Cap()); i++ {
+		lengthOk = api.Add(lengthOk, api.Mul(eqP[i], mA[i-1]))
+	}
+	n := min(a.Cap(), prefix.Cap())
+	b.withCommitment(func(api frontend.API, r frontend.Variable) error {
+		pw := powers(api, r, n)
+		// when the length is correct, then the prefix fits into a and we only
+		// need to compare the common part.
+		var diff frontend.Variable = 0
+		for i := 0; i < n; i++ {
+			diff = api.Add(diff, api.Mul(mP[i], api.Sub(a.Data[i].Val, prefix.Data[i].Val), pw[i]))
+		}
+		api.AssertIsEqual(res[0], api.Mul(lengthOk, api.IsZero(diff)))
+		return nil
+	}, b.committed([]Bytes{a, prefix}, res[0])...)
+	return res[0]
+}
+
+// IndexOf returns the index of the first occurrence of sub in a, or -1 if sub
+// is not present in a. The substring can be either a constant (see
+// [API.Constant]) or a variable byte string.
+func (b *API) IndexOf(a, sub Bytes) frontend.Variable {
+	api := b.api
+	inputs := append(hintInputs(a), hintInputs(sub)...)
+	res, err := api.Compiler().NewHint(indexOfHint, 1, inputs...)
+	if err != nil {
+		panic(fmt.Sprintf("index hint: %v", err))
+	}
+	idx := res[0]
+	found := api.Sub(1, api.IsZero(api.Add(idx, 1)))
+	mA, _ := b.mask(a.Length, a.Cap())
+	mS, eqS := b.mask(sub.Length, sub.Cap())
+	// the number of positions where the substring can start is
+	// max(0, a.Length-sub.Length+1).
+	comparator := cmp.NewBoundedComparator(api, big.NewInt(int64(a.Cap()+sub.Cap()+2)), false)
+	nbPositions := api.Select(comparator.IsLess(a.Length, sub.Length), 0, api.Add(api.Sub(a.Length, sub.Length), 1))
+	mPos, _ := b.mask(nbPositions, a.Cap()+1)
+	// if the substring is found, then there must be no match before idx.
+	// Otherwise, there must be no match at any valid position.
+	bound := api.Select(found, idx, nbPositions)
+	mBound, eqBound := b.mask(bound, a.Cap()+1)
+	// we compare the windows of a to the substring using prefix sums P_k =
+	// Σ_{i<k} a_i r^i. To get P_{j+sub.Length} we use the bytes of a shifted
+	// by sub.Length, as P_{j+sub.Length} = P_{sub.Length} + r^{sub.Length}
+	// Σ_{i<j} a_{i+sub.Length} r^i. The lookup table has to be created
+	// outside of the commitment callback.
+	masked := make([]frontend.Variable, a.Cap())
+	shifter := logderivlookup.New(api)
+	for i := range masked {
+		masked[i] = api.Mul(mA[i], a.Data[i].Val)
+		shifter.Insert(masked[i])
+	}
+	for i := 0; i <= sub.Cap(); i++ {
+		shifter.Insert(0)
+	}
+	inds := make([]frontend.Variable, a.Cap())
+	for i := range inds {
+		inds[i] = api.Add(i, sub.Length)
+	}
+	shifted := shifter.Lookup(inds...)
+	b.withCommitment(func(api frontend.API, r frontend.Variable) error {
+		pw := powers(api, r, max(a.Cap(), sub.Cap())+1)
+		sums := make([]frontend.Variable, a.Cap()+1)
+		shiftedSums := make([]frontend.Variable, a.Cap()+1)
+		sums[0], shiftedSums[0] = 0, 0
+		for i := 0; i < a.Cap(); i++ {
+			sums[i+1] = api.Add(sums[i], api.Mul(masked[i], pw[i]))
+			shiftedSums[i+1] = api.Add(shiftedSums[i], api.Mul(shifted[i], pw[i]))
+		}
+		// P_{sub.Length} and r^{sub.Length}
+		var sumAtLen, powAtLen frontend.Variable = 0, 0
+		for k := 0; k <= min(a.Cap(), sub.Cap()); k++ {
+			sumAtLen = api.Add(sumAtLen, api.Mul(eqS[k], sums[k]))
+			powAtLen = api.Add(powAtLen, api.Mul(eqS[k], pw[k]))
+		}
+		s := eval(api, sub.Data, mS, pw)
+		var diffAtBound, validAtBound frontend.Variable = 0, 0
+		for j := 0; j <= a.Cap(); j++ {
+			// the difference between the window starting at j and the
+			// substring shifted to j.
+			end := api.Add(sumAtLen, api.Mul(powAtLen, shiftedSums[j]))
+			diff := api.Sub(end, sums[j], api.Mul(pw[j], s))
+			isMatch := api.IsZero(diff)
+			api.AssertIsEqual(api.Mul(mBound[j], isMatch), 0)
+			diffAtBound = api.Add(diffAtBound, api.Mul(eqBound[j], diff))
+			validAtBound = api.Add(validAtBound, api.Mul(eqBound[j], mPos[j]))
+		}
+		// if found, then there is a match at idx.
+		api.AssertIsEqual(api.Mul(found, diffAtBound), 0)
+		api.AssertIsEqual(api.Mul(found, api.Sub(1, validAtBound)), 0)
+		return nil
+	}, b.committed([]Bytes{a, sub}, idx)...)
+	return idx
+}
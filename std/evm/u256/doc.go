@@ -0,0 +1,35 @@
+// Package u256 implements the arithmetic opcodes of the Ethereum Virtual
+// Machine over 256-bit words.
+//
+// A word is represented as [uints.U256], i.e. 32 bytes in little-endian order.
+// The methods of [API] follow the semantics of the corresponding EVM opcodes as
+// defined in the Ethereum Yellow Paper, including the edge cases:
+//   - division and modular reduction by zero yields zero (DIV, SDIV, MOD, SMOD,
+//     ADDMOD, MULMOD);
+//   - signed division of the smallest negative value by -1 overflows and
+//     returns the smallest negative value (SDIV);
+//   - shifting by at least 256 bits yields zero (SHL, SHR) or the sign fill
+//     (SAR);
+//   - BYTE with index at least 32 yields zero;
+//   - SIGNEXTEND with byte position at least 31 returns the input unchanged.
+//
+// The arguments of the methods are given in the order they are popped from the
+// stack, e.g. for SHL the first argument is the shift amount and the second
+// argument the shifted value.
+//
+// The wrapping arithmetic (ADD, SUB, MUL, DIV, ...) and the bitwise operations
+// are implemented in [uints.BinaryField], where the bitwise operations use
+// lookup tables over bytes. The modular operations ADDMOD and MULMOD, which
+// need the full 512-bit intermediate result, use variable-modulus emulated
+// arithmetic over [emparams.Mod1e256].
+package u256
+
+import (
+	"github.com/consensys/gnark/std/math/emulated/emparams"
+	"github.com/consensys/gnark/std/math/uints"
+)
+
+// only for documentation purposes. If we import the packages then godoc knows
+// how to refer to them and we get nice links in godoc.
+var _ = uints.NewU256
+var _ emparams.Mod1e256
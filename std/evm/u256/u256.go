@@ -0,0 +1,292 @@
+package u256
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/bitslice"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/math/emulated/emparams"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/std/selector"
+)
+
+// nbBytes is the number of bytes in a word.
+const nbBytes = 32
+
+// API implements the EVM arithmetic opcodes over 256-bit words.
+type API struct {
+	api   frontend.API
+	bf    *uints.BinaryField[uints.U256]
+	bf64  *uints.BinaryField[uints.U64]
+	field *emulated.Field[emparams.Mod1e256]
+}
+
+// New returns a new [API] for EVM word arithmetic.
+func New(api frontend.API) (*API, error) {
+	bf, err := uints.New[uints.U256](api)
+	if err != nil {
+		return nil, fmt.Errorf("new binary field: %w", err)
+	}
+	bf64, err := uints.New[uints.U64](api)
+	if err != nil {
+		return nil, fmt.Errorf("new 64-bit binary field: %w", err)
+	}
+	field, err := emulated.NewField[emparams.Mod1e256](api)
+	if err != nil {
+		return nil, fmt.Errorf("new emulated field: %w", err)
+	}
+	return &API{api: api, bf: bf, bf64: bf64, field: field}, nil
+}
+
+// Add implements the ADD opcode: a+b mod 2^256.
+func (e *API) Add(a, b uints.U256) uints.U256 {
+	return e.bf.Add(a, b)
+}
+
+// Mul implements the MUL opcode: a*b mod 2^256.
+func (e *API) Mul(a, b uints.U256) uints.U256 {
+	return e.bf.Mul(a, b)
+}
+
+// Sub implements the SUB opcode: a-b mod 2^256.
+func (e *API) Sub(a, b uints.U256) uints.U256 {
+	return e.bf.Sub(a, b)
+}
+
+// Div implements the DIV opcode: unsigned integer division. Returns zero if b
+// is zero.
+func (e *API) Div(a, b uints.U256) uints.U256 {
+	// the quotient is already zero for zero divisor
+	q, _ := e.bf.DivRem(a, b)
+	return q
+}
+
+// SDiv implements the SDIV opcode: signed integer division truncated towards
+// zero. Returns zero if b is zero.
+func (e *API) SDiv(a, b uints.U256) uints.U256 {
+	q, _ := e.bf.SDivRem(a, b)
+	return q
+}
+
+// Mod implements the MOD opcode: unsigned modulo. Returns zero if b is zero.
+func (e *API) Mod(a, b uints.U256) uints.U256 {
+	_, r := e.bf.DivRem(a, b)
+	return e.bf.Select(e.bf.IsZero(b), e.zero(), r)
+}
+
+// SMod implements the SMOD opcode: signed modulo, where the result has the sign
+// of the dividend. Returns zero if b is zero.
+func (e *API) SMod(a, b uints.U256) uints.U256 {
+	_, r := e.bf.SDivRem(a, b)
+	return e.bf.Select(e.bf.IsZero(b), e.zero(), r)
+}
+
+// AddMod implements the ADDMOD opcode: (a+b) mod n, where the intermediate sum
+// is not truncated to 256 bits. Returns zero if n is zero.
+func (e *API) AddMod(a, b, n uints.U256) uints.U256 {
+	return e.modOp(a, b, n, func(a, b, n *emulated.Element[emparams.Mod1e256]) *emulated.Element[emparams.Mod1e256] {
+		s := e.field.ModAdd(a, b, n)
+		// ModAdd does not reduce the result, multiply by one to reduce.
+		return e.field.ModMul(s, e.field.One(), n)
+	})
+}
+
+// MulMod implements the MULMOD opcode: (a*b) mod n, where the intermediate
+// product is not truncated to 256 bits. Returns zero if n is zero.
+func (e *API) MulMod(a, b, n uints.U256) uints.U256 {
+	return e.modOp(a, b, n, e.field.ModMul)
+}
+
+func (e *API) modOp(a, b, n uints.U256, op func(a, b, n *emulated.Element[emparams.Mod1e256]) *emulated.Element[emparams.Mod1e256]) uints.U256 {
+	// in case the modulus is zero, compute with dummy modulus and return zero
+	// as the result.
+	nIsZero := e.bf.IsZero(n)
+	nn := e.bf.Select(nIsZero, e.constant(1), n)
+	res := op(e.toElement(a), e.toElement(b), e.toElement(nn))
+	r := e.fromElement(res)
+	// the emulated arithmetic does not ensure the result is reduced, so we
+	// assert it explicitly to have a unique result.
+	e.api.AssertIsEqual(e.bf.Lt(r, nn), 1)
+	return e.bf.Select(nIsZero, e.zero(), r)
+}
+
+// Exp implements the EXP opcode: a^b mod 2^256.
+func (e *API) Exp(a, b uints.U256) uints.U256 {
+	res := e.constant(1)
+	pow := a
+	for i := 0; i < nbBytes; i++ {
+		bts := bits.ToBinary(e.api, b[i].Val, bits.WithNbDigits(8))
+		for j := range bts {
+			res = e.bf.Select(bts[j], e.bf.Mul(res, pow), res)
+			if i != nbBytes-1 || j != len(bts)-1 {
+				pow = e.bf.Mul(pow, pow)
+			}
+		}
+	}
+	return res
+}
+
+// SignExtend implements the SIGNEXTEND opcode: extends the sign of x from the
+// byte at position b (counting from the least significant byte). If b is at
+// least 31, then x is returned unchanged.
+func (e *API) SignExtend(b, x uints.U256) uints.U256 {
+	low, isSmall := e.smallValue(b, 5)
+	pos := e.api.Select(isSmall, low, nbBytes-1)
+	return e.bf.SignExtend(x, pos)
+}
+
+// Lt implements the LT opcode: returns 1 if a < b (unsigned) and 0 otherwise.
+func (e *API) Lt(a, b uints.U256) uints.U256 {
+	return e.fromBool(e.bf.Lt(a, b))
+}
+
+// Gt implements the GT opcode: returns 1 if a > b (unsigned) and 0 otherwise.
+func (e *API) Gt(a, b uints.U256) uints.U256 {
+	return e.fromBool(e.bf.Gt(a, b))
+}
+
+// Slt implements the SLT opcode: returns 1 if a < b (signed) and 0 otherwise.
+func (e *API) Slt(a, b uints.U256) uints.U256 {
+	return e.fromBool(e.bf.Slt(a, b))
+}
+
+// Sgt implements the SGT opcode: returns 1 if a > b (signed) and 0 otherwise.
+func (e *API) Sgt(a, b uints.U256) uints.U256 {
+	return e.fromBool(e.bf.Sgt(a, b))
+}
+
+// Eq implements the EQ opcode: returns 1 if a == b and 0 otherwise.
+func (e *API) Eq(a, b uints.U256) uints.U256 {
+	return e.fromBool(e.bf.Eq(a, b))
+}
+
+// IsZero implements the ISZERO opcode: returns 1 if a == 0 and 0 otherwise.
+func (e *API) IsZero(a uints.U256) uints.U256 {
+	return e.fromBool(e.bf.IsZero(a))
+}
+
+// And implements the AND opcode.
+func (e *API) And(a, b uints.U256) uints.U256 {
+	return e.bf.And(a, b)
+}
+
+// Or implements the OR opcode.
+func (e *API) Or(a, b uints.U256) uints.U256 {
+	return e.bf.Or(a, b)
+}
+
+// Xor implements the XOR opcode.
+func (e *API) Xor(a, b uints.U256) uints.U256 {
+	return e.bf.Xor(a, b)
+}
+
+// Not implements the NOT opcode.
+func (e *API) Not(a uints.U256) uints.U256 {
+	return e.bf.Not(a)
+}
+
+// Byte implements the BYTE opcode: returns the i-th byte of x, counting from
+// the most significant byte. Returns zero if i is at least 32.
+func (e *API) Byte(i, x uints.U256) uints.U256 {
+	low, isSmall := e.smallValue(i, 5)
+	// reverse the bytes as the index is big-endian
+	vals := make([]frontend.Variable, nbBytes)
+	for j := range vals {
+		vals[j] = x[nbBytes-1-j].Val
+	}
+	// for large indices we select a dummy index and return zero
+	idx := e.api.Select(isSmall, low, 0)
+	v := e.api.Select(isSmall, selector.Mux(e.api, idx, vals...), 0)
+	res := e.zero()
+	res[0] = e.bf.ByteValueOf(v)
+	return res
+}
+
+// Shl implements the SHL opcode: value << shift. Returns zero if shift is at
+// least 256.
+func (e *API) Shl(shift, value uints.U256) uints.U256 {
+	s, isSmall := e.shiftAmount(shift)
+	return e.bf.Select(isSmall, e.bf.LshiftVar(value, s), e.zero())
+}
+
+// Shr implements the SHR opcode: value >> shift (logical). Returns zero if
+// shift is at least 256.
+func (e *API) Shr(shift, value uints.U256) uints.U256 {
+	s, isSmall := e.shiftAmount(shift)
+	return e.bf.Select(isSmall, e.bf.RshiftVar(value, s), e.zero())
+}
+
+// Sar implements the SAR opcode: value >> shift (arithmetic). Returns zero or
+// -1 depending on the sign of value if shift is at least 256.
+func (e *API) Sar(shift, value uints.U256) uints.U256 {
+	s, isSmall := e.shiftAmount(shift)
+	// the variable shift fills the result by the sign when shifting by at
+	// least the width, so we only need to bound the shift amount.
+	s = e.api.Select(isSmall, s, 8*nbBytes)
+	return e.bf.SRshiftVar(value, s)
+}
+
+// shiftAmount returns the two least significant bytes of the shift amount as a
+// native variable and a boolean indicating if the upper bytes are zero.
+func (e *API) shiftAmount(shift uints.U256) (frontend.Variable, frontend.Variable) {
+	var upper frontend.Variable = 0
+	for i := 2; i < nbBytes; i++ {
+		upper = e.api.Add(upper, shift[i].Val)
+	}
+	s := e.api.Add(shift[0].Val, e.api.Mul(shift[1].Val, 256))
+	return s, e.api.IsZero(upper)
+}
+
+// smallValue returns the least significant byte of a and a boolean indicating
+// if a < 2^nbBits.
+func (e *API) smallValue(a uints.U256, nbBits uint) (frontend.Variable, frontend.Variable) {
+	var upper frontend.Variable = 0
+	for i := 1; i < nbBytes; i++ {
+		upper = e.api.Add(upper, a[i].Val)
+	}
+	_, hi := bitslice.Partition(e.api, a[0].Val, nbBits, bitslice.WithNbDigits(8))
+	upper = e.api.Add(upper, hi)
+	return a[0].Val, e.api.IsZero(upper)
+}
+
+// toElement converts the word into emulated element with 64-bit limbs.
+func (e *API) toElement(a uints.U256) *emulated.Element[emparams.Mod1e256] {
+	limbs := make([]frontend.Variable, nbBytes/8)
+	for i := range limbs {
+		var v frontend.Variable = 0
+		for j := 0; j < 8; j++ {
+			v = e.api.Add(v, e.api.Mul(a[8*i+j].Val, new(big.Int).Lsh(big.NewInt(1), uint(8*j))))
+		}
+		limbs[i] = v
+	}
+	return e.field.NewElement(limbs)
+}
+
+// fromElement converts the emulated element with 64-bit limbs into a word.
+func (e *API) fromElement(a *emulated.Element[emparams.Mod1e256]) uints.U256 {
+	var r uints.U256
+	for i := range a.Limbs {
+		limb := e.bf64.ValueOf(a.Limbs[i])
+		for j := range limb {
+			r[8*i+j] = limb[j]
+		}
+	}
+	return r
+}
+
+func (e *API) fromBool(b frontend.Variable) uints.U256 {
+	r := e.zero()
+	r[0] = uints.U8{Val: b}
+	return r
+}
+
+func (e *API) zero() uints.U256 {
+	return e.constant(0)
+}
+
+func (e *API) constant(v int64) uints.U256 {
+	return uints.NewU256(big.NewInt(v))
+}
@@ -0,0 +1,255 @@
+package u256
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/test"
+)
+
+var (
+	modulus = new(big.Int).Lsh(big.NewInt(1), 256)
+	half    = new(big.Int).Lsh(big.NewInt(1), 255)
+)
+
+func toSigned(v *big.Int) *big.Int {
+	if v.Cmp(half) >= 0 {
+		return new(big.Int).Sub(v, modulus)
+	}
+	return new(big.Int).Set(v)
+}
+
+func fromSigned(v *big.Int) *big.Int {
+	return new(big.Int).Mod(v, modulus)
+}
+
+func fromBool(b bool) *big.Int {
+	if b {
+		return big.NewInt(1)
+	}
+	return big.NewInt(0)
+}
+
+const (
+	opAdd = iota
+	opMul
+	opSub
+	opDiv
+	opSDiv
+	opMod
+	opSMod
+	opAddMod
+	opMulMod
+	opExp
+	opSignExtend
+	opLt
+	opGt
+	opSlt
+	opSgt
+	opEq
+	opIsZero
+	opAnd
+	opOr
+	opXor
+	opNot
+	opByte
+	opShl
+	opShr
+	opSar
+	nbOps
+)
+
+// evaluate computes the expected result of the opcode.
+func evaluate(op int, a, b, n *big.Int) *big.Int {
+	res := new(big.Int)
+	switch op {
+	case opAdd:
+		res.Add(a, b)
+	case opMul:
+		res.Mul(a, b)
+	case opSub:
+		res.Sub(a, b)
+	case opDiv:
+		if b.Sign() != 0 {
+			res.Quo(a, b)
+		}
+	case opSDiv:
+		if b.Sign() != 0 {
+			res.Quo(toSigned(a), toSigned(b))
+		}
+	case opMod:
+		if b.Sign() != 0 {
+			res.Rem(a, b)
+		}
+	case opSMod:
+		if b.Sign() != 0 {
+			res.Rem(toSigned(a), toSigned(b))
+		}
+	case opAddMod:
+		if n.Sign() != 0 {
+			res.Add(a, b)
+			res.Mod(res, n)
+		}
+	case opMulMod:
+		if n.Sign() != 0 {
+			res.Mul(a, b)
+			res.Mod(res, n)
+		}
+	case opExp:
+		res.Exp(a, b, modulus)
+	case opSignExtend:
+		res.Set(b)
+		if a.Cmp(big.NewInt(31)) < 0 {
+			bit := uint(8*a.Uint64() + 7)
+			mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), bit), big.NewInt(1))
+			if b.Bit(int(bit)) == 1 {
+				res.Or(b, new(big.Int).Xor(mask, new(big.Int).Sub(modulus, big.NewInt(1))))
+			} else {
+				res.And(b, mask)
+			}
+		}
+	case opLt:
+		res = fromBool(a.Cmp(b) < 0)
+	case opGt:
+		res = fromBool(a.Cmp(b) > 0)
+	case opSlt:
+		res = fromBool(toSigned(a).Cmp(toSigned(b)) < 0)
+	case opSgt:
+		res = fromBool(toSigned(a).Cmp(toSigned(b)) > 0)
+	case opEq:
+		res = fromBool(a.Cmp(b) == 0)
+	case opIsZero:
+		res = fromBool(a.Sign() == 0)
+	case opAnd:
+		res.And(a, b)
+	case opOr:
+		res.Or(a, b)
+	case opXor:
+		res.Xor(a, b)
+	case opNot:
+		res.Sub(modulus, big.NewInt(1))
+		res.Xor(res, a)
+	case opByte:
+		if a.Cmp(big.NewInt(32)) < 0 {
+			bts := make([]byte, 32)
+			b.FillBytes(bts)
+			res.SetUint64(uint64(bts[a.Uint64()]))
+		}
+	case opShl:
+		if a.Cmp(big.NewInt(256)) < 0 {
+			res.Lsh(b, uint(a.Uint64()))
+		}
+	case opShr:
+		if a.Cmp(big.NewInt(256)) < 0 {
+			res.Rsh(b, uint(a.Uint64()))
+		}
+	case opSar:
+		shift := uint(256)
+		if a.Cmp(big.NewInt(256)) < 0 {
+			shift = uint(a.Uint64())
+		}
+		res.Rsh(toSigned(b), shift)
+	}
+	return fromSigned(res)
+}
+
+type opcodeCircuit struct {
+	A, B, N  uints.U256
+	Expected [nbOps]uints.U256
+}
+
+func (c *opcodeCircuit) Define(api frontend.API) error {
+	e, err := New(api)
+	if err != nil {
+		return err
+	}
+	res := [nbOps]uints.U256{
+		opAdd:        e.Add(c.A, c.B),
+		opMul:        e.Mul(c.A, c.B),
+		opSub:        e.Sub(c.A, c.B),
+		opDiv:        e.Div(c.A, c.B),
+		opSDiv:       e.SDiv(c.A, c.B),
+		opMod:        e.Mod(c.A, c.B),
+		opSMod:       e.SMod(c.A, c.B),
+		opAddMod:     e.AddMod(c.A, c.B, c.N),
+		opMulMod:     e.MulMod(c.A, c.B, c.N),
+		opExp:        e.Exp(c.A, c.B),
+		opSignExtend: e.SignExtend(c.A, c.B),
+		opLt:         e.Lt(c.A, c.B),
+		opGt:         e.Gt(c.A, c.B),
+		opSlt:        e.Slt(c.A, c.B),
+		opSgt:        e.Sgt(c.A, c.B),
+		opEq:         e.Eq(c.A, c.B),
+		opIsZero:     e.IsZero(c.A),
+		opAnd:        e.And(c.A, c.B),
+		opOr:         e.Or(c.A, c.B),
+		opXor:        e.Xor(c.A, c.B),
+		opNot:        e.Not(c.A),
+		opByte:       e.Byte(c.A, c.B),
+		opShl:        e.Shl(c.A, c.B),
+		opShr:        e.Shr(c.A, c.B),
+		opSar:        e.Sar(c.A, c.B),
+	}
+	for i := range res {
+		for j := range res[i] {
+			api.AssertIsEqual(res[i][j].Val, c.Expected[i][j].Val)
+		}
+	}
+	return nil
+}
+
+func TestOpcodes(t *testing.T) {
+	assert := test.NewAssert(t)
+	rnd := func() *big.Int {
+		v, _ := rand.Int(rand.Reader, modulus)
+		return v
+	}
+	minusOne := new(big.Int).Sub(modulus, big.NewInt(1))
+	cases := [][3]*big.Int{
+		{rnd(), rnd(), rnd()},
+		{rnd(), big.NewInt(0), big.NewInt(0)},
+		{big.NewInt(0), big.NewInt(0), big.NewInt(1)},
+		{half, minusOne, rnd()},
+		{big.NewInt(5), rnd(), big.NewInt(7)},
+		{big.NewInt(31), rnd(), minusOne},
+		{big.NewInt(200), new(big.Int).Sub(modulus, big.NewInt(12345)), rnd()},
+		{big.NewInt(256), minusOne, rnd()},
+		{big.NewInt(3), big.NewInt(200), rnd()},
+		{new(big.Int).Add(big.NewInt(1<<16), big.NewInt(4)), half, rnd()},
+	}
+	for _, tc := range cases {
+		assignment := &opcodeCircuit{
+			A: uints.NewU256(tc[0]),
+			B: uints.NewU256(tc[1]),
+			N: uints.NewU256(tc[2]),
+		}
+		for op := 0; op < nbOps; op++ {
+			assignment.Expected[op] = uints.NewU256(evaluate(op, tc[0], tc[1], tc[2]))
+		}
+		err := test.IsSolved(&opcodeCircuit{}, assignment, ecc.BN254.ScalarField())
+		assert.NoError(err, "a=%s b=%s n=%s", tc[0], tc[1], tc[2])
+	}
+}
+
+func TestMulModNotReduced(t *testing.T) {
+	assert := test.NewAssert(t)
+	a, b, n := big.NewInt(1234567), big.NewInt(7654321), big.NewInt(1000003)
+	assignment := &opcodeCircuit{
+		A: uints.NewU256(a),
+		B: uints.NewU256(b),
+		N: uints.NewU256(n),
+	}
+	for op := 0; op < nbOps; op++ {
+		assignment.Expected[op] = uints.NewU256(evaluate(op, a, b, n))
+	}
+	err := test.IsSolved(&opcodeCircuit{}, assignment, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	// result congruent modulo n but not reduced
+	assignment.Expected[opMulMod] = uints.NewU256(new(big.Int).Add(evaluate(opMulMod, a, b, n), n))
+	err = test.IsSolved(&opcodeCircuit{}, assignment, ecc.BN254.ScalarField())
+	assert.Error(err)
+}
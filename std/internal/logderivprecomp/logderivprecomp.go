@@ -9,7 +9,10 @@
 // With this package, we can instead compute all results for two inputs of
 // length 8 bit and then just perform a lookup on the inputs.
 //
-// We use the [logderivarg] package for the actual log-derivative argument.
+// We use the [logderivarg] package for the actual log-derivative argument. If
+// the builder supports native lookups ([frontend.Lookuper]) and the circuit is
+// not compiled with [frontend.WithoutNativeLookups], then we instead define
+// the function as a fixed table of the backend.
 package logderivprecomp
 
 import (
@@ -31,6 +34,11 @@ type Precomputed struct {
 	compute solver.Hint
 	queries []frontend.Variable
 	rets    []uint
+
+	// native lookup table, defined on the first query
+	lookup  frontend.Lookuper
+	tableID frontend.LookupTableID
+	defined bool
 }
 
 // New returns a new [Precomputed]. It defers the log-derivative argument.
@@ -61,6 +69,9 @@ func New(api frontend.API, fn solver.Hint, rets []uint) (*Precomputed, error) {
 		queries: nil,
 		rets:    rets,
 	}
+	if lk, ok := frontend.NativeLookups(api); ok {
+		t.lookup = lk
+	}
 	kv.SetKeyValue(ctxPrecomputedKey{fn: reflect.ValueOf(fn).Pointer()}, t)
 	api.Compiler().Defer(t.build)
 	return t, nil
@@ -84,11 +95,64 @@ func (t *Precomputed) Query(x, y frontend.Variable) []frontend.Variable {
 	if err != nil {
 		panic(err)
 	}
+	if t.lookup != nil {
+		t.lookup.AssertLookup(t.nativeTable(), x, y, t.packRets(rets))
+		return rets
+	}
 	packed := t.pack(x, y, rets)
 	t.queries = append(t.queries, packed)
 	return rets
 }
 
+// packRets packs the outputs of the function as in the last column of the
+// native lookup table.
+func (t *Precomputed) packRets(rets []frontend.Variable) frontend.Variable {
+	packed := rets[0]
+	shift := new(big.Int).Lsh(big.NewInt(1), t.rets[0])
+	for i := 1; i < len(t.rets); i++ {
+		packed = t.api.Add(packed, t.api.Mul(rets[i], shift))
+		shift.Lsh(shift, t.rets[i])
+	}
+	return packed
+}
+
+// nativeTable defines the table (x, y, packed outputs) on the first call and
+// returns its identifier.
+func (t *Precomputed) nativeTable() frontend.LookupTableID {
+	if t.defined {
+		return t.tableID
+	}
+	tmp := new(big.Int)
+	rows := make([][]*big.Int, 0, 65536)
+	inputs := []*big.Int{big.NewInt(0), big.NewInt(0)}
+	outputs := make([]*big.Int, len(t.rets))
+	for i := range outputs {
+		outputs[i] = new(big.Int)
+	}
+	for x := int64(0); x < 256; x++ {
+		inputs[0].SetInt64(x)
+		for y := int64(0); y < 256; y++ {
+			inputs[1].SetInt64(y)
+			if err := t.compute(t.api.Compiler().Field(), inputs, outputs); err != nil {
+				panic(err)
+			}
+			packed := new(big.Int)
+			var shift uint
+			for j := range t.rets {
+				packed.Add(packed, tmp.Lsh(outputs[j], shift))
+				shift += t.rets[j]
+			}
+			rows = append(rows, []*big.Int{big.NewInt(x), big.NewInt(y), packed})
+		}
+	}
+	id, err := t.lookup.DefineLookupTable(solver.GetHintName(t.compute), rows)
+	if err != nil {
+		panic(err)
+	}
+	t.tableID, t.defined = id, true
+	return id
+}
+
 func (t *Precomputed) buildTable() []frontend.Variable {
 	tmp := new(big.Int)
 	shift := new(big.Int)
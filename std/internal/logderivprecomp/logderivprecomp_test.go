@@ -7,8 +7,10 @@ import (
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/constraint/solver"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
 	"github.com/consensys/gnark/test"
 )
 
@@ -53,3 +55,28 @@ func TestXor(t *testing.T) {
 		test.NoSerializationChecks(),
 		test.WithCurves(ecc.BN254))
 }
+
+func TestXorLookup(t *testing.T) {
+	assert := test.NewAssert(t)
+	bound := big.NewInt(255)
+	var xs, ys, ress [100]frontend.Variable
+	for i := range xs {
+		x, _ := rand.Int(rand.Reader, bound)
+		y, _ := rand.Int(rand.Reader, bound)
+		ress[i] = new(big.Int).Xor(x, y)
+		xs[i] = x
+		ys[i] = y
+	}
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &TestXORCircuit{})
+	assert.NoError(err)
+	tables := ccs.(constraint.SparseR1CS).GetLookupTables()
+	assert.Len(tables, 1)
+	assert.Len(tables[0].Rows, 65536)
+	witness := &TestXORCircuit{X: xs, Y: ys, Res: ress}
+	assert.ProverSucceeded(&TestXORCircuit{}, witness,
+		test.WithBackends(backend.PLONK),
+		test.WithSolverOpts(solver.WithHints(xorHint)),
+		test.NoFuzzing(),
+		test.NoSerializationChecks(),
+		test.WithCurves(ecc.BN254))
+}
@@ -0,0 +1,185 @@
+package fixedpoint
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/lookup/logderivlookup"
+	"github.com/consensys/gnark/std/math/bitslice"
+)
+
+// guardBits is the number of additional fractional bits used for constant
+// multiplications in range reduction.
+const guardBits = 16
+
+// Exp returns an approximation of e^a. It asserts that the result is
+// representable. Results smaller than the precision are rounded to zero.
+//
+// We compute e^a = 2^(a/ln2) = 2^n * 2^f, where n is an integer and f ∈ [0,
+// 1). 2^f is approximated using a piecewise linear function and the scaling by
+// 2^n is performed using a lookup table.
+func (f *API[P]) Exp(a Num[P]) Num[P] {
+	f.enforceRange(a)
+	// t = a / ln2, truncated to nbFrac+guardBits fractional bits. We use guard
+	// bits to avoid amplifying the truncation error in the exponent.
+	// |a*c| < 2^(nbBits-1) * 2^(nbFrac+guardBits+1)
+	fracBits := f.nbFrac + guardBits
+	c := f.scaledConstant(1/math.Ln2, fracBits)
+	t, _ := f.splitSigned(f.api.Mul(a.V, c), f.nbFrac, f.nbBits+fracBits+1)
+	// |t| < 2^(nbBits+guardBits). Split into integer part n and fractional
+	// part frac.
+	n, frac := f.splitSigned(t, fracBits, f.nbBits+guardBits+1)
+	// 2^frac ∈ [2^nbFrac, 2^(nbFrac+1)]
+	if f.exp2Table == nil {
+		f.exp2Table = f.newInterpolationTable(func(x float64) float64 { return math.Exp2(x) })
+	}
+	pf := f.interpolate(f.exp2Table, frac, fracBits)
+	// we compute floor(pf * 2^(n+shift) / 2^shift). When n+shift < 0 then the
+	// result is smaller than the precision and we return zero.
+	shift := f.nbFrac + 1
+	idx := f.api.Add(n, shift)
+	under := f.isNegative(idx, f.nbBits+2)
+	idx = f.api.Select(under, 0, idx)
+	// the valid range for idx is [0, shift+nbInt-1) as larger values always
+	// overflow. For indices in the table which are out of the valid range we
+	// store values which makes the result overflow.
+	nbValid := shift + f.nbInt - 1
+	tblBits := uint(bits.Len(uint(nbValid)))
+	if f.expScaleTable == nil {
+		f.expScaleTable = logderivlookup.New(f.api)
+		for i := uint(0); i < 1<<tblBits; i++ {
+			f.expScaleTable.Insert(new(big.Int).Lsh(big.NewInt(1), min(i, nbValid)))
+		}
+	}
+	// the index must be in the table, otherwise the result certainly
+	// overflows.
+	f.checker.Check(idx, int(tblBits))
+	pow := f.expScaleTable.Lookup(idx)[0]
+	pow = f.api.Select(under, 0, pow)
+	// pf * pow < 2^(nbFrac+2) * 2^nbValid = 2^(nbBits+nbFrac+2)
+	_, res := bitslice.Partition(f.api, f.api.Mul(pf, pow), shift, bitslice.WithNbDigits(int(f.nbBits+f.nbFrac+2)))
+	f.assertInRange(res)
+	return f.newInternal(res)
+}
+
+// Log returns an approximation of the natural logarithm of a. It asserts that a
+// is positive.
+//
+// We compute ln(a) = p*ln2 + ln(m), where p is the position of the most
+// significant bit of a and m ∈ [1, 2). ln(m) is approximated using a piecewise
+// linear function.
+func (f *API[P]) Log(a Num[P]) Num[P] {
+	f.enforceRange(a)
+	// a > 0
+	f.checker.Check(f.api.Sub(a.V, 1), int(f.nbBits-1))
+	ret, err := f.api.Compiler().NewHint(logHint, 3, f.nbFrac, a.V)
+	if err != nil {
+		panic(fmt.Sprintf("log hint: %v", err))
+	}
+	p, m, r := ret[0], ret[1], ret[2]
+	// pow = 2^p for valid p ∈ [0, nbBits-2]. For out of range indices we store
+	// value which makes the comparison below fail.
+	nbValid := f.nbBits - 1
+	tblBits := uint(bits.Len(uint(nbValid - 1)))
+	if f.logScaleTable == nil {
+		f.logScaleTable = logderivlookup.New(f.api)
+		for i := uint(0); i < 1<<tblBits; i++ {
+			if i < nbValid {
+				f.logScaleTable.Insert(new(big.Int).Lsh(big.NewInt(1), i))
+			} else {
+				f.logScaleTable.Insert(new(big.Int).Lsh(big.NewInt(1), f.nbBits))
+			}
+		}
+	}
+	f.checker.Check(p, int(tblBits))
+	pow := f.logScaleTable.Lookup(p)[0]
+	// 2^p ≤ a < 2^(p+1)
+	f.checker.Check(f.api.Sub(a.V, pow), int(f.nbBits-1))
+	f.checker.Check(f.api.Sub(f.api.Mul(pow, 2), a.V, 1), int(f.nbBits-1))
+	// a*2^nbFrac = m*2^p + r, where 0 ≤ r < 2^p and 2^nbFrac ≤ m < 2^(nbFrac+1)
+	mfrac := f.api.Sub(m, new(big.Int).Lsh(big.NewInt(1), f.nbFrac))
+	f.checker.Check(mfrac, int(f.nbFrac))
+	f.checker.Check(r, int(f.nbBits-1))
+	f.checker.Check(f.api.Sub(pow, r, 1), int(f.nbBits-1))
+	f.api.AssertIsEqual(
+		f.api.Mul(a.V, new(big.Int).Lsh(big.NewInt(1), f.nbFrac)),
+		f.api.Add(f.api.Mul(m, pow), r),
+	)
+	if f.lnTable == nil {
+		f.lnTable = f.newInterpolationTable(func(x float64) float64 { return math.Log1p(x) })
+	}
+	lnm := f.interpolate(f.lnTable, mfrac, f.nbFrac)
+	// res = (p - nbFrac) * ln2 + ln(m), computed with guard bits
+	c := f.scaledConstant(math.Ln2, f.nbFrac+guardBits)
+	res := f.api.Add(
+		f.api.Mul(f.api.Sub(p, f.nbFrac), c),
+		f.api.Mul(lnm, 1<<guardBits),
+		1<<(guardBits-1),
+	)
+	bound := uint(bits.Len(uint(f.nbBits))) + f.nbFrac + guardBits + 2
+	res, _ = f.splitSigned(res, guardBits, bound)
+	return f.newInternal(res)
+}
+
+// Sqrt returns the square root of a, truncated towards zero. It asserts that a
+// is non-negative.
+func (f *API[P]) Sqrt(a Num[P]) Num[P] {
+	f.enforceRange(a)
+	// a ≥ 0
+	f.checker.Check(a.V, int(f.nbBits-1))
+	ret, err := f.api.Compiler().NewHint(sqrtHint, 1, f.nbFrac, a.V)
+	if err != nil {
+		panic(fmt.Sprintf("sqrt hint: %v", err))
+	}
+	y := ret[0]
+	// y = floor(sqrt(a*2^nbFrac)) iff y^2 ≤ a*2^nbFrac < (y+1)^2. As
+	// sqrt(x) < max(x, 1) the result is always representable.
+	f.checker.Check(y, int(f.nbBits-1))
+	scaled := f.api.Mul(a.V, new(big.Int).Lsh(big.NewInt(1), f.nbFrac))
+	ysq := f.api.Mul(y, y)
+	f.checker.Check(f.api.Sub(scaled, ysq), int(f.nbBits+f.nbFrac))
+	f.checker.Check(f.api.Sub(f.api.Add(ysq, f.api.Mul(y, 2)), scaled), int(f.nbBits))
+	return f.newInternal(y)
+}
+
+// newInterpolationTable returns a table of 2^k+1 values fn(i/2^k) scaled by
+// 2^nbFrac for i ∈ [0, 2^k], where k is the number of table bits capped to the
+// number of fraction bits.
+func (f *API[P]) newInterpolationTable(fn func(float64) float64) *logderivlookup.Table {
+	k := min(f.tableBits, f.nbFrac)
+	tbl := logderivlookup.New(f.api)
+	for i := 0; i <= 1<<k; i++ {
+		x := float64(i) / float64(uint64(1)<<k)
+		tbl.Insert(f.scaledConstant(fn(x), f.nbFrac))
+	}
+	return tbl
+}
+
+// interpolate returns the piecewise linear approximation of the function
+// tabulated in tbl (see [API.newInterpolationTable]) at point u/2^w, where 0 ≤ u
+// < 2^w. The tabulated function must be non-decreasing and its values less than
+// 2^(nbFrac+1).
+func (f *API[P]) interpolate(tbl *logderivlookup.Table, u frontend.Variable, w uint) frontend.Variable {
+	k := min(f.tableBits, f.nbFrac)
+	if k == w {
+		return tbl.Lookup(u)[0]
+	}
+	s := w - k
+	lo, hi := bitslice.Partition(f.api, u, s, bitslice.WithNbDigits(int(w)))
+	vals := tbl.Lookup(hi, f.api.Add(hi, 1))
+	diff := f.api.Sub(vals[1], vals[0])
+	// v0*2^s + (v1-v0)*lo < 2^(nbFrac+2+s)
+	acc := f.api.Add(f.api.Mul(vals[0], new(big.Int).Lsh(big.NewInt(1), s)), f.api.Mul(diff, lo))
+	_, res := bitslice.Partition(f.api, acc, s, bitslice.WithNbDigits(int(f.nbFrac+2+s)))
+	return res
+}
+
+// scaledConstant returns round(v * 2^scale).
+func (f *API[P]) scaledConstant(v float64, scale uint) *big.Int {
+	bf := new(big.Float).SetFloat64(v)
+	bf.SetMantExp(bf, int(scale))
+	return roundFloat(bf)
+}
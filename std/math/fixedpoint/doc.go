@@ -0,0 +1,45 @@
+// Package fixedpoint implements signed fixed-point arithmetic in-circuit.
+//
+// A fixed-point number with I integer bits and F fraction bits is stored as a
+// single native variable holding the scaled integer
+//
+//	V = round(x · 2^F),
+//
+// where x is the represented real value. Negative values are stored as their
+// additive inverse in the native field (i.e. V = p - |V| for native modulus
+// p). The integer bits I include the sign bit, so a number is representable
+// when
+//
+//	-2^(I+F-1) ≤ V < 2^(I+F-1).
+//
+// The widths are given by a type implementing [Params], which allows to
+// distinguish numbers of different precision at compile time. We define some
+// commonly used parameters as [Q16x16] and [Q32x32].
+//
+// Every operation returning a [Num] asserts that the result is representable,
+// i.e. overflowing computations make the circuit unsatisfiable instead of
+// silently wrapping around. The range checks are performed using the
+// [rangecheck] package and are thus efficient when the builder supports
+// commitments. Inputs which do not come from the operations of [API] (for
+// example witness values) are range checked on first use.
+//
+// Multiplication and division truncate the exact result towards negative
+// infinity ([API.Mul], [API.Div]). [API.MulRound] instead rounds to the
+// nearest representable value.
+//
+// The transcendental functions [API.Exp] and [API.Log] use range reduction and
+// then evaluate a piecewise linear approximation stored in lookup tables (see
+// [logderivlookup]). The precision of the approximation depends on the number
+// of table entries, see [WithTableBits]. [API.Sqrt] computes the exact
+// truncated square root.
+package fixedpoint
+
+import (
+	"github.com/consensys/gnark/std/lookup/logderivlookup"
+	"github.com/consensys/gnark/std/rangecheck"
+)
+
+// only for documentation purposes. If we import the packages then godoc knows
+// how to refer to them and we get nice links in godoc.
+var _ = rangecheck.New
+var _ = logderivlookup.New
@@ -0,0 +1,375 @@
+package fixedpoint
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/kvstore"
+	"github.com/consensys/gnark/std/lookup/logderivlookup"
+	"github.com/consensys/gnark/std/math/bitslice"
+	"github.com/consensys/gnark/std/rangecheck"
+)
+
+// Num is a signed fixed-point number. The type parameter defines the widths of
+// the integer and fractional parts.
+type Num[P Params] struct {
+	// V is the value scaled by 2^FractionBits. Negative values are represented
+	// as additive inverses in the native field.
+	V frontend.Variable
+
+	// internal indicates if the number is returned from [API] methods. If so,
+	// then we can assume that the value is already range checked. Otherwise
+	// the number most probably comes from the witness and we have to check
+	// that it is representable on first use.
+	internal bool
+}
+
+// GnarkInitHook describes how to initialise the number.
+func (n *Num[P]) GnarkInitHook() {
+	if n.V == nil {
+		n.V = 0
+		n.internal = false // we need to constrain it later.
+	}
+}
+
+// ValueOf returns a fixed-point number closest to v. It can be used both for
+// defining constants in-circuit and for witness assignment. It panics if v is
+// not representable with parameters P.
+func ValueOf[P Params](v float64) Num[P] {
+	var fp P
+	bf := new(big.Float).SetFloat64(v)
+	bf.SetMantExp(bf, int(fp.FractionBits()))
+	scaled := roundFloat(bf)
+	bound := new(big.Int).Lsh(big.NewInt(1), fp.IntegerBits()+fp.FractionBits()-1)
+	if scaled.Cmp(bound) >= 0 || scaled.Cmp(new(big.Int).Neg(bound)) < 0 {
+		panic(fmt.Sprintf("value %v not representable", v))
+	}
+	return Num[P]{V: scaled, internal: true}
+}
+
+// API provides fixed-point operations over [Num] values.
+type API[P Params] struct {
+	api     frontend.API
+	checker frontend.Rangechecker
+
+	nbInt, nbFrac, nbBits uint
+	tableBits             uint
+
+	constrained map[[16]byte]struct{}
+
+	// lookup tables for the approximations, initialised on first use
+	exp2Table, lnTable, expScaleTable, logScaleTable *logderivlookup.Table
+}
+
+type ctxKey[P Params] struct{ tableBits uint }
+
+// New returns a new [API] for performing fixed-point arithmetic with widths
+// defined by the type parameter P. Instances are cached per builder, so calling
+// New multiple times with same parameters and options returns the same
+// instance.
+func New[P Params](api frontend.API, opts ...Option) (*API[P], error) {
+	cfg, err := parseOpts(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("parse options: %w", err)
+	}
+	var fp P
+	nbInt, nbFrac := fp.IntegerBits(), fp.FractionBits()
+	if nbInt < 2 {
+		return nil, fmt.Errorf("integer part must have at least 2 bits including the sign bit")
+	}
+	if nbFrac < 1 {
+		return nil, fmt.Errorf("fractional part must have at least 1 bit")
+	}
+	nbBits := nbInt + nbFrac
+	// the widest intermediate value is the product of two numbers (2*nbBits)
+	// and the range reduction in exponentiation (nbBits+nbFrac+guardBits+2).
+	widest := max(2*nbBits, nbBits+nbFrac+guardBits+2)
+	if int(widest)+1 >= api.Compiler().FieldBitLen() {
+		return nil, fmt.Errorf("fixed-point width %d too large for native field", nbBits)
+	}
+	kv, ok := api.Compiler().(kvstore.Store)
+	if !ok {
+		panic("builder should implement key-value store")
+	}
+	key := ctxKey[P]{tableBits: cfg.tableBits}
+	if stored := kv.GetKeyValue(key); stored != nil {
+		if f, ok := stored.(*API[P]); ok {
+			return f, nil
+		}
+		panic("stored fixed-point API is not valid")
+	}
+	f := &API[P]{
+		api:         api,
+		checker:     rangecheck.New(api),
+		nbInt:       nbInt,
+		nbFrac:      nbFrac,
+		nbBits:      nbBits,
+		tableBits:   cfg.tableBits,
+		constrained: make(map[[16]byte]struct{}),
+	}
+	kv.SetKeyValue(key, f)
+	return f, nil
+}
+
+func (f *API[P]) newInternal(v frontend.Variable) Num[P] {
+	return Num[P]{V: v, internal: true}
+}
+
+// Constant returns the fixed-point number closest to v. It panics if v is not
+// representable.
+func (f *API[P]) Constant(v float64) Num[P] {
+	return ValueOf[P](v)
+}
+
+// FromInteger returns the fixed-point representation of the signed integer v.
+// It asserts that the result is representable.
+func (f *API[P]) FromInteger(v frontend.Variable) Num[P] {
+	res := f.api.Mul(v, new(big.Int).Lsh(big.NewInt(1), f.nbFrac))
+	f.assertInRange(res)
+	return f.newInternal(res)
+}
+
+// Floor returns the largest integer less than or equal to a as a native
+// (signed) variable.
+func (f *API[P]) Floor(a Num[P]) frontend.Variable {
+	f.enforceRange(a)
+	hi, _ := f.splitSigned(a.V, f.nbFrac, f.nbBits)
+	return hi
+}
+
+// AssertIsInRange asserts that a is representable with parameters P.
+func (f *API[P]) AssertIsInRange(a Num[P]) {
+	if a.internal {
+		return
+	}
+	f.assertInRange(a.V)
+}
+
+// Add returns a+b. It asserts that the result is representable.
+func (f *API[P]) Add(a, b Num[P]) Num[P] {
+	f.enforceRange(a)
+	f.enforceRange(b)
+	res := f.api.Add(a.V, b.V)
+	f.assertInRange(res)
+	return f.newInternal(res)
+}
+
+// Sub returns a-b. It asserts that the result is representable.
+func (f *API[P]) Sub(a, b Num[P]) Num[P] {
+	f.enforceRange(a)
+	f.enforceRange(b)
+	res := f.api.Sub(a.V, b.V)
+	f.assertInRange(res)
+	return f.newInternal(res)
+}
+
+// Neg returns -a. It asserts that the result is representable, which fails
+// only for the smallest representable value.
+func (f *API[P]) Neg(a Num[P]) Num[P] {
+	f.enforceRange(a)
+	res := f.api.Neg(a.V)
+	f.assertInRange(res)
+	return f.newInternal(res)
+}
+
+// Mul returns a*b truncated towards negative infinity. It asserts that the
+// result is representable.
+func (f *API[P]) Mul(a, b Num[P]) Num[P] {
+	return f.mul(a, b, false)
+}
+
+// MulRound returns a*b rounded to the nearest representable value (ties are
+// rounded up). It asserts that the result is representable.
+func (f *API[P]) MulRound(a, b Num[P]) Num[P] {
+	return f.mul(a, b, true)
+}
+
+func (f *API[P]) mul(a, b Num[P], round bool) Num[P] {
+	f.enforceRange(a)
+	f.enforceRange(b)
+	prod := f.api.Mul(a.V, b.V)
+	if round {
+		prod = f.api.Add(prod, new(big.Int).Lsh(big.NewInt(1), f.nbFrac-1))
+	}
+	// |a*b| ≤ 2^(2*nbBits-2), adding the rounding term keeps it below
+	// 2^(2*nbBits-1).
+	res, _ := f.splitSigned(prod, f.nbFrac, 2*f.nbBits)
+	f.assertInRange(res)
+	return f.newInternal(res)
+}
+
+// Div returns a/b truncated towards negative infinity. It asserts that b is
+// non-zero and that the result is representable.
+func (f *API[P]) Div(a, b Num[P]) Num[P] {
+	f.enforceRange(a)
+	f.enforceRange(b)
+	f.api.AssertIsDifferent(b.V, 0)
+	ret, err := f.api.Compiler().NewHint(divHint, 2, f.nbFrac, a.V, b.V)
+	if err != nil {
+		panic(fmt.Sprintf("div hint: %v", err))
+	}
+	q, r := ret[0], ret[1]
+	// the quotient is the result, assert that it is representable.
+	f.assertInRange(q)
+	// for floor division the remainder has the same sign as the divisor and
+	// is smaller in absolute value.
+	bNeg := f.isNegative(b.V, f.nbBits)
+	absB := f.api.Select(bNeg, f.api.Neg(b.V), b.V)
+	absR := f.api.Select(bNeg, f.api.Neg(r), r)
+	f.checker.Check(absR, int(f.nbBits))
+	f.checker.Check(f.api.Sub(absB, absR, 1), int(f.nbBits))
+	// all the values are bounded, so the equation cannot overflow the native
+	// field.
+	lhs := f.api.Mul(a.V, new(big.Int).Lsh(big.NewInt(1), f.nbFrac))
+	rhs := f.api.Add(f.api.Mul(q, b.V), r)
+	f.api.AssertIsEqual(lhs, rhs)
+	return f.newInternal(q)
+}
+
+// Select returns a if b is 1 and c if b is 0. b must be boolean.
+func (f *API[P]) Select(b frontend.Variable, a, c Num[P]) Num[P] {
+	f.enforceRange(a)
+	f.enforceRange(c)
+	return f.newInternal(f.api.Select(b, a.V, c.V))
+}
+
+// Abs returns |a|. It asserts that the result is representable, which fails
+// only for the smallest representable value.
+func (f *API[P]) Abs(a Num[P]) Num[P] {
+	f.enforceRange(a)
+	neg := f.isNegative(a.V, f.nbBits)
+	res := f.api.Select(neg, f.api.Neg(a.V), a.V)
+	f.assertInRange(res)
+	return f.newInternal(res)
+}
+
+// ReLU returns max(a, 0).
+func (f *API[P]) ReLU(a Num[P]) Num[P] {
+	f.enforceRange(a)
+	neg := f.isNegative(a.V, f.nbBits)
+	return f.newInternal(f.api.Select(neg, 0, a.V))
+}
+
+// IsNegative returns 1 if a < 0 and 0 otherwise.
+func (f *API[P]) IsNegative(a Num[P]) frontend.Variable {
+	f.enforceRange(a)
+	return f.isNegative(a.V, f.nbBits)
+}
+
+// IsLess returns 1 if a < b and 0 otherwise.
+func (f *API[P]) IsLess(a, b Num[P]) frontend.Variable {
+	f.enforceRange(a)
+	f.enforceRange(b)
+	return f.isNegative(f.api.Sub(a.V, b.V), f.nbBits+1)
+}
+
+// IsLessOrEqual returns 1 if a ≤ b and 0 otherwise.
+func (f *API[P]) IsLessOrEqual(a, b Num[P]) frontend.Variable {
+	return f.api.Sub(1, f.IsLess(b, a))
+}
+
+// IsEqual returns 1 if a == b and 0 otherwise.
+func (f *API[P]) IsEqual(a, b Num[P]) frontend.Variable {
+	f.enforceRange(a)
+	f.enforceRange(b)
+	return f.api.IsZero(f.api.Sub(a.V, b.V))
+}
+
+// Max returns the larger of a and b.
+func (f *API[P]) Max(a, b Num[P]) Num[P] {
+	return f.Select(f.IsLess(a, b), b, a)
+}
+
+// Min returns the smaller of a and b.
+func (f *API[P]) Min(a, b Num[P]) Num[P] {
+	return f.Select(f.IsLess(a, b), a, b)
+}
+
+// AssertIsEqual asserts that a == b.
+func (f *API[P]) AssertIsEqual(a, b Num[P]) {
+	f.api.AssertIsEqual(a.V, b.V)
+}
+
+// AssertIsLessOrEqual asserts that a ≤ b.
+func (f *API[P]) AssertIsLessOrEqual(a, b Num[P]) {
+	f.enforceRange(a)
+	f.enforceRange(b)
+	// b-a is in [0, 2^nbBits) iff a ≤ b, as both are representable.
+	f.checker.Check(f.api.Sub(b.V, a.V), int(f.nbBits))
+}
+
+// enforceRange checks that a non-internal number is representable. For
+// variables we keep track of already checked values to avoid checking the same
+// witness value multiple times.
+func (f *API[P]) enforceRange(a Num[P]) {
+	if a.internal {
+		return
+	}
+	if c, ok := f.api.Compiler().ConstantValue(a.V); ok {
+		sc := f.signedValue(c)
+		bound := new(big.Int).Lsh(big.NewInt(1), f.nbBits-1)
+		if sc.Cmp(bound) >= 0 || sc.Cmp(new(big.Int).Neg(bound)) < 0 {
+			panic("constant not representable")
+		}
+		return
+	}
+	if vv, ok := a.V.(interface{ HashCode() [16]byte }); ok {
+		h := vv.HashCode()
+		if _, ok := f.constrained[h]; ok {
+			return
+		}
+		f.constrained[h] = struct{}{}
+	}
+	f.assertInRange(a.V)
+}
+
+// assertInRange asserts -2^(nbBits-1) ≤ v < 2^(nbBits-1).
+func (f *API[P]) assertInRange(v frontend.Variable) {
+	shifted := f.api.Add(v, new(big.Int).Lsh(big.NewInt(1), f.nbBits-1))
+	f.checker.Check(shifted, int(f.nbBits))
+}
+
+// splitSigned returns hi, lo such that v = hi*2^split + lo and 0 ≤ lo <
+// 2^split. The input must be in range -2^(bound-1) ≤ v < 2^(bound-1) and we
+// require split < bound. hi is signed and lo is non-negative.
+func (f *API[P]) splitSigned(v frontend.Variable, split, bound uint) (hi, lo frontend.Variable) {
+	shifted := f.api.Add(v, new(big.Int).Lsh(big.NewInt(1), bound-1))
+	lo, hiShifted := bitslice.Partition(f.api, shifted, split, bitslice.WithNbDigits(int(bound)))
+	hi = f.api.Sub(hiShifted, new(big.Int).Lsh(big.NewInt(1), bound-1-split))
+	return hi, lo
+}
+
+// isNegative returns 1 if v < 0 and 0 otherwise. The input must be in range
+// -2^(bound-1) ≤ v < 2^(bound-1).
+func (f *API[P]) isNegative(v frontend.Variable, bound uint) frontend.Variable {
+	shifted := f.api.Add(v, new(big.Int).Lsh(big.NewInt(1), bound-1))
+	_, nonNeg := bitslice.Partition(f.api, shifted, bound-1, bitslice.WithNbDigits(int(bound)))
+	return f.api.Sub(1, nonNeg)
+}
+
+// signedValue interprets the native field element as a signed integer.
+func (f *API[P]) signedValue(c *big.Int) *big.Int {
+	return toSigned(f.api.Compiler().Field(), c)
+}
+
+func toSigned(field, c *big.Int) *big.Int {
+	r := new(big.Int).Mod(c, field)
+	half := new(big.Int).Rsh(field, 1)
+	if r.Cmp(half) > 0 {
+		r.Sub(r, field)
+	}
+	return r
+}
+
+// roundFloat rounds to the nearest integer, ties away from zero.
+func roundFloat(v *big.Float) *big.Int {
+	half := big.NewFloat(0.5)
+	t := new(big.Float).Abs(v)
+	t.Add(t, half)
+	r, _ := t.Int(nil)
+	if v.Sign() < 0 {
+		r.Neg(r)
+	}
+	return r
+}
@@ -0,0 +1,185 @@
+package fixedpoint
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+type arithmeticCircuit[P Params] struct {
+	A, B                         Num[P]
+	Sum, Diff, Prod, ProdR, Quot Num[P]
+	AbsA, ReluA, MaxAB           Num[P]
+	Less                         frontend.Variable
+}
+
+func (c *arithmeticCircuit[P]) Define(api frontend.API) error {
+	f, err := New[P](api)
+	if err != nil {
+		return err
+	}
+	f.AssertIsEqual(f.Add(c.A, c.B), c.Sum)
+	f.AssertIsEqual(f.Sub(c.A, c.B), c.Diff)
+	f.AssertIsEqual(f.Mul(c.A, c.B), c.Prod)
+	f.AssertIsEqual(f.MulRound(c.A, c.B), c.ProdR)
+	f.AssertIsEqual(f.Div(c.A, c.B), c.Quot)
+	f.AssertIsEqual(f.Abs(c.A), c.AbsA)
+	f.AssertIsEqual(f.ReLU(c.A), c.ReluA)
+	f.AssertIsEqual(f.Max(c.A, c.B), c.MaxAB)
+	api.AssertIsEqual(f.IsLess(c.A, c.B), c.Less)
+	return nil
+}
+
+func floorDiv(a, b *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+	if r.Sign() != 0 && r.Sign() != b.Sign() {
+		q.Sub(q, big.NewInt(1))
+	}
+	return q
+}
+
+// fieldNum reduces the possibly negative value modulo the native field, as the
+// test engine does not reduce assigned values.
+func fieldNum(v *big.Int) Num[Q16x16] {
+	return Num[Q16x16]{V: new(big.Int).Mod(v, ecc.BN254.ScalarField())}
+}
+
+func arithmeticAssignment(a, b float64) *arithmeticCircuit[Q16x16] {
+	const nbFrac = 16
+	av, bv := ValueOf[Q16x16](a), ValueOf[Q16x16](b)
+	ai, bi := av.V.(*big.Int), bv.V.(*big.Int)
+	scale := new(big.Int).Lsh(big.NewInt(1), nbFrac)
+	prod := new(big.Int).Mul(ai, bi)
+	prodR := new(big.Int).Add(prod, big.NewInt(1<<(nbFrac-1)))
+	abs, relu, mx := new(big.Int).Abs(ai), new(big.Int).Set(ai), new(big.Int).Set(ai)
+	if ai.Sign() < 0 {
+		relu.SetInt64(0)
+	}
+	less := 0
+	if ai.Cmp(bi) < 0 {
+		less = 1
+		mx.Set(bi)
+	}
+	return &arithmeticCircuit[Q16x16]{
+		A:     fieldNum(ai),
+		B:     fieldNum(bi),
+		Sum:   fieldNum(new(big.Int).Add(ai, bi)),
+		Diff:  fieldNum(new(big.Int).Sub(ai, bi)),
+		Prod:  fieldNum(floorDiv(prod, scale)),
+		ProdR: fieldNum(floorDiv(prodR, scale)),
+		Quot:  fieldNum(floorDiv(new(big.Int).Mul(ai, scale), bi)),
+		AbsA:  fieldNum(abs),
+		ReluA: fieldNum(relu),
+		MaxAB: fieldNum(mx),
+		Less:  less,
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	assert := test.NewAssert(t)
+	cases := [][2]float64{
+		{1.5, 2.25},
+		{-1.5, 2.25},
+		{3.1415, -0.001},
+		{-100.125, -7.75},
+		{0, 1},
+		{-0.5, 0.5},
+	}
+	for _, tc := range cases {
+		err := test.IsSolved(&arithmeticCircuit[Q16x16]{}, arithmeticAssignment(tc[0], tc[1]), ecc.BN254.ScalarField())
+		assert.NoError(err, "a=%v b=%v", tc[0], tc[1])
+	}
+	assert.CheckCircuit(&arithmeticCircuit[Q16x16]{},
+		test.WithValidAssignment(arithmeticAssignment(-12.5, 3.75)),
+		test.WithCurves(ecc.BN254))
+}
+
+type overflowCircuit[P Params] struct {
+	A, B Num[P]
+}
+
+func (c *overflowCircuit[P]) Define(api frontend.API) error {
+	f, err := New[P](api)
+	if err != nil {
+		return err
+	}
+	f.Mul(c.A, c.B)
+	return nil
+}
+
+func TestMulOverflow(t *testing.T) {
+	assert := test.NewAssert(t)
+	err := test.IsSolved(&overflowCircuit[Q16x16]{}, &overflowCircuit[Q16x16]{A: ValueOf[Q16x16](100), B: ValueOf[Q16x16](100)}, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	err = test.IsSolved(&overflowCircuit[Q16x16]{}, &overflowCircuit[Q16x16]{A: ValueOf[Q16x16](1000), B: ValueOf[Q16x16](-1000)}, ecc.BN254.ScalarField())
+	assert.Error(err)
+	// input not representable
+	err = test.IsSolved(&overflowCircuit[Q16x16]{}, &overflowCircuit[Q16x16]{A: Num[Q16x16]{V: 1 << 31}, B: ValueOf[Q16x16](0)}, ecc.BN254.ScalarField())
+	assert.Error(err)
+}
+
+type approxCircuit[P Params] struct {
+	X              Num[P]
+	Exp, Log, Sqrt Num[P]
+
+	expTolerance, logTolerance Num[P] `gnark:"-"`
+	skipLog                    bool
+}
+
+func (c *approxCircuit[P]) Define(api frontend.API) error {
+	f, err := New[P](api)
+	if err != nil {
+		return err
+	}
+	assertClose := func(a, b, tolerance Num[P]) {
+		f.AssertIsLessOrEqual(f.Abs(f.Sub(a, b)), tolerance)
+	}
+	assertClose(f.Exp(c.X), c.Exp, c.expTolerance)
+	if !c.skipLog {
+		assertClose(f.Log(c.X), c.Log, c.logTolerance)
+		f.AssertIsEqual(f.Sqrt(c.X), c.Sqrt)
+	}
+	return nil
+}
+
+func TestApproximations(t *testing.T) {
+	assert := test.NewAssert(t)
+	const scale = 1 << 16
+	logTolerance := ValueOf[Q16x16](0.0001)
+	for _, x := range []float64{0.001, 0.5, 1, 2.75, 7.3, 10, -0.25, -3.5, -20} {
+		xv := ValueOf[Q16x16](x)
+		// use the represented value for computing expected results
+		xr := float64(xv.V.(*big.Int).Int64()) / scale
+		xv = fieldNum(xv.V.(*big.Int))
+		exp := math.Exp(xr)
+		circuit := &approxCircuit[Q16x16]{
+			expTolerance: ValueOf[Q16x16](max(0.0001, exp*0.00001)),
+			logTolerance: logTolerance,
+			skipLog:      x <= 0,
+		}
+		assignment := &approxCircuit[Q16x16]{
+			X:    xv,
+			Exp:  ValueOf[Q16x16](exp),
+			Log:  ValueOf[Q16x16](0),
+			Sqrt: Num[Q16x16]{V: 0},
+		}
+		if x > 0 {
+			assignment.Log = fieldNum(ValueOf[Q16x16](math.Log(xr)).V.(*big.Int))
+			assignment.Sqrt = Num[Q16x16]{V: new(big.Int).Sqrt(new(big.Int).Lsh(xv.V.(*big.Int), 16))}
+		}
+		err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField())
+		assert.NoError(err, "x=%v", x)
+	}
+	// exp overflows
+	circuit := &approxCircuit[Q16x16]{expTolerance: logTolerance, skipLog: true}
+	err := test.IsSolved(circuit, &approxCircuit[Q16x16]{X: ValueOf[Q16x16](11), Exp: ValueOf[Q16x16](0), Log: ValueOf[Q16x16](0), Sqrt: ValueOf[Q16x16](0)}, ecc.BN254.ScalarField())
+	assert.Error(err)
+	// log of non-positive value
+	circuit = &approxCircuit[Q16x16]{expTolerance: logTolerance, logTolerance: logTolerance}
+	err = test.IsSolved(circuit, &approxCircuit[Q16x16]{X: fieldNum(big.NewInt(-1 << 16)), Exp: ValueOf[Q16x16](math.Exp(-1)), Log: ValueOf[Q16x16](0), Sqrt: ValueOf[Q16x16](0)}, ecc.BN254.ScalarField())
+	assert.Error(err)
+}
@@ -0,0 +1,86 @@
+package fixedpoint
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/constraint/solver"
+)
+
+func init() {
+	solver.RegisterHint(GetHints()...)
+}
+
+// GetHints returns all hint functions used in the package.
+func GetHints() []solver.Hint {
+	return []solver.Hint{
+		divHint,
+		logHint,
+		sqrtHint,
+	}
+}
+
+// divHint computes the floored division q = floor(a*2^F / b) and the remainder
+// r = a*2^F - q*b for signed a, b. The inputs are F, a, b.
+func divHint(mod *big.Int, inputs, outputs []*big.Int) error {
+	if len(inputs) != 3 {
+		return fmt.Errorf("expected 3 inputs, got %d", len(inputs))
+	}
+	if len(outputs) != 2 {
+		return fmt.Errorf("expected 2 outputs, got %d", len(outputs))
+	}
+	a := toSigned(mod, inputs[1])
+	b := toSigned(mod, inputs[2])
+	if b.Sign() == 0 {
+		return fmt.Errorf("division by zero")
+	}
+	a.Lsh(a, uint(inputs[0].Uint64()))
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+	// QuoRem truncates towards zero, adjust to floor.
+	if r.Sign() != 0 && r.Sign() != b.Sign() {
+		q.Sub(q, big.NewInt(1))
+		r.Add(r, b)
+	}
+	outputs[0].Mod(q, mod)
+	outputs[1].Mod(r, mod)
+	return nil
+}
+
+// logHint computes for positive a the position of the most significant bit p
+// and the mantissa m = floor(a*2^F / 2^p) and remainder r = a*2^F - m*2^p. The
+// inputs are F, a.
+func logHint(mod *big.Int, inputs, outputs []*big.Int) error {
+	if len(inputs) != 2 {
+		return fmt.Errorf("expected 2 inputs, got %d", len(inputs))
+	}
+	if len(outputs) != 3 {
+		return fmt.Errorf("expected 3 outputs, got %d", len(outputs))
+	}
+	a := toSigned(mod, inputs[1])
+	if a.Sign() <= 0 {
+		return fmt.Errorf("logarithm of non-positive value")
+	}
+	p := uint(a.BitLen() - 1)
+	a.Lsh(a, uint(inputs[0].Uint64()))
+	outputs[0].SetUint64(uint64(p))
+	outputs[1].Rsh(a, p)
+	outputs[2].Sub(a, new(big.Int).Lsh(outputs[1], p))
+	return nil
+}
+
+// sqrtHint computes floor(sqrt(a*2^F)) for non-negative a. The inputs are F, a.
+func sqrtHint(mod *big.Int, inputs, outputs []*big.Int) error {
+	if len(inputs) != 2 {
+		return fmt.Errorf("expected 2 inputs, got %d", len(inputs))
+	}
+	if len(outputs) != 1 {
+		return fmt.Errorf("expected 1 output, got %d", len(outputs))
+	}
+	a := toSigned(mod, inputs[1])
+	if a.Sign() < 0 {
+		return fmt.Errorf("square root of negative value")
+	}
+	a.Lsh(a, uint(inputs[0].Uint64()))
+	outputs[0].Sqrt(a)
+	return nil
+}
@@ -0,0 +1,37 @@
+package fixedpoint
+
+import "fmt"
+
+type config struct {
+	tableBits uint
+}
+
+func parseOpts(opts ...Option) (*config, error) {
+	c := &config{tableBits: 8}
+	for _, apply := range opts {
+		if err := apply(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Option allows to customize the behavior of the fixed-point API. See
+// [WithTableBits] for example.
+type Option func(*config) error
+
+// WithTableBits sets the base-2 logarithm of the number of segments in the
+// piecewise linear approximations used by [API.Exp] and [API.Log]. Larger
+// values give more precise approximations but increase the size of the lookup
+// tables. The approximation error decreases quadratically in the number of
+// segments. The default is 8, giving error below 2^-18 relative to the
+// approximated function. The value is capped to the number of fraction bits.
+func WithTableBits(nbBits uint) Option {
+	return func(c *config) error {
+		if nbBits < 1 || nbBits > 16 {
+			return fmt.Errorf("table bits %d not in range [1, 16]", nbBits)
+		}
+		c.tableBits = nbBits
+		return nil
+	}
+}
@@ -0,0 +1,42 @@
+package fixedpoint
+
+// Params defines the widths of the fixed-point representation. The total width
+// of a number is IntegerBits()+FractionBits().
+type Params interface {
+	// IntegerBits returns the number of bits of the integer part, including the
+	// sign bit.
+	IntegerBits() uint
+	// FractionBits returns the number of bits of the fractional part.
+	FractionBits() uint
+}
+
+// Q16x16 provides type parametrization for fixed-point numbers:
+//   - integer bits: 16 (including sign bit)
+//   - fraction bits: 16
+//
+// The representable range is [-32768, 32768) with precision 2^-16.
+type Q16x16 struct{}
+
+func (Q16x16) IntegerBits() uint  { return 16 }
+func (Q16x16) FractionBits() uint { return 16 }
+
+// Q32x32 provides type parametrization for fixed-point numbers:
+//   - integer bits: 32 (including sign bit)
+//   - fraction bits: 32
+//
+// The representable range is [-2^31, 2^31) with precision 2^-32.
+type Q32x32 struct{}
+
+func (Q32x32) IntegerBits() uint  { return 32 }
+func (Q32x32) FractionBits() uint { return 32 }
+
+// Q8x24 provides type parametrization for fixed-point numbers:
+//   - integer bits: 8 (including sign bit)
+//   - fraction bits: 24
+//
+// The representable range is [-128, 128) with precision 2^-24. It is suitable
+// for normalized values, for example activations in neural networks.
+type Q8x24 struct{}
+
+func (Q8x24) IntegerBits() uint  { return 8 }
+func (Q8x24) FractionBits() uint { return 24 }
@@ -0,0 +1,212 @@
+package uints
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// limbBytes returns the number of bytes we pack into a single native limb for
+// arithmetic operations. We need to be able to store the product of two limbs
+// together with the carry in a native element.
+func (bf *BinaryField[T]) limbBytes() int {
+	c := 8
+	for c > 1 && 16*c+16 >= bf.api.Compiler().FieldBitLen() {
+		c /= 2
+	}
+	return min(c, bf.lenBts())
+}
+
+// toLimbs packs the bytes of a into little-endian native limbs of
+// [BinaryField.limbBytes] bytes each.
+func (bf *BinaryField[T]) toLimbs(a T) []frontend.Variable {
+	nbLimbBytes := bf.limbBytes()
+	ret := make([]frontend.Variable, bf.lenBts()/nbLimbBytes)
+	for i := range ret {
+		var v frontend.Variable = 0
+		for j := 0; j < nbLimbBytes; j++ {
+			v = bf.api.Add(v, bf.api.Mul(a[i*nbLimbBytes+j].Val, 1<<(8*j)))
+		}
+		ret[i] = v
+	}
+	return ret
+}
+
+// decompose returns nbBytes bytes and carry such that
+//
+//	v = Σ_i bytes[i] * 2^(8i) + carry * 2^(8*nbBytes),
+//
+// where carry < 2^carryBits. If carryBits is zero, then the returned carry is
+// zero. The caller must ensure that 8*nbBytes+carryBits is less than the width
+// of the native field.
+func (bf *BinaryField[T]) decompose(v frontend.Variable, nbBytes, carryBits int) ([]U8, frontend.Variable) {
+	ret := make([]U8, nbBytes)
+	if vc, ok := bf.api.Compiler().ConstantValue(v); ok {
+		if vc.BitLen() > 8*nbBytes+carryBits {
+			panic("constant value does not fit")
+		}
+		tmp := new(big.Int).Set(vc)
+		for i := range ret {
+			ret[i] = NewU8(uint8(tmp.Uint64() & 0xff))
+			tmp.Rsh(tmp, 8)
+		}
+		return ret, tmp
+	}
+	res, err := bf.api.Compiler().NewHint(decomposeHint, nbBytes+1, nbBytes, v)
+	if err != nil {
+		panic(fmt.Sprintf("decompose hint: %v", err))
+	}
+	var composed frontend.Variable = 0
+	for i := range ret {
+		ret[i] = bf.ByteValueOf(res[i])
+		composed = bf.api.Add(composed, bf.api.Mul(res[i], new(big.Int).Lsh(big.NewInt(1), uint(8*i))))
+	}
+	var carry frontend.Variable = 0
+	if carryBits > 0 {
+		carry = res[nbBytes]
+		bf.rchecker.Check(carry, carryBits)
+		composed = bf.api.Add(composed, bf.api.Mul(carry, new(big.Int).Lsh(big.NewInt(1), uint(8*nbBytes))))
+	}
+	bf.api.AssertIsEqual(composed, v)
+	return ret, carry
+}
+
+// constant returns the constant v as T.
+func (bf *BinaryField[T]) constant(v uint64) T {
+	return newLong[T](new(big.Int).SetUint64(v))
+}
+
+// Select returns a if sel is 1 and b if sel is 0. sel must be boolean.
+func (bf *BinaryField[T]) Select(sel frontend.Variable, a, b T) T {
+	var r T
+	for i := 0; i < len(r); i++ {
+		r[i] = U8{Val: bf.api.Select(sel, a[i].Val, b[i].Val), internal: a[i].internal && b[i].internal}
+	}
+	return r
+}
+
+// Sub returns a-b modulo 2^(8*len(T)).
+func (bf *BinaryField[T]) Sub(a, b T) T {
+	r, _ := bf.sub(a, b)
+	return r
+}
+
+// sub returns a-b modulo 2^(8*len(T)) and the borrow bit, which is 1 iff a <
+// b.
+func (bf *BinaryField[T]) sub(a, b T) (T, frontend.Variable) {
+	al, bl := bf.toLimbs(a), bf.toLimbs(b)
+	nbLimbBytes := bf.limbBytes()
+	base := new(big.Int).Lsh(big.NewInt(1), uint(8*nbLimbBytes))
+	var ret T
+	var borrow frontend.Variable = 0
+	for i := range al {
+		// a_i - b_i - borrow + 2^w ∈ [1, 2^(w+1))
+		s := bf.api.Add(bf.api.Sub(al[i], bl[i], borrow), base)
+		bts, carry := bf.decompose(s, nbLimbBytes, 1)
+		for j := range bts {
+			ret[i*nbLimbBytes+j] = bts[j]
+		}
+		borrow = bf.api.Sub(1, carry)
+	}
+	return ret, borrow
+}
+
+// Neg returns the two's complement negation of a.
+func (bf *BinaryField[T]) Neg(a T) T {
+	return bf.Sub(bf.constant(0), a)
+}
+
+// Mul returns a*b modulo 2^(8*len(T)).
+func (bf *BinaryField[T]) Mul(a, b T) T {
+	var r T
+	bts := bf.mulAdd(a, b, nil, len(bf.toLimbs(a)))
+	for i := 0; i < len(r); i++ {
+		r[i] = bts[i]
+	}
+	return r
+}
+
+// MulWide returns the full product a*b as the low and high halves.
+func (bf *BinaryField[T]) MulWide(a, b T) (lo, hi T) {
+	bts := bf.mulAdd(a, b, nil, 2*len(bf.toLimbs(a)))
+	for i := 0; i < len(lo); i++ {
+		lo[i] = bts[i]
+		hi[i] = bts[len(lo)+i]
+	}
+	return lo, hi
+}
+
+// mulAdd computes a*b+c limb-wise and returns the first nbOutLimbs limbs of
+// the result as bytes. If all the limbs of the result are requested, then we
+// assert that the result does not overflow. c may be shorter than the number
+// of limbs.
+func (bf *BinaryField[T]) mulAdd(a, b T, c []frontend.Variable, nbOutLimbs int) []U8 {
+	al, bl := bf.toLimbs(a), bf.toLimbs(b)
+	nbLimbs := len(al)
+	nbLimbBytes := bf.limbBytes()
+	// every column is less than nbLimbs*2^(2w), and together with the carry
+	// less than (nbLimbs+1)*2^(2w).
+	carryBits := 8*nbLimbBytes + bits.Len(uint(nbLimbs+1))
+	ret := make([]U8, 0, nbOutLimbs*nbLimbBytes)
+	var carry frontend.Variable = 0
+	for k := 0; k < nbOutLimbs; k++ {
+		s := carry
+		for i := max(0, k-nbLimbs+1); i <= k && i < nbLimbs; i++ {
+			s = bf.api.Add(s, bf.api.Mul(al[i], bl[k-i]))
+		}
+		if k < len(c) {
+			s = bf.api.Add(s, c[k])
+		}
+		outCarryBits := carryBits
+		if k == 2*nbLimbs-1 {
+			// the full result fits, the carry must be zero.
+			outCarryBits = 0
+		}
+		var bts []U8
+		bts, carry = bf.decompose(s, nbLimbBytes, outCarryBits)
+		ret = append(ret, bts...)
+	}
+	return ret
+}
+
+// DivRem returns the quotient and remainder of a divided by b. If b is zero,
+// then the quotient is zero and the remainder is a.
+func (bf *BinaryField[T]) DivRem(a, b T) (q, r T) {
+	nbBts := bf.lenBts()
+	inputs := make([]frontend.Variable, 0, 2*nbBts+1)
+	inputs = append(inputs, nbBts)
+	for i := 0; i < nbBts; i++ {
+		inputs = append(inputs, a[i].Val)
+	}
+	for i := 0; i < nbBts; i++ {
+		inputs = append(inputs, b[i].Val)
+	}
+	res, err := bf.api.Compiler().NewHint(divRemHint, 2*nbBts, inputs...)
+	if err != nil {
+		panic(fmt.Sprintf("divrem hint: %v", err))
+	}
+	for i := 0; i < nbBts; i++ {
+		q[i] = bf.ByteValueOf(res[i])
+		r[i] = bf.ByteValueOf(res[nbBts+i])
+	}
+	// q*b + r = a without overflow
+	prod := bf.mulAdd(q, b, bf.toLimbs(r), 2*len(bf.toLimbs(a)))
+	var hi frontend.Variable = 0
+	for i := 0; i < nbBts; i++ {
+		bf.api.AssertIsEqual(prod[i].Val, a[i].Val)
+		hi = bf.api.Add(hi, prod[nbBts+i].Val)
+	}
+	bf.api.AssertIsEqual(hi, 0)
+	// if b != 0 then r < b, otherwise q = 0
+	bIsZero := bf.IsZero(b)
+	rLess := bf.Lt(r, b)
+	bf.api.AssertIsEqual(bf.api.Mul(bf.api.Sub(1, bIsZero), bf.api.Sub(1, rLess)), 0)
+	var qSum frontend.Variable = 0
+	for i := 0; i < nbBts; i++ {
+		qSum = bf.api.Add(qSum, q[i].Val)
+	}
+	bf.api.AssertIsEqual(bf.api.Mul(bIsZero, qSum), 0)
+	return q, r
+}
@@ -0,0 +1,189 @@
+package uints
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+type arithCircuit[T Long] struct {
+	A, B                    T
+	Sum, Diff, Prod, ProdHi T
+	Quo, Rem, SQuo, SRem    T
+	Lt, Gt, Slt, IsZero, Eq frontend.Variable
+	SignExtendPos           frontend.Variable
+	SignExtended, NegA      T
+	ShiftAmount             frontend.Variable
+	Shl, Shr, Sar, ShlConst T
+	ShiftConst              int `gnark:"-"`
+}
+
+func (c *arithCircuit[T]) Define(api frontend.API) error {
+	uapi, err := New[T](api)
+	if err != nil {
+		return err
+	}
+	uapi.AssertEq(uapi.Add(c.A, c.B), c.Sum)
+	uapi.AssertEq(uapi.Sub(c.A, c.B), c.Diff)
+	uapi.AssertEq(uapi.Mul(c.A, c.B), c.Prod)
+	lo, hi := uapi.MulWide(c.A, c.B)
+	uapi.AssertEq(lo, c.Prod)
+	uapi.AssertEq(hi, c.ProdHi)
+	q, r := uapi.DivRem(c.A, c.B)
+	uapi.AssertEq(q, c.Quo)
+	uapi.AssertEq(r, c.Rem)
+	q, r = uapi.SDivRem(c.A, c.B)
+	uapi.AssertEq(q, c.SQuo)
+	uapi.AssertEq(r, c.SRem)
+	api.AssertIsEqual(uapi.Lt(c.A, c.B), c.Lt)
+	api.AssertIsEqual(uapi.Gt(c.A, c.B), c.Gt)
+	api.AssertIsEqual(uapi.Slt(c.A, c.B), c.Slt)
+	api.AssertIsEqual(uapi.IsZero(c.B), c.IsZero)
+	api.AssertIsEqual(uapi.Eq(c.A, c.B), c.Eq)
+	uapi.AssertEq(uapi.Neg(c.A), c.NegA)
+	uapi.AssertEq(uapi.SignExtend(c.A, c.SignExtendPos), c.SignExtended)
+	uapi.AssertEq(uapi.LshiftVar(c.A, c.ShiftAmount), c.Shl)
+	uapi.AssertEq(uapi.RshiftVar(c.A, c.ShiftAmount), c.Shr)
+	uapi.AssertEq(uapi.SRshiftVar(c.A, c.ShiftAmount), c.Sar)
+	uapi.AssertEq(uapi.Lshift(c.A, c.ShiftConst), c.ShlConst)
+	return nil
+}
+
+func toSignedInt(v *big.Int, nbBits int) *big.Int {
+	r := new(big.Int).Set(v)
+	if v.Bit(nbBits-1) == 1 {
+		r.Sub(r, new(big.Int).Lsh(big.NewInt(1), uint(nbBits)))
+	}
+	return r
+}
+
+func fromSignedInt(v *big.Int, nbBits int) *big.Int {
+	mod := new(big.Int).Lsh(big.NewInt(1), uint(nbBits))
+	return new(big.Int).Mod(v, mod)
+}
+
+func arithAssignment[T Long](a, b *big.Int, signExtendPos, shift, shiftConst int) (*arithCircuit[T], *arithCircuit[T]) {
+	var t T
+	nbBits := 8 * len(t)
+	mod := new(big.Int).Lsh(big.NewInt(1), uint(nbBits))
+	m := func(v *big.Int) T { return newLong[T](new(big.Int).Mod(v, mod)) }
+	bool2int := func(b bool) int {
+		if b {
+			return 1
+		}
+		return 0
+	}
+	prod := new(big.Int).Mul(a, b)
+	quo, rem := new(big.Int), new(big.Int).Set(a)
+	squo, srem := new(big.Int), new(big.Int).Set(a)
+	sa, sb := toSignedInt(a, nbBits), toSignedInt(b, nbBits)
+	if b.Sign() != 0 {
+		quo.QuoRem(a, b, rem)
+		squo.QuoRem(sa, sb, srem)
+	}
+	// sign extension
+	signExtended := new(big.Int).Set(a)
+	if a.Bit(8*signExtendPos+7) == 1 {
+		for i := 8*signExtendPos + 8; i < nbBits; i++ {
+			signExtended.SetBit(signExtended, i, 1)
+		}
+	} else {
+		signExtended.Mod(signExtended, new(big.Int).Lsh(big.NewInt(1), uint(8*signExtendPos+8)))
+	}
+	sar := new(big.Int).Rsh(sa, uint(shift))
+	assignment := &arithCircuit[T]{
+		A:             m(a),
+		B:             m(b),
+		Sum:           m(new(big.Int).Add(a, b)),
+		Diff:          m(new(big.Int).Sub(a, b)),
+		Prod:          m(prod),
+		ProdHi:        m(new(big.Int).Rsh(prod, uint(nbBits))),
+		Quo:           m(quo),
+		Rem:           m(rem),
+		SQuo:          m(fromSignedInt(squo, nbBits)),
+		SRem:          m(fromSignedInt(srem, nbBits)),
+		Lt:            bool2int(a.Cmp(b) < 0),
+		Gt:            bool2int(a.Cmp(b) > 0),
+		Slt:           bool2int(sa.Cmp(sb) < 0),
+		IsZero:        bool2int(b.Sign() == 0),
+		Eq:            bool2int(a.Cmp(b) == 0),
+		NegA:          m(new(big.Int).Neg(a)),
+		SignExtendPos: signExtendPos,
+		SignExtended:  m(signExtended),
+		ShiftAmount:   shift,
+		Shl:           m(new(big.Int).Lsh(a, uint(shift))),
+		Shr:           m(new(big.Int).Rsh(a, uint(shift))),
+		Sar:           m(fromSignedInt(sar, nbBits)),
+		ShlConst:      m(new(big.Int).Lsh(a, uint(shiftConst))),
+	}
+	return &arithCircuit[T]{ShiftConst: shiftConst}, assignment
+}
+
+func testArithmetic[T Long](t *testing.T) {
+	assert := test.NewAssert(t)
+	var tt T
+	nbBits := 8 * len(tt)
+	mod := new(big.Int).Lsh(big.NewInt(1), uint(nbBits))
+	half := new(big.Int).Rsh(mod, 1)
+	rnd := func() *big.Int {
+		v, _ := rand.Int(rand.Reader, mod)
+		return v
+	}
+	minusOne := new(big.Int).Sub(mod, big.NewInt(1))
+	cases := [][2]*big.Int{
+		{rnd(), rnd()},
+		{rnd(), rnd()},
+		{rnd(), big.NewInt(0)},
+		{rnd(), big.NewInt(7)},
+		{big.NewInt(3), rnd()},
+		{half, minusOne},
+		{minusOne, minusOne},
+		{big.NewInt(0), big.NewInt(0)},
+	}
+	for i, tc := range cases {
+		signExtendPos := i % len(tt)
+		shift := (i * 37) % (nbBits + 10)
+		circuit, assignment := arithAssignment[T](tc[0], tc[1], signExtendPos, shift, (i*13)%nbBits)
+		err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField())
+		assert.NoError(err, "a=%s b=%s", tc[0], tc[1])
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	t.Run(fmt.Sprintf("%T", U16{}), testArithmetic[U16])
+	t.Run(fmt.Sprintf("%T", U32{}), testArithmetic[U32])
+	t.Run(fmt.Sprintf("%T", U64{}), testArithmetic[U64])
+	t.Run(fmt.Sprintf("%T", U128{}), testArithmetic[U128])
+	t.Run(fmt.Sprintf("%T", U256{}), testArithmetic[U256])
+}
+
+type divRemCircuit struct {
+	A, B, Q, R U256
+}
+
+func (c *divRemCircuit) Define(api frontend.API) error {
+	uapi, err := New[U256](api)
+	if err != nil {
+		return err
+	}
+	q, r := uapi.DivRem(c.A, c.B)
+	uapi.AssertEq(q, c.Q)
+	uapi.AssertEq(r, c.R)
+	return nil
+}
+
+func TestDivRemCompile(t *testing.T) {
+	assert := test.NewAssert(t)
+	a, _ := new(big.Int).SetString("0xdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef", 0)
+	b, _ := new(big.Int).SetString("0x1234567890abcdef1234567890abcdef", 0)
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+	assert.CheckCircuit(&divRemCircuit{},
+		test.WithValidAssignment(&divRemCircuit{A: NewU256(a), B: NewU256(b), Q: NewU256(q), R: NewU256(r)}),
+		test.WithInvalidAssignment(&divRemCircuit{A: NewU256(a), B: NewU256(b), Q: NewU256(new(big.Int).Sub(q, big.NewInt(1))), R: NewU256(new(big.Int).Add(r, b))}),
+		test.WithCurves(ecc.BN254))
+}
@@ -0,0 +1,37 @@
+package uints
+
+import "github.com/consensys/gnark/frontend"
+
+// IsZero returns 1 if a is zero and 0 otherwise.
+func (bf *BinaryField[T]) IsZero(a T) frontend.Variable {
+	// all the bytes are non-negative and their sum does not overflow, so the
+	// sum is zero iff all bytes are zero.
+	var s frontend.Variable = 0
+	for i := 0; i < bf.lenBts(); i++ {
+		s = bf.api.Add(s, a[i].Val)
+	}
+	return bf.api.IsZero(s)
+}
+
+// Eq returns 1 if a == b and 0 otherwise.
+func (bf *BinaryField[T]) Eq(a, b T) frontend.Variable {
+	al, bl := bf.toLimbs(a), bf.toLimbs(b)
+	var res frontend.Variable = 1
+	for i := range al {
+		res = bf.api.Mul(res, bf.api.IsZero(bf.api.Sub(al[i], bl[i])))
+	}
+	return res
+}
+
+// Lt returns 1 if a < b and 0 otherwise, interpreting the inputs as unsigned
+// integers.
+func (bf *BinaryField[T]) Lt(a, b T) frontend.Variable {
+	_, borrow := bf.sub(a, b)
+	return borrow
+}
+
+// Gt returns 1 if a > b and 0 otherwise, interpreting the inputs as unsigned
+// integers.
+func (bf *BinaryField[T]) Gt(a, b T) frontend.Variable {
+	return bf.Lt(b, a)
+}
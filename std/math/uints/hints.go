@@ -16,6 +16,8 @@ func GetHints() []solver.Hint {
 		andHint,
 		xorHint,
 		toBytes,
+		decomposeHint,
+		divRemHint,
 	}
 }
 
@@ -38,10 +40,10 @@ func toBytes(m *big.Int, inputs []*big.Int, outputs []*big.Int) error {
 	}
 	nbLimbs := int(inputs[0].Uint64())
 	if len(outputs) != nbLimbs {
-		return fmt.Errorf("output must be 8 elements")
+		return fmt.Errorf("output must be %d elements", nbLimbs)
 	}
-	if !inputs[1].IsUint64() {
-		return fmt.Errorf("input must be 64 bits")
+	if inputs[1].BitLen() > 8*nbLimbs {
+		return fmt.Errorf("input must be %d bits", 8*nbLimbs)
 	}
 	base := new(big.Int).Lsh(big.NewInt(1), uint(8))
 	tmp := new(big.Int).Set(inputs[1])
@@ -51,3 +53,67 @@ func toBytes(m *big.Int, inputs []*big.Int, outputs []*big.Int) error {
 	}
 	return nil
 }
+
+// decomposeHint decomposes the input into nbBytes bytes and returns the
+// remaining upper part as the last output. The inputs are nbBytes and the value.
+func decomposeHint(m *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	if len(inputs) != 2 {
+		return fmt.Errorf("input must be 2 elements")
+	}
+	if !inputs[0].IsUint64() {
+		return fmt.Errorf("first input must be uint64")
+	}
+	nbBytes := int(inputs[0].Uint64())
+	if len(outputs) != nbBytes+1 {
+		return fmt.Errorf("output must be %d elements", nbBytes+1)
+	}
+	tmp := new(big.Int).Set(inputs[1])
+	mask := big.NewInt(0xff)
+	for i := 0; i < nbBytes; i++ {
+		outputs[i].And(tmp, mask)
+		tmp.Rsh(tmp, 8)
+	}
+	outputs[nbBytes].Set(tmp)
+	return nil
+}
+
+// divRemHint computes the quotient and remainder of unsigned integers given in
+// little-endian bytes. The inputs are the number of bytes n, n bytes of the
+// dividend and n bytes of the divisor. The outputs are n bytes of the quotient
+// and n bytes of the remainder. If the divisor is zero, then the quotient is
+// zero and the remainder is the dividend.
+func divRemHint(m *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	if len(inputs) < 1 || !inputs[0].IsUint64() {
+		return fmt.Errorf("first input must be uint64")
+	}
+	nbBytes := int(inputs[0].Uint64())
+	if len(inputs) != 2*nbBytes+1 {
+		return fmt.Errorf("input must be %d elements", 2*nbBytes+1)
+	}
+	if len(outputs) != 2*nbBytes {
+		return fmt.Errorf("output must be %d elements", 2*nbBytes)
+	}
+	a := recomposeBytes(inputs[1 : 1+nbBytes])
+	b := recomposeBytes(inputs[1+nbBytes:])
+	q, r := new(big.Int), new(big.Int).Set(a)
+	if b.Sign() != 0 {
+		q.QuoRem(a, b, r)
+	}
+	mask := big.NewInt(0xff)
+	for i := 0; i < nbBytes; i++ {
+		outputs[i].And(q, mask)
+		outputs[nbBytes+i].And(r, mask)
+		q.Rsh(q, 8)
+		r.Rsh(r, 8)
+	}
+	return nil
+}
+
+func recomposeBytes(bts []*big.Int) *big.Int {
+	r := new(big.Int)
+	for i := len(bts) - 1; i >= 0; i-- {
+		r.Lsh(r, 8)
+		r.Add(r, bts[i])
+	}
+	return r
+}
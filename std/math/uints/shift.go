@@ -0,0 +1,135 @@
+package uints
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/bitslice"
+)
+
+// nbShiftBits is the maximum width of the variable shift amount.
+const nbShiftBits = 16
+
+// Lshift returns a << c for constant c. Shifting by at least the width of T
+// returns zero.
+func (bf *BinaryField[T]) Lshift(a T, c int) T {
+	lenB := bf.lenBts()
+	var ret T
+	if c >= 8*lenB {
+		return bf.constant(0)
+	}
+	shiftBl := c / 8
+	shiftBt := c % 8
+	if shiftBt == 0 {
+		for i := 0; i < lenB; i++ {
+			if i < shiftBl {
+				ret[i] = NewU8(0)
+			} else {
+				ret[i] = a[i-shiftBl]
+			}
+		}
+		return ret
+	}
+	partitioned := make([][2]frontend.Variable, lenB-shiftBl)
+	for i := range partitioned {
+		lower, upper := bitslice.Partition(bf.api, a[i].Val, uint(8-shiftBt), bitslice.WithNbDigits(8))
+		partitioned[i] = [2]frontend.Variable{lower, upper}
+	}
+	for i := 0; i < lenB; i++ {
+		switch {
+		case i < shiftBl:
+			ret[i] = NewU8(0)
+		case i == shiftBl:
+			ret[i].Val = bf.api.Mul(1<<shiftBt, partitioned[0][0])
+		default:
+			ret[i].Val = bf.api.Add(bf.api.Mul(1<<shiftBt, partitioned[i-shiftBl][0]), partitioned[i-shiftBl-1][1])
+		}
+	}
+	return ret
+}
+
+// LshiftVar returns a << s for variable s. The shift amount must be less than
+// 2^16. Shifting by at least the width of T returns zero.
+func (bf *BinaryField[T]) LshiftVar(a T, s frontend.Variable) T {
+	return bf.shiftVar(a, s, true, 0)
+}
+
+// RshiftVar returns a >> s for variable s (logical shift). The shift amount
+// must be less than 2^16. Shifting by at least the width of T returns zero.
+func (bf *BinaryField[T]) RshiftVar(a T, s frontend.Variable) T {
+	return bf.shiftVar(a, s, false, 0)
+}
+
+// SRshiftVar returns a >> s for variable s, interpreting a as a signed integer
+// (arithmetic shift). The shift amount must be less than 2^16. Shifting by at
+// least the width of T returns -1 for negative and 0 for non-negative a.
+func (bf *BinaryField[T]) SRshiftVar(a T, s frontend.Variable) T {
+	return bf.shiftVar(a, s, false, bf.SignBit(a))
+}
+
+// shiftVar shifts a by s bits left or right. The vacated bits are filled with
+// the boolean fill.
+func (bf *BinaryField[T]) shiftVar(a T, s frontend.Variable, left bool, fill frontend.Variable) T {
+	lenB := bf.lenBts()
+	sBits := bits.ToBinary(bf.api, s, bits.WithNbDigits(nbShiftBits))
+	fillByte := bf.api.Mul(fill, 0xff)
+	cur := make([]frontend.Variable, lenB)
+	for i := range cur {
+		cur[i] = a[i].Val
+	}
+	// first we shift bytes using the bits of s starting from the fourth. If we
+	// would shift by more than the width, then the result is only filled.
+	var overflow frontend.Variable = 0
+	for k := 3; k < nbShiftBits; k++ {
+		step := 1 << (k - 3)
+		if step >= lenB {
+			overflow = bf.api.Or(overflow, sBits[k])
+			continue
+		}
+		next := make([]frontend.Variable, lenB)
+		for i := range next {
+			var shifted frontend.Variable = fillByte
+			if left && i-step >= 0 {
+				shifted = cur[i-step]
+			} else if !left && i+step < lenB {
+				shifted = cur[i+step]
+			}
+			next[i] = bf.api.Select(sBits[k], shifted, cur[i])
+		}
+		cur = next
+	}
+	// now shift by the remaining s mod 8 bits. For left shift we multiply every
+	// byte by 2^(s mod 8) and for right shift by 2^(8 - s mod 8) and split the
+	// result into a lower and upper byte which we recombine with the neighbour.
+	var pow frontend.Variable
+	if left {
+		pow = bf.api.Mul(bf.api.Add(1, sBits[0]), bf.api.Add(1, bf.api.Mul(3, sBits[1])), bf.api.Add(1, bf.api.Mul(15, sBits[2])))
+	} else {
+		pow = bf.api.Mul(2, bf.api.Sub(2, sBits[0]), bf.api.Sub(4, bf.api.Mul(3, sBits[1])), bf.api.Sub(16, bf.api.Mul(15, sBits[2])))
+	}
+	lower := make([]frontend.Variable, lenB)
+	upper := make([]frontend.Variable, lenB)
+	for i := range cur {
+		lower[i], upper[i] = bitslice.Partition(bf.api, bf.api.Mul(cur[i], pow), 8, bitslice.WithNbDigits(16))
+	}
+	var ret T
+	for i := 0; i < lenB; i++ {
+		var v frontend.Variable
+		if left {
+			if i == 0 {
+				v = lower[i]
+			} else {
+				v = bf.api.Add(lower[i], upper[i-1])
+			}
+		} else {
+			if i == lenB-1 {
+				// the lower part of the fill byte is 0xff*pow mod 256 = 256-pow
+				// when filling and zero otherwise.
+				v = bf.api.Add(upper[i], bf.api.Mul(fill, bf.api.Sub(256, pow)))
+			} else {
+				v = bf.api.Add(upper[i], lower[i+1])
+			}
+		}
+		ret[i] = U8{Val: bf.api.Select(overflow, fillByte, v), internal: true}
+	}
+	return ret
+}
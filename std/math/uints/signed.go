@@ -0,0 +1,78 @@
+package uints
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bitslice"
+	"github.com/consensys/gnark/std/selector"
+)
+
+// The methods in this file interpret the integers as signed integers in two's
+// complement representation, i.e. the most significant bit of the most
+// significant byte is the sign bit.
+
+// SignBit returns the sign bit of a, which is 1 iff a is negative when
+// interpreted as a signed integer.
+func (bf *BinaryField[T]) SignBit(a T) frontend.Variable {
+	return bf.byteSignBit(a[bf.lenBts()-1])
+}
+
+func (bf *BinaryField[T]) byteSignBit(a U8) frontend.Variable {
+	_, sign := bitslice.Partition(bf.api, a.Val, 7, bitslice.WithNbDigits(8))
+	return sign
+}
+
+// Slt returns 1 if a < b and 0 otherwise, interpreting the inputs as signed
+// integers.
+func (bf *BinaryField[T]) Slt(a, b T) frontend.Variable {
+	sa, sb := bf.SignBit(a), bf.SignBit(b)
+	// if the signs are equal, then the unsigned comparison gives the correct
+	// result. Otherwise a < b iff a is negative.
+	return bf.api.Select(bf.api.Xor(sa, sb), sa, bf.Lt(a, b))
+}
+
+// Sgt returns 1 if a > b and 0 otherwise, interpreting the inputs as signed
+// integers.
+func (bf *BinaryField[T]) Sgt(a, b T) frontend.Variable {
+	return bf.Slt(b, a)
+}
+
+// SDivRem returns the quotient and remainder of a divided by b, interpreting
+// the inputs as signed integers. The quotient is truncated towards zero and the
+// remainder has the sign of the dividend. If b is zero, then the quotient is
+// zero and the remainder is a. Dividing the smallest negative value by -1
+// overflows and returns the smallest negative value.
+func (bf *BinaryField[T]) SDivRem(a, b T) (q, r T) {
+	sa, sb := bf.SignBit(a), bf.SignBit(b)
+	absA := bf.Select(sa, bf.Neg(a), a)
+	absB := bf.Select(sb, bf.Neg(b), b)
+	q, r = bf.DivRem(absA, absB)
+	q = bf.Select(bf.api.Xor(sa, sb), bf.Neg(q), q)
+	r = bf.Select(sa, bf.Neg(r), r)
+	return q, r
+}
+
+// SignExtend extends the sign of a from the byte at (little-endian) position b,
+// i.e. all the bytes more significant than b are set to 0xff if the most
+// significant bit of the byte b is set and to zero otherwise. The position b
+// must be less than the number of bytes of a.
+func (bf *BinaryField[T]) SignExtend(a T, b frontend.Variable) T {
+	nbBts := bf.lenBts()
+	if nbBts == 1 {
+		return a
+	}
+	vals := make([]frontend.Variable, nbBts)
+	ones := make([]frontend.Variable, nbBts)
+	for i := range vals {
+		vals[i] = a[i].Val
+		ones[i] = 1
+	}
+	sign := bf.byteSignBit(U8{Val: selector.Mux(bf.api, b, vals...)})
+	fill := bf.api.Mul(sign, 0xff)
+	// keep[i] = 1 iff i ≤ b. This also enforces b < nbBts.
+	keep := selector.Partition(bf.api, bf.api.Add(b, 1), false, ones)
+	var r T
+	for i := 0; i < nbBts; i++ {
+		r[i] = U8{Val: bf.api.Select(keep[i], a[i].Val, fill), internal: a[i].internal}
+	}
+	return r
+}
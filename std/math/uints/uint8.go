@@ -11,12 +11,18 @@
 // inefficients circuits.
 //
 // This package performs boolean operations using lookup tables on bytes. So,
-// long integers are split into 4 or 8 bytes and we perform the operations
-// bytewise. In the lookup tables, we store results for all possible 2^8×2^8
+// long integers are split into 2, 4, 8, 16 or 32 bytes and we perform the
+// operations bytewise. In the lookup tables, we store results for all possible 2^8×2^8
 // inputs. With this approach, every bytewise operation costs as single lookup,
 // which depending on the backend is relatively cheap (one to three
 // constraints).
 //
+// Arithmetic operations (addition, subtraction, multiplication, division) pack
+// the bytes into limbs of up to 64 bits and propagate the carries using range
+// checks. The methods prefixed with S (as [BinaryField.Slt],
+// [BinaryField.SDivRem]) interpret the values as signed integers in two's
+// complement representation.
+//
 // NB! The package is still work in progress. The interfaces and implementation
 // details most certainly changes over time. We cannot ensure the soundness of
 // the operations.
@@ -24,6 +30,7 @@ package uints
 
 import (
 	"fmt"
+	"math/big"
 	"math/bits"
 
 	"github.com/consensys/gnark/frontend"
@@ -78,12 +85,17 @@ func (e *U8) GnarkInitHook() {
 	}
 }
 
+type U256 [32]U8
+type U128 [16]U8
 type U64 [8]U8
 type U32 [4]U8
+type U16 [2]U8
 
-type Long interface{ U32 | U64 }
+type Long interface {
+	U16 | U32 | U64 | U128 | U256
+}
 
-type BinaryField[T U32 | U64] struct {
+type BinaryField[T Long] struct {
 	api        frontend.API
 	xorT, andT *logderivprecomp.Precomputed
 	rchecker   frontend.Rangechecker
@@ -117,6 +129,13 @@ func NewU8(v uint8) U8 {
 	return U8{Val: v, internal: true}
 }
 
+func NewU16(v uint16) U16 {
+	return [2]U8{
+		NewU8(uint8((v >> (0 * 8)) & 0xff)),
+		NewU8(uint8((v >> (1 * 8)) & 0xff)),
+	}
+}
+
 func NewU32(v uint32) U32 {
 	return [4]U8{
 		NewU8(uint8((v >> (0 * 8)) & 0xff)),
@@ -139,6 +158,34 @@ func NewU64(v uint64) U64 {
 	}
 }
 
+// NewU128 returns the constant U128 from the non-negative value v. It panics
+// if v is wider than 128 bits.
+func NewU128(v *big.Int) U128 {
+	return newLong[U128](v)
+}
+
+// NewU256 returns the constant U256 from the non-negative value v. It panics
+// if v is wider than 256 bits.
+func NewU256(v *big.Int) U256 {
+	return newLong[U256](v)
+}
+
+func newLong[T Long](v *big.Int) T {
+	var r T
+	if v.Sign() < 0 || v.BitLen() > 8*len(r) {
+		panic(fmt.Sprintf("value %s does not fit into %d bytes", v, len(r)))
+	}
+	bts := v.Bytes()
+	for i := 0; i < len(r); i++ {
+		if i < len(bts) {
+			r[i] = NewU8(bts[len(bts)-i-1])
+		} else {
+			r[i] = NewU8(0)
+		}
+	}
+	return r
+}
+
 func NewU8Array(v []uint8) []U8 {
 	ret := make([]U8, len(v))
 	for i := range v {
@@ -147,6 +194,14 @@ func NewU8Array(v []uint8) []U8 {
 	return ret
 }
 
+func NewU16Array(v []uint16) []U16 {
+	ret := make([]U16, len(v))
+	for i := range v {
+		ret[i] = NewU16(v[i])
+	}
+	return ret
+}
+
 func NewU32Array(v []uint32) []U32 {
 	ret := make([]U32, len(v))
 	for i := range v {
@@ -168,6 +223,8 @@ func (bf *BinaryField[T]) ByteValueOf(a frontend.Variable) U8 {
 	return U8{Val: a, internal: true}
 }
 
+// ValueOf decomposes the native variable a into bytes. The width of T must be
+// smaller than the native field.
 func (bf *BinaryField[T]) ValueOf(a frontend.Variable) T {
 	var r T
 	bts, err := bf.api.Compiler().NewHint(toBytes, len(r), len(r), a)
@@ -184,7 +241,12 @@ func (bf *BinaryField[T]) ValueOf(a frontend.Variable) T {
 	return r
 }
 
+// ToValue packs the bytes of a into a native variable. The width of T must be
+// smaller than the native field.
 func (bf *BinaryField[T]) ToValue(a T) frontend.Variable {
+	if 8*bf.lenBts() >= bf.api.Compiler().FieldBitLen() {
+		panic("integer width does not fit into native field")
+	}
 	v := make([]frontend.Variable, bf.lenBts())
 	for i := range v {
 		v[i] = bf.api.Mul(a[i].Val, 1<<(i*8))
@@ -245,6 +307,25 @@ func (bf *BinaryField[T]) twoArgWideFn(tbl *logderivprecomp.Precomputed, a ...T)
 func (bf *BinaryField[T]) And(a ...T) T { return bf.twoArgWideFn(bf.andT, a...) }
 func (bf *BinaryField[T]) Xor(a ...T) T { return bf.twoArgWideFn(bf.xorT, a...) }
 
+// Or returns the bitwise OR of the inputs. We use the identity
+// a|b = a+b-(a&b), so that it costs a single lookup per byte and per input.
+func (bf *BinaryField[T]) Or(a ...T) T {
+	var r T
+	for i, v := range reslice(a) {
+		r[i] = bf.or(v...)
+	}
+	return r
+}
+
+func (bf *BinaryField[T]) or(a ...U8) U8 {
+	ret := a[0].Val
+	for i := 1; i < len(a); i++ {
+		and := bf.andT.Query(ret, a[i].Val)[0]
+		ret = bf.api.Sub(bf.api.Add(ret, a[i].Val), and)
+	}
+	return U8{Val: ret}
+}
+
 func (bf *BinaryField[T]) not(a U8) U8 {
 	ret := bf.xorT.Query(a.Val, bf.allOne.Val)
 	return U8{Val: ret[0]}
@@ -258,19 +339,33 @@ func (bf *BinaryField[T]) Not(a T) T {
 	return r
 }
 
+// Add returns the sum of the inputs modulo 2^(8*len(T)).
 func (bf *BinaryField[T]) Add(a ...T) T {
-	tLen := bf.lenBts() * 8
-	inLen := len(a)
-	va := make([]frontend.Variable, inLen)
+	if len(a) == 0 {
+		panic("zero-length input")
+	}
+	limbs := make([][]frontend.Variable, len(a))
 	for i := range a {
-		va[i] = bf.ToValue(a[i])
+		limbs[i] = bf.toLimbs(a[i])
 	}
-	vres := bf.api.Add(va[0], va[1], va[2:]...)
-	maxBitlen := bits.Len(uint(inLen)) + tLen
-	// bitslice.Partition below checks that the input is less than 2^maxBitlen and that we have omitted carry correctly
-	vreslow, _ := bitslice.Partition(bf.api, vres, uint(tLen), bitslice.WithNbDigits(maxBitlen), bitslice.WithUnconstrainedOutputs())
-	res := bf.ValueOf(vreslow)
-	return res
+	// the sum of len(a) limbs and the carry from the previous limb is less
+	// than (len(a)+1)*2^limbBits.
+	carryBits := bits.Len(uint(len(a)))
+	nbLimbBytes := bf.limbBytes()
+	var ret T
+	var carry frontend.Variable = 0
+	for i := range limbs[0] {
+		s := carry
+		for j := range limbs {
+			s = bf.api.Add(s, limbs[j][i])
+		}
+		var bts []U8
+		bts, carry = bf.decompose(s, nbLimbBytes, carryBits)
+		for j := range bts {
+			ret[i*nbLimbBytes+j] = bts[j]
+		}
+	}
+	return ret
 }
 
 func (bf *BinaryField[T]) Lrot(a T, c int) T {
@@ -339,7 +434,7 @@ func (bf *BinaryField[T]) lenBts() int {
 	return len(a)
 }
 
-func reslice[T U32 | U64](in []T) [][]U8 {
+func reslice[T Long](in []T) [][]U8 {
 	if len(in) == 0 {
 		panic("zero-length input")
 	}
@@ -126,3 +126,38 @@ func TestAdd(t *testing.T) {
 	err := test.IsSolved(&addCircuit{}, &addCircuit{In: [2]U32{NewU32(^uint32(0)), NewU32(2)}, Expected: NewU32(1)}, ecc.BN254.ScalarField())
 	assert.NoError(err)
 }
+
+type bitwiseCircuit struct {
+	In           [3]U32
+	And, Or, Xor U32
+}
+
+func (c *bitwiseCircuit) Define(api frontend.API) error {
+	uapi, err := New[U32](api)
+	if err != nil {
+		return err
+	}
+	uapi.AssertEq(uapi.And(c.In[:]...), c.And)
+	uapi.AssertEq(uapi.Or(c.In[:]...), c.Or)
+	uapi.AssertEq(uapi.Xor(c.In[:]...), c.Xor)
+	return nil
+}
+
+func TestBitwise(t *testing.T) {
+	assert := test.NewAssert(t)
+	a, b, c := uint32(0x12345678), uint32(0xf0f0f0f0), uint32(0x0a0b0c0d)
+	err := test.IsSolved(&bitwiseCircuit{}, &bitwiseCircuit{
+		In:  [3]U32{NewU32(a), NewU32(b), NewU32(c)},
+		And: NewU32(a & b & c),
+		Or:  NewU32(a | b | c),
+		Xor: NewU32(a ^ b ^ c),
+	}, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	err = test.IsSolved(&bitwiseCircuit{}, &bitwiseCircuit{
+		In:  [3]U32{NewU32(a), NewU32(b), NewU32(c)},
+		And: NewU32(a & b & c),
+		Or:  NewU32(a | b),
+		Xor: NewU32(a ^ b ^ c),
+	}, ecc.BN254.ScalarField())
+	assert.Error(err)
+}
@@ -2,9 +2,13 @@
 //
 // This package chooses the most optimal path for performing range checks:
 //   - if the backend supports native range checking and the frontend exports the variables in the proprietary format by implementing [frontend.Rangechecker], then use it directly;
+//   - if the backend supports fixed lookup tables by implementing [frontend.Lookuper] and the circuit is not compiled with [frontend.WithoutNativeLookups], then we decompose the variables into limbs and look them up in a range table. [scs.NewBuilder] returns a builder which implements this interface;
 //   - if the backend supports creating a commitment of variables by implementing [frontend.Committer], then we use the log-derivative variant [[Haböck22]] of the product argument as in [[BCG+18]] . [r1cs.NewBuilder] returns a builder which implements this interface;
 //   - lacking these, we perform binary decomposition of variable into bits.
 //
+// In the sub-circuit of a component, the builder implements [frontend.ComponentDeferrer] and the
+// checks are done by the range checker of the calling circuit on the variables of every instance.
+//
 // [BCG+18]: https://eprint.iacr.org/2018/380
 // [Haböck22]: https://eprint.iacr.org/2022/1530
 package rangecheck
@@ -13,18 +17,26 @@ import (
 	"github.com/consensys/gnark/constraint/solver"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
 )
 
 // only for documentation purposes. If we import the package then godoc knows
 // how to refer to package r1cs and we get nice links in godoc. We import the
 // package anyway in test.
 var _ = r1cs.NewBuilder
+var _ = scs.NewBuilder
 
 // New returns a new range checker depending on the frontend capabilities.
 func New(api frontend.API) frontend.Rangechecker {
 	if rc, ok := api.(frontend.Rangechecker); ok {
 		return rc
 	}
+	if d, ok := api.(frontend.ComponentDeferrer); ok {
+		return newComponentRangechecker(api, d)
+	}
+	if _, ok := frontend.NativeLookups(api); ok {
+		return newLookupRangechecker(api)
+	}
 	if _, ok := api.(frontend.Committer); ok {
 		return newCommitRangechecker(api)
 	}
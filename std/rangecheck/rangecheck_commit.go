@@ -71,17 +71,24 @@ func (c *commitChecker) commit(api frontend.API) error {
 		return nil
 	}
 	baseLength := c.getOptimalBasewidth(api)
+	decomposed := decompose(api, c.collected, baseLength)
+	nbTable := 1 << baseLength
+	return logderivarg.Build(api, logderivarg.AsTable(c.buildTable(nbTable)), logderivarg.AsTable(decomposed))
+}
+
+// decompose decomposes the collected variables into limbs of baseLength bits
+// and returns the limbs to check for being in [0, 2^baseLength). An additional
+// shifted most significant limb is returned for the variables whose size is not
+// a multiple of baseLength.
+func decompose(api frontend.API, collected []checkedVariable, baseLength int) []frontend.Variable {
 	// decompose into smaller limbs
-	decomposed := make([]frontend.Variable, 0, len(c.collected))
-	collected := make([]frontend.Variable, len(c.collected))
+	decomposed := make([]frontend.Variable, 0, len(collected))
 	coef := new(big.Int)
 	one := big.NewInt(1)
-	for i := range c.collected {
-		// collect all vars for commitment input
-		collected[i] = c.collected[i].v
+	for i := range collected {
 		// decompose value into limbs
-		nbLimbs := decompSize(c.collected[i].bits, baseLength)
-		limbs, err := api.Compiler().NewHint(DecomposeHint, int(nbLimbs), c.collected[i].bits, baseLength, c.collected[i].v)
+		nbLimbs := decompSize(collected[i].bits, baseLength)
+		limbs, err := api.Compiler().NewHint(DecomposeHint, int(nbLimbs), collected[i].bits, baseLength, collected[i].v)
 		if err != nil {
 			panic(fmt.Sprintf("decompose %v", err))
 		}
@@ -92,23 +99,22 @@ func (c *commitChecker) commit(api frontend.API) error {
 		for j := range limbs {
 			composed = api.Add(composed, api.Mul(limbs[j], coef.Lsh(one, uint(baseLength*j))))
 		}
-		api.AssertIsEqual(composed, c.collected[i].v)
+		api.AssertIsEqual(composed, collected[i].v)
 		// we have split the input into nbLimbs partitions of length baseLength.
 		// This ensures that the checked variable is not more than
-		// nbLimbs*baseLength bits, but was requested to be c.collected[i].bits,
+		// nbLimbs*baseLength bits, but was requested to be collected[i].bits,
 		// which may be less. Conditionally add one more check to the most
 		// significant partition. If shift is the difference between
-		// nbLimbs*baseLength and c.collected[i].bits, then check that MS*2^diff
+		// nbLimbs*baseLength and collected[i].bits, then check that MS*2^diff
 		// is also baseLength. Because of both checks for MS and MS*2^diff give
 		// ensure that the value are small we cannot have overflow.
-		shift := nbLimbs*baseLength - c.collected[i].bits
+		shift := nbLimbs*baseLength - collected[i].bits
 		if shift > 0 {
 			msLimbShifted := api.Mul(limbs[nbLimbs-1], coef.Lsh(one, uint(shift)))
 			decomposed = append(decomposed, msLimbShifted)
 		}
 	}
-	nbTable := 1 << baseLength
-	return logderivarg.Build(api, logderivarg.AsTable(c.buildTable(nbTable)), logderivarg.AsTable(decomposed))
+	return decomposed
 }
 
 func decompSize(varSize int, limbSize int) int {
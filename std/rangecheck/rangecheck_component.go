@@ -0,0 +1,50 @@
+package rangecheck
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/kvstore"
+)
+
+type ctxComponentCheckerKey struct{}
+
+// componentChecker collects the checked variables of the sub-circuit of a
+// component and checks the corresponding variables of every instance with the
+// range checker of the calling circuit, so that the checks of all the
+// instances are done together.
+type componentChecker struct {
+	collected []checkedVariable
+}
+
+func newComponentRangechecker(api frontend.API, d frontend.ComponentDeferrer) *componentChecker {
+	kv, ok := api.Compiler().(kvstore.Store)
+	if !ok {
+		panic("builder should implement key-value store")
+	}
+	ch := kv.GetKeyValue(ctxComponentCheckerKey{})
+	if ch != nil {
+		if cht, ok := ch.(*componentChecker); ok {
+			return cht
+		} else {
+			panic("stored rangechecker is not valid")
+		}
+	}
+	cht := &componentChecker{}
+	kv.SetKeyValue(ctxComponentCheckerKey{}, cht)
+	d.DeferInstance(cht.check)
+	return cht
+}
+
+func (c *componentChecker) Check(in frontend.Variable, bits int) {
+	c.collected = append(c.collected, checkedVariable{v: in, bits: bits})
+}
+
+func (c *componentChecker) check(api frontend.API, instance func(frontend.Variable) frontend.Variable) error {
+	if len(c.collected) == 0 {
+		return nil
+	}
+	rc := New(api)
+	for _, v := range c.collected {
+		rc.Check(instance(v.v), v.bits)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package rangecheck
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/kvstore"
+)
+
+type ctxLookupCheckerKey struct{}
+
+// lookupChecker decomposes the checked variables into limbs as commitChecker
+// but checks the limbs against a fixed range table using the native lookup
+// argument of the builder.
+type lookupChecker struct {
+	collected []checkedVariable
+	closed    bool
+}
+
+func newLookupRangechecker(api frontend.API) *lookupChecker {
+	kv, ok := api.Compiler().(kvstore.Store)
+	if !ok {
+		panic("builder should implement key-value store")
+	}
+	ch := kv.GetKeyValue(ctxLookupCheckerKey{})
+	if ch != nil {
+		if cht, ok := ch.(*lookupChecker); ok {
+			return cht
+		} else {
+			panic("stored rangechecker is not valid")
+		}
+	}
+	cht := &lookupChecker{}
+	kv.SetKeyValue(ctxLookupCheckerKey{}, cht)
+	api.Compiler().Defer(cht.check)
+	return cht
+}
+
+func (c *lookupChecker) Check(in frontend.Variable, bits int) {
+	if c.closed {
+		panic("checker already closed")
+	}
+	c.collected = append(c.collected, checkedVariable{v: in, bits: bits})
+}
+
+func (c *lookupChecker) check(api frontend.API) error {
+	if c.closed {
+		return nil
+	}
+	defer func() { c.closed = true }()
+	if len(c.collected) == 0 {
+		return nil
+	}
+	lk, ok := api.(frontend.Lookuper)
+	if !ok {
+		panic("builder should implement lookups")
+	}
+	baseLength := optimalWidth(nbLookupConstraints, c.collected)
+	decomposed := decompose(api, c.collected, baseLength)
+	table := make([][]*big.Int, 1<<baseLength)
+	for i := range table {
+		table[i] = []*big.Int{big.NewInt(int64(i))}
+	}
+	id, err := lk.DefineLookupTable(fmt.Sprintf("range%d", baseLength), table)
+	if err != nil {
+		return fmt.Errorf("define range table: %w", err)
+	}
+	for i := range decomposed {
+		lk.AssertLookup(id, decomposed[i])
+	}
+	return nil
+}
+
+func nbLookupConstraints(baseLength int, collected []checkedVariable) int {
+	nbDecomposed := 0
+	for i := range collected {
+		nbVarLimbs := int(decompSize(collected[i].bits, baseLength))
+		if nbVarLimbs*baseLength > collected[i].bits {
+			nbVarLimbs += 1
+		}
+		nbDecomposed += int(nbVarLimbs)
+	}
+	eqs := nbDecomposed         // check correctness of every decomposition
+	nbRight := nbDecomposed     // a lookup per limb
+	nbleft := (1 << baseLength) // the table is interpolated on the domain of the constraints
+	return nbleft + nbRight + eqs
+}
@@ -6,8 +6,10 @@ import (
 	"testing"
 
 	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
 	"github.com/consensys/gnark/test"
 )
 
@@ -44,3 +46,78 @@ func TestCheck(t *testing.T) {
 	_, err = frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuit, frontend.WithCompressThreshold(100))
 	assert.NoError(err)
 }
+
+type CheckLookupCircuit struct {
+	Vals []frontend.Variable
+	bits int
+}
+
+func (c *CheckLookupCircuit) Define(api frontend.API) error {
+	r := New(api)
+	for i := range c.Vals {
+		r.Check(c.Vals[i], c.bits)
+	}
+	return nil
+}
+
+func TestCheckLookup(t *testing.T) {
+	assert := test.NewAssert(t)
+	bits := 13
+	vals := []frontend.Variable{0, 1, 1<<bits - 1, 1234, 4321}
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &CheckLookupCircuit{Vals: make([]frontend.Variable, len(vals)), bits: bits})
+	assert.NoError(err)
+	assert.NotEmpty(ccs.(constraint.SparseR1CS).GetLookupTables())
+	ccs, err = frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &CheckLookupCircuit{Vals: make([]frontend.Variable, len(vals)), bits: bits}, frontend.WithoutNativeLookups())
+	assert.NoError(err)
+	assert.Empty(ccs.(constraint.SparseR1CS).GetLookupTables())
+	assert.CheckCircuit(&CheckLookupCircuit{Vals: make([]frontend.Variable, len(vals)), bits: bits},
+		test.WithValidAssignment(&CheckLookupCircuit{Vals: vals, bits: bits}),
+		test.WithInvalidAssignment(&CheckLookupCircuit{Vals: []frontend.Variable{0, 1, 1 << bits, 1234, 4321}, bits: bits}),
+		test.WithCurves(ecc.BN254),
+	)
+}
+
+var checkComponent = frontend.Component{
+	Name:     "check",
+	NbInputs: 2,
+	Define: func(api frontend.API, inputs []frontend.Variable) ([]frontend.Variable, error) {
+		r := New(api)
+		r.Check(inputs[0], 8)
+		sum := api.Add(inputs[0], inputs[1])
+		r.Check(sum, 8)
+		return []frontend.Variable{sum}, nil
+	},
+}
+
+type CheckComponentCircuit struct {
+	Vals []frontend.Variable
+}
+
+func (c *CheckComponentCircuit) Define(api frontend.API) error {
+	id, err := api.(frontend.Componenter).DefineComponent(checkComponent)
+	if err != nil {
+		return err
+	}
+	// the same variable can be passed twice, its wire is then referenced
+	// twice by the checked sum
+	for i := range c.Vals {
+		if _, err := api.(frontend.Componenter).CallComponent(id, c.Vals[i], c.Vals[(i+1)%len(c.Vals)]); err != nil {
+			return err
+		}
+	}
+	_, err = api.(frontend.Componenter).CallComponent(id, c.Vals[0], c.Vals[0])
+	return err
+}
+
+func TestCheckComponent(t *testing.T) {
+	assert := test.NewAssert(t)
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &CheckComponentCircuit{Vals: make([]frontend.Variable, 3)})
+	assert.NoError(err)
+	assert.Len(ccs.GetCommitments().CommitmentIndexes(), 1)
+	assert.CheckCircuit(&CheckComponentCircuit{Vals: make([]frontend.Variable, 3)},
+		test.WithValidAssignment(&CheckComponentCircuit{Vals: []frontend.Variable{0, 255, 0}}),
+		test.WithInvalidAssignment(&CheckComponentCircuit{Vals: []frontend.Variable{256, 0, 0}}),
+		test.WithInvalidAssignment(&CheckComponentCircuit{Vals: []frontend.Variable{200, 100, 0}}),
+		test.WithCurves(ecc.BN254),
+	)
+}
@@ -75,6 +75,7 @@ func GetNativeVerifierOptions(outer, field *big.Int) backend.VerifierOption {
 
 type verifierCfg struct {
 	withCompleteArithmetic bool
+	withoutZeroKnowledge   bool
 }
 
 // VerifierOption allows to modify the behaviour of PLONK verifier.
@@ -94,6 +95,16 @@ func WithCompleteArithmetic() VerifierOption {
 	}
 }
 
+// WithoutZeroKnowledge verifies the proofs generated with the
+// [backend.WithoutZeroKnowledge] prover option, whose quotient is split
+// differently. The zero knowledge proofs do not verify with this option.
+func WithoutZeroKnowledge() VerifierOption {
+	return func(cfg *verifierCfg) error {
+		cfg.withoutZeroKnowledge = true
+		return nil
+	}
+}
+
 func newCfg(opts ...VerifierOption) (*verifierCfg, error) {
 	cfg := new(verifierCfg)
 	for i := range opts {
@@ -1,7 +1,9 @@
 package plonk
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 
 	fr_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
 	fr_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
@@ -32,6 +34,10 @@ import (
 	"github.com/consensys/gnark/std/selector"
 )
 
+// errLookupNotSupported is returned when the native proof or verifying key uses
+// the lookup argument of the PLONK backend, which is not implemented in-circuit.
+var errLookupNotSupported = errors.New("in-circuit verification of the lookup argument is not supported, compile the inner circuit with frontend.WithoutNativeLookups")
+
 // Proof is a typed PLONK proof of SNARK. Use [ValueOfProof] to initialize the
 // witness from the native proof. Use [PlaceholderProof] to initialize the
 // placeholder witness for compiling the circuit.
@@ -53,6 +59,10 @@ type Proof[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2Elem
 
 	// Opening proof of Z at zeta*mu
 	ZShiftedOpening kzg.OpeningProof[FR, G1El]
+
+	// Batch opening proof of l, r, o at zeta*mu, if a custom gate uses the
+	// next row. Otherwise the list of claimed values is empty.
+	LROShiftedOpening kzg.BatchOpeningProof[FR, G1El]
 }
 
 // ValueOfProof returns the typed witness of the native proof. It returns an
@@ -67,6 +77,9 @@ func ValueOfProof[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra
 		if !ok {
 			return ret, fmt.Errorf("expected sw_bls12377.Proof, got %T", proof)
 		}
+		if len(tProof.Lookup) != 0 {
+			return ret, errLookupNotSupported
+		}
 		for i := range r.LRO {
 			r.LRO[i], err = kzg.ValueOfCommitment[sw_bls12377.G1Affine](tProof.LRO[i])
 			if err != nil {
@@ -99,11 +112,18 @@ func ValueOfProof[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra
 		if err != nil {
 			return ret, fmt.Errorf("z shifted opening proof value assignment: %w", err)
 		}
+		r.LROShiftedOpening, err = kzg.ValueOfBatchOpeningProof[sw_bls12377.ScalarField, sw_bls12377.G1Affine](tProof.LROShiftedOpening)
+		if err != nil {
+			return ret, fmt.Errorf("l, r, o shifted opening proof value assignment: %w", err)
+		}
 	case *Proof[sw_bls12381.ScalarField, sw_bls12381.G1Affine, sw_bls12381.G2Affine]:
 		tProof, ok := proof.(*plonkbackend_bls12381.Proof)
 		if !ok {
 			return ret, fmt.Errorf("expected sw_bls12381.Proof, got %T", proof)
 		}
+		if len(tProof.Lookup) != 0 {
+			return ret, errLookupNotSupported
+		}
 		for i := range r.LRO {
 			r.LRO[i], err = kzg.ValueOfCommitment[sw_bls12381.G1Affine](tProof.LRO[i])
 			if err != nil {
@@ -136,11 +156,18 @@ func ValueOfProof[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra
 		if err != nil {
 			return ret, fmt.Errorf("z shifted opening proof value assignment: %w", err)
 		}
+		r.LROShiftedOpening, err = kzg.ValueOfBatchOpeningProof[sw_bls12381.ScalarField, sw_bls12381.G1Affine](tProof.LROShiftedOpening)
+		if err != nil {
+			return ret, fmt.Errorf("l, r, o shifted opening proof value assignment: %w", err)
+		}
 	case *Proof[sw_bls24315.ScalarField, sw_bls24315.G1Affine, sw_bls24315.G2Affine]:
 		tProof, ok := proof.(*plonkbackend_bls24315.Proof)
 		if !ok {
 			return ret, fmt.Errorf("expected sw_bls24315.Proof, got %T", proof)
 		}
+		if len(tProof.Lookup) != 0 {
+			return ret, errLookupNotSupported
+		}
 		for i := range r.LRO {
 			r.LRO[i], err = kzg.ValueOfCommitment[sw_bls24315.G1Affine](tProof.LRO[i])
 			if err != nil {
@@ -173,12 +200,19 @@ func ValueOfProof[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra
 		if err != nil {
 			return ret, fmt.Errorf("z shifted opening proof value assignment: %w", err)
 		}
+		r.LROShiftedOpening, err = kzg.ValueOfBatchOpeningProof[sw_bls24315.ScalarField, sw_bls24315.G1Affine](tProof.LROShiftedOpening)
+		if err != nil {
+			return ret, fmt.Errorf("l, r, o shifted opening proof value assignment: %w", err)
+		}
 
 	case *Proof[sw_bw6761.ScalarField, sw_bw6761.G1Affine, sw_bw6761.G2Affine]:
 		tProof, ok := proof.(*plonkbackend_bw6761.Proof)
 		if !ok {
 			return ret, fmt.Errorf("expected sw_bls12377.Proof, got %T", proof)
 		}
+		if len(tProof.Lookup) != 0 {
+			return ret, errLookupNotSupported
+		}
 		for i := range r.LRO {
 			r.LRO[i], err = kzg.ValueOfCommitment[sw_bw6761.G1Affine](tProof.LRO[i])
 			if err != nil {
@@ -211,11 +245,18 @@ func ValueOfProof[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra
 		if err != nil {
 			return ret, fmt.Errorf("z shifted opening proof value assignment: %w", err)
 		}
+		r.LROShiftedOpening, err = kzg.ValueOfBatchOpeningProof[sw_bw6761.ScalarField, sw_bw6761.G1Affine](tProof.LROShiftedOpening)
+		if err != nil {
+			return ret, fmt.Errorf("l, r, o shifted opening proof value assignment: %w", err)
+		}
 	case *Proof[sw_bn254.ScalarField, sw_bn254.G1Affine, sw_bn254.G2Affine]:
 		tProof, ok := proof.(*plonkbackend_bn254.Proof)
 		if !ok {
 			return ret, fmt.Errorf("expected sw_bls12377.Proof, got %T", proof)
 		}
+		if len(tProof.Lookup) != 0 {
+			return ret, errLookupNotSupported
+		}
 		for i := range r.LRO {
 			r.LRO[i], err = kzg.ValueOfCommitment[sw_bn254.G1Affine](tProof.LRO[i])
 			if err != nil {
@@ -248,6 +289,10 @@ func ValueOfProof[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra
 		if err != nil {
 			return ret, fmt.Errorf("z shifted opening proof value assignment: %w", err)
 		}
+		r.LROShiftedOpening, err = kzg.ValueOfBatchOpeningProof[sw_bn254.ScalarField, sw_bn254.G1Affine](tProof.LROShiftedOpening)
+		if err != nil {
+			return ret, fmt.Errorf("l, r, o shifted opening proof value assignment: %w", err)
+		}
 		// TODO: missing bls24317
 	default:
 		return ret, fmt.Errorf("unknown parametric type combination: %T", ret)
@@ -260,11 +305,22 @@ func ValueOfProof[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra
 // [ValueOfProof].
 func PlaceholderProof[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT](ccs constraint.ConstraintSystem) Proof[FR, G1El, G2El] {
 	nbCommitments := len(ccs.GetCommitments().CommitmentIndexes())
+	nbShifted := 0
+	if spr, ok := ccs.(constraint.SparseR1CS); ok {
+		for _, g := range spr.GetCustomGates() {
+			if g.UsesNextRow() {
+				nbShifted = 3
+			}
+		}
+	}
 	ret := Proof[FR, G1El, G2El]{
 		BatchedProof: kzg.BatchOpeningProof[FR, G1El]{
 			ClaimedValues: make([]emulated.Element[FR], 6+nbCommitments),
 		},
 		Bsb22Commitments: make([]kzg.Commitment[G1El], nbCommitments),
+		LROShiftedOpening: kzg.BatchOpeningProof[FR, G1El]{
+			ClaimedValues: make([]emulated.Element[FR], nbShifted),
+		},
 	}
 	return ret
 }
@@ -302,6 +358,54 @@ type CircuitVerifyingKey[FR emulated.FieldParams, G1El algebra.G1ElementT] struc
 	Qcp []kzg.Commitment[G1El]
 
 	CommitmentConstraintIndexes []frontend.Variable
+
+	// Commitments to the selectors of the custom gates and the custom gates
+	// polynomials.
+	Qcg         []kzg.Commitment[G1El]
+	CustomGates []CustomGate[FR]
+}
+
+// CustomGate is a custom gate polynomial
+//
+//	G(l, r, o, l', r', o') = ∑ᵢ Coefficients[i]*l^Exponents[i][0]*r^Exponents[i][1]*o^Exponents[i][2]*
+//	                               l'^Exponents[i][3]*r'^Exponents[i][4]*o'^Exponents[i][5]
+//
+// where l', r', o' are the wires of the next row. The coefficients are part of
+// the witness, but the exponents are fixed at compile time and are taken from
+// the placeholder.
+type CustomGate[FR emulated.FieldParams] struct {
+	Coefficients []emulated.Element[FR]
+	Exponents    [][6]uint8 `gnark:"-"`
+}
+
+// quotientShardFactor returns the factor k such that the shards of the quotient
+// are of size k*(n+2), see [constraint.QuotientShardFactor]. It only depends on
+// the exponents of the custom gates, which are fixed at compile time.
+func (vk CircuitVerifyingKey[FR, G1El]) quotientShardFactor() int {
+	degree := 0
+	for _, g := range vk.CustomGates {
+		for _, e := range g.Exponents {
+			d := 0
+			for j := range e {
+				d += int(e[j])
+			}
+			degree = max(degree, d)
+		}
+	}
+	return constraint.QuotientShardFactor(degree, vk.usesNextRow())
+}
+
+// usesNextRow returns true if a custom gate depends on the wires of the next
+// row, so that the proofs open l, r, o at ωζ. It is fixed at compile time.
+func (vk CircuitVerifyingKey[FR, G1El]) usesNextRow() bool {
+	for _, g := range vk.CustomGates {
+		for _, e := range g.Exponents {
+			if e[3] != 0 || e[4] != 0 || e[5] != 0 {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // VerifyingKey is a typed PLONK verification key. Use [ValueOfVerifyingKey] or
@@ -390,6 +494,9 @@ func ValueOfCircuitVerifyingKey[FR emulated.FieldParams, G1El algebra.G1ElementT
 		if !ok {
 			return ret, fmt.Errorf("expected bls12377.VerifyingKey, got %T", vk)
 		}
+		if len(tVk.Lookup) != 0 {
+			return ret, errLookupNotSupported
+		}
 		r.Size = tVk.Size
 		r.SizeInv = sw_bls12377.NewScalar(tVk.SizeInv)
 		r.Generator = sw_bls12377.NewScalar(tVk.Generator)
@@ -426,6 +533,21 @@ func ValueOfCircuitVerifyingKey[FR emulated.FieldParams, G1El algebra.G1ElementT
 				return ret, fmt.Errorf("commitment Qcp[%d] witness assignment: %w", i, err)
 			}
 		}
+		r.Qcg = make([]kzg.Commitment[sw_bls12377.G1Affine], len(tVk.Qcg))
+		for i := range r.Qcg {
+			r.Qcg[i], err = kzg.ValueOfCommitment[sw_bls12377.G1Affine](tVk.Qcg[i])
+			if err != nil {
+				return ret, fmt.Errorf("commitment Qcg[%d] witness assignment: %w", i, err)
+			}
+		}
+		r.CustomGates = make([]CustomGate[sw_bls12377.ScalarField], len(tVk.CustomGates))
+		for i, g := range tVk.CustomGates {
+			r.CustomGates[i].Exponents = g.Exponents
+			r.CustomGates[i].Coefficients = make([]emulated.Element[sw_bls12377.ScalarField], len(g.Coefficients))
+			for j := range g.Coefficients {
+				r.CustomGates[i].Coefficients[j] = sw_bls12377.NewScalar(g.Coefficients[j])
+			}
+		}
 		r.CommitmentConstraintIndexes = make([]frontend.Variable, len(tVk.CommitmentConstraintIndexes))
 		for i := range r.CommitmentConstraintIndexes {
 			r.CommitmentConstraintIndexes[i] = tVk.CommitmentConstraintIndexes[i]
@@ -435,6 +557,9 @@ func ValueOfCircuitVerifyingKey[FR emulated.FieldParams, G1El algebra.G1ElementT
 		if !ok {
 			return ret, fmt.Errorf("expected bls12381.VerifyingKey, got %T", vk)
 		}
+		if len(tVk.Lookup) != 0 {
+			return ret, errLookupNotSupported
+		}
 		r.Size = tVk.Size
 		r.SizeInv = sw_bls12381.NewScalar(tVk.SizeInv)
 		r.Generator = sw_bls12381.NewScalar(tVk.Generator)
@@ -471,6 +596,21 @@ func ValueOfCircuitVerifyingKey[FR emulated.FieldParams, G1El algebra.G1ElementT
 				return ret, fmt.Errorf("commitment Qcp[%d] witness assignment: %w", i, err)
 			}
 		}
+		r.Qcg = make([]kzg.Commitment[sw_bls12381.G1Affine], len(tVk.Qcg))
+		for i := range r.Qcg {
+			r.Qcg[i], err = kzg.ValueOfCommitment[sw_bls12381.G1Affine](tVk.Qcg[i])
+			if err != nil {
+				return ret, fmt.Errorf("commitment Qcg[%d] witness assignment: %w", i, err)
+			}
+		}
+		r.CustomGates = make([]CustomGate[sw_bls12381.ScalarField], len(tVk.CustomGates))
+		for i, g := range tVk.CustomGates {
+			r.CustomGates[i].Exponents = g.Exponents
+			r.CustomGates[i].Coefficients = make([]emulated.Element[sw_bls12381.ScalarField], len(g.Coefficients))
+			for j := range g.Coefficients {
+				r.CustomGates[i].Coefficients[j] = sw_bls12381.NewScalar(g.Coefficients[j])
+			}
+		}
 		r.CommitmentConstraintIndexes = make([]frontend.Variable, len(tVk.CommitmentConstraintIndexes))
 		for i := range r.CommitmentConstraintIndexes {
 			r.CommitmentConstraintIndexes[i] = tVk.CommitmentConstraintIndexes[i]
@@ -480,6 +620,9 @@ func ValueOfCircuitVerifyingKey[FR emulated.FieldParams, G1El algebra.G1ElementT
 		if !ok {
 			return ret, fmt.Errorf("expected bls24315.VerifyingKey, got %T", vk)
 		}
+		if len(tVk.Lookup) != 0 {
+			return ret, errLookupNotSupported
+		}
 		r.Size = tVk.Size
 		r.SizeInv = sw_bls24315.NewScalar(tVk.SizeInv)
 		r.Generator = sw_bls24315.NewScalar(tVk.Generator)
@@ -516,6 +659,21 @@ func ValueOfCircuitVerifyingKey[FR emulated.FieldParams, G1El algebra.G1ElementT
 				return ret, fmt.Errorf("commitment Qcp[%d] witness assignment: %w", i, err)
 			}
 		}
+		r.Qcg = make([]kzg.Commitment[sw_bls24315.G1Affine], len(tVk.Qcg))
+		for i := range r.Qcg {
+			r.Qcg[i], err = kzg.ValueOfCommitment[sw_bls24315.G1Affine](tVk.Qcg[i])
+			if err != nil {
+				return ret, fmt.Errorf("commitment Qcg[%d] witness assignment: %w", i, err)
+			}
+		}
+		r.CustomGates = make([]CustomGate[sw_bls24315.ScalarField], len(tVk.CustomGates))
+		for i, g := range tVk.CustomGates {
+			r.CustomGates[i].Exponents = g.Exponents
+			r.CustomGates[i].Coefficients = make([]emulated.Element[sw_bls24315.ScalarField], len(g.Coefficients))
+			for j := range g.Coefficients {
+				r.CustomGates[i].Coefficients[j] = sw_bls24315.NewScalar(g.Coefficients[j])
+			}
+		}
 		r.CommitmentConstraintIndexes = make([]frontend.Variable, len(tVk.CommitmentConstraintIndexes))
 		for i := range r.CommitmentConstraintIndexes {
 			r.CommitmentConstraintIndexes[i] = tVk.CommitmentConstraintIndexes[i]
@@ -525,6 +683,9 @@ func ValueOfCircuitVerifyingKey[FR emulated.FieldParams, G1El algebra.G1ElementT
 		if !ok {
 			return ret, fmt.Errorf("expected bls12377.VerifyingKey, got %T", vk)
 		}
+		if len(tVk.Lookup) != 0 {
+			return ret, errLookupNotSupported
+		}
 		r.Size = tVk.Size
 		r.SizeInv = sw_bw6761.NewScalar(tVk.SizeInv)
 		r.Generator = sw_bw6761.NewScalar(tVk.Generator)
@@ -561,6 +722,21 @@ func ValueOfCircuitVerifyingKey[FR emulated.FieldParams, G1El algebra.G1ElementT
 				return ret, fmt.Errorf("commitment Qcp[%d] witness assignment: %w", i, err)
 			}
 		}
+		r.Qcg = make([]kzg.Commitment[sw_bw6761.G1Affine], len(tVk.Qcg))
+		for i := range r.Qcg {
+			r.Qcg[i], err = kzg.ValueOfCommitment[sw_bw6761.G1Affine](tVk.Qcg[i])
+			if err != nil {
+				return ret, fmt.Errorf("commitment Qcg[%d] witness assignment: %w", i, err)
+			}
+		}
+		r.CustomGates = make([]CustomGate[sw_bw6761.ScalarField], len(tVk.CustomGates))
+		for i, g := range tVk.CustomGates {
+			r.CustomGates[i].Exponents = g.Exponents
+			r.CustomGates[i].Coefficients = make([]emulated.Element[sw_bw6761.ScalarField], len(g.Coefficients))
+			for j := range g.Coefficients {
+				r.CustomGates[i].Coefficients[j] = sw_bw6761.NewScalar(g.Coefficients[j])
+			}
+		}
 		r.CommitmentConstraintIndexes = make([]frontend.Variable, len(tVk.CommitmentConstraintIndexes))
 		for i := range r.CommitmentConstraintIndexes {
 			r.CommitmentConstraintIndexes[i] = tVk.CommitmentConstraintIndexes[i]
@@ -570,6 +746,9 @@ func ValueOfCircuitVerifyingKey[FR emulated.FieldParams, G1El algebra.G1ElementT
 		if !ok {
 			return ret, fmt.Errorf("expected bn254.VerifyingKey, got %T", vk)
 		}
+		if len(tVk.Lookup) != 0 {
+			return ret, errLookupNotSupported
+		}
 		r.Size = tVk.Size
 		r.SizeInv = sw_bn254.NewScalar(tVk.SizeInv)
 		r.Generator = sw_bn254.NewScalar(tVk.Generator)
@@ -606,6 +785,21 @@ func ValueOfCircuitVerifyingKey[FR emulated.FieldParams, G1El algebra.G1ElementT
 				return ret, fmt.Errorf("commitment Qcp[%d] witness assignment: %w", i, err)
 			}
 		}
+		r.Qcg = make([]kzg.Commitment[sw_bn254.G1Affine], len(tVk.Qcg))
+		for i := range r.Qcg {
+			r.Qcg[i], err = kzg.ValueOfCommitment[sw_bn254.G1Affine](tVk.Qcg[i])
+			if err != nil {
+				return ret, fmt.Errorf("commitment Qcg[%d] witness assignment: %w", i, err)
+			}
+		}
+		r.CustomGates = make([]CustomGate[sw_bn254.ScalarField], len(tVk.CustomGates))
+		for i, g := range tVk.CustomGates {
+			r.CustomGates[i].Exponents = g.Exponents
+			r.CustomGates[i].Coefficients = make([]emulated.Element[sw_bn254.ScalarField], len(g.Coefficients))
+			for j := range g.Coefficients {
+				r.CustomGates[i].Coefficients[j] = sw_bn254.NewScalar(g.Coefficients[j])
+			}
+		}
 		r.CommitmentConstraintIndexes = make([]frontend.Variable, len(tVk.CommitmentConstraintIndexes))
 		for i := range r.CommitmentConstraintIndexes {
 			r.CommitmentConstraintIndexes[i] = tVk.CommitmentConstraintIndexes[i]
@@ -650,9 +844,23 @@ func PlaceholderBaseVerifyingKey[FR emulated.FieldParams, G1El algebra.G1Element
 // the verification key with same [BaseVerifyingKey].
 func PlaceholderCircuitVerifyingKey[FR emulated.FieldParams, G1El algebra.G1ElementT](ccs constraint.ConstraintSystem) CircuitVerifyingKey[FR, G1El] {
 	commitmentIndexes := ccs.GetCommitments().CommitmentIndexes()
+	var gates []*constraint.BlueprintCustomGate
+	if spr, ok := ccs.(constraint.SparseR1CS); ok {
+		gates = spr.GetCustomGates()
+	}
+	customGates := make([]CustomGate[FR], len(gates))
+	for i, g := range gates {
+		customGates[i].Coefficients = make([]emulated.Element[FR], len(g.Terms))
+		customGates[i].Exponents = make([][6]uint8, len(g.Terms))
+		for j := range g.Terms {
+			customGates[i].Exponents[j] = g.Terms[j].Exponents
+		}
+	}
 	return CircuitVerifyingKey[FR, G1El]{
 		CommitmentConstraintIndexes: make([]frontend.Variable, len(commitmentIndexes)),
 		Qcp:                         make([]kzg.Commitment[G1El], len(commitmentIndexes)),
+		Qcg:                         make([]kzg.Commitment[G1El], len(gates)),
+		CustomGates:                 customGates,
 	}
 }
 
@@ -787,6 +995,13 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) PrepareVerification(vk VerifyingKey[FR,
 	if len(proof.Bsb22Commitments) != len(vk.Qcp) {
 		return nil, nil, nil, fmt.Errorf("BSB22 commitment number mismatch")
 	}
+	if len(vk.Qcg) != len(vk.CustomGates) {
+		return nil, nil, nil, fmt.Errorf("custom gates number mismatch")
+	}
+	usesNextRow := vk.usesNextRow()
+	if (usesNextRow && len(proof.LROShiftedOpening.ClaimedValues) != 3) || (!usesNextRow && len(proof.LROShiftedOpening.ClaimedValues) != 0) {
+		return nil, nil, nil, fmt.Errorf("shifted l, r, o claimed values number mismatch")
+	}
 
 	fs, err := recursion.NewTranscript(v.api, fr.Modulus(), []string{"gamma", "beta", "alpha", "zeta"})
 	if err != nil {
@@ -926,8 +1141,9 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) PrepareVerification(vk VerifyingKey[FR,
 	// computing the linearised polynomial digest
 	// α²*L₁(ζ)*[Z] +
 	// _s1*[s3]+_s2*[Z] + l(ζ)*[Ql] +
-	// l(ζ)r(ζ)*[Qm] + r(ζ)*[Qr] + o(ζ)*[Qo] + [Qk] + ∑ᵢQcp_(ζ)[Pi_i] -
-	// Z_{H}(ζ)*(([H₀] + ζᵐ⁺²*[H₁] + ζ²⁽ᵐ⁺²⁾*[H₂])
+	// l(ζ)r(ζ)*[Qm] + r(ζ)*[Qr] + o(ζ)*[Qo] + [Qk] + ∑ᵢQcp_(ζ)[Pi_i] +
+	// ∑ᵢGᵢ(l(ζ), r(ζ), o(ζ), l(ωζ), r(ωζ), o(ωζ))*[Qcg_i] -
+	// Z_{H}(ζ)*(([H₀] + ζᵐ*[H₁] + ζ²ᵐ*[H₂])
 	// where
 	// _s1 =  α*(l(ζ)+β*s1(β)+γ)*(r(ζ)+β*s2(ζ)+γ)*β*Z(μζ)
 	// _s2 = -α*(l(ζ)+β*ζ+γ)*(r(ζ)+β*u*ζ+γ)*(o(ζ)+β*u²*ζ+γ)
@@ -955,14 +1171,33 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) PrepareVerification(vk VerifyingKey[FR,
 	// l(ζ)*r(ζ)
 	rl := v.scalarApi.Mul(&l, &r)
 
-	// -ζⁿ⁺², -ζ²⁽ⁿ⁺²⁾, -(ζⁿ-1)
+	// -ζᵐ, -ζ²ᵐ, -(ζⁿ-1), where m is the size of the shards of the quotient,
+	// k*(n+2) for the shard factor k of the custom gates, and k*n for the
+	// proofs without zero knowledge
 	zhZeta = v.scalarApi.Neg(zhZeta) // -(ζⁿ-1)
-	zetaPowerNPlusTwo := v.scalarApi.Mul(zeta, zetaPowerN)
-	zetaPowerNPlusTwo = v.scalarApi.Mul(zeta, zetaPowerNPlusTwo)                     // ζⁿ⁺²
-	zetaPowerNPlusTwoSquare := v.scalarApi.Mul(zetaPowerNPlusTwo, zetaPowerNPlusTwo) // ζ²⁽ⁿ⁺²⁾
+	zetaPowerNPlusTwo := zetaPowerN
+	if !cfg.withoutZeroKnowledge {
+		zetaPowerNPlusTwo = v.scalarApi.Mul(zeta, zetaPowerNPlusTwo)
+		zetaPowerNPlusTwo = v.scalarApi.Mul(zeta, zetaPowerNPlusTwo) // ζⁿ⁺²
+	}
+	zetaPowerM := zetaPowerNPlusTwo
+	for i := 1; i < vk.quotientShardFactor(); i++ {
+		zetaPowerM = v.scalarApi.Mul(zetaPowerM, zetaPowerNPlusTwo) // ζᵐ = (ζⁿ⁺²)ᵏ
+	}
+	zetaPowerMSquare := v.scalarApi.Mul(zetaPowerM, zetaPowerM) // ζ²ᵐ
 
-	// [H₀] + ζⁿ⁺²*[H₁] + ζ²⁽ⁿ⁺²⁾*[H₂]
-	foldedH, err := v.curve.MultiScalarMul([]*G1El{&proof.H[1].G1El, &proof.H[2].G1El}, []*emulated.Element[FR]{zetaPowerNPlusTwo, zetaPowerNPlusTwoSquare})
+	var msmOpts []algopts.AlgebraOption
+	if cfg.withCompleteArithmetic {
+		msmOpts = append(msmOpts, algopts.WithCompleteArithmetic())
+	}
+
+	// [H₀] + ζᵐ*[H₁] + ζ²ᵐ*[H₂]. When l, r, o are opened at ωζ the shards are
+	// twice larger and [H₂] is usually the point at infinity.
+	hOpts := msmOpts
+	if usesNextRow {
+		hOpts = []algopts.AlgebraOption{algopts.WithCompleteArithmetic()}
+	}
+	foldedH, err := v.curve.MultiScalarMul([]*G1El{&proof.H[1].G1El, &proof.H[2].G1El}, []*emulated.Element[FR]{zetaPowerM, zetaPowerMSquare}, hOpts...)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("folded H: %w", err)
 	}
@@ -987,11 +1222,15 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) PrepareVerification(vk VerifyingKey[FR,
 		_s1, coeffZ, // second part
 		zhZeta, // third part
 	)
-
-	var msmOpts []algopts.AlgebraOption
-	if cfg.withCompleteArithmetic {
-		msmOpts = append(msmOpts, algopts.WithCompleteArithmetic())
+	shiftedLRO := make([]*emulated.Element[FR], 3)
+	for i := range proof.LROShiftedOpening.ClaimedValues {
+		shiftedLRO[i] = &proof.LROShiftedOpening.ClaimedValues[i]
 	}
+	for i := range vk.CustomGates {
+		points = append(points, &vk.Qcg[i].G1El)
+		scalars = append(scalars, v.evaluateCustomGate(vk.CustomGates[i], &l, &r, &o, shiftedLRO[0], shiftedLRO[1], shiftedLRO[2]))
+	}
+
 	linearizedPolynomialDigest, err := v.curve.MultiScalarMul(points, scalars, msmOpts...)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("linearized polynomial digest MSM: %w", err)
@@ -1012,11 +1251,12 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) PrepareVerification(vk VerifyingKey[FR,
 	digestsToFold[3] = proof.LRO[2]
 	digestsToFold[4] = vk.S[0]
 	digestsToFold[5] = vk.S[1]
+	dataTranscript := append([]emulated.Element[FR]{zu}, proof.LROShiftedOpening.ClaimedValues...)
 	foldedProof, foldedDigest, err := v.kzg.FoldProof(
 		digestsToFold,
 		proof.BatchedProof,
 		*zeta,
-		zu,
+		dataTranscript...,
 	)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("fold kzg proof: %w", err)
@@ -1026,6 +1266,16 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) PrepareVerification(vk VerifyingKey[FR,
 	resCommitments := []kzg.Commitment[G1El]{foldedDigest, proof.Z}
 	resProofs := []kzg.OpeningProof[FR, G1El]{foldedProof, proof.ZShiftedOpening}
 	resPoints := []emulated.Element[FR]{*zeta, *shiftedZeta}
+	if usesNextRow {
+		// fold the opening of l, r, o at ωζ
+		foldedLROProof, foldedLRODigest, err := v.kzg.FoldProof(proof.LRO[:], proof.LROShiftedOpening, *shiftedZeta)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("fold shifted l, r, o kzg proof: %w", err)
+		}
+		resCommitments = append(resCommitments, foldedLRODigest)
+		resProofs = append(resProofs, foldedLROProof)
+		resPoints = append(resPoints, *shiftedZeta)
+	}
 
 	return resCommitments, resProofs, resPoints, nil
 }
@@ -1144,6 +1394,31 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) bindPublicData(fs *fiatshamir.Transcrip
 			return err
 		}
 	}
+	for i := range vk.Qcg {
+		if err := fs.Bind(challenge, v.curve.MarshalG1(vk.Qcg[i].G1El)); err != nil {
+			return err
+		}
+	}
+	// custom gates, encoded as in the native verifier
+	for _, g := range vk.CustomGates {
+		nbTerms := emulated.ValueOf[FR](len(g.Coefficients))
+		if err := fs.Bind(challenge, v.curve.MarshalScalar(nbTerms)); err != nil {
+			return err
+		}
+		for i := range g.Coefficients {
+			if err := fs.Bind(challenge, v.curve.MarshalScalar(g.Coefficients[i])); err != nil {
+				return err
+			}
+			var packed uint64
+			for j := range g.Exponents[i] {
+				packed |= uint64(g.Exponents[i][j]) << (8 * j)
+			}
+			exponents := emulated.ValueOf[FR](packed)
+			if err := fs.Bind(challenge, v.curve.MarshalScalar(exponents)); err != nil {
+				return err
+			}
+		}
+	}
 
 	// public inputs
 	for i := 0; i < len(witness.Public); i++ {
@@ -1155,6 +1430,23 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) bindPublicData(fs *fiatshamir.Transcrip
 	return nil
 }
 
+// evaluateCustomGate returns G(l, r, o, l', r', o') for the custom gate G. l',
+// r', o' may be nil if G does not use the next row.
+func (v *Verifier[FR, G1El, G2El, GtEl]) evaluateCustomGate(g CustomGate[FR], l, r, o, ls, rs, os *emulated.Element[FR]) *emulated.Element[FR] {
+	res := v.scalarApi.Zero()
+	wires := [6]*emulated.Element[FR]{l, r, o, ls, rs, os}
+	for i := range g.Coefficients {
+		t := &g.Coefficients[i]
+		for j := range wires {
+			for k := uint8(0); k < g.Exponents[i][j]; k++ {
+				t = v.scalarApi.Mul(t, wires[j])
+			}
+		}
+		res = v.scalarApi.Add(res, t)
+	}
+	return res
+}
+
 func (v *Verifier[FR, G1El, G2El, GtEl]) deriveRandomness(fs *fiatshamir.Transcript, challenge string, points ...G1El) (*emulated.Element[FR], error) {
 	var fr FR
 	for i := range points {
@@ -1228,6 +1520,7 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) SwitchVerificationKey(bvk BaseVerifying
 	}
 	nbIns := len(cvks)
 	nbCmts := len(cvks[0].CommitmentConstraintIndexes)
+	gates := cvks[0].CustomGates
 	for i := range cvks {
 		if len(cvks[i].CommitmentConstraintIndexes) != nbCmts {
 			return ret, fmt.Errorf("mismatching number of commitments")
@@ -1235,6 +1528,14 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) SwitchVerificationKey(bvk BaseVerifying
 		if len(cvks[i].Qcp) != nbCmts {
 			return ret, fmt.Errorf("inconsistent circuit verification key")
 		}
+		if len(cvks[i].CustomGates) != len(gates) || len(cvks[i].Qcg) != len(gates) {
+			return ret, fmt.Errorf("mismatching number of custom gates")
+		}
+		for j := range gates {
+			if !slices.Equal(cvks[i].CustomGates[j].Exponents, gates[j].Exponents) {
+				return ret, fmt.Errorf("mismatching custom gate %d", j)
+			}
+		}
 	}
 	sizeEls := make([]frontend.Variable, nbIns)
 	sizeInvEls := make([]*emulated.Element[FR], nbIns)
@@ -1292,6 +1593,24 @@ func (v *Verifier[FR, G1El, G2El, GtEl]) SwitchVerificationKey(bvk BaseVerifying
 		cvk.Qcp[i] = kzg.Commitment[G1El]{G1El: *v.curve.Mux(idx, QcpEls[i]...)}
 		cvk.CommitmentConstraintIndexes[i] = selector.Mux(v.api, idx, cmtIndicesEls[i]...)
 	}
+	cvk.Qcg = make([]kzg.Commitment[G1El], len(gates))
+	cvk.CustomGates = make([]CustomGate[FR], len(gates))
+	for i := range gates {
+		QcgEls := make([]*G1El, nbIns)
+		for j := range cvks {
+			QcgEls[j] = &cvks[j].Qcg[i].G1El
+		}
+		cvk.Qcg[i] = kzg.Commitment[G1El]{G1El: *v.curve.Mux(idx, QcgEls...)}
+		cvk.CustomGates[i].Exponents = gates[i].Exponents
+		cvk.CustomGates[i].Coefficients = make([]emulated.Element[FR], len(gates[i].Coefficients))
+		for k := range gates[i].Coefficients {
+			coeffEls := make([]*emulated.Element[FR], nbIns)
+			for j := range cvks {
+				coeffEls[j] = &cvks[j].CustomGates[i].Coefficients[k]
+			}
+			cvk.CustomGates[i].Coefficients[k] = *v.scalarApi.Mux(idx, coeffEls...)
+		}
+	}
 	return VerifyingKey[FR, G1El, G2El]{
 		BaseVerifyingKey:    bvk,
 		CircuitVerifyingKey: cvk,
@@ -10,6 +10,7 @@ import (
 	"github.com/consensys/gnark-crypto/ecc"
 	fr_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
 	kzg_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/kzg"
+	"github.com/consensys/gnark/backend"
 	native_plonk "github.com/consensys/gnark/backend/plonk"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
@@ -323,6 +324,208 @@ func TestBN254InBN254Commit(t *testing.T) {
 	assert.NoError(err)
 }
 
+//-----------------------------------------------------------------
+// Without zero knowledge
+
+type OuterCircuitWithoutZeroKnowledge[FR emulated.FieldParams, G1El algebra.G1ElementT, G2El algebra.G2ElementT, GtEl algebra.GtElementT] struct {
+	Proof        Proof[FR, G1El, G2El]
+	VerifyingKey VerifyingKey[FR, G1El, G2El] `gnark:"-"`
+	InnerWitness Witness[FR]                  `gnark:",public"`
+}
+
+func (c *OuterCircuitWithoutZeroKnowledge[FR, G1El, G2El, GtEl]) Define(api frontend.API) error {
+	verifier, err := NewVerifier[FR, G1El, G2El, GtEl](api)
+	if err != nil {
+		return fmt.Errorf("new verifier: %w", err)
+	}
+	return verifier.AssertProof(c.VerifyingKey, c.Proof, c.InnerWitness, WithCompleteArithmetic(), WithoutZeroKnowledge())
+}
+
+func TestBLS12InBW6WithoutZeroKnowledge(t *testing.T) {
+
+	assert := test.NewAssert(t)
+	field, outer := ecc.BLS12_377.ScalarField(), ecc.BW6_761.ScalarField()
+	innerCcs, err := frontend.Compile(field, scs.NewBuilder, &InnerCircuitCommit{})
+	assert.NoError(err)
+	srs, srsLagrange, err := unsafekzg.NewSRS(innerCcs)
+	assert.NoError(err)
+	innerPK, innerVK, err := native_plonk.Setup(innerCcs, srs, srsLagrange)
+	assert.NoError(err)
+	innerWitness, err := frontend.NewWitness(&InnerCircuitCommit{P: 3, Q: 5, N: 15}, field)
+	assert.NoError(err)
+	innerProof, err := native_plonk.Prove(innerCcs, innerPK, innerWitness, GetNativeProverOptions(outer, field), backend.WithoutZeroKnowledge())
+	assert.NoError(err)
+	innerPubWitness, err := innerWitness.Public()
+	assert.NoError(err)
+	err = native_plonk.Verify(innerProof, innerVK, innerPubWitness, GetNativeVerifierOptions(outer, field), backend.WithVerifierWithoutZeroKnowledge())
+	assert.NoError(err)
+
+	// outer proof
+	circuitVk, err := ValueOfVerifyingKey[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine](innerVK)
+	assert.NoError(err)
+	circuitWitness, err := ValueOfWitness[sw_bls12377.ScalarField](innerPubWitness)
+	assert.NoError(err)
+	circuitProof, err := ValueOfProof[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine](innerProof)
+	assert.NoError(err)
+
+	outerCircuit := &OuterCircuitWithoutZeroKnowledge[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT]{
+		InnerWitness: PlaceholderWitness[sw_bls12377.ScalarField](innerCcs),
+		Proof:        PlaceholderProof[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine](innerCcs),
+		VerifyingKey: circuitVk,
+	}
+	outerAssignment := &OuterCircuitWithoutZeroKnowledge[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT]{
+		InnerWitness: circuitWitness,
+		Proof:        circuitProof,
+	}
+	err = test.IsSolved(outerCircuit, outerAssignment, outer)
+	assert.NoError(err)
+
+	// the zero knowledge verifier rejects the proof
+	zkOuterCircuit := &OuterCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT]{
+		InnerWitness: PlaceholderWitness[sw_bls12377.ScalarField](innerCcs),
+		Proof:        PlaceholderProof[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine](innerCcs),
+		VerifyingKey: circuitVk,
+	}
+	zkOuterAssignment := &OuterCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT]{
+		InnerWitness: circuitWitness,
+		Proof:        circuitProof,
+	}
+	err = test.IsSolved(zkOuterCircuit, zkOuterAssignment, outer)
+	assert.Error(err)
+}
+
+//-----------------------------------------------------------------
+// With custom gates
+
+type InnerCircuitCustomGate struct {
+	P frontend.Variable
+	N frontend.Variable `gnark:",public"`
+}
+
+func (c *InnerCircuitCustomGate) Define(api frontend.API) error {
+	cg, ok := api.(frontend.CustomGater)
+	if !ok {
+		return fmt.Errorf("builder does not implement frontend.CustomGater")
+	}
+	// l⁵ + 2⋅l⋅r - o = 0, of degree 5 so that the shards of the quotient are
+	// twice larger
+	id, err := cg.DefineCustomGate(frontend.CustomGate{Name: "pow5", Terms: []frontend.CustomGateTerm{
+		{Coeff: big.NewInt(1), A: 5},
+		{Coeff: big.NewInt(2), A: 1, B: 1},
+		{Coeff: big.NewInt(-1), C: 1},
+	}})
+	if err != nil {
+		return err
+	}
+	res := cg.EvaluateCustomGate(id, c.P, c.P)
+	api.AssertIsEqual(res, c.N)
+	return nil
+}
+
+func TestBLS12InBW6CustomGate(t *testing.T) {
+
+	assert := test.NewAssert(t)
+	field, outer := ecc.BLS12_377.ScalarField(), ecc.BW6_761.ScalarField()
+	innerCcs, err := frontend.Compile(field, scs.NewBuilder, &InnerCircuitCustomGate{})
+	assert.NoError(err)
+	srs, srsLagrange, err := unsafekzg.NewSRS(innerCcs)
+	assert.NoError(err)
+	innerPK, innerVK, err := native_plonk.Setup(innerCcs, srs, srsLagrange)
+	assert.NoError(err)
+	innerWitness, err := frontend.NewWitness(&InnerCircuitCustomGate{P: 3, N: 243 + 18}, field)
+	assert.NoError(err)
+	innerProof, err := native_plonk.Prove(innerCcs, innerPK, innerWitness, GetNativeProverOptions(outer, field))
+	assert.NoError(err)
+	innerPubWitness, err := innerWitness.Public()
+	assert.NoError(err)
+	err = native_plonk.Verify(innerProof, innerVK, innerPubWitness, GetNativeVerifierOptions(outer, field))
+	assert.NoError(err)
+
+	// outer proof
+	circuitVk, err := ValueOfVerifyingKey[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine](innerVK)
+	assert.NoError(err)
+	circuitWitness, err := ValueOfWitness[sw_bls12377.ScalarField](innerPubWitness)
+	assert.NoError(err)
+	circuitProof, err := ValueOfProof[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine](innerProof)
+	assert.NoError(err)
+
+	outerCircuit := &OuterCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT]{
+		InnerWitness: PlaceholderWitness[sw_bls12377.ScalarField](innerCcs),
+		Proof:        PlaceholderProof[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine](innerCcs),
+		VerifyingKey: circuitVk,
+	}
+	outerAssignment := &OuterCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT]{
+		InnerWitness: circuitWitness,
+		Proof:        circuitProof,
+	}
+	err = test.IsSolved(outerCircuit, outerAssignment, outer)
+	assert.NoError(err)
+}
+
+type InnerCircuitCustomGateNextRow struct {
+	P frontend.Variable
+	N frontend.Variable `gnark:",public"`
+}
+
+func (c *InnerCircuitCustomGateNextRow) Define(api frontend.API) error {
+	cg, ok := api.(frontend.CustomGater)
+	if !ok {
+		return fmt.Errorf("builder does not implement frontend.CustomGater")
+	}
+	// l⋅r + o + r'⋅o' - l' = 0, so that l, r, o are also opened at ωζ
+	id, err := cg.DefineCustomGate(frontend.CustomGate{Name: "step", Terms: []frontend.CustomGateTerm{
+		{Coeff: big.NewInt(1), A: 1, B: 1},
+		{Coeff: big.NewInt(1), C: 1},
+		{Coeff: big.NewInt(1), NextB: 1, NextC: 1},
+		{Coeff: big.NewInt(-1), NextA: 1},
+	}})
+	if err != nil {
+		return err
+	}
+	cg.AssertCustomGate(id, c.P, c.P, c.P, c.N, c.P, 1)
+	return nil
+}
+
+func TestBLS12InBW6CustomGateNextRow(t *testing.T) {
+
+	assert := test.NewAssert(t)
+	field, outer := ecc.BLS12_377.ScalarField(), ecc.BW6_761.ScalarField()
+	innerCcs, err := frontend.Compile(field, scs.NewBuilder, &InnerCircuitCustomGateNextRow{})
+	assert.NoError(err)
+	srs, srsLagrange, err := unsafekzg.NewSRS(innerCcs)
+	assert.NoError(err)
+	innerPK, innerVK, err := native_plonk.Setup(innerCcs, srs, srsLagrange)
+	assert.NoError(err)
+	innerWitness, err := frontend.NewWitness(&InnerCircuitCustomGateNextRow{P: 3, N: 9 + 3 + 3}, field)
+	assert.NoError(err)
+	innerProof, err := native_plonk.Prove(innerCcs, innerPK, innerWitness, GetNativeProverOptions(outer, field))
+	assert.NoError(err)
+	innerPubWitness, err := innerWitness.Public()
+	assert.NoError(err)
+	err = native_plonk.Verify(innerProof, innerVK, innerPubWitness, GetNativeVerifierOptions(outer, field))
+	assert.NoError(err)
+
+	// outer proof
+	circuitVk, err := ValueOfVerifyingKey[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine](innerVK)
+	assert.NoError(err)
+	circuitWitness, err := ValueOfWitness[sw_bls12377.ScalarField](innerPubWitness)
+	assert.NoError(err)
+	circuitProof, err := ValueOfProof[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine](innerProof)
+	assert.NoError(err)
+
+	outerCircuit := &OuterCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT]{
+		InnerWitness: PlaceholderWitness[sw_bls12377.ScalarField](innerCcs),
+		Proof:        PlaceholderProof[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine](innerCcs),
+		VerifyingKey: circuitVk,
+	}
+	outerAssignment := &OuterCircuit[sw_bls12377.ScalarField, sw_bls12377.G1Affine, sw_bls12377.G2Affine, sw_bls12377.GT]{
+		InnerWitness: circuitWitness,
+		Proof:        circuitProof,
+	}
+	err = test.IsSolved(outerCircuit, outerAssignment, outer)
+	assert.NoError(err)
+}
+
 type InnerCircuitParametric struct {
 	X         frontend.Variable
 	Y         frontend.Variable `gnark:",public"`
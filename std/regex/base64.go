@@ -0,0 +1,150 @@
+package regex
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/kvstore"
+	"github.com/consensys/gnark/std/lookup/logderivlookup"
+	"github.com/consensys/gnark/std/math/bitslice"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/std/rangecheck"
+)
+
+const (
+	// padValue is the decoded value of the padding character.
+	padValue = 64
+	// invalidValue is the decoded value of characters not in the alphabet.
+	invalidValue = 0xff
+)
+
+// Encoding is a Base64 encoding defined by an alphabet and the use of padding
+// as described in RFC 4648.
+type Encoding struct {
+	alphabet string
+	padding  bool
+}
+
+var (
+	// StdEncoding is the standard Base64 encoding with padding.
+	StdEncoding = &Encoding{alphabet: "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/", padding: true}
+	// URLEncoding is the URL and filename safe Base64 encoding with padding.
+	URLEncoding = &Encoding{alphabet: "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_", padding: true}
+	// RawStdEncoding is the standard Base64 encoding without padding.
+	RawStdEncoding = &Encoding{alphabet: StdEncoding.alphabet, padding: false}
+	// RawURLEncoding is the URL and filename safe Base64 encoding without
+	// padding. It is used in JWTs.
+	RawURLEncoding = &Encoding{alphabet: URLEncoding.alphabet, padding: false}
+)
+
+type ctxBase64Key struct{ enc *Encoding }
+
+// decodeTable returns the lookup table mapping characters to their values. The
+// table is shared for all decodings with the same encoding in the circuit.
+func (enc *Encoding) decodeTable(api frontend.API) *logderivlookup.Table {
+	kv, ok := api.Compiler().(kvstore.Store)
+	if !ok {
+		panic("builder should implement key-value store")
+	}
+	if t := kv.GetKeyValue(ctxBase64Key{enc: enc}); t != nil {
+		if tt, ok := t.(*logderivlookup.Table); ok {
+			return tt
+		}
+		panic("stored table is not valid")
+	}
+	var values [alphabetSize]int
+	for i := range values {
+		values[i] = invalidValue
+	}
+	for i := 0; i < len(enc.alphabet); i++ {
+		values[enc.alphabet[i]] = i
+	}
+	if enc.padding {
+		values['='] = padValue
+	}
+	t := logderivlookup.New(api)
+	for i := range values {
+		t.Insert(values[i])
+	}
+	kv.SetKeyValue(ctxBase64Key{enc: enc}, t)
+	return t
+}
+
+// DecodeBase64 decodes the Base64-encoded input using the encoding enc. It
+// returns the decoded bytes and the number of decoded bytes. If the encoding
+// uses padding, then the length of the input must be a multiple of 4 and the
+// returned slice has length 3*len(input)/4, where the bytes corresponding to
+// the padding are zero. Otherwise, the length of the input modulo 4 must not be
+// 1 and all the returned bytes are decoded.
+//
+// Characters not in the alphabet of the encoding make the circuit
+// unsatisfiable. As in the default mode of [encoding/base64], the unused bits
+// of the last character are not checked to be zero.
+func DecodeBase64(api frontend.API, enc *Encoding, input []uints.U8) (decoded []uints.U8, length frontend.Variable, err error) {
+	if enc.padding && len(input)%4 != 0 {
+		return nil, nil, fmt.Errorf("padded input length %d is not a multiple of 4", len(input))
+	}
+	if len(input)%4 == 1 {
+		return nil, nil, fmt.Errorf("invalid input length %d", len(input))
+	}
+	if len(input) == 0 {
+		return nil, 0, nil
+	}
+	tbl := enc.decodeTable(api)
+	rchecker := rangecheck.New(api)
+	inds := make([]frontend.Variable, len(input))
+	for i := range input {
+		// the characters are used as table indices, so they must be bytes.
+		rchecker.Check(input[i].Val, 8)
+		inds[i] = input[i].Val
+	}
+	values := tbl.Lookup(inds...)
+	nbPad := frontend.Variable(0)
+	for i := range values {
+		if !enc.padding || i < len(values)-2 {
+			rchecker.Check(values[i], 6)
+			continue
+		}
+		// the last two characters may be padding. Valid values are in the
+		// range [0, 64].
+		rchecker.Check(values[i], 7)
+		isPad := api.IsZero(api.Sub(values[i], padValue))
+		if i == len(values)-2 {
+			nbPad = isPad
+		} else {
+			// padding must be suffix
+			api.AssertIsEqual(api.Mul(nbPad, api.Sub(1, isPad)), 0)
+			nbPad = api.Add(nbPad, isPad)
+		}
+		values[i] = api.Sub(values[i], api.Mul(isPad, padValue))
+	}
+	for i := 0; i < len(values); i += 4 {
+		chunk := values[i:min(i+4, len(values))]
+		var v frontend.Variable = 0
+		for j := range chunk {
+			v = api.Add(api.Mul(v, 1<<6), chunk[j])
+		}
+		// a chunk of k characters decodes into k-1 bytes, the remaining low
+		// bits are unused.
+		nbBits := 6 * len(chunk)
+		unused := uint(nbBits - 8*(len(chunk)-1))
+		if unused > 0 {
+			_, v = bitslice.Partition(api, v, unused, bitslice.WithNbDigits(nbBits))
+		}
+		decoded = append(decoded, splitBytes(api, v, len(chunk)-1)...)
+	}
+	length = api.Sub(len(decoded), nbPad)
+	return decoded, length, nil
+}
+
+// splitBytes splits v into nbBytes big-endian bytes.
+func splitBytes(api frontend.API, v frontend.Variable, nbBytes int) []uints.U8 {
+	res := make([]uints.U8, nbBytes)
+	for i := nbBytes - 1; i > 0; i-- {
+		var lo frontend.Variable
+		lo, v = bitslice.Partition(api, v, 8, bitslice.WithNbDigits(8*(i+1)))
+		res[i] = uints.U8{Val: lo}
+	}
+	res[0] = uints.U8{Val: v}
+	return res
+}
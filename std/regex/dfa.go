@@ -0,0 +1,384 @@
+package regex
+
+import (
+	"errors"
+	"fmt"
+	"regexp/syntax"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// alphabetSize is the number of distinct input symbols (bytes).
+	alphabetSize = 256
+	// maxStates is the maximum number of states in the DFA. The lookup tables
+	// have alphabetSize entries per state, so we limit the number of states to
+	// avoid accidentally creating huge circuits.
+	maxStates = 1 << 12
+
+	// deadState is the state from which no accepting state is reachable.
+	deadState = 0
+	// startState is the initial state of the DFA.
+	startState = 1
+)
+
+// DFA is a deterministic finite automaton compiled from a regular expression.
+// It is constructed outside of the circuit using [Compile] and then used in
+// the circuit through [Matcher].
+//
+// The automaton works on bytes and every input byte is interpreted as a single
+// code point in the range [0, 255] (Latin-1). This means that ASCII patterns
+// work as expected, but to match multi-byte UTF-8 sequences the pattern has to
+// be written using the individual bytes (e.g. `\xc3\xa9` instead of `é`).
+type DFA struct {
+	expr string
+	// transitions[s][b] is the state after consuming byte b in state s.
+	transitions [][alphabetSize]int
+	// accepting[s] indicates if the input is accepted when ending in state s.
+	accepting []bool
+	// reveal[k-1][s][b] indicates if the transition from state s by byte b
+	// consumes a byte inside the capture group k.
+	reveal [][][alphabetSize]bool
+	// ambiguous[k-1] indicates that the DFA transitions do not uniquely
+	// determine if a byte is inside the capture group k.
+	ambiguous []bool
+	names     []string
+}
+
+// Compile parses the regular expression expr using Perl syntax and compiles it
+// into a [DFA]. The whole input has to match the expression, i.e. the
+// expression is implicitly anchored at both ends. For searching a match inside
+// the input the expression has to be prefixed and suffixed with `(?s:.*)`.
+//
+// Only the empty-width assertions `^` and `$` (or `\A` and `\z`) are
+// supported, in non-multiline mode.
+func Compile(expr string) (*DFA, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+	names := re.CapNames()
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return nil, fmt.Errorf("compile: %w", err)
+	}
+	c := &compiler{prog: prog}
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+	d, err := c.build()
+	if err != nil {
+		return nil, err
+	}
+	d.expr = expr
+	d.names = names
+	return d, nil
+}
+
+// MustCompile is like [Compile] but panics if the expression cannot be
+// compiled.
+func MustCompile(expr string) *DFA {
+	d, err := Compile(expr)
+	if err != nil {
+		panic(`regex: Compile(` + strconv.Quote(expr) + `): ` + err.Error())
+	}
+	return d
+}
+
+// String returns the source text used to compile the DFA.
+func (d *DFA) String() string {
+	return d.expr
+}
+
+// NbStates returns the number of states in the DFA, including the dead state.
+func (d *DFA) NbStates() int {
+	return len(d.transitions)
+}
+
+// NumSubexp returns the number of capture groups in the expression.
+func (d *DFA) NumSubexp() int {
+	return len(d.reveal)
+}
+
+// SubexpIndex returns the index of the first capture group with the given
+// name, or -1 if there is no such group.
+func (d *DFA) SubexpIndex(name string) int {
+	if name != "" {
+		for i := 1; i < len(d.names); i++ {
+			if d.names[i] == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// run executes the DFA on the input and returns the visited states.
+func (d *DFA) run(input []byte) []int {
+	states := make([]int, len(input)+1)
+	states[0] = startState
+	for i, b := range input {
+		states[i+1] = d.transitions[states[i]][b]
+	}
+	return states
+}
+
+// match returns true if the DFA accepts the input.
+func (d *DFA) match(input []byte) bool {
+	states := d.run(input)
+	return d.accepting[states[len(states)-1]]
+}
+
+// compiler builds the DFA from the compiled program using subset construction.
+type compiler struct {
+	prog *syntax.Prog
+	// inGroup[k-1][pc] indicates that the instruction pc is inside the capture
+	// group k.
+	inGroup [][]bool
+	// live[pc] indicates that a match is reachable from the instruction pc.
+	live []bool
+}
+
+// check verifies that the program only uses supported instructions.
+func (c *compiler) check() error {
+	for _, inst := range c.prog.Inst {
+		if inst.Op != syntax.InstEmptyWidth {
+			continue
+		}
+		if syntax.EmptyOp(inst.Arg)&^(syntax.EmptyBeginText|syntax.EmptyEndText) != 0 {
+			return errors.New("unsupported empty-width assertion: only ^ and $ are supported in non-multiline mode")
+		}
+	}
+	return nil
+}
+
+// matchByte returns true if the rune instruction consumes the byte b.
+func matchByte(inst *syntax.Inst, b byte) bool {
+	switch inst.Op {
+	case syntax.InstRune, syntax.InstRune1:
+		return inst.MatchRune(rune(b))
+	case syntax.InstRuneAny:
+		return true
+	case syntax.InstRuneAnyNotNL:
+		return b != '\n'
+	}
+	return false
+}
+
+func isRune(inst *syntax.Inst) bool {
+	switch inst.Op {
+	case syntax.InstRune, syntax.InstRune1, syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+		return true
+	}
+	return false
+}
+
+// closure returns the sorted set of instructions reachable from pcs by
+// following empty transitions. Empty-width assertions not satisfied by flag
+// are kept in the set, so that the closure can be continued later with
+// different flags.
+func (c *compiler) closure(pcs []uint32, flag syntax.EmptyOp) []uint32 {
+	visited := make(map[uint32]bool)
+	stack := slices.Clone(pcs)
+	var res []uint32
+	for len(stack) > 0 {
+		pc := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if visited[pc] {
+			continue
+		}
+		visited[pc] = true
+		inst := &c.prog.Inst[pc]
+		switch inst.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			stack = append(stack, inst.Out, inst.Arg)
+		case syntax.InstCapture, syntax.InstNop:
+			stack = append(stack, inst.Out)
+		case syntax.InstEmptyWidth:
+			if syntax.EmptyOp(inst.Arg)&^flag == 0 {
+				stack = append(stack, inst.Out)
+			} else {
+				res = append(res, pc)
+			}
+		case syntax.InstMatch:
+			res = append(res, pc)
+		case syntax.InstFail:
+		default:
+			res = append(res, pc)
+		}
+	}
+	slices.Sort(res)
+	return res
+}
+
+// computeGroups computes for every instruction the capture groups it belongs
+// to. The program fragment of a capture group is delimited by the
+// instructions capturing the start and end of the group.
+func (c *compiler) computeGroups() {
+	nbGroups := c.prog.NumCap/2 - 1
+	c.inGroup = make([][]bool, nbGroups)
+	for pc, inst := range c.prog.Inst {
+		if inst.Op != syntax.InstCapture || inst.Arg%2 != 0 || inst.Arg < 2 {
+			continue
+		}
+		k := int(inst.Arg / 2)
+		if c.inGroup[k-1] == nil {
+			c.inGroup[k-1] = make([]bool, len(c.prog.Inst))
+		}
+		stack := []uint32{uint32(pc)}
+		for len(stack) > 0 {
+			cur := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if c.inGroup[k-1][cur] {
+				continue
+			}
+			c.inGroup[k-1][cur] = true
+			ci := &c.prog.Inst[cur]
+			if ci.Op == syntax.InstCapture && ci.Arg == uint32(2*k+1) {
+				continue
+			}
+			switch ci.Op {
+			case syntax.InstAlt, syntax.InstAltMatch:
+				stack = append(stack, ci.Out, ci.Arg)
+			case syntax.InstMatch, syntax.InstFail:
+			default:
+				stack = append(stack, ci.Out)
+			}
+		}
+	}
+	for k := range c.inGroup {
+		if c.inGroup[k] == nil {
+			c.inGroup[k] = make([]bool, len(c.prog.Inst))
+		}
+	}
+}
+
+// computeLive computes the instructions from which a match is reachable. It
+// ignores the empty-width assertions, so it is an over-approximation.
+func (c *compiler) computeLive() {
+	// build reverse edges and propagate from the match instructions
+	rev := make([][]uint32, len(c.prog.Inst))
+	var stack []uint32
+	for pc, inst := range c.prog.Inst {
+		switch inst.Op {
+		case syntax.InstMatch:
+			stack = append(stack, uint32(pc))
+		case syntax.InstFail:
+		case syntax.InstAlt, syntax.InstAltMatch:
+			rev[inst.Out] = append(rev[inst.Out], uint32(pc))
+			rev[inst.Arg] = append(rev[inst.Arg], uint32(pc))
+		default:
+			rev[inst.Out] = append(rev[inst.Out], uint32(pc))
+		}
+	}
+	c.live = make([]bool, len(c.prog.Inst))
+	for len(stack) > 0 {
+		pc := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if c.live[pc] {
+			continue
+		}
+		c.live[pc] = true
+		stack = append(stack, rev[pc]...)
+	}
+}
+
+// build performs the subset construction.
+func (c *compiler) build() (*DFA, error) {
+	c.computeGroups()
+	c.computeLive()
+	nbGroups := len(c.inGroup)
+	d := &DFA{
+		reveal:    make([][][alphabetSize]bool, nbGroups),
+		ambiguous: make([]bool, nbGroups),
+	}
+	type state struct {
+		pcs     []uint32
+		isStart bool
+	}
+	var states []state
+	index := make(map[string]int)
+	add := func(pcs []uint32, isStart bool) (int, error) {
+		var sb strings.Builder
+		if isStart {
+			sb.WriteString("s")
+		}
+		for _, pc := range pcs {
+			sb.WriteString(strconv.FormatUint(uint64(pc), 10))
+			sb.WriteByte(',')
+		}
+		key := sb.String()
+		if idx, ok := index[key]; ok {
+			return idx, nil
+		}
+		if len(states) >= maxStates {
+			return 0, fmt.Errorf("DFA exceeds maximum number of states %d", maxStates)
+		}
+		index[key] = len(states)
+		states = append(states, state{pcs: pcs, isStart: isStart})
+		return len(states) - 1, nil
+	}
+	if _, err := add(nil, false); err != nil {
+		return nil, err
+	}
+	if _, err := add(c.closure([]uint32{uint32(c.prog.Start)}, syntax.EmptyBeginText), true); err != nil {
+		return nil, err
+	}
+	// groupStatus records for the current transition if some live thread
+	// consumes the byte inside (bit 1) or outside (bit 0) of a group.
+	groupStatus := make([]uint8, nbGroups)
+	for s := 0; s < len(states); s++ {
+		var row [alphabetSize]int
+		for k := range d.reveal {
+			d.reveal[k] = append(d.reveal[k], [alphabetSize]bool{})
+		}
+		for b := 0; b < alphabetSize; b++ {
+			var next []uint32
+			clear(groupStatus)
+			for _, pc := range states[s].pcs {
+				inst := &c.prog.Inst[pc]
+				if !isRune(inst) || !matchByte(inst, byte(b)) {
+					continue
+				}
+				next = append(next, inst.Out)
+				if !c.live[inst.Out] {
+					continue
+				}
+				for k := range c.inGroup {
+					if c.inGroup[k][pc] {
+						groupStatus[k] |= 2
+					} else {
+						groupStatus[k] |= 1
+					}
+				}
+			}
+			idx, err := add(c.closure(next, 0), false)
+			if err != nil {
+				return nil, err
+			}
+			row[b] = idx
+			for k := range groupStatus {
+				d.reveal[k][s][b] = groupStatus[k]&2 != 0
+				if groupStatus[k] == 3 {
+					d.ambiguous[k] = true
+				}
+			}
+		}
+		d.transitions = append(d.transitions, row)
+	}
+	d.accepting = make([]bool, len(states))
+	for s := range states {
+		flag := syntax.EmptyEndText
+		if states[s].isStart {
+			flag |= syntax.EmptyBeginText
+		}
+		for _, pc := range c.closure(states[s].pcs, flag) {
+			if c.prog.Inst[pc].Op == syntax.InstMatch {
+				d.accepting[s] = true
+				break
+			}
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,173 @@
+// Package regex implements regular expression matching over variable-length
+// byte strings in circuit.
+//
+// The regular expression is compiled outside of the circuit into a
+// deterministic finite automaton (see [Compile]) whose transition table is
+// stored in a log-derivative lookup table. Matching an input of maximum length
+// n costs n table lookups, independently of the complexity of the expression.
+// The cost of the table is linear in the number of states of the DFA times
+// 256.
+//
+// Additionally to matching, the package allows to extract the substring matched
+// by a capture group (see [Matcher.ExtractSubmatch]), which is useful for
+// proving properties of parts of DKIM-signed emails or JWTs without revealing
+// the whole input, and decoding Base64-encoded strings (see [DecodeBase64]).
+package regex
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/kvstore"
+	"github.com/consensys/gnark/std/lookup/logderivlookup"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/std/rangecheck"
+)
+
+type ctxMatcherKey struct{ dfa *DFA }
+
+// Matcher matches inputs against a [DFA] in circuit.
+type Matcher struct {
+	api      frontend.API
+	dfa      *DFA
+	rchecker frontend.Rangechecker
+
+	transitions *logderivlookup.Table
+	accepting   *logderivlookup.Table
+	reveal      map[int]*logderivlookup.Table
+}
+
+// New returns a new [Matcher] for the automaton dfa. The lookup tables are
+// shared between all matchers for the same automaton in the circuit.
+func New(api frontend.API, dfa *DFA) (*Matcher, error) {
+	if dfa == nil {
+		return nil, errors.New("nil DFA")
+	}
+	kv, ok := api.Compiler().(kvstore.Store)
+	if !ok {
+		panic("builder should implement key-value store")
+	}
+	if m := kv.GetKeyValue(ctxMatcherKey{dfa: dfa}); m != nil {
+		if mm, ok := m.(*Matcher); ok {
+			return mm, nil
+		}
+		panic("stored matcher is not valid")
+	}
+	m := &Matcher{
+		api:         api,
+		dfa:         dfa,
+		rchecker:    rangecheck.New(api),
+		transitions: logderivlookup.New(api),
+		accepting:   logderivlookup.New(api),
+		reveal:      make(map[int]*logderivlookup.Table),
+	}
+	for s := range dfa.transitions {
+		for b := range dfa.transitions[s] {
+			m.transitions.Insert(dfa.transitions[s][b])
+		}
+		if dfa.accepting[s] {
+			m.accepting.Insert(1)
+		} else {
+			m.accepting.Insert(0)
+		}
+	}
+	kv.SetKeyValue(ctxMatcherKey{dfa: dfa}, m)
+	return m, nil
+}
+
+// Match returns 1 if the first length bytes of input match the expression and
+// 0 otherwise. The bytes after length are ignored. The length must be at most
+// len(input).
+func (m *Matcher) Match(input []uints.U8, length frontend.Variable) frontend.Variable {
+	states, _ := m.run(input, length)
+	return m.accepting.Lookup(states[len(states)-1])[0]
+}
+
+// AssertMatch asserts that the first length bytes of input match the
+// expression. The bytes after length are ignored. The length must be at most
+// len(input).
+func (m *Matcher) AssertMatch(input []uints.U8, length frontend.Variable) {
+	m.api.AssertIsEqual(m.Match(input, length), 1)
+}
+
+// ExtractSubmatch asserts that the first length bytes of input match the
+// expression and returns the bytes consumed by the capture group with the
+// given index. The returned slice has the same length as the input and the
+// bytes outside of the capture group are zero. The mask indicates which bytes
+// are inside the capture group.
+//
+// The bytes inside the capture group are determined by the DFA transitions, so
+// it must be possible to decide if a byte is inside the group by only looking
+// at the preceding bytes. Otherwise an error is returned. For example, for the
+// expression `(?s:.*)(a)` the DFA cannot decide if some byte 'a' is inside the
+// group, but for `[^a]*(a)` it can.
+func (m *Matcher) ExtractSubmatch(input []uints.U8, length frontend.Variable, group int) (revealed []uints.U8, mask []frontend.Variable, err error) {
+	if group < 1 || group > m.dfa.NumSubexp() {
+		return nil, nil, fmt.Errorf("capture group %d out of range [1, %d]", group, m.dfa.NumSubexp())
+	}
+	if m.dfa.ambiguous[group-1] {
+		return nil, nil, fmt.Errorf("capture group %d is not determined by the DFA transitions", group)
+	}
+	tbl, ok := m.reveal[group]
+	if !ok {
+		tbl = logderivlookup.New(m.api)
+		for s := range m.dfa.reveal[group-1] {
+			for b := range m.dfa.reveal[group-1][s] {
+				if m.dfa.reveal[group-1][s][b] {
+					tbl.Insert(1)
+				} else {
+					tbl.Insert(0)
+				}
+			}
+		}
+		m.reveal[group] = tbl
+	}
+	states, active := m.run(input, length)
+	m.api.AssertIsEqual(m.accepting.Lookup(states[len(states)-1])[0], 1)
+	revealed = make([]uints.U8, len(input))
+	mask = make([]frontend.Variable, len(input))
+	for i := range input {
+		r := tbl.Lookup(m.index(states[i], input[i]))[0]
+		mask[i] = m.api.Mul(r, active[i])
+		revealed[i] = uints.U8{Val: m.api.Mul(mask[i], input[i].Val)}
+	}
+	return revealed, mask, nil
+}
+
+// run executes the DFA on the first length bytes of the input. It returns the
+// states before and after every byte and a mask indicating the bytes before
+// length. The states after length do not change.
+func (m *Matcher) run(input []uints.U8, length frontend.Variable) (states []frontend.Variable, active []frontend.Variable) {
+	api := m.api
+	// the table index is computed from the state and the byte, so we have to
+	// ensure that the bytes are in range.
+	for i := range input {
+		m.rchecker.Check(input[i].Val, 8)
+	}
+	// active[i] is 1 iff i < length. We additionally check that length is
+	// exactly one of 0, ..., len(input).
+	active = make([]frontend.Variable, len(input))
+	var seen frontend.Variable = 0
+	for i := 0; i <= len(input); i++ {
+		seen = api.Add(seen, api.IsZero(api.Sub(i, length)))
+		if i < len(input) {
+			active[i] = api.Sub(1, seen)
+		}
+	}
+	api.AssertIsEqual(seen, 1)
+
+	states = make([]frontend.Variable, len(input)+1)
+	states[0] = startState
+	for i := range input {
+		next := m.transitions.Lookup(m.index(states[i], input[i]))[0]
+		states[i+1] = api.Select(active[i], next, states[i])
+	}
+	return states, active
+}
+
+// index returns the index of the transition from state by byte b in the
+// lookup tables.
+func (m *Matcher) index(state frontend.Variable, b uints.U8) frontend.Variable {
+	return m.api.Add(m.api.Mul(state, alphabetSize), b.Val)
+}
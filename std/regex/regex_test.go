@@ -0,0 +1,201 @@
+package regex
+
+import (
+	"encoding/base64"
+	"regexp"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/test"
+)
+
+func TestDFA(t *testing.T) {
+	assert := test.NewAssert(t)
+	patterns := []string{
+		`abc`,
+		`a*b+c?`,
+		`(a|bc)*d`,
+		`[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,4}`,
+		`(?i)hello world`,
+		`^x.y$`,
+		`(?s).*needle.*`,
+		`(ab){2,3}`,
+		``,
+	}
+	inputs := []string{
+		"", "abc", "ab", "bbc", "aaabbb", "bcbcad", "d", "bcbc",
+		"alice@example.com", "bob@x.y", "HeLLo WoRLD", "hello world",
+		"xay", "x\ny", "haystackneedlehaystack", "needl", "abab", "ababab", "abababab",
+	}
+	for _, p := range patterns {
+		d, err := Compile(p)
+		assert.NoError(err, p)
+		ref := regexp.MustCompile(`^(?:` + p + `)$`)
+		for _, in := range inputs {
+			assert.Equal(ref.MatchString(in), d.match([]byte(in)), "pattern %q input %q", p, in)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	assert := test.NewAssert(t)
+	_, err := Compile(`a\bb`)
+	assert.Error(err)
+	_, err = Compile(`(?m)^a$`)
+	assert.Error(err)
+	_, err = Compile(`a(`)
+	assert.Error(err)
+	d, err := Compile(`(?s:.*)(a)b`)
+	assert.NoError(err)
+	assert.True(d.ambiguous[0])
+	d, err = Compile(`[^a]*(a)b`)
+	assert.NoError(err)
+	assert.False(d.ambiguous[0])
+}
+
+type matchCircuit struct {
+	dfa      *DFA `gnark:"-"`
+	Input    []uints.U8
+	Length   frontend.Variable
+	Expected frontend.Variable
+}
+
+func (c *matchCircuit) Define(api frontend.API) error {
+	m, err := New(api, c.dfa)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(m.Match(c.Input, c.Length), c.Expected)
+	return nil
+}
+
+func padInput(in string, maxLen int) []uints.U8 {
+	bts := make([]byte, maxLen)
+	copy(bts, in)
+	// fill the unused part with garbage which should be ignored
+	for i := len(in); i < maxLen; i++ {
+		bts[i] = byte(i)
+	}
+	return uints.NewU8Array(bts)
+}
+
+func TestMatch(t *testing.T) {
+	assert := test.NewAssert(t)
+	const maxLen = 24
+	d := MustCompile(`[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,4}`)
+	ref := regexp.MustCompile(`^(?:` + d.String() + `)$`)
+	for _, in := range []string{"alice@example.com", "alice@example", "", "a@b.cd", "a@b.cd@"} {
+		expected := 0
+		if ref.MatchString(in) {
+			expected = 1
+		}
+		err := test.IsSolved(&matchCircuit{dfa: d, Input: make([]uints.U8, maxLen)}, &matchCircuit{Input: padInput(in, maxLen), Length: len(in), Expected: expected}, ecc.BN254.ScalarField())
+		assert.NoError(err, in)
+	}
+	// length larger than the input
+	err := test.IsSolved(&matchCircuit{dfa: d, Input: make([]uints.U8, maxLen)}, &matchCircuit{Input: padInput("", maxLen), Length: maxLen + 1, Expected: 0}, ecc.BN254.ScalarField())
+	assert.Error(err)
+}
+
+type extractCircuit struct {
+	dfa      *DFA `gnark:"-"`
+	Input    []uints.U8
+	Length   frontend.Variable
+	Expected []uints.U8
+}
+
+func (c *extractCircuit) Define(api frontend.API) error {
+	m, err := New(api, c.dfa)
+	if err != nil {
+		return err
+	}
+	revealed, _, err := m.ExtractSubmatch(c.Input, c.Length, c.dfa.SubexpIndex("subject"))
+	if err != nil {
+		return err
+	}
+	for i := range revealed {
+		api.AssertIsEqual(revealed[i].Val, c.Expected[i].Val)
+	}
+	return nil
+}
+
+func TestExtractSubmatch(t *testing.T) {
+	assert := test.NewAssert(t)
+	const maxLen = 64
+	d := MustCompile(`from:[^\r\n]*\r\nsubject:(?P<subject>[^\r\n]+)\r\n(?s:.*)`)
+	ref := regexp.MustCompile(`^(?:` + d.String() + `)$`)
+	in := "from:alice@example.com\r\nsubject:hello bob\r\nbody text"
+	loc := ref.FindStringSubmatchIndex(in)
+	expected := make([]byte, maxLen)
+	copy(expected[loc[2]:loc[3]], in[loc[2]:loc[3]])
+	circuit := &extractCircuit{dfa: d, Input: make([]uints.U8, maxLen), Expected: make([]uints.U8, maxLen)}
+	assert.CheckCircuit(circuit,
+		test.WithValidAssignment(&extractCircuit{Input: padInput(in, maxLen), Length: len(in), Expected: uints.NewU8Array(expected)}),
+		test.WithInvalidAssignment(&extractCircuit{Input: padInput(in, maxLen), Length: len(in), Expected: padInput(in, maxLen)}),
+		test.WithInvalidAssignment(&extractCircuit{Input: padInput("subject:x", maxLen), Length: len("subject:x"), Expected: make([]uints.U8, maxLen)}),
+		test.WithCurves(ecc.BN254))
+}
+
+type base64Circuit struct {
+	enc     *Encoding `gnark:"-"`
+	Encoded []uints.U8
+	Decoded []uints.U8
+	Length  frontend.Variable
+}
+
+func (c *base64Circuit) Define(api frontend.API) error {
+	decoded, length, err := DecodeBase64(api, c.enc, c.Encoded)
+	if err != nil {
+		return err
+	}
+	for i := range decoded {
+		api.AssertIsEqual(decoded[i].Val, c.Decoded[i].Val)
+	}
+	api.AssertIsEqual(length, c.Length)
+	return nil
+}
+
+func TestDecodeBase64(t *testing.T) {
+	assert := test.NewAssert(t)
+	type testcase struct {
+		enc   *Encoding
+		ref   *base64.Encoding
+		plain string
+	}
+	for _, tc := range []testcase{
+		{StdEncoding, base64.StdEncoding, "hello world"},
+		{StdEncoding, base64.StdEncoding, "hello world!"},
+		{StdEncoding, base64.StdEncoding, "hello worl"},
+		{URLEncoding, base64.URLEncoding, "\xfb\xff\xbe?>"},
+		{RawURLEncoding, base64.RawURLEncoding, `{"alg":"RS256","typ":"JWT"}`},
+		{RawStdEncoding, base64.RawStdEncoding, "ab"},
+	} {
+		encoded := tc.ref.EncodeToString([]byte(tc.plain))
+		circuit, assignment := &base64Circuit{enc: tc.enc}, &base64Circuit{}
+		circuit.Encoded = make([]uints.U8, len(encoded))
+		assignment.Encoded = uints.NewU8Array([]byte(encoded))
+		nbDecoded := 3 * len(encoded) / 4
+		if !tc.enc.padding {
+			nbDecoded = 6 * len(encoded) / 8
+		}
+		decoded := make([]byte, nbDecoded)
+		copy(decoded, tc.plain)
+		circuit.Decoded = make([]uints.U8, nbDecoded)
+		assignment.Decoded = uints.NewU8Array(decoded)
+		assignment.Length = len(tc.plain)
+		err := test.IsSolved(circuit, assignment, ecc.BN254.ScalarField())
+		assert.NoError(err, encoded)
+		// invalid character
+		invalid := []byte(encoded)
+		invalid[0] = '*'
+		assignment.Encoded = uints.NewU8Array(invalid)
+		err = test.IsSolved(circuit, assignment, ecc.BN254.ScalarField())
+		assert.Error(err, encoded)
+	}
+	// padding in the middle
+	circuit := &base64Circuit{enc: StdEncoding, Encoded: make([]uints.U8, 4), Decoded: make([]uints.U8, 3)}
+	err := test.IsSolved(circuit, &base64Circuit{Encoded: uints.NewU8Array([]byte("QQ=A")), Decoded: uints.NewU8Array([]byte{'A', 0, 0}), Length: 1}, ecc.BN254.ScalarField())
+	assert.Error(err)
+}
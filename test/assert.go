@@ -133,6 +133,10 @@ func (assert *Assert) compile(circuit frontend.Circuit, curveID ecc.ID, backendI
 		newBuilder = r1cs.NewBuilder
 	case backend.PLONK:
 		newBuilder = scs.NewBuilder
+	case backend.PLONKFRI:
+		// the FRI backend doesn't support the native lookups
+		newBuilder = scs.NewBuilder
+		compileOpts = append(compileOpts[:len(compileOpts):len(compileOpts)], frontend.WithoutNativeLookups())
 	default:
 		panic("not implemented")
 	}
@@ -7,9 +7,11 @@ import (
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/plonkfri"
 	"github.com/consensys/gnark/backend/solidity"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/constraint/analyzer"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/schema"
 	"github.com/consensys/gnark/test/unsafekzg"
@@ -24,6 +26,8 @@ import (
 //
 // Depending on the above flags, the following checks are performed:
 //   - the circuit compiles
+//   - the circuit is not under-constrained, with [WithUnderconstrainedAnalysis]
+//   - the hint outputs can not be mutated, with [WithHintMutations]
 //   - the circuit can be solved with the test engine
 //   - the circuit can be solved with the constraint system solver
 //   - the circuit can be solved with the prover
@@ -72,10 +76,30 @@ func (assert *Assert) CheckCircuit(circuit frontend.Circuit, opts ...TestingOpti
 				// run in sub-test to contextualize with backend
 				assert.Run(func(assert *Assert) {
 
-					// 1- check that the circuit compiles
-					ccs, err := assert.compile(circuit, curve, b, opt.compileOpts)
+					// 1- check that the circuit compiles, without native lookups if
+					// the proofs are checked by the Solidity verifier
+					compileOpts := opt.compileOpts
+					if b == backend.PLONK && opt.checkSolidity && curve == ecc.BN254 {
+						compileOpts = append(compileOpts[:len(compileOpts):len(compileOpts)], frontend.WithoutNativeLookups())
+					}
+					ccs, err := assert.compile(circuit, curve, b, compileOpts)
 					assert.noError(err, nil)
 
+					// check that the internal wires are determined by the inputs
+					if opt.checkUnderconstrained {
+						assert.noError(analyzer.Analyze(ccs).Err(), nil)
+					}
+
+					// check that the hint outputs can not be replaced
+					if opt.checkHintMutations {
+						for _, w := range validWitnesses {
+							w := w
+							assert.Run(func(assert *Assert) {
+								assert.noError(checkHintMutations(ccs, w.full, opt.solverOpts...), &w)
+							}, "hint_mutations")
+						}
+					}
+
 					// TODO @gbotrel check serialization round trip with constraint system.
 
 					// 2- if we are not running the full prover;
@@ -110,6 +134,8 @@ func (assert *Assert) CheckCircuit(circuit frontend.Circuit, opts ...TestingOpti
 						concreteBackend = _groth16
 					case backend.PLONK:
 						concreteBackend = _plonk
+					case backend.PLONKFRI:
+						concreteBackend = _plonkfri
 					default:
 						panic("backend not implemented")
 					}
@@ -280,4 +306,20 @@ var (
 			return plonk.Verify(proof.(plonk.Proof), vk.(plonk.VerifyingKey), publicWitness, opts...)
 		},
 	}
+
+	_plonkfri = tBackend{
+		setup: func(ccs constraint.ConstraintSystem, curve ecc.ID) (
+			pk, vk any,
+			pkBuilder, vkBuilder, proofBuilder func() any,
+			err error) {
+			pk, vk, err = plonkfri.Setup(ccs)
+			return pk, vk, func() any { return plonkfri.NewProvingKey(curve) }, func() any { return plonkfri.NewVerifyingKey(curve) }, func() any { return plonkfri.NewProof(curve) }, err
+		},
+		prove: func(ccs constraint.ConstraintSystem, pk any, fullWitness witness.Witness, opts ...backend.ProverOption) (proof any, err error) {
+			return plonkfri.Prove(ccs, pk.(plonkfri.ProvingKey), fullWitness, opts...)
+		},
+		verify: func(proof, vk any, publicWitness witness.Witness, opts ...backend.VerifierOption) error {
+			return plonkfri.Verify(proof.(plonkfri.Proof), vk.(plonkfri.VerifyingKey), publicWitness, opts...)
+		},
+	}
 )
@@ -0,0 +1,196 @@
+package test
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/constraint/solver"
+	fcs "github.com/consensys/gnark/frontend/cs"
+	"github.com/consensys/gnark/internal/hints"
+)
+
+// hintMutation returns an adversarial value for the hint output v in the field
+// of modulus q. The mutations which do not change v modulo q are ignored.
+type hintMutation struct {
+	name   string
+	mutate func(q, v *big.Int) *big.Int
+}
+
+var hintMutations []hintMutation
+
+func init() {
+	hintMutations = []hintMutation{
+		{"zero", func(q, v *big.Int) *big.Int { return new(big.Int) }},
+		{"one", func(q, v *big.Int) *big.Int { return big.NewInt(1) }},
+		{"minus one", func(q, v *big.Int) *big.Int { return new(big.Int).Sub(q, big.NewInt(1)) }},
+		{"v+1", func(q, v *big.Int) *big.Int { return new(big.Int).Add(v, big.NewInt(1)) }},
+		{"v-1", func(q, v *big.Int) *big.Int { return new(big.Int).Sub(v, big.NewInt(1)) }},
+		{"-v", func(q, v *big.Int) *big.Int { return new(big.Int).Neg(v) }},
+		{"v+2^len(v)", func(q, v *big.Int) *big.Int {
+			return new(big.Int).Add(v, new(big.Int).Lsh(big.NewInt(1), uint(v.BitLen())))
+		}},
+		{"random", func(q, v *big.Int) *big.Int {
+			r, err := rand.Int(rand.Reader, q)
+			if err != nil {
+				panic(err)
+			}
+			return r
+		}},
+	}
+
+	// the values off by a modulus, for example of an emulated field
+	seen := make(map[string]bool)
+	for _, curve := range gnark.Curves() {
+		for _, m := range []*big.Int{curve.BaseField(), curve.ScalarField()} {
+			if seen[m.String()] {
+				continue
+			}
+			seen[m.String()] = true
+			m := m
+			hintMutations = append(hintMutations,
+				hintMutation{"v+" + m.String(), func(q, v *big.Int) *big.Int { return new(big.Int).Add(v, m) }},
+				hintMutation{"v-" + m.String(), func(q, v *big.Int) *big.Int { return new(big.Int).Sub(v, m) }},
+			)
+		}
+	}
+}
+
+// hintCall is a call of a hint function by the solver.
+type hintCall struct {
+	id        solver.HintID
+	nbOutputs int
+}
+
+// checkHintMutations solves ccs with the witness w, replacing every hint
+// output in turn with adversarial values. The public inputs being fixed by w,
+// it returns an error if the constraint system is still satisfied and the
+// mutation changed the values of other wires, that is if the hint output is
+// not determined by the constraints and used to compute other values. The
+// undetermined outputs which are not used, for example the inverse computed by
+// IsZero for a zero input, are not reported.
+//
+// The solver is run once per hint output and mutation, so the check is only
+// suitable for small circuits. The commitments and their masks, which are not
+// constrained by design, are not mutated.
+func checkHintMutations(ccs constraint.ConstraintSystem, w witness.Witness, opts ...solver.Option) error {
+	config, err := solver.NewConfig(opts...)
+	if err != nil {
+		return err
+	}
+	// the commitments and their masks are random: they are replaced by a hash
+	// of the inputs so that the solving is deterministic, and not mutated.
+	hintFunctions := make(map[solver.HintID]solver.Hint, len(config.HintFunctions))
+	for id, f := range config.HintFunctions {
+		hintFunctions[id] = f
+	}
+	skip := map[solver.HintID]bool{
+		solver.GetHintID(fcs.Bsb22CommitmentComputePlaceholder): true,
+		solver.GetHintID(hints.Randomize):                       true,
+	}
+	for id := range skip {
+		hintFunctions[id] = hashHint
+	}
+
+	// the solver calls the hints in a deterministic order with a single task
+	opts = append(opts[:len(opts):len(opts)], solver.WithNbTasks(1))
+
+	// record the hint calls of the honest solving
+	var calls []hintCall
+	recorder := make([]solver.Option, 0, len(hintFunctions))
+	for id, f := range hintFunctions {
+		id, f := id, f
+		recorder = append(recorder, solver.OverrideHint(id, func(q *big.Int, inputs, outputs []*big.Int) error {
+			calls = append(calls, hintCall{id: id, nbOutputs: len(outputs)})
+			return f(q, inputs, outputs)
+		}))
+	}
+	solution, err := ccs.Solve(w, append(opts, recorder...)...)
+	if err != nil {
+		return fmt.Errorf("honest solving: %w", err)
+	}
+
+	for c, call := range calls {
+		if skip[call.id] {
+			continue
+		}
+		for output := 0; output < call.nbOutputs; output++ {
+			for _, mutation := range hintMutations {
+				var (
+					n                 int
+					honest, mutated   *big.Int
+					mutationOverrides = make([]solver.Option, 0, len(hintFunctions))
+				)
+				for id, f := range hintFunctions {
+					f := f
+					mutationOverrides = append(mutationOverrides, solver.OverrideHint(id, func(q *big.Int, inputs, outputs []*big.Int) error {
+						err := f(q, inputs, outputs)
+						if n++; n-1 != c || err != nil {
+							return err
+						}
+						v := mutation.mutate(q, outputs[output])
+						if v.Mod(v, q).Cmp(new(big.Int).Mod(outputs[output], q)) == 0 {
+							return nil
+						}
+						honest, mutated = new(big.Int).Set(outputs[output]), v
+						outputs[output].Set(v)
+						return nil
+					}))
+				}
+				mutatedSolution, err := ccs.Solve(w, append(opts, mutationOverrides...)...)
+				if err == nil && mutated != nil && propagated(solution, mutatedSolution, honest.Mod(honest, ccs.Field()), mutated) {
+					name := solver.GetHintName(config.HintFunctions[call.id])
+					return fmt.Errorf("hint %s (call %d): output %d mutated from %s to %s (%s) and the constraint system is still satisfied",
+						name, c, output, honest, mutated, mutation.name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hashHint sets the outputs to a hash of the inputs. It replaces the random
+// commitment hints.
+func hashHint(q *big.Int, inputs, outputs []*big.Int) error {
+	h := sha256.New()
+	for _, in := range inputs {
+		b := in.Bytes()
+		_ = binary.Write(h, binary.BigEndian, uint32(len(b)))
+		h.Write(b)
+	}
+	for i := range outputs {
+		outputs[i].SetBytes(h.Sum([]byte{byte(i)}))
+		outputs[i].Mod(outputs[i], q)
+	}
+	return nil
+}
+
+// propagated returns true if the wire values of the solutions differ by more
+// than the mutation of a hint output from honest to mutated.
+func propagated(solution, mutatedSolution any, honest, mutated *big.Int) bool {
+	type element interface{ BigInt(*big.Int) *big.Int }
+	s, m := reflect.ValueOf(solution).Elem(), reflect.ValueOf(mutatedSolution).Elem()
+	var a, b big.Int
+	// W are the wire values of the R1CS solutions, L, R and O the ones of the
+	// PLONK solutions
+	for _, name := range []string{"W", "L", "R", "O"} {
+		v, mv := s.FieldByName(name), m.FieldByName(name)
+		if !v.IsValid() {
+			continue
+		}
+		for i := 0; i < v.Len(); i++ {
+			v.Index(i).Addr().Interface().(element).BigInt(&a)
+			mv.Index(i).Addr().Interface().(element).BigInt(&b)
+			if a.Cmp(&b) != 0 && (a.Cmp(honest) != 0 || b.Cmp(mutated) != 0) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/stretchr/testify/require"
+)
+
+type hintMutationsCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (c *hintMutationsCircuit) Define(api frontend.API) error {
+	bits := api.ToBinary(c.X, 8)
+	isZero := api.IsZero(api.Sub(c.X, c.Y))
+	inv := api.Inverse(c.Y)
+	api.AssertIsEqual(api.Add(api.FromBinary(bits...), isZero, api.Mul(inv, c.Y)), c.Z)
+	return nil
+}
+
+func squareRootHint(q *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	outputs[0].ModSqrt(inputs[0], q)
+	return nil
+}
+
+func init() {
+	solver.RegisterHint(squareRootHint)
+}
+
+type squareRootCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *squareRootCircuit) Define(api frontend.API) error {
+	// both square roots satisfy the constraints
+	res, err := api.Compiler().NewHint(squareRootHint, 1, c.X)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(api.Mul(res[0], res[0]), c.X)
+	api.AssertIsEqual(c.X, api.Mul(c.Y, c.Y))
+	api.AssertIsDifferent(api.Add(res[0], c.X), 0)
+	return nil
+}
+
+func TestHintMutations(t *testing.T) {
+	for _, builder := range []frontend.NewBuilder{r1cs.NewBuilder, scs.NewBuilder} {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), builder, &hintMutationsCircuit{})
+		require.NoError(t, err)
+		w, err := frontend.NewWitness(&hintMutationsCircuit{X: 200, Y: 3, Z: 201}, ecc.BN254.ScalarField())
+		require.NoError(t, err)
+		require.NoError(t, checkHintMutations(ccs, w))
+
+		ccs, err = frontend.Compile(ecc.BN254.ScalarField(), builder, &squareRootCircuit{})
+		require.NoError(t, err)
+		w, err = frontend.NewWitness(&squareRootCircuit{X: 9, Y: 3}, ecc.BN254.ScalarField())
+		require.NoError(t, err)
+		require.ErrorContains(t, checkHintMutations(ccs, w), "squareRootHint")
+	}
+}
+
+func TestHintMutationsOption(t *testing.T) {
+	assert := NewAssert(t)
+	assert.CheckCircuit(&hintMutationsCircuit{}, WithHintMutations(), WithCurves(ecc.BN254),
+		WithValidAssignment(&hintMutationsCircuit{X: 5, Y: 5, Z: 7}))
+}
@@ -25,6 +25,9 @@ type testingConfig struct {
 
 	validAssignments   []frontend.Circuit
 	invalidAssignments []frontend.Circuit
+
+	checkUnderconstrained bool
+	checkHintMutations    bool
 }
 
 // default options
@@ -141,6 +144,33 @@ func NoSolidityChecks() TestingOption {
 	}
 }
 
+// WithUnderconstrainedAnalysis is a testing option which checks that the
+// internal wires of the compiled constraint systems are determined by the
+// public and secret inputs, see [github.com/consensys/gnark/constraint/analyzer].
+func WithUnderconstrainedAnalysis() TestingOption {
+	return func(opt *testingConfig) error {
+		opt.checkUnderconstrained = true
+		return nil
+	}
+}
+
+// WithHintMutations is a testing option which checks, for every valid
+// assignment, that the constraint system is not satisfied anymore when any hint
+// output is replaced by an adversarial value (zero, one, off by one, off by a
+// modulus, random, ...). As the public inputs are fixed by the assignment, a
+// satisfied mutation changing the values of other wires means that a hint
+// output is not determined by the constraints, which usually indicates a
+// missing constraint.
+//
+// The solver is run for every hint output and mutation, so this option is only
+// suitable for small circuits.
+func WithHintMutations() TestingOption {
+	return func(opt *testingConfig) error {
+		opt.checkHintMutations = true
+		return nil
+	}
+}
+
 // WithProverOpts is a testing option which uses the given proverOpts when
 // calling backend.Prover, backend.ReadAndProve and backend.IsSolved methods in
 // assertions.
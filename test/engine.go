@@ -59,6 +59,9 @@ type engine struct {
 	kvstore.Store
 	blueprints        []constraint.Blueprint
 	internalVariables []*big.Int
+	customGates       []frontend.CustomGate
+	lookupTables      []engineLookupTable
+	components        []frontend.Component
 }
 
 // TestEngineOption defines an option for the test engine.
@@ -758,6 +761,143 @@ func (e *engine) SetGkrInfo(info constraint.GkrInfo) error {
 	return nil
 }
 
+func (e *engine) DefineCustomGate(gate frontend.CustomGate) (frontend.CustomGateID, error) {
+	for _, t := range gate.Terms {
+		degree := 0
+		for _, e := range t.Exponents() {
+			if e < 0 {
+				return 0, fmt.Errorf("custom gate %q: invalid exponents %v", gate.Name, t.Exponents())
+			}
+			degree += e
+		}
+		if degree > constraint.MaxCustomGateDegree {
+			return 0, fmt.Errorf("custom gate %q has a term of degree %d > %d: %w", gate.Name, degree, constraint.MaxCustomGateDegree, constraint.ErrCustomGateDegree)
+		}
+	}
+	if len(gate.Terms) == 0 {
+		return 0, fmt.Errorf("custom gate %q has no terms", gate.Name)
+	}
+	e.customGates = append(e.customGates, gate)
+	return frontend.CustomGateID(len(e.customGates) - 1), nil
+}
+
+func (e *engine) EvaluateCustomGate(id frontend.CustomGateID, a, b frontend.Variable, next ...frontend.Variable) frontend.Variable {
+	gate := e.customGates[id]
+	// G(a, b, c, ...) = G(a, b, 0, ...) + coeff⋅c when c only appears in the
+	// term coeff⋅c
+	var coeff *big.Int
+	for _, t := range gate.Terms {
+		if t.C == 0 {
+			continue
+		}
+		if coeff != nil || t.Exponents() != [6]int{0, 0, 1, 0, 0, 0} {
+			panic(fmt.Sprintf("custom gate %q can not be used to compute the output wire", gate.Name))
+		}
+		coeff = t.Coeff
+	}
+	if coeff == nil {
+		panic(fmt.Sprintf("custom gate %q can not be used to compute the output wire", gate.Name))
+	}
+	return e.Neg(e.Div(gate.Evaluate(e, a, b, 0, next...), coeff))
+}
+
+func (e *engine) AssertCustomGate(id frontend.CustomGateID, a, b, c frontend.Variable, next ...frontend.Variable) {
+	gate := e.customGates[id]
+	res := e.toBigInt(gate.Evaluate(e, a, b, c, next...))
+	if res.Sign() != 0 {
+		panic(fmt.Sprintf("[assertCustomGate] %s(%s, %s, %s) == %s != 0", gate.Name, e.toBigInt(a), e.toBigInt(b), e.toBigInt(c), res))
+	}
+}
+
+type engineLookupTable struct {
+	name  string
+	width int
+	rows  map[string]struct{}
+	keys  map[string]*big.Int // nil if the last column is not a function of the others
+}
+
+// lookupKey returns the canonical encoding of the reduced values.
+func (e *engine) lookupKey(vals ...frontend.Variable) string {
+	var sbb strings.Builder
+	for i := range vals {
+		sbb.WriteString(new(big.Int).Mod(e.toBigInt(vals[i]), e.q).String())
+		sbb.WriteByte(',')
+	}
+	return sbb.String()
+}
+
+func (e *engine) DefineLookupTable(name string, rows [][]*big.Int) (frontend.LookupTableID, error) {
+	if len(rows) == 0 {
+		return 0, fmt.Errorf("lookup table %q has no rows", name)
+	}
+	width := len(rows[0])
+	if width < 1 || width > constraint.MaxLookupTableWidth {
+		return 0, fmt.Errorf("lookup table %q has invalid width %d", name, width)
+	}
+	t := engineLookupTable{name: name, width: width, rows: make(map[string]struct{}, len(rows))}
+	if width > 1 {
+		t.keys = make(map[string]*big.Int, len(rows))
+	}
+	for i, r := range rows {
+		if len(r) != width {
+			return 0, fmt.Errorf("lookup table %q: row %d has %d entries, expected %d", name, i, len(r), width)
+		}
+		vals := make([]frontend.Variable, width)
+		for j := range r {
+			vals[j] = r[j]
+		}
+		t.rows[e.lookupKey(vals...)] = struct{}{}
+		if t.keys != nil {
+			k := e.lookupKey(vals[:width-1]...)
+			v := new(big.Int).Mod(r[width-1], e.q)
+			if prev, ok := t.keys[k]; ok && prev.Cmp(v) != 0 {
+				t.keys = nil
+				continue
+			}
+			t.keys[k] = v
+		}
+	}
+	e.lookupTables = append(e.lookupTables, t)
+	return frontend.LookupTableID(len(e.lookupTables) - 1), nil
+}
+
+func (e *engine) Lookup(id frontend.LookupTableID, keys ...frontend.Variable) frontend.Variable {
+	t := e.lookupTables[id]
+	if t.keys == nil {
+		panic(fmt.Sprintf("lookup table %q can not be used to compute the last column", t.name))
+	}
+	if len(keys) != t.width-1 {
+		panic(fmt.Sprintf("lookup table %q expects %d keys, got %d", t.name, t.width-1, len(keys)))
+	}
+	v, ok := t.keys[e.lookupKey(keys...)]
+	if !ok {
+		panic(fmt.Sprintf("[lookup] lookup table %q has no entry for %v", t.name, keys))
+	}
+	return new(big.Int).Set(v)
+}
+
+func (e *engine) AssertLookup(id frontend.LookupTableID, vals ...frontend.Variable) {
+	t := e.lookupTables[id]
+	if len(vals) != t.width {
+		panic(fmt.Sprintf("lookup table %q expects %d values, got %d", t.name, t.width, len(vals)))
+	}
+	if _, ok := t.rows[e.lookupKey(vals...)]; !ok {
+		panic(fmt.Sprintf("[assertLookup] %v not in lookup table %q", vals, t.name))
+	}
+}
+
+func (e *engine) DefineComponent(c frontend.Component) (frontend.ComponentID, error) {
+	e.components = append(e.components, c)
+	return frontend.ComponentID(len(e.components) - 1), nil
+}
+
+func (e *engine) CallComponent(id frontend.ComponentID, inputs ...frontend.Variable) ([]frontend.Variable, error) {
+	if id < 0 || int(id) >= len(e.components) {
+		return nil, fmt.Errorf("unknown component %d", id)
+	}
+	return e.components[id].Call(e, inputs...)
+}
+
 // MustBeLessOrEqCst implements method comparing value given by its bits aBits
 // to a bound.
 func (e *engine) MustBeLessOrEqCst(aBits []frontend.Variable, bound *big.Int, aForDebug frontend.Variable) {
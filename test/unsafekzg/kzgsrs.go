@@ -67,7 +67,13 @@ func NewSRS(ccs constraint.ConstraintSystem, opts ...Option) (canonical kzg.SRS,
 	sizeSystem := nbConstraints + ccs.GetNbPublicVariables()
 
 	sizeLagrange := ecc.NextPowerOfTwo(uint64(sizeSystem))
-	sizeCanonical := sizeLagrange + 3
+	k := uint64(1)
+	if spr, ok := ccs.(constraint.SparseR1CS); ok {
+		// the shards of the quotient are larger with the custom gates of high
+		// degree or using the next row
+		k = uint64(spr.GetQuotientShardFactor())
+	}
+	sizeCanonical := k*(sizeLagrange+2) + 1
 
 	curveID := utils.FieldToCurve(ccs.Field())
 
@@ -78,7 +84,7 @@ func NewSRS(ccs constraint.ConstraintSystem, opts ...Option) (canonical kzg.SRS,
 		return nil, nil, err
 	}
 
-	key := cacheKey(curveID, sizeCanonical)
+	key := cacheKey(curveID, sizeCanonical, sizeLagrange)
 	log.Debug().Str("key", key).Msg("fetching SRS from mem cache")
 	memLock.RLock()
 	entry, ok := cache[key]
@@ -109,7 +115,7 @@ func NewSRS(ccs constraint.ConstraintSystem, opts ...Option) (canonical kzg.SRS,
 	log.Debug().Msg("SRS not found in cache, generating")
 
 	// not in cache, generate
-	canonical, lagrange, err = newSRS(curveID, sizeCanonical)
+	canonical, lagrange, err = newSRS(curveID, sizeCanonical, sizeLagrange)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -134,8 +140,8 @@ type cacheEntry struct {
 	lagrange  kzg.SRS
 }
 
-func cacheKey(curveID ecc.ID, size uint64) string {
-	return fmt.Sprintf("kzgsrs-%s-%d", curveID.String(), size)
+func cacheKey(curveID ecc.ID, sizeCanonical, sizeLagrange uint64) string {
+	return fmt.Sprintf("kzgsrs-%s-%d-%d", curveID.String(), sizeCanonical, sizeLagrange)
 }
 
 func extractCurveID(key string) (ecc.ID, error) {
@@ -147,7 +153,7 @@ func extractCurveID(key string) (ecc.ID, error) {
 	return ecc.IDFromString(matches[1])
 }
 
-func newSRS(curveID ecc.ID, size uint64) (kzg.SRS, kzg.SRS, error) {
+func newSRS(curveID ecc.ID, size, sizeLagrange uint64) (kzg.SRS, kzg.SRS, error) {
 
 	tau, err := rand.Int(rand.Reader, curveID.ScalarField())
 	if err != nil {
@@ -179,17 +185,16 @@ func newSRS(curveID ecc.ID, size uint64) (kzg.SRS, kzg.SRS, error) {
 		return nil, nil, err
 	}
 
-	return srs, toLagrange(srs, tau), nil
+	return srs, toLagrange(srs, tau, sizeLagrange), nil
 }
 
-func toLagrange(canonicalSRS kzg.SRS, tau *big.Int) kzg.SRS {
+func toLagrange(canonicalSRS kzg.SRS, tau *big.Int, size uint64) kzg.SRS {
 
 	var lagrangeSRS kzg.SRS
 
 	switch srs := canonicalSRS.(type) {
 	case *kzg_bn254.SRS:
 		newSRS := &kzg_bn254.SRS{Vk: srs.Vk}
-		size := uint64(len(srs.Pk.G1)) - 3
 
 		// instead of using ToLagrangeG1 we can directly do a fft on the powers of alpha
 		// since we know the randomness in test.
@@ -211,7 +216,6 @@ func toLagrange(canonicalSRS kzg.SRS, tau *big.Int) kzg.SRS {
 		lagrangeSRS = newSRS
 	case *kzg_bls12381.SRS:
 		newSRS := &kzg_bls12381.SRS{Vk: srs.Vk}
-		size := uint64(len(srs.Pk.G1)) - 3
 
 		// instead of using ToLagrangeG1 we can directly do a fft on the powers of alpha
 		// since we know the randomness in test.
@@ -233,7 +237,6 @@ func toLagrange(canonicalSRS kzg.SRS, tau *big.Int) kzg.SRS {
 		lagrangeSRS = newSRS
 	case *kzg_bls12377.SRS:
 		newSRS := &kzg_bls12377.SRS{Vk: srs.Vk}
-		size := uint64(len(srs.Pk.G1)) - 3
 
 		// instead of using ToLagrangeG1 we can directly do a fft on the powers of alpha
 		// since we know the randomness in test.
@@ -255,7 +258,6 @@ func toLagrange(canonicalSRS kzg.SRS, tau *big.Int) kzg.SRS {
 		lagrangeSRS = newSRS
 	case *kzg_bw6761.SRS:
 		newSRS := &kzg_bw6761.SRS{Vk: srs.Vk}
-		size := uint64(len(srs.Pk.G1)) - 3
 
 		// instead of using ToLagrangeG1 we can directly do a fft on the powers of alpha
 		// since we know the randomness in test.
@@ -278,7 +280,6 @@ func toLagrange(canonicalSRS kzg.SRS, tau *big.Int) kzg.SRS {
 		lagrangeSRS = newSRS
 	case *kzg_bls24317.SRS:
 		newSRS := &kzg_bls24317.SRS{Vk: srs.Vk}
-		size := uint64(len(srs.Pk.G1)) - 3
 
 		// instead of using ToLagrangeG1 we can directly do a fft on the powers of alpha
 		// since we know the randomness in test.
@@ -301,7 +302,6 @@ func toLagrange(canonicalSRS kzg.SRS, tau *big.Int) kzg.SRS {
 		lagrangeSRS = newSRS
 	case *kzg_bls24315.SRS:
 		newSRS := &kzg_bls24315.SRS{Vk: srs.Vk}
-		size := uint64(len(srs.Pk.G1)) - 3
 
 		// instead of using ToLagrangeG1 we can directly do a fft on the powers of alpha
 		// since we know the randomness in test.
@@ -324,7 +324,6 @@ func toLagrange(canonicalSRS kzg.SRS, tau *big.Int) kzg.SRS {
 		lagrangeSRS = newSRS
 	case *kzg_bw6633.SRS:
 		newSRS := &kzg_bw6633.SRS{Vk: srs.Vk}
-		size := uint64(len(srs.Pk.G1)) - 3
 
 		// instead of using ToLagrangeG1 we can directly do a fft on the powers of alpha
 		// since we know the randomness in test.